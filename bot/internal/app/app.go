@@ -7,8 +7,11 @@ import (
 	"github.com/go-telegram/bot"
 	"github.com/misshanya/url-shortener/bot/internal/config"
 	"github.com/misshanya/url-shortener/bot/internal/handler"
+	"github.com/misshanya/url-shortener/bot/internal/repository"
 	"github.com/misshanya/url-shortener/bot/internal/service"
 	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"github.com/misshanya/url-shortener/grpcclient"
+	"github.com/valkey-io/valkey-go"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
@@ -30,6 +33,7 @@ type App struct {
 	l              *slog.Logger
 	b              *bot.Bot
 	grpcConn       *grpc.ClientConn
+	valkeyClient   valkey.Client
 	tracerProvider *trace.TracerProvider
 }
 
@@ -50,11 +54,18 @@ func New(cfg *config.Config, l *slog.Logger) (*App, error) {
 	}
 	grpcClient := pb.NewURLShortenerServiceClient(a.grpcConn)
 
-	svc := service.New(grpcClient, cfg.Bot.PublicHost, a.l)
+	if err := a.initValkey(); err != nil {
+		return nil, err
+	}
+	valkeyRepo := repository.NewValkeyRepo(a.valkeyClient)
+
+	svc := service.New(grpcClient, valkeyRepo, cfg.Bot.PublicHost, a.l)
 	botHandler := handler.New(a.l, svc, tracer)
 
 	opts := []bot.Option{
 		bot.WithDefaultHandler(botHandler.Default),
+		bot.WithMessageTextHandler("domain", bot.MatchTypeCommandStartOnly, botHandler.Domain),
+		bot.WithMessageTextHandler("qr", bot.MatchTypeCommandStartOnly, botHandler.QR),
 	}
 	b, err := bot.New(cfg.Bot.Token, opts...)
 	if err != nil {
@@ -82,6 +93,9 @@ func (a *App) Stop(ctx context.Context) error {
 		stopErr = errors.Join(stopErr, fmt.Errorf("failed to close gRPC connection: %w", err))
 	}
 
+	a.l.Info("Closing Valkey connection...")
+	a.valkeyClient.Close()
+
 	a.l.Info("Shutting down tracer provider...")
 	if err := a.tracerProvider.Shutdown(ctx); err != nil {
 		stopErr = errors.Join(stopErr, fmt.Errorf("failed to shutdown tracer provider: %w", err))
@@ -107,7 +121,14 @@ func (a *App) initTracing() error {
 
 // initGRPCClient sets up a new gRPC connection to shortener service
 func (a *App) initGRPCClient() error {
-	grpcConn, err := grpc.NewClient(a.cfg.GRPCClient.ServerAddress,
+	opts := grpcclient.DialOptions(grpcclient.Options{
+		Timeout:         a.cfg.GRPCClient.Timeout,
+		MaxAttempts:     a.cfg.GRPCClient.MaxAttempts,
+		HedgingDelay:    a.cfg.GRPCClient.HedgingDelay,
+		BreakerFailures: a.cfg.GRPCClient.BreakerFailures,
+		BreakerCooldown: a.cfg.GRPCClient.BreakerCooldown,
+	})
+	opts = append(opts,
 		grpc.WithTransportCredentials(
 			insecure.NewCredentials(),
 		),
@@ -117,6 +138,7 @@ func (a *App) initGRPCClient() error {
 			),
 		),
 	)
+	grpcConn, err := grpc.NewClient(a.cfg.GRPCClient.ServerAddress, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to init gRPC connection to the shortener service: %w", err)
 	}
@@ -124,6 +146,19 @@ func (a *App) initGRPCClient() error {
 	return nil
 }
 
+// initValkey sets up a connection to the store of the chat domains
+func (a *App) initValkey() error {
+	client, err := valkey.NewClient(valkey.ClientOption{
+		InitAddress: []string{a.cfg.Valkey.Addr},
+		Password:    a.cfg.Valkey.Password,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to init Valkey connection: %w", err)
+	}
+	a.valkeyClient = client
+	return nil
+}
+
 // newTracerProvider creates a new OpenTelemetry provider
 func newTracerProvider(ctx context.Context, collectorAddr string) (*trace.TracerProvider, error) {
 	exporter, err := otlptracegrpc.New(ctx,
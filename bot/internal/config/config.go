@@ -2,16 +2,31 @@ package config
 
 import (
 	"github.com/ilyakaznacheev/cleanenv"
+	"time"
 )
 
 type Config struct {
 	Bot        bot
 	GRPCClient gRPCClient
+	Valkey     valkey
 	Tracing    tracing
 }
 
 type gRPCClient struct {
 	ServerAddress string `env:"GRPC_SERVER_ADDR" env-required:"true"`
+
+	// Timeout is the deadline of every call to the shortener with all its attempts
+	Timeout time.Duration `env:"GRPC_TIMEOUT" env-default:"5s"`
+
+	// MaxAttempts of idempotent calls, they are retried while the shortener is unavailable
+	MaxAttempts int `env:"GRPC_MAX_ATTEMPTS" env-default:"3"`
+
+	// HedgingDelay hedges the calls without side effects, e.g. PreviewURL, hedging is disabled if 0
+	HedgingDelay time.Duration `env:"GRPC_HEDGING_DELAY" env-default:"0s"`
+
+	// BreakerFailures in a row make the calls fail fast for BreakerCooldown, the breaker is disabled if 0
+	BreakerFailures int           `env:"GRPC_BREAKER_FAILURES" env-default:"5"`
+	BreakerCooldown time.Duration `env:"GRPC_BREAKER_COOLDOWN" env-default:"10s"`
 }
 
 type bot struct {
@@ -19,6 +34,12 @@ type bot struct {
 	PublicHost string `env:"PUBLIC_HOST" env-default:"http://localhost:8080/"`
 }
 
+// valkey keeps the default domains chosen by the chats
+type valkey struct {
+	Addr     string `env:"VALKEY_ADDR" env-required:"true"`
+	Password string `env:"VALKEY_PASSWORD" env-required:"true"`
+}
+
 type tracing struct {
 	CollectorAddr string `env:"TRACING_COLLECTOR_ADDR" env-required:"true"`
 }
@@ -1,17 +1,24 @@
 package handler
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
 	"log/slog"
 	"net/url"
+	"strings"
 )
 
 type service interface {
-	ShortenURL(ctx context.Context, url string) (string, error)
+	ShortenURL(ctx context.Context, chatID int64, url string) (string, error)
+	QRCode(shortURL string) ([]byte, error)
+	Domains(ctx context.Context) ([]string, error)
+	SetChatDomain(ctx context.Context, chatID int64, host string) (bool, error)
+	ChatDomain(ctx context.Context, chatID int64) (string, error)
 }
 
 type Handler struct {
@@ -48,7 +55,8 @@ func (h *Handler) Default(ctx context.Context, b *bot.Bot, update *models.Update
 	defer span.End()
 
 	// Short URL
-	short, err := h.s.ShortenURL(ctx, update.InlineQuery.Query)
+	// Inline queries have no chat, so the domain chosen in the private chat with the bot is used
+	short, err := h.s.ShortenURL(ctx, update.InlineQuery.From.ID, update.InlineQuery.Query)
 	if err != nil {
 		return
 	}
@@ -68,3 +76,91 @@ func (h *Handler) Default(ctx context.Context, b *bot.Bot, update *models.Update
 		h.l.Error("failed to answer inline query", slog.Any("error", err))
 	}
 }
+
+// QR handles the /qr command.
+// It shortens the URL like the inline mode does and sends the QR code of the short link with the link as the caption.
+func (h *Handler) QR(ctx context.Context, b *bot.Bot, update *models.Update) {
+	ctx, span := h.t.Start(ctx, "QR command")
+	defer span.End()
+
+	chatID := update.Message.Chat.ID
+	args := strings.Fields(update.Message.Text)
+
+	if len(args) < 2 {
+		h.sendText(ctx, b, chatID, "Use /qr <url> to get a QR code of the short link")
+		return
+	}
+	if _, err := url.ParseRequestURI(args[1]); err != nil {
+		h.sendText(ctx, b, chatID, "Invalid URL")
+		return
+	}
+
+	short, err := h.s.ShortenURL(ctx, chatID, args[1])
+	if err != nil {
+		h.sendText(ctx, b, chatID, "Failed to shorten URL, try again later")
+		return
+	}
+
+	img, err := h.s.QRCode(short)
+	if err != nil {
+		h.sendText(ctx, b, chatID, short)
+		return
+	}
+
+	if _, err := b.SendPhoto(ctx, &bot.SendPhotoParams{
+		ChatID:  chatID,
+		Photo:   &models.InputFileUpload{Filename: "qr.png", Data: bytes.NewReader(img)},
+		Caption: short,
+	}); err != nil {
+		h.l.Error("failed to send photo", slog.Any("error", err))
+	}
+}
+
+// Domain handles the /domain command.
+// Without arguments, it lists registered domains, otherwise sets the default domain of the chat.
+func (h *Handler) Domain(ctx context.Context, b *bot.Bot, update *models.Update) {
+	ctx, span := h.t.Start(ctx, "Domain command")
+	defer span.End()
+
+	chatID := update.Message.Chat.ID
+	args := strings.Fields(update.Message.Text)
+
+	var text string
+	if len(args) < 2 {
+		hosts, err := h.s.Domains(ctx)
+		var current string
+		if err == nil {
+			current, err = h.s.ChatDomain(ctx, chatID)
+		}
+		if err != nil {
+			text = "Failed to get domains, try again later"
+		} else {
+			if current == "" && len(hosts) > 0 {
+				current = hosts[0]
+			}
+			text = fmt.Sprintf("Current domain: %s\nAvailable: %s\nUse /domain <host> to change it",
+				current, strings.Join(hosts, ", "))
+		}
+	} else {
+		ok, err := h.s.SetChatDomain(ctx, chatID, args[1])
+		switch {
+		case err != nil:
+			text = "Failed to set domain, try again later"
+		case !ok:
+			text = "Unknown domain, send /domain to see available ones"
+		default:
+			text = fmt.Sprintf("Links will be shortened on %s", strings.ToLower(args[1]))
+		}
+	}
+
+	h.sendText(ctx, b, chatID, text)
+}
+
+func (h *Handler) sendText(ctx context.Context, b *bot.Bot, chatID int64, text string) {
+	if _, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   text,
+	}); err != nil {
+		h.l.Error("failed to send message", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/valkey-io/valkey-go"
+	"strconv"
+)
+
+type ValkeyRepo struct {
+	client valkey.Client
+}
+
+func NewValkeyRepo(client valkey.Client) *ValkeyRepo {
+	return &ValkeyRepo{client: client}
+}
+
+// chatDomainKey is the key of the default domain of the chat
+func chatDomainKey(chatID int64) string {
+	return "chat_domain:" + strconv.FormatInt(chatID, 10)
+}
+
+// SetChatDomain stores the default domain of the chat, it is kept until the chat chooses another one
+func (r *ValkeyRepo) SetChatDomain(ctx context.Context, chatID int64, host string) error {
+	err := r.client.Do(ctx, r.client.B().
+		Set().
+		Key(chatDomainKey(chatID)).
+		Value(host).
+		Build(),
+	).Error()
+	if err != nil {
+		return fmt.Errorf("failed to query valkey: %w", err)
+	}
+	return nil
+}
+
+// ChatDomain returns the default domain of the chat, empty if the chat hasn't chosen one
+func (r *ValkeyRepo) ChatDomain(ctx context.Context, chatID int64) (string, error) {
+	host, err := r.client.Do(ctx, r.client.B().
+		Get().
+		Key(chatDomainKey(chatID)).
+		Build(),
+	).ToString()
+	if valkey.IsValkeyNil(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query valkey: %w", err)
+	}
+	return host, nil
+}
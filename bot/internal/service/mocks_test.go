@@ -39,6 +39,89 @@ func (_m *mockgrpcClient) EXPECT() *mockgrpcClient_Expecter {
 	return &mockgrpcClient_Expecter{mock: &_m.Mock}
 }
 
+// ListDomains provides a mock function for the type mockgrpcClient
+func (_mock *mockgrpcClient) ListDomains(ctx context.Context, in *v1.ListDomainsRequest, opts ...grpc.CallOption) (*v1.ListDomainsResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDomains")
+	}
+
+	var r0 *v1.ListDomainsResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.ListDomainsRequest, ...grpc.CallOption) (*v1.ListDomainsResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.ListDomainsRequest, ...grpc.CallOption) *v1.ListDomainsResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.ListDomainsResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1.ListDomainsRequest, ...grpc.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockgrpcClient_ListDomains_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListDomains'
+type mockgrpcClient_ListDomains_Call struct {
+	*mock.Call
+}
+
+// ListDomains is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.ListDomainsRequest
+//   - opts ...grpc.CallOption
+func (_e *mockgrpcClient_Expecter) ListDomains(ctx interface{}, in interface{}, opts ...interface{}) *mockgrpcClient_ListDomains_Call {
+	return &mockgrpcClient_ListDomains_Call{Call: _e.mock.On("ListDomains",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *mockgrpcClient_ListDomains_Call) Run(run func(ctx context.Context, in *v1.ListDomainsRequest, opts ...grpc.CallOption)) *mockgrpcClient_ListDomains_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v1.ListDomainsRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v1.ListDomainsRequest)
+		}
+		var arg2 []grpc.CallOption
+		var variadicArgs []grpc.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]grpc.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockgrpcClient_ListDomains_Call) Return(listDomainsResponse *v1.ListDomainsResponse, err error) *mockgrpcClient_ListDomains_Call {
+	_c.Call.Return(listDomainsResponse, err)
+	return _c
+}
+
+func (_c *mockgrpcClient_ListDomains_Call) RunAndReturn(run func(ctx context.Context, in *v1.ListDomainsRequest, opts ...grpc.CallOption) (*v1.ListDomainsResponse, error)) *mockgrpcClient_ListDomains_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ShortenURL provides a mock function for the type mockgrpcClient
 func (_mock *mockgrpcClient) ShortenURL(ctx context.Context, in *v1.ShortenURLRequest, opts ...grpc.CallOption) (*v1.ShortenURLResponse, error) {
 	var tmpRet mock.Arguments
@@ -121,3 +204,159 @@ func (_c *mockgrpcClient_ShortenURL_Call) RunAndReturn(run func(ctx context.Cont
 	_c.Call.Return(run)
 	return _c
 }
+
+// newMockrepo creates a new instance of mockrepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockrepo(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockrepo {
+	mock := &mockrepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockrepo is an autogenerated mock type for the repo type
+type mockrepo struct {
+	mock.Mock
+}
+
+type mockrepo_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockrepo) EXPECT() *mockrepo_Expecter {
+	return &mockrepo_Expecter{mock: &_m.Mock}
+}
+
+// ChatDomain provides a mock function for the type mockrepo
+func (_mock *mockrepo) ChatDomain(ctx context.Context, chatID int64) (string, error) {
+	ret := _mock.Called(ctx, chatID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChatDomain")
+	}
+
+	var r0 string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) (string, error)); ok {
+		return returnFunc(ctx, chatID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) string); ok {
+		r0 = returnFunc(ctx, chatID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = returnFunc(ctx, chatID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockrepo_ChatDomain_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ChatDomain'
+type mockrepo_ChatDomain_Call struct {
+	*mock.Call
+}
+
+// ChatDomain is a helper method to define mock.On call
+//   - ctx context.Context
+//   - chatID int64
+func (_e *mockrepo_Expecter) ChatDomain(ctx interface{}, chatID interface{}) *mockrepo_ChatDomain_Call {
+	return &mockrepo_ChatDomain_Call{Call: _e.mock.On("ChatDomain", ctx, chatID)}
+}
+
+func (_c *mockrepo_ChatDomain_Call) Run(run func(ctx context.Context, chatID int64)) *mockrepo_ChatDomain_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockrepo_ChatDomain_Call) Return(s string, err error) *mockrepo_ChatDomain_Call {
+	_c.Call.Return(s, err)
+	return _c
+}
+
+func (_c *mockrepo_ChatDomain_Call) RunAndReturn(run func(ctx context.Context, chatID int64) (string, error)) *mockrepo_ChatDomain_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetChatDomain provides a mock function for the type mockrepo
+func (_mock *mockrepo) SetChatDomain(ctx context.Context, chatID int64, host string) error {
+	ret := _mock.Called(ctx, chatID, host)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetChatDomain")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = returnFunc(ctx, chatID, host)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockrepo_SetChatDomain_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetChatDomain'
+type mockrepo_SetChatDomain_Call struct {
+	*mock.Call
+}
+
+// SetChatDomain is a helper method to define mock.On call
+//   - ctx context.Context
+//   - chatID int64
+//   - host string
+func (_e *mockrepo_Expecter) SetChatDomain(ctx interface{}, chatID interface{}, host interface{}) *mockrepo_SetChatDomain_Call {
+	return &mockrepo_SetChatDomain_Call{Call: _e.mock.On("SetChatDomain", ctx, chatID, host)}
+}
+
+func (_c *mockrepo_SetChatDomain_Call) Run(run func(ctx context.Context, chatID int64, host string)) *mockrepo_SetChatDomain_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockrepo_SetChatDomain_Call) Return(err error) *mockrepo_SetChatDomain_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockrepo_SetChatDomain_Call) RunAndReturn(run func(ctx context.Context, chatID int64, host string) error) *mockrepo_SetChatDomain_Call {
+	_c.Call.Return(run)
+	return _c
+}
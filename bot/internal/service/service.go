@@ -3,31 +3,127 @@ package service
 import (
 	"context"
 	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"github.com/misshanya/url-shortener/qrcode"
+	"github.com/misshanya/url-shortener/shorturl"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"log/slog"
+	"strconv"
+	"strings"
 )
 
 type grpcClient interface {
 	ShortenURL(ctx context.Context, in *pb.ShortenURLRequest, opts ...grpc.CallOption) (*pb.ShortenURLResponse, error)
+	ListDomains(ctx context.Context, in *pb.ListDomainsRequest, opts ...grpc.CallOption) (*pb.ListDomainsResponse, error)
 }
 
+// repo keeps the default domains chosen by the chats
+type repo interface {
+	SetChatDomain(ctx context.Context, chatID int64, host string) error
+	ChatDomain(ctx context.Context, chatID int64) (string, error)
+}
+
+// mdPrincipal is the metadata key with the principal recorded as the owner of the links
+const mdPrincipal = "x-principal"
+
+// qrSize is the size of QR codes in pixels, big enough for Telegram not to upscale the photo
+const qrSize = 512
+
 type Service struct {
 	client     grpcClient
+	repo       repo
 	publicHost string
 	l          *slog.Logger
 }
 
-func New(client grpcClient, publicHost string, logger *slog.Logger) *Service {
-	return &Service{client: client, publicHost: publicHost, l: logger}
+func New(client grpcClient, repo repo, publicHost string, logger *slog.Logger) *Service {
+	return &Service{
+		client:     client,
+		repo:       repo,
+		publicHost: publicHost,
+		l:          logger,
+	}
 }
 
-func (s *Service) ShortenURL(ctx context.Context, url string) (string, error) {
-	resp, err := s.client.ShortenURL(ctx, &pb.ShortenURLRequest{Url: url})
+// ShortenURL shortens the URL on the default domain of the chat, the link is owned by the chat
+func (s *Service) ShortenURL(ctx context.Context, chatID int64, url string) (string, error) {
+	domain, err := s.ChatDomain(ctx, chatID)
+	if err != nil {
+		return "", err
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, mdPrincipal, "telegram:"+strconv.FormatInt(chatID, 10))
+	resp, err := s.client.ShortenURL(ctx, &pb.ShortenURLRequest{Url: url, Domain: domain})
 	if err != nil {
 		s.l.Error("failed to shorten url", slog.Any("error", err))
 		return "", err
 	}
 
-	short := s.publicHost + resp.Code
+	short := shorturl.Build(s.publicHost, resp.Domain, resp.Code)
 	return short, nil
 }
+
+// QRCode renders the short URL as a PNG QR code, the image is the same as GET /{code}/qr?size=512 of the gateway
+func (s *Service) QRCode(shortURL string) ([]byte, error) {
+	opts := qrcode.DefaultOptions()
+	opts.Size = qrSize
+
+	img, err := qrcode.PNG(shortURL, opts)
+	if err != nil {
+		s.l.Error("failed to render qr code", slog.Any("error", err))
+		return nil, err
+	}
+	return img, nil
+}
+
+// Domains returns hosts of the registered domains, the default one goes first
+func (s *Service) Domains(ctx context.Context) ([]string, error) {
+	resp, err := s.client.ListDomains(ctx, &pb.ListDomainsRequest{})
+	if err != nil {
+		s.l.Error("failed to list domains", slog.Any("error", err))
+		return nil, err
+	}
+
+	hosts := make([]string, 0, len(resp.Domains))
+	for _, domain := range resp.Domains {
+		if domain.IsDefault {
+			hosts = append([]string{domain.Host}, hosts...)
+			continue
+		}
+		hosts = append(hosts, domain.Host)
+	}
+
+	return hosts, nil
+}
+
+// SetChatDomain sets the default domain of links shortened by the chat, the choice is kept across restarts.
+// It reports whether the domain is registered.
+func (s *Service) SetChatDomain(ctx context.Context, chatID int64, host string) (bool, error) {
+	hosts, err := s.Domains(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	host = strings.ToLower(host)
+	for _, h := range hosts {
+		if h == host {
+			if err := s.repo.SetChatDomain(ctx, chatID, host); err != nil {
+				s.l.Error("failed to set chat domain", slog.Any("error", err))
+				return false, err
+			}
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ChatDomain returns the default domain of the chat, empty if the chat uses the default domain of the shortener
+func (s *Service) ChatDomain(ctx context.Context, chatID int64) (string, error) {
+	host, err := s.repo.ChatDomain(ctx, chatID)
+	if err != nil {
+		s.l.Error("failed to get chat domain", slog.Any("error", err))
+		return "", err
+	}
+	return host, nil
+}
@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"github.com/misshanya/url-shortener/qrcode"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"log/slog"
 	"os"
@@ -13,9 +16,17 @@ import (
 )
 
 func Test_ShortenURL(t *testing.T) {
+	ownedByChat := mock.MatchedBy(func(ctx context.Context) bool {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		principal := md.Get("x-principal")
+		return len(principal) == 1 && principal[0] == "telegram:1"
+	})
+
 	tests := []struct {
 		Name           string
 		PublicHost     string
+		ChatDomain     string
+		ChatDomainErr  error
 		InputURL       string
 		ExceptedResult string
 		ExceptedErr    error
@@ -28,7 +39,7 @@ func Test_ShortenURL(t *testing.T) {
 			ExceptedResult: "https://sh.some/3a",
 			ExceptedErr:    nil,
 			SetUpMocks: func(client *mockgrpcClient) {
-				client.On("ShortenURL", mock.Anything, &pb.ShortenURLRequest{Url: "https://go.dev"}).
+				client.On("ShortenURL", ownedByChat, &pb.ShortenURLRequest{Url: "https://go.dev"}).
 					Return(
 						&pb.ShortenURLResponse{
 							Code: "3a",
@@ -37,6 +48,38 @@ func Test_ShortenURL(t *testing.T) {
 					).Once()
 			},
 		},
+		{
+			Name:           "Successfully Shortened on chat domain",
+			PublicHost:     "https://sh.some/",
+			ChatDomain:     "go.some",
+			InputURL:       "https://go.dev",
+			ExceptedResult: "https://go.some/3b",
+			ExceptedErr:    nil,
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("ShortenURL", mock.Anything, &pb.ShortenURLRequest{Url: "https://go.dev", Domain: "go.some"}).
+					Return(&pb.ShortenURLResponse{Code: "3b", Domain: "go.some"}, nil).Once()
+			},
+		},
+		{
+			Name:           "Successfully Shortened on public host domain",
+			PublicHost:     "https://sh.some/",
+			InputURL:       "https://go.dev",
+			ExceptedResult: "https://sh.some/3c",
+			ExceptedErr:    nil,
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("ShortenURL", mock.Anything, &pb.ShortenURLRequest{Url: "https://go.dev"}).
+					Return(&pb.ShortenURLResponse{Code: "3c", Domain: "sh.some"}, nil).Once()
+			},
+		},
+		{
+			Name:           "Chat domain is not available",
+			PublicHost:     "https://sh.some/",
+			ChatDomainErr:  errors.New("failed to query valkey"),
+			InputURL:       "https://go.dev",
+			ExceptedResult: "",
+			ExceptedErr:    errors.New("failed to query valkey"),
+			SetUpMocks:     func(client *mockgrpcClient) {},
+		},
 		{
 			Name:           "gRPC server answered with internal error",
 			PublicHost:     "https://sh.some/",
@@ -56,11 +99,14 @@ func Test_ShortenURL(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.Name, func(t *testing.T) {
 			mockClient := mockgrpcClient{}
+			mockRepo := mockrepo{}
 
 			tt.SetUpMocks(&mockClient)
+			mockRepo.On("ChatDomain", mock.Anything, int64(1)).Return(tt.ChatDomain, tt.ChatDomainErr).Once()
 
 			service := New(
 				&mockClient,
+				&mockRepo,
 				tt.PublicHost,
 				slog.New(
 					slog.NewTextHandler(
@@ -70,11 +116,122 @@ func Test_ShortenURL(t *testing.T) {
 				),
 			)
 
-			result, err := service.ShortenURL(context.Background(), tt.InputURL)
+			result, err := service.ShortenURL(context.Background(), 1, tt.InputURL)
 			assert.Equal(t, tt.ExceptedErr, err)
 			assert.Equal(t, tt.ExceptedResult, result)
 
 			mockClient.AssertExpectations(t)
+			mockRepo.AssertExpectations(t)
 		})
 	}
 }
+
+func Test_SetChatDomain(t *testing.T) {
+	domains := &pb.ListDomainsResponse{Domains: []*pb.Domain{
+		{Host: "sh.some", IsDefault: true},
+		{Host: "go.some"},
+	}}
+
+	tests := []struct {
+		Name        string
+		InputHost   string
+		ExceptedOK  bool
+		ExceptedErr error
+		SetUpMocks  func(client *mockgrpcClient, repo *mockrepo)
+	}{
+		{
+			Name:       "Registered domain",
+			InputHost:  "Go.Some",
+			ExceptedOK: true,
+			SetUpMocks: func(client *mockgrpcClient, repo *mockrepo) {
+				client.On("ListDomains", mock.Anything, &pb.ListDomainsRequest{}).
+					Return(domains, nil).Once()
+				repo.On("SetChatDomain", mock.Anything, int64(1), "go.some").
+					Return(nil).Once()
+			},
+		},
+		{
+			Name:       "Unknown domain",
+			InputHost:  "unknown.some",
+			ExceptedOK: false,
+			SetUpMocks: func(client *mockgrpcClient, repo *mockrepo) {
+				client.On("ListDomains", mock.Anything, &pb.ListDomainsRequest{}).
+					Return(domains, nil).Once()
+			},
+		},
+		{
+			Name:        "Failed to store the domain",
+			InputHost:   "go.some",
+			ExceptedOK:  false,
+			ExceptedErr: errors.New("failed to query valkey"),
+			SetUpMocks: func(client *mockgrpcClient, repo *mockrepo) {
+				client.On("ListDomains", mock.Anything, &pb.ListDomainsRequest{}).
+					Return(domains, nil).Once()
+				repo.On("SetChatDomain", mock.Anything, int64(1), "go.some").
+					Return(errors.New("failed to query valkey")).Once()
+			},
+		},
+		{
+			Name:        "gRPC server answered with internal error",
+			InputHost:   "go.some",
+			ExceptedOK:  false,
+			ExceptedErr: status.New(codes.Internal, "Internal Server Error").Err(),
+			SetUpMocks: func(client *mockgrpcClient, repo *mockrepo) {
+				client.On("ListDomains", mock.Anything, &pb.ListDomainsRequest{}).
+					Return(nil, status.New(codes.Internal, "Internal Server Error").Err()).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockClient := mockgrpcClient{}
+			mockRepo := mockrepo{}
+
+			tt.SetUpMocks(&mockClient, &mockRepo)
+
+			service := New(
+				&mockClient,
+				&mockRepo,
+				"https://sh.some/",
+				slog.New(
+					slog.NewTextHandler(
+						os.Stdout,
+						&slog.HandlerOptions{},
+					),
+				),
+			)
+
+			ok, err := service.SetChatDomain(context.Background(), 1, tt.InputHost)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedOK, ok)
+
+			mockClient.AssertExpectations(t)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_QRCode(t *testing.T) {
+	service := New(
+		&mockgrpcClient{},
+		&mockrepo{},
+		"https://sh.some/",
+		slog.New(
+			slog.NewTextHandler(
+				os.Stdout,
+				&slog.HandlerOptions{},
+			),
+		),
+	)
+
+	img, err := service.QRCode("https://sh.some/3a")
+	assert.NoError(t, err)
+
+	// The bot sends the same image as the gateway renders
+	opts := qrcode.DefaultOptions()
+	opts.Size = 512
+	excepted, err := qrcode.PNG("https://sh.some/3a", opts)
+	assert.NoError(t, err)
+	assert.Equal(t, excepted, img)
+}
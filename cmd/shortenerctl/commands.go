@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func runShorten(ctx context.Context, client pb.URLShortenerServiceClient, out output, args []string) error {
+	flags := flag.NewFlagSet("shorten", flag.ExitOnError)
+	domain := flags.String("domain", "", "domain of the link, the default domain if empty")
+	alias := flags.String("alias", "", "custom code of the link")
+	title := flags.String("title", "", "title of the link")
+	tags := flags.String("tags", "", "comma-separated tags of the link")
+	expiresAt := flags.String("expires-at", "", "time the link expires at")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: shorten [flags] url")
+	}
+
+	expires, err := parseTime(*expiresAt)
+	if err != nil {
+		return fmt.Errorf("bad -expires-at: %w", err)
+	}
+
+	resp, err := client.ShortenURL(ctx, &pb.ShortenURLRequest{
+		Url:       flags.Arg(0),
+		Domain:    *domain,
+		Alias:     *alias,
+		Title:     *title,
+		Tags:      splitList(*tags),
+		ExpiresAt: expires,
+	})
+	if err != nil {
+		return err
+	}
+
+	return out.print(resp, func() table {
+		return shortenedTable(resp)
+	})
+}
+
+// runBatch shortens URLs from the file, one per line, optionally followed by the alias.
+// Empty lines and lines starting with # are skipped.
+func runBatch(ctx context.Context, client pb.URLShortenerServiceClient, out output, args []string) error {
+	flags := flag.NewFlagSet("batch", flag.ExitOnError)
+	domain := flags.String("domain", "", "domain of the links, the default domain if empty")
+	flags.Parse(args)
+
+	in := io.Reader(os.Stdin)
+	if flags.NArg() > 0 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	req, err := readBatch(in, *domain)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.ShortenURLBatch(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return out.print(resp, func() table {
+		return shortenedTable(resp.Urls...)
+	})
+}
+
+func readBatch(r io.Reader, domain string) (*pb.ShortenURLBatchRequest, error) {
+	var req pb.ShortenURLBatchRequest
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: expected url and optional alias", line)
+		}
+
+		short := &pb.ShortenURLRequest{Url: fields[0], Domain: domain}
+		if len(fields) == 2 {
+			short.Alias = fields[1]
+		}
+		req.Urls = append(req.Urls, short)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(req.Urls) == 0 {
+		return nil, errors.New("no URLs to shorten")
+	}
+	return &req, nil
+}
+
+func shortenedTable(resps ...*pb.ShortenURLResponse) table {
+	t := table{header: []string{"CODE", "DOMAIN", "URL", "ERROR"}, rows: make([][]string, len(resps))}
+	for i, resp := range resps {
+		t.rows[i] = []string{orDash(resp.Code), orDash(resp.Domain), resp.OriginalUrl, orDash(resp.Error)}
+	}
+	return t
+}
+
+// runResolve shows where the code goes without visiting it, so statistics are not touched
+func runResolve(ctx context.Context, client pb.URLShortenerServiceClient, out output, args []string) error {
+	domain, code, err := codeArgs("resolve", args)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.PreviewURL(ctx, &pb.PreviewURLRequest{Code: code, Domain: domain})
+	if err != nil {
+		return err
+	}
+
+	// The destination of protected links is not revealed
+	return out.print(resp, func() table {
+		return table{header: []string{"URL", "PROTECTED"}, rows: [][]string{{orDash(resp.Url), strconv.FormatBool(resp.PasswordProtected)}}}
+	})
+}
+
+func runDisable(ctx context.Context, client pb.URLShortenerServiceClient, out output, args []string) error {
+	domain, code, err := codeArgs("disable", args)
+	if err != nil {
+		return err
+	}
+
+	link, err := client.DisableURL(ctx, &pb.DisableURLRequest{Code: code, Domain: domain})
+	if err != nil {
+		return err
+	}
+
+	return out.print(link, func() table {
+		return linksTable(link)
+	})
+}
+
+func runDelete(ctx context.Context, client pb.URLShortenerServiceClient, out output, args []string) error {
+	domain, code, err := codeArgs("delete", args)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.DeleteURL(ctx, &pb.DeleteURLRequest{Code: code, Domain: domain})
+	if err != nil {
+		return err
+	}
+
+	return out.print(resp, func() table {
+		return table{header: []string{"DELETED"}, rows: [][]string{{code}}}
+	})
+}
+
+func runRetarget(ctx context.Context, client pb.URLShortenerServiceClient, out output, args []string) error {
+	flags := flag.NewFlagSet("retarget", flag.ExitOnError)
+	domain := flags.String("domain", "", "domain of the link, the default domain if empty")
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		return errors.New("usage: retarget [-domain host] code url")
+	}
+
+	link, err := client.RetargetURL(ctx, &pb.RetargetURLRequest{Code: flags.Arg(0), Domain: *domain, Url: flags.Arg(1)})
+	if err != nil {
+		return err
+	}
+
+	return out.print(link, func() table {
+		return linksTable(link)
+	})
+}
+
+func runList(ctx context.Context, client pb.URLShortenerServiceClient, out output, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	owner := flags.String("owner", "", "principal created the links")
+	domain := flags.String("domain", "", "domain of the links")
+	tags := flags.String("tags", "", "comma-separated tags the links have all of")
+	search := flags.String("search", "", "substring of the destination URL")
+	enabled := flags.String("enabled", "", "true for active links only, false for not active ones")
+	createdFrom := flags.String("created-from", "", "links created since this time")
+	createdTo := flags.String("created-to", "", "links created before this time")
+	pageSize := flags.Int("page-size", 0, "links on the page, 50 if 0, at most 100")
+	cursor := flags.String("cursor", "", "cursor of the page to start from")
+	all := flags.Bool("all", false, "follow the cursors to the last page")
+	flags.Parse(args)
+
+	req := pb.ListURLsRequest{
+		Cursor:   *cursor,
+		PageSize: int32(*pageSize),
+		Owner:    *owner,
+		Domain:   *domain,
+		Search:   *search,
+		Tags:     splitList(*tags),
+	}
+
+	var err error
+	if req.CreatedFrom, err = parseTime(*createdFrom); err != nil {
+		return fmt.Errorf("bad -created-from: %w", err)
+	}
+	if req.CreatedTo, err = parseTime(*createdTo); err != nil {
+		return fmt.Errorf("bad -created-to: %w", err)
+	}
+	if *enabled != "" {
+		value, err := strconv.ParseBool(*enabled)
+		if err != nil {
+			return fmt.Errorf("bad -enabled: %w", err)
+		}
+		req.Enabled = &value
+	}
+
+	for {
+		resp, err := client.ListURLs(ctx, &req)
+		if err != nil {
+			return err
+		}
+
+		err = out.print(resp, func() table {
+			return linksTable(resp.Links...)
+		})
+		if err != nil {
+			return err
+		}
+
+		if resp.NextCursor == "" {
+			return nil
+		}
+		if !*all {
+			fmt.Fprintln(os.Stderr, "next cursor:", resp.NextCursor)
+			return nil
+		}
+		req.Cursor = resp.NextCursor
+	}
+}
+
+func runCache(ctx context.Context, client pb.URLShortenerServiceClient, out output, args []string) error {
+	domain, code, err := codeArgs("cache", args)
+	if err != nil {
+		return err
+	}
+
+	entry, err := client.GetCacheEntry(ctx, &pb.GetCacheEntryRequest{Code: code, Domain: domain})
+	if err != nil {
+		return err
+	}
+
+	return out.print(entry, func() table {
+		return cacheTable(code, entry)
+	})
+}
+
+func cacheTable(code string, entry *pb.CacheEntry) table {
+	t := table{header: []string{"CODE", "CACHED", "TTL", "URL", "EXPIRES"}}
+	if !entry.Cached {
+		t.rows = [][]string{{code, "false", "-", "-", "-"}}
+		return t
+	}
+
+	ttl := "-"
+	if entry.Ttl > 0 {
+		ttl = (time.Duration(entry.Ttl) * time.Second).String()
+	}
+	t.rows = [][]string{{code, "true", ttl, entry.Link.GetOriginalUrl(), unixTime(entry.Link.GetExpiresAt())}}
+	return t
+}
+
+// codeArgs parses the arguments of the commands taking the code of the link only
+func codeArgs(name string, args []string) (string, string, error) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	domain := flags.String("domain", "", "domain of the link, the default domain if empty")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return "", "", fmt.Errorf("usage: %s [-domain host] code", name)
+	}
+	return *domain, flags.Arg(0), nil
+}
+
+// parseTime parses RFC 3339 time into Unix seconds, 0 for empty string
+func parseTime(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
+
+// splitList splits the comma-separated list, skipping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func runAudit(ctx context.Context, client pb.URLShortenerServiceClient, out output, args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ExitOnError)
+	domain := flags.String("domain", "", "domain of the link, the default domain if empty and the code is set")
+	actor := flags.String("actor", "", "principal made the changes")
+	action := flags.String("action", "", "kind of the change: create, import, delete, retarget, set_domain, set_expires_at or update_metadata")
+	pageSize := flags.Int("page-size", 0, "events on the page, 50 if 0, at most 100")
+	cursor := flags.String("cursor", "", "cursor of the page to start from")
+	all := flags.Bool("all", false, "follow the cursors to the last page")
+	flags.Parse(args)
+
+	if flags.NArg() > 1 {
+		return errors.New("usage: audit [flags] [code]")
+	}
+
+	req := pb.ListAuditEventsRequest{
+		Cursor:   *cursor,
+		PageSize: int32(*pageSize),
+		Code:     flags.Arg(0),
+		Domain:   *domain,
+		Actor:    *actor,
+		Action:   *action,
+	}
+
+	for {
+		resp, err := client.ListAuditEvents(ctx, &req)
+		if err != nil {
+			return err
+		}
+
+		err = out.print(resp, func() table {
+			return auditTable(resp.Events...)
+		})
+		if err != nil {
+			return err
+		}
+
+		if resp.NextCursor == "" {
+			return nil
+		}
+		if !*all {
+			fmt.Fprintln(os.Stderr, "next cursor:", resp.NextCursor)
+			return nil
+		}
+		req.Cursor = resp.NextCursor
+	}
+}
+
+func auditTable(events ...*pb.AuditEvent) table {
+	t := table{
+		header: []string{"ID", "TIME", "ACTION", "CODE", "DOMAIN", "ACTOR", "REQUEST", "OLD", "NEW"},
+		rows:   make([][]string, len(events)),
+	}
+	for i, event := range events {
+		t.rows[i] = []string{
+			strconv.FormatInt(event.Id, 10),
+			time.UnixMicro(event.CreatedAt).UTC().Format(time.RFC3339),
+			event.Action,
+			event.Code,
+			orDash(event.Domain),
+			orDash(event.Actor),
+			orDash(event.RequestId),
+			orDash(event.OldValue),
+			orDash(event.NewValue),
+		}
+	}
+	return t
+}
@@ -0,0 +1,129 @@
+// Command shortenerctl manages links through the shortener gRPC API.
+//
+//	shortenerctl [-addr host:port] [-principal name] [-token token] [-o table|json] [-timeout 10s] command [flags] [args]
+//
+// Commands:
+//
+//	shorten [-domain host] [-alias code] [-title title] [-tags a,b] [-expires-at time] url
+//	batch [-domain host] [file]
+//	resolve [-domain host] code
+//	disable [-domain host] code
+//	delete [-domain host] code
+//	retarget [-domain host] code url
+//	list [-owner name] [-domain host] [-tags a,b] [-search text] [-enabled true|false] [-created-from time] [-created-to time] [-page-size 50] [-cursor cursor] [-all]
+//	cache [-domain host] code
+//	audit [-domain host] [-actor name] [-action action] [-page-size 50] [-cursor cursor] [-all] [code]
+//
+// The shortener is reached like the gateway and the bot do, at GRPC_SERVER_ADDR from the environment
+// by default, and the principal is sent in x-principal metadata, so new links are owned by it.
+// disable, delete, retarget, cache and audit are admin RPCs, they need the ADMIN_TOKEN of the shortener.
+// list shows the links of the principal, all links and the -owner filter need the admin token.
+// Times are RFC 3339.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Metadata keys of the principal calling the API and of the admin token
+const (
+	mdPrincipal     = "x-principal"
+	mdAuthorization = "authorization"
+)
+
+// command runs the subcommand with its arguments
+type command func(ctx context.Context, client pb.URLShortenerServiceClient, out output, args []string) error
+
+var commands = map[string]command{
+	"shorten":  runShorten,
+	"batch":    runBatch,
+	"resolve":  runResolve,
+	"disable":  runDisable,
+	"delete":   runDelete,
+	"retarget": runRetarget,
+	"list":     runList,
+	"cache":    runCache,
+	"audit":    runAudit,
+}
+
+func main() {
+	flags := flag.NewFlagSet("shortenerctl", flag.ExitOnError)
+	flags.Usage = usage
+	addr := flags.String("addr", os.Getenv("GRPC_SERVER_ADDR"), "address of the shortener gRPC server")
+	principal := flags.String("principal", os.Getenv("SHORTENER_PRINCIPAL"), "principal sent to the shortener, owner of new links")
+	token := flags.String("token", os.Getenv("ADMIN_TOKEN"), "admin token of the shortener, needed by disable, delete, retarget, cache and audit")
+	format := flags.String("o", string(formatTable), "output format: table or json")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of each request")
+	flags.Parse(os.Args[1:])
+
+	if flags.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+	run, ok := commands[flags.Arg(0)]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	out, err := newOutput(os.Stdout, outputFormat(*format))
+	if err != nil {
+		fail(err)
+	}
+	if *addr == "" {
+		fail(errors.New("shortener address is not set, use -addr or GRPC_SERVER_ADDR"))
+	}
+
+	conn, err := grpc.NewClient(*addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(requestTimeout(*timeout)),
+	)
+	if err != nil {
+		fail(err)
+	}
+	defer conn.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *principal != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, mdPrincipal, *principal)
+	}
+	if *token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, mdAuthorization, "Bearer "+*token)
+	}
+
+	if err := run(ctx, pb.NewURLShortenerServiceClient(conn), out, flags.Args()[1:]); err != nil {
+		fail(err)
+	}
+}
+
+// requestTimeout limits every request, so paging through a long list is not limited as a whole
+func requestTimeout(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: shortenerctl [-addr host:port] [-principal name] [-token token] [-o table|json] [-timeout 10s] "+
+		"shorten|batch|resolve|disable|delete|retarget|list|cache|audit [flags] [args]")
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "shortenerctl:", err)
+	os.Exit(1)
+}
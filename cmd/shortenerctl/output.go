@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type outputFormat string
+
+const (
+	formatTable outputFormat = "table"
+	formatJSON  outputFormat = "json"
+)
+
+// table is a response rendered as rows under the header
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// output prints responses as aligned tables or as JSON, one message per line
+type output struct {
+	w      io.Writer
+	format outputFormat
+}
+
+func newOutput(w io.Writer, format outputFormat) (output, error) {
+	switch format {
+	case formatTable, formatJSON:
+		return output{w: w, format: format}, nil
+	default:
+		return output{}, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// print writes the message, the table is built only for the table output
+func (o output) print(msg proto.Message, render func() table) error {
+	if o.format == formatJSON {
+		b, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(o.w, string(b))
+		return err
+	}
+
+	t := render()
+	tw := tabwriter.NewWriter(o.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+var linkHeader = []string{"CODE", "DOMAIN", "URL", "OWNER", "CREATED", "EXPIRES", "ENABLED", "TAGS"}
+
+// linksTable renders the links one per row
+func linksTable(links ...*pb.Link) table {
+	t := table{header: linkHeader, rows: make([][]string, len(links))}
+	for i, link := range links {
+		t.rows[i] = linkRow(link)
+	}
+	return t
+}
+
+func linkRow(link *pb.Link) []string {
+	return []string{
+		link.Code,
+		link.Domain,
+		link.OriginalUrl,
+		orDash(link.Owner),
+		unixTime(link.CreatedAt),
+		unixTime(link.ExpiresAt),
+		strconv.FormatBool(link.Enabled),
+		orDash(strings.Join(link.Tags, ",")),
+	}
+}
+
+// unixTime formats Unix seconds in UTC, 0 is not set
+func unixTime(sec int64) string {
+	if sec == 0 {
+		return "-"
+	}
+	return time.Unix(sec, 0).UTC().Format(time.RFC3339)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
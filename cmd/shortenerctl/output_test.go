@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"strings"
+	"testing"
+)
+
+func TestOutputTable(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := newOutput(&buf, formatTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link := &pb.Link{Code: "docs", Domain: "sh.some", OriginalUrl: "https://go.dev", CreatedAt: 1735689600, Enabled: true, Tags: []string{"docs", "go"}}
+	if err := out.print(link, func() table { return linksTable(link) }); err != nil {
+		t.Fatal(err)
+	}
+
+	excepted := "CODE  DOMAIN   URL             OWNER  CREATED               EXPIRES  ENABLED  TAGS\n" +
+		"docs  sh.some  https://go.dev  -      2025-01-01T00:00:00Z  -        true     docs,go\n"
+	if buf.String() != excepted {
+		t.Errorf("got\n%s\nexcepted\n%s", buf.String(), excepted)
+	}
+}
+
+func TestOutputJSON(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := newOutput(&buf, formatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := &pb.CacheEntry{Cached: true, Link: &pb.Link{Code: "docs", OriginalUrl: "https://go.dev"}, Ttl: 90}
+	if err := out.print(entry, func() table { panic("table is built for json output") }); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Join(strings.Fields(buf.String()), "")
+	excepted := `{"cached":true,"link":{"code":"docs","original_url":"https://go.dev"},"ttl":"90"}`
+	if got != excepted {
+		t.Errorf("got %s, excepted %s", got, excepted)
+	}
+}
+
+func TestOutputUnknownFormat(t *testing.T) {
+	if _, err := newOutput(&bytes.Buffer{}, "yaml"); err == nil {
+		t.Error("unknown format is accepted")
+	}
+}
+
+func TestReadBatch(t *testing.T) {
+	input := "# links of the docs\nhttps://go.dev docs\n\n  https://pkg.go.dev  \n"
+
+	req, err := readBatch(strings.NewReader(input), "go.some")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(req.Urls) != 2 {
+		t.Fatalf("got %d URLs, excepted 2", len(req.Urls))
+	}
+	if req.Urls[0].Url != "https://go.dev" || req.Urls[0].Alias != "docs" || req.Urls[0].Domain != "go.some" {
+		t.Errorf("bad first URL: %v", req.Urls[0])
+	}
+	if req.Urls[1].Url != "https://pkg.go.dev" || req.Urls[1].Alias != "" {
+		t.Errorf("bad second URL: %v", req.Urls[1])
+	}
+
+	if _, err := readBatch(strings.NewReader("https://go.dev docs extra\n"), ""); err == nil {
+		t.Error("line with extra fields is accepted")
+	}
+	if _, err := readBatch(strings.NewReader("# nothing\n"), ""); err == nil {
+		t.Error("empty batch is accepted")
+	}
+}
+
+func TestAuditTable(t *testing.T) {
+	var buf bytes.Buffer
+	out, err := newOutput(&buf, formatTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := &pb.AuditEvent{Id: 7, Code: "docs", Domain: "sh.some", Action: "retarget", Actor: "telegram:1",
+		OldValue: `{"url":"https://go.dev"}`, NewValue: `{"url":"https://pkg.go.dev"}`, CreatedAt: 1735689600000000}
+	if err := out.print(event, func() table { return auditTable(event) }); err != nil {
+		t.Fatal(err)
+	}
+
+	excepted := "ID  TIME                  ACTION    CODE  DOMAIN   ACTOR       REQUEST  OLD                       NEW\n" +
+		`7   2025-01-01T00:00:00Z  retarget  docs  sh.some  telegram:1  -        {"url":"https://go.dev"}  {"url":"https://pkg.go.dev"}` + "\n"
+	if buf.String() != excepted {
+		t.Errorf("got\n%s\nexcepted\n%s", buf.String(), excepted)
+	}
+}
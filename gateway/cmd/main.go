@@ -30,7 +30,7 @@ func main() {
 	defer stop()
 
 	errChan := make(chan error)
-	go a.Start(errChan)
+	go a.Start(ctx, errChan)
 
 	select {
 	case err := <-errChan:
@@ -6,10 +6,18 @@ import (
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/misshanya/url-shortener/gateway/internal/auth"
 	"github.com/misshanya/url-shortener/gateway/internal/config"
+	"github.com/misshanya/url-shortener/gateway/internal/consumer"
+	"github.com/misshanya/url-shortener/gateway/internal/geoip"
+	"github.com/misshanya/url-shortener/gateway/internal/ratelimit"
+	"github.com/misshanya/url-shortener/gateway/internal/redirectcache"
 	"github.com/misshanya/url-shortener/gateway/internal/service"
 	handler "github.com/misshanya/url-shortener/gateway/internal/transport/http"
 	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"github.com/misshanya/url-shortener/grpcclient"
+	"github.com/segmentio/kafka-go"
+	"github.com/valkey-io/valkey-go"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
@@ -21,7 +29,10 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 )
 
@@ -32,6 +43,13 @@ var (
 type App struct {
 	e              *echo.Echo
 	grpcConn       *grpc.ClientConn
+	geo            *geoip.Resolver
+	valkeyClient   valkey.Client
+	kafkaReader    *kafka.Reader
+	consumer       *consumer.Consumer
+	visits         *service.VisitRecorder
+	stopVisits     context.CancelFunc
+	visitsDone     chan struct{}
 	cfg            *config.Config
 	l              *slog.Logger
 	tracerProvider *trace.TracerProvider
@@ -53,21 +71,96 @@ func New(cfg *config.Config, l *slog.Logger) (*App, error) {
 	}
 	grpcClient := pb.NewURLShortenerServiceClient(a.grpcConn)
 
+	if err := a.initGeoIP(); err != nil {
+		return nil, err
+	}
+
+	interstitial, err := handler.NewInterstitial(a.cfg.Interstitial.TemplatePath, a.cfg.Interstitial.Warning)
+	if err != nil {
+		return nil, err
+	}
+
+	openAPI, err := handler.NewOpenAPI()
+	if err != nil {
+		return nil, err
+	}
+
 	svc := service.NewService(grpcClient, a.cfg.Server.PublicHost)
-	shortenerHandler := handler.NewHandler(svc)
+	if err := a.initRedirectCache(svc, grpcClient); err != nil {
+		return nil, err
+	}
+	if a.cfg.GRPCClient.HedgeGetURL {
+		if a.cfg.Auth.AdminToken == "" {
+			return nil, errors.New("hedged redirects need ADMIN_TOKEN to record their visits")
+		}
+		svc.WithHedgedRedirects(a.visitRecorder(grpcClient))
+	}
+
+	shortenerHandler := handler.NewHandler(svc, a.geo, interstitial)
+	authenticator := handler.NewAuth(svc, handler.AuthPolicy{
+		AdminToken:        a.cfg.Auth.AdminToken,
+		AnonymousScopes:   a.cfg.Auth.AnonymousScopes,
+		AnonymousMaxBatch: a.cfg.Auth.AnonymousMaxBatch,
+		KeyCacheTTL:       a.cfg.Auth.APIKeyCacheTTL,
+	})
+
+	limiter, err := a.initRateLimiter()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.initEcho(); err != nil {
+		return nil, err
+	}
+	a.e.Use(openAPI.Validator())
+
+	a.e.GET("/openapi.json", openAPI.Spec)
+	a.e.GET("/docs", openAPI.Docs)
+	a.e.GET("/docs/:file", openAPI.DocsAsset)
 
-	a.initEcho()
+	ui := handler.NewUI()
+	a.e.GET("/", ui.Page)
+	a.e.GET("/ui.css", ui.Asset)
+	a.e.GET("/ui.js", ui.Asset)
 
-	a.e.POST("/shorten/batch", shortenerHandler.ShortenURLBatch)
-	a.e.POST("/shorten", shortenerHandler.ShortenURL)
-	a.e.GET("/:code", shortenerHandler.UnshortenURL)
+	a.e.POST("/shorten/batch", shortenerHandler.ShortenURLBatch,
+		limiter.GuardAuth("batch"), authenticator.RequireScope(auth.ScopeShorten), limiter.Limit("batch"))
+	a.e.POST("/shorten", shortenerHandler.ShortenURL,
+		limiter.GuardAuth("shorten"), authenticator.RequireScope(auth.ScopeShorten), limiter.Limit("shorten"))
+	a.e.GET("/api/links", shortenerHandler.ListLinks,
+		limiter.GuardAuth("links"), authenticator.RequireScope(auth.ScopeManageLinks), limiter.Limit("links"))
+
+	if a.cfg.Auth.AdminToken != "" {
+		admin := a.e.Group("/api/admin", authenticator.RequireAdmin())
+		admin.POST("/keys", authenticator.CreateAPIKey)
+		admin.GET("/keys", authenticator.ListAPIKeys)
+		admin.DELETE("/keys/:id", authenticator.RevokeAPIKey)
+		admin.GET("/audit", authenticator.ListAuditEvents)
+	}
+	a.e.GET("/api/audit", authenticator.ListAuditEvents,
+		limiter.GuardAuth("links"), authenticator.RequireScope(auth.ScopeReadStats), limiter.Limit("links"))
+	a.e.GET("/:code", shortenerHandler.UnshortenURL, limiter.Limit("redirect"))
+	a.e.GET("/:code/qr", shortenerHandler.QRCode, limiter.Limit("redirect"))
+	a.e.POST("/:code", shortenerHandler.VerifyLinkPassword, limiter.Limit("redirect"))
 
 	return a, nil
 }
 
 // Start performs a start of all functional services
-func (a *App) Start(errChan chan<- error) {
+func (a *App) Start(ctx context.Context, errChan chan<- error) {
 	a.l.Info("starting server", slog.String("addr", a.cfg.Server.Addr))
+	if a.consumer != nil {
+		go a.consumer.ReadMessages(ctx)
+	}
+	if a.visits != nil {
+		// The recorder is stopped by Stop after the last request is served, so no visit is lost
+		ctxVisits, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		a.stopVisits = cancel
+		go func() {
+			a.visits.Run(ctxVisits)
+			close(a.visitsDone)
+		}()
+	}
 	if err := a.e.Start(a.cfg.Server.Addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		errChan <- err
 	}
@@ -84,11 +177,39 @@ func (a *App) Stop(ctx context.Context) error {
 		stopErr = errors.Join(stopErr, fmt.Errorf("failed to stop http server: %w", err))
 	}
 
+	if a.stopVisits != nil {
+		a.l.Info("Sending the rest of the visits...")
+		a.stopVisits()
+		select {
+		case <-a.visitsDone:
+		case <-ctx.Done():
+			stopErr = errors.Join(stopErr, fmt.Errorf("failed to send the rest of the visits: %w", ctx.Err()))
+		}
+	}
+
+	if a.kafkaReader != nil {
+		if err := a.kafkaReader.Close(); err != nil {
+			stopErr = errors.Join(stopErr, fmt.Errorf("failed to close Kafka connection: %w", err))
+		}
+	}
+
 	a.l.Info("Closing gRPC connection...")
 	if err := a.grpcConn.Close(); err != nil {
 		stopErr = errors.Join(stopErr, fmt.Errorf("failed to close gRPC connection: %w", err))
 	}
 
+	if a.valkeyClient != nil {
+		a.l.Info("Closing Valkey connection...")
+		a.valkeyClient.Close()
+	}
+
+	if a.geo != nil {
+		a.l.Info("Closing GeoIP database...")
+		if err := a.geo.Close(); err != nil {
+			stopErr = errors.Join(stopErr, fmt.Errorf("failed to close GeoIP database: %w", err))
+		}
+	}
+
 	a.l.Info("Shutting down tracer provider...")
 	if err := a.tracerProvider.Shutdown(ctx); err != nil {
 		stopErr = errors.Join(stopErr, fmt.Errorf("failed to shutdown tracer provider: %w", err))
@@ -114,7 +235,15 @@ func (a *App) initTracing() error {
 
 // initGRPCClient sets up a new gRPC connection to shortener service
 func (a *App) initGRPCClient() error {
-	grpcConn, err := grpc.NewClient(a.cfg.GRPCClient.ServerAddress,
+	opts := grpcclient.DialOptions(grpcclient.Options{
+		Timeout:         a.cfg.GRPCClient.Timeout,
+		MaxAttempts:     a.cfg.GRPCClient.MaxAttempts,
+		HedgingDelay:    a.cfg.GRPCClient.HedgingDelay,
+		HedgeGetURL:     a.cfg.GRPCClient.HedgeGetURL,
+		BreakerFailures: a.cfg.GRPCClient.BreakerFailures,
+		BreakerCooldown: a.cfg.GRPCClient.BreakerCooldown,
+	})
+	opts = append(opts,
 		grpc.WithTransportCredentials(
 			insecure.NewCredentials(),
 		),
@@ -123,7 +252,9 @@ func (a *App) initGRPCClient() error {
 				otelgrpc.WithTracerProvider(a.tracerProvider),
 			),
 		),
+		grpc.WithUnaryInterceptor(auth.UnaryClientInterceptor()),
 	)
+	grpcConn, err := grpc.NewClient(a.cfg.GRPCClient.ServerAddress, opts...)
 	if err != nil {
 		return fmt.Errorf("failed to init gRPC connection to the shortener service: %w", err)
 	}
@@ -131,9 +262,109 @@ func (a *App) initGRPCClient() error {
 	return nil
 }
 
-// initEcho sets up a new Echo instance with CORS, tracer, logger and recoverer
-func (a *App) initEcho() {
+// initGeoIP opens the GeoIP database if it is configured
+func (a *App) initGeoIP() error {
+	if a.cfg.GeoIP.DBPath == "" {
+		return nil
+	}
+
+	geo, err := geoip.Open(a.cfg.GeoIP.DBPath)
+	if err != nil {
+		return err
+	}
+	a.geo = geo
+	return nil
+}
+
+// initRateLimiter sets up the rate limiter, the limits are shared through Valkey if it is configured
+func (a *App) initRateLimiter() (*handler.RateLimiter, error) {
+	rules, err := ratelimit.ParseRules(a.cfg.RateLimit.Limits)
+	if err != nil {
+		return nil, err
+	}
+
+	allowlist, err := parseNetworks(a.cfg.RateLimit.Allowlist)
+	if err != nil {
+		return nil, fmt.Errorf("bad rate limit allowlist: %w", err)
+	}
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if a.cfg.RateLimit.ValkeyAddr != "" {
+		client, err := valkey.NewClient(valkey.ClientOption{
+			InitAddress: []string{a.cfg.RateLimit.ValkeyAddr},
+			Password:    a.cfg.RateLimit.ValkeyPassword,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to init Valkey connection: %w", err)
+		}
+		a.valkeyClient = client
+		store = ratelimit.NewValkeyStore(client)
+	}
+
+	return handler.NewRateLimiter(store, rules, allowlist), nil
+}
+
+// initRedirectCache sets up the redirect cache with its Kafka reader of the invalidation events if the cache is enabled
+func (a *App) initRedirectCache(svc *service.Service, client pb.URLShortenerServiceClient) error {
+	cfg := a.cfg.Redirects
+	if cfg.CacheSize <= 0 {
+		return nil
+	}
+	if cfg.KafkaAddr == "" {
+		return errors.New("redirect cache needs KAFKA_ADDR to receive invalidation events")
+	}
+	if a.cfg.Auth.AdminToken == "" {
+		return errors.New("redirect cache needs ADMIN_TOKEN to record the visits served from it")
+	}
+
+	// Test a connection before creating reader
+	testKafkaConn, err := kafka.Dial("tcp", cfg.KafkaAddr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Kafka: %w", err)
+	}
+	testKafkaConn.Close()
+
+	// Every gateway has its own cache, so every gateway reads all the events in its own group
+	hostname, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("failed to get hostname: %w", err)
+	}
+	a.kafkaReader = kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     []string{cfg.KafkaAddr},
+		GroupID:     "gateway-" + hostname,
+		GroupTopics: []string{"shortener.invalidated"},
+		StartOffset: kafka.LastOffset,
+	})
+
+	redirects := redirectcache.New(cfg.CacheSize, cfg.CacheTTL)
+	a.consumer = consumer.New(a.l, a.kafkaReader, redirects)
+	svc.WithRedirectCache(redirects, a.visitRecorder(client))
+
+	return nil
+}
+
+// visitRecorder returns the recorder of the visits the gateway reports to the shortener, creating it once
+func (a *App) visitRecorder(client pb.URLShortenerServiceClient) *service.VisitRecorder {
+	if a.visits == nil {
+		cfg := a.cfg.Redirects
+		a.visits = service.NewVisitRecorder(client, a.l, a.cfg.Auth.AdminToken, cfg.VisitBatchSize, cfg.VisitFlushInterval)
+		a.visitsDone = make(chan struct{})
+	}
+	return a.visits
+}
+
+// initEcho sets up a new Echo instance with IP extractor, problem error handler, request id, CORS, tracer, logger and recoverer
+func (a *App) initEcho() error {
+	ipExtractor, err := newIPExtractor(a.cfg.Server.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
 	a.e = echo.New()
+	a.e.IPExtractor = ipExtractor
+	a.e.HTTPErrorHandler = handler.ProblemHandler
+
+	a.e.Use(middleware.RequestID())
 
 	a.e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{a.cfg.Server.CORSOrigin},
@@ -142,14 +373,16 @@ func (a *App) initEcho() {
 	a.e.Use(otelecho.Middleware(serviceName, otelecho.WithTracerProvider(a.tracerProvider)))
 
 	a.e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
-		LogStatus:   true,
-		LogURI:      true,
-		LogError:    true,
-		HandleError: true,
+		LogStatus:    true,
+		LogURI:       true,
+		LogError:     true,
+		LogRequestID: true,
+		HandleError:  true,
 		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
 			if v.Error == nil {
 				a.l.LogAttrs(context.Background(), slog.LevelInfo, "REQUEST",
 					slog.String("uri", v.URI),
+					slog.String("request_id", v.RequestID),
 					slog.Int("status", v.Status),
 					slog.String("ip", v.RemoteIP),
 					slog.String("latency", time.Now().Sub(v.StartTime).String()),
@@ -157,6 +390,7 @@ func (a *App) initEcho() {
 			} else {
 				a.l.LogAttrs(context.Background(), slog.LevelError, "REQUEST_ERROR",
 					slog.String("uri", v.URI),
+					slog.String("request_id", v.RequestID),
 					slog.Int("status", v.Status),
 					slog.String("ip", v.RemoteIP),
 					slog.String("latency", time.Now().Sub(v.StartTime).String()),
@@ -168,6 +402,59 @@ func (a *App) initEcho() {
 	}))
 
 	a.e.Use(middleware.Recover())
+
+	return nil
+}
+
+// newIPExtractor creates an IP extractor that honours X-Forwarded-For only from the trusted proxies.
+// Without trusted proxies, the IP of the connection is used.
+func newIPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	ipRanges, err := parseNetworks(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("bad trusted proxy: %w", err)
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, ipRange := range ipRanges {
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// parseNetworks parses IPs or CIDRs, an IP is the network of itself
+func parseNetworks(networks []string) ([]*net.IPNet, error) {
+	var ipRanges []*net.IPNet
+	for _, network := range networks {
+		network = strings.TrimSpace(network)
+		if network == "" {
+			continue
+		}
+
+		cidr := network
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", network, err)
+		}
+		ipRanges = append(ipRanges, ipRange)
+	}
+	return ipRanges, nil
 }
 
 // newTracerProvider creates a new OpenTelemetry provider
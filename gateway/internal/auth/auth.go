@@ -0,0 +1,75 @@
+// Package auth carries the caller of the request from the HTTP middleware to the gRPC calls of the shortener
+package auth
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"slices"
+)
+
+// Scopes of API keys
+const (
+	ScopeShorten     = "shorten"
+	ScopeReadStats   = "read-stats"
+	ScopeManageLinks = "manage-links"
+)
+
+// mdPrincipal is the metadata key the shortener takes the principal from, it is recorded as the owner of new links
+const mdPrincipal = "x-principal"
+
+// mdAuthorization is the metadata key the shortener takes the admin token of the admin RPCs from
+const mdAuthorization = "authorization"
+
+// Caller is who makes the request, either the owner of the API key or anonymous
+type Caller struct {
+	// Principal is empty if the caller is anonymous
+	Principal string
+	Scopes    []string
+
+	// MaxBatch limits the number of links shortened at once, 0 if unlimited
+	MaxBatch int
+}
+
+// IsAnonymous reports whether the request came without an API key
+func (c Caller) IsAnonymous() bool {
+	return c.Principal == ""
+}
+
+// HasScope reports whether the caller is allowed to do what the scope grants
+func (c Caller) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+type callerKey struct{}
+
+// WithCaller returns the context carrying the caller
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// FromContext returns the caller of the context, false if the request is not authenticated
+func FromContext(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(callerKey{}).(Caller)
+	return caller, ok
+}
+
+type adminTokenKey struct{}
+
+// WithAdminToken returns the context carrying the admin token checked by the gateway
+func WithAdminToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, adminTokenKey{}, token)
+}
+
+// UnaryClientInterceptor forwards the principal of the caller and the admin token to the shortener
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if caller, ok := FromContext(ctx); ok && !caller.IsAnonymous() {
+			ctx = metadata.AppendToOutgoingContext(ctx, mdPrincipal, caller.Principal)
+		}
+		if token, ok := ctx.Value(adminTokenKey{}).(string); ok && token != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, mdAuthorization, "Bearer "+token)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
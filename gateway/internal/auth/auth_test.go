@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"testing"
+)
+
+func Test_UnaryClientInterceptor(t *testing.T) {
+	tests := []struct {
+		Name                  string
+		Ctx                   context.Context
+		ExceptedPrincipal     []string
+		ExceptedAuthorization []string
+	}{
+		{
+			Name:              "API key",
+			Ctx:               WithCaller(context.Background(), Caller{Principal: "apikey:ci", Scopes: []string{ScopeShorten}}),
+			ExceptedPrincipal: []string{"apikey:ci"},
+		},
+		{
+			Name: "Anonymous",
+			Ctx:  WithCaller(context.Background(), Caller{Scopes: []string{ScopeShorten}, MaxBatch: 10}),
+		},
+		{
+			Name: "Not authenticated",
+			Ctx:  context.Background(),
+		},
+		{
+			Name:                  "Admin",
+			Ctx:                   WithAdminToken(context.Background(), "admin-secret"),
+			ExceptedAuthorization: []string{"Bearer admin-secret"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var md metadata.MD
+			invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				md, _ = metadata.FromOutgoingContext(ctx)
+				return nil
+			}
+
+			err := UnaryClientInterceptor()(tt.Ctx, "/v1.URLShortenerService/ShortenURL", nil, nil, nil, invoker)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ExceptedPrincipal, md.Get(mdPrincipal))
+			assert.Equal(t, tt.ExceptedAuthorization, md.Get(mdAuthorization))
+		})
+	}
+}
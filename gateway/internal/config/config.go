@@ -2,22 +2,108 @@ package config
 
 import (
 	"github.com/ilyakaznacheev/cleanenv"
+	"time"
 )
 
 type Config struct {
-	Server     server
-	GRPCClient gRPCClient
-	Tracing    tracing
+	Server       server
+	GRPCClient   gRPCClient
+	Tracing      tracing
+	GeoIP        geoIP
+	Interstitial interstitial
+	Auth         auth
+	RateLimit    rateLimit
+	Redirects    redirects
 }
 
 type server struct {
 	Addr       string `env:"SERVER_ADDR" env-default:":8080"`
-	PublicHost string `env:"PUBLIC_HOST" env-default:"localhost:8080"`
+	PublicHost string `env:"PUBLIC_HOST" env-default:"http://localhost:8080/"`
 	CORSOrigin string `env:"CORS_ORIGIN" env-default:"localhost:8080"`
+
+	// TrustedProxies are IPs or CIDRs of proxies allowed to set X-Forwarded-For
+	TrustedProxies []string `env:"TRUSTED_PROXIES" env-separator:","`
 }
 
 type gRPCClient struct {
 	ServerAddress string `env:"GRPC_SERVER_ADDR" env-required:"true"`
+
+	// Timeout is the deadline of every call to the shortener with all its attempts
+	Timeout time.Duration `env:"GRPC_TIMEOUT" env-default:"5s"`
+
+	// MaxAttempts of idempotent calls, they are retried while the shortener is unavailable
+	MaxAttempts int `env:"GRPC_MAX_ATTEMPTS" env-default:"3"`
+
+	// HedgingDelay hedges the calls without side effects, e.g. PreviewURL, hedging is disabled if 0
+	HedgingDelay time.Duration `env:"GRPC_HEDGING_DELAY" env-default:"0s"`
+
+	// HedgeGetURL hedges the redirects too, the gateway resolves them without the visit and records it itself then.
+	// It needs ADMIN_TOKEN, the visits are recorded with the admin RPC RecordVisits
+	HedgeGetURL bool `env:"GRPC_HEDGE_GET_URL" env-default:"false"`
+
+	// BreakerFailures in a row make the calls fail fast for BreakerCooldown, the breaker is disabled if 0
+	BreakerFailures int           `env:"GRPC_BREAKER_FAILURES" env-default:"5"`
+	BreakerCooldown time.Duration `env:"GRPC_BREAKER_COOLDOWN" env-default:"10s"`
+}
+
+type geoIP struct {
+	// DBPath is a path to MaxMind-format .mmdb file, geo rules are disabled if empty
+	DBPath string `env:"GEOIP_DB_PATH"`
+}
+
+// interstitial configures the page showing the destination of the link before the visitor goes there
+type interstitial struct {
+	// TemplatePath is a path to html/template file replacing the embedded page
+	TemplatePath string `env:"INTERSTITIAL_TEMPLATE"`
+
+	// Warning is the policy banner on the page, no banner if empty
+	Warning string `env:"INTERSTITIAL_WARNING" env-default:"Check the destination before you continue. Never enter passwords or payment details on a site you don't trust."`
+}
+
+// auth configures API keys and what is allowed without them
+type auth struct {
+	// AdminToken is the bearer token of /api/admin, the admin API is disabled if empty
+	AdminToken string `env:"ADMIN_TOKEN"`
+
+	// AnonymousScopes are granted to requests without an API key, anonymous access is disabled if empty
+	AnonymousScopes []string `env:"ANONYMOUS_SCOPES" env-separator:"," env-default:"shorten"`
+
+	// AnonymousMaxBatch limits the links in one batch without an API key, 0 if unlimited
+	AnonymousMaxBatch int `env:"ANONYMOUS_MAX_BATCH" env-default:"10"`
+
+	// APIKeyCacheTTL is how long authenticated keys are cached, keys revoked on other gateways work until it passes
+	APIKeyCacheTTL time.Duration `env:"API_KEY_CACHE_TTL" env-default:"1m"`
+}
+
+// rateLimit configures throttling of the routes per client IP and per API key
+type rateLimit struct {
+	// ValkeyAddr is the Valkey sharing the limits between the gateways, every gateway limits on its own if empty
+	ValkeyAddr     string `env:"RATE_LIMIT_VALKEY_ADDR"`
+	ValkeyPassword string `env:"RATE_LIMIT_VALKEY_PASSWORD"`
+
+	// Limits are route:subject=rate/period, routes are shorten, batch and redirect, subjects are ip and key
+	Limits []string `env:"RATE_LIMITS" env-separator:"," env-default:"shorten:ip=20/1m,shorten:key=300/1m,batch:ip=2/1m,batch:key=30/1m,links:ip=60/1m,links:key=600/1m,redirect:ip=300/1m"`
+
+	// Allowlist are IPs or CIDRs of trusted networks which are never limited
+	Allowlist []string `env:"RATE_LIMIT_ALLOWLIST" env-separator:","`
+}
+
+// redirects configures caching of the static redirects of hot codes in the gateway
+type redirects struct {
+	// CacheSize is the max number of cached redirects, the cache is disabled if 0.
+	// The cache needs ADMIN_TOKEN, the visits served from it are recorded with the admin RPC RecordVisits
+	CacheSize int `env:"REDIRECT_CACHE_SIZE" env-default:"0"`
+
+	// CacheTTL is how long a redirect is cached, changes of the link missed by the invalidation events show up after it passes
+	CacheTTL time.Duration `env:"REDIRECT_CACHE_TTL" env-default:"10s"`
+
+	// KafkaAddr is the Kafka with the invalidation events of the links, required if the cache is enabled
+	KafkaAddr string `env:"KAFKA_ADDR"`
+
+	// Visits served from the cache are sent to the shortener by VisitBatchSize or every VisitFlushInterval,
+	// the defaults are used if they are not positive
+	VisitBatchSize     int           `env:"VISIT_BATCH_SIZE" env-default:"100"`
+	VisitFlushInterval time.Duration `env:"VISIT_FLUSH_INTERVAL" env-default:"1s"`
 }
 
 type tracing struct {
@@ -0,0 +1,61 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/segmentio/kafka-go"
+	"log/slog"
+)
+
+// invalidated is the event of the shortener telling the link changed or stopped redirecting
+type invalidated struct {
+	Code   string `json:"code"`
+	Domain string `json:"domain"`
+}
+
+type cache interface {
+	Invalidate(code string)
+}
+
+type Consumer struct {
+	l     *slog.Logger
+	kr    *kafka.Reader
+	cache cache
+}
+
+func New(l *slog.Logger, kr *kafka.Reader, cache cache) *Consumer {
+	return &Consumer{
+		l:     l,
+		kr:    kr,
+		cache: cache,
+	}
+}
+
+// ReadMessages drops the invalidated links from the redirect cache until ctx is done
+func (c *Consumer) ReadMessages(ctx context.Context) {
+	for {
+		m, err := c.kr.ReadMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			c.l.Error("Failed to read message", "error", err)
+			continue
+		}
+
+		if m.Topic != "shortener.invalidated" {
+			continue
+		}
+
+		var msg invalidated
+		if err := json.Unmarshal(m.Value, &msg); err != nil {
+			c.l.Error("Failed to unmarshal JSON",
+				"topic", m.Topic,
+				"error", err)
+			continue
+		}
+
+		c.cache.Invalidate(msg.Code)
+	}
+}
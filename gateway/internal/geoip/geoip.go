@@ -0,0 +1,53 @@
+package geoip
+
+import (
+	"fmt"
+	"github.com/oschwald/maxminddb-golang"
+	"net"
+)
+
+// Resolver looks up the country of IP addresses in a MaxMind-format database
+type Resolver struct {
+	db *maxminddb.Reader
+}
+
+// record holds the fields of GeoIP2/GeoLite2 Country and City databases we need
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+}
+
+// Open opens the .mmdb database at path
+func Open(path string) (*Resolver, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open GeoIP database: %w", err)
+	}
+	return &Resolver{db: db}, nil
+}
+
+// Country returns ISO 3166-1 alpha-2 code of the IP country.
+// It returns empty string if the country is unknown or the resolver is nil.
+func (r *Resolver) Country(ip net.IP) string {
+	if r == nil || ip == nil {
+		return ""
+	}
+
+	var rec record
+	if err := r.db.Lookup(ip, &rec); err != nil {
+		return ""
+	}
+	if rec.Country.ISOCode != "" {
+		return rec.Country.ISOCode
+	}
+	return rec.RegisteredCountry.ISOCode
+}
+
+// Close closes the database
+func (r *Resolver) Close() error {
+	return r.db.Close()
+}
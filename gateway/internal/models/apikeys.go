@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// APIKey is the key issued by the shortener, its secret is known only when it is created
+type APIKey struct {
+	ID int64
+
+	// Prefix is the beginning of the key to tell the keys apart
+	Prefix    string
+	Principal string
+	Name      string
+	Scopes    []string
+	CreatedAt time.Time
+
+	// RevokedAt is zero if the key is active
+	RevokedAt time.Time
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// AuditEvent records who created, changed or deleted the link and when
+type AuditEvent struct {
+	ID     int64
+	Code   string
+	Domain string
+	Action string
+
+	// Actor is the principal made the change, empty if anonymous
+	Actor     string
+	RequestID string
+
+	// OldValue and NewValue are JSON objects with the changed fields, empty if there is no such state
+	OldValue  string
+	NewValue  string
+	CreatedAt time.Time
+}
+
+// AuditFilter selects the listed audit events, zero fields are not filtered
+type AuditFilter struct {
+	Code   string
+	Domain string
+	Actor  string
+	Action string
+}
@@ -1,6 +1,26 @@
 package models
 
+import "time"
+
+// HTTPError is the error of the request, it is rendered as application/problem+json
 type HTTPError struct {
 	Code    int
 	Message string
+
+	// ErrorCode is the stable machine-readable code of the error, e.g. not_found or alias_taken
+	ErrorCode  string
+	Violations []Violation
+
+	// RetryAfter is how long the client waits before retrying, zero if unknown
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	return e.Message
+}
+
+// Violation is the problem with the field of the request
+type Violation struct {
+	Field   string
+	Message string
 }
@@ -1,7 +1,121 @@
 package models
 
+import "time"
+
 type Short struct {
 	ShortURL    string
 	OriginalURL string
+	Options     LinkOptions
 	Error       string
 }
+
+// Link is a stored link as listed by the shortener
+type Link struct {
+	ShortURL          string
+	Code              string
+	Domain            string
+	OriginalURL       string
+	Owner             string
+	CreatedAt         time.Time
+	NotBefore         time.Time
+	ExpiresAt         time.Time
+	PasswordProtected bool
+	Enabled           bool
+	Title             string
+	Tags              []string
+}
+
+// LinkFilter selects the listed links, zero fields are not filtered
+type LinkFilter struct {
+	Owner       string
+	Domain      string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Enabled     *bool
+	Search      string
+	Tags        []string
+}
+
+// LinkOptions describes how a link behaves on redirect
+type LinkOptions struct {
+	ForwardQuery bool
+	UTM          map[string]string
+	Rules        []RoutingRule
+	Destinations []Destination
+	Password     string
+	NotBefore    time.Time
+	ExpiresAt    time.Time
+
+	// Domain is the registered host of the link, the default domain if empty
+	Domain string
+
+	// Alias is the custom code of the link
+	Alias string
+
+	// Title, Notes and Tags help to organise links
+	Title string
+	Notes string
+	Tags  []string
+
+	// Interstitial shows the visitor the destination before the redirect
+	Interstitial bool
+}
+
+// Destination is a variant of the link chosen by weight
+type Destination struct {
+	URL    string
+	Weight uint32
+	Name   string
+}
+
+// RoutingRule sends visitors matching all of its non-empty conditions to URL
+type RoutingRule struct {
+	URL            string
+	Name           string
+	UserAgent      string
+	Platform       string
+	AcceptLanguage string
+	Countries      []string
+}
+
+// Redirect is where the visitor of the link is sent
+type Redirect struct {
+	URL string
+
+	// Interstitial tells to show the destination to the visitor instead of redirecting right away
+	Interstitial bool
+}
+
+// LinkPreview is the link shown to the visitor without visiting it
+type LinkPreview struct {
+	ShortURL string
+	Code     string
+	Domain   string
+
+	// OriginalURL is empty if the link is password-protected
+	OriginalURL       string
+	CreatedAt         time.Time
+	PasswordProtected bool
+	Interstitial      bool
+}
+
+// Visit describes the incoming redirect request
+type Visit struct {
+	// Host is the host the link is requested on, it selects the domain of the link
+	Host           string
+	Query          string
+	Referer        string
+	UserAgent      string
+	Platform       string
+	AcceptLanguage string
+	Country        string
+	VisitorID      string
+	LinkToken      string
+
+	// IP is the client IP, taken from X-Forwarded-For only if the request came through a trusted proxy
+	IP string
+
+	// Rule and Variant the shortener resolved the visit to, reported with the visit the gateway records
+	Rule    string
+	Variant string
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memorySweepSize is the number of keys after which the keys with full quota are dropped
+const memorySweepSize = 10000
+
+// MemoryStore keeps the quotas in the process, so every replica has its own limits.
+// It is used when Valkey is not configured.
+type MemoryStore struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+	now  func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tats: make(map[string]time.Time), now: time.Now}
+}
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if len(s.tats) >= memorySweepSize {
+		s.sweep(now)
+	}
+
+	tat, result := gcra(now, s.tats[key], limit)
+	s.tats[key] = tat
+	return result, nil
+}
+
+func (s *MemoryStore) Peek(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, result := gcra(s.now(), s.tats[key], limit)
+	return result, nil
+}
+
+// sweep drops the keys whose quota is full, they are the same as missing ones
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, tat := range s.tats {
+		if !tat.After(now) {
+			delete(s.tats, key)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Package ratelimit limits how often the clients call the gateway with GCRA,
+// the state is kept in Valkey, so the limits are shared by the replicas
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Subjects the limits are counted for
+const (
+	SubjectIP  = "ip"
+	SubjectKey = "key"
+)
+
+// Limit allows Rate requests per Period, all of them may come at once
+type Limit struct {
+	Rate   int
+	Period time.Duration
+}
+
+// interval is the time one request takes from the quota
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+// Result is the decision on the request and the state of the quota after it
+type Result struct {
+	Allowed   bool
+	Limit     Limit
+	Remaining int
+
+	// RetryAfter is when the next request is allowed, 0 if it is allowed now
+	RetryAfter time.Duration
+	// ResetAfter is when the whole quota is available again
+	ResetAfter time.Duration
+}
+
+// Store takes a request from the quota of the key
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+	// Peek decides on the request like Allow, but doesn't take it from the quota
+	Peek(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Rules are the limits of the routes by the subject
+type Rules map[string]map[string]Limit
+
+// ParseRules parses "route:subject=rate/period" items, e.g. "shorten:ip=20/1m"
+func ParseRules(items []string) (Rules, error) {
+	rules := make(Rules)
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		target, value, ok := strings.Cut(item, "=")
+		route, subject, ok2 := strings.Cut(target, ":")
+		if !ok || !ok2 || route == "" {
+			return nil, fmt.Errorf("bad rate limit %q, want route:subject=rate/period", item)
+		}
+		if subject != SubjectIP && subject != SubjectKey {
+			return nil, fmt.Errorf("bad rate limit %q, subject is %s or %s", item, SubjectIP, SubjectKey)
+		}
+
+		limit, err := ParseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("bad rate limit %q: %w", item, err)
+		}
+
+		if rules[route] == nil {
+			rules[route] = make(map[string]Limit)
+		}
+		rules[route][subject] = limit
+	}
+	return rules, nil
+}
+
+// ParseLimit parses "rate/period", e.g. "20/1m"
+func ParseLimit(s string) (Limit, error) {
+	rateStr, periodStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, errors.New("want rate/period")
+	}
+
+	rate, err := strconv.Atoi(rateStr)
+	if err != nil || rate <= 0 {
+		return Limit{}, errors.New("rate must be a positive integer")
+	}
+	period, err := time.ParseDuration(periodStr)
+	if err != nil || period <= 0 {
+		return Limit{}, errors.New("period must be a positive duration, e.g. 1m")
+	}
+	if period/time.Duration(rate) < time.Microsecond {
+		return Limit{}, errors.New("rate is too high for the period")
+	}
+
+	return Limit{Rate: rate, Period: period}, nil
+}
+
+// gcra decides on the request arriving at now given the theoretical arrival time of the key,
+// it returns the new theoretical arrival time, which is tat itself if the request is denied
+func gcra(now, tat time.Time, limit Limit) (time.Time, Result) {
+	interval := limit.interval()
+	// tolerance lets the whole quota be spent at once
+	tolerance := limit.Period
+
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-tolerance)
+
+	if now.Before(allowAt) {
+		return tat, Result{
+			Limit:      limit,
+			RetryAfter: allowAt.Sub(now),
+			ResetAfter: tat.Sub(now),
+		}
+	}
+
+	return newTAT, Result{
+		Allowed:    true,
+		Limit:      limit,
+		Remaining:  int(now.Sub(allowAt) / interval),
+		ResetAfter: newTAT.Sub(now),
+	}
+}
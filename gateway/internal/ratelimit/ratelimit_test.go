@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_ParseRules(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Items         []string
+		ExceptedRules Rules
+		ExceptedErr   string
+	}{
+		{
+			Name:  "Routes and subjects",
+			Items: []string{"shorten:ip=20/1m", " shorten:key=300/1m", "redirect:ip=5/1s", ""},
+			ExceptedRules: Rules{
+				"shorten":  {SubjectIP: {Rate: 20, Period: time.Minute}, SubjectKey: {Rate: 300, Period: time.Minute}},
+				"redirect": {SubjectIP: {Rate: 5, Period: time.Second}},
+			},
+		},
+		{
+			Name:        "No subject",
+			Items:       []string{"shorten=20/1m"},
+			ExceptedErr: `bad rate limit "shorten=20/1m", want route:subject=rate/period`,
+		},
+		{
+			Name:        "Unknown subject",
+			Items:       []string{"shorten:user=20/1m"},
+			ExceptedErr: `bad rate limit "shorten:user=20/1m", subject is ip or key`,
+		},
+		{
+			Name:        "Bad rate",
+			Items:       []string{"shorten:ip=0/1m"},
+			ExceptedErr: `bad rate limit "shorten:ip=0/1m": rate must be a positive integer`,
+		},
+		{
+			Name:        "Bad period",
+			Items:       []string{"shorten:ip=20/minute"},
+			ExceptedErr: `bad rate limit "shorten:ip=20/minute": period must be a positive duration, e.g. 1m`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			rules, err := ParseRules(tt.Items)
+			if tt.ExceptedErr != "" {
+				assert.EqualError(t, err, tt.ExceptedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.ExceptedRules, rules)
+		})
+	}
+}
+
+func Test_MemoryStore(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Rate: 3, Period: 3 * time.Second}
+
+	now := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	// The whole quota may be spent at once
+	for remaining := 2; remaining >= 0; remaining-- {
+		result, err := store.Allow(ctx, "ip:1.2.3.4", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+	}
+
+	result, err := store.Allow(ctx, "ip:1.2.3.4", limit)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Limit: limit, RetryAfter: time.Second, ResetAfter: 3 * time.Second}, result)
+
+	// Peek decides without taking from the quota
+	result, err = store.Peek(ctx, "ip:1.2.3.4", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	result, err = store.Peek(ctx, "ip:5.6.7.8", limit)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Allowed: true, Limit: limit, Remaining: 2, ResetAfter: time.Second}, result)
+
+	// Other keys have their own quota
+	result, err = store.Allow(ctx, "ip:5.6.7.8", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// One request is given back every interval
+	now = now.Add(time.Second)
+	result, err = store.Allow(ctx, "ip:1.2.3.4", limit)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Allowed: true, Limit: limit, ResetAfter: 3 * time.Second}, result)
+
+	result, err = store.Allow(ctx, "ip:1.2.3.4", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	// The quota is full again after the period
+	now = now.Add(limit.Period)
+	result, err = store.Allow(ctx, "ip:1.2.3.4", limit)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Allowed: true, Limit: limit, Remaining: 2, ResetAfter: time.Second}, result)
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/valkey-io/valkey-go"
+	"strconv"
+	"time"
+)
+
+// keyPrefix separates the quotas from other data of the Valkey database
+const keyPrefix = "ratelimit:"
+
+// gcraScript is gcra run atomically in Valkey, times are in microseconds of the Valkey clock,
+// so the replicas agree on the time. The key holds the theoretical arrival time and expires when the quota is full,
+// it is not changed if the third argument is 0.
+// It returns allowed (0 or 1), remaining, retry after and reset after.
+var gcraScript = valkey.NewLuaScript(`
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local take = ARGV[3] == '1'
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+  tat = now
+end
+
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+
+if now < allow_at then
+  return {0, 0, allow_at - now, tat - now}
+end
+
+if take then
+  -- %d keeps all digits, numbers are converted to strings with 14 significant digits
+  redis.call('SET', KEYS[1], string.format('%d', new_tat), 'PX', string.format('%d', math.ceil((new_tat - now) / 1000)))
+end
+return {1, math.floor((now - allow_at) / interval), 0, new_tat - now}
+`)
+
+// ValkeyStore keeps the quotas in Valkey, so they are shared by the replicas of the gateway
+type ValkeyStore struct {
+	client valkey.Client
+}
+
+func NewValkeyStore(client valkey.Client) *ValkeyStore {
+	return &ValkeyStore{client: client}
+}
+
+func (s *ValkeyStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	return s.gcra(ctx, key, limit, true)
+}
+
+func (s *ValkeyStore) Peek(ctx context.Context, key string, limit Limit) (Result, error) {
+	return s.gcra(ctx, key, limit, false)
+}
+
+// gcra runs gcraScript for the key, the request is taken from the quota if take is set
+func (s *ValkeyStore) gcra(ctx context.Context, key string, limit Limit, take bool) (Result, error) {
+	takeArg := "0"
+	if take {
+		takeArg = "1"
+	}
+
+	values, err := gcraScript.Exec(ctx, s.client, []string{keyPrefix + key}, []string{
+		strconv.FormatInt(limit.interval().Microseconds(), 10),
+		strconv.FormatInt(limit.Period.Microseconds(), 10),
+		takeArg,
+	}).AsIntSlice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(values) != 4 {
+		return Result{}, fmt.Errorf("unexpected reply of the rate limit script: %v", values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
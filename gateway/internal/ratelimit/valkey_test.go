@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valkey-io/valkey-go"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestValkeyStore needs a disposable Valkey, the keys are unique to the run
+func TestValkeyStore(t *testing.T) {
+	addr := os.Getenv("GATEWAY_TEST_VALKEY_ADDR")
+	if addr == "" {
+		t.Skip("GATEWAY_TEST_VALKEY_ADDR is not set")
+	}
+
+	client, err := valkey.NewClient(valkey.ClientOption{InitAddress: []string{addr}})
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+
+	ctx := context.Background()
+	store := NewValkeyStore(client)
+	key := "ip:test-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	limit := Limit{Rate: 2, Period: time.Minute}
+
+	result, err := store.Peek(ctx, key, limit)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Remaining)
+	exists, err := client.Do(ctx, client.B().Exists().Key(keyPrefix+key).Build()).AsInt64()
+	require.NoError(t, err)
+	assert.Zero(t, exists, "peek doesn't take from the quota")
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		result, err := store.Allow(ctx, key, limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, remaining, result.Remaining)
+	}
+
+	result, err = store.Allow(ctx, key, limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.InDelta(t, 30*time.Second, result.RetryAfter, float64(time.Second))
+	assert.InDelta(t, time.Minute, result.ResetAfter, float64(time.Second))
+
+	ttl, err := client.Do(ctx, client.B().Pttl().Key(keyPrefix+key).Build()).AsInt64()
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute.Milliseconds(), ttl, 1000, "the key expires when the quota is full")
+}
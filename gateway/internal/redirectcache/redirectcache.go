@@ -0,0 +1,126 @@
+// Package redirectcache keeps the static redirects of hot codes in the gateway, so they skip the shortener.
+// Entries live for a short TTL and are dropped by the invalidation events of the shortener,
+// the least recently used entries are evicted when the cache is full.
+package redirectcache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Entry is the cached redirect
+type Entry struct {
+	URL          string
+	Interstitial bool
+}
+
+type item struct {
+	host      string
+	code      string
+	entry     Entry
+	expiresAt time.Time
+}
+
+// Cache is the bounded LRU cache of redirects by the host and the code
+type Cache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu  sync.Mutex
+	lru *list.List
+
+	// items are indexed by the code first, so the code is invalidated on every host at once
+	items map[string]map[string]*list.Element
+}
+
+func New(size int, ttl time.Duration) *Cache {
+	return &Cache{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		lru:   list.New(),
+		items: make(map[string]map[string]*list.Element),
+	}
+}
+
+// Get returns the redirect of the code on the host
+func (c *Cache) Get(host, code string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[code][host]
+	if !ok {
+		return Entry{}, false
+	}
+
+	it := el.Value.(*item)
+	if !c.now().Before(it.expiresAt) {
+		c.remove(el)
+		return Entry{}, false
+	}
+
+	c.lru.MoveToFront(el)
+	return it.entry, true
+}
+
+// Set caches the redirect for the TTL, but not after the link expires. Zero expiresAt means the link never expires.
+func (c *Cache) Set(host, code string, entry Entry, expiresAt time.Time) {
+	now := c.now()
+	until := now.Add(c.ttl)
+	if !expiresAt.IsZero() && expiresAt.Before(until) {
+		until = expiresAt
+	}
+	if !until.After(now) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[code][host]; ok {
+		it := el.Value.(*item)
+		it.entry, it.expiresAt = entry, until
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	el := c.lru.PushFront(&item{host: host, code: code, entry: entry, expiresAt: until})
+	if c.items[code] == nil {
+		c.items[code] = make(map[string]*list.Element)
+	}
+	c.items[code][host] = el
+
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+	}
+}
+
+// Invalidate drops the code on every host.
+// The event names the domain of the link, but the link may be requested on other hosts falling back to it,
+// so the code of the links on other domains is dropped too, it is only a cache miss for them.
+func (c *Cache) Invalidate(code string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, el := range c.items[code] {
+		c.remove(el)
+	}
+}
+
+// Len returns the number of entries, expired ones included until they are touched
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+func (c *Cache) remove(el *list.Element) {
+	it := c.lru.Remove(el).(*item)
+	delete(c.items[it.code], it.host)
+	if len(c.items[it.code]) == 0 {
+		delete(c.items, it.code)
+	}
+}
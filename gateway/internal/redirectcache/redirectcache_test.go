@@ -0,0 +1,80 @@
+package redirectcache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Cache(t *testing.T) {
+	now := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	newCache := func(size int) *Cache {
+		c := New(size, 10*time.Second)
+		c.now = func() time.Time { return now }
+		return c
+	}
+	goDev := Entry{URL: "https://go.dev"}
+
+	t.Run("Entry expires after TTL", func(t *testing.T) {
+		c := newCache(10)
+		c.Set("sh.some", "3a", goDev, time.Time{})
+
+		entry, ok := c.Get("sh.some", "3a")
+		assert.True(t, ok)
+		assert.Equal(t, goDev, entry)
+
+		_, ok = c.Get("go.some", "3a")
+		assert.False(t, ok)
+
+		now = now.Add(10 * time.Second)
+		_, ok = c.Get("sh.some", "3a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("Entry doesn't outlive the link", func(t *testing.T) {
+		c := newCache(10)
+		c.Set("sh.some", "3a", goDev, now.Add(time.Second))
+
+		now = now.Add(time.Second)
+		_, ok := c.Get("sh.some", "3a")
+		assert.False(t, ok)
+
+		// Expired link is not cached at all
+		c.Set("sh.some", "3a", goDev, now)
+		assert.Equal(t, 0, c.Len())
+	})
+
+	t.Run("Least recently used entry is evicted", func(t *testing.T) {
+		c := newCache(2)
+		c.Set("sh.some", "1", goDev, time.Time{})
+		c.Set("sh.some", "2", goDev, time.Time{})
+		c.Get("sh.some", "1")
+		c.Set("sh.some", "3", goDev, time.Time{})
+
+		_, ok := c.Get("sh.some", "2")
+		assert.False(t, ok)
+		_, ok = c.Get("sh.some", "1")
+		assert.True(t, ok)
+		_, ok = c.Get("sh.some", "3")
+		assert.True(t, ok)
+		assert.Equal(t, 2, c.Len())
+	})
+
+	t.Run("Invalidation drops the code on every host", func(t *testing.T) {
+		c := newCache(10)
+		c.Set("sh.some", "docs", goDev, time.Time{})
+		c.Set("sh.some:8080", "docs", goDev, time.Time{})
+		c.Set("sh.some", "3a", goDev, time.Time{})
+
+		c.Invalidate("docs")
+
+		_, ok := c.Get("sh.some", "docs")
+		assert.False(t, ok)
+		_, ok = c.Get("sh.some:8080", "docs")
+		assert.False(t, ok)
+		_, ok = c.Get("sh.some", "3a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, c.Len())
+	})
+}
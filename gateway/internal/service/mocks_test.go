@@ -6,7 +6,9 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"github.com/misshanya/url-shortener/gateway/internal/models"
 	"github.com/misshanya/url-shortener/gen/go/v1"
 	mock "github.com/stretchr/testify/mock"
 	"google.golang.org/grpc"
@@ -39,6 +41,172 @@ func (_m *mockgrpcClient) EXPECT() *mockgrpcClient_Expecter {
 	return &mockgrpcClient_Expecter{mock: &_m.Mock}
 }
 
+// AuthenticateAPIKey provides a mock function for the type mockgrpcClient
+func (_mock *mockgrpcClient) AuthenticateAPIKey(ctx context.Context, in *v1.AuthenticateAPIKeyRequest, opts ...grpc.CallOption) (*v1.APIKey, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for AuthenticateAPIKey")
+	}
+
+	var r0 *v1.APIKey
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.AuthenticateAPIKeyRequest, ...grpc.CallOption) (*v1.APIKey, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.AuthenticateAPIKeyRequest, ...grpc.CallOption) *v1.APIKey); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.APIKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1.AuthenticateAPIKeyRequest, ...grpc.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockgrpcClient_AuthenticateAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AuthenticateAPIKey'
+type mockgrpcClient_AuthenticateAPIKey_Call struct {
+	*mock.Call
+}
+
+// AuthenticateAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.AuthenticateAPIKeyRequest
+//   - opts ...grpc.CallOption
+func (_e *mockgrpcClient_Expecter) AuthenticateAPIKey(ctx interface{}, in interface{}, opts ...interface{}) *mockgrpcClient_AuthenticateAPIKey_Call {
+	return &mockgrpcClient_AuthenticateAPIKey_Call{Call: _e.mock.On("AuthenticateAPIKey",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *mockgrpcClient_AuthenticateAPIKey_Call) Run(run func(ctx context.Context, in *v1.AuthenticateAPIKeyRequest, opts ...grpc.CallOption)) *mockgrpcClient_AuthenticateAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v1.AuthenticateAPIKeyRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v1.AuthenticateAPIKeyRequest)
+		}
+		var arg2 []grpc.CallOption
+		var variadicArgs []grpc.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]grpc.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockgrpcClient_AuthenticateAPIKey_Call) Return(aPIKey *v1.APIKey, err error) *mockgrpcClient_AuthenticateAPIKey_Call {
+	_c.Call.Return(aPIKey, err)
+	return _c
+}
+
+func (_c *mockgrpcClient_AuthenticateAPIKey_Call) RunAndReturn(run func(ctx context.Context, in *v1.AuthenticateAPIKeyRequest, opts ...grpc.CallOption) (*v1.APIKey, error)) *mockgrpcClient_AuthenticateAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateAPIKey provides a mock function for the type mockgrpcClient
+func (_mock *mockgrpcClient) CreateAPIKey(ctx context.Context, in *v1.CreateAPIKeyRequest, opts ...grpc.CallOption) (*v1.CreateAPIKeyResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAPIKey")
+	}
+
+	var r0 *v1.CreateAPIKeyResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.CreateAPIKeyRequest, ...grpc.CallOption) (*v1.CreateAPIKeyResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.CreateAPIKeyRequest, ...grpc.CallOption) *v1.CreateAPIKeyResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.CreateAPIKeyResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1.CreateAPIKeyRequest, ...grpc.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockgrpcClient_CreateAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateAPIKey'
+type mockgrpcClient_CreateAPIKey_Call struct {
+	*mock.Call
+}
+
+// CreateAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.CreateAPIKeyRequest
+//   - opts ...grpc.CallOption
+func (_e *mockgrpcClient_Expecter) CreateAPIKey(ctx interface{}, in interface{}, opts ...interface{}) *mockgrpcClient_CreateAPIKey_Call {
+	return &mockgrpcClient_CreateAPIKey_Call{Call: _e.mock.On("CreateAPIKey",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *mockgrpcClient_CreateAPIKey_Call) Run(run func(ctx context.Context, in *v1.CreateAPIKeyRequest, opts ...grpc.CallOption)) *mockgrpcClient_CreateAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v1.CreateAPIKeyRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v1.CreateAPIKeyRequest)
+		}
+		var arg2 []grpc.CallOption
+		var variadicArgs []grpc.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]grpc.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockgrpcClient_CreateAPIKey_Call) Return(createAPIKeyResponse *v1.CreateAPIKeyResponse, err error) *mockgrpcClient_CreateAPIKey_Call {
+	_c.Call.Return(createAPIKeyResponse, err)
+	return _c
+}
+
+func (_c *mockgrpcClient_CreateAPIKey_Call) RunAndReturn(run func(ctx context.Context, in *v1.CreateAPIKeyRequest, opts ...grpc.CallOption) (*v1.CreateAPIKeyResponse, error)) *mockgrpcClient_CreateAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetURL provides a mock function for the type mockgrpcClient
 func (_mock *mockgrpcClient) GetURL(ctx context.Context, in *v1.GetURLRequest, opts ...grpc.CallOption) (*v1.GetURLResponse, error) {
 	var tmpRet mock.Arguments
@@ -50,22 +218,354 @@ func (_mock *mockgrpcClient) GetURL(ctx context.Context, in *v1.GetURLRequest, o
 	ret := tmpRet
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetURL")
+		panic("no return value specified for GetURL")
+	}
+
+	var r0 *v1.GetURLResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.GetURLRequest, ...grpc.CallOption) (*v1.GetURLResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.GetURLRequest, ...grpc.CallOption) *v1.GetURLResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.GetURLResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1.GetURLRequest, ...grpc.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockgrpcClient_GetURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetURL'
+type mockgrpcClient_GetURL_Call struct {
+	*mock.Call
+}
+
+// GetURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.GetURLRequest
+//   - opts ...grpc.CallOption
+func (_e *mockgrpcClient_Expecter) GetURL(ctx interface{}, in interface{}, opts ...interface{}) *mockgrpcClient_GetURL_Call {
+	return &mockgrpcClient_GetURL_Call{Call: _e.mock.On("GetURL",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *mockgrpcClient_GetURL_Call) Run(run func(ctx context.Context, in *v1.GetURLRequest, opts ...grpc.CallOption)) *mockgrpcClient_GetURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v1.GetURLRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v1.GetURLRequest)
+		}
+		var arg2 []grpc.CallOption
+		var variadicArgs []grpc.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]grpc.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockgrpcClient_GetURL_Call) Return(getURLResponse *v1.GetURLResponse, err error) *mockgrpcClient_GetURL_Call {
+	_c.Call.Return(getURLResponse, err)
+	return _c
+}
+
+func (_c *mockgrpcClient_GetURL_Call) RunAndReturn(run func(ctx context.Context, in *v1.GetURLRequest, opts ...grpc.CallOption) (*v1.GetURLResponse, error)) *mockgrpcClient_GetURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAPIKeys provides a mock function for the type mockgrpcClient
+func (_mock *mockgrpcClient) ListAPIKeys(ctx context.Context, in *v1.ListAPIKeysRequest, opts ...grpc.CallOption) (*v1.ListAPIKeysResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAPIKeys")
+	}
+
+	var r0 *v1.ListAPIKeysResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.ListAPIKeysRequest, ...grpc.CallOption) (*v1.ListAPIKeysResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.ListAPIKeysRequest, ...grpc.CallOption) *v1.ListAPIKeysResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.ListAPIKeysResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1.ListAPIKeysRequest, ...grpc.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockgrpcClient_ListAPIKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAPIKeys'
+type mockgrpcClient_ListAPIKeys_Call struct {
+	*mock.Call
+}
+
+// ListAPIKeys is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.ListAPIKeysRequest
+//   - opts ...grpc.CallOption
+func (_e *mockgrpcClient_Expecter) ListAPIKeys(ctx interface{}, in interface{}, opts ...interface{}) *mockgrpcClient_ListAPIKeys_Call {
+	return &mockgrpcClient_ListAPIKeys_Call{Call: _e.mock.On("ListAPIKeys",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *mockgrpcClient_ListAPIKeys_Call) Run(run func(ctx context.Context, in *v1.ListAPIKeysRequest, opts ...grpc.CallOption)) *mockgrpcClient_ListAPIKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v1.ListAPIKeysRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v1.ListAPIKeysRequest)
+		}
+		var arg2 []grpc.CallOption
+		var variadicArgs []grpc.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]grpc.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockgrpcClient_ListAPIKeys_Call) Return(listAPIKeysResponse *v1.ListAPIKeysResponse, err error) *mockgrpcClient_ListAPIKeys_Call {
+	_c.Call.Return(listAPIKeysResponse, err)
+	return _c
+}
+
+func (_c *mockgrpcClient_ListAPIKeys_Call) RunAndReturn(run func(ctx context.Context, in *v1.ListAPIKeysRequest, opts ...grpc.CallOption) (*v1.ListAPIKeysResponse, error)) *mockgrpcClient_ListAPIKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAuditEvents provides a mock function for the type mockgrpcClient
+func (_mock *mockgrpcClient) ListAuditEvents(ctx context.Context, in *v1.ListAuditEventsRequest, opts ...grpc.CallOption) (*v1.ListAuditEventsResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAuditEvents")
+	}
+
+	var r0 *v1.ListAuditEventsResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.ListAuditEventsRequest, ...grpc.CallOption) (*v1.ListAuditEventsResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.ListAuditEventsRequest, ...grpc.CallOption) *v1.ListAuditEventsResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.ListAuditEventsResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1.ListAuditEventsRequest, ...grpc.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockgrpcClient_ListAuditEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAuditEvents'
+type mockgrpcClient_ListAuditEvents_Call struct {
+	*mock.Call
+}
+
+// ListAuditEvents is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.ListAuditEventsRequest
+//   - opts ...grpc.CallOption
+func (_e *mockgrpcClient_Expecter) ListAuditEvents(ctx interface{}, in interface{}, opts ...interface{}) *mockgrpcClient_ListAuditEvents_Call {
+	return &mockgrpcClient_ListAuditEvents_Call{Call: _e.mock.On("ListAuditEvents",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *mockgrpcClient_ListAuditEvents_Call) Run(run func(ctx context.Context, in *v1.ListAuditEventsRequest, opts ...grpc.CallOption)) *mockgrpcClient_ListAuditEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v1.ListAuditEventsRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v1.ListAuditEventsRequest)
+		}
+		var arg2 []grpc.CallOption
+		var variadicArgs []grpc.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]grpc.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockgrpcClient_ListAuditEvents_Call) Return(listAuditEventsResponse *v1.ListAuditEventsResponse, err error) *mockgrpcClient_ListAuditEvents_Call {
+	_c.Call.Return(listAuditEventsResponse, err)
+	return _c
+}
+
+func (_c *mockgrpcClient_ListAuditEvents_Call) RunAndReturn(run func(ctx context.Context, in *v1.ListAuditEventsRequest, opts ...grpc.CallOption) (*v1.ListAuditEventsResponse, error)) *mockgrpcClient_ListAuditEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListURLs provides a mock function for the type mockgrpcClient
+func (_mock *mockgrpcClient) ListURLs(ctx context.Context, in *v1.ListURLsRequest, opts ...grpc.CallOption) (*v1.ListURLsResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListURLs")
+	}
+
+	var r0 *v1.ListURLsResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.ListURLsRequest, ...grpc.CallOption) (*v1.ListURLsResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.ListURLsRequest, ...grpc.CallOption) *v1.ListURLsResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.ListURLsResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1.ListURLsRequest, ...grpc.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockgrpcClient_ListURLs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListURLs'
+type mockgrpcClient_ListURLs_Call struct {
+	*mock.Call
+}
+
+// ListURLs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.ListURLsRequest
+//   - opts ...grpc.CallOption
+func (_e *mockgrpcClient_Expecter) ListURLs(ctx interface{}, in interface{}, opts ...interface{}) *mockgrpcClient_ListURLs_Call {
+	return &mockgrpcClient_ListURLs_Call{Call: _e.mock.On("ListURLs",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *mockgrpcClient_ListURLs_Call) Run(run func(ctx context.Context, in *v1.ListURLsRequest, opts ...grpc.CallOption)) *mockgrpcClient_ListURLs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v1.ListURLsRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v1.ListURLsRequest)
+		}
+		var arg2 []grpc.CallOption
+		var variadicArgs []grpc.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]grpc.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockgrpcClient_ListURLs_Call) Return(listURLsResponse *v1.ListURLsResponse, err error) *mockgrpcClient_ListURLs_Call {
+	_c.Call.Return(listURLsResponse, err)
+	return _c
+}
+
+func (_c *mockgrpcClient_ListURLs_Call) RunAndReturn(run func(ctx context.Context, in *v1.ListURLsRequest, opts ...grpc.CallOption) (*v1.ListURLsResponse, error)) *mockgrpcClient_ListURLs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PreviewURL provides a mock function for the type mockgrpcClient
+func (_mock *mockgrpcClient) PreviewURL(ctx context.Context, in *v1.PreviewURLRequest, opts ...grpc.CallOption) (*v1.LinkPreview, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for PreviewURL")
 	}
 
-	var r0 *v1.GetURLResponse
+	var r0 *v1.LinkPreview
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.GetURLRequest, ...grpc.CallOption) (*v1.GetURLResponse, error)); ok {
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.PreviewURLRequest, ...grpc.CallOption) (*v1.LinkPreview, error)); ok {
 		return returnFunc(ctx, in, opts...)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.GetURLRequest, ...grpc.CallOption) *v1.GetURLResponse); ok {
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.PreviewURLRequest, ...grpc.CallOption) *v1.LinkPreview); ok {
 		r0 = returnFunc(ctx, in, opts...)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*v1.GetURLResponse)
+			r0 = ret.Get(0).(*v1.LinkPreview)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1.GetURLRequest, ...grpc.CallOption) error); ok {
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1.PreviewURLRequest, ...grpc.CallOption) error); ok {
 		r1 = returnFunc(ctx, in, opts...)
 	} else {
 		r1 = ret.Error(1)
@@ -73,29 +573,29 @@ func (_mock *mockgrpcClient) GetURL(ctx context.Context, in *v1.GetURLRequest, o
 	return r0, r1
 }
 
-// mockgrpcClient_GetURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetURL'
-type mockgrpcClient_GetURL_Call struct {
+// mockgrpcClient_PreviewURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PreviewURL'
+type mockgrpcClient_PreviewURL_Call struct {
 	*mock.Call
 }
 
-// GetURL is a helper method to define mock.On call
+// PreviewURL is a helper method to define mock.On call
 //   - ctx context.Context
-//   - in *v1.GetURLRequest
+//   - in *v1.PreviewURLRequest
 //   - opts ...grpc.CallOption
-func (_e *mockgrpcClient_Expecter) GetURL(ctx interface{}, in interface{}, opts ...interface{}) *mockgrpcClient_GetURL_Call {
-	return &mockgrpcClient_GetURL_Call{Call: _e.mock.On("GetURL",
+func (_e *mockgrpcClient_Expecter) PreviewURL(ctx interface{}, in interface{}, opts ...interface{}) *mockgrpcClient_PreviewURL_Call {
+	return &mockgrpcClient_PreviewURL_Call{Call: _e.mock.On("PreviewURL",
 		append([]interface{}{ctx, in}, opts...)...)}
 }
 
-func (_c *mockgrpcClient_GetURL_Call) Run(run func(ctx context.Context, in *v1.GetURLRequest, opts ...grpc.CallOption)) *mockgrpcClient_GetURL_Call {
+func (_c *mockgrpcClient_PreviewURL_Call) Run(run func(ctx context.Context, in *v1.PreviewURLRequest, opts ...grpc.CallOption)) *mockgrpcClient_PreviewURL_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 *v1.GetURLRequest
+		var arg1 *v1.PreviewURLRequest
 		if args[1] != nil {
-			arg1 = args[1].(*v1.GetURLRequest)
+			arg1 = args[1].(*v1.PreviewURLRequest)
 		}
 		var arg2 []grpc.CallOption
 		var variadicArgs []grpc.CallOption
@@ -112,12 +612,178 @@ func (_c *mockgrpcClient_GetURL_Call) Run(run func(ctx context.Context, in *v1.G
 	return _c
 }
 
-func (_c *mockgrpcClient_GetURL_Call) Return(getURLResponse *v1.GetURLResponse, err error) *mockgrpcClient_GetURL_Call {
-	_c.Call.Return(getURLResponse, err)
+func (_c *mockgrpcClient_PreviewURL_Call) Return(linkPreview *v1.LinkPreview, err error) *mockgrpcClient_PreviewURL_Call {
+	_c.Call.Return(linkPreview, err)
 	return _c
 }
 
-func (_c *mockgrpcClient_GetURL_Call) RunAndReturn(run func(ctx context.Context, in *v1.GetURLRequest, opts ...grpc.CallOption) (*v1.GetURLResponse, error)) *mockgrpcClient_GetURL_Call {
+func (_c *mockgrpcClient_PreviewURL_Call) RunAndReturn(run func(ctx context.Context, in *v1.PreviewURLRequest, opts ...grpc.CallOption) (*v1.LinkPreview, error)) *mockgrpcClient_PreviewURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordVisits provides a mock function for the type mockgrpcClient
+func (_mock *mockgrpcClient) RecordVisits(ctx context.Context, in *v1.RecordVisitsRequest, opts ...grpc.CallOption) (*v1.RecordVisitsResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordVisits")
+	}
+
+	var r0 *v1.RecordVisitsResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.RecordVisitsRequest, ...grpc.CallOption) (*v1.RecordVisitsResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.RecordVisitsRequest, ...grpc.CallOption) *v1.RecordVisitsResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.RecordVisitsResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1.RecordVisitsRequest, ...grpc.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockgrpcClient_RecordVisits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordVisits'
+type mockgrpcClient_RecordVisits_Call struct {
+	*mock.Call
+}
+
+// RecordVisits is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.RecordVisitsRequest
+//   - opts ...grpc.CallOption
+func (_e *mockgrpcClient_Expecter) RecordVisits(ctx interface{}, in interface{}, opts ...interface{}) *mockgrpcClient_RecordVisits_Call {
+	return &mockgrpcClient_RecordVisits_Call{Call: _e.mock.On("RecordVisits",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *mockgrpcClient_RecordVisits_Call) Run(run func(ctx context.Context, in *v1.RecordVisitsRequest, opts ...grpc.CallOption)) *mockgrpcClient_RecordVisits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v1.RecordVisitsRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v1.RecordVisitsRequest)
+		}
+		var arg2 []grpc.CallOption
+		var variadicArgs []grpc.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]grpc.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockgrpcClient_RecordVisits_Call) Return(recordVisitsResponse *v1.RecordVisitsResponse, err error) *mockgrpcClient_RecordVisits_Call {
+	_c.Call.Return(recordVisitsResponse, err)
+	return _c
+}
+
+func (_c *mockgrpcClient_RecordVisits_Call) RunAndReturn(run func(ctx context.Context, in *v1.RecordVisitsRequest, opts ...grpc.CallOption) (*v1.RecordVisitsResponse, error)) *mockgrpcClient_RecordVisits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeAPIKey provides a mock function for the type mockgrpcClient
+func (_mock *mockgrpcClient) RevokeAPIKey(ctx context.Context, in *v1.RevokeAPIKeyRequest, opts ...grpc.CallOption) (*v1.APIKey, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 *v1.APIKey
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.RevokeAPIKeyRequest, ...grpc.CallOption) (*v1.APIKey, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.RevokeAPIKeyRequest, ...grpc.CallOption) *v1.APIKey); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.APIKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1.RevokeAPIKeyRequest, ...grpc.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockgrpcClient_RevokeAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeAPIKey'
+type mockgrpcClient_RevokeAPIKey_Call struct {
+	*mock.Call
+}
+
+// RevokeAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.RevokeAPIKeyRequest
+//   - opts ...grpc.CallOption
+func (_e *mockgrpcClient_Expecter) RevokeAPIKey(ctx interface{}, in interface{}, opts ...interface{}) *mockgrpcClient_RevokeAPIKey_Call {
+	return &mockgrpcClient_RevokeAPIKey_Call{Call: _e.mock.On("RevokeAPIKey",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *mockgrpcClient_RevokeAPIKey_Call) Run(run func(ctx context.Context, in *v1.RevokeAPIKeyRequest, opts ...grpc.CallOption)) *mockgrpcClient_RevokeAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v1.RevokeAPIKeyRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v1.RevokeAPIKeyRequest)
+		}
+		var arg2 []grpc.CallOption
+		var variadicArgs []grpc.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]grpc.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockgrpcClient_RevokeAPIKey_Call) Return(aPIKey *v1.APIKey, err error) *mockgrpcClient_RevokeAPIKey_Call {
+	_c.Call.Return(aPIKey, err)
+	return _c
+}
+
+func (_c *mockgrpcClient_RevokeAPIKey_Call) RunAndReturn(run func(ctx context.Context, in *v1.RevokeAPIKeyRequest, opts ...grpc.CallOption) (*v1.APIKey, error)) *mockgrpcClient_RevokeAPIKey_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -287,3 +953,165 @@ func (_c *mockgrpcClient_ShortenURLBatch_Call) RunAndReturn(run func(ctx context
 	_c.Call.Return(run)
 	return _c
 }
+
+// VerifyLinkPassword provides a mock function for the type mockgrpcClient
+func (_mock *mockgrpcClient) VerifyLinkPassword(ctx context.Context, in *v1.VerifyLinkPasswordRequest, opts ...grpc.CallOption) (*v1.VerifyLinkPasswordResponse, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(ctx, in, opts)
+	} else {
+		tmpRet = _mock.Called(ctx, in)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyLinkPassword")
+	}
+
+	var r0 *v1.VerifyLinkPasswordResponse
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.VerifyLinkPasswordRequest, ...grpc.CallOption) (*v1.VerifyLinkPasswordResponse, error)); ok {
+		return returnFunc(ctx, in, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *v1.VerifyLinkPasswordRequest, ...grpc.CallOption) *v1.VerifyLinkPasswordResponse); ok {
+		r0 = returnFunc(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*v1.VerifyLinkPasswordResponse)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *v1.VerifyLinkPasswordRequest, ...grpc.CallOption) error); ok {
+		r1 = returnFunc(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockgrpcClient_VerifyLinkPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'VerifyLinkPassword'
+type mockgrpcClient_VerifyLinkPassword_Call struct {
+	*mock.Call
+}
+
+// VerifyLinkPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - in *v1.VerifyLinkPasswordRequest
+//   - opts ...grpc.CallOption
+func (_e *mockgrpcClient_Expecter) VerifyLinkPassword(ctx interface{}, in interface{}, opts ...interface{}) *mockgrpcClient_VerifyLinkPassword_Call {
+	return &mockgrpcClient_VerifyLinkPassword_Call{Call: _e.mock.On("VerifyLinkPassword",
+		append([]interface{}{ctx, in}, opts...)...)}
+}
+
+func (_c *mockgrpcClient_VerifyLinkPassword_Call) Run(run func(ctx context.Context, in *v1.VerifyLinkPasswordRequest, opts ...grpc.CallOption)) *mockgrpcClient_VerifyLinkPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *v1.VerifyLinkPasswordRequest
+		if args[1] != nil {
+			arg1 = args[1].(*v1.VerifyLinkPasswordRequest)
+		}
+		var arg2 []grpc.CallOption
+		var variadicArgs []grpc.CallOption
+		if len(args) > 2 {
+			variadicArgs = args[2].([]grpc.CallOption)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockgrpcClient_VerifyLinkPassword_Call) Return(verifyLinkPasswordResponse *v1.VerifyLinkPasswordResponse, err error) *mockgrpcClient_VerifyLinkPassword_Call {
+	_c.Call.Return(verifyLinkPasswordResponse, err)
+	return _c
+}
+
+func (_c *mockgrpcClient_VerifyLinkPassword_Call) RunAndReturn(run func(ctx context.Context, in *v1.VerifyLinkPasswordRequest, opts ...grpc.CallOption) (*v1.VerifyLinkPasswordResponse, error)) *mockgrpcClient_VerifyLinkPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockvisitRecorder creates a new instance of mockvisitRecorder. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockvisitRecorder(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockvisitRecorder {
+	mock := &mockvisitRecorder{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockvisitRecorder is an autogenerated mock type for the visitRecorder type
+type mockvisitRecorder struct {
+	mock.Mock
+}
+
+type mockvisitRecorder_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockvisitRecorder) EXPECT() *mockvisitRecorder_Expecter {
+	return &mockvisitRecorder_Expecter{mock: &_m.Mock}
+}
+
+// Record provides a mock function for the type mockvisitRecorder
+func (_mock *mockvisitRecorder) Record(code string, visit models.Visit, at time.Time) {
+	_mock.Called(code, visit, at)
+	return
+}
+
+// mockvisitRecorder_Record_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Record'
+type mockvisitRecorder_Record_Call struct {
+	*mock.Call
+}
+
+// Record is a helper method to define mock.On call
+//   - code string
+//   - visit models.Visit
+//   - at time.Time
+func (_e *mockvisitRecorder_Expecter) Record(code interface{}, visit interface{}, at interface{}) *mockvisitRecorder_Record_Call {
+	return &mockvisitRecorder_Record_Call{Call: _e.mock.On("Record", code, visit, at)}
+}
+
+func (_c *mockvisitRecorder_Record_Call) Run(run func(code string, visit models.Visit, at time.Time)) *mockvisitRecorder_Record_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 models.Visit
+		if args[1] != nil {
+			arg1 = args[1].(models.Visit)
+		}
+		var arg2 time.Time
+		if args[2] != nil {
+			arg2 = args[2].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockvisitRecorder_Record_Call) Return() *mockvisitRecorder_Record_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockvisitRecorder_Record_Call) RunAndReturn(run func(code string, visit models.Visit, at time.Time)) *mockvisitRecorder_Record_Call {
+	_c.Run(run)
+	return _c
+}
@@ -3,93 +3,263 @@ package service
 import (
 	"context"
 	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"github.com/misshanya/url-shortener/gateway/internal/redirectcache"
 	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"github.com/misshanya/url-shortener/shorturl"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"net/http"
+	"strings"
+	"time"
+	"unicode"
 )
 
 type grpcClient interface {
 	ShortenURL(ctx context.Context, in *pb.ShortenURLRequest, opts ...grpc.CallOption) (*pb.ShortenURLResponse, error)
 	ShortenURLBatch(ctx context.Context, in *pb.ShortenURLBatchRequest, opts ...grpc.CallOption) (*pb.ShortenURLBatchResponse, error)
 	GetURL(ctx context.Context, in *pb.GetURLRequest, opts ...grpc.CallOption) (*pb.GetURLResponse, error)
+	RecordVisits(ctx context.Context, in *pb.RecordVisitsRequest, opts ...grpc.CallOption) (*pb.RecordVisitsResponse, error)
+	PreviewURL(ctx context.Context, in *pb.PreviewURLRequest, opts ...grpc.CallOption) (*pb.LinkPreview, error)
+	VerifyLinkPassword(ctx context.Context, in *pb.VerifyLinkPasswordRequest, opts ...grpc.CallOption) (*pb.VerifyLinkPasswordResponse, error)
+	ListURLs(ctx context.Context, in *pb.ListURLsRequest, opts ...grpc.CallOption) (*pb.ListURLsResponse, error)
+	CreateAPIKey(ctx context.Context, in *pb.CreateAPIKeyRequest, opts ...grpc.CallOption) (*pb.CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, in *pb.ListAPIKeysRequest, opts ...grpc.CallOption) (*pb.ListAPIKeysResponse, error)
+	RevokeAPIKey(ctx context.Context, in *pb.RevokeAPIKeyRequest, opts ...grpc.CallOption) (*pb.APIKey, error)
+	ListAuditEvents(ctx context.Context, in *pb.ListAuditEventsRequest, opts ...grpc.CallOption) (*pb.ListAuditEventsResponse, error)
+	AuthenticateAPIKey(ctx context.Context, in *pb.AuthenticateAPIKeyRequest, opts ...grpc.CallOption) (*pb.APIKey, error)
+}
+
+type visitRecorder interface {
+	Record(code string, visit models.Visit, at time.Time)
 }
 
 type Service struct {
 	client     grpcClient
 	publicHost string
+
+	// redirects is nil if the redirect cache is disabled, the visits served from it go to visits
+	redirects *redirectcache.Cache
+	visits    visitRecorder
+
+	// skipVisits resolves the redirects without recording the visits, so the calls can be hedged, they go to visits
+	skipVisits bool
 }
 
 func NewService(client grpcClient, publicHost string) *Service {
 	return &Service{client: client, publicHost: publicHost}
 }
 
+// WithRedirectCache serves the static redirects from the cache, the visits served from it are reported to the recorder
+func (s *Service) WithRedirectCache(redirects *redirectcache.Cache, visits visitRecorder) *Service {
+	s.redirects = redirects
+	s.visits = visits
+	return s
+}
+
+// WithHedgedRedirects resolves the redirects without recording the visits, so the client can hedge them.
+// The visits are reported to the recorder instead.
+func (s *Service) WithHedgedRedirects(visits visitRecorder) *Service {
+	s.skipVisits = true
+	s.visits = visits
+	return s
+}
+
+// statusClientClosedRequest is the non-standard status of the request canceled by the client
+const statusClientClosedRequest = 499
+
+// grpcStatuses maps gRPC codes into HTTP statuses
+var grpcStatuses = map[codes.Code]int{
+	codes.Canceled:           statusClientClosedRequest,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// hiddenCodes are the codes whose messages are about the internals, the client gets the status text instead
+var hiddenCodes = map[codes.Code]bool{
+	codes.Unknown:          true,
+	codes.DeadlineExceeded: true,
+	codes.Unavailable:      true,
+	codes.DataLoss:         true,
+}
+
+// mapGRPCError maps the gRPC status with its details into the HTTP error.
+// ErrorInfo sets the error code, BadRequest, PreconditionFailure and QuotaFailure add violations, RetryInfo sets Retry-After.
 func mapGRPCError(err error) *models.HTTPError {
 	s, ok := status.FromError(err)
 	if !ok {
 		return &models.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: "Internal Server Error",
+			Code:      http.StatusInternalServerError,
+			Message:   "Internal Server Error",
+			ErrorCode: "internal",
 		}
 	}
-
-	switch s.Code() {
-	case codes.OK:
+	if s.Code() == codes.OK {
 		return nil
-	case codes.Internal:
+	}
+
+	code, ok := grpcStatuses[s.Code()]
+	if !ok {
 		return &models.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: s.Message(),
+			Code:      http.StatusInternalServerError,
+			Message:   "Internal Server Error",
+			ErrorCode: "internal",
 		}
-	case codes.NotFound:
-		return &models.HTTPError{
-			Code:    http.StatusNotFound,
-			Message: s.Message(),
+	}
+
+	httpErr := &models.HTTPError{
+		Code:      code,
+		Message:   s.Message(),
+		ErrorCode: errorCode(s.Code()),
+	}
+	if hiddenCodes[s.Code()] {
+		httpErr.Message = http.StatusText(code)
+	}
+
+	for _, detail := range s.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if detail.GetReason() != "" {
+				httpErr.ErrorCode = strings.ToLower(detail.GetReason())
+			}
+		case *errdetails.BadRequest:
+			for _, v := range detail.GetFieldViolations() {
+				httpErr.Violations = append(httpErr.Violations, models.Violation{Field: v.GetField(), Message: v.GetDescription()})
+			}
+		case *errdetails.PreconditionFailure:
+			for _, v := range detail.GetViolations() {
+				httpErr.Violations = append(httpErr.Violations, models.Violation{Field: v.GetSubject(), Message: v.GetDescription()})
+			}
+		case *errdetails.QuotaFailure:
+			for _, v := range detail.GetViolations() {
+				httpErr.Violations = append(httpErr.Violations, models.Violation{Field: v.GetSubject(), Message: v.GetDescription()})
+			}
+		case *errdetails.RetryInfo:
+			httpErr.RetryAfter = detail.GetRetryDelay().AsDuration()
 		}
-	case codes.InvalidArgument:
-		return &models.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: s.Message(),
+	}
+
+	return httpErr
+}
+
+// errorCode converts the gRPC code name into the error code, e.g. InvalidArgument into invalid_argument
+func errorCode(code codes.Code) string {
+	var b strings.Builder
+	for i, r := range code.String() {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
 		}
-	default:
-		return &models.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: "Internal Server Error",
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// shortenRequest maps URL with link options into a gRPC request
+func shortenRequest(url string, options models.LinkOptions) *pb.ShortenURLRequest {
+	req := &pb.ShortenURLRequest{
+		Url:          url,
+		ForwardQuery: options.ForwardQuery,
+		Utm:          options.UTM,
+		Password:     options.Password,
+		Domain:       options.Domain,
+		Alias:        options.Alias,
+		Title:        options.Title,
+		Notes:        options.Notes,
+		Tags:         options.Tags,
+		Interstitial: options.Interstitial,
+	}
+	if !options.NotBefore.IsZero() {
+		req.NotBefore = options.NotBefore.Unix()
+	}
+	if !options.ExpiresAt.IsZero() {
+		req.ExpiresAt = options.ExpiresAt.Unix()
+	}
+	for _, rule := range options.Rules {
+		req.Rules = append(req.Rules, &pb.RoutingRule{
+			Url:            rule.URL,
+			Name:           rule.Name,
+			UserAgent:      rule.UserAgent,
+			Platform:       rule.Platform,
+			AcceptLanguage: rule.AcceptLanguage,
+			Countries:      rule.Countries,
+		})
+	}
+	for _, destination := range options.Destinations {
+		req.Destinations = append(req.Destinations, &pb.Destination{
+			Url:    destination.URL,
+			Weight: destination.Weight,
+			Name:   destination.Name,
+		})
+	}
+	return req
+}
+
+// visitMetadata appends the visitor attributes to the outgoing metadata
+func visitMetadata(ctx context.Context, visit models.Visit) context.Context {
+	var kv []string
+	for key, value := range map[string]string{
+		"x-client-referer":         visit.Referer,
+		"x-client-user-agent":      visit.UserAgent,
+		"x-client-platform":        visit.Platform,
+		"x-client-accept-language": visit.AcceptLanguage,
+		"x-client-country":         visit.Country,
+		"x-client-visitor-id":      visit.VisitorID,
+		"x-client-ip":              visit.IP,
+		"x-link-token":             visit.LinkToken,
+	} {
+		if value != "" {
+			kv = append(kv, key, value)
 		}
 	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
 }
 
-func (s *Service) ShortenURL(ctx context.Context, url string) (string, *models.HTTPError) {
-	resp, err := s.client.ShortenURL(ctx, &pb.ShortenURLRequest{Url: url})
+func (s *Service) ShortenURL(ctx context.Context, url string, options models.LinkOptions) (string, *models.HTTPError) {
+	resp, err := s.client.ShortenURL(ctx, shortenRequest(url, options))
 	if httpErr := mapGRPCError(err); httpErr != nil {
-		return "", &models.HTTPError{
-			Code:    httpErr.Code,
-			Message: httpErr.Message,
-		}
+		return "", httpErr
 	}
 
-	short := s.publicHost + resp.Code
+	short := shorturl.Build(s.publicHost, resp.Domain, resp.Code)
 	return short, nil
 }
 
 func (s *Service) ShortenURLBatch(ctx context.Context, urls []*models.Short) *models.HTTPError {
 	urlsForReq := make([]*pb.ShortenURLRequest, len(urls))
 	for i, url := range urls {
-		urlsForReq[i] = &pb.ShortenURLRequest{Url: url.OriginalURL}
+		urlsForReq[i] = shortenRequest(url.OriginalURL, url.Options)
 	}
 	resp, err := s.client.ShortenURLBatch(ctx, &pb.ShortenURLBatchRequest{Urls: urlsForReq})
 	if httpErr := mapGRPCError(err); httpErr != nil {
-		return &models.HTTPError{
-			Code:    httpErr.Code,
-			Message: httpErr.Message,
-		}
+		return httpErr
 	}
 
 	if len(resp.Urls) != len(urls) {
 		return &models.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: "Internal Server Error",
+			Code:      http.StatusInternalServerError,
+			Message:   "Internal Server Error",
+			ErrorCode: "internal",
 		}
 	}
 
@@ -98,20 +268,205 @@ func (s *Service) ShortenURLBatch(ctx context.Context, urls []*models.Short) *mo
 			urls[i].Error = url.Error
 			continue
 		}
-		urls[i].ShortURL = s.publicHost + url.Code
+		urls[i].ShortURL = shorturl.Build(s.publicHost, url.Domain, url.Code)
 	}
 
 	return nil
 }
 
-func (s *Service) UnshortenURL(ctx context.Context, code string) (string, *models.HTTPError) {
-	resp, err := s.client.GetURL(ctx, &pb.GetURLRequest{Code: code})
+// UnshortenURL resolves the code for the visitor.
+// Redirects the shortener marks cacheable are cached if the cache is enabled, the visit is still counted.
+// The visits of hedged redirects are recorded by the gateway.
+func (s *Service) UnshortenURL(ctx context.Context, code string, visit models.Visit) (*models.Redirect, *models.HTTPError) {
+	if s.redirects != nil {
+		if entry, ok := s.redirects.Get(visit.Host, code); ok {
+			s.visits.Record(code, visit, time.Now())
+			return &models.Redirect{URL: entry.URL, Interstitial: entry.Interstitial}, nil
+		}
+	}
+
+	ctx = visitMetadata(ctx, visit)
+	resp, err := s.client.GetURL(ctx, &pb.GetURLRequest{Code: code, Query: visit.Query, Domain: visit.Host, SkipVisit: s.skipVisits})
 	if httpErr := mapGRPCError(err); httpErr != nil {
-		return "", &models.HTTPError{
-			Code:    httpErr.Code,
-			Message: httpErr.Message,
+		return nil, httpErr
+	}
+
+	if s.skipVisits {
+		visit.Rule, visit.Variant = resp.Rule, resp.Variant
+		s.visits.Record(code, visit, time.Now())
+	}
+
+	if s.redirects != nil && resp.Cacheable {
+		s.redirects.Set(visit.Host, code, redirectcache.Entry{URL: resp.Url, Interstitial: resp.Interstitial}, unixTime(resp.ExpiresAt))
+	}
+
+	return &models.Redirect{URL: resp.Url, Interstitial: resp.Interstitial}, nil
+}
+
+// PreviewURL returns where the link on the host goes without visiting it
+func (s *Service) PreviewURL(ctx context.Context, host, code string) (*models.LinkPreview, *models.HTTPError) {
+	resp, err := s.client.PreviewURL(ctx, &pb.PreviewURLRequest{Code: code, Domain: host})
+	if httpErr := mapGRPCError(err); httpErr != nil {
+		return nil, httpErr
+	}
+
+	return &models.LinkPreview{
+		ShortURL:          shorturl.Build(s.publicHost, resp.Domain, resp.Code),
+		Code:              resp.Code,
+		Domain:            resp.Domain,
+		OriginalURL:       resp.Url,
+		CreatedAt:         unixTime(resp.CreatedAt),
+		PasswordProtected: resp.PasswordProtected,
+		Interstitial:      resp.Interstitial,
+	}, nil
+}
+
+func (s *Service) VerifyLinkPassword(ctx context.Context, host, code, password string) (string, time.Time, *models.HTTPError) {
+	resp, err := s.client.VerifyLinkPassword(ctx, &pb.VerifyLinkPasswordRequest{Code: code, Password: password, Domain: host})
+	if httpErr := mapGRPCError(err); httpErr != nil {
+		return "", time.Time{}, httpErr
+	}
+
+	return resp.Token, time.Unix(resp.ExpiresAt, 0), nil
+}
+
+// ListLinks returns a page of the links matching the filter and the cursor of the next page
+func (s *Service) ListLinks(ctx context.Context, filter models.LinkFilter, cursor string, limit int) ([]models.Link, string, *models.HTTPError) {
+	req := &pb.ListURLsRequest{
+		Cursor:   cursor,
+		PageSize: int32(limit),
+		Owner:    filter.Owner,
+		Domain:   filter.Domain,
+		Enabled:  filter.Enabled,
+		Search:   filter.Search,
+		Tags:     filter.Tags,
+	}
+	if !filter.CreatedFrom.IsZero() {
+		req.CreatedFrom = filter.CreatedFrom.Unix()
+	}
+	if !filter.CreatedTo.IsZero() {
+		req.CreatedTo = filter.CreatedTo.Unix()
+	}
+
+	resp, err := s.client.ListURLs(ctx, req)
+	if httpErr := mapGRPCError(err); httpErr != nil {
+		return nil, "", httpErr
+	}
+
+	links := make([]models.Link, len(resp.Links))
+	for i, link := range resp.Links {
+		links[i] = models.Link{
+			ShortURL:          shorturl.Build(s.publicHost, link.Domain, link.Code),
+			Code:              link.Code,
+			Domain:            link.Domain,
+			OriginalURL:       link.OriginalUrl,
+			Owner:             link.Owner,
+			CreatedAt:         unixTime(link.CreatedAt),
+			NotBefore:         unixTime(link.NotBefore),
+			ExpiresAt:         unixTime(link.ExpiresAt),
+			PasswordProtected: link.PasswordProtected,
+			Enabled:           link.Enabled,
+			Title:             link.Title,
+			Tags:              link.Tags,
 		}
 	}
 
-	return resp.Url, nil
+	return links, resp.NextCursor, nil
+}
+
+// ListAuditEvents returns a page of the audit events matching the filter and the cursor of the next page.
+// The shortener lists them only to admins, ctx must carry the admin token.
+func (s *Service) ListAuditEvents(ctx context.Context, filter models.AuditFilter, cursor string, limit int) ([]models.AuditEvent, string, *models.HTTPError) {
+	resp, err := s.client.ListAuditEvents(ctx, &pb.ListAuditEventsRequest{
+		Cursor:   cursor,
+		PageSize: int32(limit),
+		Code:     filter.Code,
+		Domain:   filter.Domain,
+		Actor:    filter.Actor,
+		Action:   filter.Action,
+	})
+	if httpErr := mapGRPCError(err); httpErr != nil {
+		return nil, "", httpErr
+	}
+
+	events := make([]models.AuditEvent, len(resp.Events))
+	for i, event := range resp.Events {
+		events[i] = models.AuditEvent{
+			ID:        event.Id,
+			Code:      event.Code,
+			Domain:    event.Domain,
+			Action:    event.Action,
+			Actor:     event.Actor,
+			RequestID: event.RequestId,
+			OldValue:  event.OldValue,
+			NewValue:  event.NewValue,
+			CreatedAt: time.UnixMicro(event.CreatedAt).UTC(),
+		}
+	}
+
+	return events, resp.NextCursor, nil
+}
+
+// CreateAPIKey issues the key of the principal, the secret key is returned only here
+func (s *Service) CreateAPIKey(ctx context.Context, principal, name string, scopes []string) (*models.APIKey, string, *models.HTTPError) {
+	resp, err := s.client.CreateAPIKey(ctx, &pb.CreateAPIKeyRequest{Principal: principal, Name: name, Scopes: scopes})
+	if httpErr := mapGRPCError(err); httpErr != nil {
+		return nil, "", httpErr
+	}
+
+	return apiKeyFromProto(resp.ApiKey), resp.Key, nil
+}
+
+// ListAPIKeys returns every API key, newest first
+func (s *Service) ListAPIKeys(ctx context.Context) ([]models.APIKey, *models.HTTPError) {
+	resp, err := s.client.ListAPIKeys(ctx, &pb.ListAPIKeysRequest{})
+	if httpErr := mapGRPCError(err); httpErr != nil {
+		return nil, httpErr
+	}
+
+	keys := make([]models.APIKey, len(resp.ApiKeys))
+	for i, key := range resp.ApiKeys {
+		keys[i] = *apiKeyFromProto(key)
+	}
+
+	return keys, nil
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, *models.HTTPError) {
+	resp, err := s.client.RevokeAPIKey(ctx, &pb.RevokeAPIKeyRequest{Id: id})
+	if httpErr := mapGRPCError(err); httpErr != nil {
+		return nil, httpErr
+	}
+
+	return apiKeyFromProto(resp), nil
+}
+
+// AuthenticateAPIKey returns the active key by its secret, unknown and revoked keys are 401
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, *models.HTTPError) {
+	resp, err := s.client.AuthenticateAPIKey(ctx, &pb.AuthenticateAPIKeyRequest{Key: key})
+	if httpErr := mapGRPCError(err); httpErr != nil {
+		return nil, httpErr
+	}
+
+	return apiKeyFromProto(resp), nil
+}
+
+func apiKeyFromProto(key *pb.APIKey) *models.APIKey {
+	return &models.APIKey{
+		ID:        key.Id,
+		Prefix:    key.Prefix,
+		Principal: key.Principal,
+		Name:      key.Name,
+		Scopes:    key.Scopes,
+		CreatedAt: unixTime(key.CreatedAt),
+		RevokedAt: unixTime(key.RevokedAt),
+	}
+}
+
+// unixTime returns the time of Unix seconds, zero time for 0
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
 }
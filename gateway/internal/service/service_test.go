@@ -4,13 +4,27 @@ import (
 	"context"
 	"errors"
 	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"github.com/misshanya/url-shortener/gateway/internal/redirectcache"
 	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"github.com/misshanya/url-shortener/grpcclient"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func Test_mapGRPCError(t *testing.T) {
@@ -28,40 +42,135 @@ func Test_mapGRPCError(t *testing.T) {
 			Name:     "Internal",
 			InputErr: status.New(codes.Internal, "Internal").Err(),
 			ExceptedErr: &models.HTTPError{
-				Code:    http.StatusInternalServerError,
-				Message: "Internal",
+				Code:      http.StatusInternalServerError,
+				Message:   "Internal",
+				ErrorCode: "internal",
 			},
 		},
 		{
 			Name:     "Not Found",
 			InputErr: status.New(codes.NotFound, "Not Found").Err(),
 			ExceptedErr: &models.HTTPError{
-				Code:    http.StatusNotFound,
-				Message: "Not Found",
+				Code:      http.StatusNotFound,
+				Message:   "Not Found",
+				ErrorCode: "not_found",
 			},
 		},
 		{
 			Name:     "Invalid Argument",
 			InputErr: status.New(codes.InvalidArgument, "Invalid Argument").Err(),
 			ExceptedErr: &models.HTTPError{
-				Code:    http.StatusBadRequest,
-				Message: "Invalid Argument",
+				Code:      http.StatusBadRequest,
+				Message:   "Invalid Argument",
+				ErrorCode: "invalid_argument",
+			},
+		},
+		{
+			Name:     "Already Exists",
+			InputErr: status.New(codes.AlreadyExists, "alias is taken").Err(),
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusConflict,
+				Message:   "alias is taken",
+				ErrorCode: "already_exists",
+			},
+		},
+		{
+			Name:     "Already Exists with error info",
+			InputErr: withDetails(status.New(codes.AlreadyExists, "alias is taken"), &errdetails.ErrorInfo{Reason: "ALIAS_TAKEN", Domain: "shortener"}),
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusConflict,
+				Message:   "alias is taken",
+				ErrorCode: "alias_taken",
+			},
+		},
+		{
+			Name: "Invalid Argument with field violations",
+			InputErr: withDetails(status.New(codes.InvalidArgument, "bad request"), &errdetails.BadRequest{
+				FieldViolations: []*errdetails.BadRequest_FieldViolation{
+					{Field: "alias", Description: "bad alias"},
+					{Field: "rules[0].platform", Description: "unknown platform"},
+				},
+			}),
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusBadRequest,
+				Message:   "bad request",
+				ErrorCode: "invalid_argument",
+				Violations: []models.Violation{
+					{Field: "alias", Message: "bad alias"},
+					{Field: "rules[0].platform", Message: "unknown platform"},
+				},
+			},
+		},
+		{
+			Name: "Invalid Argument with error info and field violation",
+			InputErr: withDetails(status.New(codes.InvalidArgument, "bad tag"),
+				&errdetails.ErrorInfo{Reason: "INVALID_ARGUMENT", Domain: "shortener", Metadata: map[string]string{"field": "tags"}},
+				&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "tags", Description: "bad tag"}}},
+			),
+			ExceptedErr: &models.HTTPError{
+				Code:       http.StatusBadRequest,
+				Message:    "bad tag",
+				ErrorCode:  "invalid_argument",
+				Violations: []models.Violation{{Field: "tags", Message: "bad tag"}},
+			},
+		},
+		{
+			Name: "Resource Exhausted with retry info",
+			InputErr: withDetails(status.New(codes.ResourceExhausted, "too many links"),
+				&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{{Subject: "telegram:123", Description: "100 links per day"}}},
+				&errdetails.RetryInfo{RetryDelay: durationpb.New(30 * time.Second)},
+			),
+			ExceptedErr: &models.HTTPError{
+				Code:       http.StatusTooManyRequests,
+				Message:    "too many links",
+				ErrorCode:  "resource_exhausted",
+				Violations: []models.Violation{{Field: "telegram:123", Message: "100 links per day"}},
+				RetryAfter: 30 * time.Second,
+			},
+		},
+		{
+			Name:     "Unauthenticated",
+			InputErr: status.New(codes.Unauthenticated, "missing API key").Err(),
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusUnauthorized,
+				Message:   "missing API key",
+				ErrorCode: "unauthenticated",
+			},
+		},
+		{
+			Name:     "Permission Denied",
+			InputErr: status.New(codes.PermissionDenied, "not an owner").Err(),
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusForbidden,
+				Message:   "not an owner",
+				ErrorCode: "permission_denied",
+			},
+		},
+		{
+			Name:     "Unavailable hides the message",
+			InputErr: status.New(codes.Unavailable, "connection refused").Err(),
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusServiceUnavailable,
+				Message:   "Service Unavailable",
+				ErrorCode: "unavailable",
 			},
 		},
 		{
 			Name:     "Non-gRPC error",
 			InputErr: errors.New("some unmappable error"),
 			ExceptedErr: &models.HTTPError{
-				Code:    http.StatusInternalServerError,
-				Message: "Internal Server Error",
+				Code:      http.StatusInternalServerError,
+				Message:   "Internal Server Error",
+				ErrorCode: "internal",
 			},
 		},
 		{
 			Name:     "Default case (unknown)",
 			InputErr: status.New(codes.Unknown, "Unknown Error").Err(),
 			ExceptedErr: &models.HTTPError{
-				Code:    http.StatusInternalServerError,
-				Message: "Internal Server Error",
+				Code:      http.StatusInternalServerError,
+				Message:   "Internal Server Error",
+				ErrorCode: "unknown",
 			},
 		},
 	}
@@ -74,11 +183,21 @@ func Test_mapGRPCError(t *testing.T) {
 	}
 }
 
+// withDetails attaches the details to the status
+func withDetails(s *status.Status, details ...protoadapt.MessageV1) error {
+	s, err := s.WithDetails(details...)
+	if err != nil {
+		panic(err)
+	}
+	return s.Err()
+}
+
 func Test_ShortenURL(t *testing.T) {
 	tests := []struct {
 		Name           string
 		PublicHost     string
 		InputURL       string
+		InputOptions   models.LinkOptions
 		ExceptedResult string
 		ExceptedErr    *models.HTTPError
 		SetUpMocks     func(client *mockgrpcClient)
@@ -100,14 +219,95 @@ func Test_ShortenURL(t *testing.T) {
 					).Once()
 			},
 		},
+		{
+			Name:       "Successfully Shortened with activation window",
+			PublicHost: "https://sh.some/",
+			InputURL:   "https://go.dev",
+			InputOptions: models.LinkOptions{
+				NotBefore: time.Unix(1_700_000_000, 0),
+				ExpiresAt: time.Unix(1_800_000_000, 0),
+			},
+			ExceptedResult: "https://sh.some/3b",
+			ExceptedErr:    nil,
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("ShortenURL", mock.Anything, &pb.ShortenURLRequest{
+					Url:       "https://go.dev",
+					NotBefore: 1_700_000_000,
+					ExpiresAt: 1_800_000_000,
+				}).
+					Return(&pb.ShortenURLResponse{Code: "3b", OriginalUrl: "https://go.dev"}, nil).Once()
+			},
+		},
+		{
+			Name:       "Successfully Shortened with metadata",
+			PublicHost: "https://sh.some/",
+			InputURL:   "https://go.dev",
+			InputOptions: models.LinkOptions{
+				Title: "Go",
+				Notes: "Docs of the Go",
+				Tags:  []string{"docs", "go"},
+			},
+			ExceptedResult: "https://sh.some/3m",
+			ExceptedErr:    nil,
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("ShortenURL", mock.Anything, &pb.ShortenURLRequest{
+					Url:   "https://go.dev",
+					Title: "Go",
+					Notes: "Docs of the Go",
+					Tags:  []string{"docs", "go"},
+				}).
+					Return(&pb.ShortenURLResponse{Code: "3m", OriginalUrl: "https://go.dev"}, nil).Once()
+			},
+		},
+		{
+			Name:           "Successfully Shortened on public host domain",
+			PublicHost:     "https://sh.some/",
+			InputURL:       "https://go.dev",
+			InputOptions:   models.LinkOptions{Domain: "sh.some"},
+			ExceptedResult: "https://sh.some/3c",
+			ExceptedErr:    nil,
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("ShortenURL", mock.Anything, &pb.ShortenURLRequest{Url: "https://go.dev", Domain: "sh.some"}).
+					Return(&pb.ShortenURLResponse{Code: "3c", OriginalUrl: "https://go.dev", Domain: "sh.some"}, nil).Once()
+			},
+		},
+		{
+			Name:           "Successfully Shortened with alias on another domain",
+			PublicHost:     "https://sh.some/",
+			InputURL:       "https://go.dev",
+			InputOptions:   models.LinkOptions{Domain: "go.some", Alias: "my-promo"},
+			ExceptedResult: "https://go.some/my-promo",
+			ExceptedErr:    nil,
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("ShortenURL", mock.Anything, &pb.ShortenURLRequest{Url: "https://go.dev", Domain: "go.some", Alias: "my-promo"}).
+					Return(&pb.ShortenURLResponse{Code: "my-promo", OriginalUrl: "https://go.dev", Domain: "go.some"}, nil).Once()
+			},
+		},
+		{
+			Name:           "Alias is taken",
+			PublicHost:     "https://sh.some/",
+			InputURL:       "https://go.dev",
+			InputOptions:   models.LinkOptions{Alias: "my-promo"},
+			ExceptedResult: "",
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusConflict,
+				Message:   "alias is taken",
+				ErrorCode: "already_exists",
+			},
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("ShortenURL", mock.Anything, &pb.ShortenURLRequest{Url: "https://go.dev", Alias: "my-promo"}).
+					Return(nil, status.Error(codes.AlreadyExists, "alias is taken")).Once()
+			},
+		},
 		{
 			Name:           "gRPC server answered with internal error",
 			PublicHost:     "https://sh.some/",
 			InputURL:       "https://go.dev",
 			ExceptedResult: "",
 			ExceptedErr: &models.HTTPError{
-				Code:    http.StatusInternalServerError,
-				Message: "Internal Server Error",
+				Code:      http.StatusInternalServerError,
+				Message:   "Internal Server Error",
+				ErrorCode: "internal",
 			},
 			SetUpMocks: func(client *mockgrpcClient) {
 				client.On("ShortenURL", mock.Anything, &pb.ShortenURLRequest{Url: "https://go.dev"}).
@@ -127,7 +327,7 @@ func Test_ShortenURL(t *testing.T) {
 
 			service := NewService(&mockClient, tt.PublicHost)
 
-			result, err := service.ShortenURL(context.Background(), tt.InputURL)
+			result, err := service.ShortenURL(context.Background(), tt.InputURL, tt.InputOptions)
 			assert.Equal(t, tt.ExceptedErr, err)
 			assert.Equal(t, tt.ExceptedResult, result)
 
@@ -196,8 +396,9 @@ func Test_ShortenURLBatch(t *testing.T) {
 			Name:      "gRPC server returned slice not the same length",
 			InputURLs: make([]inputModel, 5),
 			ExceptedErr: &models.HTTPError{
-				Code:    http.StatusInternalServerError,
-				Message: "Internal Server Error",
+				Code:      http.StatusInternalServerError,
+				Message:   "Internal Server Error",
+				ErrorCode: "internal",
 			},
 			SetUpMocks: func(client *mockgrpcClient, inputs []inputModel) {
 				client.On("ShortenURLBatch", mock.Anything, mock.Anything).
@@ -213,8 +414,9 @@ func Test_ShortenURLBatch(t *testing.T) {
 			Name:      "gRPC server answered with error",
 			InputURLs: make([]inputModel, 2),
 			ExceptedErr: &models.HTTPError{
-				Code:    http.StatusInternalServerError,
-				Message: "Something went wrong",
+				Code:      http.StatusInternalServerError,
+				Message:   "Something went wrong",
+				ErrorCode: "internal",
 			},
 			SetUpMocks: func(client *mockgrpcClient, inputs []inputModel) {
 				client.On("ShortenURLBatch", mock.Anything, mock.Anything).
@@ -260,26 +462,93 @@ func Test_UnshortenURL(t *testing.T) {
 	tests := []struct {
 		Name           string
 		InputCode      string
-		ExceptedResult string
+		InputVisit     models.Visit
+		ExceptedResult *models.Redirect
 		ExceptedErr    *models.HTTPError
 		SetUpMocks     func(client *mockgrpcClient)
 	}{
 		{
 			Name:           "Successfully Unshortened",
 			InputCode:      "3a",
-			ExceptedResult: "https://go.dev",
+			ExceptedResult: &models.Redirect{URL: "https://go.dev"},
 			ExceptedErr:    nil,
 			SetUpMocks: func(client *mockgrpcClient) {
 				client.On("GetURL", mock.Anything, &pb.GetURLRequest{Code: "3a"}).
 					Return(&pb.GetURLResponse{Url: "https://go.dev"}, nil).Once()
 			},
+		}, {
+			Name:      "Successfully Unshortened with visitor attributes",
+			InputCode: "3a",
+			InputVisit: models.Visit{
+				Query:     "ref=newsletter",
+				Referer:   "https://news.some/post",
+				UserAgent: "Mozilla/5.0 (iPhone)",
+				Platform:  "ios",
+				Country:   "US",
+				VisitorID: "f00d",
+				IP:        "203.0.113.7",
+			},
+			ExceptedResult: &models.Redirect{URL: "https://go.dev?ref=newsletter"},
+			ExceptedErr:    nil,
+			SetUpMocks: func(client *mockgrpcClient) {
+				hasMetadata := mock.MatchedBy(func(ctx context.Context) bool {
+					md, _ := metadata.FromOutgoingContext(ctx)
+					return assert.ObjectsAreEqual([]string{"Mozilla/5.0 (iPhone)"}, md.Get("x-client-user-agent")) &&
+						assert.ObjectsAreEqual([]string{"ios"}, md.Get("x-client-platform")) &&
+						assert.ObjectsAreEqual([]string{"US"}, md.Get("x-client-country")) &&
+						assert.ObjectsAreEqual([]string{"f00d"}, md.Get("x-client-visitor-id")) &&
+						assert.ObjectsAreEqual([]string{"https://news.some/post"}, md.Get("x-client-referer")) &&
+						assert.ObjectsAreEqual([]string{"203.0.113.7"}, md.Get("x-client-ip")) &&
+						len(md.Get("x-client-accept-language")) == 0
+				})
+				client.On("GetURL", hasMetadata, &pb.GetURLRequest{Code: "3a", Query: "ref=newsletter"}).
+					Return(&pb.GetURLResponse{Url: "https://go.dev?ref=newsletter"}, nil).Once()
+			},
+		}, {
+			Name:           "Successfully Unshortened on domain",
+			InputCode:      "my-promo",
+			InputVisit:     models.Visit{Host: "go.some"},
+			ExceptedResult: &models.Redirect{URL: "https://go.dev"},
+			ExceptedErr:    nil,
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("GetURL", mock.Anything, &pb.GetURLRequest{Code: "my-promo", Domain: "go.some"}).
+					Return(&pb.GetURLResponse{Url: "https://go.dev"}, nil).Once()
+			},
+		}, {
+			Name:           "Successfully Unshortened interstitial link",
+			InputCode:      "3i",
+			ExceptedResult: &models.Redirect{URL: "https://unknown.example", Interstitial: true},
+			ExceptedErr:    nil,
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("GetURL", mock.Anything, &pb.GetURLRequest{Code: "3i"}).
+					Return(&pb.GetURLResponse{Url: "https://unknown.example", Interstitial: true}, nil).Once()
+			},
+		}, {
+			Name:           "Protected link requires password",
+			InputCode:      "3a",
+			InputVisit:     models.Visit{LinkToken: "expired"},
+			ExceptedResult: nil,
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusForbidden,
+				Message:   "password required",
+				ErrorCode: "permission_denied",
+			},
+			SetUpMocks: func(client *mockgrpcClient) {
+				hasToken := mock.MatchedBy(func(ctx context.Context) bool {
+					md, _ := metadata.FromOutgoingContext(ctx)
+					return assert.ObjectsAreEqual([]string{"expired"}, md.Get("x-link-token"))
+				})
+				client.On("GetURL", hasToken, &pb.GetURLRequest{Code: "3a"}).
+					Return(nil, status.Error(codes.PermissionDenied, "password required")).Once()
+			},
 		}, {
 			Name:           "gRPC server answered with internal error",
 			InputCode:      "3a",
-			ExceptedResult: "",
+			ExceptedResult: nil,
 			ExceptedErr: &models.HTTPError{
-				Code:    http.StatusInternalServerError,
-				Message: "Internal Server Error",
+				Code:      http.StatusInternalServerError,
+				Message:   "Internal Server Error",
+				ErrorCode: "internal",
 			},
 			SetUpMocks: func(client *mockgrpcClient) {
 				client.On("GetURL", mock.Anything, &pb.GetURLRequest{Code: "3a"}).
@@ -302,7 +571,7 @@ func Test_UnshortenURL(t *testing.T) {
 
 			service := NewService(&mockClient, "")
 
-			result, err := service.UnshortenURL(context.Background(), tt.InputCode)
+			result, err := service.UnshortenURL(context.Background(), tt.InputCode, tt.InputVisit)
 			assert.Equal(t, tt.ExceptedErr, err)
 			assert.Equal(t, tt.ExceptedResult, result)
 
@@ -310,3 +579,492 @@ func Test_UnshortenURL(t *testing.T) {
 		})
 	}
 }
+
+func Test_UnshortenURL_Cache(t *testing.T) {
+	mockClient := mockgrpcClient{}
+	mockClient.On("GetURL", mock.Anything, &pb.GetURLRequest{Code: "3a", Domain: "sh.some"}).
+		Return(&pb.GetURLResponse{Url: "https://go.dev", Cacheable: true}, nil).Once()
+	mockClient.On("GetURL", mock.Anything, &pb.GetURLRequest{Code: "ab", Domain: "sh.some"}).
+		Return(&pb.GetURLResponse{Url: "https://go.dev/ab"}, nil).Twice()
+
+	visit := models.Visit{Host: "sh.some", UserAgent: "Mozilla/5.0 (iPhone)", IP: "203.0.113.7"}
+	mockVisits := mockvisitRecorder{}
+	mockVisits.On("Record", "3a", visit, mock.AnythingOfType("time.Time")).
+		Return().Twice()
+
+	service := NewService(&mockClient, "").WithRedirectCache(redirectcache.New(10, time.Minute), &mockVisits)
+
+	// The first visit is resolved by the shortener, the next ones are served from the cache and recorded
+	for range 3 {
+		result, err := service.UnshortenURL(context.Background(), "3a", visit)
+		assert.Nil(t, err)
+		assert.Equal(t, &models.Redirect{URL: "https://go.dev"}, result)
+	}
+
+	// Not cacheable redirects always go to the shortener
+	for range 2 {
+		result, err := service.UnshortenURL(context.Background(), "ab", visit)
+		assert.Nil(t, err)
+		assert.Equal(t, &models.Redirect{URL: "https://go.dev/ab"}, result)
+	}
+
+	mockClient.AssertExpectations(t)
+	mockVisits.AssertExpectations(t)
+}
+
+// slowShortener answers GetURL after the delay, counting the attempts and the visits recorded by either RPC
+type slowShortener struct {
+	pb.UnimplementedURLShortenerServiceServer
+	delay    time.Duration
+	attempts atomic.Int32
+	visits   atomic.Int32
+	recorded chan *pb.Visit
+}
+
+func (s *slowShortener) GetURL(ctx context.Context, req *pb.GetURLRequest) (*pb.GetURLResponse, error) {
+	s.attempts.Add(1)
+	if !req.SkipVisit {
+		s.visits.Add(1)
+	}
+	time.Sleep(s.delay)
+	return &pb.GetURLResponse{Url: "https://go.dev/de", Rule: "germany", Variant: "b"}, nil
+}
+
+func (s *slowShortener) RecordVisits(ctx context.Context, req *pb.RecordVisitsRequest) (*pb.RecordVisitsResponse, error) {
+	s.visits.Add(int32(len(req.Visits)))
+	for _, visit := range req.Visits {
+		s.recorded <- visit
+	}
+	return &pb.RecordVisitsResponse{Recorded: int32(len(req.Visits))}, nil
+}
+
+func Test_UnshortenURL_Hedged(t *testing.T) {
+	shortener := &slowShortener{delay: 100 * time.Millisecond, recorded: make(chan *pb.Visit, 10)}
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterURLShortenerServiceServer(server, shortener)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	opts := append(grpcclient.DialOptions(grpcclient.Options{MaxAttempts: 2, HedgingDelay: 20 * time.Millisecond, HedgeGetURL: true}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+	)
+	conn, err := grpc.NewClient("passthrough:///shortener", opts...)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	client := pb.NewURLShortenerServiceClient(conn)
+
+	recorder := NewVisitRecorder(client, slog.New(slog.NewTextHandler(os.Stdout, nil)), "admin", 10, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		recorder.Run(ctx)
+		close(done)
+	}()
+
+	service := NewService(client, "").WithHedgedRedirects(recorder)
+	result, httpErr := service.UnshortenURL(context.Background(), "3a", models.Visit{Host: "sh.some", Country: "DE"})
+	assert.Nil(t, httpErr)
+	assert.Equal(t, &models.Redirect{URL: "https://go.dev/de"}, result)
+
+	// The visits left are sent when the recorder stops
+	cancel()
+	<-done
+
+	// Both hedged attempts reached the shortener, the visit is recorded once with the rule and the variant it got
+	assert.Equal(t, int32(2), shortener.attempts.Load())
+	assert.Equal(t, int32(1), shortener.visits.Load())
+	visit := <-shortener.recorded
+	assert.Equal(t, "3a", visit.Code)
+	assert.Equal(t, "sh.some", visit.Domain)
+	assert.Equal(t, "germany", visit.Rule)
+	assert.Equal(t, "b", visit.Variant)
+}
+
+func Test_PreviewURL(t *testing.T) {
+	tests := []struct {
+		Name           string
+		InputCode      string
+		ExceptedResult *models.LinkPreview
+		ExceptedErr    *models.HTTPError
+		SetUpMocks     func(client *mockgrpcClient)
+	}{
+		{
+			Name:      "Successfully Previewed",
+			InputCode: "3a",
+			ExceptedResult: &models.LinkPreview{
+				ShortURL:    "https://sh.some/3a",
+				Code:        "3a",
+				Domain:      "sh.some",
+				OriginalURL: "https://go.dev",
+				CreatedAt:   time.Unix(1_700_000_000, 0).UTC(),
+			},
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("PreviewURL", mock.Anything, &pb.PreviewURLRequest{Code: "3a", Domain: "sh.some"}).
+					Return(&pb.LinkPreview{Code: "3a", Domain: "sh.some", Url: "https://go.dev", CreatedAt: 1_700_000_000}, nil).Once()
+			},
+		},
+		{
+			Name:      "Protected link hides destination",
+			InputCode: "3p",
+			ExceptedResult: &models.LinkPreview{
+				ShortURL:          "https://sh.some/3p",
+				Code:              "3p",
+				Domain:            "sh.some",
+				CreatedAt:         time.Unix(1_700_000_000, 0).UTC(),
+				PasswordProtected: true,
+			},
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("PreviewURL", mock.Anything, &pb.PreviewURLRequest{Code: "3p", Domain: "sh.some"}).
+					Return(&pb.LinkPreview{Code: "3p", Domain: "sh.some", CreatedAt: 1_700_000_000, PasswordProtected: true}, nil).Once()
+			},
+		},
+		{
+			Name:      "Link not found",
+			InputCode: "zz",
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusNotFound,
+				Message:   "not found",
+				ErrorCode: "not_found",
+			},
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("PreviewURL", mock.Anything, &pb.PreviewURLRequest{Code: "zz", Domain: "sh.some"}).
+					Return(nil, status.Error(codes.NotFound, "not found")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockClient := mockgrpcClient{}
+
+			tt.SetUpMocks(&mockClient)
+
+			service := NewService(&mockClient, "https://sh.some/")
+
+			result, err := service.PreviewURL(context.Background(), "sh.some", tt.InputCode)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedResult, result)
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_VerifyLinkPassword(t *testing.T) {
+	tests := []struct {
+		Name           string
+		InputCode      string
+		InputPassword  string
+		ExceptedToken  string
+		ExceptedExpiry time.Time
+		ExceptedErr    *models.HTTPError
+		SetUpMocks     func(client *mockgrpcClient)
+	}{
+		{
+			Name:           "Successfully Verified",
+			InputCode:      "3a",
+			InputPassword:  "s3cret",
+			ExceptedToken:  "token",
+			ExceptedExpiry: time.Unix(1_700_000_900, 0),
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("VerifyLinkPassword", mock.Anything, &pb.VerifyLinkPasswordRequest{Code: "3a", Password: "s3cret", Domain: "sh.some"}).
+					Return(&pb.VerifyLinkPasswordResponse{Token: "token", ExpiresAt: 1_700_000_900}, nil).Once()
+			},
+		},
+		{
+			Name:          "Wrong password",
+			InputCode:     "3a",
+			InputPassword: "qwerty",
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusForbidden,
+				Message:   "wrong password",
+				ErrorCode: "permission_denied",
+			},
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("VerifyLinkPassword", mock.Anything, &pb.VerifyLinkPasswordRequest{Code: "3a", Password: "qwerty", Domain: "sh.some"}).
+					Return(nil, status.Error(codes.PermissionDenied, "wrong password")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockClient := mockgrpcClient{}
+
+			tt.SetUpMocks(&mockClient)
+
+			service := NewService(&mockClient, "")
+
+			token, expiresAt, err := service.VerifyLinkPassword(context.Background(), "sh.some", tt.InputCode, tt.InputPassword)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedToken, token)
+			if tt.ExceptedErr == nil {
+				assert.True(t, tt.ExceptedExpiry.Equal(expiresAt))
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_ListLinks(t *testing.T) {
+	tests := []struct {
+		Name           string
+		InputFilter    models.LinkFilter
+		InputCursor    string
+		InputLimit     int
+		ExceptedLinks  []models.Link
+		ExceptedCursor string
+		ExceptedErr    *models.HTTPError
+		SetUpMocks     func(client *mockgrpcClient)
+	}{
+		{
+			Name: "Successfully Listed",
+			InputFilter: models.LinkFilter{
+				Owner:       "telegram:1",
+				CreatedFrom: time.Unix(1_700_000_000, 0),
+				Search:      "go.dev",
+				Tags:        []string{"go"},
+			},
+			InputCursor: "NjI",
+			InputLimit:  10,
+			ExceptedLinks: []models.Link{
+				{
+					ShortURL:    "https://sh.some/3a",
+					Code:        "3a",
+					Domain:      "sh.some",
+					OriginalURL: "https://go.dev",
+					Owner:       "telegram:1",
+					CreatedAt:   time.Unix(1_700_000_900, 0).UTC(),
+					Enabled:     true,
+				},
+				{
+					ShortURL:          "https://go.some/docs",
+					Code:              "docs",
+					Domain:            "go.some",
+					OriginalURL:       "https://go.dev/doc",
+					Owner:             "telegram:1",
+					CreatedAt:         time.Unix(1_700_000_100, 0).UTC(),
+					ExpiresAt:         time.Unix(1_700_000_200, 0).UTC(),
+					PasswordProtected: true,
+				},
+			},
+			ExceptedCursor: "NQ",
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("ListURLs", mock.Anything, &pb.ListURLsRequest{
+					Cursor:      "NjI",
+					PageSize:    10,
+					Owner:       "telegram:1",
+					CreatedFrom: 1_700_000_000,
+					Search:      "go.dev",
+					Tags:        []string{"go"},
+				}).
+					Return(&pb.ListURLsResponse{
+						Links: []*pb.Link{
+							{Code: "3a", Domain: "sh.some", OriginalUrl: "https://go.dev", Owner: "telegram:1", CreatedAt: 1_700_000_900, Enabled: true},
+							{Code: "docs", Domain: "go.some", OriginalUrl: "https://go.dev/doc", Owner: "telegram:1", CreatedAt: 1_700_000_100, ExpiresAt: 1_700_000_200, PasswordProtected: true},
+						},
+						NextCursor: "NQ",
+					}, nil).Once()
+			},
+		},
+		{
+			Name:        "Unknown domain",
+			InputFilter: models.LinkFilter{Domain: "unknown.some"},
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusBadRequest,
+				Message:   "unknown domain",
+				ErrorCode: "invalid_argument",
+			},
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("ListURLs", mock.Anything, &pb.ListURLsRequest{Domain: "unknown.some"}).
+					Return(nil, status.Error(codes.InvalidArgument, "unknown domain")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockClient := mockgrpcClient{}
+
+			tt.SetUpMocks(&mockClient)
+
+			service := NewService(&mockClient, "https://sh.some/")
+
+			links, cursor, err := service.ListLinks(context.Background(), tt.InputFilter, tt.InputCursor, tt.InputLimit)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedLinks, links)
+			assert.Equal(t, tt.ExceptedCursor, cursor)
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_ListAuditEvents(t *testing.T) {
+	tests := []struct {
+		Name           string
+		InputFilter    models.AuditFilter
+		InputCursor    string
+		InputLimit     int
+		ExceptedEvents []models.AuditEvent
+		ExceptedCursor string
+		ExceptedErr    *models.HTTPError
+		SetUpMocks     func(client *mockgrpcClient)
+	}{
+		{
+			Name:        "Successfully Listed",
+			InputFilter: models.AuditFilter{Code: "docs", Domain: "go.some", Actor: "telegram:1", Action: "retarget"},
+			InputCursor: "MTIx",
+			InputLimit:  10,
+			ExceptedEvents: []models.AuditEvent{
+				{
+					ID:        7,
+					Code:      "docs",
+					Domain:    "go.some",
+					Action:    "retarget",
+					Actor:     "telegram:1",
+					RequestID: "req-1",
+					OldValue:  `{"url":"https://go.dev"}`,
+					NewValue:  `{"url":"https://pkg.go.dev"}`,
+					CreatedAt: time.UnixMicro(1_700_000_000_000_001).UTC(),
+				},
+			},
+			ExceptedCursor: "Nw",
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("ListAuditEvents", mock.Anything, &pb.ListAuditEventsRequest{
+					Cursor:   "MTIx",
+					PageSize: 10,
+					Code:     "docs",
+					Domain:   "go.some",
+					Actor:    "telegram:1",
+					Action:   "retarget",
+				}).
+					Return(&pb.ListAuditEventsResponse{
+						Events: []*pb.AuditEvent{
+							{
+								Id: 7, Code: "docs", Domain: "go.some", Action: "retarget", Actor: "telegram:1", RequestId: "req-1",
+								OldValue: `{"url":"https://go.dev"}`, NewValue: `{"url":"https://pkg.go.dev"}`, CreatedAt: 1_700_000_000_000_001,
+							},
+						},
+						NextCursor: "Nw",
+					}, nil).Once()
+			},
+		},
+		{
+			Name: "Admin token is rejected",
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusUnauthorized,
+				Message:   "admin token required",
+				ErrorCode: "unauthenticated",
+			},
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("ListAuditEvents", mock.Anything, &pb.ListAuditEventsRequest{}).
+					Return(nil, status.Error(codes.Unauthenticated, "admin token required")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockClient := mockgrpcClient{}
+
+			tt.SetUpMocks(&mockClient)
+
+			service := NewService(&mockClient, "https://sh.some/")
+
+			events, cursor, err := service.ListAuditEvents(context.Background(), tt.InputFilter, tt.InputCursor, tt.InputLimit)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedEvents, events)
+			assert.Equal(t, tt.ExceptedCursor, cursor)
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_AuthenticateAPIKey(t *testing.T) {
+	tests := []struct {
+		Name           string
+		InputKey       string
+		ExceptedAPIKey *models.APIKey
+		ExceptedErr    *models.HTTPError
+		SetUpMocks     func(client *mockgrpcClient)
+	}{
+		{
+			Name:     "Active key",
+			InputKey: "shk_secret",
+			ExceptedAPIKey: &models.APIKey{
+				ID:        1,
+				Prefix:    "shk_secr",
+				Principal: "apikey:ci",
+				Scopes:    []string{"shorten"},
+				CreatedAt: time.Unix(1_700_000_000, 0).UTC(),
+			},
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("AuthenticateAPIKey", mock.Anything, &pb.AuthenticateAPIKeyRequest{Key: "shk_secret"}).
+					Return(&pb.APIKey{Id: 1, Prefix: "shk_secr", Principal: "apikey:ci", Scopes: []string{"shorten"}, CreatedAt: 1_700_000_000}, nil).Once()
+			},
+		},
+		{
+			Name:     "Revoked key",
+			InputKey: "shk_secret",
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusUnauthorized,
+				Message:   "api key is revoked",
+				ErrorCode: "unauthenticated",
+			},
+			SetUpMocks: func(client *mockgrpcClient) {
+				client.On("AuthenticateAPIKey", mock.Anything, &pb.AuthenticateAPIKeyRequest{Key: "shk_secret"}).
+					Return(nil, status.Error(codes.Unauthenticated, "api key is revoked")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockClient := mockgrpcClient{}
+
+			tt.SetUpMocks(&mockClient)
+
+			service := NewService(&mockClient, "https://sh.some/")
+
+			apiKey, err := service.AuthenticateAPIKey(context.Background(), tt.InputKey)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedAPIKey, apiKey)
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_CreateAPIKey(t *testing.T) {
+	mockClient := mockgrpcClient{}
+	mockClient.On("CreateAPIKey", mock.Anything, &pb.CreateAPIKeyRequest{Principal: "apikey:ci", Name: "CI", Scopes: []string{"shorten"}}).
+		Return(&pb.CreateAPIKeyResponse{
+			ApiKey: &pb.APIKey{Id: 3, Prefix: "shk_abcdefgh", Principal: "apikey:ci", Name: "CI", Scopes: []string{"shorten"}, CreatedAt: 1_700_000_000},
+			Key:    "shk_abcdefghijkl",
+		}, nil).Once()
+
+	service := NewService(&mockClient, "https://sh.some/")
+
+	apiKey, key, err := service.CreateAPIKey(context.Background(), "apikey:ci", "CI", []string{"shorten"})
+	assert.Nil(t, err)
+	assert.Equal(t, "shk_abcdefghijkl", key)
+	assert.Equal(t, &models.APIKey{
+		ID:        3,
+		Prefix:    "shk_abcdefgh",
+		Principal: "apikey:ci",
+		Name:      "CI",
+		Scopes:    []string{"shorten"},
+		CreatedAt: time.Unix(1_700_000_000, 0).UTC(),
+	}, apiKey)
+
+	mockClient.AssertExpectations(t)
+}
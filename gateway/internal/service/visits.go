@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"github.com/misshanya/url-shortener/gateway/internal/auth"
+	"github.com/misshanya/url-shortener/gateway/internal/models"
+	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"log/slog"
+	"time"
+)
+
+const (
+	// visitFlushTimeout limits sending of the visits left when the recorder stops
+	visitFlushTimeout = 5 * time.Second
+
+	// defaultVisitBatchSize and defaultVisitFlushInterval replace the batch size and the interval if they aren't positive
+	defaultVisitBatchSize     = 100
+	defaultVisitFlushInterval = time.Second
+)
+
+// VisitRecorder sends the visits served from the redirect cache or resolved without them to the shortener in batches,
+// so they are counted like the visits the shortener recorded itself
+type VisitRecorder struct {
+	client grpcClient
+	l      *slog.Logger
+
+	// adminToken authenticates the gateway to RecordVisits, the shortener takes visits from nobody else
+	adminToken string
+	batchSize  int
+	interval   time.Duration
+	visits     chan *pb.Visit
+}
+
+// NewVisitRecorder creates the recorder sending a batch with the admin token once it is full or the interval passes.
+// Up to ten batches are queued, the visits over that are dropped while the shortener is slow.
+func NewVisitRecorder(client grpcClient, l *slog.Logger, adminToken string, batchSize int, interval time.Duration) *VisitRecorder {
+	if batchSize <= 0 {
+		batchSize = defaultVisitBatchSize
+	}
+	if interval <= 0 {
+		interval = defaultVisitFlushInterval
+	}
+
+	return &VisitRecorder{
+		client:     client,
+		l:          l,
+		adminToken: adminToken,
+		batchSize:  batchSize,
+		interval:   interval,
+		visits:     make(chan *pb.Visit, 10*batchSize),
+	}
+}
+
+// Record queues the visit of the code, it never blocks the redirect
+func (r *VisitRecorder) Record(code string, visit models.Visit, at time.Time) {
+	select {
+	case r.visits <- &pb.Visit{
+		Code:           code,
+		Domain:         visit.Host,
+		VisitedAt:      at.Unix(),
+		Referer:        visit.Referer,
+		UserAgent:      visit.UserAgent,
+		AcceptLanguage: visit.AcceptLanguage,
+		Ip:             visit.IP,
+		Country:        visit.Country,
+		Rule:           visit.Rule,
+		Variant:        visit.Variant,
+	}:
+	default:
+		r.l.Warn("visit queue is full, dropping the visit", slog.String("code", code))
+	}
+}
+
+// Run sends the queued visits until ctx is done, then sends the rest
+func (r *VisitRecorder) Run(ctx context.Context) {
+	ctx = auth.WithAdminToken(ctx, r.adminToken)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	batch := make([]*pb.Visit, 0, r.batchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if _, err := r.client.RecordVisits(ctx, &pb.RecordVisitsRequest{Visits: batch}); err != nil {
+			r.l.Error("failed to record visits", slog.Int("visits", len(batch)), slog.String("err", err.Error()))
+		}
+		batch = make([]*pb.Visit, 0, r.batchSize)
+	}
+
+	for {
+		select {
+		case visit := <-r.visits:
+			batch = append(batch, visit)
+			if len(batch) >= r.batchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		case <-ctx.Done():
+			ctxFlush, cancel := context.WithTimeout(context.WithoutCancel(ctx), visitFlushTimeout)
+			defer cancel()
+			for {
+				select {
+				case visit := <-r.visits:
+					batch = append(batch, visit)
+					if len(batch) >= r.batchSize {
+						flush(ctxFlush)
+					}
+				default:
+					flush(ctxFlush)
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"github.com/misshanya/url-shortener/gateway/internal/auth"
+	"github.com/misshanya/url-shortener/gateway/internal/models"
+	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_VisitRecorder(t *testing.T) {
+	visitedAt := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	visit := models.Visit{
+		Host:           "go.some",
+		Query:          "ref=tg",
+		Referer:        "https://news.some/post",
+		UserAgent:      "Mozilla/5.0 (iPhone)",
+		AcceptLanguage: "en-US",
+		Country:        "DE",
+		IP:             "203.0.113.7",
+	}
+	excepted := &pb.Visit{
+		Code:           "docs",
+		Domain:         "go.some",
+		VisitedAt:      visitedAt.Unix(),
+		Referer:        "https://news.some/post",
+		UserAgent:      "Mozilla/5.0 (iPhone)",
+		AcceptLanguage: "en-US",
+		Ip:             "203.0.113.7",
+		Country:        "DE",
+	}
+
+	// The shortener takes the visits only with the admin token, the rest are sent with it too
+	withAdminToken := mock.MatchedBy(func(ctx context.Context) bool {
+		return assert.ObjectsAreEqual([]string{"Bearer admin"}, forwardedAuthorization(ctx))
+	})
+
+	mockClient := mockgrpcClient{}
+	// The full batch is sent at once, the rest when the recorder stops
+	mockClient.On("RecordVisits", withAdminToken, &pb.RecordVisitsRequest{Visits: []*pb.Visit{excepted, excepted}}).
+		Return(&pb.RecordVisitsResponse{Recorded: 2}, nil).Once()
+	mockClient.On("RecordVisits", withAdminToken, &pb.RecordVisitsRequest{Visits: []*pb.Visit{excepted}}).
+		Return(&pb.RecordVisitsResponse{Recorded: 1}, nil).Once()
+
+	recorder := NewVisitRecorder(&mockClient, slog.New(slog.NewTextHandler(os.Stdout, nil)), "admin", 2, time.Hour)
+	for range 3 {
+		recorder.Record("docs", visit, visitedAt)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		recorder.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return len(recorder.visits) == 0
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	mockClient.AssertExpectations(t)
+}
+
+func Test_VisitRecorder_Defaults(t *testing.T) {
+	visitedAt := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	mockClient := mockgrpcClient{}
+	mockClient.On("RecordVisits", mock.Anything, &pb.RecordVisitsRequest{Visits: []*pb.Visit{{Code: "docs", VisitedAt: visitedAt.Unix()}}}).
+		Return(&pb.RecordVisitsResponse{Recorded: 1}, nil).Once()
+
+	// Neither the zero batch size nor the zero interval may leave the queue unbuffered or panic in Run
+	recorder := NewVisitRecorder(&mockClient, slog.New(slog.NewTextHandler(os.Stdout, nil)), "admin", 0, 0)
+	assert.Equal(t, defaultVisitBatchSize, recorder.batchSize)
+	assert.Equal(t, defaultVisitFlushInterval, recorder.interval)
+	assert.Equal(t, 10*defaultVisitBatchSize, cap(recorder.visits))
+
+	recorder.Record("docs", models.Visit{}, visitedAt)
+	assert.Len(t, recorder.visits, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		recorder.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return len(recorder.visits) == 0
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	mockClient.AssertExpectations(t)
+}
+
+// forwardedAuthorization returns the authorization metadata the shortener gets with the call made with ctx
+func forwardedAuthorization(ctx context.Context) []string {
+	var forwarded []string
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		forwarded = md.Get("authorization")
+		return nil
+	}
+	auth.UnaryClientInterceptor()(ctx, "/v1.URLShortenerService/RecordVisits", nil, nil, nil, invoker)
+	return forwarded
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"github.com/misshanya/url-shortener/gateway/internal/transport/http/dto"
+	"net/http"
+	"strconv"
+)
+
+// CreateAPIKey issues the API key, the response is the only place the key is shown
+func (a *Auth) CreateAPIKey(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req dto.CreateAPIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		return bindError(err)
+	}
+
+	apiKey, key, httpErr := a.service.CreateAPIKey(ctx, req.Principal, req.Name, req.Scopes)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	resp := &dto.CreateAPIKeyResponse{APIKey: dto.APIKey(*apiKey), Key: key}
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// ListAPIKeys lists the API keys without their secrets, newest first
+func (a *Auth) ListAPIKeys(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	keys, httpErr := a.service.ListAPIKeys(ctx)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	resp := &dto.ListAPIKeysResponse{APIKeys: make([]dto.APIKey, len(keys))}
+	for i, key := range keys {
+		resp.APIKeys[i] = dto.APIKey(key)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// RevokeAPIKey revokes the API key, it stops working on this gateway right away
+func (a *Auth) RevokeAPIKey(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return &models.HTTPError{
+			Code:       http.StatusBadRequest,
+			Message:    "bad API key ID",
+			ErrorCode:  "invalid_argument",
+			Violations: []models.Violation{{Field: "id", Message: "must be a positive integer"}},
+		}
+	}
+
+	apiKey, httpErr := a.service.RevokeAPIKey(ctx, id)
+	if httpErr != nil {
+		return httpErr
+	}
+	a.forget(id)
+
+	return c.JSON(http.StatusOK, dto.APIKey(*apiKey))
+}
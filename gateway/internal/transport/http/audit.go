@@ -0,0 +1,63 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/auth"
+	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"github.com/misshanya/url-shortener/gateway/internal/transport/http/dto"
+	"net/http"
+)
+
+// ListAuditEvents lists who created, changed or deleted the links and when, newest first.
+// Admins read the whole log, the callers with an API key only the changes they made themselves.
+func (a *Auth) ListAuditEvents(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	filter := models.AuditFilter{
+		Code:   c.QueryParam("code"),
+		Domain: c.QueryParam("domain"),
+		Actor:  c.QueryParam("actor"),
+		Action: c.QueryParam("action"),
+	}
+	// The admin route carries the admin token instead of a caller
+	if caller, ok := auth.FromContext(ctx); ok {
+		if caller.IsAnonymous() {
+			return unauthorized(c, "an API key is required to list the audit events")
+		}
+		filter.Actor = caller.Principal
+	}
+	limit, err := limitParam(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	events, next, httpErr := a.service.ListAuditEvents(ctx, filter, c.QueryParam("cursor"), limit)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	resp := &dto.ListAuditEventsResponse{Events: make([]dto.AuditEvent, len(events)), NextCursor: next}
+	for i, event := range events {
+		resp.Events[i] = dto.AuditEvent{
+			ID:        event.ID,
+			Code:      event.Code,
+			Domain:    event.Domain,
+			Action:    event.Action,
+			Actor:     event.Actor,
+			RequestID: event.RequestID,
+			OldValue:  rawJSON(event.OldValue),
+			NewValue:  rawJSON(event.NewValue),
+			CreatedAt: event.CreatedAt,
+		}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// rawJSON embeds the JSON value as is, nil if it is empty
+func rawJSON(value string) json.RawMessage {
+	if value == "" {
+		return nil
+	}
+	return json.RawMessage(value)
+}
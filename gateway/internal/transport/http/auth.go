@@ -0,0 +1,213 @@
+package http
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/auth"
+	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// headerAPIKey is the alternative to Authorization: Bearer for clients that can't set it
+const headerAPIKey = "X-API-Key"
+
+// AuthPolicy configures who can call the API
+type AuthPolicy struct {
+	// AdminToken is the bearer token of the admin API, nobody is admin if it is empty
+	AdminToken string
+
+	// AnonymousScopes are granted to requests without an API key, anonymous access is disabled if empty
+	AnonymousScopes []string
+
+	// AnonymousMaxBatch limits the links shortened at once without an API key, 0 if unlimited
+	AnonymousMaxBatch int
+
+	// KeyCacheTTL is how long authenticated keys are remembered, a revoked key still works on other gateways until then
+	KeyCacheTTL time.Duration
+}
+
+// Auth authenticates the API requests by their API keys
+type Auth struct {
+	service   service
+	policy    AuthPolicy
+	anonymous auth.Caller
+	keys      *keyCache
+}
+
+func NewAuth(service service, policy AuthPolicy) *Auth {
+	anonymous := auth.Caller{MaxBatch: policy.AnonymousMaxBatch}
+	for _, scope := range policy.AnonymousScopes {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			anonymous.Scopes = append(anonymous.Scopes, scope)
+		}
+	}
+
+	return &Auth{
+		service:   service,
+		policy:    policy,
+		anonymous: anonymous,
+		keys:      newKeyCache(policy.KeyCacheTTL),
+	}
+}
+
+// RequireScope authenticates the request and lets it through if the caller has the scope.
+// Requests without a key are anonymous, a key that is not valid is rejected even if anonymous callers have the scope.
+func (a *Auth) RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			caller, err := a.authenticate(c)
+			if err != nil {
+				return err
+			}
+
+			if !caller.HasScope(scope) {
+				if caller.IsAnonymous() {
+					return unauthorized(c, "an API key with the "+scope+" scope is required")
+				}
+				return &models.HTTPError{
+					Code:      http.StatusForbidden,
+					Message:   "the API key has no " + scope + " scope",
+					ErrorCode: "insufficient_scope",
+				}
+			}
+
+			ctx := auth.WithCaller(c.Request().Context(), caller)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// RequireAdmin lets through the requests with the admin token, the token is forwarded to the admin RPCs of the shortener
+func (a *Auth) RequireAdmin() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := bearerToken(c.Request())
+			if a.policy.AdminToken == "" || token == "" {
+				return unauthorized(c, "the admin token is required")
+			}
+			if subtle.ConstantTimeCompare([]byte(token), []byte(a.policy.AdminToken)) != 1 {
+				return unauthorized(c, "invalid admin token")
+			}
+
+			ctx := auth.WithAdminToken(c.Request().Context(), token)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// authenticate returns the owner of the API key of the request, the anonymous caller if there is no key
+func (a *Auth) authenticate(c echo.Context) (auth.Caller, error) {
+	key := apiKey(c.Request())
+	if key == "" {
+		return a.anonymous, nil
+	}
+
+	hash := sha256.Sum256([]byte(key))
+	if caller, ok := a.keys.get(hash); ok {
+		return caller, nil
+	}
+
+	apiKey, httpErr := a.service.AuthenticateAPIKey(c.Request().Context(), key)
+	if httpErr != nil {
+		if httpErr.Code == http.StatusUnauthorized {
+			return auth.Caller{}, unauthorized(c, httpErr.Message)
+		}
+		return auth.Caller{}, httpErr
+	}
+
+	caller := auth.Caller{Principal: apiKey.Principal, Scopes: apiKey.Scopes}
+	a.keys.set(hash, apiKey.ID, caller)
+	return caller, nil
+}
+
+// forget makes the gateway authenticate the key again on the next request
+func (a *Auth) forget(id int64) {
+	a.keys.deleteID(id)
+}
+
+// apiKey returns the API key of X-API-Key or Authorization: Bearer, empty if the request has none
+func apiKey(r *http.Request) string {
+	if key := r.Header.Get(headerAPIKey); key != "" {
+		return key
+	}
+	return bearerToken(r)
+}
+
+// bearerToken returns the token of Authorization: Bearer, empty if there is no such header
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get(echo.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// unauthorized asks the client to authenticate with a bearer token
+func unauthorized(c echo.Context, message string) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+	return &models.HTTPError{
+		Code:      http.StatusUnauthorized,
+		Message:   message,
+		ErrorCode: "unauthenticated",
+	}
+}
+
+// keyCache remembers the callers of the authenticated keys by the hash of the key, so the shortener is not asked every request
+type keyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[[sha256.Size]byte]keyCacheEntry
+}
+
+type keyCacheEntry struct {
+	id        int64
+	caller    auth.Caller
+	expiresAt time.Time
+}
+
+func newKeyCache(ttl time.Duration) *keyCache {
+	return &keyCache{ttl: ttl, entries: make(map[[sha256.Size]byte]keyCacheEntry)}
+}
+
+func (k *keyCache) get(hash [sha256.Size]byte) (auth.Caller, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entry, ok := k.entries[hash]
+	if !ok {
+		return auth.Caller{}, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(k.entries, hash)
+		return auth.Caller{}, false
+	}
+	return entry.caller, true
+}
+
+func (k *keyCache) set(hash [sha256.Size]byte, id int64, caller auth.Caller) {
+	if k.ttl <= 0 {
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.entries[hash] = keyCacheEntry{id: id, caller: caller, expiresAt: time.Now().Add(k.ttl)}
+}
+
+func (k *keyCache) deleteID(id int64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for hash, entry := range k.entries {
+		if entry.id == id {
+			delete(k.entries, hash)
+		}
+	}
+}
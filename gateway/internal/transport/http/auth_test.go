@@ -0,0 +1,416 @@
+package http
+
+import (
+	"context"
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/auth"
+	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_RequireScope(t *testing.T) {
+	tests := []struct {
+		Name              string
+		Scope             string
+		Headers           map[string]string
+		ExceptedStatus    int
+		ExceptedBody      string
+		ExceptedPrincipal string
+		SetUpMocks        func(service *mockservice)
+	}{
+		{
+			Name:           "Anonymous with the scope",
+			Scope:          auth.ScopeShorten,
+			ExceptedStatus: http.StatusOK,
+			SetUpMocks:     func(service *mockservice) {},
+		},
+		{
+			Name:           "Anonymous without the scope",
+			Scope:          auth.ScopeManageLinks,
+			ExceptedStatus: http.StatusUnauthorized,
+			ExceptedBody:   problemBody(http.StatusUnauthorized, "unauthenticated", "an API key with the manage-links scope is required", "/test"),
+			SetUpMocks:     func(service *mockservice) {},
+		},
+		{
+			Name:              "Bearer key",
+			Scope:             auth.ScopeManageLinks,
+			Headers:           map[string]string{echo.HeaderAuthorization: "Bearer shk_secret"},
+			ExceptedStatus:    http.StatusOK,
+			ExceptedPrincipal: "apikey:ci",
+			SetUpMocks: func(service *mockservice) {
+				service.On("AuthenticateAPIKey", mock.Anything, "shk_secret").
+					Return(&models.APIKey{ID: 1, Principal: "apikey:ci", Scopes: []string{auth.ScopeManageLinks}}, nil).Once()
+			},
+		},
+		{
+			Name:              "X-API-Key header",
+			Scope:             auth.ScopeShorten,
+			Headers:           map[string]string{headerAPIKey: "shk_secret"},
+			ExceptedStatus:    http.StatusOK,
+			ExceptedPrincipal: "apikey:ci",
+			SetUpMocks: func(service *mockservice) {
+				service.On("AuthenticateAPIKey", mock.Anything, "shk_secret").
+					Return(&models.APIKey{ID: 1, Principal: "apikey:ci", Scopes: []string{auth.ScopeShorten}}, nil).Once()
+			},
+		},
+		{
+			Name:           "Key without the scope",
+			Scope:          auth.ScopeShorten,
+			Headers:        map[string]string{headerAPIKey: "shk_secret"},
+			ExceptedStatus: http.StatusForbidden,
+			ExceptedBody:   problemBody(http.StatusForbidden, "insufficient_scope", "the API key has no shorten scope", "/test"),
+			SetUpMocks: func(service *mockservice) {
+				service.On("AuthenticateAPIKey", mock.Anything, "shk_secret").
+					Return(&models.APIKey{ID: 1, Principal: "apikey:ci", Scopes: []string{auth.ScopeReadStats}}, nil).Once()
+			},
+		},
+		{
+			Name:           "Revoked key is rejected even if anonymous callers have the scope",
+			Scope:          auth.ScopeShorten,
+			Headers:        map[string]string{echo.HeaderAuthorization: "Bearer shk_secret"},
+			ExceptedStatus: http.StatusUnauthorized,
+			ExceptedBody:   problemBody(http.StatusUnauthorized, "unauthenticated", "api key is revoked", "/test"),
+			SetUpMocks: func(service *mockservice) {
+				service.On("AuthenticateAPIKey", mock.Anything, "shk_secret").
+					Return(nil, &models.HTTPError{Code: http.StatusUnauthorized, Message: "api key is revoked", ErrorCode: "unauthenticated"}).Once()
+			},
+		},
+		{
+			Name:           "Shortener is unavailable",
+			Scope:          auth.ScopeShorten,
+			Headers:        map[string]string{headerAPIKey: "shk_secret"},
+			ExceptedStatus: http.StatusServiceUnavailable,
+			ExceptedBody:   problemBody(http.StatusServiceUnavailable, "unavailable", "", "/test"),
+			SetUpMocks: func(service *mockservice) {
+				service.On("AuthenticateAPIKey", mock.Anything, "shk_secret").
+					Return(nil, &models.HTTPError{Code: http.StatusServiceUnavailable, Message: "Service Unavailable", ErrorCode: "unavailable"}).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			a := NewAuth(&mockService, AuthPolicy{AnonymousScopes: []string{auth.ScopeShorten}, AnonymousMaxBatch: 10})
+
+			var principal string
+			e := echo.New()
+			e.HTTPErrorHandler = ProblemHandler
+			e.POST("/test", func(c echo.Context) error {
+				caller, _ := auth.FromContext(c.Request().Context())
+				principal = caller.Principal
+				return c.NoContent(http.StatusOK)
+			}, a.RequireScope(tt.Scope))
+
+			req := httptest.NewRequest(http.MethodPost, "/test", nil)
+			for key, value := range tt.Headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.ExceptedStatus, rec.Code)
+			if tt.ExceptedBody != "" {
+				assert.JSONEq(t, tt.ExceptedBody, rec.Body.String())
+			}
+			if tt.ExceptedStatus == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", rec.Header().Get(echo.HeaderWWWAuthenticate))
+			}
+			assert.Equal(t, tt.ExceptedPrincipal, principal)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_RequireScope_KeyCache(t *testing.T) {
+	mockService := mockservice{}
+	mockService.On("AuthenticateAPIKey", mock.Anything, "shk_secret").
+		Return(&models.APIKey{ID: 7, Principal: "apikey:ci", Scopes: []string{auth.ScopeShorten}}, nil).Twice()
+	mockService.On("RevokeAPIKey", mock.Anything, int64(7)).
+		Return(&models.APIKey{ID: 7, Principal: "apikey:ci", Scopes: []string{auth.ScopeShorten}, RevokedAt: time.Now()}, nil).Once()
+
+	a := NewAuth(&mockService, AuthPolicy{AdminToken: "admin", KeyCacheTTL: time.Minute})
+
+	e := echo.New()
+	e.HTTPErrorHandler = ProblemHandler
+	e.POST("/test", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, a.RequireScope(auth.ScopeShorten))
+	e.DELETE("/api/admin/keys/:id", a.RevokeAPIKey, a.RequireAdmin())
+
+	call := func(method, path, token string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, call(http.MethodPost, "/test", "shk_secret"))
+	assert.Equal(t, http.StatusOK, call(http.MethodPost, "/test", "shk_secret"), "the key is cached")
+
+	// Revoking the key on this gateway drops it from the cache
+	assert.Equal(t, http.StatusOK, call(http.MethodDelete, "/api/admin/keys/7", "admin"))
+	assert.Equal(t, http.StatusOK, call(http.MethodPost, "/test", "shk_secret"))
+
+	mockService.AssertExpectations(t)
+}
+
+func Test_RequireAdmin(t *testing.T) {
+	tests := []struct {
+		Name           string
+		AdminToken     string
+		Authorization  string
+		ExceptedStatus int
+	}{
+		{Name: "Admin token", AdminToken: "admin", Authorization: "Bearer admin", ExceptedStatus: http.StatusOK},
+		{Name: "Wrong token", AdminToken: "admin", Authorization: "Bearer shk_secret", ExceptedStatus: http.StatusUnauthorized},
+		{Name: "No token", AdminToken: "admin", ExceptedStatus: http.StatusUnauthorized},
+		{Name: "Admin API is disabled", Authorization: "Bearer ", ExceptedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			a := NewAuth(&mockservice{}, AuthPolicy{AdminToken: tt.AdminToken})
+
+			e := echo.New()
+			e.HTTPErrorHandler = ProblemHandler
+			// forwarded is the authorization metadata the shortener gets
+			var forwarded []string
+			e.GET("/api/admin/keys", func(c echo.Context) error {
+				invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+					md, _ := metadata.FromOutgoingContext(ctx)
+					forwarded = md.Get("authorization")
+					return nil
+				}
+				if err := auth.UnaryClientInterceptor()(c.Request().Context(), "/v1.URLShortenerService/ListAPIKeys", nil, nil, nil, invoker); err != nil {
+					return err
+				}
+				return c.NoContent(http.StatusOK)
+			}, a.RequireAdmin())
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/keys", nil)
+			if tt.Authorization != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.Authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.ExceptedStatus, rec.Code)
+			if tt.ExceptedStatus == http.StatusOK {
+				assert.Equal(t, []string{tt.Authorization}, forwarded, "the admin token is forwarded to the shortener")
+			}
+		})
+	}
+}
+
+func Test_CreateAPIKey(t *testing.T) {
+	mockService := mockservice{}
+	mockService.On("CreateAPIKey", mock.Anything, "apikey:ci", "CI", []string{"shorten"}).
+		Return(&models.APIKey{
+			ID:        3,
+			Prefix:    "shk_abcdefgh",
+			Principal: "apikey:ci",
+			Name:      "CI",
+			Scopes:    []string{"shorten"},
+			CreatedAt: time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC),
+		}, "shk_abcdefghijkl", nil).Once()
+
+	e := echo.New()
+	e.HTTPErrorHandler = ProblemHandler
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/keys", strings.NewReader(`{ "principal": "apikey:ci", "name": "CI", "scopes": ["shorten"] }`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+
+	err := NewAuth(&mockService, AuthPolicy{}).CreateAPIKey(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.JSONEq(t, `{
+		"api_key": {
+			"id": 3,
+			"prefix": "shk_abcdefgh",
+			"principal": "apikey:ci",
+			"name": "CI",
+			"scopes": ["shorten"],
+			"created_at": "2030-01-01T10:00:00Z"
+		},
+		"key": "shk_abcdefghijkl"
+	}`, rec.Body.String())
+
+	mockService.AssertExpectations(t)
+}
+
+func Test_RevokeAPIKey_BadID(t *testing.T) {
+	e := echo.New()
+
+	c := e.NewContext(httptest.NewRequest(http.MethodDelete, "/api/admin/keys/abc", nil), httptest.NewRecorder())
+	c.SetParamNames("id")
+	c.SetParamValues("abc")
+
+	err := NewAuth(&mockservice{}, AuthPolicy{}).RevokeAPIKey(c)
+	assert.Equal(t, &models.HTTPError{
+		Code:       http.StatusBadRequest,
+		Message:    "bad API key ID",
+		ErrorCode:  "invalid_argument",
+		Violations: []models.Violation{{Field: "id", Message: "must be a positive integer"}},
+	}, err)
+}
+
+func Test_ListAuditEvents(t *testing.T) {
+	events := []models.AuditEvent{
+		{
+			ID: 7, Code: "docs", Domain: "go.some", Action: "retarget", Actor: "telegram:1", RequestID: "req-1",
+			OldValue: `{"url":"https://go.dev"}`, NewValue: `{"url":"https://pkg.go.dev"}`,
+			CreatedAt: time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{ID: 3, Code: "docs", Domain: "go.some", Action: "create", CreatedAt: time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)},
+	}
+	eventsBody := `{
+		"events": [
+			{
+				"id": 7,
+				"code": "docs",
+				"domain": "go.some",
+				"action": "retarget",
+				"actor": "telegram:1",
+				"request_id": "req-1",
+				"old_value": {"url": "https://go.dev"},
+				"new_value": {"url": "https://pkg.go.dev"},
+				"created_at": "2030-01-01T10:00:00Z"
+			},
+			{"id": 3, "code": "docs", "domain": "go.some", "action": "create", "created_at": "2030-01-01T09:00:00Z"}
+		],
+		"next_cursor": "Mw"
+	}`
+	withAdminToken := mock.MatchedBy(func(ctx context.Context) bool {
+		return assert.ObjectsAreEqual([]string{"Bearer admin"}, forwardedAuthorization(ctx))
+	})
+	withoutAdminToken := mock.MatchedBy(func(ctx context.Context) bool {
+		return len(forwardedAuthorization(ctx)) == 0
+	})
+
+	tests := []struct {
+		Name            string
+		Path            string
+		AnonymousScopes []string
+		Headers         map[string]string
+		ExceptedStatus  int
+		ExceptedBody    string
+		SetUpMocks      func(service *mockservice)
+	}{
+		{
+			Name:           "Admin lists all events",
+			Path:           "/api/admin/audit",
+			Headers:        map[string]string{echo.HeaderAuthorization: "Bearer admin"},
+			ExceptedStatus: http.StatusOK,
+			ExceptedBody:   eventsBody,
+			SetUpMocks: func(service *mockservice) {
+				service.On("ListAuditEvents", withAdminToken, models.AuditFilter{Code: "docs", Domain: "go.some", Actor: "telegram:1", Action: "retarget"}, "MTIx", 10).
+					Return(events, "Mw", nil).Once()
+			},
+		},
+		{
+			Name:           "Key lists only its own events",
+			Path:           "/api/audit",
+			Headers:        map[string]string{headerAPIKey: "shk_secret"},
+			ExceptedStatus: http.StatusOK,
+			ExceptedBody:   `{"events": []}`,
+			SetUpMocks: func(service *mockservice) {
+				service.On("AuthenticateAPIKey", mock.Anything, "shk_secret").
+					Return(&models.APIKey{ID: 1, Principal: "apikey:stats", Scopes: []string{auth.ScopeReadStats}}, nil).Once()
+				// The actor of the query is replaced and the log is not read as admin
+				service.On("ListAuditEvents", withoutAdminToken, models.AuditFilter{Code: "docs", Domain: "go.some", Actor: "apikey:stats", Action: "retarget"}, "MTIx", 10).
+					Return([]models.AuditEvent{}, "", nil).Once()
+			},
+		},
+		{
+			Name:           "Key without the scope",
+			Path:           "/api/audit",
+			Headers:        map[string]string{headerAPIKey: "shk_secret"},
+			ExceptedStatus: http.StatusForbidden,
+			ExceptedBody:   problemBody(http.StatusForbidden, "insufficient_scope", "the API key has no read-stats scope", "/api/audit"),
+			SetUpMocks: func(service *mockservice) {
+				service.On("AuthenticateAPIKey", mock.Anything, "shk_secret").
+					Return(&models.APIKey{ID: 1, Principal: "apikey:ci", Scopes: []string{auth.ScopeManageLinks}}, nil).Once()
+			},
+		},
+		{
+			Name:           "Anonymous",
+			Path:           "/api/audit",
+			ExceptedStatus: http.StatusUnauthorized,
+			ExceptedBody:   problemBody(http.StatusUnauthorized, "unauthenticated", "an API key with the read-stats scope is required", "/api/audit"),
+			SetUpMocks:     func(service *mockservice) {},
+		},
+		{
+			Name:            "Anonymous with the scope",
+			Path:            "/api/audit",
+			AnonymousScopes: []string{auth.ScopeReadStats},
+			ExceptedStatus:  http.StatusUnauthorized,
+			ExceptedBody:    problemBody(http.StatusUnauthorized, "unauthenticated", "an API key is required to list the audit events", "/api/audit"),
+			SetUpMocks:      func(service *mockservice) {},
+		},
+		{
+			Name:           "Admin route with an API key",
+			Path:           "/api/admin/audit",
+			Headers:        map[string]string{echo.HeaderAuthorization: "Bearer shk_secret"},
+			ExceptedStatus: http.StatusUnauthorized,
+			ExceptedBody:   problemBody(http.StatusUnauthorized, "unauthenticated", "invalid admin token", "/api/admin/audit"),
+			SetUpMocks:     func(service *mockservice) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			a := NewAuth(&mockService, AuthPolicy{AdminToken: "admin", AnonymousScopes: tt.AnonymousScopes})
+
+			e := echo.New()
+			e.HTTPErrorHandler = ProblemHandler
+			e.GET("/api/admin/audit", a.ListAuditEvents, a.RequireAdmin())
+			e.GET("/api/audit", a.ListAuditEvents, a.RequireScope(auth.ScopeReadStats))
+
+			req := httptest.NewRequest(http.MethodGet, tt.Path+"?code=docs&domain=go.some&actor=telegram:1&action=retarget&limit=10&cursor=MTIx", nil)
+			for key, value := range tt.Headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.ExceptedStatus, rec.Code)
+			assert.JSONEq(t, tt.ExceptedBody, rec.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// forwardedAuthorization returns the authorization metadata the shortener gets with the call made with ctx
+func forwardedAuthorization(ctx context.Context) []string {
+	var forwarded []string
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		forwarded = md.Get("authorization")
+		return nil
+	}
+	auth.UnaryClientInterceptor()(ctx, "/v1.URLShortenerService/ListAuditEvents", nil, nil, nil, invoker)
+	return forwarded
+}
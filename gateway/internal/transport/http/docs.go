@@ -0,0 +1,36 @@
+package http
+
+import (
+	_ "embed"
+	"github.com/labstack/echo/v4"
+	swaggerFiles "github.com/swaggo/files"
+	"net/http"
+)
+
+//go:embed templates/docs.html
+var docsPage []byte
+
+// docsAssets are the Swagger UI files embedded into the binary, so the docs work without access to a CDN
+var docsAssets = map[string]struct {
+	contentType string
+	content     []byte
+}{
+	"swagger-ui.css":                  {"text/css; charset=utf-8", swaggerFiles.FileSwaggerUICSS},
+	"swagger-ui-bundle.js":            {"text/javascript; charset=utf-8", swaggerFiles.FileSwaggerUIBundleJs},
+	"swagger-ui-standalone-preset.js": {"text/javascript; charset=utf-8", swaggerFiles.FileSwaggerUIStandalonePresetJs},
+}
+
+// Docs serves Swagger UI of the specification at /openapi.json
+func (o *OpenAPI) Docs(c echo.Context) error {
+	return c.HTMLBlob(http.StatusOK, docsPage)
+}
+
+// DocsAsset serves the script or the stylesheet of Swagger UI
+func (o *OpenAPI) DocsAsset(c echo.Context) error {
+	asset, ok := docsAssets[c.Param("file")]
+	if !ok {
+		return echo.ErrNotFound
+	}
+	c.Response().Header().Set("Cache-Control", "public, max-age=86400")
+	return c.Blob(http.StatusOK, asset.contentType, asset.content)
+}
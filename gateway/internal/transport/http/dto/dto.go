@@ -1,7 +1,40 @@
 package dto
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type ShortenURLRequest struct {
-	URL string `json:"url"`
+	URL          string            `json:"url"`
+	ForwardQuery bool              `json:"forward_query,omitempty"`
+	UTM          map[string]string `json:"utm,omitempty"`
+	Rules        []RoutingRule     `json:"rules,omitempty"`
+	Destinations []Destination     `json:"destinations,omitempty"`
+	Password     string            `json:"password,omitempty"`
+	NotBefore    time.Time         `json:"not_before,omitzero"`
+	ExpiresAt    time.Time         `json:"expires_at,omitzero"`
+	Domain       string            `json:"domain,omitempty"`
+	Alias        string            `json:"alias,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Notes        string            `json:"notes,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	Interstitial bool              `json:"interstitial,omitempty"`
+}
+
+type Destination struct {
+	URL    string `json:"url"`
+	Weight uint32 `json:"weight"`
+	Name   string `json:"name,omitempty"`
+}
+
+type RoutingRule struct {
+	URL            string   `json:"url"`
+	Name           string   `json:"name,omitempty"`
+	UserAgent      string   `json:"user_agent,omitempty"`
+	Platform       string   `json:"platform,omitempty"`
+	AcceptLanguage string   `json:"accept_language,omitempty"`
+	Countries      []string `json:"countries,omitempty"`
 }
 
 type ShortenURLResponse struct {
@@ -17,3 +50,83 @@ type ShortenURLBatchRequest struct {
 type ShortenURLBatchResponse struct {
 	URLs []ShortenURLResponse `json:"urls"`
 }
+
+type Link struct {
+	ShortURL          string    `json:"short_url"`
+	Code              string    `json:"code"`
+	Domain            string    `json:"domain"`
+	OriginalURL       string    `json:"original_url"`
+	Owner             string    `json:"owner,omitempty"`
+	CreatedAt         time.Time `json:"created_at,omitzero"`
+	NotBefore         time.Time `json:"not_before,omitzero"`
+	ExpiresAt         time.Time `json:"expires_at,omitzero"`
+	PasswordProtected bool      `json:"password_protected"`
+	Enabled           bool      `json:"enabled"`
+	Title             string    `json:"title,omitempty"`
+	Tags              []string  `json:"tags,omitempty"`
+}
+
+type ListLinksResponse struct {
+	Links      []Link `json:"links"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type AuditEvent struct {
+	ID        int64           `json:"id"`
+	Code      string          `json:"code"`
+	Domain    string          `json:"domain"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	OldValue  json.RawMessage `json:"old_value,omitempty"`
+	NewValue  json.RawMessage `json:"new_value,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type ListAuditEventsResponse struct {
+	Events     []AuditEvent `json:"events"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type APIKey struct {
+	ID        int64     `json:"id"`
+	Prefix    string    `json:"prefix"`
+	Principal string    `json:"principal"`
+	Name      string    `json:"name,omitempty"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+	RevokedAt time.Time `json:"revoked_at,omitzero"`
+}
+
+type CreateAPIKeyRequest struct {
+	Principal string   `json:"principal"`
+	Name      string   `json:"name,omitempty"`
+	Scopes    []string `json:"scopes"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey APIKey `json:"api_key"`
+	// Key is the secret, it is shown only once
+	Key string `json:"key"`
+}
+
+type ListAPIKeysResponse struct {
+	APIKeys []APIKey `json:"api_keys"`
+}
+
+// Problem is the error response in the application/problem+json format of RFC 9457
+type Problem struct {
+	Type       string      `json:"type"`
+	Title      string      `json:"title"`
+	Status     int         `json:"status"`
+	Detail     string      `json:"detail,omitempty"`
+	Instance   string      `json:"instance,omitempty"`
+	Code       string      `json:"code"`
+	RequestID  string      `json:"request_id,omitempty"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
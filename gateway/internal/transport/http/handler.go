@@ -2,24 +2,69 @@ package http
 
 import (
 	"context"
+	"errors"
 	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/auth"
 	"github.com/misshanya/url-shortener/gateway/internal/models"
 	"github.com/misshanya/url-shortener/gateway/internal/transport/http/dto"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type service interface {
-	ShortenURL(ctx context.Context, url string) (string, *models.HTTPError)
+	ShortenURL(ctx context.Context, url string, options models.LinkOptions) (string, *models.HTTPError)
 	ShortenURLBatch(ctx context.Context, urls []*models.Short) *models.HTTPError
-	UnshortenURL(ctx context.Context, code string) (string, *models.HTTPError)
+	UnshortenURL(ctx context.Context, code string, visit models.Visit) (*models.Redirect, *models.HTTPError)
+	PreviewURL(ctx context.Context, host, code string) (*models.LinkPreview, *models.HTTPError)
+	VerifyLinkPassword(ctx context.Context, host, code, password string) (string, time.Time, *models.HTTPError)
+	ListLinks(ctx context.Context, filter models.LinkFilter, cursor string, limit int) ([]models.Link, string, *models.HTTPError)
+	ListAuditEvents(ctx context.Context, filter models.AuditFilter, cursor string, limit int) ([]models.AuditEvent, string, *models.HTTPError)
+	CreateAPIKey(ctx context.Context, principal, name string, scopes []string) (*models.APIKey, string, *models.HTTPError)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, *models.HTTPError)
+	RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, *models.HTTPError)
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, *models.HTTPError)
+}
+
+type geoResolver interface {
+	Country(ip net.IP) string
 }
 
 type Handler struct {
-	service service
+	service      service
+	geo          geoResolver
+	interstitial *Interstitial
+	qrCache      *qrCache
 }
 
-func NewHandler(service service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service service, geo geoResolver, interstitial *Interstitial) *Handler {
+	return &Handler{service: service, geo: geo, interstitial: interstitial, qrCache: newQRCache(qrCacheSize)}
+}
+
+// linkOptions maps link options from the request
+func linkOptions(req dto.ShortenURLRequest) models.LinkOptions {
+	options := models.LinkOptions{
+		ForwardQuery: req.ForwardQuery,
+		UTM:          req.UTM,
+		Password:     req.Password,
+		NotBefore:    req.NotBefore,
+		ExpiresAt:    req.ExpiresAt,
+		Domain:       req.Domain,
+		Alias:        req.Alias,
+		Title:        req.Title,
+		Notes:        req.Notes,
+		Tags:         req.Tags,
+		Interstitial: req.Interstitial,
+	}
+	for _, rule := range req.Rules {
+		options.Rules = append(options.Rules, models.RoutingRule(rule))
+	}
+	for _, destination := range req.Destinations {
+		options.Destinations = append(options.Destinations, models.Destination(destination))
+	}
+	return options
 }
 
 func (h *Handler) ShortenURL(c echo.Context) error {
@@ -27,12 +72,12 @@ func (h *Handler) ShortenURL(c echo.Context) error {
 
 	var req dto.ShortenURLRequest
 	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return bindError(err)
 	}
 
-	url, httpErr := h.service.ShortenURL(ctx, req.URL)
+	url, httpErr := h.service.ShortenURL(ctx, req.URL, linkOptions(req))
 	if httpErr != nil {
-		return echo.NewHTTPError(httpErr.Code, httpErr.Message)
+		return httpErr
 	}
 
 	resp := &dto.ShortenURLResponse{ShortURL: url, OriginalURL: req.URL}
@@ -44,18 +89,27 @@ func (h *Handler) ShortenURLBatch(c echo.Context) error {
 
 	var req dto.ShortenURLBatchRequest
 	if err := c.Bind(&req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return bindError(err)
+	}
+
+	if caller, ok := auth.FromContext(ctx); ok && caller.MaxBatch > 0 && len(req.URLs) > caller.MaxBatch {
+		return &models.HTTPError{
+			Code:      http.StatusForbidden,
+			Message:   "batches without an API key are limited to " + strconv.Itoa(caller.MaxBatch) + " links",
+			ErrorCode: "batch_limit_exceeded",
+		}
 	}
 
 	urls := make([]*models.Short, len(req.URLs))
 	for i, url := range req.URLs {
 		urls[i] = &models.Short{
 			OriginalURL: url.URL,
+			Options:     linkOptions(url),
 		}
 	}
 
 	if httpErr := h.service.ShortenURLBatch(ctx, urls); httpErr != nil {
-		return echo.NewHTTPError(httpErr.Code, httpErr.Message)
+		return httpErr
 	}
 
 	urlsForResp := make([]dto.ShortenURLResponse, len(urls))
@@ -68,15 +122,162 @@ func (h *Handler) ShortenURLBatch(c echo.Context) error {
 	return c.JSON(http.StatusCreated, resp)
 }
 
+// UnshortenURL redirects the visitor to the destination of the link,
+// the code followed by + shows the preview of the link instead
 func (h *Handler) UnshortenURL(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	code := c.Param("code")
+	if preview, ok := strings.CutSuffix(code, previewSuffix); ok {
+		return h.PreviewURL(c, preview)
+	}
+
+	visit := visitFromRequest(c.Request())
+	visit.Host = c.Request().Host
+	visit.VisitorID = visitorID(c)
+	visit.IP = c.RealIP()
+	visit.Country = h.geo.Country(net.ParseIP(visit.IP))
+	visit.LinkToken = linkToken(c, code)
+
+	redirect, httpErr := h.service.UnshortenURL(ctx, code, visit)
+	if httpErr != nil {
+		// The link is password-protected
+		if httpErr.Code == http.StatusForbidden {
+			return renderPasswordForm(c, http.StatusForbidden, "")
+		}
+		return httpErr
+	}
+
+	// The visit is already counted, the visitor continues to the resolved destination
+	if redirect.Interstitial {
+		return h.interstitial.render(c, interstitialPage{
+			Destination: redirect.URL,
+			ContinueURL: redirect.URL,
+		})
+	}
+
+	return c.Redirect(http.StatusFound, redirect.URL)
+}
 
-	url, httpErr := h.service.UnshortenURL(ctx, code)
+// PreviewURL shows where the link goes without visiting it, the visitor continues through the link itself
+func (h *Handler) PreviewURL(c echo.Context, code string) error {
+	ctx := c.Request().Context()
+
+	preview, httpErr := h.service.PreviewURL(ctx, c.Request().Host, code)
 	if httpErr != nil {
-		return echo.NewHTTPError(httpErr.Code, httpErr.Message)
+		return httpErr
 	}
 
-	return c.Redirect(http.StatusFound, url)
+	return h.interstitial.render(c, interstitialPage{
+		Preview:           true,
+		ShortURL:          preview.ShortURL,
+		Destination:       preview.OriginalURL,
+		CreatedAt:         preview.CreatedAt,
+		PasswordProtected: preview.PasswordProtected,
+		ContinueURL:       "/" + code,
+	})
+}
+
+// VerifyLinkPassword handles the password form of the protected link.
+// On success, it stores the token in the cookie and redirects back to the link.
+func (h *Handler) VerifyLinkPassword(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	code := c.Param("code")
+	password := c.FormValue("password")
+	if password == "" {
+		return renderPasswordForm(c, http.StatusBadRequest, "Enter the password")
+	}
+
+	token, expiresAt, httpErr := h.service.VerifyLinkPassword(ctx, c.Request().Host, code, password)
+	if httpErr != nil {
+		if httpErr.Code == http.StatusForbidden {
+			return renderPasswordForm(c, http.StatusForbidden, "Wrong password")
+		}
+		return httpErr
+	}
+
+	setLinkToken(c, code, token, expiresAt)
+
+	return c.Redirect(http.StatusSeeOther, c.Request().URL.RequestURI())
+}
+
+// linkFilter maps the query params of the request into link filter
+func linkFilter(c echo.Context) (models.LinkFilter, error) {
+	filter := models.LinkFilter{
+		Domain: c.QueryParam("domain"),
+		Search: c.QueryParam("q"),
+		Tags:   c.QueryParams()["tag"],
+	}
+
+	for param, t := range map[string]*time.Time{
+		"created_from": &filter.CreatedFrom,
+		"created_to":   &filter.CreatedTo,
+	} {
+		value := c.QueryParam(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return models.LinkFilter{}, errors.New("bad " + param)
+		}
+		*t = parsed
+	}
+
+	if value := c.QueryParam("enabled"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return models.LinkFilter{}, errors.New("bad enabled")
+		}
+		filter.Enabled = &enabled
+	}
+
+	return filter, nil
+}
+
+// limitParam returns the page size from the limit query param, 0 if it is not set
+func limitParam(c echo.Context) (int, error) {
+	value := c.QueryParam("limit")
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, errors.New("bad limit")
+	}
+	return limit, nil
+}
+
+// ListLinks lists the links of the principal of the API key by the query params, newest first.
+// Anonymous callers own no links, so they can't list them.
+func (h *Handler) ListLinks(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	caller, ok := auth.FromContext(ctx)
+	if !ok || caller.IsAnonymous() {
+		return unauthorized(c, "an API key is required to list the links")
+	}
+
+	filter, err := linkFilter(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	filter.Owner = caller.Principal
+
+	limit, err := limitParam(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	links, next, httpErr := h.service.ListLinks(ctx, filter, c.QueryParam("cursor"), limit)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	resp := &dto.ListLinksResponse{Links: make([]dto.Link, len(links)), NextCursor: next}
+	for i, link := range links {
+		resp.Links[i] = dto.Link(link)
+	}
+	return c.JSON(http.StatusOK, resp)
 }
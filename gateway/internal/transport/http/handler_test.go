@@ -1,16 +1,22 @@
 package http
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/auth"
 	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"github.com/misshanya/url-shortener/gateway/internal/transport/http/dto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 func Test_ShortenURL(t *testing.T) {
@@ -27,29 +33,56 @@ func Test_ShortenURL(t *testing.T) {
 			ExceptedStatus: http.StatusCreated,
 			ExceptedBody:   `{ "short_url": "https://sh.some/3a", "original_url": "https://go.dev" }`,
 			SetUpMocks: func(service *mockservice) {
-				service.On("ShortenURL", mock.Anything, "https://go.dev").
+				service.On("ShortenURL", mock.Anything, "https://go.dev", models.LinkOptions{}).
 					Return("https://sh.some/3a", nil).Once()
 			},
 		},
+		{
+			Name:           "Successfully Shortened with options",
+			RequestBody:    `{ "url": "https://go.dev", "forward_query": true, "utm": { "utm_source": "sh" } }`,
+			ExceptedStatus: http.StatusCreated,
+			ExceptedBody:   `{ "short_url": "https://sh.some/3b", "original_url": "https://go.dev" }`,
+			SetUpMocks: func(service *mockservice) {
+				service.On("ShortenURL", mock.Anything, "https://go.dev", models.LinkOptions{
+					ForwardQuery: true,
+					UTM:          map[string]string{"utm_source": "sh"},
+				}).
+					Return("https://sh.some/3b", nil).Once()
+			},
+		},
+		{
+			Name:           "Successfully Shortened with activation window",
+			RequestBody:    `{ "url": "https://go.dev", "not_before": "2030-01-01T10:00:00Z" }`,
+			ExceptedStatus: http.StatusCreated,
+			ExceptedBody:   `{ "short_url": "https://sh.some/3c", "original_url": "https://go.dev" }`,
+			SetUpMocks: func(service *mockservice) {
+				service.On("ShortenURL", mock.Anything, "https://go.dev", models.LinkOptions{
+					NotBefore: time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC),
+				}).
+					Return("https://sh.some/3c", nil).Once()
+			},
+		},
 		{
 			Name:           "URL in request body is not a string",
 			RequestBody:    `{ "url": 1 }`,
 			ExceptedStatus: http.StatusBadRequest,
-			ExceptedBody:   `{ "message": "code=400, message=Unmarshal type error: expected=string, got=number, field=url, offset=10, internal=json: cannot unmarshal number into Go struct field ShortenURLRequest.url of type string" }`,
-			SetUpMocks:     func(service *mockservice) {},
+			ExceptedBody: problemBody(http.StatusBadRequest, "invalid_body", "invalid request body", "/shorten",
+				dto.Violation{Field: "url", Message: "must be a string, got number"}),
+			SetUpMocks: func(service *mockservice) {},
 		},
 		{
 			Name:           "Service returned an internal server error",
 			RequestBody:    `{ "url": "https://go.dev" }`,
 			ExceptedStatus: http.StatusInternalServerError,
-			ExceptedBody:   `{ "message": "Unknown error :)" }`,
+			ExceptedBody:   problemBody(http.StatusInternalServerError, "internal", "Unknown error :)", "/shorten"),
 			SetUpMocks: func(service *mockservice) {
-				service.On("ShortenURL", mock.Anything, "https://go.dev").
+				service.On("ShortenURL", mock.Anything, "https://go.dev", models.LinkOptions{}).
 					Return(
 						"",
 						&models.HTTPError{
-							Code:    http.StatusInternalServerError,
-							Message: "Unknown error :)",
+							Code:      http.StatusInternalServerError,
+							Message:   "Unknown error :)",
+							ErrorCode: "internal",
 						},
 					).Once()
 			},
@@ -63,6 +96,7 @@ func Test_ShortenURL(t *testing.T) {
 			tt.SetUpMocks(&mockService)
 
 			e := echo.New()
+			e.HTTPErrorHandler = ProblemHandler
 
 			req := httptest.NewRequest(http.MethodPost, "/shorten", strings.NewReader(tt.RequestBody))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -71,7 +105,7 @@ func Test_ShortenURL(t *testing.T) {
 
 			c := e.NewContext(req, rec)
 
-			handler := NewHandler(&mockService)
+			handler := NewHandler(&mockService, nil, nil)
 
 			err := handler.ShortenURL(c)
 			if err != nil {
@@ -152,12 +186,13 @@ func Test_ShortenURLBatch(t *testing.T) {
 }
 `,
 			ExceptedStatus: http.StatusInternalServerError,
-			ExceptedBody:   `{ "message": "some error :)" }`,
+			ExceptedBody:   problemBody(http.StatusInternalServerError, "internal", "some error :)", "/shorten/batch"),
 			SetUpMocks: func(service *mockservice) {
 				service.On("ShortenURLBatch", mock.Anything, mock.AnythingOfType("[]*models.Short")).
 					Return(&models.HTTPError{
-						Code:    http.StatusInternalServerError,
-						Message: "some error :)",
+						Code:      http.StatusInternalServerError,
+						Message:   "some error :)",
+						ErrorCode: "internal",
 					}).Once()
 			},
 		},
@@ -165,15 +200,13 @@ func Test_ShortenURLBatch(t *testing.T) {
 			Name: "Invalid request body",
 			RequestBody: `
 {
-  "urls": [
-    "https://github.com",
-    "https://gitlab.com"
-  ]
+  "urls": "https://github.com"
 }
 `,
 			ExceptedStatus: http.StatusBadRequest,
-			ExceptedBody:   `{ "message": "code=400, message=Unmarshal type error: expected=dto.ShortenURLRequest, got=string, field=urls, offset=39, internal=json: cannot unmarshal string into Go struct field ShortenURLBatchRequest.urls of type dto.ShortenURLRequest" }`,
-			SetUpMocks:     func(service *mockservice) {},
+			ExceptedBody: problemBody(http.StatusBadRequest, "invalid_body", "invalid request body", "/shorten/batch",
+				dto.Violation{Field: "urls", Message: "must be an array, got string"}),
+			SetUpMocks: func(service *mockservice) {},
 		},
 	}
 
@@ -184,6 +217,7 @@ func Test_ShortenURLBatch(t *testing.T) {
 			tt.SetUpMocks(&mockService)
 
 			e := echo.New()
+			e.HTTPErrorHandler = ProblemHandler
 
 			req := httptest.NewRequest(http.MethodPost, "/shorten/batch", strings.NewReader(tt.RequestBody))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -192,7 +226,7 @@ func Test_ShortenURLBatch(t *testing.T) {
 
 			c := e.NewContext(req, rec)
 
-			handler := NewHandler(&mockService)
+			handler := NewHandler(&mockService, nil, nil)
 
 			err := handler.ShortenURLBatch(c)
 			if err != nil {
@@ -211,9 +245,16 @@ func Test_UnshortenURL(t *testing.T) {
 	tests := []struct {
 		Name           string
 		InputCode      string
+		InputQuery     string
+		InputHeaders   map[string]string
+		NewVisitor     bool
+		Country        string
+		InputLinkToken string
 		ExceptedStatus int
 		ExceptedURL    string
 		ExceptedBody   string
+		ExceptedForm   bool
+		ExceptedPage   []string
 		SetUpMocks     func(service *mockservice)
 	}{
 		{
@@ -222,20 +263,159 @@ func Test_UnshortenURL(t *testing.T) {
 			ExceptedStatus: http.StatusFound,
 			ExceptedURL:    "https://go.dev",
 			SetUpMocks: func(service *mockservice) {
-				service.On("UnshortenURL", mock.Anything, "3a").
-					Return("https://go.dev", nil).Once()
+				service.On("UnshortenURL", mock.Anything, "3a", models.Visit{Host: "sh.some", VisitorID: "f00d", IP: "192.0.2.1"}).
+					Return(&models.Redirect{URL: "https://go.dev"}, nil).Once()
+			},
+		},
+		{
+			Name:           "Successfully Unshortened with query",
+			InputCode:      "3a",
+			InputQuery:     "ref=newsletter",
+			ExceptedStatus: http.StatusFound,
+			ExceptedURL:    "https://go.dev?ref=newsletter",
+			SetUpMocks: func(service *mockservice) {
+				service.On("UnshortenURL", mock.Anything, "3a", models.Visit{Host: "sh.some", Query: "ref=newsletter", VisitorID: "f00d", IP: "192.0.2.1"}).
+					Return(&models.Redirect{URL: "https://go.dev?ref=newsletter"}, nil).Once()
+			},
+		},
+		{
+			Name:      "Visitor attributes are passed to the service",
+			InputCode: "3a",
+			InputHeaders: map[string]string{
+				"User-Agent":      "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X)",
+				"Accept-Language": "de-CH, de;q=0.9",
+				"Referer":         "https://news.some/post",
+			},
+			ExceptedStatus: http.StatusFound,
+			ExceptedURL:    "https://apps.apple.com/app",
+			SetUpMocks: func(service *mockservice) {
+				service.On("UnshortenURL", mock.Anything, "3a", models.Visit{
+					Host:           "sh.some",
+					Referer:        "https://news.some/post",
+					UserAgent:      "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X)",
+					Platform:       "ios",
+					AcceptLanguage: "de-CH, de;q=0.9",
+					VisitorID:      "f00d",
+					IP:             "192.0.2.1",
+				}).
+					Return(&models.Redirect{URL: "https://apps.apple.com/app"}, nil).Once()
+			},
+		},
+		{
+			Name:           "Country is resolved from the client IP",
+			InputCode:      "3a",
+			Country:        "DE",
+			ExceptedStatus: http.StatusFound,
+			ExceptedURL:    "https://go.dev/de",
+			SetUpMocks: func(service *mockservice) {
+				service.On("UnshortenURL", mock.Anything, "3a", models.Visit{Host: "sh.some", Country: "DE", VisitorID: "f00d", IP: "192.0.2.1"}).
+					Return(&models.Redirect{URL: "https://go.dev/de"}, nil).Once()
+			},
+		},
+		{
+			Name:           "Protected link shows password form",
+			InputCode:      "3a",
+			ExceptedStatus: http.StatusForbidden,
+			ExceptedForm:   true,
+			SetUpMocks: func(service *mockservice) {
+				service.On("UnshortenURL", mock.Anything, "3a", models.Visit{Host: "sh.some", VisitorID: "f00d", IP: "192.0.2.1"}).
+					Return(nil, &models.HTTPError{
+						Code:    http.StatusForbidden,
+						Message: "password required",
+					}).Once()
+			},
+		},
+		{
+			Name:           "Protected link with token from cookie",
+			InputCode:      "3a",
+			InputLinkToken: "token",
+			ExceptedStatus: http.StatusFound,
+			ExceptedURL:    "https://go.dev",
+			SetUpMocks: func(service *mockservice) {
+				service.On("UnshortenURL", mock.Anything, "3a", models.Visit{Host: "sh.some", VisitorID: "f00d", IP: "192.0.2.1", LinkToken: "token"}).
+					Return(&models.Redirect{URL: "https://go.dev"}, nil).Once()
+			},
+		},
+		{
+			Name:           "New visitor gets a visitor cookie",
+			InputCode:      "3a",
+			NewVisitor:     true,
+			ExceptedStatus: http.StatusFound,
+			ExceptedURL:    "https://go.dev",
+			SetUpMocks: func(service *mockservice) {
+				hasVisitorID := mock.MatchedBy(func(visit models.Visit) bool {
+					return len(visit.VisitorID) == 32 && visit.IP == "192.0.2.1"
+				})
+				service.On("UnshortenURL", mock.Anything, "3a", hasVisitorID).
+					Return(&models.Redirect{URL: "https://go.dev"}, nil).Once()
+			},
+		},
+		{
+			Name:           "Interstitial link shows the destination",
+			InputCode:      "3i",
+			ExceptedStatus: http.StatusOK,
+			ExceptedPage:   []string{"Check the destination", "https://unknown.example", `href="https://unknown.example"`},
+			SetUpMocks: func(service *mockservice) {
+				service.On("UnshortenURL", mock.Anything, "3i", models.Visit{Host: "sh.some", VisitorID: "f00d", IP: "192.0.2.1"}).
+					Return(&models.Redirect{URL: "https://unknown.example", Interstitial: true}, nil).Once()
+			},
+		},
+		{
+			Name:           "Preview shows the destination without a visit",
+			InputCode:      "3a+",
+			ExceptedStatus: http.StatusOK,
+			ExceptedPage:   []string{"Check the destination", "https://sh.some/3a goes to", "https://go.dev", "Created on November 14, 2023", `href="/3a"`},
+			SetUpMocks: func(service *mockservice) {
+				service.On("PreviewURL", mock.Anything, "sh.some", "3a").
+					Return(&models.LinkPreview{
+						ShortURL:    "https://sh.some/3a",
+						Code:        "3a",
+						Domain:      "sh.some",
+						OriginalURL: "https://go.dev",
+						CreatedAt:   time.Unix(1_700_000_000, 0).UTC(),
+					}, nil).Once()
+			},
+		},
+		{
+			Name:           "Preview of protected link hides the destination",
+			InputCode:      "3p+",
+			ExceptedStatus: http.StatusOK,
+			ExceptedPage:   []string{"password-protected", `href="/3p"`},
+			SetUpMocks: func(service *mockservice) {
+				service.On("PreviewURL", mock.Anything, "sh.some", "3p").
+					Return(&models.LinkPreview{
+						ShortURL:          "https://sh.some/3p",
+						Code:              "3p",
+						Domain:            "sh.some",
+						PasswordProtected: true,
+					}, nil).Once()
+			},
+		},
+		{
+			Name:           "Preview of unknown link",
+			InputCode:      "zz+",
+			ExceptedStatus: http.StatusNotFound,
+			ExceptedBody:   problemBody(http.StatusNotFound, "not_found", "not found", "/zz+"),
+			SetUpMocks: func(service *mockservice) {
+				service.On("PreviewURL", mock.Anything, "sh.some", "zz").
+					Return(nil, &models.HTTPError{
+						Code:      http.StatusNotFound,
+						Message:   "not found",
+						ErrorCode: "not_found",
+					}).Once()
 			},
 		},
 		{
 			Name:           "Service returned an error",
 			InputCode:      "3a",
 			ExceptedStatus: http.StatusInternalServerError,
-			ExceptedBody:   `{ "message": "some error :)" }`,
+			ExceptedBody:   problemBody(http.StatusInternalServerError, "internal", "some error :)", "/3a"),
 			SetUpMocks: func(service *mockservice) {
-				service.On("UnshortenURL", mock.Anything, "3a").
-					Return("", &models.HTTPError{
-						Code:    http.StatusInternalServerError,
-						Message: "some error :)",
+				service.On("UnshortenURL", mock.Anything, "3a", models.Visit{Host: "sh.some", VisitorID: "f00d", IP: "192.0.2.1"}).
+					Return(nil, &models.HTTPError{
+						Code:      http.StatusInternalServerError,
+						Message:   "some error :)",
+						ErrorCode: "internal",
 					}).Once()
 			},
 		},
@@ -247,10 +427,35 @@ func Test_UnshortenURL(t *testing.T) {
 
 			tt.SetUpMocks(&mockService)
 
+			// Previews are not visits, the visitor is not located
+			mockGeo := mockgeoResolver{}
+			if !strings.HasSuffix(tt.InputCode, previewSuffix) {
+				mockGeo.On("Country", net.ParseIP("192.0.2.1")).Return(tt.Country).Once()
+			}
+
+			interstitial, err := NewInterstitial("", "Check the destination before you continue.")
+			require.NoError(t, err)
+
 			e := echo.New()
+			e.HTTPErrorHandler = ProblemHandler
+			e.IPExtractor = echo.ExtractIPDirect()
 
-			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/%s", tt.InputCode), nil)
+			target := fmt.Sprintf("/%s", tt.InputCode)
+			if tt.InputQuery != "" {
+				target += "?" + tt.InputQuery
+			}
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			req.Host = "sh.some"
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			for key, value := range tt.InputHeaders {
+				req.Header.Set(key, value)
+			}
+			if !tt.NewVisitor {
+				req.AddCookie(&http.Cookie{Name: "sh_vid", Value: "f00d"})
+			}
+			if tt.InputLinkToken != "" {
+				req.AddCookie(&http.Cookie{Name: "sh_unlock_" + tt.InputCode, Value: tt.InputLinkToken})
+			}
 
 			rec := httptest.NewRecorder()
 
@@ -259,9 +464,9 @@ func Test_UnshortenURL(t *testing.T) {
 			c.SetParamNames("code")
 			c.SetParamValues(tt.InputCode)
 
-			handler := NewHandler(&mockService)
+			handler := NewHandler(&mockService, &mockGeo, interstitial)
 
-			err := handler.UnshortenURL(c)
+			err = handler.UnshortenURL(c)
 			if err != nil {
 				e.HTTPErrorHandler(err, c)
 			}
@@ -270,11 +475,343 @@ func Test_UnshortenURL(t *testing.T) {
 
 			assert.Equal(t, tt.ExceptedURL, rec.Header().Get("Location"))
 
+			// Visitor cookie is set only once
+			setCookie := rec.Header().Get("Set-Cookie")
+			if tt.NewVisitor {
+				assert.Contains(t, setCookie, "sh_vid=")
+			} else {
+				assert.Empty(t, setCookie)
+			}
+
 			if tt.ExceptedBody != "" {
 				assert.JSONEq(t, tt.ExceptedBody, rec.Body.String())
 			}
+			if tt.ExceptedForm {
+				assert.Contains(t, rec.Body.String(), `name="password"`)
+			}
+			for _, part := range tt.ExceptedPage {
+				assert.Contains(t, rec.Body.String(), part)
+			}
 
 			mockService.AssertExpectations(t)
+			mockGeo.AssertExpectations(t)
 		})
 	}
 }
+
+func Test_VerifyLinkPassword(t *testing.T) {
+	expiresAt := time.Now().Add(15 * time.Minute)
+
+	tests := []struct {
+		Name           string
+		InputCode      string
+		InputQuery     string
+		InputPassword  string
+		ExceptedStatus int
+		ExceptedURL    string
+		ExceptedCookie string
+		ExceptedError  string
+		SetUpMocks     func(service *mockservice)
+	}{
+		{
+			Name:           "Successfully Verified",
+			InputCode:      "3a",
+			InputQuery:     "ref=newsletter",
+			InputPassword:  "s3cret",
+			ExceptedStatus: http.StatusSeeOther,
+			ExceptedURL:    "/3a?ref=newsletter",
+			ExceptedCookie: "sh_unlock_3a=token",
+			SetUpMocks: func(service *mockservice) {
+				service.On("VerifyLinkPassword", mock.Anything, "sh.some", "3a", "s3cret").
+					Return("token", expiresAt, nil).Once()
+			},
+		},
+		{
+			Name:           "Wrong password",
+			InputCode:      "3a",
+			InputPassword:  "qwerty",
+			ExceptedStatus: http.StatusForbidden,
+			ExceptedError:  "Wrong password",
+			SetUpMocks: func(service *mockservice) {
+				service.On("VerifyLinkPassword", mock.Anything, "sh.some", "3a", "qwerty").
+					Return("", time.Time{}, &models.HTTPError{
+						Code:    http.StatusForbidden,
+						Message: "wrong password",
+					}).Once()
+			},
+		},
+		{
+			Name:           "Empty password",
+			InputCode:      "3a",
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedError:  "Enter the password",
+			SetUpMocks:     func(service *mockservice) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			e := echo.New()
+			e.HTTPErrorHandler = ProblemHandler
+
+			target := fmt.Sprintf("/%s", tt.InputCode)
+			if tt.InputQuery != "" {
+				target += "?" + tt.InputQuery
+			}
+			form := url.Values{"password": {tt.InputPassword}}
+			req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+			req.Host = "sh.some"
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+
+			rec := httptest.NewRecorder()
+
+			c := e.NewContext(req, rec)
+
+			c.SetParamNames("code")
+			c.SetParamValues(tt.InputCode)
+
+			handler := NewHandler(&mockService, nil, nil)
+
+			err := handler.VerifyLinkPassword(c)
+			if err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			assert.Equal(t, tt.ExceptedStatus, rec.Code)
+			assert.Equal(t, tt.ExceptedURL, rec.Header().Get("Location"))
+
+			if tt.ExceptedCookie != "" {
+				assert.Contains(t, rec.Header().Get("Set-Cookie"), tt.ExceptedCookie)
+				assert.Contains(t, rec.Header().Get("Set-Cookie"), "Path=/3a")
+			} else {
+				assert.Empty(t, rec.Header().Get("Set-Cookie"))
+			}
+			if tt.ExceptedError != "" {
+				assert.Contains(t, rec.Body.String(), tt.ExceptedError)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_ListLinks(t *testing.T) {
+	enabled := true
+
+	tests := []struct {
+		Name           string
+		Query          string
+		ExceptedStatus int
+		ExceptedBody   string
+		SetUpMocks     func(service *mockservice)
+	}{
+		{
+			Name:           "Successfully Listed",
+			Query:          "?domain=go.some&created_from=2030-01-01T10:00:00Z&enabled=true&q=go.dev&tag=go&tag=docs&limit=10&cursor=NjI",
+			ExceptedStatus: http.StatusOK,
+			ExceptedBody: `{
+				"links": [{
+					"short_url": "https://go.some/3a",
+					"code": "3a",
+					"domain": "go.some",
+					"original_url": "https://go.dev",
+					"owner": "telegram:1",
+					"created_at": "2030-01-01T11:00:00Z",
+					"password_protected": false,
+					"enabled": true,
+					"title": "Go",
+					"tags": ["docs", "go"]
+				}],
+				"next_cursor": "NjE"
+			}`,
+			SetUpMocks: func(service *mockservice) {
+				service.On("ListLinks", mock.Anything, models.LinkFilter{
+					Owner:       "telegram:1",
+					Domain:      "go.some",
+					CreatedFrom: time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC),
+					Enabled:     &enabled,
+					Search:      "go.dev",
+					Tags:        []string{"go", "docs"},
+				}, "NjI", 10).
+					Return([]models.Link{
+						{
+							ShortURL:    "https://go.some/3a",
+							Code:        "3a",
+							Domain:      "go.some",
+							OriginalURL: "https://go.dev",
+							Owner:       "telegram:1",
+							CreatedAt:   time.Date(2030, 1, 1, 11, 0, 0, 0, time.UTC),
+							Enabled:     true,
+							Title:       "Go",
+							Tags:        []string{"docs", "go"},
+						},
+					}, "NjE", nil).Once()
+			},
+		},
+		{
+			Name:           "No links",
+			ExceptedStatus: http.StatusOK,
+			ExceptedBody:   `{ "links": [] }`,
+			SetUpMocks: func(service *mockservice) {
+				service.On("ListLinks", mock.Anything, models.LinkFilter{Owner: "telegram:1"}, "", 0).
+					Return([]models.Link{}, "", nil).Once()
+			},
+		},
+		{
+			Name:           "Bad created time",
+			Query:          "?created_to=yesterday",
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody:   problemBody(http.StatusBadRequest, "bad_request", "bad created_to", "/api/links"),
+			SetUpMocks:     func(service *mockservice) {},
+		},
+		{
+			Name:           "Bad enabled",
+			Query:          "?enabled=maybe",
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody:   problemBody(http.StatusBadRequest, "bad_request", "bad enabled", "/api/links"),
+			SetUpMocks:     func(service *mockservice) {},
+		},
+		{
+			Name:           "Bad limit",
+			Query:          "?limit=-1",
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody:   problemBody(http.StatusBadRequest, "bad_request", "bad limit", "/api/links"),
+			SetUpMocks:     func(service *mockservice) {},
+		},
+		{
+			Name:           "Bad cursor",
+			Query:          "?cursor=bad",
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody:   problemBody(http.StatusBadRequest, "invalid_argument", "bad cursor", "/api/links"),
+			SetUpMocks: func(service *mockservice) {
+				service.On("ListLinks", mock.Anything, models.LinkFilter{Owner: "telegram:1"}, "bad", 0).
+					Return(nil, "", &models.HTTPError{Code: http.StatusBadRequest, Message: "bad cursor", ErrorCode: "invalid_argument"}).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			e := echo.New()
+			e.HTTPErrorHandler = ProblemHandler
+
+			req := httptest.NewRequest(http.MethodGet, "/api/links"+tt.Query, nil)
+			req = req.WithContext(auth.WithCaller(req.Context(), auth.Caller{Principal: "telegram:1", Scopes: []string{auth.ScopeManageLinks}}))
+			rec := httptest.NewRecorder()
+
+			c := e.NewContext(req, rec)
+
+			handler := NewHandler(&mockService, nil, nil)
+
+			err := handler.ListLinks(c)
+			if err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			assert.Equal(t, tt.ExceptedStatus, rec.Code)
+			assert.JSONEq(t, tt.ExceptedBody, rec.Body.String())
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+// problemBody builds the application/problem+json body of the error
+func problemBody(status int, code, detail, instance string, violations ...dto.Violation) string {
+	body, err := json.Marshal(dto.Problem{
+		Type:       problemTypeBase + code,
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     detail,
+		Instance:   instance,
+		Code:       code,
+		Violations: violations,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return string(body)
+}
+
+func Test_ShortenURLBatch_AnonymousLimit(t *testing.T) {
+	mockService := mockservice{}
+
+	e := echo.New()
+	e.HTTPErrorHandler = ProblemHandler
+
+	body := `{ "urls": [{ "url": "https://go.dev" }, { "url": "https://go.dev/doc" }, { "url": "https://go.dev/blog" }] }`
+	req := httptest.NewRequest(http.MethodPost, "/shorten/batch", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req = req.WithContext(auth.WithCaller(req.Context(), auth.Caller{Scopes: []string{auth.ScopeShorten}, MaxBatch: 2}))
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+
+	err := NewHandler(&mockService, nil, nil).ShortenURLBatch(c)
+	if err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.JSONEq(t,
+		problemBody(http.StatusForbidden, "batch_limit_exceeded", "batches without an API key are limited to 2 links", "/shorten/batch"),
+		rec.Body.String(),
+	)
+
+	mockService.AssertExpectations(t)
+}
+
+func Test_ListLinks_APIKeyOwner(t *testing.T) {
+	mockService := mockservice{}
+	mockService.On("ListLinks", mock.Anything, models.LinkFilter{Owner: "apikey:ci"}, "", 0).
+		Return([]models.Link{}, "", nil).Once()
+
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/links?owner=telegram:1", nil)
+	req = req.WithContext(auth.WithCaller(req.Context(), auth.Caller{Principal: "apikey:ci", Scopes: []string{auth.ScopeManageLinks}}))
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+
+	err := NewHandler(&mockService, nil, nil).ListLinks(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func Test_ListLinks_Anonymous(t *testing.T) {
+	mockService := mockservice{}
+
+	e := echo.New()
+	e.HTTPErrorHandler = ProblemHandler
+
+	// Anonymous callers with the scope don't list the links of others, whatever the owner param is
+	req := httptest.NewRequest(http.MethodGet, "/api/links?owner=telegram:1", nil)
+	req = req.WithContext(auth.WithCaller(req.Context(), auth.Caller{Scopes: []string{auth.ScopeManageLinks}}))
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+
+	err := NewHandler(&mockService, nil, nil).ListLinks(c)
+	if err != nil {
+		e.HTTPErrorHandler(err, c)
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.JSONEq(t,
+		problemBody(http.StatusUnauthorized, "unauthenticated", "an API key is required to list the links", "/api/links"),
+		rec.Body.String(),
+	)
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+// previewSuffix follows the code to preview the link instead of visiting it
+const previewSuffix = "+"
+
+//go:embed templates/interstitial.html
+var interstitialTemplate string
+
+// Interstitial renders the page showing the destination of the link with the policy warning
+type Interstitial struct {
+	tmpl    *template.Template
+	warning string
+}
+
+// NewInterstitial parses the page template from the file at path, the embedded template is used if path is empty.
+// The template gets interstitialPage as its data.
+func NewInterstitial(path, warning string) (*Interstitial, error) {
+	tmpl := template.New("interstitial")
+
+	var err error
+	if path == "" {
+		tmpl, err = tmpl.Parse(interstitialTemplate)
+	} else {
+		tmpl, err = template.ParseFiles(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse interstitial template: %w", err)
+	}
+
+	return &Interstitial{tmpl: tmpl, warning: warning}, nil
+}
+
+// interstitialPage is the data of the interstitial template
+type interstitialPage struct {
+	// Preview is set if the visitor asked where the link goes, otherwise the link requires the interstitial page
+	Preview bool
+
+	// Warning is the policy banner
+	Warning string
+
+	// ShortURL is set for the preview only
+	ShortURL string
+
+	// Destination is empty if the link is password-protected
+	Destination       string
+	CreatedAt         time.Time
+	PasswordProtected bool
+
+	// ContinueURL is where the continue button leads
+	ContinueURL string
+}
+
+// render responds with the page, it is never indexed or cached, so the destination is always current
+func (i *Interstitial) render(c echo.Context, page interstitialPage) error {
+	page.Warning = i.warning
+
+	var buf bytes.Buffer
+	if err := i.tmpl.Execute(&buf, page); err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("X-Robots-Tag", "noindex")
+	return c.HTMLBlob(http.StatusOK, buf.Bytes())
+}
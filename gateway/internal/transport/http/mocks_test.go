@@ -6,6 +6,8 @@ package http
 
 import (
 	"context"
+	"net"
+	"time"
 
 	"github.com/misshanya/url-shortener/gateway/internal/models"
 	mock "github.com/stretchr/testify/mock"
@@ -38,9 +40,553 @@ func (_m *mockservice) EXPECT() *mockservice_Expecter {
 	return &mockservice_Expecter{mock: &_m.Mock}
 }
 
+// AuthenticateAPIKey provides a mock function for the type mockservice
+func (_mock *mockservice) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, *models.HTTPError) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AuthenticateAPIKey")
+	}
+
+	var r0 *models.APIKey
+	var r1 *models.HTTPError
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.APIKey, *models.HTTPError)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.APIKey); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.APIKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) *models.HTTPError); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*models.HTTPError)
+		}
+	}
+	return r0, r1
+}
+
+// mockservice_AuthenticateAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AuthenticateAPIKey'
+type mockservice_AuthenticateAPIKey_Call struct {
+	*mock.Call
+}
+
+// AuthenticateAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *mockservice_Expecter) AuthenticateAPIKey(ctx interface{}, key interface{}) *mockservice_AuthenticateAPIKey_Call {
+	return &mockservice_AuthenticateAPIKey_Call{Call: _e.mock.On("AuthenticateAPIKey", ctx, key)}
+}
+
+func (_c *mockservice_AuthenticateAPIKey_Call) Run(run func(ctx context.Context, key string)) *mockservice_AuthenticateAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_AuthenticateAPIKey_Call) Return(aPIKey *models.APIKey, hTTPError *models.HTTPError) *mockservice_AuthenticateAPIKey_Call {
+	_c.Call.Return(aPIKey, hTTPError)
+	return _c
+}
+
+func (_c *mockservice_AuthenticateAPIKey_Call) RunAndReturn(run func(ctx context.Context, key string) (*models.APIKey, *models.HTTPError)) *mockservice_AuthenticateAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateAPIKey provides a mock function for the type mockservice
+func (_mock *mockservice) CreateAPIKey(ctx context.Context, principal string, name string, scopes []string) (*models.APIKey, string, *models.HTTPError) {
+	ret := _mock.Called(ctx, principal, name, scopes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAPIKey")
+	}
+
+	var r0 *models.APIKey
+	var r1 string
+	var r2 *models.HTTPError
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []string) (*models.APIKey, string, *models.HTTPError)); ok {
+		return returnFunc(ctx, principal, name, scopes)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []string) *models.APIKey); ok {
+		r0 = returnFunc(ctx, principal, name, scopes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.APIKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, []string) string); ok {
+		r1 = returnFunc(ctx, principal, name, scopes)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, string, []string) *models.HTTPError); ok {
+		r2 = returnFunc(ctx, principal, name, scopes)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(*models.HTTPError)
+		}
+	}
+	return r0, r1, r2
+}
+
+// mockservice_CreateAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateAPIKey'
+type mockservice_CreateAPIKey_Call struct {
+	*mock.Call
+}
+
+// CreateAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - principal string
+//   - name string
+//   - scopes []string
+func (_e *mockservice_Expecter) CreateAPIKey(ctx interface{}, principal interface{}, name interface{}, scopes interface{}) *mockservice_CreateAPIKey_Call {
+	return &mockservice_CreateAPIKey_Call{Call: _e.mock.On("CreateAPIKey", ctx, principal, name, scopes)}
+}
+
+func (_c *mockservice_CreateAPIKey_Call) Run(run func(ctx context.Context, principal string, name string, scopes []string)) *mockservice_CreateAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []string
+		if args[3] != nil {
+			arg3 = args[3].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_CreateAPIKey_Call) Return(aPIKey *models.APIKey, s string, hTTPError *models.HTTPError) *mockservice_CreateAPIKey_Call {
+	_c.Call.Return(aPIKey, s, hTTPError)
+	return _c
+}
+
+func (_c *mockservice_CreateAPIKey_Call) RunAndReturn(run func(ctx context.Context, principal string, name string, scopes []string) (*models.APIKey, string, *models.HTTPError)) *mockservice_CreateAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAPIKeys provides a mock function for the type mockservice
+func (_mock *mockservice) ListAPIKeys(ctx context.Context) ([]models.APIKey, *models.HTTPError) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAPIKeys")
+	}
+
+	var r0 []models.APIKey
+	var r1 *models.HTTPError
+	if returnFunc, ok := ret.Get(0).(func(context.Context) ([]models.APIKey, *models.HTTPError)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) []models.APIKey); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.APIKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) *models.HTTPError); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*models.HTTPError)
+		}
+	}
+	return r0, r1
+}
+
+// mockservice_ListAPIKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAPIKeys'
+type mockservice_ListAPIKeys_Call struct {
+	*mock.Call
+}
+
+// ListAPIKeys is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *mockservice_Expecter) ListAPIKeys(ctx interface{}) *mockservice_ListAPIKeys_Call {
+	return &mockservice_ListAPIKeys_Call{Call: _e.mock.On("ListAPIKeys", ctx)}
+}
+
+func (_c *mockservice_ListAPIKeys_Call) Run(run func(ctx context.Context)) *mockservice_ListAPIKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_ListAPIKeys_Call) Return(aPIKeys []models.APIKey, hTTPError *models.HTTPError) *mockservice_ListAPIKeys_Call {
+	_c.Call.Return(aPIKeys, hTTPError)
+	return _c
+}
+
+func (_c *mockservice_ListAPIKeys_Call) RunAndReturn(run func(ctx context.Context) ([]models.APIKey, *models.HTTPError)) *mockservice_ListAPIKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAuditEvents provides a mock function for the type mockservice
+func (_mock *mockservice) ListAuditEvents(ctx context.Context, filter models.AuditFilter, cursor string, limit int) ([]models.AuditEvent, string, *models.HTTPError) {
+	ret := _mock.Called(ctx, filter, cursor, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAuditEvents")
+	}
+
+	var r0 []models.AuditEvent
+	var r1 string
+	var r2 *models.HTTPError
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.AuditFilter, string, int) ([]models.AuditEvent, string, *models.HTTPError)); ok {
+		return returnFunc(ctx, filter, cursor, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.AuditFilter, string, int) []models.AuditEvent); ok {
+		r0 = returnFunc(ctx, filter, cursor, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AuditEvent)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, models.AuditFilter, string, int) string); ok {
+		r1 = returnFunc(ctx, filter, cursor, limit)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, models.AuditFilter, string, int) *models.HTTPError); ok {
+		r2 = returnFunc(ctx, filter, cursor, limit)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(*models.HTTPError)
+		}
+	}
+	return r0, r1, r2
+}
+
+// mockservice_ListAuditEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAuditEvents'
+type mockservice_ListAuditEvents_Call struct {
+	*mock.Call
+}
+
+// ListAuditEvents is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter models.AuditFilter
+//   - cursor string
+//   - limit int
+func (_e *mockservice_Expecter) ListAuditEvents(ctx interface{}, filter interface{}, cursor interface{}, limit interface{}) *mockservice_ListAuditEvents_Call {
+	return &mockservice_ListAuditEvents_Call{Call: _e.mock.On("ListAuditEvents", ctx, filter, cursor, limit)}
+}
+
+func (_c *mockservice_ListAuditEvents_Call) Run(run func(ctx context.Context, filter models.AuditFilter, cursor string, limit int)) *mockservice_ListAuditEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 models.AuditFilter
+		if args[1] != nil {
+			arg1 = args[1].(models.AuditFilter)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_ListAuditEvents_Call) Return(auditEvents []models.AuditEvent, s string, hTTPError *models.HTTPError) *mockservice_ListAuditEvents_Call {
+	_c.Call.Return(auditEvents, s, hTTPError)
+	return _c
+}
+
+func (_c *mockservice_ListAuditEvents_Call) RunAndReturn(run func(ctx context.Context, filter models.AuditFilter, cursor string, limit int) ([]models.AuditEvent, string, *models.HTTPError)) *mockservice_ListAuditEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListLinks provides a mock function for the type mockservice
+func (_mock *mockservice) ListLinks(ctx context.Context, filter models.LinkFilter, cursor string, limit int) ([]models.Link, string, *models.HTTPError) {
+	ret := _mock.Called(ctx, filter, cursor, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListLinks")
+	}
+
+	var r0 []models.Link
+	var r1 string
+	var r2 *models.HTTPError
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.LinkFilter, string, int) ([]models.Link, string, *models.HTTPError)); ok {
+		return returnFunc(ctx, filter, cursor, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.LinkFilter, string, int) []models.Link); ok {
+		r0 = returnFunc(ctx, filter, cursor, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Link)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, models.LinkFilter, string, int) string); ok {
+		r1 = returnFunc(ctx, filter, cursor, limit)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, models.LinkFilter, string, int) *models.HTTPError); ok {
+		r2 = returnFunc(ctx, filter, cursor, limit)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(*models.HTTPError)
+		}
+	}
+	return r0, r1, r2
+}
+
+// mockservice_ListLinks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListLinks'
+type mockservice_ListLinks_Call struct {
+	*mock.Call
+}
+
+// ListLinks is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter models.LinkFilter
+//   - cursor string
+//   - limit int
+func (_e *mockservice_Expecter) ListLinks(ctx interface{}, filter interface{}, cursor interface{}, limit interface{}) *mockservice_ListLinks_Call {
+	return &mockservice_ListLinks_Call{Call: _e.mock.On("ListLinks", ctx, filter, cursor, limit)}
+}
+
+func (_c *mockservice_ListLinks_Call) Run(run func(ctx context.Context, filter models.LinkFilter, cursor string, limit int)) *mockservice_ListLinks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 models.LinkFilter
+		if args[1] != nil {
+			arg1 = args[1].(models.LinkFilter)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_ListLinks_Call) Return(links []models.Link, s string, hTTPError *models.HTTPError) *mockservice_ListLinks_Call {
+	_c.Call.Return(links, s, hTTPError)
+	return _c
+}
+
+func (_c *mockservice_ListLinks_Call) RunAndReturn(run func(ctx context.Context, filter models.LinkFilter, cursor string, limit int) ([]models.Link, string, *models.HTTPError)) *mockservice_ListLinks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PreviewURL provides a mock function for the type mockservice
+func (_mock *mockservice) PreviewURL(ctx context.Context, host string, code string) (*models.LinkPreview, *models.HTTPError) {
+	ret := _mock.Called(ctx, host, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PreviewURL")
+	}
+
+	var r0 *models.LinkPreview
+	var r1 *models.HTTPError
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.LinkPreview, *models.HTTPError)); ok {
+		return returnFunc(ctx, host, code)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.LinkPreview); ok {
+		r0 = returnFunc(ctx, host, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.LinkPreview)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) *models.HTTPError); ok {
+		r1 = returnFunc(ctx, host, code)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*models.HTTPError)
+		}
+	}
+	return r0, r1
+}
+
+// mockservice_PreviewURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PreviewURL'
+type mockservice_PreviewURL_Call struct {
+	*mock.Call
+}
+
+// PreviewURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - code string
+func (_e *mockservice_Expecter) PreviewURL(ctx interface{}, host interface{}, code interface{}) *mockservice_PreviewURL_Call {
+	return &mockservice_PreviewURL_Call{Call: _e.mock.On("PreviewURL", ctx, host, code)}
+}
+
+func (_c *mockservice_PreviewURL_Call) Run(run func(ctx context.Context, host string, code string)) *mockservice_PreviewURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_PreviewURL_Call) Return(linkPreview *models.LinkPreview, hTTPError *models.HTTPError) *mockservice_PreviewURL_Call {
+	_c.Call.Return(linkPreview, hTTPError)
+	return _c
+}
+
+func (_c *mockservice_PreviewURL_Call) RunAndReturn(run func(ctx context.Context, host string, code string) (*models.LinkPreview, *models.HTTPError)) *mockservice_PreviewURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeAPIKey provides a mock function for the type mockservice
+func (_mock *mockservice) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, *models.HTTPError) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 *models.APIKey
+	var r1 *models.HTTPError
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) (*models.APIKey, *models.HTTPError)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) *models.APIKey); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.APIKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int64) *models.HTTPError); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*models.HTTPError)
+		}
+	}
+	return r0, r1
+}
+
+// mockservice_RevokeAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeAPIKey'
+type mockservice_RevokeAPIKey_Call struct {
+	*mock.Call
+}
+
+// RevokeAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *mockservice_Expecter) RevokeAPIKey(ctx interface{}, id interface{}) *mockservice_RevokeAPIKey_Call {
+	return &mockservice_RevokeAPIKey_Call{Call: _e.mock.On("RevokeAPIKey", ctx, id)}
+}
+
+func (_c *mockservice_RevokeAPIKey_Call) Run(run func(ctx context.Context, id int64)) *mockservice_RevokeAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_RevokeAPIKey_Call) Return(aPIKey *models.APIKey, hTTPError *models.HTTPError) *mockservice_RevokeAPIKey_Call {
+	_c.Call.Return(aPIKey, hTTPError)
+	return _c
+}
+
+func (_c *mockservice_RevokeAPIKey_Call) RunAndReturn(run func(ctx context.Context, id int64) (*models.APIKey, *models.HTTPError)) *mockservice_RevokeAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ShortenURL provides a mock function for the type mockservice
-func (_mock *mockservice) ShortenURL(ctx context.Context, url string) (string, *models.HTTPError) {
-	ret := _mock.Called(ctx, url)
+func (_mock *mockservice) ShortenURL(ctx context.Context, url string, options models.LinkOptions) (string, *models.HTTPError) {
+	ret := _mock.Called(ctx, url, options)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ShortenURL")
@@ -48,16 +594,16 @@ func (_mock *mockservice) ShortenURL(ctx context.Context, url string) (string, *
 
 	var r0 string
 	var r1 *models.HTTPError
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, *models.HTTPError)); ok {
-		return returnFunc(ctx, url)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, models.LinkOptions) (string, *models.HTTPError)); ok {
+		return returnFunc(ctx, url, options)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = returnFunc(ctx, url)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, models.LinkOptions) string); ok {
+		r0 = returnFunc(ctx, url, options)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) *models.HTTPError); ok {
-		r1 = returnFunc(ctx, url)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, models.LinkOptions) *models.HTTPError); ok {
+		r1 = returnFunc(ctx, url, options)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*models.HTTPError)
@@ -74,11 +620,12 @@ type mockservice_ShortenURL_Call struct {
 // ShortenURL is a helper method to define mock.On call
 //   - ctx context.Context
 //   - url string
-func (_e *mockservice_Expecter) ShortenURL(ctx interface{}, url interface{}) *mockservice_ShortenURL_Call {
-	return &mockservice_ShortenURL_Call{Call: _e.mock.On("ShortenURL", ctx, url)}
+//   - options models.LinkOptions
+func (_e *mockservice_Expecter) ShortenURL(ctx interface{}, url interface{}, options interface{}) *mockservice_ShortenURL_Call {
+	return &mockservice_ShortenURL_Call{Call: _e.mock.On("ShortenURL", ctx, url, options)}
 }
 
-func (_c *mockservice_ShortenURL_Call) Run(run func(ctx context.Context, url string)) *mockservice_ShortenURL_Call {
+func (_c *mockservice_ShortenURL_Call) Run(run func(ctx context.Context, url string, options models.LinkOptions)) *mockservice_ShortenURL_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -88,9 +635,14 @@ func (_c *mockservice_ShortenURL_Call) Run(run func(ctx context.Context, url str
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 models.LinkOptions
+		if args[2] != nil {
+			arg2 = args[2].(models.LinkOptions)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
@@ -101,7 +653,7 @@ func (_c *mockservice_ShortenURL_Call) Return(s string, hTTPError *models.HTTPEr
 	return _c
 }
 
-func (_c *mockservice_ShortenURL_Call) RunAndReturn(run func(ctx context.Context, url string) (string, *models.HTTPError)) *mockservice_ShortenURL_Call {
+func (_c *mockservice_ShortenURL_Call) RunAndReturn(run func(ctx context.Context, url string, options models.LinkOptions) (string, *models.HTTPError)) *mockservice_ShortenURL_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -166,25 +718,27 @@ func (_c *mockservice_ShortenURLBatch_Call) RunAndReturn(run func(ctx context.Co
 }
 
 // UnshortenURL provides a mock function for the type mockservice
-func (_mock *mockservice) UnshortenURL(ctx context.Context, code string) (string, *models.HTTPError) {
-	ret := _mock.Called(ctx, code)
+func (_mock *mockservice) UnshortenURL(ctx context.Context, code string, visit models.Visit) (*models.Redirect, *models.HTTPError) {
+	ret := _mock.Called(ctx, code, visit)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UnshortenURL")
 	}
 
-	var r0 string
+	var r0 *models.Redirect
 	var r1 *models.HTTPError
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, *models.HTTPError)); ok {
-		return returnFunc(ctx, code)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, models.Visit) (*models.Redirect, *models.HTTPError)); ok {
+		return returnFunc(ctx, code, visit)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = returnFunc(ctx, code)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, models.Visit) *models.Redirect); ok {
+		r0 = returnFunc(ctx, code, visit)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Redirect)
+		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) *models.HTTPError); ok {
-		r1 = returnFunc(ctx, code)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, models.Visit) *models.HTTPError); ok {
+		r1 = returnFunc(ctx, code, visit)
 	} else {
 		if ret.Get(1) != nil {
 			r1 = ret.Get(1).(*models.HTTPError)
@@ -201,11 +755,12 @@ type mockservice_UnshortenURL_Call struct {
 // UnshortenURL is a helper method to define mock.On call
 //   - ctx context.Context
 //   - code string
-func (_e *mockservice_Expecter) UnshortenURL(ctx interface{}, code interface{}) *mockservice_UnshortenURL_Call {
-	return &mockservice_UnshortenURL_Call{Call: _e.mock.On("UnshortenURL", ctx, code)}
+//   - visit models.Visit
+func (_e *mockservice_Expecter) UnshortenURL(ctx interface{}, code interface{}, visit interface{}) *mockservice_UnshortenURL_Call {
+	return &mockservice_UnshortenURL_Call{Call: _e.mock.On("UnshortenURL", ctx, code, visit)}
 }
 
-func (_c *mockservice_UnshortenURL_Call) Run(run func(ctx context.Context, code string)) *mockservice_UnshortenURL_Call {
+func (_c *mockservice_UnshortenURL_Call) Run(run func(ctx context.Context, code string, visit models.Visit)) *mockservice_UnshortenURL_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -215,20 +770,189 @@ func (_c *mockservice_UnshortenURL_Call) Run(run func(ctx context.Context, code
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 models.Visit
+		if args[2] != nil {
+			arg2 = args[2].(models.Visit)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *mockservice_UnshortenURL_Call) Return(s string, hTTPError *models.HTTPError) *mockservice_UnshortenURL_Call {
-	_c.Call.Return(s, hTTPError)
+func (_c *mockservice_UnshortenURL_Call) Return(redirect *models.Redirect, hTTPError *models.HTTPError) *mockservice_UnshortenURL_Call {
+	_c.Call.Return(redirect, hTTPError)
+	return _c
+}
+
+func (_c *mockservice_UnshortenURL_Call) RunAndReturn(run func(ctx context.Context, code string, visit models.Visit) (*models.Redirect, *models.HTTPError)) *mockservice_UnshortenURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// VerifyLinkPassword provides a mock function for the type mockservice
+func (_mock *mockservice) VerifyLinkPassword(ctx context.Context, host string, code string, password string) (string, time.Time, *models.HTTPError) {
+	ret := _mock.Called(ctx, host, code, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyLinkPassword")
+	}
+
+	var r0 string
+	var r1 time.Time
+	var r2 *models.HTTPError
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (string, time.Time, *models.HTTPError)); ok {
+		return returnFunc(ctx, host, code, password)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) string); ok {
+		r0 = returnFunc(ctx, host, code, password)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) time.Time); ok {
+		r1 = returnFunc(ctx, host, code, password)
+	} else {
+		r1 = ret.Get(1).(time.Time)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, string, string) *models.HTTPError); ok {
+		r2 = returnFunc(ctx, host, code, password)
+	} else {
+		if ret.Get(2) != nil {
+			r2 = ret.Get(2).(*models.HTTPError)
+		}
+	}
+	return r0, r1, r2
+}
+
+// mockservice_VerifyLinkPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'VerifyLinkPassword'
+type mockservice_VerifyLinkPassword_Call struct {
+	*mock.Call
+}
+
+// VerifyLinkPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - code string
+//   - password string
+func (_e *mockservice_Expecter) VerifyLinkPassword(ctx interface{}, host interface{}, code interface{}, password interface{}) *mockservice_VerifyLinkPassword_Call {
+	return &mockservice_VerifyLinkPassword_Call{Call: _e.mock.On("VerifyLinkPassword", ctx, host, code, password)}
+}
+
+func (_c *mockservice_VerifyLinkPassword_Call) Run(run func(ctx context.Context, host string, code string, password string)) *mockservice_VerifyLinkPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_VerifyLinkPassword_Call) Return(s string, time1 time.Time, hTTPError *models.HTTPError) *mockservice_VerifyLinkPassword_Call {
+	_c.Call.Return(s, time1, hTTPError)
+	return _c
+}
+
+func (_c *mockservice_VerifyLinkPassword_Call) RunAndReturn(run func(ctx context.Context, host string, code string, password string) (string, time.Time, *models.HTTPError)) *mockservice_VerifyLinkPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockgeoResolver creates a new instance of mockgeoResolver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockgeoResolver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockgeoResolver {
+	mock := &mockgeoResolver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockgeoResolver is an autogenerated mock type for the geoResolver type
+type mockgeoResolver struct {
+	mock.Mock
+}
+
+type mockgeoResolver_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockgeoResolver) EXPECT() *mockgeoResolver_Expecter {
+	return &mockgeoResolver_Expecter{mock: &_m.Mock}
+}
+
+// Country provides a mock function for the type mockgeoResolver
+func (_mock *mockgeoResolver) Country(ip net.IP) string {
+	ret := _mock.Called(ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Country")
+	}
+
+	var r0 string
+	if returnFunc, ok := ret.Get(0).(func(net.IP) string); ok {
+		r0 = returnFunc(ip)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	return r0
+}
+
+// mockgeoResolver_Country_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Country'
+type mockgeoResolver_Country_Call struct {
+	*mock.Call
+}
+
+// Country is a helper method to define mock.On call
+//   - ip net.IP
+func (_e *mockgeoResolver_Expecter) Country(ip interface{}) *mockgeoResolver_Country_Call {
+	return &mockgeoResolver_Country_Call{Call: _e.mock.On("Country", ip)}
+}
+
+func (_c *mockgeoResolver_Country_Call) Run(run func(ip net.IP)) *mockgeoResolver_Country_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 net.IP
+		if args[0] != nil {
+			arg0 = args[0].(net.IP)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *mockgeoResolver_Country_Call) Return(s string) *mockgeoResolver_Country_Call {
+	_c.Call.Return(s)
 	return _c
 }
 
-func (_c *mockservice_UnshortenURL_Call) RunAndReturn(run func(ctx context.Context, code string) (string, *models.HTTPError)) *mockservice_UnshortenURL_Call {
+func (_c *mockgeoResolver_Country_Call) RunAndReturn(run func(ip net.IP) string) *mockgeoResolver_Country_Call {
 	_c.Call.Return(run)
 	return _c
 }
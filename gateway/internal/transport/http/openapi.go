@@ -0,0 +1,146 @@
+package http
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"net/http"
+	"strings"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// OpenAPI serves the specification of the gateway and validates the request bodies against it
+type OpenAPI struct {
+	doc *openapi3.T
+}
+
+// NewOpenAPI loads and validates the embedded specification
+func NewOpenAPI() (*OpenAPI, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load openapi spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi spec: %w", err)
+	}
+	return &OpenAPI{doc: doc}, nil
+}
+
+// Spec serves the specification as is
+func (o *OpenAPI) Spec(c echo.Context) error {
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, openAPISpec)
+}
+
+// Validator checks JSON request bodies of the documented operations against their schema.
+// Mismatches are rejected with 400 listing every invalid field, so handlers get well-formed bodies only.
+// Query params and non-JSON bodies are left to the handlers.
+func (o *OpenAPI) Validator() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			route := o.route(c)
+			if route == nil {
+				return next(c)
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request: c.Request(),
+				Route:   route,
+				Options: &openapi3filter.Options{
+					MultiError:          true,
+					SkipSettingDefaults: true,
+				},
+			}
+			if err := openapi3filter.ValidateRequestBody(c.Request().Context(), input, route.Operation.RequestBody.Value); err != nil {
+				return validationError(err)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// route finds the operation of the matched echo route, it is nil if the operation takes no JSON body
+func (o *OpenAPI) route(c echo.Context) *routers.Route {
+	// Echo path params are :name, OpenAPI ones are {name}
+	segments := strings.Split(c.Path(), "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	path := strings.Join(segments, "/")
+
+	pathItem := o.doc.Paths.Find(path)
+	if pathItem == nil {
+		return nil
+	}
+	operation := pathItem.GetOperation(c.Request().Method)
+	if operation == nil || operation.RequestBody == nil || operation.RequestBody.Value.Content.Get(echo.MIMEApplicationJSON) == nil {
+		return nil
+	}
+
+	return &routers.Route{
+		Spec:      o.doc,
+		Path:      path,
+		PathItem:  pathItem,
+		Method:    c.Request().Method,
+		Operation: operation,
+	}
+}
+
+// validationError maps the validation error into the error listing the invalid fields
+func validationError(err error) *models.HTTPError {
+	httpErr := &models.HTTPError{
+		Code:      http.StatusBadRequest,
+		Message:   "invalid request body",
+		ErrorCode: "invalid_body",
+	}
+
+	var reqErr *openapi3filter.RequestError
+	var parseErr *openapi3filter.ParseError
+	var multiErr openapi3.MultiError
+	var schemaErr *openapi3.SchemaError
+	switch {
+	case !errors.As(err, &reqErr):
+		httpErr.Message = err.Error()
+	case errors.Is(reqErr.Err, openapi3filter.ErrInvalidRequired):
+		httpErr.Message = "request body is required"
+	case errors.As(reqErr.Err, &parseErr):
+		httpErr.Message = "request body is not valid JSON"
+	case errors.As(reqErr.Err, &multiErr), errors.As(reqErr.Err, &schemaErr):
+		httpErr.Violations = violations(reqErr.Err)
+	case reqErr.Reason != "":
+		// The body has another content type
+		httpErr.Message = reqErr.Reason
+	}
+	return httpErr
+}
+
+// violations flattens the schema errors into violations, the field is the dotted path of the value
+func violations(err error) []models.Violation {
+	var multiErr openapi3.MultiError
+	if errors.As(err, &multiErr) {
+		var fields []models.Violation
+		for _, err := range multiErr {
+			fields = append(fields, violations(err)...)
+		}
+		return fields
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		return []models.Violation{{Message: err.Error()}}
+	}
+	return []models.Violation{{
+		Field:   strings.Join(schemaErr.JSONPointer(), "."),
+		Message: schemaErr.Reason,
+	}}
+}
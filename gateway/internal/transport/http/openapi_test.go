@@ -0,0 +1,166 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/transport/http/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_OpenAPIValidator(t *testing.T) {
+	openAPI, err := NewOpenAPI()
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name           string
+		Method         string
+		Path           string
+		ContentType    string
+		RequestBody    string
+		ExceptedStatus int
+		ExceptedBody   string
+	}{
+		{
+			Name:           "Valid body",
+			Method:         http.MethodPost,
+			Path:           "/shorten",
+			ContentType:    echo.MIMEApplicationJSON,
+			RequestBody:    `{"url": "https://go.dev", "alias": "go-dev", "expires_at": "2030-01-01T10:00:00Z"}`,
+			ExceptedStatus: http.StatusOK,
+			ExceptedBody:   `{"url": "https://go.dev", "alias": "go-dev", "expires_at": "2030-01-01T10:00:00Z"}`,
+		},
+		{
+			Name:           "Invalid fields",
+			Method:         http.MethodPost,
+			Path:           "/shorten",
+			ContentType:    echo.MIMEApplicationJSON,
+			RequestBody:    `{"url": 1, "alias": "a", "destinations": [{"url": "https://go.dev", "weight": -1}]}`,
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody: problemBody(http.StatusBadRequest, "invalid_body", "invalid request body", "/shorten",
+				dto.Violation{Field: "alias", Message: `string doesn't match the regular expression "^[A-Za-z0-9_-]{3,64}$"`},
+				dto.Violation{Field: "destinations.0.weight", Message: "number must be at least 0"},
+				dto.Violation{Field: "url", Message: "value must be a string"},
+			),
+		},
+		{
+			Name:           "Missing nested field",
+			Method:         http.MethodPost,
+			Path:           "/shorten/batch",
+			ContentType:    echo.MIMEApplicationJSON,
+			RequestBody:    `{"urls": [{"url": "https://go.dev"}, {"title": "no url"}]}`,
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody: problemBody(http.StatusBadRequest, "invalid_body", "invalid request body", "/shorten/batch",
+				dto.Violation{Field: "urls.1.url", Message: `property "url" is missing`},
+			),
+		},
+		{
+			Name:           "Not JSON",
+			Method:         http.MethodPost,
+			Path:           "/shorten/batch",
+			ContentType:    echo.MIMEApplicationJSON,
+			RequestBody:    `{"urls": [`,
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody:   problemBody(http.StatusBadRequest, "invalid_body", "request body is not valid JSON", "/shorten/batch"),
+		},
+		{
+			Name:           "Form is not validated",
+			Method:         http.MethodPost,
+			Path:           "/3a",
+			ContentType:    echo.MIMEApplicationForm,
+			RequestBody:    "password=secret",
+			ExceptedStatus: http.StatusOK,
+			ExceptedBody:   `"password=secret"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			echoBody := func(c echo.Context) error {
+				body, err := io.ReadAll(c.Request().Body)
+				if err != nil {
+					return err
+				}
+				return c.JSONBlob(http.StatusOK, body)
+			}
+			formBody := func(c echo.Context) error {
+				body, err := io.ReadAll(c.Request().Body)
+				if err != nil {
+					return err
+				}
+				return c.JSON(http.StatusOK, string(body))
+			}
+
+			e := echo.New()
+			e.HTTPErrorHandler = ProblemHandler
+			e.Use(openAPI.Validator())
+			e.POST("/shorten", echoBody)
+			e.POST("/shorten/batch", echoBody)
+			e.POST("/:code", formBody)
+
+			req := httptest.NewRequest(tt.Method, tt.Path, strings.NewReader(tt.RequestBody))
+			req.Header.Set(echo.HeaderContentType, tt.ContentType)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.ExceptedStatus, rec.Code)
+			assert.JSONEq(t, tt.ExceptedBody, rec.Body.String())
+		})
+	}
+}
+
+func Test_OpenAPIDocs(t *testing.T) {
+	openAPI, err := NewOpenAPI()
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.GET("/openapi.json", openAPI.Spec)
+	e.GET("/docs", openAPI.Docs)
+	e.GET("/docs/:file", openAPI.DocsAsset)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := serve("/openapi.json")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var spec struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+	for path, method := range map[string]string{
+		"/shorten":         "post",
+		"/shorten/batch":   "post",
+		"/api/links":       "get",
+		"/api/audit":       "get",
+		"/api/admin/audit": "get",
+		"/{code}":          "get",
+		"/{code}/qr":       "get",
+		"/":                "get",
+		"/ui.css":          "get",
+		"/ui.js":           "get",
+	} {
+		assert.Contains(t, spec.Paths[path], method, path)
+	}
+
+	rec = serve("/docs")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `url: "/openapi.json"`)
+
+	rec = serve("/docs/swagger-ui-bundle.js")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/javascript; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+	assert.NotEmpty(t, rec.Body.Bytes())
+
+	rec = serve("/docs/unknown.js")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"bytes"
+	_ "embed"
+	"github.com/labstack/echo/v4"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+const linkTokenCookiePrefix = "sh_unlock_"
+
+//go:embed templates/password.html
+var passwordPage string
+
+var passwordTemplate = template.Must(template.New("password").Parse(passwordPage))
+
+// renderPasswordForm responds with the password form of the protected link
+func renderPasswordForm(c echo.Context, status int, errMessage string) error {
+	var buf bytes.Buffer
+	if err := passwordTemplate.Execute(&buf, struct{ Error string }{Error: errMessage}); err != nil {
+		return err
+	}
+	return c.HTMLBlob(status, buf.Bytes())
+}
+
+// linkToken returns the token unlocking the link from the cookie
+func linkToken(c echo.Context, code string) string {
+	cookie, err := c.Cookie(linkTokenCookiePrefix + code)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// setLinkToken stores the token unlocking the link in the cookie sent only for this link
+func setLinkToken(c echo.Context, code, token string, expiresAt time.Time) {
+	c.SetCookie(&http.Cookie{
+		Name:     linkTokenCookiePrefix + code,
+		Value:    token,
+		Path:     "/" + code,
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"github.com/misshanya/url-shortener/gateway/internal/transport/http/dto"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MIMEApplicationProblemJSON is the content type of the error responses
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// problemTypeBase is followed by the error code to build the type URI of the problem,
+// the codes are described in docs/problems.md
+const problemTypeBase = "https://github.com/misshanya/url-shortener/blob/main/docs/problems.md#"
+
+// ProblemHandler renders every error as application/problem+json.
+// Errors other than models.HTTPError and echo.HTTPError are internal, their messages never reach the client.
+func ProblemHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	httpErr := problemError(err)
+
+	title := http.StatusText(httpErr.Code)
+	if title == "" {
+		title = "Error"
+	}
+	code := httpErr.ErrorCode
+	if code == "" {
+		code = strings.ReplaceAll(strings.ToLower(title), " ", "_")
+	}
+
+	problem := &dto.Problem{
+		Type:      problemTypeBase + code,
+		Title:     title,
+		Status:    httpErr.Code,
+		Instance:  c.Request().URL.Path,
+		Code:      code,
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+	}
+	if httpErr.Message != title {
+		problem.Detail = httpErr.Message
+	}
+	for _, v := range httpErr.Violations {
+		problem.Violations = append(problem.Violations, dto.Violation(v))
+	}
+
+	if httpErr.RetryAfter > 0 {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(ceilSeconds(httpErr.RetryAfter)))
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(httpErr.Code)
+	} else {
+		var body []byte
+		body, err = json.Marshal(problem)
+		if err == nil {
+			err = c.Blob(httpErr.Code, MIMEApplicationProblemJSON, body)
+		}
+	}
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+// problemError brings the error to models.HTTPError
+func problemError(err error) *models.HTTPError {
+	var httpErr *models.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+
+	var echoErr *echo.HTTPError
+	if errors.As(err, &echoErr) {
+		message := http.StatusText(echoErr.Code)
+		if m, ok := echoErr.Message.(string); ok && echoErr.Code < http.StatusInternalServerError {
+			message = m
+		}
+		return &models.HTTPError{Code: echoErr.Code, Message: message}
+	}
+
+	return &models.HTTPError{
+		Code:      http.StatusInternalServerError,
+		Message:   http.StatusText(http.StatusInternalServerError),
+		ErrorCode: "internal",
+	}
+}
+
+// bindError maps the error of binding the JSON body into the error naming the invalid field
+func bindError(err error) error {
+	var echoErr *echo.HTTPError
+	if errors.As(err, &echoErr) && echoErr.Code != http.StatusBadRequest {
+		return err
+	}
+
+	httpErr := &models.HTTPError{
+		Code:      http.StatusBadRequest,
+		Message:   "invalid request body",
+		ErrorCode: "invalid_body",
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var timeErr *time.ParseError
+	switch {
+	case errors.As(err, &typeErr):
+		httpErr.Violations = []models.Violation{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be %s, got %s", jsonType(typeErr.Type), typeErr.Value),
+		}}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		httpErr.Message = "request body is not valid JSON"
+	case errors.As(err, &timeErr):
+		httpErr.Message = "times must be in RFC 3339 format"
+	}
+	return httpErr
+}
+
+// jsonType names the JSON type of the Go type
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+	return t.String()
+}
@@ -0,0 +1,182 @@
+package http
+
+import (
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"github.com/misshanya/url-shortener/gateway/internal/transport/http/dto"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_ProblemHandler(t *testing.T) {
+	tests := []struct {
+		Name               string
+		Method             string
+		InputErr           error
+		ExceptedStatus     int
+		ExceptedBody       string
+		ExceptedRetryAfter string
+	}{
+		{
+			Name:   "HTTP error with violations",
+			Method: http.MethodPost,
+			InputErr: &models.HTTPError{
+				Code:       http.StatusBadRequest,
+				Message:    "bad alias",
+				ErrorCode:  "invalid_argument",
+				Violations: []models.Violation{{Field: "alias", Message: "3-64 letters, digits, - or _"}},
+			},
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody: problemBody(http.StatusBadRequest, "invalid_argument", "bad alias", "/shorten",
+				dto.Violation{Field: "alias", Message: "3-64 letters, digits, - or _"},
+			),
+		},
+		{
+			Name:   "Retry after",
+			Method: http.MethodPost,
+			InputErr: &models.HTTPError{
+				Code:       http.StatusTooManyRequests,
+				Message:    "too many links",
+				ErrorCode:  "resource_exhausted",
+				RetryAfter: 1500 * time.Millisecond,
+			},
+			ExceptedStatus:     http.StatusTooManyRequests,
+			ExceptedBody:       problemBody(http.StatusTooManyRequests, "resource_exhausted", "too many links", "/shorten"),
+			ExceptedRetryAfter: "2",
+		},
+		{
+			Name:           "Echo error",
+			Method:         http.MethodPost,
+			InputErr:       echo.ErrMethodNotAllowed,
+			ExceptedStatus: http.StatusMethodNotAllowed,
+			ExceptedBody:   problemBody(http.StatusMethodNotAllowed, "method_not_allowed", "", "/shorten"),
+		},
+		{
+			Name:           "Echo internal error hides the message",
+			Method:         http.MethodPost,
+			InputErr:       echo.NewHTTPError(http.StatusInternalServerError, "dial tcp: connection refused"),
+			ExceptedStatus: http.StatusInternalServerError,
+			ExceptedBody:   problemBody(http.StatusInternalServerError, "internal_server_error", "", "/shorten"),
+		},
+		{
+			Name:           "Unknown error",
+			Method:         http.MethodPost,
+			InputErr:       errors.New("template: no such template"),
+			ExceptedStatus: http.StatusInternalServerError,
+			ExceptedBody:   problemBody(http.StatusInternalServerError, "internal", "", "/shorten"),
+		},
+		{
+			Name:           "HEAD has no body",
+			Method:         http.MethodHead,
+			InputErr:       &models.HTTPError{Code: http.StatusNotFound, Message: "not found", ErrorCode: "not_found"},
+			ExceptedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			e := echo.New()
+
+			req := httptest.NewRequest(tt.Method, "/shorten", nil)
+			rec := httptest.NewRecorder()
+
+			c := e.NewContext(req, rec)
+
+			ProblemHandler(tt.InputErr, c)
+
+			assert.Equal(t, tt.ExceptedStatus, rec.Code)
+			assert.Equal(t, tt.ExceptedRetryAfter, rec.Header().Get("Retry-After"))
+			if tt.ExceptedBody == "" {
+				assert.Empty(t, rec.Body.String())
+				return
+			}
+			assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+			assert.JSONEq(t, tt.ExceptedBody, rec.Body.String())
+		})
+	}
+}
+
+func Test_ProblemHandlerRequestID(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = ProblemHandler
+	e.Pre(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set(echo.HeaderXRequestID, "req-1")
+			return next(c)
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing/route", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `
+{
+  "type": "https://github.com/misshanya/url-shortener/blob/main/docs/problems.md#not_found",
+  "title": "Not Found",
+  "status": 404,
+  "instance": "/missing/route",
+  "code": "not_found",
+  "request_id": "req-1"
+}
+`, rec.Body.String())
+}
+
+func Test_BindError(t *testing.T) {
+	e := echo.New()
+
+	tests := []struct {
+		Name        string
+		RequestBody string
+		ExceptedErr error
+	}{
+		{
+			Name:        "Wrong type",
+			RequestBody: `{ "url": "https://go.dev", "tags": "go" }`,
+			ExceptedErr: &models.HTTPError{
+				Code:       http.StatusBadRequest,
+				Message:    "invalid request body",
+				ErrorCode:  "invalid_body",
+				Violations: []models.Violation{{Field: "tags", Message: "must be an array, got string"}},
+			},
+		},
+		{
+			Name:        "Not JSON",
+			RequestBody: `{ "url": `,
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusBadRequest,
+				Message:   "request body is not valid JSON",
+				ErrorCode: "invalid_body",
+			},
+		},
+		{
+			Name:        "Bad time",
+			RequestBody: `{ "url": "https://go.dev", "expires_at": "tomorrow" }`,
+			ExceptedErr: &models.HTTPError{
+				Code:      http.StatusBadRequest,
+				Message:   "times must be in RFC 3339 format",
+				ErrorCode: "invalid_body",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/shorten", strings.NewReader(tt.RequestBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			var body dto.ShortenURLRequest
+			err := c.Bind(&body)
+			assert.Equal(t, tt.ExceptedErr, bindError(err))
+		})
+	}
+
+	// Not a body problem, e.g. unsupported content type
+	assert.Equal(t, echo.ErrUnsupportedMediaType, bindError(echo.ErrUnsupportedMediaType))
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"container/list"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/qrcode"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// qrCacheSize is the number of rendered images kept in memory
+const qrCacheSize = 256
+
+// QRCode renders the QR code of the short link as PNG or SVG.
+// The link is looked up without counting a visit, unknown codes return 404.
+// Clients revalidate the image by its ETag, rendered images are kept in memory by the same ETag.
+func (h *Handler) QRCode(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	format, opts, err := qrOptions(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	preview, httpErr := h.service.PreviewURL(ctx, c.Request().Host, c.Param("code"))
+	if httpErr != nil {
+		return httpErr
+	}
+
+	etag := qrcode.ETag(preview.ShortURL, format, opts)
+	c.Response().Header().Set("ETag", etag)
+	c.Response().Header().Set("Cache-Control", "public, no-cache")
+	if etagMatch(c.Request().Header.Get("If-None-Match"), etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	img, ok := h.qrCache.get(etag)
+	if !ok {
+		render := qrcode.PNG
+		if format == "svg" {
+			render = qrcode.SVG
+		}
+		img, err = render(preview.ShortURL, opts)
+		if errors.Is(err, qrcode.ErrInvalidOptions) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err != nil {
+			return err
+		}
+		h.qrCache.add(etag, img)
+	}
+
+	contentType := "image/png"
+	if format == "svg" {
+		contentType = "image/svg+xml"
+	}
+	return c.Blob(http.StatusOK, contentType, img)
+}
+
+// qrOptions parses the format and the options of the image from the query, defaults are used for missing params
+func qrOptions(c echo.Context) (string, qrcode.Options, error) {
+	opts := qrcode.DefaultOptions()
+
+	format := strings.ToLower(c.QueryParam("format"))
+	switch format {
+	case "":
+		format = "png"
+	case "png", "svg":
+	default:
+		return "", opts, errors.New("format must be png or svg")
+	}
+
+	var err error
+	if value := c.QueryParam("size"); value != "" {
+		if opts.Size, err = strconv.Atoi(value); err != nil {
+			return "", opts, errors.New("bad size")
+		}
+	}
+	if value := c.QueryParam("margin"); value != "" {
+		if opts.Margin, err = strconv.Atoi(value); err != nil {
+			return "", opts, errors.New("bad margin")
+		}
+	}
+	if value := c.QueryParam("level"); value != "" {
+		if opts.Level, err = qrcode.ParseLevel(value); err != nil {
+			return "", opts, err
+		}
+	}
+	if value := c.QueryParam("fg"); value != "" {
+		if opts.Foreground, err = qrcode.ParseColor(value); err != nil {
+			return "", opts, err
+		}
+	}
+	if value := c.QueryParam("bg"); value != "" {
+		if opts.Background, err = qrcode.ParseColor(value); err != nil {
+			return "", opts, err
+		}
+	}
+
+	return format, opts, nil
+}
+
+// etagMatch reports whether the If-None-Match header lists the entity tag
+func etagMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// qrCache keeps the recently rendered images, the least recently used one is evicted
+type qrCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type qrCacheEntry struct {
+	etag string
+	img  []byte
+}
+
+func newQRCache(size int) *qrCache {
+	return &qrCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (q *qrCache) get(etag string) ([]byte, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	elem, ok := q.entries[etag]
+	if !ok {
+		return nil, false
+	}
+	q.order.MoveToFront(elem)
+	return elem.Value.(*qrCacheEntry).img, true
+}
+
+func (q *qrCache) add(etag string, img []byte) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if elem, ok := q.entries[etag]; ok {
+		q.order.MoveToFront(elem)
+		return
+	}
+
+	q.entries[etag] = q.order.PushFront(&qrCacheEntry{etag: etag, img: img})
+	if q.order.Len() > q.size {
+		oldest := q.order.Back()
+		q.order.Remove(oldest)
+		delete(q.entries, oldest.Value.(*qrCacheEntry).etag)
+	}
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"github.com/misshanya/url-shortener/qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_QRCode(t *testing.T) {
+	preview := &models.LinkPreview{ShortURL: "https://sh.some/3a", Code: "3a", Domain: "sh.some"}
+	pngETag := qrcode.ETag("https://sh.some/3a", "png", qrcode.DefaultOptions())
+
+	tests := []struct {
+		Name                string
+		Query               string
+		IfNoneMatch         string
+		ExceptedStatus      int
+		ExceptedContentType string
+		ExceptedETag        string
+		ExceptedBody        string
+		SetUpMocks          func(service *mockservice)
+	}{
+		{
+			Name:                "PNG by default",
+			ExceptedStatus:      http.StatusOK,
+			ExceptedContentType: "image/png",
+			ExceptedETag:        pngETag,
+			SetUpMocks: func(service *mockservice) {
+				service.On("PreviewURL", mock.Anything, "sh.some", "3a").Return(preview, nil).Once()
+			},
+		},
+		{
+			Name:                "SVG with options",
+			Query:               "?format=svg&size=512&level=h&margin=2&fg=1565c0&bg=fff",
+			ExceptedStatus:      http.StatusOK,
+			ExceptedContentType: "image/svg+xml",
+			ExceptedBody:        `<path fill="#1565c0"`,
+			SetUpMocks: func(service *mockservice) {
+				service.On("PreviewURL", mock.Anything, "sh.some", "3a").Return(preview, nil).Once()
+			},
+		},
+		{
+			Name:           "Not modified",
+			IfNoneMatch:    `"other", ` + pngETag,
+			ExceptedStatus: http.StatusNotModified,
+			ExceptedETag:   pngETag,
+			SetUpMocks: func(service *mockservice) {
+				service.On("PreviewURL", mock.Anything, "sh.some", "3a").Return(preview, nil).Once()
+			},
+		},
+		{
+			Name:           "Unknown code",
+			ExceptedStatus: http.StatusNotFound,
+			ExceptedBody:   `"detail":"not found"`,
+			SetUpMocks: func(service *mockservice) {
+				service.On("PreviewURL", mock.Anything, "sh.some", "3a").
+					Return(nil, &models.HTTPError{Code: http.StatusNotFound, Message: "not found"}).Once()
+			},
+		},
+		{
+			Name:           "Bad format",
+			Query:          "?format=gif",
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody:   `"detail":"format must be png or svg"`,
+			SetUpMocks:     func(service *mockservice) {},
+		},
+		{
+			Name:           "Bad colour",
+			Query:          "?fg=blue",
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody:   `invalid colour`,
+			SetUpMocks:     func(service *mockservice) {},
+		},
+		{
+			Name:           "Size out of range",
+			Query:          "?size=4096",
+			ExceptedStatus: http.StatusBadRequest,
+			ExceptedBody:   `size must be between 64 and 2048`,
+			SetUpMocks: func(service *mockservice) {
+				service.On("PreviewURL", mock.Anything, "sh.some", "3a").Return(preview, nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			e := echo.New()
+			e.HTTPErrorHandler = ProblemHandler
+
+			req := httptest.NewRequest(http.MethodGet, "/3a/qr"+tt.Query, nil)
+			req.Host = "sh.some"
+			if tt.IfNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.IfNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			c := e.NewContext(req, rec)
+			c.SetParamNames("code")
+			c.SetParamValues("3a")
+
+			handler := NewHandler(&mockService, nil, nil)
+
+			err := handler.QRCode(c)
+			if err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+
+			assert.Equal(t, tt.ExceptedStatus, rec.Code)
+			if tt.ExceptedContentType != "" {
+				assert.Equal(t, tt.ExceptedContentType, rec.Header().Get(echo.HeaderContentType))
+			}
+			if tt.ExceptedETag != "" {
+				assert.Equal(t, tt.ExceptedETag, rec.Header().Get("ETag"))
+			}
+			if tt.ExceptedBody != "" {
+				assert.Contains(t, rec.Body.String(), tt.ExceptedBody)
+			}
+			if tt.ExceptedContentType == "image/png" {
+				_, err := png.Decode(rec.Body)
+				assert.NoError(t, err)
+			}
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_qrCache(t *testing.T) {
+	cache := newQRCache(2)
+	cache.add("a", []byte("a"))
+	cache.add("b", []byte("b"))
+
+	// a is used recently, so b is evicted
+	_, ok := cache.get("a")
+	assert.True(t, ok)
+	cache.add("c", []byte("c"))
+
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	img, ok := cache.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("a"), img)
+}
@@ -0,0 +1,159 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/auth"
+	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"github.com/misshanya/url-shortener/gateway/internal/ratelimit"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers of the quota of the client, draft-ietf-httpapi-ratelimit-headers
+const (
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+	headerRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// RateLimiter throttles the routes per client IP and per API key
+type RateLimiter struct {
+	store     ratelimit.Store
+	rules     ratelimit.Rules
+	allowlist []*net.IPNet
+}
+
+// NewRateLimiter creates the limiter, clients from the allowlist networks are never limited
+func NewRateLimiter(store ratelimit.Store, rules ratelimit.Rules, allowlist []*net.IPNet) *RateLimiter {
+	return &RateLimiter{store: store, rules: rules, allowlist: allowlist}
+}
+
+// Limit throttles the route by its rules. Requests with an API key are counted per principal
+// if the route has a key limit, the others per client IP.
+// The caller is taken from the context, so it goes after RequireScope, and GuardAuth goes before it.
+// If the store fails, requests are let through.
+func (r *RateLimiter) Limit(route string) echo.MiddlewareFunc {
+	limits := r.rules[route]
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if len(limits) == 0 {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			ip := c.RealIP()
+			if r.isAllowed(net.ParseIP(ip)) {
+				return next(c)
+			}
+
+			subject, id := ratelimit.SubjectIP, ip
+			if caller, ok := auth.FromContext(c.Request().Context()); ok && !caller.IsAnonymous() {
+				if _, ok := limits[ratelimit.SubjectKey]; ok {
+					subject, id = ratelimit.SubjectKey, caller.Principal
+				}
+			}
+			limit, ok := limits[subject]
+			if !ok {
+				return next(c)
+			}
+
+			result, err := r.store.Allow(c.Request().Context(), route+":"+subject+":"+id, limit)
+			if err != nil {
+				c.Logger().Errorf("failed to check rate limit: %v", err)
+				return next(c)
+			}
+
+			setRateLimitHeaders(c, limit, result)
+			if !result.Allowed {
+				return rateLimited(result)
+			}
+			return next(c)
+		}
+	}
+}
+
+// GuardAuth counts the failed authentications on the route against the quota of the client IP, it goes before RequireScope.
+// Requests with an API key are rejected before they are authenticated while the quota is spent,
+// so floods of invalid keys don't reach the shortener. Valid keys are never counted here.
+func (r *RateLimiter) GuardAuth(route string) echo.MiddlewareFunc {
+	limit, ok := r.rules[route][ratelimit.SubjectIP]
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if !ok {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			// Anonymous requests are not authenticated, Limit counts them
+			if apiKey(c.Request()) == "" {
+				return next(c)
+			}
+			ip := c.RealIP()
+			if r.isAllowed(net.ParseIP(ip)) {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+			key := route + ":" + ratelimit.SubjectIP + ":" + ip
+			result, err := r.store.Peek(ctx, key, limit)
+			if err != nil {
+				c.Logger().Errorf("failed to check rate limit: %v", err)
+			} else if !result.Allowed {
+				setRateLimitHeaders(c, limit, result)
+				return rateLimited(result)
+			}
+
+			err = next(c)
+			var httpErr *models.HTTPError
+			if errors.As(err, &httpErr) && httpErr.Code == http.StatusUnauthorized {
+				if _, err := r.store.Allow(ctx, key, limit); err != nil {
+					c.Logger().Errorf("failed to count failed authentication: %v", err)
+				}
+			}
+			return err
+		}
+	}
+}
+
+// setRateLimitHeaders tells the client its quota after the request
+func setRateLimitHeaders(c echo.Context, limit ratelimit.Limit, result ratelimit.Result) {
+	header := c.Response().Header()
+	header.Set(headerRateLimitLimit, strconv.Itoa(limit.Rate))
+	header.Set(headerRateLimitRemaining, strconv.Itoa(result.Remaining))
+	header.Set(headerRateLimitReset, strconv.Itoa(ceilSeconds(result.ResetAfter)))
+	header.Set(headerRateLimitPolicy, fmt.Sprintf("%d;w=%d", limit.Rate, ceilSeconds(limit.Period)))
+}
+
+// rateLimited is the error of the request over the limit
+func rateLimited(result ratelimit.Result) error {
+	return &models.HTTPError{
+		Code:       http.StatusTooManyRequests,
+		Message:    "rate limit exceeded, retry later",
+		ErrorCode:  "rate_limited",
+		RetryAfter: result.RetryAfter,
+	}
+}
+
+// isAllowed reports whether the IP is in the allowlist
+func (r *RateLimiter) isAllowed(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range r.allowlist {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ceilSeconds rounds the duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
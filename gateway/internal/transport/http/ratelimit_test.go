@@ -0,0 +1,232 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/auth"
+	"github.com/misshanya/url-shortener/gateway/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// failingStore is the store that is down
+type failingStore struct{}
+
+func (failingStore) Allow(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("dial tcp: connection refused")
+}
+
+func (failingStore) Peek(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("dial tcp: connection refused")
+}
+
+func Test_RateLimiter(t *testing.T) {
+	rules := ratelimit.Rules{
+		"shorten":  {ratelimit.SubjectIP: {Rate: 2, Period: time.Minute}, ratelimit.SubjectKey: {Rate: 3, Period: time.Minute}},
+		"redirect": {ratelimit.SubjectIP: {Rate: 1, Period: time.Minute}},
+	}
+	_, trusted, _ := net.ParseCIDR("10.0.0.0/8")
+
+	tests := []struct {
+		Name             string
+		Store            ratelimit.Store
+		Route            string
+		RemoteAddr       string
+		Caller           *auth.Caller
+		Requests         int
+		ExceptedStatus   int
+		ExceptedHeaders  map[string]string
+		ExceptedBody     string
+		ExceptedNoHeader bool
+	}{
+		{
+			Name:           "Under the IP limit",
+			Route:          "shorten",
+			RemoteAddr:     "203.0.113.1:1234",
+			Requests:       1,
+			ExceptedStatus: http.StatusOK,
+			ExceptedHeaders: map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "1",
+				"RateLimit-Reset":     "30",
+				"RateLimit-Policy":    "2;w=60",
+			},
+		},
+		{
+			Name:           "Over the IP limit",
+			Route:          "shorten",
+			RemoteAddr:     "203.0.113.1:1234",
+			Requests:       3,
+			ExceptedStatus: http.StatusTooManyRequests,
+			ExceptedHeaders: map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"Retry-After":         "30",
+			},
+			ExceptedBody: problemBody(http.StatusTooManyRequests, "rate_limited", "rate limit exceeded, retry later", "/"),
+		},
+		{
+			Name:           "API key has its own limit",
+			Route:          "shorten",
+			RemoteAddr:     "203.0.113.1:1234",
+			Caller:         &auth.Caller{Principal: "apikey:ci", Scopes: []string{auth.ScopeShorten}},
+			Requests:       3,
+			ExceptedStatus: http.StatusOK,
+			ExceptedHeaders: map[string]string{
+				"RateLimit-Limit":     "3",
+				"RateLimit-Remaining": "0",
+			},
+		},
+		{
+			Name:           "API key without a key limit is counted by IP",
+			Route:          "redirect",
+			RemoteAddr:     "203.0.113.1:1234",
+			Caller:         &auth.Caller{Principal: "apikey:ci", Scopes: []string{auth.ScopeShorten}},
+			Requests:       2,
+			ExceptedStatus: http.StatusTooManyRequests,
+			ExceptedBody:   problemBody(http.StatusTooManyRequests, "rate_limited", "rate limit exceeded, retry later", "/"),
+		},
+		{
+			Name:             "Allowlisted network",
+			Route:            "redirect",
+			RemoteAddr:       "10.1.2.3:1234",
+			Requests:         5,
+			ExceptedStatus:   http.StatusOK,
+			ExceptedNoHeader: true,
+		},
+		{
+			Name:             "Route without rules",
+			Route:            "qr",
+			RemoteAddr:       "203.0.113.1:1234",
+			Requests:         5,
+			ExceptedStatus:   http.StatusOK,
+			ExceptedNoHeader: true,
+		},
+		{
+			Name:             "Store is down",
+			Store:            failingStore{},
+			Route:            "redirect",
+			RemoteAddr:       "203.0.113.1:1234",
+			Requests:         5,
+			ExceptedStatus:   http.StatusOK,
+			ExceptedNoHeader: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			store := tt.Store
+			if store == nil {
+				store = ratelimit.NewMemoryStore()
+			}
+			limiter := NewRateLimiter(store, rules, []*net.IPNet{trusted})
+
+			e := echo.New()
+			e.HTTPErrorHandler = ProblemHandler
+			e.IPExtractor = echo.ExtractIPDirect()
+			e.GET("/", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, limiter.Limit(tt.Route))
+
+			var rec *httptest.ResponseRecorder
+			for range tt.Requests {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.RemoteAddr = tt.RemoteAddr
+				if tt.Caller != nil {
+					req = req.WithContext(auth.WithCaller(req.Context(), *tt.Caller))
+				}
+				rec = httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+			}
+
+			assert.Equal(t, tt.ExceptedStatus, rec.Code)
+			for header, value := range tt.ExceptedHeaders {
+				assert.Equal(t, value, rec.Header().Get(header), header)
+			}
+			if tt.ExceptedNoHeader {
+				assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+			}
+			if tt.ExceptedBody != "" {
+				assert.JSONEq(t, tt.ExceptedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func Test_RateLimiter_GuardAuth(t *testing.T) {
+	rules := ratelimit.Rules{
+		"shorten": {ratelimit.SubjectIP: {Rate: 2, Period: time.Minute}},
+	}
+
+	tests := []struct {
+		Name                    string
+		Headers                 map[string]string
+		Requests                int
+		ExceptedStatus          int
+		ExceptedAuthentications int
+	}{
+		{
+			Name:                    "Invalid keys are throttled before authentication",
+			Headers:                 map[string]string{headerAPIKey: "shk_invalid"},
+			Requests:                5,
+			ExceptedStatus:          http.StatusTooManyRequests,
+			ExceptedAuthentications: 2,
+		},
+		{
+			Name:                    "Valid keys are not counted",
+			Headers:                 map[string]string{echo.HeaderAuthorization: "Bearer shk_valid"},
+			Requests:                5,
+			ExceptedStatus:          http.StatusOK,
+			ExceptedAuthentications: 5,
+		},
+		{
+			Name:                    "Anonymous requests are left to Limit",
+			Requests:                5,
+			ExceptedStatus:          http.StatusOK,
+			ExceptedAuthentications: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			limiter := NewRateLimiter(ratelimit.NewMemoryStore(), rules, nil)
+
+			// authentications counts the requests that reached the authentication
+			authentications := 0
+			e := echo.New()
+			e.HTTPErrorHandler = ProblemHandler
+			e.IPExtractor = echo.ExtractIPDirect()
+			e.GET("/", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, limiter.GuardAuth("shorten"), func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					authentications++
+					if apiKey(c.Request()) == "shk_invalid" {
+						return unauthorized(c, "invalid API key")
+					}
+					return next(c)
+				}
+			})
+
+			var rec *httptest.ResponseRecorder
+			for range tt.Requests {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.RemoteAddr = "203.0.113.1:1234"
+				for header, value := range tt.Headers {
+					req.Header.Set(header, value)
+				}
+				rec = httptest.NewRecorder()
+				e.ServeHTTP(rec, req)
+			}
+
+			assert.Equal(t, tt.ExceptedStatus, rec.Code)
+			assert.Equal(t, tt.ExceptedAuthentications, authentications)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"embed"
+	"github.com/labstack/echo/v4"
+	"io/fs"
+	"net/http"
+	"path"
+)
+
+//go:embed ui
+var uiFiles embed.FS
+
+// uiAssets are the files of the web UI served at the root next to the page.
+// Their names have a dot, which is never in a code, so they don't shadow the redirects.
+var uiAssets = map[string]string{
+	"ui.css": "text/css; charset=utf-8",
+	"ui.js":  "text/javascript; charset=utf-8",
+}
+
+// UI serves the web page for shortening and managing links, it works through the REST API of the gateway
+type UI struct {
+	files fs.FS
+}
+
+func NewUI() *UI {
+	return &UI{files: uiFiles}
+}
+
+// Page serves the page at /
+func (u *UI) Page(c echo.Context) error {
+	page, err := fs.ReadFile(u.files, "ui/index.html")
+	if err != nil {
+		return err
+	}
+	return c.HTMLBlob(http.StatusOK, page)
+}
+
+// Asset serves the script or the stylesheet of the page by the path of the route
+func (u *UI) Asset(c echo.Context) error {
+	name := path.Base(c.Path())
+	contentType, ok := uiAssets[name]
+	if !ok {
+		return echo.ErrNotFound
+	}
+
+	asset, err := fs.ReadFile(u.files, "ui/"+name)
+	if err != nil {
+		return err
+	}
+	c.Response().Header().Set("Cache-Control", "no-cache")
+	return c.Blob(http.StatusOK, contentType, asset)
+}
@@ -0,0 +1,221 @@
+"use strict";
+
+// The page only talks to the REST API of the gateway serving it.
+// The API key is kept in the local storage of the browser, links shortened without it are remembered there too.
+
+const storage = {
+    key: () => localStorage.getItem("apiKey") || "",
+    setKey: (key) => key ? localStorage.setItem("apiKey", key) : localStorage.removeItem("apiKey"),
+    recent: () => JSON.parse(localStorage.getItem("recentLinks") || "[]"),
+    remember: (link) => {
+        const links = [link, ...storage.recent().filter((l) => l.short_url !== link.short_url)].slice(0, 50);
+        localStorage.setItem("recentLinks", JSON.stringify(links));
+    },
+};
+
+const $ = (id) => document.getElementById(id);
+
+// api calls the gateway and throws the detail of the problem if the call fails
+async function api(method, path, body) {
+    const headers = { "Accept": "application/json" };
+    if (body !== undefined) {
+        headers["Content-Type"] = "application/json";
+    }
+    if (storage.key()) {
+        headers["X-API-Key"] = storage.key();
+    }
+
+    const resp = await fetch(path, { method, headers, body: body === undefined ? undefined : JSON.stringify(body) });
+    const data = await resp.json().catch(() => ({}));
+    if (!resp.ok) {
+        const violations = (data.violations || []).map((v) => `${v.field}: ${v.message}`);
+        throw new Error([data.detail || data.title || resp.statusText, ...violations].join("\n"));
+    }
+    return data;
+}
+
+function showError(el, err) {
+    el.textContent = err ? err.message : "";
+    el.hidden = !err;
+}
+
+function qrURL(shortURL, size) {
+    return `${shortURL}/qr?size=${size}`;
+}
+
+async function copy(text, button) {
+    try {
+        await navigator.clipboard.writeText(text);
+    } catch {
+        // The clipboard API needs a secure context, fall back to the selection
+        const input = document.createElement("textarea");
+        input.value = text;
+        document.body.append(input);
+        input.select();
+        document.execCommand("copy");
+        input.remove();
+    }
+    const label = button.textContent;
+    button.textContent = "Copied";
+    setTimeout(() => { button.textContent = label; }, 1500);
+}
+
+function button(label, className, onClick) {
+    const b = document.createElement("button");
+    b.type = "button";
+    b.className = className;
+    b.textContent = label;
+    b.addEventListener("click", () => onClick(b));
+    return b;
+}
+
+function cell(row, content, className) {
+    const td = row.insertCell();
+    if (className) {
+        td.className = className;
+    }
+    if (content instanceof Node) {
+        td.append(content);
+    } else {
+        td.textContent = content || "";
+        td.title = content || "";
+    }
+    return td;
+}
+
+function link(href) {
+    const a = document.createElement("a");
+    a.href = href;
+    a.textContent = href;
+    a.target = "_blank";
+    a.rel = "noopener";
+    return a;
+}
+
+function actions(shortURL) {
+    const span = document.createElement("span");
+    span.append(
+        button("Copy", "copy", (b) => copy(shortURL, b)),
+        " ",
+        button("QR", "qr", () => {
+            const dialog = $("qr-dialog");
+            dialog.querySelector("img").src = qrURL(shortURL, 320);
+            dialog.showModal();
+        }),
+    );
+    return span;
+}
+
+$("api-key").value = storage.key();
+$("key-form").addEventListener("submit", (e) => {
+    e.preventDefault();
+    storage.setKey($("api-key").value.trim());
+    loadLinks();
+});
+
+$("shorten-form").addEventListener("submit", async (e) => {
+    e.preventDefault();
+    const req = { url: $("url").value.trim() };
+    for (const [field, id] of [["alias", "alias"], ["title", "title"], ["password", "password"]]) {
+        if ($(id).value.trim()) {
+            req[field] = $(id).value.trim();
+        }
+    }
+    if ($("expires-at").value) {
+        req.expires_at = new Date($("expires-at").value).toISOString();
+    }
+
+    try {
+        const resp = await api("POST", "/shorten", req);
+        showError($("shorten-error"), null);
+        $("short-url").href = resp.short_url;
+        $("short-url").textContent = resp.short_url;
+        $("qr").src = qrURL(resp.short_url, 192);
+        $("shorten-result").hidden = false;
+        storage.remember({ short_url: resp.short_url, original_url: resp.original_url, created_at: new Date().toISOString() });
+        e.target.reset();
+        loadLinks();
+    } catch (err) {
+        showError($("shorten-error"), err);
+    }
+});
+
+$("copy-short-url").addEventListener("click", (e) => copy($("short-url").textContent, e.target));
+
+$("batch-form").addEventListener("submit", async (e) => {
+    e.preventDefault();
+    const urls = $("batch-urls").value.split("\n").map((u) => u.trim()).filter(Boolean);
+    if (urls.length === 0) {
+        return;
+    }
+
+    try {
+        const resp = await api("POST", "/shorten/batch", { urls: urls.map((url) => ({ url })) });
+        showError($("batch-error"), null);
+
+        const tbody = $("batch-result").tBodies[0];
+        tbody.replaceChildren();
+        for (const result of resp.urls) {
+            const row = tbody.insertRow();
+            cell(row, result.original_url, "destination");
+            if (result.error) {
+                cell(row, result.error).classList.add("error");
+                cell(row, "");
+                continue;
+            }
+            cell(row, link(result.short_url));
+            cell(row, actions(result.short_url), "actions");
+            storage.remember({ short_url: result.short_url, original_url: result.original_url, created_at: new Date().toISOString() });
+        }
+        $("batch-result").hidden = false;
+        loadLinks();
+    } catch (err) {
+        showError($("batch-error"), err);
+    }
+});
+
+let cursor = "";
+
+// loadLinks lists the links of the API key, or the links shortened in this browser without it
+async function loadLinks(more) {
+    const tbody = $("links").tBodies[0];
+    if (!more) {
+        tbody.replaceChildren();
+        cursor = "";
+    }
+
+    let links = [];
+    if (storage.key()) {
+        $("links-note").textContent = "Links of your API key, newest first.";
+        try {
+            const params = new URLSearchParams({ limit: "20" });
+            if (cursor) {
+                params.set("cursor", cursor);
+            }
+            const resp = await api("GET", `/api/links?${params}`);
+            showError($("links-error"), null);
+            links = resp.links;
+            cursor = resp.next_cursor || "";
+        } catch (err) {
+            showError($("links-error"), err);
+            cursor = "";
+        }
+    } else {
+        $("links-note").textContent = "Links shortened in this browser. Save an API key to see all the links of the key.";
+        showError($("links-error"), null);
+        links = storage.recent();
+    }
+    $("links-more").hidden = !cursor;
+
+    for (const l of links) {
+        const row = tbody.insertRow();
+        cell(row, link(l.short_url));
+        cell(row, l.original_url, "destination");
+        cell(row, l.created_at ? new Date(l.created_at).toLocaleString() : "");
+        cell(row, actions(l.short_url), "actions");
+    }
+}
+
+$("links-more").addEventListener("click", () => loadLinks(true));
+
+loadLinks();
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_UI(t *testing.T) {
+	ui := NewUI()
+
+	e := echo.New()
+	e.GET("/", ui.Page)
+	e.GET("/ui.css", ui.Asset)
+	e.GET("/ui.js", ui.Asset)
+	e.GET("/:code", func(c echo.Context) error {
+		return c.String(http.StatusFound, "redirect "+c.Param("code"))
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := serve("/")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMETextHTMLCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, rec.Body.String(), `<script src="/ui.js"></script>`)
+	// Everything is embedded, nothing comes from a CDN
+	assert.NotRegexp(t, `(src|href)="(https?:)?//`, rec.Body.String())
+
+	for path, contentType := range map[string]string{
+		"/ui.css": "text/css; charset=utf-8",
+		"/ui.js":  "text/javascript; charset=utf-8",
+	} {
+		rec = serve(path)
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, contentType, rec.Header().Get(echo.HeaderContentType), path)
+		assert.NotEmpty(t, rec.Body.Bytes(), path)
+	}
+
+	// The page doesn't shadow the codes
+	for _, code := range []string{"ui", "1z", "index"} {
+		rec = serve("/" + code)
+		assert.Equal(t, http.StatusFound, rec.Code, code)
+		assert.Equal(t, "redirect "+code, rec.Body.String())
+	}
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/labstack/echo/v4"
+	"github.com/misshanya/url-shortener/gateway/internal/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	visitorCookieName = "sh_vid"
+	visitorCookieTTL  = 365 * 24 * time.Hour
+)
+
+// visitFromRequest collects the visitor attributes used to resolve the link
+func visitFromRequest(r *http.Request) models.Visit {
+	userAgent := r.UserAgent()
+	return models.Visit{
+		Query:          r.URL.RawQuery,
+		Referer:        r.Referer(),
+		UserAgent:      userAgent,
+		Platform:       platform(r.Header.Get("Sec-CH-UA-Platform"), userAgent),
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+	}
+}
+
+// visitorID returns the visitor ID from the cookie.
+// If the visitor has no cookie yet, it issues a new one,
+// so weighted destinations stay the same for the visitor.
+func visitorID(c echo.Context) string {
+	if cookie, err := c.Cookie(visitorCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	id := hex.EncodeToString(b)
+
+	c.SetCookie(&http.Cookie{
+		Name:     visitorCookieName,
+		Value:    id,
+		Path:     "/",
+		Expires:  time.Now().Add(visitorCookieTTL),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return id
+}
+
+// clientHintPlatforms maps Sec-CH-UA-Platform values to platforms
+var clientHintPlatforms = map[string]string{
+	"ios":         "ios",
+	"android":     "android",
+	"windows":     "windows",
+	"macos":       "macos",
+	"linux":       "linux",
+	"chrome os":   "chromeos",
+	"chromium os": "chromeos",
+}
+
+// platform detects the visitor platform from the client hint,
+// falling back to the User-Agent if there is no hint
+func platform(clientHint, userAgent string) string {
+	if clientHint != "" {
+		if p, ok := clientHintPlatforms[strings.ToLower(strings.Trim(clientHint, `"`))]; ok {
+			return p
+		}
+	}
+
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"), strings.Contains(ua, "ipod"):
+		return "ios"
+	case strings.Contains(ua, "android"):
+		return "android"
+	case strings.Contains(ua, "windows"):
+		return "windows"
+	case strings.Contains(ua, "cros"):
+		return "chromeos"
+	case strings.Contains(ua, "macintosh"), strings.Contains(ua, "mac os x"):
+		return "macos"
+	case strings.Contains(ua, "linux"):
+		return "linux"
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_platform(t *testing.T) {
+	tests := []struct {
+		Name             string
+		ClientHint       string
+		UserAgent        string
+		ExceptedPlatform string
+	}{
+		{
+			Name:             "Client hint",
+			ClientHint:       `"Android"`,
+			UserAgent:        "Mozilla/5.0 (Linux; Android 10; K)",
+			ExceptedPlatform: "android",
+		},
+		{
+			Name:             "Unknown client hint falls back to User-Agent",
+			ClientHint:       `"Unknown"`,
+			UserAgent:        "Mozilla/5.0 (Windows NT 10.0; Win64; x64)",
+			ExceptedPlatform: "windows",
+		},
+		{
+			Name:             "iPhone",
+			UserAgent:        "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15",
+			ExceptedPlatform: "ios",
+		},
+		{
+			Name:             "Android",
+			UserAgent:        "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36",
+			ExceptedPlatform: "android",
+		},
+		{
+			Name:             "Mac",
+			UserAgent:        "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15",
+			ExceptedPlatform: "macos",
+		},
+		{
+			Name:             "Linux",
+			UserAgent:        "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0",
+			ExceptedPlatform: "linux",
+		},
+		{
+			Name:             "Unknown",
+			UserAgent:        "curl/8.8.0",
+			ExceptedPlatform: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.ExceptedPlatform, platform(tt.ClientHint, tt.UserAgent))
+		})
+	}
+}
@@ -22,8 +22,32 @@ const (
 )
 
 type ShortenURLRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Merge the query string of the incoming request into the destination
+	ForwardQuery bool `protobuf:"varint,2,opt,name=forward_query,json=forwardQuery,proto3" json:"forward_query,omitempty"`
+	// UTM parameters to apply to the destination, values may contain {code}
+	Utm map[string]string `protobuf:"bytes,3,rep,name=utm,proto3" json:"utm,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Ordered routing rules, the first matching rule sets the destination
+	Rules []*RoutingRule `protobuf:"bytes,4,rep,name=rules,proto3" json:"rules,omitempty"`
+	// Weighted destinations to split the traffic between if no rule matched
+	Destinations []*Destination `protobuf:"bytes,5,rep,name=destinations,proto3" json:"destinations,omitempty"`
+	// Password to protect the link with, stored hashed
+	Password string `protobuf:"bytes,6,opt,name=password,proto3" json:"password,omitempty"`
+	// The link is not found before this time, Unix seconds, 0 if active right away
+	NotBefore int64 `protobuf:"varint,7,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	// The link is not found since this time, Unix seconds, 0 if it never expires
+	ExpiresAt int64 `protobuf:"varint,8,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Registered domain (host) of the link, the default domain if empty
+	Domain string `protobuf:"bytes,9,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Custom code of the link, unique within the domain
+	Alias string `protobuf:"bytes,10,opt,name=alias,proto3" json:"alias,omitempty"`
+	Title string `protobuf:"bytes,11,opt,name=title,proto3" json:"title,omitempty"`
+	Notes string `protobuf:"bytes,12,opt,name=notes,proto3" json:"notes,omitempty"`
+	// Tags of the link, lower case
+	Tags []string `protobuf:"bytes,13,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Show the interstitial page with the destination before the redirect, for untrusted destinations
+	Interstitial  bool `protobuf:"varint,14,opt,name=interstitial,proto3" json:"interstitial,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -65,18 +89,264 @@ func (x *ShortenURLRequest) GetUrl() string {
 	return ""
 }
 
+func (x *ShortenURLRequest) GetForwardQuery() bool {
+	if x != nil {
+		return x.ForwardQuery
+	}
+	return false
+}
+
+func (x *ShortenURLRequest) GetUtm() map[string]string {
+	if x != nil {
+		return x.Utm
+	}
+	return nil
+}
+
+func (x *ShortenURLRequest) GetRules() []*RoutingRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+func (x *ShortenURLRequest) GetDestinations() []*Destination {
+	if x != nil {
+		return x.Destinations
+	}
+	return nil
+}
+
+func (x *ShortenURLRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *ShortenURLRequest) GetNotBefore() int64 {
+	if x != nil {
+		return x.NotBefore
+	}
+	return 0
+}
+
+func (x *ShortenURLRequest) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *ShortenURLRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ShortenURLRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *ShortenURLRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *ShortenURLRequest) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *ShortenURLRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ShortenURLRequest) GetInterstitial() bool {
+	if x != nil {
+		return x.Interstitial
+	}
+	return false
+}
+
+type Destination struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Relative weight of the destination, must be positive
+	Weight uint32 `protobuf:"varint,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	// Name of the variant for statistics, defaults to the destination position
+	Name          string `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Destination) Reset() {
+	*x = Destination{}
+	mi := &file_v1_shortener_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Destination) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Destination) ProtoMessage() {}
+
+func (x *Destination) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Destination.ProtoReflect.Descriptor instead.
+func (*Destination) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Destination) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Destination) GetWeight() uint32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *Destination) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// RoutingRule matches when all of its non-empty conditions match the visitor
+type RoutingRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Name of the rule for statistics, defaults to the rule position
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Case-insensitive substring of the User-Agent
+	UserAgent string `protobuf:"bytes,3,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	// Platform of the visitor: ios, android, windows, macos, linux,
+	// or one of the groups: mobile, desktop
+	Platform string `protobuf:"bytes,4,opt,name=platform,proto3" json:"platform,omitempty"`
+	// Language prefix matched against Accept-Language, e.g. "de" or "pt-BR"
+	AcceptLanguage string `protobuf:"bytes,5,opt,name=accept_language,json=acceptLanguage,proto3" json:"accept_language,omitempty"`
+	// ISO 3166-1 alpha-2 country codes of the visitor, e.g. "DE", any of them matches
+	Countries     []string `protobuf:"bytes,6,rep,name=countries,proto3" json:"countries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RoutingRule) Reset() {
+	*x = RoutingRule{}
+	mi := &file_v1_shortener_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RoutingRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoutingRule) ProtoMessage() {}
+
+func (x *RoutingRule) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoutingRule.ProtoReflect.Descriptor instead.
+func (*RoutingRule) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RoutingRule) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *RoutingRule) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RoutingRule) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *RoutingRule) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *RoutingRule) GetAcceptLanguage() string {
+	if x != nil {
+		return x.AcceptLanguage
+	}
+	return ""
+}
+
+func (x *RoutingRule) GetCountries() []string {
+	if x != nil {
+		return x.Countries
+	}
+	return nil
+}
+
 type ShortenURLResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
-	OriginalUrl   string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
-	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Code        string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	OriginalUrl string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	Error       string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	// Domain (host) of the link
+	Domain        string `protobuf:"bytes,4,opt,name=domain,proto3" json:"domain,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ShortenURLResponse) Reset() {
 	*x = ShortenURLResponse{}
-	mi := &file_v1_shortener_proto_msgTypes[1]
+	mi := &file_v1_shortener_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -88,7 +358,7 @@ func (x *ShortenURLResponse) String() string {
 func (*ShortenURLResponse) ProtoMessage() {}
 
 func (x *ShortenURLResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_shortener_proto_msgTypes[1]
+	mi := &file_v1_shortener_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -101,7 +371,7 @@ func (x *ShortenURLResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ShortenURLResponse.ProtoReflect.Descriptor instead.
 func (*ShortenURLResponse) Descriptor() ([]byte, []int) {
-	return file_v1_shortener_proto_rawDescGZIP(), []int{1}
+	return file_v1_shortener_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *ShortenURLResponse) GetCode() string {
@@ -125,6 +395,13 @@ func (x *ShortenURLResponse) GetError() string {
 	return ""
 }
 
+func (x *ShortenURLResponse) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
 type ShortenURLBatchRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Urls          []*ShortenURLRequest   `protobuf:"bytes,1,rep,name=urls,proto3" json:"urls,omitempty"`
@@ -134,7 +411,7 @@ type ShortenURLBatchRequest struct {
 
 func (x *ShortenURLBatchRequest) Reset() {
 	*x = ShortenURLBatchRequest{}
-	mi := &file_v1_shortener_proto_msgTypes[2]
+	mi := &file_v1_shortener_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -146,7 +423,7 @@ func (x *ShortenURLBatchRequest) String() string {
 func (*ShortenURLBatchRequest) ProtoMessage() {}
 
 func (x *ShortenURLBatchRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_shortener_proto_msgTypes[2]
+	mi := &file_v1_shortener_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -159,7 +436,7 @@ func (x *ShortenURLBatchRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ShortenURLBatchRequest.ProtoReflect.Descriptor instead.
 func (*ShortenURLBatchRequest) Descriptor() ([]byte, []int) {
-	return file_v1_shortener_proto_rawDescGZIP(), []int{2}
+	return file_v1_shortener_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ShortenURLBatchRequest) GetUrls() []*ShortenURLRequest {
@@ -178,7 +455,7 @@ type ShortenURLBatchResponse struct {
 
 func (x *ShortenURLBatchResponse) Reset() {
 	*x = ShortenURLBatchResponse{}
-	mi := &file_v1_shortener_proto_msgTypes[3]
+	mi := &file_v1_shortener_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -190,7 +467,7 @@ func (x *ShortenURLBatchResponse) String() string {
 func (*ShortenURLBatchResponse) ProtoMessage() {}
 
 func (x *ShortenURLBatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_shortener_proto_msgTypes[3]
+	mi := &file_v1_shortener_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -203,7 +480,7 @@ func (x *ShortenURLBatchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ShortenURLBatchResponse.ProtoReflect.Descriptor instead.
 func (*ShortenURLBatchResponse) Descriptor() ([]byte, []int) {
-	return file_v1_shortener_proto_rawDescGZIP(), []int{3}
+	return file_v1_shortener_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *ShortenURLBatchResponse) GetUrls() []*ShortenURLResponse {
@@ -214,15 +491,22 @@ func (x *ShortenURLBatchResponse) GetUrls() []*ShortenURLResponse {
 }
 
 type GetURLRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Raw query string of the incoming request, without leading "?"
+	Query string `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
+	// Host the link is requested on, unknown hosts fall back to the default domain
+	Domain string `protobuf:"bytes,3,opt,name=domain,proto3" json:"domain,omitempty"`
+	// The visit is not recorded, the caller reports it with RecordVisits passing rule and variant of the response.
+	// Such a call has no side effects, so the caller may hedge it.
+	SkipVisit     bool `protobuf:"varint,4,opt,name=skip_visit,json=skipVisit,proto3" json:"skip_visit,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetURLRequest) Reset() {
 	*x = GetURLRequest{}
-	mi := &file_v1_shortener_proto_msgTypes[4]
+	mi := &file_v1_shortener_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -234,7 +518,7 @@ func (x *GetURLRequest) String() string {
 func (*GetURLRequest) ProtoMessage() {}
 
 func (x *GetURLRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_shortener_proto_msgTypes[4]
+	mi := &file_v1_shortener_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -247,7 +531,7 @@ func (x *GetURLRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetURLRequest.ProtoReflect.Descriptor instead.
 func (*GetURLRequest) Descriptor() ([]byte, []int) {
-	return file_v1_shortener_proto_rawDescGZIP(), []int{4}
+	return file_v1_shortener_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetURLRequest) GetCode() string {
@@ -257,16 +541,47 @@ func (x *GetURLRequest) GetCode() string {
 	return ""
 }
 
+func (x *GetURLRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *GetURLRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *GetURLRequest) GetSkipVisit() bool {
+	if x != nil {
+		return x.SkipVisit
+	}
+	return false
+}
+
 type GetURLResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Url   string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// The visitor is shown the interstitial page with the destination instead of being redirected
+	Interstitial bool `protobuf:"varint,2,opt,name=interstitial,proto3" json:"interstitial,omitempty"`
+	// The redirect is the same for every visitor, so the caller may cache it.
+	// The visits served from the cache are reported with RecordVisits.
+	Cacheable bool `protobuf:"varint,3,opt,name=cacheable,proto3" json:"cacheable,omitempty"`
+	// Unix seconds, 0 if not limited, the cached redirect must not outlive the link
+	ExpiresAt int64 `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Matched routing rule and chosen weighted destination, empty if none
+	Rule          string `protobuf:"bytes,5,opt,name=rule,proto3" json:"rule,omitempty"`
+	Variant       string `protobuf:"bytes,6,opt,name=variant,proto3" json:"variant,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetURLResponse) Reset() {
 	*x = GetURLResponse{}
-	mi := &file_v1_shortener_proto_msgTypes[5]
+	mi := &file_v1_shortener_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -278,7 +593,7 @@ func (x *GetURLResponse) String() string {
 func (*GetURLResponse) ProtoMessage() {}
 
 func (x *GetURLResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_v1_shortener_proto_msgTypes[5]
+	mi := &file_v1_shortener_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -291,7 +606,7 @@ func (x *GetURLResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetURLResponse.ProtoReflect.Descriptor instead.
 func (*GetURLResponse) Descriptor() ([]byte, []int) {
-	return file_v1_shortener_proto_rawDescGZIP(), []int{5}
+	return file_v1_shortener_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GetURLResponse) GetUrl() string {
@@ -301,30 +616,2515 @@ func (x *GetURLResponse) GetUrl() string {
 	return ""
 }
 
-var File_v1_shortener_proto protoreflect.FileDescriptor
+func (x *GetURLResponse) GetInterstitial() bool {
+	if x != nil {
+		return x.Interstitial
+	}
+	return false
+}
 
-const file_v1_shortener_proto_rawDesc = "" +
-	"\n" +
-	"\x12v1/shortener.proto\x12\x02v1\"%\n" +
-	"\x11ShortenURLRequest\x12\x10\n" +
-	"\x03url\x18\x01 \x01(\tR\x03url\"a\n" +
-	"\x12ShortenURLResponse\x12\x12\n" +
-	"\x04code\x18\x01 \x01(\tR\x04code\x12!\n" +
-	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\"C\n" +
-	"\x16ShortenURLBatchRequest\x12)\n" +
-	"\x04urls\x18\x01 \x03(\v2\x15.v1.ShortenURLRequestR\x04urls\"E\n" +
-	"\x17ShortenURLBatchResponse\x12*\n" +
-	"\x04urls\x18\x01 \x03(\v2\x16.v1.ShortenURLResponseR\x04urls\"#\n" +
-	"\rGetURLRequest\x12\x12\n" +
-	"\x04code\x18\x01 \x01(\tR\x04code\"\"\n" +
-	"\x0eGetURLResponse\x12\x10\n" +
-	"\x03url\x18\x01 \x01(\tR\x03url2\xcf\x01\n" +
-	"\x13URLShortenerService\x12;\n" +
-	"\n" +
-	"ShortenURL\x12\x15.v1.ShortenURLRequest\x1a\x16.v1.ShortenURLResponse\x12J\n" +
-	"\x0fShortenURLBatch\x12\x1a.v1.ShortenURLBatchRequest\x1a\x1b.v1.ShortenURLBatchResponse\x12/\n" +
-	"\x06GetURL\x12\x11.v1.GetURLRequest\x1a\x12.v1.GetURLResponseB.Z,github.com/misshanya/url-shortener/gen/go/v1b\x06proto3"
+func (x *GetURLResponse) GetCacheable() bool {
+	if x != nil {
+		return x.Cacheable
+	}
+	return false
+}
+
+func (x *GetURLResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *GetURLResponse) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *GetURLResponse) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+type Visit struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Host the link was requested on, the same as in GetURLRequest
+	Domain string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Unix seconds
+	VisitedAt      int64  `protobuf:"varint,3,opt,name=visited_at,json=visitedAt,proto3" json:"visited_at,omitempty"`
+	Referer        string `protobuf:"bytes,4,opt,name=referer,proto3" json:"referer,omitempty"`
+	UserAgent      string `protobuf:"bytes,5,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	AcceptLanguage string `protobuf:"bytes,6,opt,name=accept_language,json=acceptLanguage,proto3" json:"accept_language,omitempty"`
+	Ip             string `protobuf:"bytes,7,opt,name=ip,proto3" json:"ip,omitempty"`
+	// ISO 3166-1 alpha-2 code
+	Country string `protobuf:"bytes,8,opt,name=country,proto3" json:"country,omitempty"`
+	// Rule and variant of the GetURL response the visit skipped, empty for cached redirects
+	Rule          string `protobuf:"bytes,9,opt,name=rule,proto3" json:"rule,omitempty"`
+	Variant       string `protobuf:"bytes,10,opt,name=variant,proto3" json:"variant,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Visit) Reset() {
+	*x = Visit{}
+	mi := &file_v1_shortener_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Visit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Visit) ProtoMessage() {}
+
+func (x *Visit) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Visit.ProtoReflect.Descriptor instead.
+func (*Visit) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Visit) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Visit) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *Visit) GetVisitedAt() int64 {
+	if x != nil {
+		return x.VisitedAt
+	}
+	return 0
+}
+
+func (x *Visit) GetReferer() string {
+	if x != nil {
+		return x.Referer
+	}
+	return ""
+}
+
+func (x *Visit) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *Visit) GetAcceptLanguage() string {
+	if x != nil {
+		return x.AcceptLanguage
+	}
+	return ""
+}
+
+func (x *Visit) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *Visit) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *Visit) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Visit) GetVariant() string {
+	if x != nil {
+		return x.Variant
+	}
+	return ""
+}
+
+type RecordVisitsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Visits        []*Visit               `protobuf:"bytes,1,rep,name=visits,proto3" json:"visits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordVisitsRequest) Reset() {
+	*x = RecordVisitsRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordVisitsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordVisitsRequest) ProtoMessage() {}
+
+func (x *RecordVisitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordVisitsRequest.ProtoReflect.Descriptor instead.
+func (*RecordVisitsRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RecordVisitsRequest) GetVisits() []*Visit {
+	if x != nil {
+		return x.Visits
+	}
+	return nil
+}
+
+type RecordVisitsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of the visits written
+	Recorded      int32 `protobuf:"varint,1,opt,name=recorded,proto3" json:"recorded,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordVisitsResponse) Reset() {
+	*x = RecordVisitsResponse{}
+	mi := &file_v1_shortener_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordVisitsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordVisitsResponse) ProtoMessage() {}
+
+func (x *RecordVisitsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordVisitsResponse.ProtoReflect.Descriptor instead.
+func (*RecordVisitsResponse) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *RecordVisitsResponse) GetRecorded() int32 {
+	if x != nil {
+		return x.Recorded
+	}
+	return 0
+}
+
+type PreviewURLRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Host the link is requested on, the same as in GetURLRequest
+	Domain        string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PreviewURLRequest) Reset() {
+	*x = PreviewURLRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreviewURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreviewURLRequest) ProtoMessage() {}
+
+func (x *PreviewURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreviewURLRequest.ProtoReflect.Descriptor instead.
+func (*PreviewURLRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PreviewURLRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *PreviewURLRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type LinkPreview struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Code   string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Domain string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Destination of the link, empty if the link is password-protected
+	Url string `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	// Unix seconds
+	CreatedAt         int64 `protobuf:"varint,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	PasswordProtected bool  `protobuf:"varint,5,opt,name=password_protected,json=passwordProtected,proto3" json:"password_protected,omitempty"`
+	Interstitial      bool  `protobuf:"varint,6,opt,name=interstitial,proto3" json:"interstitial,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *LinkPreview) Reset() {
+	*x = LinkPreview{}
+	mi := &file_v1_shortener_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkPreview) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkPreview) ProtoMessage() {}
+
+func (x *LinkPreview) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkPreview.ProtoReflect.Descriptor instead.
+func (*LinkPreview) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *LinkPreview) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *LinkPreview) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *LinkPreview) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *LinkPreview) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *LinkPreview) GetPasswordProtected() bool {
+	if x != nil {
+		return x.PasswordProtected
+	}
+	return false
+}
+
+func (x *LinkPreview) GetInterstitial() bool {
+	if x != nil {
+		return x.Interstitial
+	}
+	return false
+}
+
+type VerifyLinkPasswordRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Code     string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Password string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	// Host the link is requested on, the same as in GetURLRequest
+	Domain        string `protobuf:"bytes,3,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyLinkPasswordRequest) Reset() {
+	*x = VerifyLinkPasswordRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyLinkPasswordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyLinkPasswordRequest) ProtoMessage() {}
+
+func (x *VerifyLinkPasswordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyLinkPasswordRequest.ProtoReflect.Descriptor instead.
+func (*VerifyLinkPasswordRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *VerifyLinkPasswordRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *VerifyLinkPasswordRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *VerifyLinkPasswordRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type VerifyLinkPasswordResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Token to pass in the x-link-token metadata of GetURL
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	// Expiration time of the token, Unix seconds
+	ExpiresAt     int64 `protobuf:"varint,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyLinkPasswordResponse) Reset() {
+	*x = VerifyLinkPasswordResponse{}
+	mi := &file_v1_shortener_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyLinkPasswordResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyLinkPasswordResponse) ProtoMessage() {}
+
+func (x *VerifyLinkPasswordResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyLinkPasswordResponse.ProtoReflect.Descriptor instead.
+func (*VerifyLinkPasswordResponse) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *VerifyLinkPasswordResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *VerifyLinkPasswordResponse) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type Domain struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Host  string                 `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	// Links are created on the default domain if no domain is requested
+	IsDefault     bool `protobuf:"varint,2,opt,name=is_default,json=isDefault,proto3" json:"is_default,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Domain) Reset() {
+	*x = Domain{}
+	mi := &file_v1_shortener_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Domain) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Domain) ProtoMessage() {}
+
+func (x *Domain) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Domain.ProtoReflect.Descriptor instead.
+func (*Domain) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Domain) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *Domain) GetIsDefault() bool {
+	if x != nil {
+		return x.IsDefault
+	}
+	return false
+}
+
+type CreateDomainRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Host of the domain, e.g. "sh.some" or "localhost:8080"
+	Host          string `protobuf:"bytes,1,opt,name=host,proto3" json:"host,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateDomainRequest) Reset() {
+	*x = CreateDomainRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateDomainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateDomainRequest) ProtoMessage() {}
+
+func (x *CreateDomainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateDomainRequest.ProtoReflect.Descriptor instead.
+func (*CreateDomainRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CreateDomainRequest) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+type ListDomainsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDomainsRequest) Reset() {
+	*x = ListDomainsRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDomainsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDomainsRequest) ProtoMessage() {}
+
+func (x *ListDomainsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDomainsRequest.ProtoReflect.Descriptor instead.
+func (*ListDomainsRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{17}
+}
+
+type ListDomainsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Domains       []*Domain              `protobuf:"bytes,1,rep,name=domains,proto3" json:"domains,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDomainsResponse) Reset() {
+	*x = ListDomainsResponse{}
+	mi := &file_v1_shortener_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDomainsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDomainsResponse) ProtoMessage() {}
+
+func (x *ListDomainsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDomainsResponse.ProtoReflect.Descriptor instead.
+func (*ListDomainsResponse) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListDomainsResponse) GetDomains() []*Domain {
+	if x != nil {
+		return x.Domains
+	}
+	return nil
+}
+
+type ListURLsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Cursor of the page from the previous response, empty for the first page
+	Cursor string `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// Number of links on the page, 50 if 0, at most 100
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Principal created the links, only admins filter by it, other callers get their own links
+	Owner string `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`
+	// Domain (host) of the links
+	Domain string `protobuf:"bytes,4,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Links created since this time, Unix seconds, 0 if not limited
+	CreatedFrom int64 `protobuf:"varint,5,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	// Links created before this time, Unix seconds, 0 if not limited
+	CreatedTo int64 `protobuf:"varint,6,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	// Links active at the moment if true, not active if false
+	Enabled *bool `protobuf:"varint,7,opt,name=enabled,proto3,oneof" json:"enabled,omitempty"`
+	// Case-insensitive substring of the destination URL
+	Search string `protobuf:"bytes,8,opt,name=search,proto3" json:"search,omitempty"`
+	// Links having all of the tags
+	Tags          []string `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListURLsRequest) Reset() {
+	*x = ListURLsRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListURLsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListURLsRequest) ProtoMessage() {}
+
+func (x *ListURLsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListURLsRequest.ProtoReflect.Descriptor instead.
+func (*ListURLsRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListURLsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListURLsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListURLsRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *ListURLsRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ListURLsRequest) GetCreatedFrom() int64 {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return 0
+}
+
+func (x *ListURLsRequest) GetCreatedTo() int64 {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return 0
+}
+
+func (x *ListURLsRequest) GetEnabled() bool {
+	if x != nil && x.Enabled != nil {
+		return *x.Enabled
+	}
+	return false
+}
+
+func (x *ListURLsRequest) GetSearch() string {
+	if x != nil {
+		return x.Search
+	}
+	return ""
+}
+
+func (x *ListURLsRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type Link struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Code        string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Domain      string                 `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	OriginalUrl string                 `protobuf:"bytes,3,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	Owner       string                 `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`
+	// Unix seconds
+	CreatedAt int64 `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Unix seconds, 0 if not limited
+	NotBefore         int64 `protobuf:"varint,6,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	ExpiresAt         int64 `protobuf:"varint,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	PasswordProtected bool  `protobuf:"varint,8,opt,name=password_protected,json=passwordProtected,proto3" json:"password_protected,omitempty"`
+	// The link is active at the moment
+	Enabled       bool     `protobuf:"varint,9,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Title         string   `protobuf:"bytes,10,opt,name=title,proto3" json:"title,omitempty"`
+	Tags          []string `protobuf:"bytes,11,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Link) Reset() {
+	*x = Link{}
+	mi := &file_v1_shortener_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Link) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Link) ProtoMessage() {}
+
+func (x *Link) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Link.ProtoReflect.Descriptor instead.
+func (*Link) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Link) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Link) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *Link) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *Link) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Link) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Link) GetNotBefore() int64 {
+	if x != nil {
+		return x.NotBefore
+	}
+	return 0
+}
+
+func (x *Link) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+func (x *Link) GetPasswordProtected() bool {
+	if x != nil {
+		return x.PasswordProtected
+	}
+	return false
+}
+
+func (x *Link) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Link) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Link) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type ListURLsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Links []*Link                `protobuf:"bytes,1,rep,name=links,proto3" json:"links,omitempty"`
+	// Cursor of the next page, empty if this page is the last one
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListURLsResponse) Reset() {
+	*x = ListURLsResponse{}
+	mi := &file_v1_shortener_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListURLsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListURLsResponse) ProtoMessage() {}
+
+func (x *ListURLsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListURLsResponse.ProtoReflect.Descriptor instead.
+func (*ListURLsResponse) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListURLsResponse) GetLinks() []*Link {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *ListURLsResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type SetLinkMetadataRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Domain (host) of the link, the default domain if empty
+	Domain string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	Title  string `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Notes  string `protobuf:"bytes,4,opt,name=notes,proto3" json:"notes,omitempty"`
+	// Tags of the link, replace the current ones
+	Tags          []string `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetLinkMetadataRequest) Reset() {
+	*x = SetLinkMetadataRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetLinkMetadataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetLinkMetadataRequest) ProtoMessage() {}
+
+func (x *SetLinkMetadataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetLinkMetadataRequest.ProtoReflect.Descriptor instead.
+func (*SetLinkMetadataRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SetLinkMetadataRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *SetLinkMetadataRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *SetLinkMetadataRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *SetLinkMetadataRequest) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *SetLinkMetadataRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type GetLinkMetadataRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Domain (host) of the link, the default domain if empty
+	Domain        string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLinkMetadataRequest) Reset() {
+	*x = GetLinkMetadataRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLinkMetadataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLinkMetadataRequest) ProtoMessage() {}
+
+func (x *GetLinkMetadataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLinkMetadataRequest.ProtoReflect.Descriptor instead.
+func (*GetLinkMetadataRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetLinkMetadataRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *GetLinkMetadataRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type LinkMetadata struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Notes         string                 `protobuf:"bytes,2,opt,name=notes,proto3" json:"notes,omitempty"`
+	Tags          []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LinkMetadata) Reset() {
+	*x = LinkMetadata{}
+	mi := &file_v1_shortener_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LinkMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkMetadata) ProtoMessage() {}
+
+func (x *LinkMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkMetadata.ProtoReflect.Descriptor instead.
+func (*LinkMetadata) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *LinkMetadata) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *LinkMetadata) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *LinkMetadata) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type ListTagsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTagsRequest) Reset() {
+	*x = ListTagsRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTagsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTagsRequest) ProtoMessage() {}
+
+func (x *ListTagsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTagsRequest.ProtoReflect.Descriptor instead.
+func (*ListTagsRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{25}
+}
+
+type Tag struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Number of links with the tag
+	Links         int64 `protobuf:"varint,2,opt,name=links,proto3" json:"links,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tag) Reset() {
+	*x = Tag{}
+	mi := &file_v1_shortener_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tag) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tag) ProtoMessage() {}
+
+func (x *Tag) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tag.ProtoReflect.Descriptor instead.
+func (*Tag) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Tag) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tag) GetLinks() int64 {
+	if x != nil {
+		return x.Links
+	}
+	return 0
+}
+
+type ListTagsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tags          []*Tag                 `protobuf:"bytes,1,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTagsResponse) Reset() {
+	*x = ListTagsResponse{}
+	mi := &file_v1_shortener_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTagsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTagsResponse) ProtoMessage() {}
+
+func (x *ListTagsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTagsResponse.ProtoReflect.Descriptor instead.
+func (*ListTagsResponse) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListTagsResponse) GetTags() []*Tag {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type DeleteURLRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Domain (host) of the link, the default domain if empty
+	Domain        string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteURLRequest) Reset() {
+	*x = DeleteURLRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteURLRequest) ProtoMessage() {}
+
+func (x *DeleteURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteURLRequest.ProtoReflect.Descriptor instead.
+func (*DeleteURLRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *DeleteURLRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *DeleteURLRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type DeleteURLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteURLResponse) Reset() {
+	*x = DeleteURLResponse{}
+	mi := &file_v1_shortener_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteURLResponse) ProtoMessage() {}
+
+func (x *DeleteURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteURLResponse.ProtoReflect.Descriptor instead.
+func (*DeleteURLResponse) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{29}
+}
+
+type DisableURLRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Domain (host) of the link, the default domain if empty
+	Domain        string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DisableURLRequest) Reset() {
+	*x = DisableURLRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisableURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisableURLRequest) ProtoMessage() {}
+
+func (x *DisableURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisableURLRequest.ProtoReflect.Descriptor instead.
+func (*DisableURLRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *DisableURLRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *DisableURLRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type RetargetURLRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Domain (host) of the link, the default domain if empty
+	Domain string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	// New destination of the link
+	Url           string `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetargetURLRequest) Reset() {
+	*x = RetargetURLRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetargetURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetargetURLRequest) ProtoMessage() {}
+
+func (x *RetargetURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetargetURLRequest.ProtoReflect.Descriptor instead.
+func (*RetargetURLRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *RetargetURLRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *RetargetURLRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *RetargetURLRequest) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type GetCacheEntryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Code  string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	// Domain (host) of the link, the default domain if empty
+	Domain        string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCacheEntryRequest) Reset() {
+	*x = GetCacheEntryRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCacheEntryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCacheEntryRequest) ProtoMessage() {}
+
+func (x *GetCacheEntryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCacheEntryRequest.ProtoReflect.Descriptor instead.
+func (*GetCacheEntryRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *GetCacheEntryRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *GetCacheEntryRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+type CacheEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The link is cached, the other fields are empty otherwise
+	Cached bool `protobuf:"varint,1,opt,name=cached,proto3" json:"cached,omitempty"`
+	// The link as it is cached, it may be stale until the entry expires
+	Link *Link `protobuf:"bytes,2,opt,name=link,proto3" json:"link,omitempty"`
+	// Seconds until the entry expires, 0 if it does not expire
+	Ttl           int64 `protobuf:"varint,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CacheEntry) Reset() {
+	*x = CacheEntry{}
+	mi := &file_v1_shortener_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CacheEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CacheEntry) ProtoMessage() {}
+
+func (x *CacheEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CacheEntry.ProtoReflect.Descriptor instead.
+func (*CacheEntry) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *CacheEntry) GetCached() bool {
+	if x != nil {
+		return x.Cached
+	}
+	return false
+}
+
+func (x *CacheEntry) GetLink() *Link {
+	if x != nil {
+		return x.Link
+	}
+	return nil
+}
+
+func (x *CacheEntry) GetTtl() int64 {
+	if x != nil {
+		return x.Ttl
+	}
+	return 0
+}
+
+type ListAuditEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Cursor of the page from the previous response, empty for the first page
+	Cursor string `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// Number of events on the page, 50 if 0, at most 100
+	PageSize int32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// Code of the link, events of all links if empty
+	Code string `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	// Domain (host) of the link, the default domain if empty and the code is set
+	Domain string `protobuf:"bytes,4,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Principal made the changes
+	Actor string `protobuf:"bytes,5,opt,name=actor,proto3" json:"actor,omitempty"`
+	// Kind of the change: create, import, delete, retarget, set_domain, set_expires_at or update_metadata
+	Action        string `protobuf:"bytes,6,opt,name=action,proto3" json:"action,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditEventsRequest) Reset() {
+	*x = ListAuditEventsRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditEventsRequest) ProtoMessage() {}
+
+func (x *ListAuditEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditEventsRequest.ProtoReflect.Descriptor instead.
+func (*ListAuditEventsRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ListAuditEventsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListAuditEventsRequest) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *ListAuditEventsRequest) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+type AuditEvent struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Code   string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	Domain string                 `protobuf:"bytes,3,opt,name=domain,proto3" json:"domain,omitempty"`
+	Action string                 `protobuf:"bytes,4,opt,name=action,proto3" json:"action,omitempty"`
+	// Principal made the change, empty if anonymous
+	Actor     string `protobuf:"bytes,5,opt,name=actor,proto3" json:"actor,omitempty"`
+	RequestId string `protobuf:"bytes,6,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// JSON objects with the changed fields, empty if there is no such state
+	OldValue string `protobuf:"bytes,7,opt,name=old_value,json=oldValue,proto3" json:"old_value,omitempty"`
+	NewValue string `protobuf:"bytes,8,opt,name=new_value,json=newValue,proto3" json:"new_value,omitempty"`
+	// Unix microseconds
+	CreatedAt     int64 `protobuf:"varint,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditEvent) Reset() {
+	*x = AuditEvent{}
+	mi := &file_v1_shortener_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEvent) ProtoMessage() {}
+
+func (x *AuditEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEvent.ProtoReflect.Descriptor instead.
+func (*AuditEvent) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *AuditEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AuditEvent) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetActor() string {
+	if x != nil {
+		return x.Actor
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetOldValue() string {
+	if x != nil {
+		return x.OldValue
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetNewValue() string {
+	if x != nil {
+		return x.NewValue
+	}
+	return ""
+}
+
+func (x *AuditEvent) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+type ListAuditEventsResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Events []*AuditEvent          `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	// Cursor of the next page, empty if this page is the last one
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAuditEventsResponse) Reset() {
+	*x = ListAuditEventsResponse{}
+	mi := &file_v1_shortener_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAuditEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAuditEventsResponse) ProtoMessage() {}
+
+func (x *ListAuditEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAuditEventsResponse.ProtoReflect.Descriptor instead.
+func (*ListAuditEventsResponse) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ListAuditEventsResponse) GetEvents() []*AuditEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *ListAuditEventsResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type APIKey struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// First characters of the key to tell the keys apart, e.g. "shk_3fQx9"
+	Prefix string `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// Principal the requests with the key are made on behalf of, recorded as the owner of new links
+	Principal string `protobuf:"bytes,3,opt,name=principal,proto3" json:"principal,omitempty"`
+	Name      string `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	// Scopes granted to the key: shorten, read-stats, manage-links
+	Scopes []string `protobuf:"bytes,5,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	// Unix seconds
+	CreatedAt int64 `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Unix seconds, 0 if the key is active
+	RevokedAt     int64 `protobuf:"varint,7,opt,name=revoked_at,json=revokedAt,proto3" json:"revoked_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *APIKey) Reset() {
+	*x = APIKey{}
+	mi := &file_v1_shortener_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *APIKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*APIKey) ProtoMessage() {}
+
+func (x *APIKey) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use APIKey.ProtoReflect.Descriptor instead.
+func (*APIKey) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *APIKey) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *APIKey) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *APIKey) GetPrincipal() string {
+	if x != nil {
+		return x.Principal
+	}
+	return ""
+}
+
+func (x *APIKey) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *APIKey) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+func (x *APIKey) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *APIKey) GetRevokedAt() int64 {
+	if x != nil {
+		return x.RevokedAt
+	}
+	return 0
+}
+
+type CreateAPIKeyRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Principal of the key, e.g. "apikey:ci"
+	Principal string `protobuf:"bytes,1,opt,name=principal,proto3" json:"principal,omitempty"`
+	// Human-readable name, e.g. "CI pipeline"
+	Name          string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Scopes        []string `protobuf:"bytes,3,rep,name=scopes,proto3" json:"scopes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAPIKeyRequest) Reset() {
+	*x = CreateAPIKeyRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAPIKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAPIKeyRequest) ProtoMessage() {}
+
+func (x *CreateAPIKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAPIKeyRequest.ProtoReflect.Descriptor instead.
+func (*CreateAPIKeyRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *CreateAPIKeyRequest) GetPrincipal() string {
+	if x != nil {
+		return x.Principal
+	}
+	return ""
+}
+
+func (x *CreateAPIKeyRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateAPIKeyRequest) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
+type CreateAPIKeyResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	ApiKey *APIKey                `protobuf:"bytes,1,opt,name=api_key,json=apiKey,proto3" json:"api_key,omitempty"`
+	// The key itself, it can't be retrieved later
+	Key           string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAPIKeyResponse) Reset() {
+	*x = CreateAPIKeyResponse{}
+	mi := &file_v1_shortener_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAPIKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAPIKeyResponse) ProtoMessage() {}
+
+func (x *CreateAPIKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAPIKeyResponse.ProtoReflect.Descriptor instead.
+func (*CreateAPIKeyResponse) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *CreateAPIKeyResponse) GetApiKey() *APIKey {
+	if x != nil {
+		return x.ApiKey
+	}
+	return nil
+}
+
+func (x *CreateAPIKeyResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type ListAPIKeysRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAPIKeysRequest) Reset() {
+	*x = ListAPIKeysRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAPIKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAPIKeysRequest) ProtoMessage() {}
+
+func (x *ListAPIKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAPIKeysRequest.ProtoReflect.Descriptor instead.
+func (*ListAPIKeysRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{40}
+}
+
+type ListAPIKeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ApiKeys       []*APIKey              `protobuf:"bytes,1,rep,name=api_keys,json=apiKeys,proto3" json:"api_keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAPIKeysResponse) Reset() {
+	*x = ListAPIKeysResponse{}
+	mi := &file_v1_shortener_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAPIKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAPIKeysResponse) ProtoMessage() {}
+
+func (x *ListAPIKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAPIKeysResponse.ProtoReflect.Descriptor instead.
+func (*ListAPIKeysResponse) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ListAPIKeysResponse) GetApiKeys() []*APIKey {
+	if x != nil {
+		return x.ApiKeys
+	}
+	return nil
+}
+
+type RevokeAPIKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeAPIKeyRequest) Reset() {
+	*x = RevokeAPIKeyRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeAPIKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeAPIKeyRequest) ProtoMessage() {}
+
+func (x *RevokeAPIKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeAPIKeyRequest.ProtoReflect.Descriptor instead.
+func (*RevokeAPIKeyRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *RevokeAPIKeyRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type AuthenticateAPIKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthenticateAPIKeyRequest) Reset() {
+	*x = AuthenticateAPIKeyRequest{}
+	mi := &file_v1_shortener_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthenticateAPIKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthenticateAPIKeyRequest) ProtoMessage() {}
+
+func (x *AuthenticateAPIKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_shortener_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthenticateAPIKeyRequest.ProtoReflect.Descriptor instead.
+func (*AuthenticateAPIKeyRequest) Descriptor() ([]byte, []int) {
+	return file_v1_shortener_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *AuthenticateAPIKeyRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+var File_v1_shortener_proto protoreflect.FileDescriptor
+
+const file_v1_shortener_proto_rawDesc = "" +
+	"\n" +
+	"\x12v1/shortener.proto\x12\x02v1\"\xfc\x03\n" +
+	"\x11ShortenURLRequest\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12#\n" +
+	"\rforward_query\x18\x02 \x01(\bR\fforwardQuery\x120\n" +
+	"\x03utm\x18\x03 \x03(\v2\x1e.v1.ShortenURLRequest.UtmEntryR\x03utm\x12%\n" +
+	"\x05rules\x18\x04 \x03(\v2\x0f.v1.RoutingRuleR\x05rules\x123\n" +
+	"\fdestinations\x18\x05 \x03(\v2\x0f.v1.DestinationR\fdestinations\x12\x1a\n" +
+	"\bpassword\x18\x06 \x01(\tR\bpassword\x12\x1d\n" +
+	"\n" +
+	"not_before\x18\a \x01(\x03R\tnotBefore\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\b \x01(\x03R\texpiresAt\x12\x16\n" +
+	"\x06domain\x18\t \x01(\tR\x06domain\x12\x14\n" +
+	"\x05alias\x18\n" +
+	" \x01(\tR\x05alias\x12\x14\n" +
+	"\x05title\x18\v \x01(\tR\x05title\x12\x14\n" +
+	"\x05notes\x18\f \x01(\tR\x05notes\x12\x12\n" +
+	"\x04tags\x18\r \x03(\tR\x04tags\x12\"\n" +
+	"\finterstitial\x18\x0e \x01(\bR\finterstitial\x1a6\n" +
+	"\bUtmEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"K\n" +
+	"\vDestination\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x16\n" +
+	"\x06weight\x18\x02 \x01(\rR\x06weight\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\"\xb5\x01\n" +
+	"\vRoutingRule\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x03 \x01(\tR\tuserAgent\x12\x1a\n" +
+	"\bplatform\x18\x04 \x01(\tR\bplatform\x12'\n" +
+	"\x0faccept_language\x18\x05 \x01(\tR\x0eacceptLanguage\x12\x1c\n" +
+	"\tcountries\x18\x06 \x03(\tR\tcountries\"y\n" +
+	"\x12ShortenURLResponse\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12!\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x16\n" +
+	"\x06domain\x18\x04 \x01(\tR\x06domain\"C\n" +
+	"\x16ShortenURLBatchRequest\x12)\n" +
+	"\x04urls\x18\x01 \x03(\v2\x15.v1.ShortenURLRequestR\x04urls\"E\n" +
+	"\x17ShortenURLBatchResponse\x12*\n" +
+	"\x04urls\x18\x01 \x03(\v2\x16.v1.ShortenURLResponseR\x04urls\"p\n" +
+	"\rGetURLRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\x12\x16\n" +
+	"\x06domain\x18\x03 \x01(\tR\x06domain\x12\x1d\n" +
+	"\n" +
+	"skip_visit\x18\x04 \x01(\bR\tskipVisit\"\xb1\x01\n" +
+	"\x0eGetURLResponse\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\"\n" +
+	"\finterstitial\x18\x02 \x01(\bR\finterstitial\x12\x1c\n" +
+	"\tcacheable\x18\x03 \x01(\bR\tcacheable\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt\x12\x12\n" +
+	"\x04rule\x18\x05 \x01(\tR\x04rule\x12\x18\n" +
+	"\avariant\x18\x06 \x01(\tR\avariant\"\x8c\x02\n" +
+	"\x05Visit\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x1d\n" +
+	"\n" +
+	"visited_at\x18\x03 \x01(\x03R\tvisitedAt\x12\x18\n" +
+	"\areferer\x18\x04 \x01(\tR\areferer\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x05 \x01(\tR\tuserAgent\x12'\n" +
+	"\x0faccept_language\x18\x06 \x01(\tR\x0eacceptLanguage\x12\x0e\n" +
+	"\x02ip\x18\a \x01(\tR\x02ip\x12\x18\n" +
+	"\acountry\x18\b \x01(\tR\acountry\x12\x12\n" +
+	"\x04rule\x18\t \x01(\tR\x04rule\x12\x18\n" +
+	"\avariant\x18\n" +
+	" \x01(\tR\avariant\"8\n" +
+	"\x13RecordVisitsRequest\x12!\n" +
+	"\x06visits\x18\x01 \x03(\v2\t.v1.VisitR\x06visits\"2\n" +
+	"\x14RecordVisitsResponse\x12\x1a\n" +
+	"\brecorded\x18\x01 \x01(\x05R\brecorded\"?\n" +
+	"\x11PreviewURLRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\"\xbd\x01\n" +
+	"\vLinkPreview\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\x03R\tcreatedAt\x12-\n" +
+	"\x12password_protected\x18\x05 \x01(\bR\x11passwordProtected\x12\"\n" +
+	"\finterstitial\x18\x06 \x01(\bR\finterstitial\"c\n" +
+	"\x19VerifyLinkPasswordRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x16\n" +
+	"\x06domain\x18\x03 \x01(\tR\x06domain\"Q\n" +
+	"\x1aVerifyLinkPasswordResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\x03R\texpiresAt\";\n" +
+	"\x06Domain\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\x12\x1d\n" +
+	"\n" +
+	"is_default\x18\x02 \x01(\bR\tisDefault\")\n" +
+	"\x13CreateDomainRequest\x12\x12\n" +
+	"\x04host\x18\x01 \x01(\tR\x04host\"\x14\n" +
+	"\x12ListDomainsRequest\";\n" +
+	"\x13ListDomainsResponse\x12$\n" +
+	"\adomains\x18\x01 \x03(\v2\n" +
+	".v1.DomainR\adomains\"\x8d\x02\n" +
+	"\x0fListURLsRequest\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\tR\x06cursor\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\x12\x16\n" +
+	"\x06domain\x18\x04 \x01(\tR\x06domain\x12!\n" +
+	"\fcreated_from\x18\x05 \x01(\x03R\vcreatedFrom\x12\x1d\n" +
+	"\n" +
+	"created_to\x18\x06 \x01(\x03R\tcreatedTo\x12\x1d\n" +
+	"\aenabled\x18\a \x01(\bH\x00R\aenabled\x88\x01\x01\x12\x16\n" +
+	"\x06search\x18\b \x01(\tR\x06search\x12\x12\n" +
+	"\x04tags\x18\t \x03(\tR\x04tagsB\n" +
+	"\n" +
+	"\b_enabled\"\xbb\x02\n" +
+	"\x04Link\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12!\n" +
+	"\foriginal_url\x18\x03 \x01(\tR\voriginalUrl\x12\x14\n" +
+	"\x05owner\x18\x04 \x01(\tR\x05owner\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"not_before\x18\x06 \x01(\x03R\tnotBefore\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\x03R\texpiresAt\x12-\n" +
+	"\x12password_protected\x18\b \x01(\bR\x11passwordProtected\x12\x18\n" +
+	"\aenabled\x18\t \x01(\bR\aenabled\x12\x14\n" +
+	"\x05title\x18\n" +
+	" \x01(\tR\x05title\x12\x12\n" +
+	"\x04tags\x18\v \x03(\tR\x04tags\"S\n" +
+	"\x10ListURLsResponse\x12\x1e\n" +
+	"\x05links\x18\x01 \x03(\v2\b.v1.LinkR\x05links\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"\x84\x01\n" +
+	"\x16SetLinkMetadataRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x14\n" +
+	"\x05title\x18\x03 \x01(\tR\x05title\x12\x14\n" +
+	"\x05notes\x18\x04 \x01(\tR\x05notes\x12\x12\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\"D\n" +
+	"\x16GetLinkMetadataRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\"N\n" +
+	"\fLinkMetadata\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x14\n" +
+	"\x05notes\x18\x02 \x01(\tR\x05notes\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\"\x11\n" +
+	"\x0fListTagsRequest\"/\n" +
+	"\x03Tag\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05links\x18\x02 \x01(\x03R\x05links\"/\n" +
+	"\x10ListTagsResponse\x12\x1b\n" +
+	"\x04tags\x18\x01 \x03(\v2\a.v1.TagR\x04tags\">\n" +
+	"\x10DeleteURLRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\"\x13\n" +
+	"\x11DeleteURLResponse\"?\n" +
+	"\x11DisableURLRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\"R\n" +
+	"\x12RetargetURLRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\"B\n" +
+	"\x14GetCacheEntryRequest\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\"T\n" +
+	"\n" +
+	"CacheEntry\x12\x16\n" +
+	"\x06cached\x18\x01 \x01(\bR\x06cached\x12\x1c\n" +
+	"\x04link\x18\x02 \x01(\v2\b.v1.LinkR\x04link\x12\x10\n" +
+	"\x03ttl\x18\x03 \x01(\x03R\x03ttl\"\xa7\x01\n" +
+	"\x16ListAuditEventsRequest\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\tR\x06cursor\x12\x1b\n" +
+	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x04 \x01(\tR\x06domain\x12\x14\n" +
+	"\x05actor\x18\x05 \x01(\tR\x05actor\x12\x16\n" +
+	"\x06action\x18\x06 \x01(\tR\x06action\"\xee\x01\n" +
+	"\n" +
+	"AuditEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12\x16\n" +
+	"\x06domain\x18\x03 \x01(\tR\x06domain\x12\x16\n" +
+	"\x06action\x18\x04 \x01(\tR\x06action\x12\x14\n" +
+	"\x05actor\x18\x05 \x01(\tR\x05actor\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x06 \x01(\tR\trequestId\x12\x1b\n" +
+	"\told_value\x18\a \x01(\tR\boldValue\x12\x1b\n" +
+	"\tnew_value\x18\b \x01(\tR\bnewValue\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\t \x01(\x03R\tcreatedAt\"b\n" +
+	"\x17ListAuditEventsResponse\x12&\n" +
+	"\x06events\x18\x01 \x03(\v2\x0e.v1.AuditEventR\x06events\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"\xb8\x01\n" +
+	"\x06APIKey\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06prefix\x18\x02 \x01(\tR\x06prefix\x12\x1c\n" +
+	"\tprincipal\x18\x03 \x01(\tR\tprincipal\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x16\n" +
+	"\x06scopes\x18\x05 \x03(\tR\x06scopes\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"revoked_at\x18\a \x01(\x03R\trevokedAt\"_\n" +
+	"\x13CreateAPIKeyRequest\x12\x1c\n" +
+	"\tprincipal\x18\x01 \x01(\tR\tprincipal\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06scopes\x18\x03 \x03(\tR\x06scopes\"M\n" +
+	"\x14CreateAPIKeyResponse\x12#\n" +
+	"\aapi_key\x18\x01 \x01(\v2\n" +
+	".v1.APIKeyR\x06apiKey\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\"\x14\n" +
+	"\x12ListAPIKeysRequest\"<\n" +
+	"\x13ListAPIKeysResponse\x12%\n" +
+	"\bapi_keys\x18\x01 \x03(\v2\n" +
+	".v1.APIKeyR\aapiKeys\"%\n" +
+	"\x13RevokeAPIKeyRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"-\n" +
+	"\x19AuthenticateAPIKeyRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key2\x9c\n" +
+	"\n" +
+	"\x13URLShortenerService\x12;\n" +
+	"\n" +
+	"ShortenURL\x12\x15.v1.ShortenURLRequest\x1a\x16.v1.ShortenURLResponse\x12J\n" +
+	"\x0fShortenURLBatch\x12\x1a.v1.ShortenURLBatchRequest\x1a\x1b.v1.ShortenURLBatchResponse\x12/\n" +
+	"\x06GetURL\x12\x11.v1.GetURLRequest\x1a\x12.v1.GetURLResponse\x12A\n" +
+	"\fRecordVisits\x12\x17.v1.RecordVisitsRequest\x1a\x18.v1.RecordVisitsResponse\x124\n" +
+	"\n" +
+	"PreviewURL\x12\x15.v1.PreviewURLRequest\x1a\x0f.v1.LinkPreview\x12S\n" +
+	"\x12VerifyLinkPassword\x12\x1d.v1.VerifyLinkPasswordRequest\x1a\x1e.v1.VerifyLinkPasswordResponse\x123\n" +
+	"\fCreateDomain\x12\x17.v1.CreateDomainRequest\x1a\n" +
+	".v1.Domain\x12>\n" +
+	"\vListDomains\x12\x16.v1.ListDomainsRequest\x1a\x17.v1.ListDomainsResponse\x125\n" +
+	"\bListURLs\x12\x13.v1.ListURLsRequest\x1a\x14.v1.ListURLsResponse\x12?\n" +
+	"\x0fSetLinkMetadata\x12\x1a.v1.SetLinkMetadataRequest\x1a\x10.v1.LinkMetadata\x12?\n" +
+	"\x0fGetLinkMetadata\x12\x1a.v1.GetLinkMetadataRequest\x1a\x10.v1.LinkMetadata\x125\n" +
+	"\bListTags\x12\x13.v1.ListTagsRequest\x1a\x14.v1.ListTagsResponse\x128\n" +
+	"\tDeleteURL\x12\x14.v1.DeleteURLRequest\x1a\x15.v1.DeleteURLResponse\x12-\n" +
+	"\n" +
+	"DisableURL\x12\x15.v1.DisableURLRequest\x1a\b.v1.Link\x12/\n" +
+	"\vRetargetURL\x12\x16.v1.RetargetURLRequest\x1a\b.v1.Link\x129\n" +
+	"\rGetCacheEntry\x12\x18.v1.GetCacheEntryRequest\x1a\x0e.v1.CacheEntry\x12J\n" +
+	"\x0fListAuditEvents\x12\x1a.v1.ListAuditEventsRequest\x1a\x1b.v1.ListAuditEventsResponse\x12A\n" +
+	"\fCreateAPIKey\x12\x17.v1.CreateAPIKeyRequest\x1a\x18.v1.CreateAPIKeyResponse\x12>\n" +
+	"\vListAPIKeys\x12\x16.v1.ListAPIKeysRequest\x1a\x17.v1.ListAPIKeysResponse\x123\n" +
+	"\fRevokeAPIKey\x12\x17.v1.RevokeAPIKeyRequest\x1a\n" +
+	".v1.APIKey\x12?\n" +
+	"\x12AuthenticateAPIKey\x12\x1d.v1.AuthenticateAPIKeyRequest\x1a\n" +
+	".v1.APIKeyB.Z,github.com/misshanya/url-shortener/gen/go/v1b\x06proto3"
 
 var (
 	file_v1_shortener_proto_rawDescOnce sync.Once
@@ -338,29 +3138,115 @@ func file_v1_shortener_proto_rawDescGZIP() []byte {
 	return file_v1_shortener_proto_rawDescData
 }
 
-var file_v1_shortener_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_v1_shortener_proto_msgTypes = make([]protoimpl.MessageInfo, 45)
 var file_v1_shortener_proto_goTypes = []any{
-	(*ShortenURLRequest)(nil),       // 0: v1.ShortenURLRequest
-	(*ShortenURLResponse)(nil),      // 1: v1.ShortenURLResponse
-	(*ShortenURLBatchRequest)(nil),  // 2: v1.ShortenURLBatchRequest
-	(*ShortenURLBatchResponse)(nil), // 3: v1.ShortenURLBatchResponse
-	(*GetURLRequest)(nil),           // 4: v1.GetURLRequest
-	(*GetURLResponse)(nil),          // 5: v1.GetURLResponse
+	(*ShortenURLRequest)(nil),          // 0: v1.ShortenURLRequest
+	(*Destination)(nil),                // 1: v1.Destination
+	(*RoutingRule)(nil),                // 2: v1.RoutingRule
+	(*ShortenURLResponse)(nil),         // 3: v1.ShortenURLResponse
+	(*ShortenURLBatchRequest)(nil),     // 4: v1.ShortenURLBatchRequest
+	(*ShortenURLBatchResponse)(nil),    // 5: v1.ShortenURLBatchResponse
+	(*GetURLRequest)(nil),              // 6: v1.GetURLRequest
+	(*GetURLResponse)(nil),             // 7: v1.GetURLResponse
+	(*Visit)(nil),                      // 8: v1.Visit
+	(*RecordVisitsRequest)(nil),        // 9: v1.RecordVisitsRequest
+	(*RecordVisitsResponse)(nil),       // 10: v1.RecordVisitsResponse
+	(*PreviewURLRequest)(nil),          // 11: v1.PreviewURLRequest
+	(*LinkPreview)(nil),                // 12: v1.LinkPreview
+	(*VerifyLinkPasswordRequest)(nil),  // 13: v1.VerifyLinkPasswordRequest
+	(*VerifyLinkPasswordResponse)(nil), // 14: v1.VerifyLinkPasswordResponse
+	(*Domain)(nil),                     // 15: v1.Domain
+	(*CreateDomainRequest)(nil),        // 16: v1.CreateDomainRequest
+	(*ListDomainsRequest)(nil),         // 17: v1.ListDomainsRequest
+	(*ListDomainsResponse)(nil),        // 18: v1.ListDomainsResponse
+	(*ListURLsRequest)(nil),            // 19: v1.ListURLsRequest
+	(*Link)(nil),                       // 20: v1.Link
+	(*ListURLsResponse)(nil),           // 21: v1.ListURLsResponse
+	(*SetLinkMetadataRequest)(nil),     // 22: v1.SetLinkMetadataRequest
+	(*GetLinkMetadataRequest)(nil),     // 23: v1.GetLinkMetadataRequest
+	(*LinkMetadata)(nil),               // 24: v1.LinkMetadata
+	(*ListTagsRequest)(nil),            // 25: v1.ListTagsRequest
+	(*Tag)(nil),                        // 26: v1.Tag
+	(*ListTagsResponse)(nil),           // 27: v1.ListTagsResponse
+	(*DeleteURLRequest)(nil),           // 28: v1.DeleteURLRequest
+	(*DeleteURLResponse)(nil),          // 29: v1.DeleteURLResponse
+	(*DisableURLRequest)(nil),          // 30: v1.DisableURLRequest
+	(*RetargetURLRequest)(nil),         // 31: v1.RetargetURLRequest
+	(*GetCacheEntryRequest)(nil),       // 32: v1.GetCacheEntryRequest
+	(*CacheEntry)(nil),                 // 33: v1.CacheEntry
+	(*ListAuditEventsRequest)(nil),     // 34: v1.ListAuditEventsRequest
+	(*AuditEvent)(nil),                 // 35: v1.AuditEvent
+	(*ListAuditEventsResponse)(nil),    // 36: v1.ListAuditEventsResponse
+	(*APIKey)(nil),                     // 37: v1.APIKey
+	(*CreateAPIKeyRequest)(nil),        // 38: v1.CreateAPIKeyRequest
+	(*CreateAPIKeyResponse)(nil),       // 39: v1.CreateAPIKeyResponse
+	(*ListAPIKeysRequest)(nil),         // 40: v1.ListAPIKeysRequest
+	(*ListAPIKeysResponse)(nil),        // 41: v1.ListAPIKeysResponse
+	(*RevokeAPIKeyRequest)(nil),        // 42: v1.RevokeAPIKeyRequest
+	(*AuthenticateAPIKeyRequest)(nil),  // 43: v1.AuthenticateAPIKeyRequest
+	nil,                                // 44: v1.ShortenURLRequest.UtmEntry
 }
 var file_v1_shortener_proto_depIdxs = []int32{
-	0, // 0: v1.ShortenURLBatchRequest.urls:type_name -> v1.ShortenURLRequest
-	1, // 1: v1.ShortenURLBatchResponse.urls:type_name -> v1.ShortenURLResponse
-	0, // 2: v1.URLShortenerService.ShortenURL:input_type -> v1.ShortenURLRequest
-	2, // 3: v1.URLShortenerService.ShortenURLBatch:input_type -> v1.ShortenURLBatchRequest
-	4, // 4: v1.URLShortenerService.GetURL:input_type -> v1.GetURLRequest
-	1, // 5: v1.URLShortenerService.ShortenURL:output_type -> v1.ShortenURLResponse
-	3, // 6: v1.URLShortenerService.ShortenURLBatch:output_type -> v1.ShortenURLBatchResponse
-	5, // 7: v1.URLShortenerService.GetURL:output_type -> v1.GetURLResponse
-	5, // [5:8] is the sub-list for method output_type
-	2, // [2:5] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	44, // 0: v1.ShortenURLRequest.utm:type_name -> v1.ShortenURLRequest.UtmEntry
+	2,  // 1: v1.ShortenURLRequest.rules:type_name -> v1.RoutingRule
+	1,  // 2: v1.ShortenURLRequest.destinations:type_name -> v1.Destination
+	0,  // 3: v1.ShortenURLBatchRequest.urls:type_name -> v1.ShortenURLRequest
+	3,  // 4: v1.ShortenURLBatchResponse.urls:type_name -> v1.ShortenURLResponse
+	8,  // 5: v1.RecordVisitsRequest.visits:type_name -> v1.Visit
+	15, // 6: v1.ListDomainsResponse.domains:type_name -> v1.Domain
+	20, // 7: v1.ListURLsResponse.links:type_name -> v1.Link
+	26, // 8: v1.ListTagsResponse.tags:type_name -> v1.Tag
+	20, // 9: v1.CacheEntry.link:type_name -> v1.Link
+	35, // 10: v1.ListAuditEventsResponse.events:type_name -> v1.AuditEvent
+	37, // 11: v1.CreateAPIKeyResponse.api_key:type_name -> v1.APIKey
+	37, // 12: v1.ListAPIKeysResponse.api_keys:type_name -> v1.APIKey
+	0,  // 13: v1.URLShortenerService.ShortenURL:input_type -> v1.ShortenURLRequest
+	4,  // 14: v1.URLShortenerService.ShortenURLBatch:input_type -> v1.ShortenURLBatchRequest
+	6,  // 15: v1.URLShortenerService.GetURL:input_type -> v1.GetURLRequest
+	9,  // 16: v1.URLShortenerService.RecordVisits:input_type -> v1.RecordVisitsRequest
+	11, // 17: v1.URLShortenerService.PreviewURL:input_type -> v1.PreviewURLRequest
+	13, // 18: v1.URLShortenerService.VerifyLinkPassword:input_type -> v1.VerifyLinkPasswordRequest
+	16, // 19: v1.URLShortenerService.CreateDomain:input_type -> v1.CreateDomainRequest
+	17, // 20: v1.URLShortenerService.ListDomains:input_type -> v1.ListDomainsRequest
+	19, // 21: v1.URLShortenerService.ListURLs:input_type -> v1.ListURLsRequest
+	22, // 22: v1.URLShortenerService.SetLinkMetadata:input_type -> v1.SetLinkMetadataRequest
+	23, // 23: v1.URLShortenerService.GetLinkMetadata:input_type -> v1.GetLinkMetadataRequest
+	25, // 24: v1.URLShortenerService.ListTags:input_type -> v1.ListTagsRequest
+	28, // 25: v1.URLShortenerService.DeleteURL:input_type -> v1.DeleteURLRequest
+	30, // 26: v1.URLShortenerService.DisableURL:input_type -> v1.DisableURLRequest
+	31, // 27: v1.URLShortenerService.RetargetURL:input_type -> v1.RetargetURLRequest
+	32, // 28: v1.URLShortenerService.GetCacheEntry:input_type -> v1.GetCacheEntryRequest
+	34, // 29: v1.URLShortenerService.ListAuditEvents:input_type -> v1.ListAuditEventsRequest
+	38, // 30: v1.URLShortenerService.CreateAPIKey:input_type -> v1.CreateAPIKeyRequest
+	40, // 31: v1.URLShortenerService.ListAPIKeys:input_type -> v1.ListAPIKeysRequest
+	42, // 32: v1.URLShortenerService.RevokeAPIKey:input_type -> v1.RevokeAPIKeyRequest
+	43, // 33: v1.URLShortenerService.AuthenticateAPIKey:input_type -> v1.AuthenticateAPIKeyRequest
+	3,  // 34: v1.URLShortenerService.ShortenURL:output_type -> v1.ShortenURLResponse
+	5,  // 35: v1.URLShortenerService.ShortenURLBatch:output_type -> v1.ShortenURLBatchResponse
+	7,  // 36: v1.URLShortenerService.GetURL:output_type -> v1.GetURLResponse
+	10, // 37: v1.URLShortenerService.RecordVisits:output_type -> v1.RecordVisitsResponse
+	12, // 38: v1.URLShortenerService.PreviewURL:output_type -> v1.LinkPreview
+	14, // 39: v1.URLShortenerService.VerifyLinkPassword:output_type -> v1.VerifyLinkPasswordResponse
+	15, // 40: v1.URLShortenerService.CreateDomain:output_type -> v1.Domain
+	18, // 41: v1.URLShortenerService.ListDomains:output_type -> v1.ListDomainsResponse
+	21, // 42: v1.URLShortenerService.ListURLs:output_type -> v1.ListURLsResponse
+	24, // 43: v1.URLShortenerService.SetLinkMetadata:output_type -> v1.LinkMetadata
+	24, // 44: v1.URLShortenerService.GetLinkMetadata:output_type -> v1.LinkMetadata
+	27, // 45: v1.URLShortenerService.ListTags:output_type -> v1.ListTagsResponse
+	29, // 46: v1.URLShortenerService.DeleteURL:output_type -> v1.DeleteURLResponse
+	20, // 47: v1.URLShortenerService.DisableURL:output_type -> v1.Link
+	20, // 48: v1.URLShortenerService.RetargetURL:output_type -> v1.Link
+	33, // 49: v1.URLShortenerService.GetCacheEntry:output_type -> v1.CacheEntry
+	36, // 50: v1.URLShortenerService.ListAuditEvents:output_type -> v1.ListAuditEventsResponse
+	39, // 51: v1.URLShortenerService.CreateAPIKey:output_type -> v1.CreateAPIKeyResponse
+	41, // 52: v1.URLShortenerService.ListAPIKeys:output_type -> v1.ListAPIKeysResponse
+	37, // 53: v1.URLShortenerService.RevokeAPIKey:output_type -> v1.APIKey
+	37, // 54: v1.URLShortenerService.AuthenticateAPIKey:output_type -> v1.APIKey
+	34, // [34:55] is the sub-list for method output_type
+	13, // [13:34] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_v1_shortener_proto_init() }
@@ -368,13 +3254,14 @@ func file_v1_shortener_proto_init() {
 	if File_v1_shortener_proto != nil {
 		return
 	}
+	file_v1_shortener_proto_msgTypes[19].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_v1_shortener_proto_rawDesc), len(file_v1_shortener_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   45,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,9 +19,27 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	URLShortenerService_ShortenURL_FullMethodName      = "/v1.URLShortenerService/ShortenURL"
-	URLShortenerService_ShortenURLBatch_FullMethodName = "/v1.URLShortenerService/ShortenURLBatch"
-	URLShortenerService_GetURL_FullMethodName          = "/v1.URLShortenerService/GetURL"
+	URLShortenerService_ShortenURL_FullMethodName         = "/v1.URLShortenerService/ShortenURL"
+	URLShortenerService_ShortenURLBatch_FullMethodName    = "/v1.URLShortenerService/ShortenURLBatch"
+	URLShortenerService_GetURL_FullMethodName             = "/v1.URLShortenerService/GetURL"
+	URLShortenerService_RecordVisits_FullMethodName       = "/v1.URLShortenerService/RecordVisits"
+	URLShortenerService_PreviewURL_FullMethodName         = "/v1.URLShortenerService/PreviewURL"
+	URLShortenerService_VerifyLinkPassword_FullMethodName = "/v1.URLShortenerService/VerifyLinkPassword"
+	URLShortenerService_CreateDomain_FullMethodName       = "/v1.URLShortenerService/CreateDomain"
+	URLShortenerService_ListDomains_FullMethodName        = "/v1.URLShortenerService/ListDomains"
+	URLShortenerService_ListURLs_FullMethodName           = "/v1.URLShortenerService/ListURLs"
+	URLShortenerService_SetLinkMetadata_FullMethodName    = "/v1.URLShortenerService/SetLinkMetadata"
+	URLShortenerService_GetLinkMetadata_FullMethodName    = "/v1.URLShortenerService/GetLinkMetadata"
+	URLShortenerService_ListTags_FullMethodName           = "/v1.URLShortenerService/ListTags"
+	URLShortenerService_DeleteURL_FullMethodName          = "/v1.URLShortenerService/DeleteURL"
+	URLShortenerService_DisableURL_FullMethodName         = "/v1.URLShortenerService/DisableURL"
+	URLShortenerService_RetargetURL_FullMethodName        = "/v1.URLShortenerService/RetargetURL"
+	URLShortenerService_GetCacheEntry_FullMethodName      = "/v1.URLShortenerService/GetCacheEntry"
+	URLShortenerService_ListAuditEvents_FullMethodName    = "/v1.URLShortenerService/ListAuditEvents"
+	URLShortenerService_CreateAPIKey_FullMethodName       = "/v1.URLShortenerService/CreateAPIKey"
+	URLShortenerService_ListAPIKeys_FullMethodName        = "/v1.URLShortenerService/ListAPIKeys"
+	URLShortenerService_RevokeAPIKey_FullMethodName       = "/v1.URLShortenerService/RevokeAPIKey"
+	URLShortenerService_AuthenticateAPIKey_FullMethodName = "/v1.URLShortenerService/AuthenticateAPIKey"
 )
 
 // URLShortenerServiceClient is the client API for URLShortenerService service.
@@ -30,7 +48,58 @@ const (
 type URLShortenerServiceClient interface {
 	ShortenURL(ctx context.Context, in *ShortenURLRequest, opts ...grpc.CallOption) (*ShortenURLResponse, error)
 	ShortenURLBatch(ctx context.Context, in *ShortenURLBatchRequest, opts ...grpc.CallOption) (*ShortenURLBatchResponse, error)
+	// GetURL resolves the code for the visitor.
+	// Visitor attributes are read from the metadata:
+	// x-client-user-agent, x-client-platform, x-client-accept-language,
+	// x-client-country (ISO 3166-1 alpha-2 code),
+	// x-client-visitor-id (keeps the visitor on the same weighted destination),
+	// x-link-token (token from VerifyLinkPassword for the password-protected link).
+	// Returns NOT_FOUND if the link is not active yet or already expired,
+	// PERMISSION_DENIED if the link is password-protected and the token is missing or expired.
 	GetURL(ctx context.Context, in *GetURLRequest, opts ...grpc.CallOption) (*GetURLResponse, error)
+	// RecordVisits writes unshortened events of the visits the caller served from its cache of GetURL
+	// or resolved with skip_visit.
+	// Visits of links not active anymore are skipped.
+	RecordVisits(ctx context.Context, in *RecordVisitsRequest, opts ...grpc.CallOption) (*RecordVisitsResponse, error)
+	// PreviewURL returns where the link goes without visiting it, no unshortened event is written.
+	// The destination of the password-protected link is not revealed.
+	// Returns NOT_FOUND if the link is not active like GetURL does.
+	PreviewURL(ctx context.Context, in *PreviewURLRequest, opts ...grpc.CallOption) (*LinkPreview, error)
+	// VerifyLinkPassword checks the password of the protected link and issues a short-lived token for GetURL
+	VerifyLinkPassword(ctx context.Context, in *VerifyLinkPasswordRequest, opts ...grpc.CallOption) (*VerifyLinkPasswordResponse, error)
+	// CreateDomain registers a short domain with its own code namespace
+	CreateDomain(ctx context.Context, in *CreateDomainRequest, opts ...grpc.CallOption) (*Domain, error)
+	ListDomains(ctx context.Context, in *ListDomainsRequest, opts ...grpc.CallOption) (*ListDomainsResponse, error)
+	// ListURLs returns links matching the filters, newest first, page by page
+	// Admins list all links, other principals only their own, anonymous callers none
+	ListURLs(ctx context.Context, in *ListURLsRequest, opts ...grpc.CallOption) (*ListURLsResponse, error)
+	// SetLinkMetadata replaces title, notes and tags of the link
+	SetLinkMetadata(ctx context.Context, in *SetLinkMetadataRequest, opts ...grpc.CallOption) (*LinkMetadata, error)
+	// GetLinkMetadata returns title, notes and tags of the link, principals other than admins read only their own links
+	GetLinkMetadata(ctx context.Context, in *GetLinkMetadataRequest, opts ...grpc.CallOption) (*LinkMetadata, error)
+	// ListTags returns the tags in use with the number of their links
+	// Admins get the tags of all links, other principals of their own links only
+	ListTags(ctx context.Context, in *ListTagsRequest, opts ...grpc.CallOption) (*ListTagsResponse, error)
+	// DeleteURL deletes the link, its code becomes free for aliases
+	DeleteURL(ctx context.Context, in *DeleteURLRequest, opts ...grpc.CallOption) (*DeleteURLResponse, error)
+	// DisableURL expires the link right away
+	DisableURL(ctx context.Context, in *DisableURLRequest, opts ...grpc.CallOption) (*Link, error)
+	// RetargetURL changes the destination of the link
+	RetargetURL(ctx context.Context, in *RetargetURLRequest, opts ...grpc.CallOption) (*Link, error)
+	// GetCacheEntry returns the cached redirect of the link, if any
+	GetCacheEntry(ctx context.Context, in *GetCacheEntryRequest, opts ...grpc.CallOption) (*CacheEntry, error)
+	// ListAuditEvents returns who created, changed or deleted links and when, newest first
+	ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error)
+	// CreateAPIKey issues a key acting on behalf of the principal.
+	// The key is returned only here, only its SHA-256 hash is stored.
+	CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error)
+	// ListAPIKeys returns the keys without their secrets, newest first
+	ListAPIKeys(ctx context.Context, in *ListAPIKeysRequest, opts ...grpc.CallOption) (*ListAPIKeysResponse, error)
+	// RevokeAPIKey makes the key invalid right away, revoked keys are kept to be listed
+	RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error)
+	// AuthenticateAPIKey returns the key by its secret.
+	// Returns UNAUTHENTICATED if the key is unknown or revoked.
+	AuthenticateAPIKey(ctx context.Context, in *AuthenticateAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error)
 }
 
 type uRLShortenerServiceClient struct {
@@ -71,13 +140,244 @@ func (c *uRLShortenerServiceClient) GetURL(ctx context.Context, in *GetURLReques
 	return out, nil
 }
 
+func (c *uRLShortenerServiceClient) RecordVisits(ctx context.Context, in *RecordVisitsRequest, opts ...grpc.CallOption) (*RecordVisitsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecordVisitsResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_RecordVisits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) PreviewURL(ctx context.Context, in *PreviewURLRequest, opts ...grpc.CallOption) (*LinkPreview, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LinkPreview)
+	err := c.cc.Invoke(ctx, URLShortenerService_PreviewURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) VerifyLinkPassword(ctx context.Context, in *VerifyLinkPasswordRequest, opts ...grpc.CallOption) (*VerifyLinkPasswordResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyLinkPasswordResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_VerifyLinkPassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) CreateDomain(ctx context.Context, in *CreateDomainRequest, opts ...grpc.CallOption) (*Domain, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Domain)
+	err := c.cc.Invoke(ctx, URLShortenerService_CreateDomain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) ListDomains(ctx context.Context, in *ListDomainsRequest, opts ...grpc.CallOption) (*ListDomainsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDomainsResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_ListDomains_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) ListURLs(ctx context.Context, in *ListURLsRequest, opts ...grpc.CallOption) (*ListURLsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListURLsResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_ListURLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) SetLinkMetadata(ctx context.Context, in *SetLinkMetadataRequest, opts ...grpc.CallOption) (*LinkMetadata, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LinkMetadata)
+	err := c.cc.Invoke(ctx, URLShortenerService_SetLinkMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) GetLinkMetadata(ctx context.Context, in *GetLinkMetadataRequest, opts ...grpc.CallOption) (*LinkMetadata, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LinkMetadata)
+	err := c.cc.Invoke(ctx, URLShortenerService_GetLinkMetadata_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) ListTags(ctx context.Context, in *ListTagsRequest, opts ...grpc.CallOption) (*ListTagsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTagsResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_ListTags_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) DeleteURL(ctx context.Context, in *DeleteURLRequest, opts ...grpc.CallOption) (*DeleteURLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteURLResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_DeleteURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) DisableURL(ctx context.Context, in *DisableURLRequest, opts ...grpc.CallOption) (*Link, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Link)
+	err := c.cc.Invoke(ctx, URLShortenerService_DisableURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) RetargetURL(ctx context.Context, in *RetargetURLRequest, opts ...grpc.CallOption) (*Link, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Link)
+	err := c.cc.Invoke(ctx, URLShortenerService_RetargetURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) GetCacheEntry(ctx context.Context, in *GetCacheEntryRequest, opts ...grpc.CallOption) (*CacheEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CacheEntry)
+	err := c.cc.Invoke(ctx, URLShortenerService_GetCacheEntry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAuditEventsResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_ListAuditEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) CreateAPIKey(ctx context.Context, in *CreateAPIKeyRequest, opts ...grpc.CallOption) (*CreateAPIKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateAPIKeyResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_CreateAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) ListAPIKeys(ctx context.Context, in *ListAPIKeysRequest, opts ...grpc.CallOption) (*ListAPIKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAPIKeysResponse)
+	err := c.cc.Invoke(ctx, URLShortenerService_ListAPIKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) RevokeAPIKey(ctx context.Context, in *RevokeAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(APIKey)
+	err := c.cc.Invoke(ctx, URLShortenerService_RevokeAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerServiceClient) AuthenticateAPIKey(ctx context.Context, in *AuthenticateAPIKeyRequest, opts ...grpc.CallOption) (*APIKey, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(APIKey)
+	err := c.cc.Invoke(ctx, URLShortenerService_AuthenticateAPIKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // URLShortenerServiceServer is the server API for URLShortenerService service.
 // All implementations must embed UnimplementedURLShortenerServiceServer
 // for forward compatibility.
 type URLShortenerServiceServer interface {
 	ShortenURL(context.Context, *ShortenURLRequest) (*ShortenURLResponse, error)
 	ShortenURLBatch(context.Context, *ShortenURLBatchRequest) (*ShortenURLBatchResponse, error)
+	// GetURL resolves the code for the visitor.
+	// Visitor attributes are read from the metadata:
+	// x-client-user-agent, x-client-platform, x-client-accept-language,
+	// x-client-country (ISO 3166-1 alpha-2 code),
+	// x-client-visitor-id (keeps the visitor on the same weighted destination),
+	// x-link-token (token from VerifyLinkPassword for the password-protected link).
+	// Returns NOT_FOUND if the link is not active yet or already expired,
+	// PERMISSION_DENIED if the link is password-protected and the token is missing or expired.
 	GetURL(context.Context, *GetURLRequest) (*GetURLResponse, error)
+	// RecordVisits writes unshortened events of the visits the caller served from its cache of GetURL
+	// or resolved with skip_visit.
+	// Visits of links not active anymore are skipped.
+	RecordVisits(context.Context, *RecordVisitsRequest) (*RecordVisitsResponse, error)
+	// PreviewURL returns where the link goes without visiting it, no unshortened event is written.
+	// The destination of the password-protected link is not revealed.
+	// Returns NOT_FOUND if the link is not active like GetURL does.
+	PreviewURL(context.Context, *PreviewURLRequest) (*LinkPreview, error)
+	// VerifyLinkPassword checks the password of the protected link and issues a short-lived token for GetURL
+	VerifyLinkPassword(context.Context, *VerifyLinkPasswordRequest) (*VerifyLinkPasswordResponse, error)
+	// CreateDomain registers a short domain with its own code namespace
+	CreateDomain(context.Context, *CreateDomainRequest) (*Domain, error)
+	ListDomains(context.Context, *ListDomainsRequest) (*ListDomainsResponse, error)
+	// ListURLs returns links matching the filters, newest first, page by page
+	// Admins list all links, other principals only their own, anonymous callers none
+	ListURLs(context.Context, *ListURLsRequest) (*ListURLsResponse, error)
+	// SetLinkMetadata replaces title, notes and tags of the link
+	SetLinkMetadata(context.Context, *SetLinkMetadataRequest) (*LinkMetadata, error)
+	// GetLinkMetadata returns title, notes and tags of the link, principals other than admins read only their own links
+	GetLinkMetadata(context.Context, *GetLinkMetadataRequest) (*LinkMetadata, error)
+	// ListTags returns the tags in use with the number of their links
+	// Admins get the tags of all links, other principals of their own links only
+	ListTags(context.Context, *ListTagsRequest) (*ListTagsResponse, error)
+	// DeleteURL deletes the link, its code becomes free for aliases
+	DeleteURL(context.Context, *DeleteURLRequest) (*DeleteURLResponse, error)
+	// DisableURL expires the link right away
+	DisableURL(context.Context, *DisableURLRequest) (*Link, error)
+	// RetargetURL changes the destination of the link
+	RetargetURL(context.Context, *RetargetURLRequest) (*Link, error)
+	// GetCacheEntry returns the cached redirect of the link, if any
+	GetCacheEntry(context.Context, *GetCacheEntryRequest) (*CacheEntry, error)
+	// ListAuditEvents returns who created, changed or deleted links and when, newest first
+	ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error)
+	// CreateAPIKey issues a key acting on behalf of the principal.
+	// The key is returned only here, only its SHA-256 hash is stored.
+	CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error)
+	// ListAPIKeys returns the keys without their secrets, newest first
+	ListAPIKeys(context.Context, *ListAPIKeysRequest) (*ListAPIKeysResponse, error)
+	// RevokeAPIKey makes the key invalid right away, revoked keys are kept to be listed
+	RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*APIKey, error)
+	// AuthenticateAPIKey returns the key by its secret.
+	// Returns UNAUTHENTICATED if the key is unknown or revoked.
+	AuthenticateAPIKey(context.Context, *AuthenticateAPIKeyRequest) (*APIKey, error)
 	mustEmbedUnimplementedURLShortenerServiceServer()
 }
 
@@ -97,6 +397,60 @@ func (UnimplementedURLShortenerServiceServer) ShortenURLBatch(context.Context, *
 func (UnimplementedURLShortenerServiceServer) GetURL(context.Context, *GetURLRequest) (*GetURLResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetURL not implemented")
 }
+func (UnimplementedURLShortenerServiceServer) RecordVisits(context.Context, *RecordVisitsRequest) (*RecordVisitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordVisits not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) PreviewURL(context.Context, *PreviewURLRequest) (*LinkPreview, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PreviewURL not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) VerifyLinkPassword(context.Context, *VerifyLinkPasswordRequest) (*VerifyLinkPasswordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyLinkPassword not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) CreateDomain(context.Context, *CreateDomainRequest) (*Domain, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDomain not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) ListDomains(context.Context, *ListDomainsRequest) (*ListDomainsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDomains not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) ListURLs(context.Context, *ListURLsRequest) (*ListURLsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListURLs not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) SetLinkMetadata(context.Context, *SetLinkMetadataRequest) (*LinkMetadata, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetLinkMetadata not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) GetLinkMetadata(context.Context, *GetLinkMetadataRequest) (*LinkMetadata, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLinkMetadata not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) ListTags(context.Context, *ListTagsRequest) (*ListTagsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTags not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) DeleteURL(context.Context, *DeleteURLRequest) (*DeleteURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteURL not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) DisableURL(context.Context, *DisableURLRequest) (*Link, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableURL not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) RetargetURL(context.Context, *RetargetURLRequest) (*Link, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetargetURL not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) GetCacheEntry(context.Context, *GetCacheEntryRequest) (*CacheEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCacheEntry not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEvents not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) CreateAPIKey(context.Context, *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAPIKey not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) ListAPIKeys(context.Context, *ListAPIKeysRequest) (*ListAPIKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAPIKeys not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) RevokeAPIKey(context.Context, *RevokeAPIKeyRequest) (*APIKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeAPIKey not implemented")
+}
+func (UnimplementedURLShortenerServiceServer) AuthenticateAPIKey(context.Context, *AuthenticateAPIKeyRequest) (*APIKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AuthenticateAPIKey not implemented")
+}
 func (UnimplementedURLShortenerServiceServer) mustEmbedUnimplementedURLShortenerServiceServer() {}
 func (UnimplementedURLShortenerServiceServer) testEmbeddedByValue()                             {}
 
@@ -172,6 +526,330 @@ func _URLShortenerService_GetURL_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _URLShortenerService_RecordVisits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordVisitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).RecordVisits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_RecordVisits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).RecordVisits(ctx, req.(*RecordVisitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_PreviewURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreviewURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).PreviewURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_PreviewURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).PreviewURL(ctx, req.(*PreviewURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_VerifyLinkPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyLinkPasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).VerifyLinkPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_VerifyLinkPassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).VerifyLinkPassword(ctx, req.(*VerifyLinkPasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_CreateDomain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateDomainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).CreateDomain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_CreateDomain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).CreateDomain(ctx, req.(*CreateDomainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_ListDomains_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDomainsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).ListDomains(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_ListDomains_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).ListDomains(ctx, req.(*ListDomainsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_ListURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListURLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).ListURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_ListURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).ListURLs(ctx, req.(*ListURLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_SetLinkMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLinkMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).SetLinkMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_SetLinkMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).SetLinkMetadata(ctx, req.(*SetLinkMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_GetLinkMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLinkMetadataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).GetLinkMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_GetLinkMetadata_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).GetLinkMetadata(ctx, req.(*GetLinkMetadataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_ListTags_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTagsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).ListTags(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_ListTags_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).ListTags(ctx, req.(*ListTagsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_DeleteURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).DeleteURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_DeleteURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).DeleteURL(ctx, req.(*DeleteURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_DisableURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisableURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).DisableURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_DisableURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).DisableURL(ctx, req.(*DisableURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_RetargetURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetargetURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).RetargetURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_RetargetURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).RetargetURL(ctx, req.(*RetargetURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_GetCacheEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCacheEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).GetCacheEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_GetCacheEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).GetCacheEntry(ctx, req.(*GetCacheEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_ListAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).ListAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_ListAuditEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).ListAuditEvents(ctx, req.(*ListAuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_CreateAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).CreateAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_CreateAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).CreateAPIKey(ctx, req.(*CreateAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_ListAPIKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAPIKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).ListAPIKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_ListAPIKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).ListAPIKeys(ctx, req.(*ListAPIKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_RevokeAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).RevokeAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_RevokeAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).RevokeAPIKey(ctx, req.(*RevokeAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortenerService_AuthenticateAPIKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthenticateAPIKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServiceServer).AuthenticateAPIKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortenerService_AuthenticateAPIKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServiceServer).AuthenticateAPIKey(ctx, req.(*AuthenticateAPIKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // URLShortenerService_ServiceDesc is the grpc.ServiceDesc for URLShortenerService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -191,6 +869,78 @@ var URLShortenerService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetURL",
 			Handler:    _URLShortenerService_GetURL_Handler,
 		},
+		{
+			MethodName: "RecordVisits",
+			Handler:    _URLShortenerService_RecordVisits_Handler,
+		},
+		{
+			MethodName: "PreviewURL",
+			Handler:    _URLShortenerService_PreviewURL_Handler,
+		},
+		{
+			MethodName: "VerifyLinkPassword",
+			Handler:    _URLShortenerService_VerifyLinkPassword_Handler,
+		},
+		{
+			MethodName: "CreateDomain",
+			Handler:    _URLShortenerService_CreateDomain_Handler,
+		},
+		{
+			MethodName: "ListDomains",
+			Handler:    _URLShortenerService_ListDomains_Handler,
+		},
+		{
+			MethodName: "ListURLs",
+			Handler:    _URLShortenerService_ListURLs_Handler,
+		},
+		{
+			MethodName: "SetLinkMetadata",
+			Handler:    _URLShortenerService_SetLinkMetadata_Handler,
+		},
+		{
+			MethodName: "GetLinkMetadata",
+			Handler:    _URLShortenerService_GetLinkMetadata_Handler,
+		},
+		{
+			MethodName: "ListTags",
+			Handler:    _URLShortenerService_ListTags_Handler,
+		},
+		{
+			MethodName: "DeleteURL",
+			Handler:    _URLShortenerService_DeleteURL_Handler,
+		},
+		{
+			MethodName: "DisableURL",
+			Handler:    _URLShortenerService_DisableURL_Handler,
+		},
+		{
+			MethodName: "RetargetURL",
+			Handler:    _URLShortenerService_RetargetURL_Handler,
+		},
+		{
+			MethodName: "GetCacheEntry",
+			Handler:    _URLShortenerService_GetCacheEntry_Handler,
+		},
+		{
+			MethodName: "ListAuditEvents",
+			Handler:    _URLShortenerService_ListAuditEvents_Handler,
+		},
+		{
+			MethodName: "CreateAPIKey",
+			Handler:    _URLShortenerService_CreateAPIKey_Handler,
+		},
+		{
+			MethodName: "ListAPIKeys",
+			Handler:    _URLShortenerService_ListAPIKeys_Handler,
+		},
+		{
+			MethodName: "RevokeAPIKey",
+			Handler:    _URLShortenerService_RevokeAPIKey_Handler,
+		},
+		{
+			MethodName: "AuthenticateAPIKey",
+			Handler:    _URLShortenerService_AuthenticateAPIKey_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "v1/shortener.proto",
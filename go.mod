@@ -3,6 +3,7 @@ module github.com/misshanya/url-shortener
 go 1.24
 
 require (
+	github.com/boombuler/barcode v1.1.0
 	google.golang.org/grpc v1.74.2
 	google.golang.org/protobuf v1.36.6
 )
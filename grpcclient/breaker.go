@@ -0,0 +1,88 @@
+package grpcclient
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
+)
+
+// errBreakerOpen is returned without calling the shortener while the breaker is open
+var errBreakerOpen = status.Error(codes.Unavailable, "shortener is unavailable, circuit breaker is open")
+
+// Breaker fails RPCs fast while the shortener is down.
+// It opens after the number of failures in a row, and after the cooldown lets one RPC through:
+// the breaker closes if the shortener answers, otherwise stays open for another cooldown.
+// Only the shortener being unavailable or too slow is a failure, other errors are its answers.
+type Breaker struct {
+	failures int
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	inARow   int
+	open     bool
+	openedAt time.Time
+	probing  bool
+}
+
+func NewBreaker(failures int, cooldown time.Duration) *Breaker {
+	return &Breaker{failures: failures, cooldown: cooldown, now: time.Now}
+}
+
+// UnaryClientInterceptor guards the RPCs with the breaker
+func (b *Breaker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		allowed, probe := b.allow()
+		if !allowed {
+			return errBreakerOpen
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(err, probe)
+		return err
+	}
+}
+
+// allow reports whether the RPC may be sent and whether it is the probe of the open breaker
+func (b *Breaker) allow() (allowed, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true, false
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+// record counts the result of the RPC
+func (b *Breaker) record(err error, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		b.inARow++
+		if probe || b.inARow >= b.failures {
+			b.open = true
+			b.openedAt = b.now()
+		}
+	case codes.Canceled:
+		// The caller gave up, it says nothing about the shortener
+	default:
+		b.inARow = 0
+		if probe {
+			b.open = false
+		}
+	}
+}
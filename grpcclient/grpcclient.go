@@ -0,0 +1,135 @@
+// Package grpcclient configures the connection to the shortener: deadlines, retries, hedging and circuit breaking.
+// The gateway and the bot share it, so both of them survive the shortener failures the same way.
+package grpcclient
+
+import (
+	"context"
+	"encoding/json"
+	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"google.golang.org/grpc"
+	"strconv"
+	"time"
+)
+
+// idempotentMethods are retried while the shortener is unavailable, the attempt that found it unavailable wasn't handled
+var idempotentMethods = []string{"GetURL"}
+
+// hedgedMethods have no side effects, so they are hedged: their attempts may all be handled by the shortener.
+// Only side-effect-free RPCs may be added here. GetURL records the visit, a hedged visit would be counted twice,
+// so it is hedged only with Options.HedgeGetURL and skip_visit set.
+var hedgedMethods = []string{"AuthenticateAPIKey", "ListURLs", "PreviewURL"}
+
+// Options of the client, zero values disable the feature
+type Options struct {
+	// Timeout is the deadline of every RPC with all its attempts, an earlier deadline of the caller wins
+	Timeout time.Duration
+
+	// MaxAttempts of idempotent RPCs, the shortener being unavailable is retried with backoff
+	MaxAttempts int
+
+	// HedgingDelay enables hedging of the RPCs without side effects:
+	// another attempt is sent every time the delay passes with no answer, up to MaxAttempts
+	HedgingDelay time.Duration
+
+	// HedgeGetURL hedges GetURL calls with skip_visit set as well, the caller records their visits with RecordVisits
+	HedgeGetURL bool
+
+	// BreakerFailures opens the circuit breaker after so many failures in a row
+	BreakerFailures int
+
+	// BreakerCooldown is how long the open breaker fails RPCs before it lets one through to probe the shortener
+	BreakerCooldown time.Duration
+}
+
+// DialOptions returns the options of the connection to the shortener
+func DialOptions(opts Options) []grpc.DialOption {
+	var interceptors []grpc.UnaryClientInterceptor
+	if opts.BreakerFailures > 0 {
+		interceptors = append(interceptors, NewBreaker(opts.BreakerFailures, opts.BreakerCooldown).UnaryClientInterceptor())
+	}
+	if opts.Timeout > 0 {
+		interceptors = append(interceptors, timeoutInterceptor(opts.Timeout))
+	}
+	if opts.HedgingDelay > 0 && opts.MaxAttempts > 1 {
+		interceptors = append(interceptors, hedgingInterceptor(opts.HedgingDelay, opts.MaxAttempts, opts.HedgeGetURL))
+	}
+
+	return []grpc.DialOption{
+		grpc.WithDefaultServiceConfig(ServiceConfig(opts)),
+		grpc.WithChainUnaryInterceptor(interceptors...),
+	}
+}
+
+type serviceConfig struct {
+	MethodConfig []methodConfig `json:"methodConfig,omitempty"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method,omitempty"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+// ServiceConfig builds the gRPC service config with the retry policy of idempotent RPCs
+func ServiceConfig(opts Options) string {
+	var cfg serviceConfig
+	if opts.MaxAttempts > 1 {
+		names := make([]methodName, 0, len(idempotentMethods))
+		for _, method := range idempotentMethods {
+			names = append(names, methodName{Service: pb.URLShortenerService_ServiceDesc.ServiceName, Method: method})
+		}
+		cfg.MethodConfig = append(cfg.MethodConfig, methodConfig{
+			Name: names,
+			RetryPolicy: &retryPolicy{
+				MaxAttempts:          opts.MaxAttempts,
+				InitialBackoff:       durationJSON(100 * time.Millisecond),
+				MaxBackoff:           durationJSON(time.Second),
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []string{"UNAVAILABLE"},
+			},
+		})
+	}
+
+	b, _ := json.Marshal(cfg)
+	return string(b)
+}
+
+// durationJSON formats the duration as the JSON of google.protobuf.Duration, e.g. 0.1s
+func durationJSON(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// isHedged reports whether the RPC has no side effects: the full method name is one of hedgedMethods,
+// or it is GetURL skipping the visit and hedgeGetURL is set
+func isHedged(fullMethod string, req any, hedgeGetURL bool) bool {
+	if getURL, ok := req.(*pb.GetURLRequest); ok && fullMethod == pb.URLShortenerService_GetURL_FullMethodName {
+		return hedgeGetURL && getURL.GetSkipVisit()
+	}
+	for _, method := range hedgedMethods {
+		if fullMethod == "/"+pb.URLShortenerService_ServiceDesc.ServiceName+"/"+method {
+			return true
+		}
+	}
+	return false
+}
+
+// timeoutInterceptor sets the deadline of the RPC
+func timeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
@@ -0,0 +1,366 @@
+package grpcclient
+
+import (
+	"context"
+	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestServiceConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		excepted string
+	}{
+		{"No retries", Options{MaxAttempts: 1}, `{}`},
+		{
+			"Retries",
+			Options{MaxAttempts: 3},
+			`{"methodConfig":[{"name":[{"service":"v1.URLShortenerService","method":"GetURL"}],"retryPolicy":{"maxAttempts":3,"initialBackoff":"0.1s","maxBackoff":"1s","backoffMultiplier":2,"retryableStatusCodes":["UNAVAILABLE"]}}]}`,
+		},
+		{
+			"GetURL is retried with hedging",
+			Options{MaxAttempts: 3, HedgingDelay: 50 * time.Millisecond},
+			`{"methodConfig":[{"name":[{"service":"v1.URLShortenerService","method":"GetURL"}],"retryPolicy":{"maxAttempts":3,"initialBackoff":"0.1s","maxBackoff":"1s","backoffMultiplier":2,"retryableStatusCodes":["UNAVAILABLE"]}}]}`,
+		},
+	}
+	for _, tt := range tests {
+		got := ServiceConfig(tt.opts)
+		if got != tt.excepted {
+			t.Errorf("%s: ServiceConfig() = %s, excepted %s", tt.name, got, tt.excepted)
+		}
+
+		// gRPC rejects the connection if the service config is invalid
+		opts := append(DialOptions(tt.opts), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		conn, err := grpc.NewClient("passthrough:///shortener:5000", opts...)
+		if err != nil {
+			t.Errorf("%s: grpc.NewClient: %v", tt.name, err)
+			continue
+		}
+		conn.Close()
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	interceptor := timeoutInterceptor(time.Second)
+
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) > time.Second {
+			t.Errorf("deadline = %v, %v, excepted within a second", deadline, ok)
+		}
+		return nil
+	}
+	if err := interceptor(context.Background(), pb.URLShortenerService_ShortenURL_FullMethodName, nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+
+	// The earlier deadline of the caller wins
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	callerDeadline, _ := ctx.Deadline()
+	invoker = func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		if deadline, _ := ctx.Deadline(); !deadline.Equal(callerDeadline) {
+			t.Errorf("deadline = %v, excepted %v", deadline, callerDeadline)
+		}
+		return nil
+	}
+	if err := interceptor(ctx, pb.URLShortenerService_ShortenURL_FullMethodName, nil, nil, nil, invoker); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	b := NewBreaker(2, 10*time.Second)
+	b.now = func() time.Time { return now }
+	interceptor := b.UnaryClientInterceptor()
+
+	var calls int
+	var answer error
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return answer
+	}
+	call := func() error {
+		return interceptor(context.Background(), pb.URLShortenerService_GetURL_FullMethodName, nil, nil, nil, invoker)
+	}
+
+	// Answers of the shortener keep the breaker closed
+	answer = status.Error(codes.NotFound, "not found")
+	call()
+	answer = status.Error(codes.Unavailable, "connection refused")
+	call()
+	answer = status.Error(codes.NotFound, "not found")
+	call()
+	answer = status.Error(codes.Unavailable, "connection refused")
+	call()
+	if b.open {
+		t.Fatal("breaker opened without failures in a row")
+	}
+
+	// The second failure in a row opens it
+	call()
+	if !b.open {
+		t.Fatal("breaker is not open after failures in a row")
+	}
+	calls = 0
+	if err := call(); err != errBreakerOpen || calls != 0 {
+		t.Fatalf("open breaker: err = %v, calls = %d", err, calls)
+	}
+
+	// The failed probe opens it for another cooldown
+	now = now.Add(10 * time.Second)
+	if err := call(); status.Code(err) != codes.Unavailable || calls != 1 {
+		t.Fatalf("probe: err = %v, calls = %d", err, calls)
+	}
+	if err := call(); err != errBreakerOpen || calls != 1 {
+		t.Fatalf("after failed probe: err = %v, calls = %d", err, calls)
+	}
+
+	// The answered probe closes it
+	now = now.Add(10 * time.Second)
+	answer = nil
+	if err := call(); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if b.open {
+		t.Fatal("breaker is open after answered probe")
+	}
+}
+
+func TestBreakerSingleProbe(t *testing.T) {
+	now := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+	b := NewBreaker(1, time.Second)
+	b.now = func() time.Time { return now }
+
+	b.record(status.Error(codes.DeadlineExceeded, "deadline exceeded"), false)
+	now = now.Add(time.Second)
+
+	if allowed, probe := b.allow(); !allowed || !probe {
+		t.Fatalf("allow() = %v, %v, excepted the probe", allowed, probe)
+	}
+	if allowed, _ := b.allow(); allowed {
+		t.Fatal("second RPC is let through while probing")
+	}
+
+	// The canceled probe lets the next RPC probe
+	b.record(status.Error(codes.Canceled, "context canceled"), true)
+	if allowed, probe := b.allow(); !allowed || !probe {
+		t.Fatalf("allow() = %v, %v, excepted the probe", allowed, probe)
+	}
+}
+
+func TestHedging(t *testing.T) {
+	interceptor := hedgingInterceptor(20*time.Millisecond, 3, false)
+
+	t.Run("Slow attempt is hedged", func(t *testing.T) {
+		var attempts atomic.Int32
+		invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			if attempts.Add(1) == 1 {
+				<-ctx.Done()
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			reply.(*pb.LinkPreview).Url = "https://go.dev"
+			return nil
+		}
+
+		var resp pb.LinkPreview
+		err := interceptor(context.Background(), pb.URLShortenerService_PreviewURL_FullMethodName, &pb.PreviewURLRequest{Code: "1z"}, &resp, nil, invoker)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetUrl() != "https://go.dev" || attempts.Load() != 2 {
+			t.Fatalf("url = %q, attempts = %d", resp.GetUrl(), attempts.Load())
+		}
+	})
+
+	t.Run("Unavailable is retried at once", func(t *testing.T) {
+		var attempts atomic.Int32
+		invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			attempts.Add(1)
+			return status.Error(codes.Unavailable, "connection refused")
+		}
+
+		start := time.Now()
+		var resp pb.LinkPreview
+		err := interceptor(context.Background(), pb.URLShortenerService_PreviewURL_FullMethodName, &pb.PreviewURLRequest{Code: "1z"}, &resp, nil, invoker)
+		if status.Code(err) != codes.Unavailable || attempts.Load() != 3 {
+			t.Fatalf("err = %v, attempts = %d", err, attempts.Load())
+		}
+		if time.Since(start) >= 20*time.Millisecond {
+			t.Fatalf("attempts waited for the delay: %v", time.Since(start))
+		}
+	})
+
+	t.Run("Other errors are answers", func(t *testing.T) {
+		var attempts atomic.Int32
+		invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			attempts.Add(1)
+			return status.Error(codes.NotFound, "not found")
+		}
+
+		var resp pb.LinkPreview
+		err := interceptor(context.Background(), pb.URLShortenerService_PreviewURL_FullMethodName, &pb.PreviewURLRequest{Code: "1z"}, &resp, nil, invoker)
+		if status.Code(err) != codes.NotFound || attempts.Load() != 1 {
+			t.Fatalf("err = %v, attempts = %d", err, attempts.Load())
+		}
+	})
+
+	t.Run("RPC with side effects is sent once", func(t *testing.T) {
+		var attempts atomic.Int32
+		invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			attempts.Add(1)
+			return status.Error(codes.Unavailable, "connection refused")
+		}
+
+		var resp pb.ShortenURLResponse
+		err := interceptor(context.Background(), pb.URLShortenerService_ShortenURL_FullMethodName, &pb.ShortenURLRequest{Url: "https://go.dev"}, &resp, nil, invoker)
+		if status.Code(err) != codes.Unavailable || attempts.Load() != 1 {
+			t.Fatalf("err = %v, attempts = %d", err, attempts.Load())
+		}
+	})
+}
+
+func TestHedgedGetURLIsNotRetried(t *testing.T) {
+	shortener := &visitCounter{unavailable: true}
+	client := dialVisitCounter(t, shortener, Options{MaxAttempts: 3, HedgingDelay: 20 * time.Millisecond, HedgeGetURL: true})
+
+	// Every hedged attempt is sent once, the retry policy would send each of them up to 3 times
+	_, err := client.GetURL(context.Background(), &pb.GetURLRequest{Code: "1z", SkipVisit: true})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("err = %v, excepted unavailable", err)
+	}
+	if resolves := shortener.resolves.Load(); resolves != 3 {
+		t.Fatalf("resolves = %d, excepted 3", resolves)
+	}
+
+	// GetURL recording the visit isn't hedged, so the retry policy still applies
+	shortener.resolves.Store(0)
+	_, err = client.GetURL(context.Background(), &pb.GetURLRequest{Code: "1z"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("err = %v, excepted unavailable", err)
+	}
+	if resolves := shortener.resolves.Load(); resolves != 3 {
+		t.Fatalf("resolves = %d, excepted 3", resolves)
+	}
+}
+
+// visitCounter is the shortener that counts the visits recorded by GetURL and RecordVisits, GetURL and PreviewURL answer after the delay.
+// GetURL fails as unavailable if unavailable is set.
+type visitCounter struct {
+	pb.UnimplementedURLShortenerServiceServer
+	delay       time.Duration
+	unavailable bool
+	resolves    atomic.Int32
+	visits      atomic.Int32
+	previews    atomic.Int32
+}
+
+func (s *visitCounter) GetURL(ctx context.Context, req *pb.GetURLRequest) (*pb.GetURLResponse, error) {
+	s.resolves.Add(1)
+	if !req.GetSkipVisit() {
+		s.visits.Add(1)
+	}
+	time.Sleep(s.delay)
+	if s.unavailable {
+		return nil, status.Error(codes.Unavailable, "shutting down")
+	}
+	return &pb.GetURLResponse{Url: "https://go.dev"}, nil
+}
+
+func (s *visitCounter) RecordVisits(ctx context.Context, req *pb.RecordVisitsRequest) (*pb.RecordVisitsResponse, error) {
+	s.visits.Add(int32(len(req.GetVisits())))
+	return &pb.RecordVisitsResponse{Recorded: int32(len(req.GetVisits()))}, nil
+}
+
+func (s *visitCounter) PreviewURL(ctx context.Context, req *pb.PreviewURLRequest) (*pb.LinkPreview, error) {
+	s.previews.Add(1)
+	time.Sleep(s.delay)
+	return &pb.LinkPreview{Url: "https://go.dev"}, nil
+}
+
+// dialVisitCounter connects the client with the options to the counting shortener
+func dialVisitCounter(t *testing.T, shortener *visitCounter, options Options) pb.URLShortenerServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterURLShortenerServiceServer(server, shortener)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	opts := append(DialOptions(options),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+	)
+	conn, err := grpc.NewClient("passthrough:///shortener", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewURLShortenerServiceClient(conn)
+}
+
+func TestHedgingCountsVisitsOnce(t *testing.T) {
+	shortener := &visitCounter{delay: 100 * time.Millisecond}
+	client := dialVisitCounter(t, shortener, Options{MaxAttempts: 3, HedgingDelay: 20 * time.Millisecond})
+
+	if _, err := client.GetURL(context.Background(), &pb.GetURLRequest{Code: "1z"}); err != nil {
+		t.Fatal(err)
+	}
+	if visits := shortener.visits.Load(); visits != 1 {
+		t.Fatalf("visits = %d, excepted 1", visits)
+	}
+
+	// GetURL skipping the visit is hedged only with HedgeGetURL
+	if _, err := client.GetURL(context.Background(), &pb.GetURLRequest{Code: "1z", SkipVisit: true}); err != nil {
+		t.Fatal(err)
+	}
+	if resolves := shortener.resolves.Load(); resolves != 2 {
+		t.Fatalf("resolves = %d, excepted 2", resolves)
+	}
+
+	// The slow preview is hedged, so hedging is on
+	if _, err := client.PreviewURL(context.Background(), &pb.PreviewURLRequest{Code: "1z"}); err != nil {
+		t.Fatal(err)
+	}
+	if previews := shortener.previews.Load(); previews < 2 {
+		t.Fatalf("previews = %d, excepted hedged attempts", previews)
+	}
+}
+
+func TestHedgeGetURL(t *testing.T) {
+	shortener := &visitCounter{delay: 100 * time.Millisecond}
+	client := dialVisitCounter(t, shortener, Options{MaxAttempts: 3, HedgingDelay: 20 * time.Millisecond, HedgeGetURL: true})
+
+	// GetURL recording the visit is never hedged
+	if _, err := client.GetURL(context.Background(), &pb.GetURLRequest{Code: "1z"}); err != nil {
+		t.Fatal(err)
+	}
+	if resolves, visits := shortener.resolves.Load(), shortener.visits.Load(); resolves != 1 || visits != 1 {
+		t.Fatalf("resolves = %d, visits = %d, excepted 1 and 1", resolves, visits)
+	}
+
+	// The hedged attempts skip the visit, the caller records it once
+	if _, err := client.GetURL(context.Background(), &pb.GetURLRequest{Code: "1z", SkipVisit: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.RecordVisits(context.Background(), &pb.RecordVisitsRequest{Visits: []*pb.Visit{{Code: "1z"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if resolves := shortener.resolves.Load(); resolves < 3 {
+		t.Fatalf("resolves = %d, excepted hedged attempts", resolves)
+	}
+	if visits := shortener.visits.Load(); visits != 2 {
+		t.Fatalf("visits = %d, excepted 2", visits)
+	}
+}
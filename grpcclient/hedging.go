@@ -0,0 +1,71 @@
+package grpcclient
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+// hedgingInterceptor sends another attempt of the RPC without side effects every time the delay passes with no answer,
+// or at once if an attempt finds the shortener unavailable, up to maxAttempts.
+// The first answer wins and the other attempts are canceled.
+// grpc-go ignores hedgingPolicy of the service config, so hedging is done here.
+// The attempts skip the retry policy of the service config, GetURL would be sent up to maxAttempts times by every hedge.
+func hedgingInterceptor(delay time.Duration, maxAttempts int, hedgeGetURL bool) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		replyMsg, ok := reply.(proto.Message)
+		if !ok || !isHedged(method, req, hedgeGetURL) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// With no buffer for replays the attempt is committed once the request is sent, so it is never retried
+		opts = append(opts, grpc.MaxRetryRPCBufferSize(0))
+
+		type result struct {
+			reply proto.Message
+			err   error
+		}
+		results := make(chan result, maxAttempts)
+		attempt := func() {
+			r := replyMsg.ProtoReflect().New().Interface()
+			err := invoker(ctx, method, req, r, cc, opts...)
+			results <- result{reply: r, err: err}
+		}
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		go attempt()
+		sent, done := 1, 0
+		for {
+			select {
+			case <-timer.C:
+				if sent < maxAttempts {
+					go attempt()
+					sent++
+					timer.Reset(delay)
+				}
+			case res := <-results:
+				done++
+				if res.err == nil {
+					proto.Merge(replyMsg, res.reply)
+					return nil
+				}
+				if status.Code(res.err) != codes.Unavailable || done == maxAttempts {
+					return res.err
+				}
+				if sent < maxAttempts {
+					go attempt()
+					sent++
+					timer.Reset(delay)
+				}
+			}
+		}
+	}
+}
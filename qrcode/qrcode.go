@@ -0,0 +1,216 @@
+// Package qrcode renders QR codes of short links as PNG and SVG images.
+// The gateway and the bot share it, so the same link gets the same image everywhere.
+package qrcode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+)
+
+// Limits of the options
+const (
+	MinSize   = 64
+	MaxSize   = 2048
+	MaxMargin = 16
+)
+
+// ErrInvalidOptions is returned if the options can't be used to render the code
+var ErrInvalidOptions = errors.New("invalid qr code options")
+
+// Level is the error correction level, higher levels survive more damage but make denser codes
+type Level string
+
+const (
+	LevelLow      Level = "L"
+	LevelMedium   Level = "M"
+	LevelQuartile Level = "Q"
+	LevelHigh     Level = "H"
+)
+
+// ParseLevel parses one of L, M, Q or H in any case
+func ParseLevel(s string) (Level, error) {
+	level := Level(strings.ToUpper(s))
+	if _, ok := qrLevels[level]; !ok {
+		return "", fmt.Errorf("%w: unknown error correction level %q", ErrInvalidOptions, s)
+	}
+	return level, nil
+}
+
+var qrLevels = map[Level]qr.ErrorCorrectionLevel{
+	LevelLow:      qr.L,
+	LevelMedium:   qr.M,
+	LevelQuartile: qr.Q,
+	LevelHigh:     qr.H,
+}
+
+// ParseColor parses a hex colour as RGB, RRGGBB or RRGGBBAA, with an optional leading #
+func ParseColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 8 || err != nil {
+		return color.NRGBA{}, fmt.Errorf("%w: invalid colour %q", ErrInvalidOptions, s)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// Options of the rendered image
+type Options struct {
+	// Size is the width and the height of the image in pixels
+	Size  int
+	Level Level
+
+	// Margin is the quiet zone around the code in modules
+	Margin     int
+	Foreground color.NRGBA
+	Background color.NRGBA
+}
+
+// DefaultOptions returns black on white 256px code with medium error correction and the standard margin
+func DefaultOptions() Options {
+	return Options{
+		Size:       256,
+		Level:      LevelMedium,
+		Margin:     4,
+		Foreground: color.NRGBA{A: 0xff},
+		Background: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+}
+
+// ETag returns a strong entity tag of the image of the content, it changes with the content and any of the options
+func ETag(content, format string, opts Options) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00%s\x00%d\x00%v\x00%v", content, format, opts.Size, opts.Level, opts.Margin, opts.Foreground, opts.Background)
+	return fmt.Sprintf(`"%x"`, h.Sum(nil)[:16])
+}
+
+// code is the matrix of the modules including the margin
+type code struct {
+	modules [][]bool
+}
+
+func encode(content string, opts Options) (*code, error) {
+	level, ok := qrLevels[opts.Level]
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("%w: unknown error correction level %q", ErrInvalidOptions, opts.Level)
+	case opts.Size < MinSize || opts.Size > MaxSize:
+		return nil, fmt.Errorf("%w: size must be between %d and %d", ErrInvalidOptions, MinSize, MaxSize)
+	case opts.Margin < 0 || opts.Margin > MaxMargin:
+		return nil, fmt.Errorf("%w: margin must be between 0 and %d", ErrInvalidOptions, MaxMargin)
+	}
+
+	bc, err := qr.EncodeWithColor(content, level, qr.Auto, barcode.ColorScheme8)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode qr code: %w", err)
+	}
+
+	dim := bc.Bounds().Dx()
+	n := dim + 2*opts.Margin
+	if n > opts.Size {
+		return nil, fmt.Errorf("%w: size must be at least %d for this link", ErrInvalidOptions, n)
+	}
+
+	modules := make([][]bool, n)
+	for y := range modules {
+		modules[y] = make([]bool, n)
+	}
+	for y := 0; y < dim; y++ {
+		for x := 0; x < dim; x++ {
+			modules[y+opts.Margin][x+opts.Margin] = bc.At(x, y) == barcode.ColorScheme8.Foreground
+		}
+	}
+
+	return &code{modules: modules}, nil
+}
+
+// PNG renders the content as a PNG image.
+// Modules are whole pixels, the image is centered and padded with the background up to the size.
+func PNG(content string, opts Options) ([]byte, error) {
+	c, err := encode(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(c.modules)
+	scale := opts.Size / n
+	offset := (opts.Size - n*scale) / 2
+
+	img := image.NewPaletted(image.Rect(0, 0, opts.Size, opts.Size), color.Palette{opts.Background, opts.Foreground})
+	for y, row := range c.modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for py := offset + y*scale; py < offset+(y+1)*scale; py++ {
+				for px := offset + x*scale; px < offset+(x+1)*scale; px++ {
+					img.SetColorIndex(px, py, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders the content as an SVG image.
+// Dark modules are drawn as one path of horizontal runs, so the image scales without seams.
+func SVG(content string, opts Options) ([]byte, error) {
+	c, err := encode(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(c.modules)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" %s/>`, n, n, svgFill(opts.Background))
+	fmt.Fprintf(&buf, `<path %s d="`, svgFill(opts.Foreground))
+	for y, row := range c.modules {
+		for x := 0; x < n; x++ {
+			if !row[x] {
+				continue
+			}
+			run := 1
+			for x+run < n && row[x+run] {
+				run++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", x, y, run, run)
+			x += run
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+
+	return buf.Bytes(), nil
+}
+
+// svgFill returns the fill attributes of the colour, the opacity is set only for translucent colours
+func svgFill(c color.NRGBA) string {
+	fill := fmt.Sprintf(`fill="#%02x%02x%02x"`, c.R, c.G, c.B)
+	if c.A != 0xff {
+		fill += fmt.Sprintf(` fill-opacity="%s"`, strconv.FormatFloat(float64(c.A)/0xff, 'f', 3, 64))
+	}
+	return fill
+}
@@ -0,0 +1,141 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+const testLink = "https://sh.some/3a"
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		input    string
+		excepted color.NRGBA
+	}{
+		{"000", color.NRGBA{A: 0xff}},
+		{"#1565c0", color.NRGBA{R: 0x15, G: 0x65, B: 0xc0, A: 0xff}},
+		{"ffffff00", color.NRGBA{R: 0xff, G: 0xff, B: 0xff}},
+	}
+	for _, tt := range tests {
+		got, err := ParseColor(tt.input)
+		if err != nil {
+			t.Errorf("ParseColor(%q): %v", tt.input, err)
+			continue
+		}
+		if got != tt.excepted {
+			t.Errorf("ParseColor(%q) = %v, excepted %v", tt.input, got, tt.excepted)
+		}
+	}
+
+	for _, input := range []string{"", "12", "red", "#12345", "1234567890"} {
+		if _, err := ParseColor(input); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("ParseColor(%q) error = %v, excepted ErrInvalidOptions", input, err)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("q")
+	if err != nil || level != LevelQuartile {
+		t.Errorf("ParseLevel(q) = %q, %v", level, err)
+	}
+	if _, err := ParseLevel("X"); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("unknown level error = %v, excepted ErrInvalidOptions", err)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Foreground = color.NRGBA{R: 0x15, G: 0x65, B: 0xc0, A: 0xff}
+
+	data, err := PNG(testLink, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != opts.Size || b.Dy() != opts.Size {
+		t.Fatalf("image is %dx%d, excepted %dx%d", b.Dx(), b.Dy(), opts.Size, opts.Size)
+	}
+
+	// The corner is in the margin, the finder pattern starts right after it
+	c, err := encode(testLink, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scale := opts.Size / len(c.modules)
+	offset := (opts.Size-len(c.modules)*scale)/2 + opts.Margin*scale
+	if got := color.NRGBAModel.Convert(img.At(0, 0)); got != opts.Background {
+		t.Errorf("margin is %v, excepted background %v", got, opts.Background)
+	}
+	if got := color.NRGBAModel.Convert(img.At(offset, offset)); got != opts.Foreground {
+		t.Errorf("finder pattern is %v, excepted foreground %v", got, opts.Foreground)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Margin = 0
+	opts.Background = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0x80}
+
+	data, err := SVG(testLink, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svg := string(data)
+	for _, part := range []string{
+		`width="256" height="256"`,
+		`fill="#ffffff" fill-opacity="0.502"`,
+		`<path fill="#000000" d="M0 0h7v1h-7z`,
+	} {
+		if !strings.Contains(svg, part) {
+			t.Errorf("svg has no %s:\n%s", part, svg)
+		}
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	tests := map[string]func(opts *Options){
+		"too small":     func(opts *Options) { opts.Size = MinSize - 1 },
+		"too large":     func(opts *Options) { opts.Size = MaxSize + 1 },
+		"wide margin":   func(opts *Options) { opts.Margin = MaxMargin + 1 },
+		"unknown level": func(opts *Options) { opts.Level = "X" },
+	}
+	for name, modify := range tests {
+		opts := DefaultOptions()
+		modify(&opts)
+		if _, err := PNG(testLink, opts); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("%s: error = %v, excepted ErrInvalidOptions", name, err)
+		}
+	}
+
+	// Long links make codes with more modules than pixels
+	opts := DefaultOptions()
+	opts.Size = MinSize
+	if _, err := PNG(testLink+"?"+strings.Repeat("utm_source=newsletter&", 8), opts); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("dense code: error = %v, excepted ErrInvalidOptions", err)
+	}
+}
+
+func TestETag(t *testing.T) {
+	opts := DefaultOptions()
+	etag := ETag(testLink, "png", opts)
+	if etag != ETag(testLink, "png", opts) {
+		t.Error("etag of the same image differs")
+	}
+
+	opts.Margin = 2
+	for _, other := range []string{ETag(testLink, "png", opts), ETag(testLink, "svg", DefaultOptions()), ETag(testLink+"b", "png", DefaultOptions())} {
+		if other == etag {
+			t.Errorf("etag %s is reused for another image", etag)
+		}
+	}
+}
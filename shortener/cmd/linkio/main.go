@@ -0,0 +1,203 @@
+// Command linkio imports links with their codes into the shortener storage and exports them.
+//
+//	linkio import [-format csv|jsonl] [-on-conflict skip|overwrite|fail] [-batch-size 1000] [-dry-run] [file]
+//	linkio export [-format csv|jsonl] [-domain host] [-page-size 1000] [file]
+//
+// The storage is configured like the shortener, from .env or the environment.
+// Standard input and output are used if the file is not set.
+// Imported links are recorded in the audit log as changed by SHORTENER_PRINCIPAL, linkio if it is not set.
+// Imports write to the storage directly, so overwritten codes keep redirecting to their old URLs from the caches
+// until the entries expire, flush the Valkey of the shortener to drop them at once.
+// A dry run writes nothing, the default domain isn't registered either.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/misshanya/url-shortener/shortener/internal/app"
+	"github.com/misshanya/url-shortener/shortener/internal/audit"
+	"github.com/misshanya/url-shortener/shortener/internal/config"
+	"github.com/misshanya/url-shortener/shortener/internal/linkio"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/misshanya/url-shortener/shortener/internal/repository"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	principal := os.Getenv("SHORTENER_PRINCIPAL")
+	if principal == "" {
+		principal = "linkio"
+	}
+	ctx = audit.WithActor(ctx, audit.Actor{Principal: principal, RequestID: audit.NewRequestID()})
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = runImport(ctx, logger, os.Args[2:])
+	case "export":
+		err = runExport(ctx, logger, os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		logger.Error("failed", slog.String("command", os.Args[1]), slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: linkio import|export [flags] [file]")
+}
+
+func runImport(ctx context.Context, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "csv", "file format: csv or jsonl")
+	onConflict := flags.String("on-conflict", "fail", "what to do with taken codes: skip, overwrite or fail")
+	batchSize := flags.Int("batch-size", 1000, "links stored in one transaction")
+	dryRun := flags.Bool("dry-run", false, "check the file and count the links without storing them")
+	flags.Parse(args)
+
+	in := io.Reader(os.Stdin)
+	if flags.NArg() > 0 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	r, err := linkio.NewReader(in, linkio.Format(*format))
+	if err != nil {
+		return err
+	}
+
+	repo, closeStorage, err := openRepo(ctx, *dryRun)
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	start := time.Now()
+	result, err := linkio.Import(ctx, repo, r, linkio.ImportOptions{
+		OnConflict: models.ConflictPolicy(*onConflict),
+		DryRun:     *dryRun,
+		BatchSize:  *batchSize,
+		Progress: func(result models.ImportResult) {
+			logger.Info("imported", importAttrs(result, start)...)
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("import is done", append(importAttrs(result, start), slog.Bool("dry_run", *dryRun))...)
+	if result.Updated > 0 && !*dryRun {
+		logger.Warn("overwritten links are served from cache until it expires, flush the Valkey of the shortener to drop them",
+			slog.Int64("updated", result.Updated))
+	}
+	return nil
+}
+
+func importAttrs(result models.ImportResult, start time.Time) []any {
+	return []any{
+		slog.Int64("records", result.Total()),
+		slog.Int64("inserted", result.Inserted),
+		slog.Int64("updated", result.Updated),
+		slog.Int64("skipped", result.Skipped),
+		slog.Int64("conflicts", result.Conflicts),
+		slog.String("rate", rate(result.Total(), start)),
+	}
+}
+
+func runExport(ctx context.Context, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "csv", "file format: csv or jsonl")
+	domain := flags.String("domain", "", "export links of the domain only")
+	pageSize := flags.Int("page-size", 1000, "links loaded at once")
+	flags.Parse(args)
+
+	out := io.Writer(os.Stdout)
+	if flags.NArg() > 0 {
+		f, err := os.Create(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w, err := linkio.NewWriter(out, linkio.Format(*format))
+	if err != nil {
+		return err
+	}
+
+	repo, closeStorage, err := openRepo(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	start := time.Now()
+	exported, err := linkio.Export(ctx, repo, w, linkio.ExportOptions{
+		Domain:   strings.ToLower(*domain),
+		PageSize: int32(*pageSize),
+		Progress: func(exported int64) {
+			logger.Info("exported", slog.Int64("records", exported), slog.String("rate", rate(exported, start)))
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("export is done", slog.Int64("records", exported), slog.String("rate", rate(exported, start)))
+	return nil
+}
+
+// openRepo opens the configured storage and registers the default domain like the shortener does on start.
+// The dry run only reads, the domains are taken as the shortener registered them.
+func openRepo(ctx context.Context, dryRun bool) (repository.Repo, func() error, error) {
+	cfg, err := config.NewStorageConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	repo, closeStorage, err := app.OpenRepo(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dryRun {
+		return repo, closeStorage, nil
+	}
+
+	// Links moved to the default domain weren't reachable before, so they have nothing in cache to drop
+	if _, _, err := repo.SetDefaultDomain(ctx, strings.ToLower(cfg.DefaultDomain)); err != nil {
+		closeStorage()
+		return nil, nil, fmt.Errorf("failed to set default domain: %w", err)
+	}
+
+	return repo, closeStorage, nil
+}
+
+// rate formats records per second since start
+func rate(records int64, start time.Time) string {
+	return fmt.Sprintf("%.0f/s", float64(records)/time.Since(start).Seconds())
+}
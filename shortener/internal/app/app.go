@@ -2,19 +2,17 @@ package app
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/misshanya/url-shortener/shortener/internal/config"
 	"github.com/misshanya/url-shortener/shortener/internal/consumer"
-	"github.com/misshanya/url-shortener/shortener/internal/db"
-	"github.com/misshanya/url-shortener/shortener/internal/db/sqlc/storage"
+	"github.com/misshanya/url-shortener/shortener/internal/idalloc"
 	"github.com/misshanya/url-shortener/shortener/internal/repository"
 	"github.com/misshanya/url-shortener/shortener/internal/service"
 	handler "github.com/misshanya/url-shortener/shortener/internal/transport/grpc"
+	"github.com/misshanya/url-shortener/shortener/pkg/ipanon"
+	"github.com/misshanya/url-shortener/shortener/pkg/linktoken"
 	"github.com/segmentio/kafka-go"
 	"github.com/valkey-io/valkey-go"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
@@ -27,6 +25,7 @@ import (
 	"google.golang.org/grpc"
 	"log/slog"
 	"net"
+	"time"
 )
 
 var (
@@ -37,12 +36,15 @@ type App struct {
 	cfg            *config.Config
 	l              *slog.Logger
 	lis            *net.Listener
-	dbPool         *pgxpool.Pool
+	repo           repository.Repo
+	closeStorage   func() error
+	ids            idalloc.Allocator
 	grpcSrv        *grpc.Server
 	kafkaWriter    *kafka.Writer
 	kafkaReader    *kafka.Reader
 	valkeyClient   valkey.Client
 	consumer       *consumer.Consumer
+	svc            *service.Service
 	tracerProvider *trace.TracerProvider
 }
 
@@ -69,33 +71,42 @@ func New(ctx context.Context, cfg *config.Config, l *slog.Logger) (*App, error)
 		return nil, err
 	}
 
-	if err := a.initDB(ctx); err != nil {
+	if err := a.initStorage(ctx); err != nil {
 		return nil, err
 	}
 
-	if err := a.initValkey(); err != nil {
+	if err := a.initIDAllocator(); err != nil {
 		return nil, err
 	}
 
-	if err := a.migrateDB(); err != nil {
+	if err := a.initValkey(); err != nil {
 		return nil, err
 	}
 
-	queries := storage.New(a.dbPool)
-
 	a.initGRPCServer()
 
 	if err := a.initKafka(); err != nil {
 		return nil, err
 	}
 
-	repo := repository.NewPostgresRepo(queries)
+	anonymizer, err := ipanon.New(ipanon.Mode(cfg.Privacy.IPAnonymization), cfg.Privacy.IPHashKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ip anonymizer: %w", err)
+	}
+
 	valkeyRepo := repository.NewValkeyRepo(a.valkeyClient)
-	svc := service.New(repo, valkeyRepo, a.l, a.kafkaWriter, tracer, cfg.MaxBatchWorkers)
+	signer := linktoken.New([]byte(cfg.LinkToken.Secret), cfg.LinkToken.TTL)
+	svc := service.New(a.repo, valkeyRepo, a.l, a.kafkaWriter, tracer, signer, a.ids, anonymizer, cfg.MaxBatchWorkers)
+
+	a.svc = svc
+
+	if err := a.initDefaultDomain(ctx); err != nil {
+		return nil, err
+	}
 
 	a.consumer = consumer.New(a.l, a.kafkaReader, svc)
 
-	handler.NewHandler(a.grpcSrv, svc)
+	handler.NewHandler(a.grpcSrv, svc, a.cfg.Server.AdminToken)
 
 	return a, nil
 }
@@ -104,6 +115,7 @@ func New(ctx context.Context, cfg *config.Config, l *slog.Logger) (*App, error)
 func (a *App) Start(ctx context.Context, errChan chan<- error) {
 	a.l.Info("starting server", slog.String("addr", a.cfg.Server.Addr))
 	go a.consumer.ReadMessages(ctx)
+	go a.relayAudit(ctx)
 	if err := a.grpcSrv.Serve(*a.lis); err != nil {
 		errChan <- err
 	}
@@ -118,8 +130,10 @@ func (a *App) Stop(ctx context.Context) error {
 	a.l.Info("Stopping gRPC server...")
 	a.grpcSrv.GracefulStop()
 
-	a.l.Info("Closing database pool...")
-	a.dbPool.Close()
+	a.l.Info("Closing storage...")
+	if err := a.closeStorage(); err != nil {
+		stopErr = errors.Join(stopErr, fmt.Errorf("failed to close storage: %w", err))
+	}
 
 	a.l.Info("Closing Valkey connection...")
 	a.valkeyClient.Close()
@@ -141,16 +155,22 @@ func (a *App) Stop(ctx context.Context) error {
 	return nil
 }
 
-// initDB initializes a new pool for PostgreSQL db
-func initDB(ctx context.Context, dbURL string, maxConns int32) (*pgxpool.Pool, error) {
-	pool, err := pgxpool.New(ctx, dbURL)
-	if err != nil {
-		return nil, err
+// relayAudit publishes new audit events to Kafka until ctx is done,
+// events are kept in the storage until they are published, so nothing is lost if Kafka is down
+func (a *App) relayAudit(ctx context.Context) {
+	ticker := time.NewTicker(a.cfg.Audit.RelayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := a.svc.PublishAuditEvents(ctx); err != nil && ctx.Err() == nil {
+				a.l.Error("failed to publish audit events", "error", err)
+			}
+		}
 	}
-
-	pool.Config().MaxConns = maxConns
-
-	return pool, nil
 }
 
 // initTracing sets up a new OpenTelemetry provider
@@ -173,19 +193,52 @@ func (a *App) initListener() error {
 	return nil
 }
 
-// initDB sets up PostgreSQL db
-func (a *App) initDB(ctx context.Context) error {
-	dbPool, err := initDB(ctx, a.cfg.Postgres.URL, a.cfg.Postgres.MaxConns)
+// initStorage sets up the repository of the configured storage driver
+func (a *App) initStorage(ctx context.Context) error {
+	repo, closeStorage, err := OpenRepo(ctx, &a.cfg.StorageConfig)
 	if err != nil {
-		return fmt.Errorf("failed to init db connection: %w", err)
+		return err
+	}
+	a.repo = repo
+	a.closeStorage = closeStorage
+
+	a.l.Info("storage is set up", slog.String("driver", a.cfg.Storage.Driver))
+	return nil
+}
+
+// initIDAllocator sets up the allocator of the configured strategy,
+// the storage assigns IDs itself with the sequence strategy
+func (a *App) initIDAllocator() error {
+	switch a.cfg.IDs.Strategy {
+	case "sequence":
+	case "lease":
+		lease, err := idalloc.NewLease(a.repo, a.cfg.IDs.LeaseSize)
+		if err != nil {
+			return fmt.Errorf("failed to init id allocator: %w", err)
+		}
+		a.ids = lease
+	case "snowflake":
+		snowflake, err := idalloc.NewSnowflake(a.cfg.IDs.Node)
+		if err != nil {
+			return fmt.Errorf("failed to init id allocator: %w", err)
+		}
+		a.ids = snowflake
+	default:
+		return fmt.Errorf("unknown id strategy %q", a.cfg.IDs.Strategy)
 	}
-	a.dbPool = dbPool
+
+	a.l.Info("id allocator is set up", slog.String("strategy", a.cfg.IDs.Strategy))
 	return nil
 }
 
-// migrateDB performs a migration to ensure the schema is up to date
-func (a *App) migrateDB() error {
-	return db.Migrate(sql.OpenDB(stdlib.GetConnector(*a.dbPool.Config().ConnConfig)))
+// initDefaultDomain registers the configured default domain
+func (a *App) initDefaultDomain(ctx context.Context) error {
+	domain, err := a.svc.SetDefaultDomain(ctx, a.cfg.DefaultDomain)
+	if err != nil {
+		return fmt.Errorf("failed to set default domain: %w", err)
+	}
+	a.l.Info("default domain is set", slog.String("host", domain.Host))
+	return nil
 }
 
 // initValkey sets up a connection to cache
@@ -210,6 +263,7 @@ func (a *App) initGRPCServer() {
 	a.grpcSrv = grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
 			logging.UnaryServerInterceptor(InterceptorLogger(a.l), opts...),
+			handler.ActorInterceptor(),
 		),
 		grpc.StatsHandler(
 			otelgrpc.NewServerHandler(
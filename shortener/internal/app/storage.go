@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/misshanya/url-shortener/shortener/internal/config"
+	"github.com/misshanya/url-shortener/shortener/internal/db"
+	"github.com/misshanya/url-shortener/shortener/internal/repository"
+)
+
+// OpenRepo opens the repository of the configured storage driver and brings its schema up to date.
+// closeStorage releases connections of the storage.
+func OpenRepo(ctx context.Context, cfg *config.StorageConfig) (repo repository.Repo, closeStorage func() error, err error) {
+	switch cfg.Storage.Driver {
+	case "postgres":
+		pool, err := openPostgres(ctx, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return repository.NewPostgresRepo(pool), func() error { pool.Close(); return nil }, nil
+	case "sqlite":
+		sqliteDB, err := openSQLite(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return repository.NewSQLiteRepo(sqliteDB), sqliteDB.Close, nil
+	case "memory":
+		return repository.NewMemoryRepo(), func() error { return nil }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+// openPostgres initializes a new pool for PostgreSQL db and performs a migration to ensure the schema is up to date
+func openPostgres(ctx context.Context, cfg *config.StorageConfig) (*pgxpool.Pool, error) {
+	if cfg.Postgres.URL == "" {
+		return nil, errors.New("POSTGRES_URL is required for postgres storage")
+	}
+
+	pool, err := pgxpool.New(ctx, cfg.Postgres.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init db connection: %w", err)
+	}
+
+	pool.Config().MaxConns = cfg.Postgres.MaxConns
+
+	if err := db.Migrate(sql.OpenDB(stdlib.GetConnector(*pool.Config().ConnConfig))); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// openSQLite opens SQLite db and brings its schema up to date
+func openSQLite(cfg *config.StorageConfig) (*sql.DB, error) {
+	sqliteDB, err := db.OpenSQLite(cfg.Storage.SQLitePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite db: %w", err)
+	}
+
+	if err := db.MigrateSQLite(sqliteDB); err != nil {
+		sqliteDB.Close()
+		return nil, fmt.Errorf("failed to migrate SQLite db: %w", err)
+	}
+
+	return sqliteDB, nil
+}
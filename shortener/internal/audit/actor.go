@@ -0,0 +1,34 @@
+// Package audit carries who makes the request to the storage, where it is recorded with every link change
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Actor is the principal making the request, empty if anonymous, and the ID of the request
+type Actor struct {
+	Principal string
+	RequestID string
+}
+
+type actorKey struct{}
+
+// WithActor returns the context carrying the actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor of the request, zero if there is none
+func ActorFromContext(ctx context.Context) Actor {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
+
+// NewRequestID returns a random ID for the request that came without one
+func NewRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -2,24 +2,63 @@ package config
 
 import (
 	"github.com/ilyakaznacheev/cleanenv"
+	"time"
 )
 
 type Config struct {
-	Server   server
-	Postgres postgres
-	Kafka    kafka
-	Valkey   valkey
-	Tracing  tracing
+	StorageConfig
+
+	Server    server
+	IDs       ids
+	Kafka     kafka
+	Audit     audit
+	Valkey    valkey
+	Tracing   tracing
+	LinkToken linkToken
+	Privacy   privacy
 
 	MaxBatchWorkers int `env:"MAX_BATCH_WORKERS" env-default:"100"`
 }
 
+// StorageConfig is the part of Config needed to open the storage, the command line tools read it only
+type StorageConfig struct {
+	Storage  storage
+	Postgres postgres
+
+	// DefaultDomain is the host links are created on if no domain is requested,
+	// it is registered on start
+	DefaultDomain string `env:"DEFAULT_DOMAIN" env-default:"localhost:8080"`
+}
+
 type server struct {
 	Addr string `env:"SERVER_ADDR" env-default:":8080"`
+
+	// AdminToken is the bearer token of the admin RPCs such as CreateDomain, they are disabled if empty
+	AdminToken string `env:"ADMIN_TOKEN"`
+}
+
+// storage selects where links and domains are kept
+type storage struct {
+	// Driver is postgres, sqlite or memory
+	Driver     string `env:"STORAGE_DRIVER" env-default:"postgres"`
+	SQLitePath string `env:"SQLITE_PATH" env-default:"shortener.db"`
 }
 
+// ids selects how IDs of new links are allocated
+type ids struct {
+	// Strategy is sequence (assigned by the storage), lease or snowflake
+	Strategy string `env:"ID_STRATEGY" env-default:"sequence"`
+
+	// LeaseSize is the number of IDs a replica leases at once
+	LeaseSize int64 `env:"ID_LEASE_SIZE" env-default:"1000"`
+
+	// Node is the snowflake node ID from 0 to 1023, unique for every replica
+	Node int64 `env:"ID_NODE" env-default:"0"`
+}
+
+// postgres is used by the postgres storage driver, URL is required then
 type postgres struct {
-	URL      string `env:"POSTGRES_URL" env-required:"true"`
+	URL      string `env:"POSTGRES_URL"`
 	MaxConns int32  `env:"POSTGRES_MAX_CONNECTIONS" env-default:"100"`
 }
 
@@ -27,28 +66,59 @@ type kafka struct {
 	Addr string `env:"KAFKA_ADDR" env-required:"true"`
 }
 
+// audit configures publishing of the audit log to Kafka
+type audit struct {
+	// RelayInterval is how often new audit events are published
+	RelayInterval time.Duration `env:"AUDIT_RELAY_INTERVAL" env-default:"5s"`
+}
+
 type valkey struct {
 	Addr     string `env:"VALKEY_ADDR" env-required:"true"`
 	Password string `env:"VALKEY_PASSWORD" env-required:"true"`
 }
 
+// linkToken configures tokens unlocking password-protected links
+type linkToken struct {
+	Secret string        `env:"LINK_TOKEN_SECRET" env-required:"true"`
+	TTL    time.Duration `env:"LINK_TOKEN_TTL" env-default:"15m"`
+}
+
+// privacy configures what the clicks published to Kafka carry
+type privacy struct {
+	// IPAnonymization is full, truncate, hash or drop
+	IPAnonymization string `env:"IP_ANONYMIZATION" env-default:"truncate"`
+
+	// IPHashKey is the HMAC key of the hash mode
+	IPHashKey string `env:"IP_HASH_KEY"`
+}
+
 type tracing struct {
 	CollectorAddr string `env:"TRACING_COLLECTOR_ADDR" env-required:"true"`
 }
 
 func NewConfig() (*Config, error) {
 	var cfg Config
+	if err := read(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func NewStorageConfig() (*StorageConfig, error) {
+	var cfg StorageConfig
+	if err := read(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
 
+func read(cfg any) error {
 	// Read .env file
 	// If failed to read file, will try ReadEnv
-	if err := cleanenv.ReadConfig(".env", &cfg); err == nil {
-		return &cfg, nil
+	if err := cleanenv.ReadConfig(".env", cfg); err == nil {
+		return nil
 	}
 
 	// Read env
-	if err := cleanenv.ReadEnv(&cfg); err != nil {
-		return nil, err
-	}
-
-	return &cfg, nil
+	return cleanenv.ReadEnv(cfg)
 }
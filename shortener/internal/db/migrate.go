@@ -9,6 +9,9 @@ import (
 //go:embed migrations/*.sql
 var embedMigrations embed.FS
 
+//go:embed sqlite/*.sql
+var embedSQLiteMigrations embed.FS
+
 func Migrate(db *sql.DB) error {
 	goose.SetBaseFS(embedMigrations)
 
@@ -22,3 +25,19 @@ func Migrate(db *sql.DB) error {
 
 	return nil
 }
+
+// MigrateSQLite brings the schema of SQLite storage up to date.
+// SQLite has its own migrations, the schema is the same as in PostgreSQL.
+func MigrateSQLite(db *sql.DB) error {
+	goose.SetBaseFS(embedSQLiteMigrations)
+
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		return err
+	}
+
+	if err := goose.Up(db, "sqlite"); err != nil {
+		return err
+	}
+
+	return nil
+}
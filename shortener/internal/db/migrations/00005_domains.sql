@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS domains (
+    id SERIAL PRIMARY KEY,
+    host TEXT NOT NULL UNIQUE,
+    is_default BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS domains_default_idx ON domains (is_default) WHERE is_default;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS domain_id INTEGER REFERENCES domains (id),
+    ADD COLUMN IF NOT EXISTS alias TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS urls_domain_alias_idx ON urls (domain_id, alias) WHERE alias IS NOT NULL;
+CREATE INDEX IF NOT EXISTS urls_url_idx ON urls (url);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS urls_url_idx;
+DROP INDEX IF EXISTS urls_domain_alias_idx;
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS alias,
+    DROP COLUMN IF EXISTS domain_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS domains;
+-- +goose StatementEnd
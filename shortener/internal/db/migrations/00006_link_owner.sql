@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS owner TEXT,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+CREATE INDEX IF NOT EXISTS urls_owner_idx ON urls (owner, id);
+CREATE INDEX IF NOT EXISTS urls_created_at_idx ON urls (created_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS urls_url_trgm_idx ON urls USING gin (url gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS urls_url_trgm_idx;
+DROP INDEX IF EXISTS urls_created_at_idx;
+DROP INDEX IF EXISTS urls_owner_idx;
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS owner;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE urls
+    ADD COLUMN IF NOT EXISTS title TEXT,
+    ADD COLUMN IF NOT EXISTS notes TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS url_tags (
+    url_id BIGINT NOT NULL REFERENCES urls (id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (url_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS url_tags_tag_idx ON url_tags (tag_id, url_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS url_tags;
+DROP TABLE IF EXISTS tags;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE urls
+    DROP COLUMN IF EXISTS notes,
+    DROP COLUMN IF EXISTS title;
+-- +goose StatementEnd
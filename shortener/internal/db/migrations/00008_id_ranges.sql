@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS id_ranges (
+    name TEXT PRIMARY KEY,
+    next_id BIGINT NOT NULL
+);
+INSERT INTO id_ranges (name, next_id) VALUES ('urls', 1) ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS id_ranges;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS link_audit (
+    id BIGSERIAL PRIMARY KEY,
+    url_id BIGINT NOT NULL,
+    domain_id INTEGER,
+    code TEXT NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT,
+    request_id TEXT,
+    old_value JSONB,
+    new_value JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS link_audit_code_idx ON link_audit (domain_id, code, id);
+CREATE INDEX IF NOT EXISTS link_audit_actor_idx ON link_audit (actor, id);
+
+CREATE OR REPLACE FUNCTION link_audit_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'link_audit is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER link_audit_append_only
+    BEFORE UPDATE OR DELETE ON link_audit
+    FOR EACH ROW EXECUTE FUNCTION link_audit_append_only();
+
+-- Last audit entry published by the relay
+CREATE TABLE IF NOT EXISTS audit_relay (
+    name TEXT PRIMARY KEY,
+    last_id BIGINT NOT NULL
+);
+INSERT INTO audit_relay (name, last_id) VALUES ('kafka', 0) ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_relay;
+DROP TABLE IF EXISTS link_audit;
+DROP FUNCTION IF EXISTS link_audit_append_only();
+-- +goose StatementEnd
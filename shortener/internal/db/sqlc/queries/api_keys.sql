@@ -0,0 +1,17 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (prefix, key_hash, principal, name, scopes)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, prefix, key_hash, principal, name, scopes, created_at, revoked_at;
+
+-- name: GetAPIKeyByHash :one
+SELECT id, prefix, key_hash, principal, name, scopes, created_at, revoked_at FROM api_keys
+WHERE key_hash = $1;
+
+-- name: ListAPIKeys :many
+SELECT id, prefix, key_hash, principal, name, scopes, created_at, revoked_at FROM api_keys
+ORDER BY id DESC;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now())
+WHERE id = $1
+RETURNING id, prefix, key_hash, principal, name, scopes, created_at, revoked_at;
@@ -0,0 +1,42 @@
+-- name: LockAuditWrite :exec
+-- Writers share the lock, so the relay waits for the entries with taken IDs to be committed
+SELECT pg_advisory_xact_lock_shared(hashtext('link_audit'));
+
+-- name: InsertLinkAudit :exec
+INSERT INTO link_audit (url_id, domain_id, code, action, actor, request_id, old_value, new_value)
+SELECT e.url_id, e.domain_id, e.code, e.action, sqlc.narg('actor')::TEXT, sqlc.narg('request_id')::TEXT,
+       NULLIF(e.old_value, '')::JSONB, NULLIF(e.new_value, '')::JSONB
+FROM unnest(
+    sqlc.arg('url_ids')::BIGINT[],
+    sqlc.arg('domain_ids')::INTEGER[],
+    sqlc.arg('codes')::TEXT[],
+    sqlc.arg('actions')::TEXT[],
+    sqlc.arg('old_values')::TEXT[],
+    sqlc.arg('new_values')::TEXT[]
+) AS e (url_id, domain_id, code, action, old_value, new_value);
+
+-- name: ListLinkAudit :many
+SELECT id, url_id, domain_id, code, action, actor, request_id, old_value, new_value, created_at FROM link_audit
+WHERE (sqlc.narg('cursor')::BIGINT IS NULL OR id < sqlc.narg('cursor'))
+  AND (sqlc.narg('domain_id')::INTEGER IS NULL OR domain_id = sqlc.narg('domain_id'))
+  AND (sqlc.narg('code')::TEXT IS NULL OR code = sqlc.narg('code'))
+  AND (sqlc.narg('actor')::TEXT IS NULL OR actor = sqlc.narg('actor'))
+  AND (sqlc.narg('action')::TEXT IS NULL OR action = sqlc.narg('action'))
+ORDER BY id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetAuditRelay :one
+SELECT last_id FROM audit_relay WHERE name = $1 FOR UPDATE;
+
+-- name: LockAuditRead :exec
+-- Waits for the writers holding the shared lock
+SELECT pg_advisory_xact_lock(hashtext('link_audit'));
+
+-- name: ListLinkAuditAfter :many
+SELECT id, url_id, domain_id, code, action, actor, request_id, old_value, new_value, created_at FROM link_audit
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
+
+-- name: SetAuditRelay :exec
+UPDATE audit_relay SET last_id = $2 WHERE name = $1;
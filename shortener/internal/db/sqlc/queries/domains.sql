@@ -0,0 +1,14 @@
+-- name: CreateDomain :one
+INSERT INTO domains (host) VALUES ($1)
+RETURNING id, host, is_default;
+
+-- name: ListDomains :many
+SELECT id, host, is_default FROM domains ORDER BY id;
+
+-- name: UnsetDefaultDomain :exec
+UPDATE domains SET is_default = FALSE WHERE is_default AND host <> $1;
+
+-- name: SetDefaultDomain :one
+INSERT INTO domains (host, is_default) VALUES ($1, TRUE)
+ON CONFLICT (host) DO UPDATE SET is_default = TRUE
+RETURNING id, host, is_default;
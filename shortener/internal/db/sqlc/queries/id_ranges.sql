@@ -0,0 +1,23 @@
+-- name: LeaseIDRange :one
+-- The sequence is moved past the range, so the strategies can be switched
+WITH lease AS (
+    UPDATE id_ranges
+    SET next_id = GREATEST(next_id, (SELECT last_value + 1 FROM urls_id_seq)) + sqlc.arg('size')::BIGINT
+    WHERE name = 'urls'
+    RETURNING next_id
+)
+SELECT (setval('urls_id_seq', next_id - 1) - sqlc.arg('size')::BIGINT + 1)::BIGINT AS start_id FROM lease;
+
+-- name: AdvanceIDs :exec
+-- The sequence and the leases are moved past the ID, so the imported codes are not generated again
+WITH lease AS (
+    UPDATE id_ranges
+    SET next_id = GREATEST(next_id, sqlc.arg('id')::BIGINT + 1)
+    WHERE name = 'urls'
+    RETURNING next_id
+)
+SELECT setval('urls_id_seq', GREATEST((SELECT last_value FROM urls_id_seq), sqlc.arg('id')::BIGINT)) FROM lease;
+
+-- name: GetLastID :one
+-- The greatest ID given by the sequence or leased
+SELECT GREATEST(next_id - 1, (SELECT last_value FROM urls_id_seq))::BIGINT AS last_id FROM id_ranges WHERE name = 'urls';
@@ -0,0 +1,26 @@
+-- name: SetURLTags :exec
+WITH link_tags AS (
+    INSERT INTO tags (name) SELECT unnest(sqlc.arg('names')::TEXT[])
+    ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+    RETURNING id
+), removed AS (
+    DELETE FROM url_tags
+    WHERE url_tags.url_id = sqlc.arg('url_id') AND url_tags.tag_id NOT IN (SELECT id FROM link_tags)
+)
+INSERT INTO url_tags (url_id, tag_id)
+SELECT sqlc.arg('url_id'), id FROM link_tags
+ON CONFLICT DO NOTHING;
+
+-- name: GetTagsByURLs :many
+SELECT url_tags.url_id, tags.name FROM url_tags
+JOIN tags ON tags.id = url_tags.tag_id
+WHERE url_tags.url_id = ANY(sqlc.arg('url_ids')::BIGINT[])
+ORDER BY url_tags.url_id, tags.name;
+
+-- name: ListTags :many
+SELECT tags.name, count(url_tags.url_id) AS links FROM tags
+JOIN url_tags ON url_tags.tag_id = tags.id
+JOIN urls ON urls.id = url_tags.url_id
+WHERE (sqlc.narg('owner')::TEXT IS NULL OR urls.owner = sqlc.narg('owner'))
+GROUP BY tags.name
+ORDER BY tags.name;
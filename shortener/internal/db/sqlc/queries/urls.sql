@@ -1,9 +1,110 @@
 -- name: StoreShort :one
-INSERT INTO urls (url) VALUES ($1)
+-- The sequence is used if no ID is allocated
+INSERT INTO urls (id, url, options, password_hash, not_before, expires_at, domain_id, alias, owner, title, notes)
+VALUES (
+    COALESCE(sqlc.narg('id')::BIGINT, nextval('urls_id_seq')),
+    sqlc.arg('url'),
+    sqlc.arg('options'),
+    sqlc.arg('password_hash'),
+    sqlc.arg('not_before'),
+    sqlc.arg('expires_at'),
+    sqlc.arg('domain_id'),
+    sqlc.arg('alias'),
+    sqlc.arg('owner'),
+    sqlc.arg('title'),
+    sqlc.arg('notes')
+)
 RETURNING id;
 
 -- name: GetID :one
-SELECT id FROM urls WHERE url = $1;
+SELECT id FROM urls
+WHERE url = $1
+  AND domain_id = $2
+  AND owner IS NOT DISTINCT FROM $3
+  AND options IS NULL
+  AND password_hash IS NULL
+  AND not_before IS NULL
+  AND expires_at IS NULL
+  AND alias IS NULL
+  AND title IS NULL
+  AND notes IS NULL
+  AND NOT EXISTS (SELECT 1 FROM url_tags WHERE url_tags.url_id = urls.id);
 
 -- name: GetURLByID :one
-SELECT url FROM urls WHERE id = $1;
+SELECT id, url, options, password_hash, not_before, expires_at, domain_id, alias, owner, created_at, title, notes FROM urls WHERE id = $1;
+
+-- name: LockURL :one
+SELECT id, url, options, password_hash, not_before, expires_at, domain_id, alias, owner, created_at, title, notes FROM urls
+WHERE id = $1
+FOR UPDATE;
+
+-- name: LockURLsByAliases :many
+SELECT id, url, domain_id, alias, created_at FROM urls
+WHERE (domain_id, alias) IN (SELECT * FROM unnest(sqlc.arg('domain_ids')::INTEGER[], sqlc.arg('aliases')::TEXT[]))
+FOR UPDATE;
+
+-- name: GetURLByAlias :one
+SELECT id, url, options, password_hash, not_before, expires_at, domain_id, alias, owner, created_at, title, notes FROM urls
+WHERE domain_id = $1 AND alias = $2;
+
+-- name: ListURLs :many
+-- The search comes with %, _ and \ escaped, so it matches as a plain substring
+SELECT id, url, options, password_hash, not_before, expires_at, domain_id, alias, owner, created_at, title, notes FROM urls
+WHERE (sqlc.narg('cursor_created_at')::TIMESTAMPTZ IS NULL OR
+       (created_at, id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::BIGINT))
+  AND (sqlc.narg('owner')::TEXT IS NULL OR owner = sqlc.narg('owner'))
+  AND (sqlc.narg('domain_id')::INTEGER IS NULL OR domain_id = sqlc.narg('domain_id'))
+  AND (sqlc.narg('created_from')::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::TIMESTAMPTZ IS NULL OR created_at < sqlc.narg('created_to'))
+  AND (sqlc.narg('enabled')::BOOLEAN IS NULL OR
+       ((not_before IS NULL OR not_before <= now()) AND (expires_at IS NULL OR expires_at > now())) = sqlc.narg('enabled'))
+  AND (sqlc.narg('search')::TEXT IS NULL OR url ILIKE '%' || sqlc.narg('search') || '%' ESCAPE '\')
+  AND (sqlc.narg('tags')::TEXT[] IS NULL OR id IN (
+      SELECT url_tags.url_id FROM url_tags
+      JOIN tags ON tags.id = url_tags.tag_id
+      WHERE tags.name = ANY(sqlc.narg('tags')::TEXT[])
+      GROUP BY url_tags.url_id
+      HAVING count(*) = cardinality(sqlc.narg('tags')::TEXT[])))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: DeleteURL :exec
+DELETE FROM urls WHERE id = $1;
+
+-- name: AssignDomainToURLs :many
+UPDATE urls SET domain_id = $1 WHERE domain_id IS NULL
+RETURNING id, alias;
+
+-- name: UpdateURLMetadata :execrows
+UPDATE urls SET title = $2, notes = $3 WHERE id = $1;
+
+-- name: UpdateURLTarget :execrows
+UPDATE urls SET url = $2 WHERE id = $1;
+
+-- name: UpdateURLExpiresAt :execrows
+UPDATE urls SET expires_at = $2 WHERE id = $1;
+
+-- name: ImportURLs :many
+-- Links with alias equal to the generated code of a link in the domain are not imported
+INSERT INTO urls (url, domain_id, alias, created_at)
+SELECT i.url, i.domain_id, i.alias, COALESCE(i.created_at, now())
+FROM unnest(
+    sqlc.arg('urls')::TEXT[],
+    sqlc.arg('domain_ids')::INTEGER[],
+    sqlc.arg('aliases')::TEXT[],
+    sqlc.arg('created_at')::TIMESTAMPTZ[],
+    sqlc.arg('code_ids')::BIGINT[]
+) AS i (url, domain_id, alias, created_at, code_id)
+WHERE NOT EXISTS (
+    SELECT 1 FROM urls WHERE urls.id = i.code_id AND urls.domain_id = i.domain_id AND urls.alias IS NULL
+)
+ON CONFLICT (domain_id, alias) WHERE alias IS NOT NULL
+DO UPDATE SET url = EXCLUDED.url, created_at = EXCLUDED.created_at WHERE sqlc.arg('overwrite')::BOOLEAN
+RETURNING id, domain_id, alias, url, created_at, (xmax = 0)::BOOLEAN AS inserted;
+
+-- name: CountGeneratedCodes :one
+-- Links with generated codes among the codes in the domains
+SELECT count(*) FROM urls
+JOIN unnest(sqlc.arg('code_ids')::BIGINT[], sqlc.arg('domain_ids')::INTEGER[]) AS c (code_id, domain_id)
+    ON urls.id = c.code_id AND urls.domain_id = c.domain_id
+WHERE urls.alias IS NULL;
@@ -4,7 +4,71 @@
 
 package storage
 
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ApiKey struct {
+	ID        int64
+	Prefix    string
+	KeyHash   string
+	Principal string
+	Name      string
+	Scopes    []string
+	CreatedAt pgtype.Timestamptz
+	RevokedAt pgtype.Timestamptz
+}
+
+type AuditRelay struct {
+	Name   string
+	LastID int64
+}
+
+type Domain struct {
+	ID        int32
+	Host      string
+	IsDefault bool
+}
+
+type IDRange struct {
+	Name   string
+	NextID int64
+}
+
+type LinkAudit struct {
+	ID        int64
+	UrlID     int64
+	DomainID  pgtype.Int4
+	Code      string
+	Action    string
+	Actor     pgtype.Text
+	RequestID pgtype.Text
+	OldValue  []byte
+	NewValue  []byte
+	CreatedAt pgtype.Timestamptz
+}
+
+type Tag struct {
+	ID   int32
+	Name string
+}
+
 type Url struct {
-	ID  int64
-	Url string
+	ID           int64
+	Url          string
+	Options      []byte
+	PasswordHash pgtype.Text
+	NotBefore    pgtype.Timestamptz
+	ExpiresAt    pgtype.Timestamptz
+	DomainID     pgtype.Int4
+	Alias        pgtype.Text
+	Owner        pgtype.Text
+	CreatedAt    pgtype.Timestamptz
+	Title        pgtype.Text
+	Notes        pgtype.Text
+}
+
+type UrlTag struct {
+	UrlID int64
+	TagID int32
 }
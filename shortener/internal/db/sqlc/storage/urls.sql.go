@@ -7,38 +7,472 @@ package storage
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const assignDomainToURLs = `-- name: AssignDomainToURLs :many
+UPDATE urls SET domain_id = $1 WHERE domain_id IS NULL
+RETURNING id, alias
+`
+
+type AssignDomainToURLsRow struct {
+	ID    int64
+	Alias pgtype.Text
+}
+
+func (q *Queries) AssignDomainToURLs(ctx context.Context, domainID pgtype.Int4) ([]AssignDomainToURLsRow, error) {
+	rows, err := q.db.Query(ctx, assignDomainToURLs, domainID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AssignDomainToURLsRow
+	for rows.Next() {
+		var i AssignDomainToURLsRow
+		if err := rows.Scan(&i.ID, &i.Alias); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countGeneratedCodes = `-- name: CountGeneratedCodes :one
+SELECT count(*) FROM urls
+JOIN unnest($1::BIGINT[], $2::INTEGER[]) AS c (code_id, domain_id)
+    ON urls.id = c.code_id AND urls.domain_id = c.domain_id
+WHERE urls.alias IS NULL
+`
+
+type CountGeneratedCodesParams struct {
+	CodeIds   []int64
+	DomainIds []int32
+}
+
+// Links with generated codes among the codes in the domains
+func (q *Queries) CountGeneratedCodes(ctx context.Context, arg CountGeneratedCodesParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countGeneratedCodes, arg.CodeIds, arg.DomainIds)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const deleteURL = `-- name: DeleteURL :exec
+DELETE FROM urls WHERE id = $1
+`
+
+func (q *Queries) DeleteURL(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, deleteURL, id)
+	return err
+}
+
 const getID = `-- name: GetID :one
-SELECT id FROM urls WHERE url = $1
+SELECT id FROM urls
+WHERE url = $1
+  AND domain_id = $2
+  AND owner IS NOT DISTINCT FROM $3
+  AND options IS NULL
+  AND password_hash IS NULL
+  AND not_before IS NULL
+  AND expires_at IS NULL
+  AND alias IS NULL
+  AND title IS NULL
+  AND notes IS NULL
+  AND NOT EXISTS (SELECT 1 FROM url_tags WHERE url_tags.url_id = urls.id)
 `
 
-func (q *Queries) GetID(ctx context.Context, url string) (int64, error) {
-	row := q.db.QueryRow(ctx, getID, url)
+type GetIDParams struct {
+	Url      string
+	DomainID pgtype.Int4
+	Owner    pgtype.Text
+}
+
+func (q *Queries) GetID(ctx context.Context, arg GetIDParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getID, arg.Url, arg.DomainID, arg.Owner)
 	var id int64
 	err := row.Scan(&id)
 	return id, err
 }
 
+const getURLByAlias = `-- name: GetURLByAlias :one
+SELECT id, url, options, password_hash, not_before, expires_at, domain_id, alias, owner, created_at, title, notes FROM urls
+WHERE domain_id = $1 AND alias = $2
+`
+
+type GetURLByAliasParams struct {
+	DomainID pgtype.Int4
+	Alias    pgtype.Text
+}
+
+func (q *Queries) GetURLByAlias(ctx context.Context, arg GetURLByAliasParams) (Url, error) {
+	row := q.db.QueryRow(ctx, getURLByAlias, arg.DomainID, arg.Alias)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Options,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.ExpiresAt,
+		&i.DomainID,
+		&i.Alias,
+		&i.Owner,
+		&i.CreatedAt,
+		&i.Title,
+		&i.Notes,
+	)
+	return i, err
+}
+
 const getURLByID = `-- name: GetURLByID :one
-SELECT url FROM urls WHERE id = $1
+SELECT id, url, options, password_hash, not_before, expires_at, domain_id, alias, owner, created_at, title, notes FROM urls WHERE id = $1
 `
 
-func (q *Queries) GetURLByID(ctx context.Context, id int64) (string, error) {
+func (q *Queries) GetURLByID(ctx context.Context, id int64) (Url, error) {
 	row := q.db.QueryRow(ctx, getURLByID, id)
-	var url string
-	err := row.Scan(&url)
-	return url, err
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Options,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.ExpiresAt,
+		&i.DomainID,
+		&i.Alias,
+		&i.Owner,
+		&i.CreatedAt,
+		&i.Title,
+		&i.Notes,
+	)
+	return i, err
+}
+
+const importURLs = `-- name: ImportURLs :many
+INSERT INTO urls (url, domain_id, alias, created_at)
+SELECT i.url, i.domain_id, i.alias, COALESCE(i.created_at, now())
+FROM unnest(
+    $1::TEXT[],
+    $2::INTEGER[],
+    $3::TEXT[],
+    $4::TIMESTAMPTZ[],
+    $5::BIGINT[]
+) AS i (url, domain_id, alias, created_at, code_id)
+WHERE NOT EXISTS (
+    SELECT 1 FROM urls WHERE urls.id = i.code_id AND urls.domain_id = i.domain_id AND urls.alias IS NULL
+)
+ON CONFLICT (domain_id, alias) WHERE alias IS NOT NULL
+DO UPDATE SET url = EXCLUDED.url, created_at = EXCLUDED.created_at WHERE $6::BOOLEAN
+RETURNING id, domain_id, alias, url, created_at, (xmax = 0)::BOOLEAN AS inserted
+`
+
+type ImportURLsParams struct {
+	Urls      []string
+	DomainIds []int32
+	Aliases   []string
+	CreatedAt []pgtype.Timestamptz
+	CodeIds   []int64
+	Overwrite bool
+}
+
+type ImportURLsRow struct {
+	ID        int64
+	DomainID  pgtype.Int4
+	Alias     pgtype.Text
+	Url       string
+	CreatedAt pgtype.Timestamptz
+	Inserted  bool
+}
+
+// Links with alias equal to the generated code of a link in the domain are not imported
+func (q *Queries) ImportURLs(ctx context.Context, arg ImportURLsParams) ([]ImportURLsRow, error) {
+	rows, err := q.db.Query(ctx, importURLs,
+		arg.Urls,
+		arg.DomainIds,
+		arg.Aliases,
+		arg.CreatedAt,
+		arg.CodeIds,
+		arg.Overwrite,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ImportURLsRow
+	for rows.Next() {
+		var i ImportURLsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.DomainID,
+			&i.Alias,
+			&i.Url,
+			&i.CreatedAt,
+			&i.Inserted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listURLs = `-- name: ListURLs :many
+SELECT id, url, options, password_hash, not_before, expires_at, domain_id, alias, owner, created_at, title, notes FROM urls
+WHERE ($1::TIMESTAMPTZ IS NULL OR
+       (created_at, id) < ($1, $2::BIGINT))
+  AND ($3::TEXT IS NULL OR owner = $3)
+  AND ($4::INTEGER IS NULL OR domain_id = $4)
+  AND ($5::TIMESTAMPTZ IS NULL OR created_at >= $5)
+  AND ($6::TIMESTAMPTZ IS NULL OR created_at < $6)
+  AND ($7::BOOLEAN IS NULL OR
+       ((not_before IS NULL OR not_before <= now()) AND (expires_at IS NULL OR expires_at > now())) = $7)
+  AND ($8::TEXT IS NULL OR url ILIKE '%' || $8 || '%' ESCAPE '\')
+  AND ($9::TEXT[] IS NULL OR id IN (
+      SELECT url_tags.url_id FROM url_tags
+      JOIN tags ON tags.id = url_tags.tag_id
+      WHERE tags.name = ANY($9::TEXT[])
+      GROUP BY url_tags.url_id
+      HAVING count(*) = cardinality($9::TEXT[])))
+ORDER BY created_at DESC, id DESC
+LIMIT $10
+`
+
+type ListURLsParams struct {
+	CursorCreatedAt pgtype.Timestamptz
+	CursorID        pgtype.Int8
+	Owner           pgtype.Text
+	DomainID        pgtype.Int4
+	CreatedFrom     pgtype.Timestamptz
+	CreatedTo       pgtype.Timestamptz
+	Enabled         pgtype.Bool
+	Search          pgtype.Text
+	Tags            []string
+	PageSize        int32
+}
+
+// The search comes with %, _ and \ escaped, so it matches as a plain substring
+func (q *Queries) ListURLs(ctx context.Context, arg ListURLsParams) ([]Url, error) {
+	rows, err := q.db.Query(ctx, listURLs,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Owner,
+		arg.DomainID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.Enabled,
+		arg.Search,
+		arg.Tags,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Url
+	for rows.Next() {
+		var i Url
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.Options,
+			&i.PasswordHash,
+			&i.NotBefore,
+			&i.ExpiresAt,
+			&i.DomainID,
+			&i.Alias,
+			&i.Owner,
+			&i.CreatedAt,
+			&i.Title,
+			&i.Notes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockURL = `-- name: LockURL :one
+SELECT id, url, options, password_hash, not_before, expires_at, domain_id, alias, owner, created_at, title, notes FROM urls
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockURL(ctx context.Context, id int64) (Url, error) {
+	row := q.db.QueryRow(ctx, lockURL, id)
+	var i Url
+	err := row.Scan(
+		&i.ID,
+		&i.Url,
+		&i.Options,
+		&i.PasswordHash,
+		&i.NotBefore,
+		&i.ExpiresAt,
+		&i.DomainID,
+		&i.Alias,
+		&i.Owner,
+		&i.CreatedAt,
+		&i.Title,
+		&i.Notes,
+	)
+	return i, err
+}
+
+const lockURLsByAliases = `-- name: LockURLsByAliases :many
+SELECT id, url, domain_id, alias, created_at FROM urls
+WHERE (domain_id, alias) IN (SELECT * FROM unnest($1::INTEGER[], $2::TEXT[]))
+FOR UPDATE
+`
+
+type LockURLsByAliasesParams struct {
+	DomainIds []int32
+	Aliases   []string
+}
+
+type LockURLsByAliasesRow struct {
+	ID        int64
+	Url       string
+	DomainID  pgtype.Int4
+	Alias     pgtype.Text
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) LockURLsByAliases(ctx context.Context, arg LockURLsByAliasesParams) ([]LockURLsByAliasesRow, error) {
+	rows, err := q.db.Query(ctx, lockURLsByAliases, arg.DomainIds, arg.Aliases)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LockURLsByAliasesRow
+	for rows.Next() {
+		var i LockURLsByAliasesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Url,
+			&i.DomainID,
+			&i.Alias,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const storeShort = `-- name: StoreShort :one
-INSERT INTO urls (url) VALUES ($1)
+INSERT INTO urls (id, url, options, password_hash, not_before, expires_at, domain_id, alias, owner, title, notes)
+VALUES (
+    COALESCE($1::BIGINT, nextval('urls_id_seq')),
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11
+)
 RETURNING id
 `
 
-func (q *Queries) StoreShort(ctx context.Context, url string) (int64, error) {
-	row := q.db.QueryRow(ctx, storeShort, url)
+type StoreShortParams struct {
+	ID           pgtype.Int8
+	Url          string
+	Options      []byte
+	PasswordHash pgtype.Text
+	NotBefore    pgtype.Timestamptz
+	ExpiresAt    pgtype.Timestamptz
+	DomainID     pgtype.Int4
+	Alias        pgtype.Text
+	Owner        pgtype.Text
+	Title        pgtype.Text
+	Notes        pgtype.Text
+}
+
+// The sequence is used if no ID is allocated
+func (q *Queries) StoreShort(ctx context.Context, arg StoreShortParams) (int64, error) {
+	row := q.db.QueryRow(ctx, storeShort,
+		arg.ID,
+		arg.Url,
+		arg.Options,
+		arg.PasswordHash,
+		arg.NotBefore,
+		arg.ExpiresAt,
+		arg.DomainID,
+		arg.Alias,
+		arg.Owner,
+		arg.Title,
+		arg.Notes,
+	)
 	var id int64
 	err := row.Scan(&id)
 	return id, err
 }
+
+const updateURLExpiresAt = `-- name: UpdateURLExpiresAt :execrows
+UPDATE urls SET expires_at = $2 WHERE id = $1
+`
+
+type UpdateURLExpiresAtParams struct {
+	ID        int64
+	ExpiresAt pgtype.Timestamptz
+}
+
+func (q *Queries) UpdateURLExpiresAt(ctx context.Context, arg UpdateURLExpiresAtParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateURLExpiresAt, arg.ID, arg.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateURLMetadata = `-- name: UpdateURLMetadata :execrows
+UPDATE urls SET title = $2, notes = $3 WHERE id = $1
+`
+
+type UpdateURLMetadataParams struct {
+	ID    int64
+	Title pgtype.Text
+	Notes pgtype.Text
+}
+
+func (q *Queries) UpdateURLMetadata(ctx context.Context, arg UpdateURLMetadataParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateURLMetadata, arg.ID, arg.Title, arg.Notes)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateURLTarget = `-- name: UpdateURLTarget :execrows
+UPDATE urls SET url = $2 WHERE id = $1
+`
+
+type UpdateURLTargetParams struct {
+	ID  int64
+	Url string
+}
+
+func (q *Queries) UpdateURLTarget(ctx context.Context, arg UpdateURLTargetParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateURLTarget, arg.ID, arg.Url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"database/sql"
+	_ "modernc.org/sqlite"
+	"net/url"
+)
+
+// OpenSQLite opens the SQLite database file, creating it if needed.
+// Foreign keys are enforced and writers wait for each other instead of failing with SQLITE_BUSY.
+func OpenSQLite(path string) (*sql.DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Set("_txlock", "immediate")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS domains (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    host TEXT NOT NULL UNIQUE,
+    is_default INTEGER NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS domains_default_idx ON domains (is_default) WHERE is_default;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS urls (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL,
+    options TEXT,
+    password_hash TEXT,
+    not_before INTEGER,
+    expires_at INTEGER,
+    domain_id INTEGER REFERENCES domains (id),
+    alias TEXT,
+    owner TEXT,
+    created_at INTEGER NOT NULL,
+    title TEXT,
+    notes TEXT
+);
+CREATE UNIQUE INDEX IF NOT EXISTS urls_domain_alias_idx ON urls (domain_id, alias) WHERE alias IS NOT NULL;
+CREATE INDEX IF NOT EXISTS urls_url_idx ON urls (url);
+CREATE INDEX IF NOT EXISTS urls_owner_idx ON urls (owner, id);
+CREATE INDEX IF NOT EXISTS urls_created_at_idx ON urls (created_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS url_tags (
+    url_id INTEGER NOT NULL REFERENCES urls (id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (url_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS url_tags_tag_idx ON url_tags (tag_id, url_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS url_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS urls;
+DROP TABLE IF EXISTS domains;
+-- +goose StatementEnd
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS link_audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url_id INTEGER NOT NULL,
+    domain_id INTEGER,
+    code TEXT NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT,
+    request_id TEXT,
+    old_value TEXT,
+    new_value TEXT,
+    created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS link_audit_code_idx ON link_audit (domain_id, code, id);
+CREATE INDEX IF NOT EXISTS link_audit_actor_idx ON link_audit (actor, id);
+
+CREATE TRIGGER IF NOT EXISTS link_audit_no_update BEFORE UPDATE ON link_audit
+BEGIN
+    SELECT RAISE(ABORT, 'link_audit is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS link_audit_no_delete BEFORE DELETE ON link_audit
+BEGIN
+    SELECT RAISE(ABORT, 'link_audit is append-only');
+END;
+
+-- Last audit entry published by the relay
+CREATE TABLE IF NOT EXISTS audit_relay (
+    name TEXT PRIMARY KEY,
+    last_id INTEGER NOT NULL
+);
+INSERT INTO audit_relay (name, last_id) VALUES ('kafka', 0) ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_relay;
+DROP TABLE IF EXISTS link_audit;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+DROP INDEX IF EXISTS urls_owner_idx;
+DROP INDEX IF EXISTS urls_created_at_idx;
+CREATE INDEX IF NOT EXISTS urls_owner_idx ON urls (owner, created_at, id);
+CREATE INDEX IF NOT EXISTS urls_created_at_idx ON urls (created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS urls_owner_idx;
+DROP INDEX IF EXISTS urls_created_at_idx;
+CREATE INDEX IF NOT EXISTS urls_owner_idx ON urls (owner, id);
+CREATE INDEX IF NOT EXISTS urls_created_at_idx ON urls (created_at);
+-- +goose StatementEnd
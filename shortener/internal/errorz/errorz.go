@@ -0,0 +1,12 @@
+package errorz
+
+import "errors"
+
+var (
+	ErrAliasTaken   = errors.New("alias is taken")
+	ErrDomainExists = errors.New("domain already exists")
+
+	// ErrCodeShadowed is returned when the generated code of the new link is taken by an alias,
+	// nothing is stored and the ID is not used again
+	ErrCodeShadowed = errors.New("generated code is taken by alias")
+)
@@ -0,0 +1,41 @@
+package errorz
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// Domain is the domain of the ErrorInfo details of the shortener statuses
+const Domain = "shortener"
+
+// Reasons of the ErrorInfo details, the gateway reports them in lower case as the error codes
+const (
+	ReasonAliasTaken      = "ALIAS_TAKEN"
+	ReasonDomainExists    = "DOMAIN_EXISTS"
+	ReasonInvalidArgument = "INVALID_ARGUMENT"
+)
+
+// Conflict returns the AlreadyExists status with the ErrorInfo of the reason
+func Conflict(reason, message string) error {
+	return withDetails(status.New(codes.AlreadyExists, message),
+		&errdetails.ErrorInfo{Reason: reason, Domain: Domain},
+	)
+}
+
+// InvalidField returns the InvalidArgument status with the ErrorInfo and BadRequest details naming the invalid field
+func InvalidField(field, description string) error {
+	return withDetails(status.New(codes.InvalidArgument, description),
+		&errdetails.ErrorInfo{Reason: ReasonInvalidArgument, Domain: Domain, Metadata: map[string]string{"field": field}},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: description}}},
+	)
+}
+
+// withDetails attaches the details to the status, the status is returned as is if they can't be marshalled
+func withDetails(s *status.Status, details ...protoadapt.MessageV1) error {
+	if detailed, err := s.WithDetails(details...); err == nil {
+		return detailed.Err()
+	}
+	return s.Err()
+}
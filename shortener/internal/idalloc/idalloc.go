@@ -0,0 +1,15 @@
+// Package idalloc allocates link IDs without the storage sequence,
+// so links can be written by several replicas or to several shards
+package idalloc
+
+import "context"
+
+// Allocator returns unique link IDs
+type Allocator interface {
+	NextID(ctx context.Context) (int64, error)
+}
+
+var (
+	_ Allocator = (*Lease)(nil)
+	_ Allocator = (*Snowflake)(nil)
+)
@@ -0,0 +1,47 @@
+package idalloc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type rangeLeaser interface {
+	LeaseIDRange(ctx context.Context, size int64) (int64, error)
+}
+
+// Lease hands out IDs from a range leased from the storage, the next range is leased when it is exhausted.
+// Unused IDs of the range are lost when the replica stops.
+type Lease struct {
+	mu     sync.Mutex
+	leaser rangeLeaser
+	size   int64
+
+	// next is the next ID to hand out, end is the first ID out of the range
+	next int64
+	end  int64
+}
+
+func NewLease(leaser rangeLeaser, size int64) (*Lease, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("lease size must be positive, got %d", size)
+	}
+	return &Lease{leaser: leaser, size: size}, nil
+}
+
+func (l *Lease) NextID(ctx context.Context) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.next == l.end {
+		start, err := l.leaser.LeaseIDRange(ctx, l.size)
+		if err != nil {
+			return 0, fmt.Errorf("failed to lease id range: %w", err)
+		}
+		l.next, l.end = start, start+l.size
+	}
+
+	id := l.next
+	l.next++
+	return id, nil
+}
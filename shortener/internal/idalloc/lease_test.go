@@ -0,0 +1,47 @@
+package idalloc
+
+import (
+	"context"
+	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type failingLeaser struct{}
+
+func (failingLeaser) LeaseIDRange(ctx context.Context, size int64) (int64, error) {
+	return 0, errors.New("some unknown error")
+}
+
+func TestLease(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepo()
+
+	first, err := NewLease(repo, 3)
+	require.NoError(t, err)
+	second, err := NewLease(repo, 3)
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, lease := range []*Lease{first, second, first, first, first, second} {
+		id, err := lease.NextID(ctx)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	// first leases 1-3 and 7-9, second leases 4-6
+	assert.Equal(t, []int64{1, 4, 2, 3, 7, 5}, ids)
+}
+
+func TestLease_Error(t *testing.T) {
+	lease, err := NewLease(failingLeaser{}, 10)
+	require.NoError(t, err)
+
+	_, err = lease.NextID(context.Background())
+	assert.ErrorContains(t, err, "failed to lease id range")
+
+	_, err = NewLease(failingLeaser{}, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+package idalloc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Snowflake ID is milliseconds since epoch (41 bits), node (10 bits) and sequence within the millisecond (12 bits).
+// IDs are far above the ones taken from the sequence, so both can live in one table.
+const (
+	nodeBits     = 10
+	sequenceBits = 12
+
+	MaxNode     = 1<<nodeBits - 1
+	maxSequence = 1<<sequenceBits - 1
+)
+
+// epoch is the zero time of snowflake IDs
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Snowflake generates time-ordered IDs on its own, every replica must have a unique node
+type Snowflake struct {
+	mu   sync.Mutex
+	node int64
+	now  func() time.Time
+
+	lastMs   int64
+	sequence int64
+}
+
+func NewSnowflake(node int64) (*Snowflake, error) {
+	if node < 0 || node > MaxNode {
+		return nil, fmt.Errorf("snowflake node must be in [0, %d], got %d", MaxNode, node)
+	}
+	return &Snowflake{node: node, now: time.Now}, nil
+}
+
+// NextID returns the next ID. If the clock goes back or the sequence of the millisecond is exhausted,
+// the next millisecond is borrowed instead of waiting, so IDs keep growing.
+func (s *Snowflake) NextID(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ms := s.now().Sub(epoch).Milliseconds()
+	if ms < 0 {
+		return 0, fmt.Errorf("clock is before snowflake epoch %s", epoch.Format(time.DateOnly))
+	}
+
+	switch {
+	case ms > s.lastMs:
+		s.lastMs = ms
+		s.sequence = 0
+	case s.sequence < maxSequence:
+		s.sequence++
+	default:
+		s.lastMs++
+		s.sequence = 0
+	}
+
+	return s.lastMs<<(nodeBits+sequenceBits) | s.node<<sequenceBits | s.sequence, nil
+}
@@ -0,0 +1,63 @@
+package idalloc
+
+import (
+	"context"
+	"github.com/misshanya/url-shortener/shortener/pkg/base62"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSnowflake(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := NewSnowflake(5)
+	require.NoError(t, err)
+
+	now := epoch.Add(time.Hour)
+	s.now = func() time.Time { return now }
+
+	id, err := s.NextID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(time.Hour/time.Millisecond)<<22|5<<12, id)
+	assert.Equal(t, id, base62.Decode(base62.Encode(id)))
+
+	next, err := s.NextID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, id+1, next, "sequence grows within the millisecond")
+
+	now = now.Add(-time.Second)
+	back, err := s.NextID(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, back, next, "IDs grow when the clock goes back")
+
+	s.sequence = maxSequence
+	borrowed, err := s.NextID(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, borrowed, back, "IDs grow when the sequence is exhausted")
+	assert.Equal(t, int64(0), borrowed&maxSequence)
+}
+
+func TestSnowflake_Node(t *testing.T) {
+	ctx := context.Background()
+	now := func() time.Time { return epoch.Add(time.Minute) }
+
+	a, err := NewSnowflake(1)
+	require.NoError(t, err)
+	a.now = now
+	b, err := NewSnowflake(2)
+	require.NoError(t, err)
+	b.now = now
+
+	idA, err := a.NextID(ctx)
+	require.NoError(t, err)
+	idB, err := b.NextID(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, idA, idB, "nodes never clash")
+
+	_, err = NewSnowflake(MaxNode + 1)
+	assert.Error(t, err)
+	_, err = NewSnowflake(-1)
+	assert.Error(t, err)
+}
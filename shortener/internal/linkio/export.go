@@ -0,0 +1,90 @@
+package linkio
+
+import (
+	"context"
+	"fmt"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/misshanya/url-shortener/shortener/pkg/base62"
+)
+
+type exportRepo interface {
+	ListLinks(ctx context.Context, filter models.LinkFilter, domainID int32, after models.LinkCursor, limit int32) ([]models.Link, error)
+	ListDomains(ctx context.Context) ([]models.Domain, error)
+}
+
+const defaultPageSize = 1000
+
+type ExportOptions struct {
+	// Domain limits the export to the host if not empty
+	Domain   string
+	PageSize int32
+
+	// Progress is called with the number of exported records after every page
+	Progress func(exported int64)
+}
+
+// Export writes the links page by page, newest first, so the whole table is never loaded
+func Export(ctx context.Context, repo exportRepo, w Writer, opts ExportOptions) (int64, error) {
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultPageSize
+	}
+
+	domains, err := repo.ListDomains(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list domains: %w", err)
+	}
+
+	hosts := make(map[int32]string, len(domains))
+	var domainID int32
+	for _, domain := range domains {
+		hosts[domain.ID] = domain.Host
+		if domain.Host == opts.Domain {
+			domainID = domain.ID
+		}
+	}
+	if opts.Domain != "" && domainID == 0 {
+		return 0, fmt.Errorf("unknown domain %q", opts.Domain)
+	}
+
+	var (
+		exported int64
+		cursor   models.LinkCursor
+	)
+	for {
+		links, err := repo.ListLinks(ctx, models.LinkFilter{}, domainID, cursor, opts.PageSize)
+		if err != nil {
+			return exported, fmt.Errorf("failed to list links after %d records: %w", exported, err)
+		}
+
+		for _, link := range links {
+			code := link.Alias
+			if code == "" {
+				code = base62.Encode(link.ID)
+			}
+
+			err := w.Write(Record{
+				Code:      code,
+				URL:       link.URL,
+				CreatedAt: link.CreatedAt,
+				Domain:    hosts[link.DomainID],
+			})
+			if err != nil {
+				return exported, err
+			}
+			exported++
+		}
+
+		if err := w.Flush(); err != nil {
+			return exported, err
+		}
+		if opts.Progress != nil {
+			opts.Progress(exported)
+		}
+
+		if len(links) < int(opts.PageSize) {
+			return exported, nil
+		}
+		last := links[len(links)-1]
+		cursor = models.LinkCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
@@ -0,0 +1,89 @@
+package linkio
+
+import (
+	"bytes"
+	"context"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func Test_Export(t *testing.T) {
+	ctx := context.Background()
+
+	for _, format := range []Format{FormatCSV, FormatJSONL} {
+		t.Run(string(format), func(t *testing.T) {
+			repo := newTestRepo(t)
+
+			createdAt := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+			_, err := repo.StoreURL(ctx, &models.Link{URL: "https://example.com", DomainID: 1})
+			require.NoError(t, err)
+			_, err = repo.ImportLinks(ctx, []models.Link{
+				{URL: "https://example.org", DomainID: 2, Alias: "promo", CreatedAt: createdAt},
+				{URL: "https://example.net", DomainID: 1, Alias: "old", CreatedAt: createdAt},
+			}, models.ConflictFail, false)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, format)
+			require.NoError(t, err)
+
+			var pages []int64
+			exported, err := Export(ctx, repo, w, ExportOptions{
+				PageSize: 2,
+				Progress: func(exported int64) { pages = append(pages, exported) },
+			})
+			require.NoError(t, err)
+			assert.Equal(t, int64(3), exported)
+			assert.Equal(t, []int64{2, 3}, pages)
+
+			// The export is imported back into the empty repo as is
+			r, err := NewReader(&buf, format)
+			require.NoError(t, err)
+
+			var records []Record
+			for {
+				record, err := r.Read()
+				if err != nil {
+					break
+				}
+				records = append(records, record)
+			}
+
+			// Newest first, the imported links of the same time are split between the pages by ID
+			require.Len(t, records, 3)
+			assert.Equal(t, "1", records[0].Code)
+			assert.Equal(t, "https://example.com", records[0].URL)
+			assert.Equal(t, "old", records[1].Code)
+			assert.Equal(t, "sh.some", records[1].Domain)
+			assert.True(t, createdAt.Equal(records[1].CreatedAt))
+			assert.Equal(t, "promo", records[2].Code)
+			assert.Equal(t, "go.some", records[2].Domain)
+		})
+	}
+}
+
+func Test_Export_Domain(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepo(t)
+
+	_, err := repo.StoreURL(ctx, &models.Link{URL: "https://example.com", DomainID: 1})
+	require.NoError(t, err)
+	_, err = repo.StoreURL(ctx, &models.Link{URL: "https://example.org", DomainID: 2})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatJSONL)
+	require.NoError(t, err)
+
+	exported, err := Export(ctx, repo, w, ExportOptions{Domain: "go.some"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), exported)
+	assert.Contains(t, buf.String(), `{"code":"2","url":"https://example.org",`)
+	assert.NotContains(t, buf.String(), "https://example.com")
+
+	_, err = Export(ctx, repo, w, ExportOptions{Domain: "unknown.some"})
+	assert.ErrorContains(t, err, `unknown domain "unknown.some"`)
+}
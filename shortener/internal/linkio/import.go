@@ -0,0 +1,143 @@
+package linkio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+type importRepo interface {
+	ImportLinks(ctx context.Context, links []models.Link, onConflict models.ConflictPolicy, dryRun bool) (models.ImportResult, error)
+	ListDomains(ctx context.Context) ([]models.Domain, error)
+}
+
+// codePattern accepts URL-safe codes, they are stored as aliases
+var codePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+const defaultBatchSize = 1000
+
+type ImportOptions struct {
+	OnConflict models.ConflictPolicy
+	DryRun     bool
+	BatchSize  int
+
+	// Progress is called with the running totals after every batch
+	Progress func(result models.ImportResult)
+}
+
+// Import stores the records batch by batch, each batch in one transaction.
+// The import stops on the first bad record or failed batch, the batches before it stay stored.
+func Import(ctx context.Context, repo importRepo, r Reader, opts ImportOptions) (models.ImportResult, error) {
+	switch opts.OnConflict {
+	case models.ConflictSkip, models.ConflictOverwrite, models.ConflictFail:
+	default:
+		return models.ImportResult{}, fmt.Errorf("unknown conflict policy %q", opts.OnConflict)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+
+	domains, err := domainIDs(ctx, repo)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+
+	var (
+		total models.ImportResult
+		batch = make([]models.Link, 0, opts.BatchSize)
+
+		// keys of the batch, the same code in one batch is moved to the next one
+		keys = make(map[string]struct{}, opts.BatchSize)
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		result, err := repo.ImportLinks(ctx, batch, opts.OnConflict, opts.DryRun)
+		if err != nil {
+			return fmt.Errorf("failed to import batch after %d records: %w", total.Total(), err)
+		}
+		total = total.Add(result)
+
+		batch = batch[:0]
+		clear(keys)
+
+		if opts.Progress != nil {
+			opts.Progress(total)
+		}
+		return nil
+	}
+
+	for n := 1; ; n++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return total, fmt.Errorf("record %d: %w", n, err)
+		}
+
+		link, err := recordLink(record, domains)
+		if err != nil {
+			return total, fmt.Errorf("record %d: %w", n, err)
+		}
+
+		key := fmt.Sprintf("%d/%s", link.DomainID, link.Alias)
+		if _, ok := keys[key]; ok || len(batch) == opts.BatchSize {
+			if err := flush(); err != nil {
+				return total, err
+			}
+		}
+		batch = append(batch, link)
+		keys[key] = struct{}{}
+	}
+
+	return total, flush()
+}
+
+// recordLink validates the record and makes the link of it
+func recordLink(record Record, domains map[string]int32) (models.Link, error) {
+	if !codePattern.MatchString(record.Code) {
+		return models.Link{}, fmt.Errorf("bad code %q", record.Code)
+	}
+	if models.IsReservedAlias(record.Code) {
+		return models.Link{}, fmt.Errorf("reserved code %q", record.Code)
+	}
+	if _, err := url.ParseRequestURI(record.URL); err != nil {
+		return models.Link{}, fmt.Errorf("bad url %q", record.URL)
+	}
+
+	domainID, ok := domains[strings.ToLower(record.Domain)]
+	if !ok {
+		return models.Link{}, fmt.Errorf("unknown domain %q", record.Domain)
+	}
+
+	return models.Link{
+		URL:       record.URL,
+		DomainID:  domainID,
+		Alias:     record.Code,
+		CreatedAt: record.CreatedAt,
+	}, nil
+}
+
+// domainIDs maps hosts to IDs of the domains, the default domain is mapped from empty host too
+func domainIDs(ctx context.Context, repo importRepo) (map[string]int32, error) {
+	domains, err := repo.ListDomains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %w", err)
+	}
+
+	ids := make(map[string]int32, len(domains)+1)
+	for _, domain := range domains {
+		ids[domain.Host] = domain.ID
+		if domain.IsDefault {
+			ids[""] = domain.ID
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,174 @@
+package linkio
+
+import (
+	"context"
+	"github.com/misshanya/url-shortener/shortener/internal/errorz"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/misshanya/url-shortener/shortener/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRepo returns the repo with the default domain sh.some and go.some
+func newTestRepo(t *testing.T) *repository.MemoryRepo {
+	t.Helper()
+
+	repo := repository.NewMemoryRepo()
+	_, _, err := repo.SetDefaultDomain(context.Background(), "sh.some")
+	require.NoError(t, err)
+	_, err = repo.CreateDomain(context.Background(), "go.some")
+	require.NoError(t, err)
+	return repo
+}
+
+func Test_Import(t *testing.T) {
+	tests := []struct {
+		Name            string
+		Format          Format
+		Input           string
+		OnConflict      models.ConflictPolicy
+		ExceptedResult  models.ImportResult
+		ExceptedBatches int
+		ExceptedError   string
+	}{
+		{
+			Name:   "CSV",
+			Format: FormatCSV,
+			Input: "url,code,created_at,domain\n" +
+				"https://example.com,abc,2020-05-01T12:00:00Z,\n" +
+				"https://example.org,abc,,go.some\n" +
+				"https://example.net,xyz,2020-05-01T12:00:00Z,SH.some\n",
+			OnConflict:      models.ConflictFail,
+			ExceptedResult:  models.ImportResult{Inserted: 3},
+			ExceptedBatches: 2,
+		},
+		{
+			Name:   "JSONL",
+			Format: FormatJSONL,
+			Input: `{"code":"abc","url":"https://example.com","created_at":"2020-05-01T12:00:00Z"}` + "\n" +
+				`{"code":"xyz","url":"https://example.org","domain":"go.some"}` + "\n",
+			OnConflict:      models.ConflictFail,
+			ExceptedResult:  models.ImportResult{Inserted: 2},
+			ExceptedBatches: 1,
+		},
+		{
+			Name:   "Same code in one batch",
+			Format: FormatJSONL,
+			Input: `{"code":"abc","url":"https://example.com"}` + "\n" +
+				`{"code":"abc","url":"https://example.org"}` + "\n",
+			OnConflict:      models.ConflictOverwrite,
+			ExceptedResult:  models.ImportResult{Inserted: 1, Updated: 1},
+			ExceptedBatches: 2,
+		},
+		{
+			Name:   "Conflict fails",
+			Format: FormatJSONL,
+			Input: `{"code":"abc","url":"https://example.com"}` + "\n" +
+				`{"code":"abc","url":"https://example.org"}` + "\n",
+			OnConflict:      models.ConflictFail,
+			ExceptedResult:  models.ImportResult{Inserted: 1},
+			ExceptedBatches: 1,
+			ExceptedError:   errorz.ErrAliasTaken.Error(),
+		},
+		{
+			Name:          "Missing CSV column",
+			Format:        FormatCSV,
+			Input:         "code,url\nabc,https://example.com\n",
+			OnConflict:    models.ConflictSkip,
+			ExceptedError: `csv column "created_at" is missing`,
+		},
+		{
+			Name:          "Bad code",
+			Format:        FormatJSONL,
+			Input:         `{"code":"a/b","url":"https://example.com"}`,
+			OnConflict:    models.ConflictSkip,
+			ExceptedError: `record 1: bad code "a/b"`,
+		},
+		{
+			Name:          "Reserved code",
+			Format:        FormatJSONL,
+			Input:         `{"code":"api","url":"https://example.com"}`,
+			OnConflict:    models.ConflictSkip,
+			ExceptedError: `record 1: reserved code "api"`,
+		},
+		{
+			Name:          "Bad URL",
+			Format:        FormatJSONL,
+			Input:         `{"code":"abc","url":"example"}`,
+			OnConflict:    models.ConflictSkip,
+			ExceptedError: `record 1: bad url "example"`,
+		},
+		{
+			Name:          "Unknown domain",
+			Format:        FormatJSONL,
+			Input:         `{"code":"abc","url":"https://example.com","domain":"unknown.some"}`,
+			OnConflict:    models.ConflictSkip,
+			ExceptedError: `record 1: unknown domain "unknown.some"`,
+		},
+		{
+			Name:          "Unknown policy",
+			Format:        FormatJSONL,
+			OnConflict:    "replace",
+			ExceptedError: `unknown conflict policy "replace"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			repo := newTestRepo(t)
+
+			var batches int
+			result, err := func() (models.ImportResult, error) {
+				r, err := NewReader(strings.NewReader(tt.Input), tt.Format)
+				if err != nil {
+					return models.ImportResult{}, err
+				}
+				return Import(context.Background(), repo, r, ImportOptions{
+					OnConflict: tt.OnConflict,
+					BatchSize:  2,
+					Progress:   func(models.ImportResult) { batches++ },
+				})
+			}()
+
+			if tt.ExceptedError != "" {
+				assert.ErrorContains(t, err, tt.ExceptedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.ExceptedResult, result)
+			assert.Equal(t, tt.ExceptedBatches, batches)
+		})
+	}
+}
+
+func Test_Import_KeepsCreationTime(t *testing.T) {
+	repo := newTestRepo(t)
+
+	r, err := NewReader(strings.NewReader("code,url,created_at\nabc,https://example.com,2020-05-01T12:00:00Z\n"), FormatCSV)
+	require.NoError(t, err)
+
+	_, err = Import(context.Background(), repo, r, ImportOptions{OnConflict: models.ConflictFail})
+	require.NoError(t, err)
+
+	link, err := repo.GetLinkByAlias(context.Background(), 1, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", link.URL)
+	assert.True(t, time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC).Equal(link.CreatedAt))
+}
+
+func Test_Import_DryRun(t *testing.T) {
+	repo := newTestRepo(t)
+
+	r, err := NewReader(strings.NewReader(`{"code":"abc","url":"https://example.com"}`), FormatJSONL)
+	require.NoError(t, err)
+
+	result, err := Import(context.Background(), repo, r, ImportOptions{OnConflict: models.ConflictFail, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Inserted: 1}, result)
+
+	_, err = repo.GetLinkByAlias(context.Background(), 1, "abc")
+	assert.Error(t, err)
+}
@@ -0,0 +1,165 @@
+// Package linkio imports links with their codes from CSV or JSONL and exports them back
+package linkio
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Record is a link in the file, the code is kept as alias on import
+type Record struct {
+	Code      string    `json:"code"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+
+	// Domain is the host of the link, the default domain if empty
+	Domain string `json:"domain,omitempty"`
+}
+
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+// csvHeader is written on export, on import the columns may go in any order and domain may be missing
+var csvHeader = []string{"code", "url", "created_at", "domain"}
+
+type Reader interface {
+	// Read returns io.EOF after the last record
+	Read() (Record, error)
+}
+
+type Writer interface {
+	Write(record Record) error
+	Flush() error
+}
+
+func NewReader(r io.Reader, format Format) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSONL:
+		return &jsonlReader{decoder: json.NewDecoder(r)}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func NewWriter(w io.Writer, format Format) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case FormatJSONL:
+		buffered := bufio.NewWriter(w)
+		return &jsonlWriter{buffered: buffered, encoder: json.NewEncoder(buffered)}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("csv header is missing")
+	} else if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range csvHeader[:3] {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv column %q is missing", name)
+		}
+	}
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvReader) Read() (Record, error) {
+	row, err := r.reader.Read()
+	if err != nil {
+		return Record{}, err
+	}
+
+	record := Record{
+		Code: row[r.columns["code"]],
+		URL:  row[r.columns["url"]],
+	}
+	if i, ok := r.columns["domain"]; ok {
+		record.Domain = row[i]
+	}
+	if createdAt := row[r.columns["created_at"]]; createdAt != "" {
+		record.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			return Record{}, fmt.Errorf("bad created_at: %w", err)
+		}
+	}
+
+	return record, nil
+}
+
+type csvWriter struct {
+	writer      *csv.Writer
+	wroteHeader bool
+}
+
+func (w *csvWriter) Write(record Record) error {
+	if !w.wroteHeader {
+		if err := w.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		w.wroteHeader = true
+	}
+
+	var createdAt string
+	if !record.CreatedAt.IsZero() {
+		createdAt = record.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return w.writer.Write([]string{record.Code, record.URL, createdAt, record.Domain})
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonlReader struct {
+	decoder *json.Decoder
+}
+
+func (r *jsonlReader) Read() (Record, error) {
+	var record Record
+	err := r.decoder.Decode(&record)
+	return record, err
+}
+
+type jsonlWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (w *jsonlWriter) Write(record Record) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.buffered.Flush()
+}
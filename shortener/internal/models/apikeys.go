@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Scopes of API keys
+const (
+	ScopeShorten     = "shorten"
+	ScopeReadStats   = "read-stats"
+	ScopeManageLinks = "manage-links"
+)
+
+// Scopes lists every scope an API key can be granted
+var Scopes = []string{ScopeShorten, ScopeReadStats, ScopeManageLinks}
+
+// APIKey authenticates its principal on the gateway, only the hash of the secret key is stored
+type APIKey struct {
+	ID int64
+
+	// Prefix is the beginning of the key shown to tell the keys apart
+	Prefix  string
+	KeyHash string
+
+	Principal string
+	Name      string
+	Scopes    []string
+
+	CreatedAt time.Time
+	// RevokedAt is zero if the key is active
+	RevokedAt time.Time
+}
+
+// IsRevoked reports whether the key can't be used anymore
+func (k *APIKey) IsRevoked() bool {
+	return !k.RevokedAt.IsZero()
+}
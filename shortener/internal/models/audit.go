@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditAction is the kind of the link change
+type AuditAction string
+
+const (
+	AuditCreate         AuditAction = "create"
+	AuditImport         AuditAction = "import"
+	AuditDelete         AuditAction = "delete"
+	AuditRetarget       AuditAction = "retarget"
+	AuditSetDomain      AuditAction = "set_domain"
+	AuditSetExpiresAt   AuditAction = "set_expires_at"
+	AuditUpdateMetadata AuditAction = "update_metadata"
+)
+
+// AuditEvent records who changed the link and how, entries are never changed after they are written
+type AuditEvent struct {
+	ID     int64
+	LinkID int64
+
+	// DomainID and Code identify the link, they are kept after the link is deleted
+	DomainID int32
+	Code     string
+	Domain   string
+
+	Action AuditAction
+
+	// Actor is the principal made the change, empty if anonymous
+	Actor     string
+	RequestID string
+
+	// OldValue and NewValue are JSON objects with the changed fields, nil if there is no such state,
+	// e.g. before the link is created
+	OldValue json.RawMessage
+	NewValue json.RawMessage
+
+	CreatedAt time.Time
+}
+
+// AuditFilter selects audit events, zero fields don't filter
+type AuditFilter struct {
+	DomainID int32
+	Code     string
+	Actor    string
+	Action   AuditAction
+}
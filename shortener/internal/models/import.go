@@ -0,0 +1,43 @@
+package models
+
+// ConflictPolicy decides what happens to the imported link whose code is taken in the domain
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the existing link
+	ConflictSkip ConflictPolicy = "skip"
+
+	// ConflictOverwrite replaces URL and creation time of the existing link with alias.
+	// Links with generated codes are never overwritten.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+
+	// ConflictFail stops the import
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// ImportResult counts imported links
+type ImportResult struct {
+	Inserted int64
+	Updated  int64
+	Skipped  int64
+
+	// Conflicts are the links whose code is the generated code of a link in the domain,
+	// they are never imported and fail the import with ConflictFail
+	Conflicts int64
+}
+
+// Add sums the results
+func (r ImportResult) Add(other ImportResult) ImportResult {
+	return ImportResult{
+		Inserted: r.Inserted + other.Inserted,
+		Updated:  r.Updated + other.Updated,
+		Skipped:  r.Skipped + other.Skipped,
+
+		Conflicts: r.Conflicts + other.Conflicts,
+	}
+}
+
+// Total is the number of processed links
+func (r ImportResult) Total() int64 {
+	return r.Inserted + r.Updated + r.Skipped + r.Conflicts
+}
@@ -1,17 +1,36 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type KafkaMessageShortened struct {
 	ShortenedAt time.Time `json:"shortened_at"`
 	OriginalURL string    `json:"original_url"`
 	ShortCode   string    `json:"short_code"`
+	Domain      string    `json:"domain"`
+	Tags        []string  `json:"tags,omitempty"`
 }
 
 type KafkaMessageUnshortened struct {
 	UnshortenedAt time.Time `json:"unshortened_at"`
 	OriginalURL   string    `json:"original_url"`
 	ShortCode     string    `json:"short_code"`
+	Domain        string    `json:"domain"`
+	Rule          string    `json:"rule,omitempty"`
+	Variant       string    `json:"variant,omitempty"`
+	Country       string    `json:"country,omitempty"`
+
+	// Click context of the visit
+	Referer        string `json:"referer,omitempty"`
+	UserAgent      string `json:"user_agent,omitempty"`
+	IP             string `json:"ip,omitempty"`
+	AcceptLanguage string `json:"accept_language,omitempty"`
+
+	// Bot is set if the visit came from a link unfurler, crawler, monitor or script, BotName names it
+	Bot     bool   `json:"bot"`
+	BotName string `json:"bot_name,omitempty"`
 }
 
 type KafkaMessageUnshortenedTop struct {
@@ -19,5 +38,26 @@ type KafkaMessageUnshortenedTop struct {
 	Top        []struct {
 		OriginalURL string `json:"original_url"`
 		ShortCode   string `json:"short_code"`
+		Domain      string `json:"domain,omitempty"`
 	} `json:"top"`
 }
+
+// KafkaMessageInvalidated tells the caches outside the shortener to drop the changed link
+type KafkaMessageInvalidated struct {
+	Code          string    `json:"code"`
+	Domain        string    `json:"domain"`
+	InvalidatedAt time.Time `json:"invalidated_at"`
+}
+
+// KafkaMessageAudit is the link change from the audit log
+type KafkaMessageAudit struct {
+	ID        int64           `json:"id"`
+	Code      string          `json:"code"`
+	Domain    string          `json:"domain"`
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	OldValue  json.RawMessage `json:"old_value,omitempty"`
+	NewValue  json.RawMessage `json:"new_value,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
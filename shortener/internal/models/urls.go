@@ -3,15 +3,232 @@ package models
 import "time"
 
 type Short struct {
-	URL   string
-	Short string
+	URL     string
+	Short   string
+	Options LinkOptions
+
+	// Password protects the link if not empty
+	Password string
+
+	// NotBefore and ExpiresAt limit the time the link is active, zero if not limited
+	NotBefore time.Time
+	ExpiresAt time.Time
+
+	// Domain is the host of the link, the default domain if empty.
+	// It is set to the resolved host after shortening.
+	Domain string
+
+	// Alias is the custom code of the link, unique within the domain
+	Alias string
+
+	// Owner is the principal creating the link, empty if anonymous
+	Owner string
+
+	Metadata LinkMetadata
+
 	Error error
 }
 
-type UnshortenedTop struct {
-	ValidUntil time.Time
-	Top        []struct {
-		OriginalURL string
-		ShortCode   string
+// IsPlain reports whether the link is a plain redirect, so it can be shared between equal URLs
+func (s *Short) IsPlain() bool {
+	return s.Options.IsZero() && s.Password == "" && s.NotBefore.IsZero() && s.ExpiresAt.IsZero() && s.Alias == "" &&
+		s.Metadata.IsZero()
+}
+
+// LinkMetadata helps to organise links, it does not affect redirects
+type LinkMetadata struct {
+	Title string `json:"title,omitempty"`
+	Notes string `json:"notes,omitempty"`
+
+	// Tags are lower case and sorted
+	Tags []string `json:"tags,omitempty"`
+}
+
+// IsZero reports whether no metadata is set
+func (m LinkMetadata) IsZero() bool {
+	return m.Title == "" && m.Notes == "" && len(m.Tags) == 0
+}
+
+// TagCount is a tag with the number of its links
+type TagCount struct {
+	Name  string
+	Links int64
+}
+
+// LinkOptions describes how a link behaves on redirect
+type LinkOptions struct {
+	// ForwardQuery merges the query of the incoming request into the destination
+	ForwardQuery bool `json:"forward_query,omitempty"`
+
+	// UTM holds utm_* parameters applied to the destination.
+	// Values may contain the {code} placeholder.
+	UTM map[string]string `json:"utm,omitempty"`
+
+	// Rules are checked in order, the first matching rule sets the destination
+	Rules []RoutingRule `json:"rules,omitempty"`
+
+	// Destinations split the traffic by weight if no rule matched
+	Destinations []Destination `json:"destinations,omitempty"`
+
+	// Interstitial shows the visitor the destination before the redirect, for untrusted destinations
+	Interstitial bool `json:"interstitial,omitempty"`
+}
+
+// IsZero reports whether no options are set, so the link is a plain redirect
+func (o LinkOptions) IsZero() bool {
+	return !o.ForwardQuery && len(o.UTM) == 0 && len(o.Rules) == 0 && len(o.Destinations) == 0 && !o.Interstitial
+}
+
+// IsStatic reports whether the destination is the same for every visitor
+func (o LinkOptions) IsStatic() bool {
+	return !o.ForwardQuery && len(o.Rules) == 0 && len(o.Destinations) == 0
+}
+
+// RoutingRule sends visitors matching all of its non-empty conditions to URL
+type RoutingRule struct {
+	URL            string   `json:"url"`
+	Name           string   `json:"name,omitempty"`
+	UserAgent      string   `json:"user_agent,omitempty"`
+	Platform       string   `json:"platform,omitempty"`
+	AcceptLanguage string   `json:"accept_language,omitempty"`
+	Countries      []string `json:"countries,omitempty"`
+}
+
+type Link struct {
+	ID      int64       `json:"id"`
+	Code    string      `json:"code"`
+	URL     string      `json:"url"`
+	Options LinkOptions `json:"options"`
+
+	// PasswordHash is bcrypt hash of the link password, empty if the link is not protected.
+	// It is never marshaled, protected links are not cached.
+	PasswordHash string `json:"-"`
+
+	NotBefore time.Time `json:"not_before,omitzero"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+
+	DomainID int32  `json:"domain_id"`
+	Domain   string `json:"domain"`
+	Alias    string `json:"alias,omitempty"`
+
+	Owner     string    `json:"owner,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+
+	// Metadata tags are loaded only when links are listed or the metadata is requested
+	Metadata LinkMetadata `json:"-"`
+}
+
+// reservedAliases are the paths of the gateway routes, which would shadow the links with these aliases
+var reservedAliases = map[string]struct{}{
+	"api":     {},
+	"docs":    {},
+	"shorten": {},
+}
+
+// IsReservedAlias reports whether the link with the alias would be shadowed by a gateway route
+func IsReservedAlias(alias string) bool {
+	_, ok := reservedAliases[alias]
+	return ok
+}
+
+// LinkFilter selects the listed links, zero fields are not filtered
+type LinkFilter struct {
+	Owner  string
+	Domain string
+
+	// CreatedFrom is inclusive, CreatedTo is exclusive
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+
+	// Enabled selects links active at the moment if true, not active if false
+	Enabled *bool
+
+	// Search is a case-insensitive substring of the destination URL
+	Search string
+
+	// Tags selects links having all of the tags
+	Tags []string
+}
+
+// LinkCursor points after the link in the list ordered by creation time and ID, newest first.
+// The zero cursor points at the start of the list.
+type LinkCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// IsZero reports whether the cursor points at the start of the list
+func (c LinkCursor) IsZero() bool {
+	return c.ID == 0
+}
+
+// IsActive reports whether the link can be visited at the moment
+func (l *Link) IsActive(now time.Time) bool {
+	if !l.NotBefore.IsZero() && now.Before(l.NotBefore) {
+		return false
 	}
+	if !l.ExpiresAt.IsZero() && !now.Before(l.ExpiresAt) {
+		return false
+	}
+	return true
+}
+
+// IsProtected reports whether the link requires a password
+func (l *Link) IsProtected() bool {
+	return l.PasswordHash != ""
+}
+
+// Domain is a registered short domain with its own code namespace
+type Domain struct {
+	ID        int32
+	Host      string
+	IsDefault bool
+}
+
+// Destination is a variant of the link chosen with the probability proportional to its weight
+type Destination struct {
+	URL    string `json:"url"`
+	Weight uint32 `json:"weight"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Redirect is where the visitor of the link is sent
+type Redirect struct {
+	URL string
+
+	// Interstitial tells to show the destination to the visitor instead of redirecting right away
+	Interstitial bool
+
+	// Cacheable is set if the redirect is the same for every visitor, ExpiresAt limits the cache then
+	Cacheable bool
+	ExpiresAt time.Time
+
+	// Rule is the matched routing rule and Variant the chosen weighted destination, empty if none
+	Rule    string
+	Variant string
+}
+
+// Visit describes the incoming request the link is resolved for
+type Visit struct {
+	Query          string
+	Referer        string
+	UserAgent      string
+	Platform       string
+	AcceptLanguage string
+
+	// Country is ISO 3166-1 alpha-2 code resolved from the visitor IP, may be empty
+	Country string
+
+	// VisitorID identifies the visitor between visits, may be empty
+	VisitorID string
+
+	// LinkToken unlocks the password-protected link, issued on password verification
+	LinkToken string
+
+	// IP is the client IP as the gateway resolved it, anonymised before the click is published
+	IP string
+
+	// Rule and Variant of the visit resolved before it is recorded, empty if none
+	Rule    string
+	Variant string
 }
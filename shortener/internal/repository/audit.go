@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/misshanya/url-shortener/shortener/internal/audit"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/misshanya/url-shortener/shortener/pkg/base62"
+	"time"
+)
+
+// auditRelayName is the relay offset of the events published to Kafka
+const auditRelayName = "kafka"
+
+// linkState is the audited state of the whole link, it is recorded when the link is created or deleted
+type linkState struct {
+	URL               string              `json:"url"`
+	Alias             string              `json:"alias,omitempty"`
+	Owner             string              `json:"owner,omitempty"`
+	Options           *models.LinkOptions `json:"options,omitempty"`
+	PasswordProtected bool                `json:"password_protected,omitempty"`
+	NotBefore         *time.Time          `json:"not_before,omitempty"`
+	ExpiresAt         *time.Time          `json:"expires_at,omitempty"`
+	CreatedAt         *time.Time          `json:"created_at,omitempty"`
+	Title             string              `json:"title,omitempty"`
+	Notes             string              `json:"notes,omitempty"`
+	Tags              []string            `json:"tags,omitempty"`
+}
+
+func stateOf(link models.Link) linkState {
+	state := linkState{
+		URL:               link.URL,
+		Alias:             link.Alias,
+		Owner:             link.Owner,
+		PasswordProtected: link.PasswordHash != "",
+		NotBefore:         timeOrNil(link.NotBefore),
+		ExpiresAt:         timeOrNil(link.ExpiresAt),
+		CreatedAt:         timeOrNil(link.CreatedAt),
+		Title:             link.Metadata.Title,
+		Notes:             link.Metadata.Notes,
+		Tags:              link.Metadata.Tags,
+	}
+	if !link.Options.IsZero() {
+		state.Options = &link.Options
+	}
+	return state
+}
+
+// importState is the part of the link changed by import
+type importState struct {
+	URL       string     `json:"url"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// urlState is the destination changed by retarget
+type urlState struct {
+	URL string `json:"url"`
+}
+
+// expiresState is the expiration time, null if the link never expires
+type expiresState struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// domainState is the domain of the link, null for links created before domains were introduced
+type domainState struct {
+	Domain *string `json:"domain"`
+}
+
+// movedLinkEvents makes the events of the links moved to the default domain
+func movedLinkEvents(ctx context.Context, moved []models.Link, host string) ([]models.AuditEvent, error) {
+	events := make([]models.AuditEvent, 0, len(moved))
+	for _, link := range moved {
+		event, err := newAuditEvent(ctx, models.AuditSetDomain, link, domainState{}, domainState{Domain: &host})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// metadataState is the metadata as a whole, empty fields are kept to show what was cleared
+type metadataState struct {
+	Title string   `json:"title"`
+	Notes string   `json:"notes"`
+	Tags  []string `json:"tags"`
+}
+
+func metadataStateOf(metadata models.LinkMetadata) metadataState {
+	tags := metadata.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return metadataState{Title: metadata.Title, Notes: metadata.Notes, Tags: tags}
+}
+
+// newAuditEvent makes the event of the link change by the actor from the context.
+// Old or new state is nil if there is no such state.
+func newAuditEvent(ctx context.Context, action models.AuditAction, link models.Link, oldState, newState any) (models.AuditEvent, error) {
+	actor := audit.ActorFromContext(ctx)
+	event := models.AuditEvent{
+		LinkID:    link.ID,
+		DomainID:  link.DomainID,
+		Code:      linkCode(link),
+		Action:    action,
+		Actor:     actor.Principal,
+		RequestID: actor.RequestID,
+	}
+
+	var err error
+	if oldState != nil {
+		if event.OldValue, err = json.Marshal(oldState); err != nil {
+			return models.AuditEvent{}, err
+		}
+	}
+	if newState != nil {
+		if event.NewValue, err = json.Marshal(newState); err != nil {
+			return models.AuditEvent{}, err
+		}
+	}
+
+	return event, nil
+}
+
+// linkCode is the alias of the link or its generated code
+func linkCode(link models.Link) string {
+	if link.Alias != "" {
+		return link.Alias
+	}
+	return base62.Encode(link.ID)
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
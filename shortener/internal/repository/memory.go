@@ -0,0 +1,627 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/misshanya/url-shortener/shortener/internal/errorz"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/misshanya/url-shortener/shortener/pkg/base62"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRepo keeps links and domains in memory, it is meant for local development and tests.
+// Everything is lost when the process stops.
+type MemoryRepo struct {
+	mu      sync.RWMutex
+	links   map[int64]models.Link
+	tags    map[int64][]string
+	domains []models.Domain
+
+	// lastID is the greatest assigned or leased ID, like AUTOINCREMENT counter of SQLite
+	lastID int64
+
+	// audit is the log of link changes, ID of the event is its position starting from 1
+	audit []models.AuditEvent
+	// relayed is ID of the last relayed audit event
+	relayed int64
+
+	// apiKeys are ordered by ID which is the position starting from 1
+	apiKeys []models.APIKey
+}
+
+func NewMemoryRepo() *MemoryRepo {
+	return &MemoryRepo{
+		links: make(map[int64]models.Link),
+		tags:  make(map[int64][]string),
+	}
+}
+
+// StoreURL stores the link, the next ID is assigned if not allocated.
+// errorz.ErrCodeShadowed is returned if the generated code of the link is taken by an alias.
+func (r *MemoryRepo) StoreURL(ctx context.Context, link *models.Link) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.links[link.ID]; ok {
+		return 0, fmt.Errorf("link with id %d already exists", link.ID)
+	}
+	if link.Alias != "" && r.findAlias(link.DomainID, link.Alias) != 0 {
+		return 0, errorz.ErrAliasTaken
+	}
+
+	stored, err := cloneLink(*link)
+	if err != nil {
+		return 0, err
+	}
+
+	if stored.ID == 0 {
+		stored.ID = r.lastID + 1
+	}
+	r.lastID = max(r.lastID, stored.ID)
+	if stored.Alias == "" && r.findAlias(stored.DomainID, base62.Encode(stored.ID)) != 0 {
+		return 0, errorz.ErrCodeShadowed
+	}
+	stored.Code = ""
+	stored.Domain = ""
+	stored.CreatedAt = time.Now().Truncate(time.Microsecond)
+	if len(stored.Metadata.Tags) > 0 {
+		r.tags[stored.ID] = stored.Metadata.Tags
+	}
+	// The creation time is recorded by the event itself, like other backends do
+	created := stored
+	created.CreatedAt = time.Time{}
+	event, err := newAuditEvent(ctx, models.AuditCreate, created, nil, stateOf(created))
+	if err != nil {
+		return 0, err
+	}
+
+	stored.Metadata.Tags = nil
+	r.links[stored.ID] = stored
+	r.appendAudit(event)
+
+	return stored.ID, nil
+}
+
+// GetID returns ID of the plain link (without options, password, activation window, alias and metadata)
+// for the URL in the domain created by the owner
+func (r *MemoryRepo) GetID(ctx context.Context, url string, domainID int32, owner string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, id := range slices.Sorted(maps.Keys(r.links)) {
+		link := r.links[id]
+		if link.URL == url && link.DomainID == domainID && link.Owner == owner && r.isPlain(link) {
+			return id, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+func (r *MemoryRepo) GetLink(ctx context.Context, id int64) (*models.Link, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	link, ok := r.links[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return r.get(link)
+}
+
+// GetLinkByAlias returns the link with the alias in the domain
+func (r *MemoryRepo) GetLinkByAlias(ctx context.Context, domainID int32, alias string) (*models.Link, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id := r.findAlias(domainID, alias)
+	if id == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return r.get(r.links[id])
+}
+
+// ListLinks returns up to limit links matching the filter after the cursor, newest first.
+// Zero domainID and cursor are not filtered.
+func (r *MemoryRepo) ListLinks(ctx context.Context, filter models.LinkFilter, domainID int32, after models.LinkCursor, limit int32) ([]models.Link, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := slices.SortedFunc(maps.Keys(r.links), func(a, b int64) int {
+		return compareLinks(r.links[b], r.links[a])
+	})
+
+	now := time.Now()
+	search := strings.ToLower(filter.Search)
+
+	links := make([]models.Link, 0, min(int(limit), len(ids)))
+	for _, id := range ids {
+		if len(links) == int(limit) {
+			break
+		}
+
+		link := r.links[id]
+		switch {
+		case !after.IsZero() && compareLinks(link, models.Link{ID: after.ID, CreatedAt: after.CreatedAt}) >= 0,
+			filter.Owner != "" && link.Owner != filter.Owner,
+			domainID != 0 && link.DomainID != domainID,
+			!filter.CreatedFrom.IsZero() && link.CreatedAt.Before(filter.CreatedFrom),
+			!filter.CreatedTo.IsZero() && !link.CreatedAt.Before(filter.CreatedTo),
+			filter.Enabled != nil && link.IsActive(now) != *filter.Enabled,
+			search != "" && !strings.Contains(strings.ToLower(link.URL), search),
+			!hasTags(r.tags[id], filter.Tags):
+			continue
+		}
+
+		found, err := r.get(link)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *found)
+	}
+
+	return links, nil
+}
+
+// DeleteLink deletes the link with its tags, deleting the missing link is not an error
+func (r *MemoryRepo) DeleteLink(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link, ok := r.links[id]
+	if !ok {
+		return nil
+	}
+	link.Metadata.Tags = slices.Sorted(slices.Values(r.tags[id]))
+	event, err := newAuditEvent(ctx, models.AuditDelete, link, stateOf(link), nil)
+	if err != nil {
+		return err
+	}
+
+	delete(r.links, id)
+	delete(r.tags, id)
+	r.appendAudit(event)
+	return nil
+}
+
+// ImportLinks stores the links with aliases keeping their creation time, all or nothing.
+// Links with taken codes are handled by the policy, the failed import returns errorz.ErrAliasTaken.
+// Nothing is stored on dry run.
+func (r *MemoryRepo) ImportLinks(ctx context.Context, links []models.Link, onConflict models.ConflictPolicy, dryRun bool) (models.ImportResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var (
+		result  models.ImportResult
+		inserts []models.Link
+		updates = make(map[int64]models.Link)
+		events  []models.AuditEvent
+	)
+	for _, link := range links {
+		if shadowed, ok := r.links[codeID(link.Alias)]; ok && shadowed.DomainID == link.DomainID && shadowed.Alias == "" {
+			result.Conflicts++
+			continue
+		}
+
+		createdAt := link.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		createdAt = createdAt.Truncate(time.Microsecond)
+
+		id := r.findAlias(link.DomainID, link.Alias)
+		switch {
+		case id == 0:
+			inserts = append(inserts, models.Link{URL: link.URL, DomainID: link.DomainID, Alias: link.Alias, CreatedAt: createdAt})
+			result.Inserted++
+		case onConflict == models.ConflictOverwrite:
+			previous := r.links[id]
+			updated := previous
+			updated.URL = link.URL
+			updated.CreatedAt = createdAt
+			updates[id] = updated
+			result.Updated++
+
+			event, err := newAuditEvent(ctx, models.AuditImport, updated,
+				importState{URL: previous.URL, CreatedAt: timeOrNil(previous.CreatedAt)},
+				importState{URL: updated.URL, CreatedAt: timeOrNil(updated.CreatedAt)})
+			if err != nil {
+				return models.ImportResult{}, err
+			}
+			events = append(events, event)
+		default:
+			result.Skipped++
+		}
+	}
+
+	if onConflict == models.ConflictFail && result.Skipped+result.Conflicts > 0 {
+		return models.ImportResult{}, errorz.ErrAliasTaken
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	// IDs are moved past the imported codes, so they are not generated again
+	r.lastID = max(r.lastID, maxSequenceCodeID(links, r.lastID))
+
+	maps.Copy(r.links, updates)
+	for _, link := range inserts {
+		r.lastID++
+		link.ID = r.lastID
+		r.links[link.ID] = link
+
+		event, err := newAuditEvent(ctx, models.AuditImport, link, nil, importState{URL: link.URL, CreatedAt: timeOrNil(link.CreatedAt)})
+		if err != nil {
+			return models.ImportResult{}, err
+		}
+		events = append(events, event)
+	}
+	r.appendAudit(events...)
+
+	return result, nil
+}
+
+// LeaseIDRange reserves size IDs for links and returns the first of them
+func (r *MemoryRepo) LeaseIDRange(ctx context.Context, size int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := r.lastID + 1
+	r.lastID += size
+	return start, nil
+}
+
+// SetLinkMetadata replaces title, notes and tags of the link
+func (r *MemoryRepo) SetLinkMetadata(ctx context.Context, id int64, metadata models.LinkMetadata) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link, ok := r.links[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	previous := link.Metadata
+	previous.Tags = slices.Sorted(slices.Values(r.tags[id]))
+	event, err := newAuditEvent(ctx, models.AuditUpdateMetadata, link, metadataStateOf(previous), metadataStateOf(metadata))
+	if err != nil {
+		return err
+	}
+	r.appendAudit(event)
+
+	link.Metadata = models.LinkMetadata{Title: metadata.Title, Notes: metadata.Notes}
+	r.links[id] = link
+
+	if len(metadata.Tags) > 0 {
+		r.tags[id] = slices.Clone(metadata.Tags)
+	} else {
+		delete(r.tags, id)
+	}
+
+	return nil
+}
+
+// SetLinkURL changes the destination of the link
+func (r *MemoryRepo) SetLinkURL(ctx context.Context, id int64, url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link, ok := r.links[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	event, err := newAuditEvent(ctx, models.AuditRetarget, link, urlState{URL: link.URL}, urlState{URL: url})
+	if err != nil {
+		return err
+	}
+	r.appendAudit(event)
+
+	link.URL = url
+	r.links[id] = link
+	return nil
+}
+
+// SetLinkExpiresAt changes when the link expires, zero time removes the limit
+func (r *MemoryRepo) SetLinkExpiresAt(ctx context.Context, id int64, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link, ok := r.links[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	event, err := newAuditEvent(ctx, models.AuditSetExpiresAt, link,
+		expiresState{ExpiresAt: timeOrNil(link.ExpiresAt)}, expiresState{ExpiresAt: timeOrNil(expiresAt)})
+	if err != nil {
+		return err
+	}
+	r.appendAudit(event)
+
+	link.ExpiresAt = expiresAt
+	r.links[id] = link
+	return nil
+}
+
+// ListAuditEvents returns audit events matching the filter, newest first, before the cursor ID if it is not 0
+func (r *MemoryRepo) ListAuditEvents(ctx context.Context, filter models.AuditFilter, cursor int64, limit int32) ([]models.AuditEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]models.AuditEvent, 0, min(int(limit), len(r.audit)))
+	for i := len(r.audit) - 1; i >= 0 && len(events) < int(limit); i-- {
+		event := r.audit[i]
+		switch {
+		case cursor > 0 && event.ID >= cursor,
+			filter.DomainID != 0 && event.DomainID != filter.DomainID,
+			filter.Code != "" && event.Code != filter.Code,
+			filter.Actor != "" && event.Actor != filter.Actor,
+			filter.Action != "" && event.Action != filter.Action:
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// RelayAuditEvents passes up to limit events not relayed yet to publish in order of their IDs,
+// they are marked as relayed if publish succeeds
+func (r *MemoryRepo) RelayAuditEvents(ctx context.Context, limit int32, publish func([]models.AuditEvent) error) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := r.audit[r.relayed:min(r.relayed+int64(limit), int64(len(r.audit)))]
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := publish(slices.Clone(events)); err != nil {
+		return 0, err
+	}
+
+	r.relayed = events[len(events)-1].ID
+	return len(events), nil
+}
+
+// appendAudit assigns IDs and creation time to the events and appends them to the log
+func (r *MemoryRepo) appendAudit(events ...models.AuditEvent) {
+	now := time.Now().Truncate(time.Microsecond)
+	for _, event := range events {
+		event.ID = int64(len(r.audit)) + 1
+		event.CreatedAt = now
+		r.audit = append(r.audit, event)
+	}
+}
+
+// GetLinkTags returns sorted tags of the links by their IDs, links without tags are omitted
+func (r *MemoryRepo) GetLinkTags(ctx context.Context, ids []int64) (map[int64][]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tags := make(map[int64][]string)
+	for _, id := range ids {
+		if linkTags, ok := r.tags[id]; ok {
+			tags[id] = slices.Sorted(slices.Values(linkTags))
+		}
+	}
+
+	return tags, nil
+}
+
+// ListTags returns the tags with the number of their links, only of the links of the owner if it is set
+func (r *MemoryRepo) ListTags(ctx context.Context, owner string) ([]models.TagCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int64)
+	for id, linkTags := range r.tags {
+		if owner != "" && r.links[id].Owner != owner {
+			continue
+		}
+		for _, tag := range linkTags {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]models.TagCount, 0, len(counts))
+	for _, name := range slices.Sorted(maps.Keys(counts)) {
+		tags = append(tags, models.TagCount{Name: name, Links: counts[name]})
+	}
+
+	return tags, nil
+}
+
+func (r *MemoryRepo) CreateDomain(ctx context.Context, host string) (*models.Domain, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.findDomain(host) >= 0 {
+		return nil, errorz.ErrDomainExists
+	}
+
+	domain := r.addDomain(host)
+	return &domain, nil
+}
+
+func (r *MemoryRepo) ListDomains(ctx context.Context) ([]models.Domain, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.domains), nil
+}
+
+// SetDefaultDomain makes the host the default domain, registering it if needed.
+// Links without domain are moved to it, every move is audited.
+func (r *MemoryRepo) SetDefaultDomain(ctx context.Context, host string) (*models.Domain, []models.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.findDomain(host)
+	if i < 0 {
+		r.addDomain(host)
+		i = len(r.domains) - 1
+	}
+	for j := range r.domains {
+		r.domains[j].IsDefault = j == i
+	}
+	domain := r.domains[i]
+
+	var moved []models.Link
+	for _, id := range slices.Sorted(maps.Keys(r.links)) {
+		link := r.links[id]
+		if link.DomainID == 0 {
+			link.DomainID = domain.ID
+			r.links[id] = link
+			moved = append(moved, models.Link{ID: id, DomainID: domain.ID, Alias: link.Alias})
+		}
+	}
+
+	events, err := movedLinkEvents(ctx, moved, domain.Host)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.appendAudit(events...)
+
+	return &domain, moved, nil
+}
+
+func (r *MemoryRepo) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *key
+	stored.ID = int64(len(r.apiKeys)) + 1
+	stored.Scopes = slices.Clone(key.Scopes)
+	stored.CreatedAt = time.Now()
+	stored.RevokedAt = time.Time{}
+	r.apiKeys = append(r.apiKeys, stored)
+
+	return cloneAPIKey(stored), nil
+}
+
+func (r *MemoryRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.apiKeys {
+		if key.KeyHash == keyHash {
+			return cloneAPIKey(key), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *MemoryRepo) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]models.APIKey, 0, len(r.apiKeys))
+	for i := len(r.apiKeys) - 1; i >= 0; i-- {
+		keys = append(keys, *cloneAPIKey(r.apiKeys[i]))
+	}
+	return keys, nil
+}
+
+func (r *MemoryRepo) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if id < 1 || id > int64(len(r.apiKeys)) {
+		return nil, sql.ErrNoRows
+	}
+	key := &r.apiKeys[id-1]
+	if key.RevokedAt.IsZero() {
+		key.RevokedAt = time.Now()
+	}
+	return cloneAPIKey(*key), nil
+}
+
+// get returns a copy of the stored link, so the caller can't change it
+func (r *MemoryRepo) get(link models.Link) (*models.Link, error) {
+	found, err := cloneLink(link)
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+// isPlain reports whether the stored link can be shared between equal URLs
+func (r *MemoryRepo) isPlain(link models.Link) bool {
+	return link.Options.IsZero() && link.PasswordHash == "" && link.NotBefore.IsZero() && link.ExpiresAt.IsZero() &&
+		link.Alias == "" && link.Metadata.Title == "" && link.Metadata.Notes == "" && len(r.tags[link.ID]) == 0
+}
+
+// findAlias returns ID of the link with the alias in the domain, 0 if there is no such link
+func (r *MemoryRepo) findAlias(domainID int32, alias string) int64 {
+	for id, link := range r.links {
+		if link.DomainID == domainID && link.Alias == alias {
+			return id
+		}
+	}
+	return 0
+}
+
+// findDomain returns the index of the domain with the host, -1 if it is not registered
+func (r *MemoryRepo) findDomain(host string) int {
+	return slices.IndexFunc(r.domains, func(domain models.Domain) bool { return domain.Host == host })
+}
+
+// addDomain registers the domain with the next ID
+func (r *MemoryRepo) addDomain(host string) models.Domain {
+	var id int32 = 1
+	if len(r.domains) > 0 {
+		id = r.domains[len(r.domains)-1].ID + 1
+	}
+	domain := models.Domain{ID: id, Host: host}
+	r.domains = append(r.domains, domain)
+	return domain
+}
+
+// cloneAPIKey copies the key, so the caller can't change the stored scopes
+func cloneAPIKey(key models.APIKey) *models.APIKey {
+	key.Scopes = slices.Clone(key.Scopes)
+	return &key
+}
+
+// cloneLink deeply copies the link, options are copied through JSON as they are stored in the db
+func cloneLink(link models.Link) (models.Link, error) {
+	if !link.Options.IsZero() {
+		optionsJSON, err := json.Marshal(link.Options)
+		if err != nil {
+			return models.Link{}, err
+		}
+		link.Options = models.LinkOptions{}
+		if err := json.Unmarshal(optionsJSON, &link.Options); err != nil {
+			return models.Link{}, err
+		}
+	} else {
+		link.Options = models.LinkOptions{}
+	}
+	link.Metadata.Tags = slices.Clone(link.Metadata.Tags)
+	return link, nil
+}
+
+// hasTags reports whether the link tags contain all of the wanted tags
+func hasTags(linkTags, wanted []string) bool {
+	for _, tag := range wanted {
+		if !slices.Contains(linkTags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareLinks orders the links by creation time and then by ID
+func compareLinks(a, b models.Link) int {
+	return cmp.Or(cmp.Compare(a.CreatedAt.UnixMicro(), b.CreatedAt.UnixMicro()), cmp.Compare(a.ID, b.ID))
+}
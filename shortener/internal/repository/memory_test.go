@@ -0,0 +1,13 @@
+package repository_test
+
+import (
+	"github.com/misshanya/url-shortener/shortener/internal/repository"
+	"github.com/misshanya/url-shortener/shortener/internal/repository/repotest"
+	"testing"
+)
+
+func TestMemoryRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.Repo {
+		return repository.NewMemoryRepo()
+	})
+}
@@ -2,25 +2,753 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/misshanya/url-shortener/shortener/internal/audit"
 	"github.com/misshanya/url-shortener/shortener/internal/db/sqlc/storage"
+	"github.com/misshanya/url-shortener/shortener/internal/errorz"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/misshanya/url-shortener/shortener/pkg/base62"
+	"strings"
+	"time"
 )
 
 type PostgresRepo struct {
+	pool    *pgxpool.Pool
 	queries *storage.Queries
 }
 
-func NewPostgresRepo(queries *storage.Queries) *PostgresRepo {
-	return &PostgresRepo{queries: queries}
+func NewPostgresRepo(pool *pgxpool.Pool) *PostgresRepo {
+	return &PostgresRepo{pool: pool, queries: storage.New(pool)}
 }
 
-func (r *PostgresRepo) StoreURL(ctx context.Context, url string) (int64, error) {
-	return r.queries.StoreShort(ctx, url)
+// StoreURL stores the link with its options, password hash, activation window, alias and metadata
+// Unset fields are stored as NULL for plain links, so they can be found by GetID.
+// The link ID is taken from the sequence if not allocated.
+// errorz.ErrCodeShadowed is returned if the generated code of the link is taken by an alias.
+func (r *PostgresRepo) StoreURL(ctx context.Context, link *models.Link) (int64, error) {
+	var optionsJSON []byte
+	if !link.Options.IsZero() {
+		var err error
+		optionsJSON, err = json.Marshal(link.Options)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var id int64
+	err := r.inTx(ctx, func(q *storage.Queries) error {
+		var err error
+		id, err = q.StoreShort(ctx, storage.StoreShortParams{
+			ID:           pgtype.Int8{Int64: link.ID, Valid: link.ID != 0},
+			Url:          link.URL,
+			Options:      optionsJSON,
+			PasswordHash: pgtype.Text{String: link.PasswordHash, Valid: link.PasswordHash != ""},
+			NotBefore:    pgtype.Timestamptz{Time: link.NotBefore, Valid: !link.NotBefore.IsZero()},
+			ExpiresAt:    pgtype.Timestamptz{Time: link.ExpiresAt, Valid: !link.ExpiresAt.IsZero()},
+			DomainID:     pgtype.Int4{Int32: link.DomainID, Valid: true},
+			Alias:        pgtype.Text{String: link.Alias, Valid: link.Alias != ""},
+			Owner:        pgtype.Text{String: link.Owner, Valid: link.Owner != ""},
+			Title:        pgtype.Text{String: link.Metadata.Title, Valid: link.Metadata.Title != ""},
+			Notes:        pgtype.Text{String: link.Metadata.Notes, Valid: link.Metadata.Notes != ""},
+		})
+		if isUniqueViolation(err, aliasConstraint) {
+			return errorz.ErrAliasTaken
+		} else if err != nil {
+			return err
+		}
+
+		// The transaction is rolled back, the sequence doesn't give the ID again
+		if link.Alias == "" {
+			_, err := q.GetURLByAlias(ctx, storage.GetURLByAliasParams{
+				DomainID: pgtype.Int4{Int32: link.DomainID, Valid: true},
+				Alias:    pgtype.Text{String: base62.Encode(id), Valid: true},
+			})
+			if err == nil {
+				return errorz.ErrCodeShadowed
+			} else if !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+		}
+
+		if len(link.Metadata.Tags) > 0 {
+			if err := q.SetURLTags(ctx, storage.SetURLTagsParams{Names: link.Metadata.Tags, UrlID: id}); err != nil {
+				return err
+			}
+		}
+
+		stored := *link
+		stored.ID = id
+		event, err := newAuditEvent(ctx, models.AuditCreate, stored, nil, stateOf(stored))
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, q, event)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ImportLinks stores the links with aliases keeping their creation time in one transaction.
+// Links with taken codes are handled by the policy, the failed import returns errorz.ErrAliasTaken.
+// Nothing is stored on dry run.
+func (r *PostgresRepo) ImportLinks(ctx context.Context, links []models.Link, onConflict models.ConflictPolicy, dryRun bool) (models.ImportResult, error) {
+	params := storage.ImportURLsParams{
+		Urls:      make([]string, len(links)),
+		DomainIds: make([]int32, len(links)),
+		Aliases:   make([]string, len(links)),
+		CreatedAt: make([]pgtype.Timestamptz, len(links)),
+		CodeIds:   make([]int64, len(links)),
+		Overwrite: onConflict == models.ConflictOverwrite,
+	}
+	for i, link := range links {
+		params.Urls[i] = link.URL
+		params.DomainIds[i] = link.DomainID
+		params.Aliases[i] = link.Alias
+		params.CreatedAt[i] = pgtype.Timestamptz{Time: link.CreatedAt, Valid: !link.CreatedAt.IsZero()}
+		params.CodeIds[i] = codeID(link.Alias)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	defer tx.Rollback(ctx)
+	q := r.queries.WithTx(tx)
+
+	// Overwritten links are locked to record their previous state
+	previous := make(map[aliasKey]importState)
+	if params.Overwrite {
+		rows, err := q.LockURLsByAliases(ctx, storage.LockURLsByAliasesParams{DomainIds: params.DomainIds, Aliases: params.Aliases})
+		if err != nil {
+			return models.ImportResult{}, err
+		}
+		for _, row := range rows {
+			key := aliasKey{domainID: row.DomainID.Int32, alias: row.Alias.String}
+			previous[key] = importState{URL: row.Url, CreatedAt: timeOrNil(row.CreatedAt.Time)}
+		}
+	}
+
+	// Codes equal to generated ones are not imported by ImportURLs, they are reported as conflicts
+	conflicts, err := q.CountGeneratedCodes(ctx, storage.CountGeneratedCodesParams{CodeIds: params.CodeIds, DomainIds: params.DomainIds})
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	rows, err := q.ImportURLs(ctx, params)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+
+	result := models.ImportResult{Conflicts: conflicts}
+	events := make([]models.AuditEvent, 0, len(rows))
+	for _, row := range rows {
+		var oldState any
+		if row.Inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+			oldState = previous[aliasKey{domainID: row.DomainID.Int32, alias: row.Alias.String}]
+		}
+
+		link := models.Link{ID: row.ID, DomainID: row.DomainID.Int32, Alias: row.Alias.String}
+		event, err := newAuditEvent(ctx, models.AuditImport, link, oldState, importState{URL: row.Url, CreatedAt: timeOrNil(row.CreatedAt.Time)})
+		if err != nil {
+			return models.ImportResult{}, err
+		}
+		events = append(events, event)
+	}
+	result.Skipped = int64(len(links)) - result.Inserted - result.Updated - result.Conflicts
+
+	if onConflict == models.ConflictFail && result.Skipped+result.Conflicts > 0 {
+		return models.ImportResult{}, errorz.ErrAliasTaken
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	// The sequence is not rolled back, so it is moved only when the import is committed
+	lastID, err := q.GetLastID(ctx)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	if id := maxSequenceCodeID(links, lastID); id > 0 {
+		if err := q.AdvanceIDs(ctx, id); err != nil {
+			return models.ImportResult{}, err
+		}
+	}
+
+	if err := writeAudit(ctx, q, events...); err != nil {
+		return models.ImportResult{}, err
+	}
+
+	return result, tx.Commit(ctx)
+}
+
+// LeaseIDRange reserves size IDs for links and returns the first of them
+func (r *PostgresRepo) LeaseIDRange(ctx context.Context, size int64) (int64, error) {
+	return r.queries.LeaseIDRange(ctx, size)
+}
+
+// SetLinkMetadata replaces title, notes and tags of the link
+func (r *PostgresRepo) SetLinkMetadata(ctx context.Context, id int64, metadata models.LinkMetadata) error {
+	return r.inTx(ctx, func(q *storage.Queries) error {
+		link, err := lockLink(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		tags, err := q.GetTagsByURLs(ctx, []int64{id})
+		if err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			link.Metadata.Tags = append(link.Metadata.Tags, tag.Name)
+		}
+
+		_, err = q.UpdateURLMetadata(ctx, storage.UpdateURLMetadataParams{
+			ID:    id,
+			Title: pgtype.Text{String: metadata.Title, Valid: metadata.Title != ""},
+			Notes: pgtype.Text{String: metadata.Notes, Valid: metadata.Notes != ""},
+		})
+		if err != nil {
+			return err
+		}
+
+		// Empty array removes all tags of the link
+		names := metadata.Tags
+		if names == nil {
+			names = []string{}
+		}
+		if err := q.SetURLTags(ctx, storage.SetURLTagsParams{Names: names, UrlID: id}); err != nil {
+			return err
+		}
+
+		event, err := newAuditEvent(ctx, models.AuditUpdateMetadata, *link, metadataStateOf(link.Metadata), metadataStateOf(metadata))
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, q, event)
+	})
+}
+
+// SetLinkURL changes the destination of the link
+func (r *PostgresRepo) SetLinkURL(ctx context.Context, id int64, url string) error {
+	return r.inTx(ctx, func(q *storage.Queries) error {
+		link, err := lockLink(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.UpdateURLTarget(ctx, storage.UpdateURLTargetParams{ID: id, Url: url}); err != nil {
+			return err
+		}
+
+		event, err := newAuditEvent(ctx, models.AuditRetarget, *link, urlState{URL: link.URL}, urlState{URL: url})
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, q, event)
+	})
+}
+
+// SetLinkExpiresAt changes when the link expires, zero time removes the limit
+func (r *PostgresRepo) SetLinkExpiresAt(ctx context.Context, id int64, expiresAt time.Time) error {
+	return r.inTx(ctx, func(q *storage.Queries) error {
+		link, err := lockLink(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.UpdateURLExpiresAt(ctx, storage.UpdateURLExpiresAtParams{
+			ID:        id,
+			ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: !expiresAt.IsZero()},
+		})
+		if err != nil {
+			return err
+		}
+
+		event, err := newAuditEvent(ctx, models.AuditSetExpiresAt, *link,
+			expiresState{ExpiresAt: timeOrNil(link.ExpiresAt)}, expiresState{ExpiresAt: timeOrNil(expiresAt)})
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, q, event)
+	})
+}
+
+// GetLinkTags returns sorted tags of the links by their IDs, links without tags are omitted
+func (r *PostgresRepo) GetLinkTags(ctx context.Context, ids []int64) (map[int64][]string, error) {
+	rows, err := r.queries.GetTagsByURLs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[int64][]string)
+	for _, row := range rows {
+		tags[row.UrlID] = append(tags[row.UrlID], row.Name)
+	}
+
+	return tags, nil
+}
+
+// ListTags returns the tags with the number of their links, only of the links of the owner if it is set
+func (r *PostgresRepo) ListTags(ctx context.Context, owner string) ([]models.TagCount, error) {
+	rows, err := r.queries.ListTags(ctx, pgtype.Text{String: owner, Valid: owner != ""})
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]models.TagCount, len(rows))
+	for i, row := range rows {
+		tags[i] = models.TagCount{Name: row.Name, Links: row.Links}
+	}
+
+	return tags, nil
+}
+
+// GetID returns ID of the plain link (without options, password, activation window and alias)
+// for the URL in the domain created by the owner
+func (r *PostgresRepo) GetID(ctx context.Context, url string, domainID int32, owner string) (int64, error) {
+	return r.queries.GetID(ctx, storage.GetIDParams{
+		Url:      url,
+		DomainID: pgtype.Int4{Int32: domainID, Valid: true},
+		Owner:    pgtype.Text{String: owner, Valid: owner != ""},
+	})
+}
+
+func (r *PostgresRepo) GetLink(ctx context.Context, id int64) (*models.Link, error) {
+	row, err := r.queries.GetURLByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return linkFromRow(row)
+}
+
+// GetLinkByAlias returns the link with the alias in the domain
+func (r *PostgresRepo) GetLinkByAlias(ctx context.Context, domainID int32, alias string) (*models.Link, error) {
+	row, err := r.queries.GetURLByAlias(ctx, storage.GetURLByAliasParams{
+		DomainID: pgtype.Int4{Int32: domainID, Valid: true},
+		Alias:    pgtype.Text{String: alias, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return linkFromRow(row)
+}
+
+// likeEscaper escapes LIKE wildcards, so the search is a plain substring
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// ListLinks returns up to limit links matching the filter after the cursor, newest first.
+// Zero domainID and cursor are not filtered.
+func (r *PostgresRepo) ListLinks(ctx context.Context, filter models.LinkFilter, domainID int32, after models.LinkCursor, limit int32) ([]models.Link, error) {
+	params := storage.ListURLsParams{
+		CursorCreatedAt: pgtype.Timestamptz{Time: after.CreatedAt, Valid: !after.IsZero()},
+		CursorID:        pgtype.Int8{Int64: after.ID, Valid: !after.IsZero()},
+		Owner:           pgtype.Text{String: filter.Owner, Valid: filter.Owner != ""},
+		DomainID:        pgtype.Int4{Int32: domainID, Valid: domainID != 0},
+		CreatedFrom:     pgtype.Timestamptz{Time: filter.CreatedFrom, Valid: !filter.CreatedFrom.IsZero()},
+		CreatedTo:       pgtype.Timestamptz{Time: filter.CreatedTo, Valid: !filter.CreatedTo.IsZero()},
+		Search:          pgtype.Text{String: likeEscaper.Replace(filter.Search), Valid: filter.Search != ""},
+		Tags:            filter.Tags,
+		PageSize:        limit,
+	}
+	if filter.Enabled != nil {
+		params.Enabled = pgtype.Bool{Bool: *filter.Enabled, Valid: true}
+	}
+
+	rows, err := r.queries.ListURLs(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]models.Link, 0, len(rows))
+	for _, row := range rows {
+		link, err := linkFromRow(row)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+
+	return links, nil
+}
+
+// DeleteLink deletes the link with its tags, deleting the missing link is not an error
+func (r *PostgresRepo) DeleteLink(ctx context.Context, id int64) error {
+	return r.inTx(ctx, func(q *storage.Queries) error {
+		link, err := lockLink(ctx, q, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		tags, err := q.GetTagsByURLs(ctx, []int64{id})
+		if err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			link.Metadata.Tags = append(link.Metadata.Tags, tag.Name)
+		}
+
+		if err := q.DeleteURL(ctx, id); err != nil {
+			return err
+		}
+
+		event, err := newAuditEvent(ctx, models.AuditDelete, *link, stateOf(*link), nil)
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, q, event)
+	})
+}
+
+func (r *PostgresRepo) CreateDomain(ctx context.Context, host string) (*models.Domain, error) {
+	row, err := r.queries.CreateDomain(ctx, host)
+	if isUniqueViolation(err, hostConstraint) {
+		return nil, errorz.ErrDomainExists
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &models.Domain{ID: row.ID, Host: row.Host, IsDefault: row.IsDefault}, nil
+}
+
+func (r *PostgresRepo) ListDomains(ctx context.Context) ([]models.Domain, error) {
+	rows, err := r.queries.ListDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	domains := make([]models.Domain, len(rows))
+	for i, row := range rows {
+		domains[i] = models.Domain{ID: row.ID, Host: row.Host, IsDefault: row.IsDefault}
+	}
+
+	return domains, nil
+}
+
+// SetDefaultDomain makes the host the default domain, registering it if needed.
+// Links created before domains were introduced are moved to it, every move is audited.
+func (r *PostgresRepo) SetDefaultDomain(ctx context.Context, host string) (*models.Domain, []models.Link, error) {
+	var (
+		row   storage.Domain
+		moved []models.Link
+	)
+	err := r.inTx(ctx, func(q *storage.Queries) error {
+		if err := q.UnsetDefaultDomain(ctx, host); err != nil {
+			return err
+		}
+
+		var err error
+		row, err = q.SetDefaultDomain(ctx, host)
+		if err != nil {
+			return err
+		}
+
+		rows, err := q.AssignDomainToURLs(ctx, pgtype.Int4{Int32: row.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		moved = make([]models.Link, len(rows))
+		for i, url := range rows {
+			moved[i] = models.Link{ID: url.ID, DomainID: row.ID, Alias: url.Alias.String}
+		}
+
+		events, err := movedLinkEvents(ctx, moved, row.Host)
+		if err != nil {
+			return err
+		}
+		return writeAudit(ctx, q, events...)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &models.Domain{ID: row.ID, Host: row.Host, IsDefault: row.IsDefault}, moved, nil
 }
 
-func (r *PostgresRepo) GetID(ctx context.Context, url string) (int64, error) {
-	return r.queries.GetID(ctx, url)
+// ListAuditEvents returns audit events matching the filter, newest first, before the cursor ID if it is not 0
+func (r *PostgresRepo) ListAuditEvents(ctx context.Context, filter models.AuditFilter, cursor int64, limit int32) ([]models.AuditEvent, error) {
+	rows, err := r.queries.ListLinkAudit(ctx, storage.ListLinkAuditParams{
+		Cursor:   pgtype.Int8{Int64: cursor, Valid: cursor != 0},
+		DomainID: pgtype.Int4{Int32: filter.DomainID, Valid: filter.DomainID != 0},
+		Code:     pgtype.Text{String: filter.Code, Valid: filter.Code != ""},
+		Actor:    pgtype.Text{String: filter.Actor, Valid: filter.Actor != ""},
+		Action:   pgtype.Text{String: string(filter.Action), Valid: filter.Action != ""},
+		PageSize: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return auditEventsFromRows(rows), nil
 }
 
-func (r *PostgresRepo) GetURL(ctx context.Context, id int64) (string, error) {
-	return r.queries.GetURLByID(ctx, id)
+// RelayAuditEvents passes up to limit events not relayed yet to publish in order of their IDs,
+// they are marked as relayed if publish succeeds. Concurrent relays wait for each other.
+func (r *PostgresRepo) RelayAuditEvents(ctx context.Context, limit int32, publish func([]models.AuditEvent) error) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	q := r.queries.WithTx(tx)
+
+	lastID, err := q.GetAuditRelay(ctx, auditRelayName)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := r.listAuditAfter(ctx, lastID, limit)
+	if err != nil || len(rows) == 0 {
+		return 0, err
+	}
+
+	if err := publish(auditEventsFromRows(rows)); err != nil {
+		return 0, err
+	}
+
+	err = q.SetAuditRelay(ctx, storage.SetAuditRelayParams{Name: auditRelayName, LastID: rows[len(rows)-1].ID})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(rows), tx.Commit(ctx)
+}
+
+// listAuditAfter reads the entries after the ID when no writer holds an ID taken before them,
+// so the entries committed later are never skipped
+func (r *PostgresRepo) listAuditAfter(ctx context.Context, id int64, limit int32) ([]storage.LinkAudit, error) {
+	var rows []storage.LinkAudit
+	err := r.inTx(ctx, func(q *storage.Queries) error {
+		if err := q.LockAuditRead(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		rows, err = q.ListLinkAuditAfter(ctx, storage.ListLinkAuditAfterParams{ID: id, Limit: limit})
+		return err
+	})
+	return rows, err
+}
+
+func (r *PostgresRepo) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	row, err := r.queries.CreateAPIKey(ctx, storage.CreateAPIKeyParams{
+		Prefix:    key.Prefix,
+		KeyHash:   key.KeyHash,
+		Principal: key.Principal,
+		Name:      key.Name,
+		Scopes:    key.Scopes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKeyFromRow(row), nil
+}
+
+func (r *PostgresRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	row, err := r.queries.GetAPIKeyByHash(ctx, keyHash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, sql.ErrNoRows
+	} else if err != nil {
+		return nil, err
+	}
+
+	return apiKeyFromRow(row), nil
+}
+
+func (r *PostgresRepo) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := r.queries.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]models.APIKey, len(rows))
+	for i, row := range rows {
+		keys[i] = *apiKeyFromRow(row)
+	}
+
+	return keys, nil
+}
+
+func (r *PostgresRepo) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	row, err := r.queries.RevokeAPIKey(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, sql.ErrNoRows
+	} else if err != nil {
+		return nil, err
+	}
+
+	return apiKeyFromRow(row), nil
+}
+
+// inTx runs fn with the queries in one transaction, it is committed if fn succeeds
+func (r *PostgresRepo) inTx(ctx context.Context, fn func(q *storage.Queries) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(r.queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// lockLink returns the link locked until the end of the transaction
+func lockLink(ctx context.Context, q *storage.Queries, id int64) (*models.Link, error) {
+	row, err := q.LockURL(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, sql.ErrNoRows
+	} else if err != nil {
+		return nil, err
+	}
+
+	return linkFromRow(row)
+}
+
+// writeAudit appends the events in the transaction of the change they record
+func writeAudit(ctx context.Context, q *storage.Queries, events ...models.AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := q.LockAuditWrite(ctx); err != nil {
+		return err
+	}
+
+	actor := audit.ActorFromContext(ctx)
+	params := storage.InsertLinkAuditParams{
+		Actor:     pgtype.Text{String: actor.Principal, Valid: actor.Principal != ""},
+		RequestID: pgtype.Text{String: actor.RequestID, Valid: actor.RequestID != ""},
+		UrlIds:    make([]int64, len(events)),
+		DomainIds: make([]int32, len(events)),
+		Codes:     make([]string, len(events)),
+		Actions:   make([]string, len(events)),
+		OldValues: make([]string, len(events)),
+		NewValues: make([]string, len(events)),
+	}
+	for i, event := range events {
+		params.UrlIds[i] = event.LinkID
+		params.DomainIds[i] = event.DomainID
+		params.Codes[i] = event.Code
+		params.Actions[i] = string(event.Action)
+		params.OldValues[i] = string(event.OldValue)
+		params.NewValues[i] = string(event.NewValue)
+	}
+
+	return q.InsertLinkAudit(ctx, params)
+}
+
+func auditEventsFromRows(rows []storage.LinkAudit) []models.AuditEvent {
+	events := make([]models.AuditEvent, len(rows))
+	for i, row := range rows {
+		events[i] = models.AuditEvent{
+			ID:        row.ID,
+			LinkID:    row.UrlID,
+			DomainID:  row.DomainID.Int32,
+			Code:      row.Code,
+			Action:    models.AuditAction(row.Action),
+			Actor:     row.Actor.String,
+			RequestID: row.RequestID.String,
+			OldValue:  row.OldValue,
+			NewValue:  row.NewValue,
+			CreatedAt: row.CreatedAt.Time,
+		}
+	}
+	return events
+}
+
+func apiKeyFromRow(row storage.ApiKey) *models.APIKey {
+	return &models.APIKey{
+		ID:        row.ID,
+		Prefix:    row.Prefix,
+		KeyHash:   row.KeyHash,
+		Principal: row.Principal,
+		Name:      row.Name,
+		Scopes:    row.Scopes,
+		CreatedAt: row.CreatedAt.Time,
+		RevokedAt: row.RevokedAt.Time,
+	}
+}
+
+// aliasKey identifies the link with alias
+type aliasKey struct {
+	domainID int32
+	alias    string
+}
+
+// linkFromRow maps the db row into the link, the domain host is not set
+func linkFromRow(row storage.Url) (*models.Link, error) {
+	link := &models.Link{
+		ID:           row.ID,
+		URL:          row.Url,
+		PasswordHash: row.PasswordHash.String,
+		NotBefore:    row.NotBefore.Time,
+		ExpiresAt:    row.ExpiresAt.Time,
+		DomainID:     row.DomainID.Int32,
+		Alias:        row.Alias.String,
+		Owner:        row.Owner.String,
+		CreatedAt:    row.CreatedAt.Time,
+		Metadata: models.LinkMetadata{
+			Title: row.Title.String,
+			Notes: row.Notes.String,
+		},
+	}
+	if len(row.Options) > 0 {
+		if err := json.Unmarshal(row.Options, &link.Options); err != nil {
+			return nil, err
+		}
+	}
+
+	return link, nil
+}
+
+// uniqueViolation is the PostgreSQL error code of the unique constraint violation
+const uniqueViolation = "23505"
+
+// Unique constraints reported with errorz errors, a taken ID is not one of them
+const (
+	aliasConstraint = "urls_domain_alias_idx"
+	hostConstraint  = "domains_host_key"
+)
+
+// isUniqueViolation reports whether err is caused by the unique constraint
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == constraint
+}
+
+// codeID returns ID of the link with the generated code equal to the alias, 0 if the alias can't be a code
+func codeID(alias string) int64 {
+	if !base62.IsCanonical(alias) {
+		return 0
+	}
+	return base62.Decode(alias)
+}
+
+// maxSequenceCodeID returns the greatest ID of the imported codes as long as the codes generated after lastID, 0 if there is none.
+// Moving the sequence past a longer code would make every generated code long, the new link taking it is shadowed instead.
+func maxSequenceCodeID(links []models.Link, lastID int64) int64 {
+	length := len(base62.Encode(lastID + 1))
+
+	var maxID int64
+	for _, link := range links {
+		if len(link.Alias) <= length {
+			maxID = max(maxID, codeID(link.Alias))
+		}
+	}
+	return maxID
 }
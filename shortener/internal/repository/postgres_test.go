@@ -0,0 +1,45 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/misshanya/url-shortener/shortener/internal/db"
+	"github.com/misshanya/url-shortener/shortener/internal/repository"
+	"github.com/misshanya/url-shortener/shortener/internal/repository/repotest"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+// resetQueries empty the tables and seed the leases and the relay cursor like the migrations do.
+// TRUNCATE doesn't fire the append-only trigger of link_audit.
+var resetQueries = []string{
+	"TRUNCATE urls, domains, tags, url_tags, api_keys, link_audit, audit_relay, id_ranges RESTART IDENTITY CASCADE",
+	"INSERT INTO id_ranges (name, next_id) VALUES ('urls', 1)",
+	"INSERT INTO audit_relay (name, last_id) VALUES ('kafka', 0)",
+}
+
+// TestPostgresRepo needs a disposable database, its tables are truncated before every subtest
+func TestPostgresRepo(t *testing.T) {
+	dbURL := os.Getenv("SHORTENER_TEST_POSTGRES_URL")
+	if dbURL == "" {
+		t.Skip("SHORTENER_TEST_POSTGRES_URL is not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), dbURL)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	require.NoError(t, db.Migrate(sql.OpenDB(stdlib.GetConnector(*pool.Config().ConnConfig))))
+
+	repotest.Run(t, func(t *testing.T) repository.Repo {
+		for _, query := range resetQueries {
+			_, err := pool.Exec(context.Background(), query)
+			require.NoError(t, err)
+		}
+
+		return repository.NewPostgresRepo(pool)
+	})
+}
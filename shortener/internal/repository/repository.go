@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"time"
+)
+
+// Repo is a storage of links and domains, every storage backend implements it.
+// Missing links and API keys are reported with sql.ErrNoRows, taken aliases and registered domains with errorz errors.
+// Every link change is recorded in the audit log in the same transaction, the actor is taken from the context.
+type Repo interface {
+	StoreURL(ctx context.Context, link *models.Link) (int64, error)
+	LeaseIDRange(ctx context.Context, size int64) (int64, error)
+	// ImportLinks expects aliases unique within the domain in one call
+	ImportLinks(ctx context.Context, links []models.Link, onConflict models.ConflictPolicy, dryRun bool) (models.ImportResult, error)
+	GetID(ctx context.Context, url string, domainID int32, owner string) (int64, error)
+	GetLink(ctx context.Context, id int64) (*models.Link, error)
+	GetLinkByAlias(ctx context.Context, domainID int32, alias string) (*models.Link, error)
+	DeleteLink(ctx context.Context, id int64) error
+	ListLinks(ctx context.Context, filter models.LinkFilter, domainID int32, after models.LinkCursor, limit int32) ([]models.Link, error)
+	SetLinkMetadata(ctx context.Context, id int64, metadata models.LinkMetadata) error
+	SetLinkURL(ctx context.Context, id int64, url string) error
+	// SetLinkExpiresAt removes the limit if expiresAt is zero
+	SetLinkExpiresAt(ctx context.Context, id int64, expiresAt time.Time) error
+	GetLinkTags(ctx context.Context, ids []int64) (map[int64][]string, error)
+	ListTags(ctx context.Context, owner string) ([]models.TagCount, error)
+	CreateDomain(ctx context.Context, host string) (*models.Domain, error)
+	ListDomains(ctx context.Context) ([]models.Domain, error)
+	// SetDefaultDomain returns the links created before domains were introduced moved to the domain
+	SetDefaultDomain(ctx context.Context, host string) (*models.Domain, []models.Link, error)
+	// ListAuditEvents returns events newest first, before the cursor ID if it is not 0
+	ListAuditEvents(ctx context.Context, filter models.AuditFilter, cursor int64, limit int32) ([]models.AuditEvent, error)
+	// RelayAuditEvents passes up to limit events not relayed yet to publish in order of their IDs,
+	// they are marked as relayed if publish succeeds
+	RelayAuditEvents(ctx context.Context, limit int32, publish func([]models.AuditEvent) error) (int, error)
+	CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
+	// GetAPIKeyByHash returns revoked keys too
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	// ListAPIKeys returns keys newest first
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	// RevokeAPIKey keeps the time of the first revocation
+	RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error)
+}
+
+var (
+	_ Repo = (*PostgresRepo)(nil)
+	_ Repo = (*MemoryRepo)(nil)
+	_ Repo = (*SQLiteRepo)(nil)
+)
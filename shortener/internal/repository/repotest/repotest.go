@@ -0,0 +1,845 @@
+// Package repotest is the conformance suite every storage backend runs against
+package repotest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/audit"
+	"github.com/misshanya/url-shortener/shortener/internal/errorz"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/misshanya/url-shortener/shortener/internal/repository"
+	"github.com/misshanya/url-shortener/shortener/pkg/base62"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// Run runs the suite, newRepo must return an empty repo for every subtest
+func Run(t *testing.T, newRepo func(t *testing.T) repository.Repo) {
+	tests := []struct {
+		Name string
+		Test func(t *testing.T, repo repository.Repo)
+	}{
+		{Name: "StoreURL and GetLink", Test: testStoreAndGet},
+		{Name: "StoreURL with allocated ID", Test: testStoreWithID},
+		{Name: "StoreURL with code shadowed by alias", Test: testStoreShadowedCode},
+		{Name: "LeaseIDRange", Test: testLeaseIDRange},
+		{Name: "ImportLinks", Test: testImportLinks},
+		{Name: "ImportLinks conflicts", Test: testImportLinksConflicts},
+		{Name: "ImportLinks then shorten", Test: testImportThenShorten},
+		{Name: "GetLink not found", Test: testGetLinkNotFound},
+		{Name: "Alias is unique within domain", Test: testAliasUnique},
+		{Name: "GetID finds plain links only", Test: testGetID},
+		{Name: "DeleteLink", Test: testDeleteLink},
+		{Name: "ListLinks filters", Test: testListLinksFilters},
+		{Name: "ListLinks pages", Test: testListLinksPages},
+		{Name: "SetLinkMetadata", Test: testSetLinkMetadata},
+		{Name: "SetLinkURL", Test: testSetLinkURL},
+		{Name: "SetLinkExpiresAt", Test: testSetLinkExpiresAt},
+		{Name: "ListTags", Test: testListTags},
+		{Name: "Domains", Test: testDomains},
+		{Name: "Audit of link changes", Test: testAuditLinkChanges},
+		{Name: "Audit of import", Test: testAuditImport},
+		{Name: "ListAuditEvents", Test: testListAuditEvents},
+		{Name: "RelayAuditEvents", Test: testRelayAuditEvents},
+		{Name: "API keys", Test: testAPIKeys},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tt.Test(t, newRepo(t))
+		})
+	}
+}
+
+// setUpDomains registers the default domain sh.some and go.some
+func setUpDomains(t *testing.T, repo repository.Repo) (models.Domain, models.Domain) {
+	t.Helper()
+
+	def, _, err := repo.SetDefaultDomain(context.Background(), "sh.some")
+	require.NoError(t, err)
+	other, err := repo.CreateDomain(context.Background(), "go.some")
+	require.NoError(t, err)
+
+	return *def, *other
+}
+
+// store stores the link and returns it with the assigned ID
+func store(t *testing.T, repo repository.Repo, link models.Link) models.Link {
+	t.Helper()
+
+	id, err := repo.StoreURL(context.Background(), &link)
+	require.NoError(t, err)
+	link.ID = id
+	return link
+}
+
+func testStoreAndGet(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	before := time.Now().Add(-time.Second)
+	link := store(t, repo, models.Link{
+		URL: "https://example.com",
+		Options: models.LinkOptions{
+			ForwardQuery: true,
+			UTM:          map[string]string{"utm_source": "{code}"},
+			Destinations: []models.Destination{{URL: "https://example.org", Weight: 1}},
+		},
+		PasswordHash: "hash",
+		NotBefore:    time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		ExpiresAt:    time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC),
+		DomainID:     def.ID,
+		Alias:        "promo",
+		Owner:        "alice",
+		Metadata:     models.LinkMetadata{Title: "Example", Notes: "Some notes", Tags: []string{"a", "b"}},
+	})
+
+	found, err := repo.GetLink(ctx, link.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, link.ID, found.ID)
+	assert.Equal(t, link.URL, found.URL)
+	assert.Equal(t, link.Options, found.Options)
+	assert.Equal(t, link.PasswordHash, found.PasswordHash)
+	assert.True(t, link.NotBefore.Equal(found.NotBefore))
+	assert.True(t, link.ExpiresAt.Equal(found.ExpiresAt))
+	assert.Equal(t, link.DomainID, found.DomainID)
+	assert.Equal(t, link.Alias, found.Alias)
+	assert.Equal(t, link.Owner, found.Owner)
+	assert.Equal(t, "Example", found.Metadata.Title)
+	assert.Equal(t, "Some notes", found.Metadata.Notes)
+	assert.WithinRange(t, found.CreatedAt, before, time.Now().Add(time.Second))
+
+	tags, err := repo.GetLinkTags(ctx, []int64{link.ID})
+	require.NoError(t, err)
+	assert.Equal(t, map[int64][]string{link.ID: {"a", "b"}}, tags)
+
+	byAlias, err := repo.GetLinkByAlias(ctx, def.ID, "promo")
+	require.NoError(t, err)
+	assert.Equal(t, link.ID, byAlias.ID)
+
+	plain := store(t, repo, models.Link{URL: "https://example.com/plain", DomainID: def.ID})
+	found, err = repo.GetLink(ctx, plain.ID)
+	require.NoError(t, err)
+	assert.True(t, found.Options.IsZero())
+	assert.Zero(t, found.NotBefore)
+	assert.Zero(t, found.ExpiresAt)
+	assert.Empty(t, found.Alias)
+	assert.Empty(t, found.Owner)
+	assert.True(t, found.Metadata.IsZero())
+}
+
+func testStoreWithID(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	var id int64 = 1 << 40
+	link := store(t, repo, models.Link{ID: id, URL: "https://example.com", DomainID: def.ID})
+	assert.Equal(t, id, link.ID)
+
+	found, err := repo.GetLink(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", found.URL)
+
+	_, err = repo.StoreURL(ctx, &models.Link{ID: id, URL: "https://example.org", DomainID: def.ID})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, errorz.ErrAliasTaken)
+}
+
+func testStoreShadowedCode(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	first := store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID})
+	// The alias takes the next ID itself, its value is the code of the one after it
+	shadowing := base62.Encode(first.ID + 2)
+	store(t, repo, models.Link{URL: "https://example.com/alias", DomainID: def.ID, Alias: shadowing})
+
+	_, err := repo.StoreURL(ctx, &models.Link{URL: "https://example.org", DomainID: def.ID})
+	require.ErrorIs(t, err, errorz.ErrCodeShadowed)
+	_, err = repo.GetLink(ctx, first.ID+2)
+	assert.ErrorIs(t, err, sql.ErrNoRows, "nothing is stored")
+
+	// The ID is not given again
+	next := store(t, repo, models.Link{URL: "https://example.org", DomainID: def.ID})
+	assert.Greater(t, next.ID, first.ID+2)
+
+	events, err := repo.ListAuditEvents(ctx, models.AuditFilter{}, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 3, "the shadowed link is not audited")
+}
+
+func testLeaseIDRange(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	assigned := store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID})
+
+	start, err := repo.LeaseIDRange(ctx, 10)
+	require.NoError(t, err)
+	assert.Greater(t, start, assigned.ID)
+
+	next, err := repo.LeaseIDRange(ctx, 10)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, next, start+10)
+
+	// IDs assigned by the storage are not in the leased ranges
+	assigned = store(t, repo, models.Link{URL: "https://example.org", DomainID: def.ID})
+	assert.GreaterOrEqual(t, assigned.ID, next+10)
+}
+
+func testImportLinks(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, other := setUpDomains(t, repo)
+
+	createdAt := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	links := []models.Link{
+		{URL: "https://example.com", DomainID: def.ID, Alias: "old1", CreatedAt: createdAt},
+		{URL: "https://example.org", DomainID: other.ID, Alias: "old1"},
+	}
+
+	result, err := repo.ImportLinks(ctx, links, models.ConflictFail, true)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Inserted: 2}, result)
+
+	_, err = repo.GetLinkByAlias(ctx, def.ID, "old1")
+	assert.ErrorIs(t, err, sql.ErrNoRows, "nothing is stored on dry run")
+
+	result, err = repo.ImportLinks(ctx, links, models.ConflictFail, false)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Inserted: 2}, result)
+
+	found, err := repo.GetLinkByAlias(ctx, def.ID, "old1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", found.URL)
+	assert.True(t, createdAt.Equal(found.CreatedAt), "creation time is kept")
+
+	found, err = repo.GetLinkByAlias(ctx, other.ID, "old1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.org", found.URL)
+	assert.WithinDuration(t, time.Now(), found.CreatedAt, time.Minute)
+}
+
+func testImportLinksConflicts(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	aliased := store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID, Alias: "taken"})
+	generated := store(t, repo, models.Link{URL: "https://example.com/generated", DomainID: def.ID})
+	generatedCode := base62.Encode(generated.ID)
+
+	createdAt := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	links := []models.Link{
+		{URL: "https://example.org", DomainID: def.ID, Alias: "taken", CreatedAt: createdAt},
+		{URL: "https://example.org", DomainID: def.ID, Alias: generatedCode},
+		{URL: "https://example.org", DomainID: def.ID, Alias: "new1"},
+	}
+
+	_, err := repo.ImportLinks(ctx, links, models.ConflictFail, false)
+	assert.ErrorIs(t, err, errorz.ErrAliasTaken)
+	_, err = repo.GetLinkByAlias(ctx, def.ID, "new1")
+	assert.ErrorIs(t, err, sql.ErrNoRows, "failed import stores nothing")
+
+	result, err := repo.ImportLinks(ctx, links, models.ConflictSkip, false)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Inserted: 1, Skipped: 1, Conflicts: 1}, result)
+
+	found, err := repo.GetLink(ctx, aliased.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", found.URL)
+
+	links[2].Alias = "new2"
+	result, err = repo.ImportLinks(ctx, links, models.ConflictOverwrite, false)
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportResult{Inserted: 1, Updated: 1, Conflicts: 1}, result)
+
+	found, err = repo.GetLink(ctx, aliased.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.org", found.URL)
+	assert.True(t, createdAt.Equal(found.CreatedAt))
+
+	// Links with generated codes are never overwritten or shadowed
+	found, err = repo.GetLink(ctx, generated.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/generated", found.URL)
+	_, err = repo.GetLinkByAlias(ctx, def.ID, generatedCode)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testImportThenShorten(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	// Dry runs and failed imports don't move the IDs
+	store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID, Alias: "taken"})
+	near := models.Link{URL: "https://example.com/near", DomainID: def.ID, Alias: base62.Encode(40)}
+	_, err := repo.ImportLinks(ctx, []models.Link{near}, models.ConflictFail, true)
+	require.NoError(t, err)
+	_, err = repo.ImportLinks(ctx, []models.Link{near, {URL: "https://example.org", DomainID: def.ID, Alias: "taken"}}, models.ConflictFail, false)
+	require.ErrorIs(t, err, errorz.ErrAliasTaken)
+	stored := store(t, repo, models.Link{URL: "https://example.org", DomainID: def.ID})
+	assert.Less(t, stored.ID, int64(40))
+
+	links := []models.Link{
+		{URL: "https://example.com/imported", DomainID: def.ID, Alias: base62.Encode(50)},
+		// Codes longer than the generated ones don't move the IDs
+		{URL: "https://example.com/far", DomainID: def.ID, Alias: base62.Encode(1 << 40)},
+	}
+	_, err = repo.ImportLinks(ctx, links, models.ConflictFail, false)
+	require.NoError(t, err)
+
+	// Generated codes don't take the imported ones
+	stored = store(t, repo, models.Link{URL: "https://example.org", DomainID: def.ID})
+	assert.Greater(t, stored.ID, int64(50))
+	assert.Less(t, stored.ID, int64(62*62))
+
+	start, err := repo.LeaseIDRange(ctx, 10)
+	require.NoError(t, err)
+	assert.Greater(t, start, int64(50))
+	assert.Less(t, start, int64(62*62))
+
+	found, err := repo.GetLinkByAlias(ctx, def.ID, base62.Encode(50))
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/imported", found.URL)
+}
+
+func testGetLinkNotFound(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	_, err := repo.GetLink(ctx, 404)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = repo.GetLinkByAlias(ctx, def.ID, "missing")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = repo.GetID(ctx, "https://example.com", def.ID, "")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testAliasUnique(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, other := setUpDomains(t, repo)
+
+	store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID, Alias: "promo"})
+
+	_, err := repo.StoreURL(ctx, &models.Link{URL: "https://example.org", DomainID: def.ID, Alias: "promo"})
+	assert.ErrorIs(t, err, errorz.ErrAliasTaken)
+
+	link := store(t, repo, models.Link{URL: "https://example.org", DomainID: other.ID, Alias: "promo"})
+	found, err := repo.GetLinkByAlias(ctx, other.ID, "promo")
+	require.NoError(t, err)
+	assert.Equal(t, link.ID, found.ID)
+	assert.Equal(t, "https://example.org", found.URL)
+}
+
+func testGetID(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, other := setUpDomains(t, repo)
+
+	store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID, Alias: "promo"})
+	store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID, Metadata: models.LinkMetadata{Tags: []string{"a"}}})
+	store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID, PasswordHash: "hash"})
+	plain := store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID})
+	owned := store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID, Owner: "alice"})
+
+	id, err := repo.GetID(ctx, "https://example.com", def.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, plain.ID, id)
+
+	id, err = repo.GetID(ctx, "https://example.com", def.ID, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, owned.ID, id)
+
+	_, err = repo.GetID(ctx, "https://example.com", other.ID, "")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testDeleteLink(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	link := store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID, Metadata: models.LinkMetadata{Tags: []string{"a"}}})
+
+	require.NoError(t, repo.DeleteLink(ctx, link.ID))
+
+	_, err := repo.GetLink(ctx, link.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	tags, err := repo.ListTags(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+}
+
+func testListLinksFilters(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, other := setUpDomains(t, repo)
+
+	first := store(t, repo, models.Link{URL: "https://Example.com/100%_off", DomainID: def.ID, Owner: "alice",
+		Metadata: models.LinkMetadata{Tags: []string{"promo", "sale"}}})
+	second := store(t, repo, models.Link{URL: "https://example.org", DomainID: other.ID, Owner: "alice",
+		Metadata: models.LinkMetadata{Tags: []string{"promo"}}})
+	third := store(t, repo, models.Link{URL: "https://example.net/100", DomainID: def.ID, Owner: "bob",
+		ExpiresAt: time.Now().Add(-time.Hour)})
+
+	enabled, disabled := true, false
+
+	tests := []struct {
+		Name        string
+		Filter      models.LinkFilter
+		DomainID    int32
+		ExceptedIDs []int64
+	}{
+		{Name: "No filter", ExceptedIDs: []int64{third.ID, second.ID, first.ID}},
+		{Name: "Owner", Filter: models.LinkFilter{Owner: "alice"}, ExceptedIDs: []int64{second.ID, first.ID}},
+		{Name: "Domain", DomainID: def.ID, ExceptedIDs: []int64{third.ID, first.ID}},
+		{Name: "Enabled", Filter: models.LinkFilter{Enabled: &enabled}, ExceptedIDs: []int64{second.ID, first.ID}},
+		{Name: "Disabled", Filter: models.LinkFilter{Enabled: &disabled}, ExceptedIDs: []int64{third.ID}},
+		{Name: "Search is case-insensitive", Filter: models.LinkFilter{Search: "EXAMPLE.COM"}, ExceptedIDs: []int64{first.ID}},
+		{Name: "Search escapes wildcards", Filter: models.LinkFilter{Search: "100%_"}, ExceptedIDs: []int64{first.ID}},
+		{Name: "Search escapes backslash", Filter: models.LinkFilter{Search: `0\`}, ExceptedIDs: []int64{}},
+		{Name: "Tag", Filter: models.LinkFilter{Tags: []string{"promo"}}, ExceptedIDs: []int64{second.ID, first.ID}},
+		{Name: "All tags", Filter: models.LinkFilter{Tags: []string{"promo", "sale"}}, ExceptedIDs: []int64{first.ID}},
+		{Name: "Created range", Filter: models.LinkFilter{
+			CreatedFrom: time.Now().Add(-time.Hour),
+			CreatedTo:   time.Now().Add(time.Hour),
+		}, ExceptedIDs: []int64{third.ID, second.ID, first.ID}},
+		{Name: "Created in future", Filter: models.LinkFilter{CreatedFrom: time.Now().Add(time.Hour)}, ExceptedIDs: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			links, err := repo.ListLinks(ctx, tt.Filter, tt.DomainID, models.LinkCursor{}, 10)
+			require.NoError(t, err)
+
+			ids := make([]int64, 0, len(links))
+			for _, link := range links {
+				ids = append(ids, link.ID)
+			}
+			assert.Equal(t, tt.ExceptedIDs, ids)
+		})
+	}
+}
+
+func testListLinksPages(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	var ids []int64
+	for range 4 {
+		ids = append(ids, store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID}).ID)
+	}
+
+	// The imported link gets the highest ID, but it is the oldest one
+	_, err := repo.ImportLinks(ctx, []models.Link{{URL: "https://example.org", DomainID: def.ID, Alias: "old",
+		CreatedAt: time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)}}, models.ConflictFail, false)
+	require.NoError(t, err)
+	imported, err := repo.GetLinkByAlias(ctx, def.ID, "old")
+	require.NoError(t, err)
+	require.Greater(t, imported.ID, ids[3])
+
+	page, err := repo.ListLinks(ctx, models.LinkFilter{}, 0, models.LinkCursor{}, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, ids[3], page[0].ID)
+	assert.Equal(t, ids[2], page[1].ID)
+
+	after := models.LinkCursor{CreatedAt: page[1].CreatedAt, ID: page[1].ID}
+	page, err = repo.ListLinks(ctx, models.LinkFilter{}, 0, after, 10)
+	require.NoError(t, err)
+	require.Len(t, page, 3)
+	assert.Equal(t, ids[1], page[0].ID)
+	assert.Equal(t, ids[0], page[1].ID)
+	assert.Equal(t, imported.ID, page[2].ID)
+}
+
+func testSetLinkMetadata(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	link := store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID,
+		Metadata: models.LinkMetadata{Title: "Old", Tags: []string{"a", "b"}}})
+
+	err := repo.SetLinkMetadata(ctx, link.ID, models.LinkMetadata{Notes: "New notes", Tags: []string{"b", "c"}})
+	require.NoError(t, err)
+
+	found, err := repo.GetLink(ctx, link.ID)
+	require.NoError(t, err)
+	assert.Empty(t, found.Metadata.Title)
+	assert.Equal(t, "New notes", found.Metadata.Notes)
+
+	tags, err := repo.GetLinkTags(ctx, []int64{link.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, tags[link.ID])
+
+	require.NoError(t, repo.SetLinkMetadata(ctx, link.ID, models.LinkMetadata{}))
+	tags, err = repo.GetLinkTags(ctx, []int64{link.ID})
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	err = repo.SetLinkMetadata(ctx, 404, models.LinkMetadata{Title: "Missing"})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testSetLinkURL(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	link := store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID})
+
+	require.NoError(t, repo.SetLinkURL(ctx, link.ID, "https://example.org"))
+
+	found, err := repo.GetLink(ctx, link.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.org", found.URL)
+
+	err = repo.SetLinkURL(ctx, 404, "https://example.org")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testSetLinkExpiresAt(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	link := store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID})
+	expiresAt := time.Now().Truncate(time.Second)
+
+	require.NoError(t, repo.SetLinkExpiresAt(ctx, link.ID, expiresAt))
+
+	found, err := repo.GetLink(ctx, link.ID)
+	require.NoError(t, err)
+	assert.True(t, expiresAt.Equal(found.ExpiresAt))
+
+	// The disabled link is not shared with new plain links anymore
+	_, err = repo.GetID(ctx, "https://example.com", def.ID, "")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, repo.SetLinkExpiresAt(ctx, link.ID, time.Time{}))
+	found, err = repo.GetLink(ctx, link.ID)
+	require.NoError(t, err)
+	assert.True(t, found.ExpiresAt.IsZero())
+
+	err = repo.SetLinkExpiresAt(ctx, 404, expiresAt)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testListTags(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	tags, err := repo.ListTags(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID, Metadata: models.LinkMetadata{Tags: []string{"promo", "sale"}}})
+	store(t, repo, models.Link{URL: "https://example.org", DomainID: def.ID, Owner: "alice", Metadata: models.LinkMetadata{Tags: []string{"promo"}}})
+
+	tags, err = repo.ListTags(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []models.TagCount{{Name: "promo", Links: 2}, {Name: "sale", Links: 1}}, tags)
+
+	tags, err = repo.ListTags(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []models.TagCount{{Name: "promo", Links: 1}}, tags)
+
+	tags, err = repo.ListTags(ctx, "bob")
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+}
+
+func testDomains(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+
+	def, moved, err := repo.SetDefaultDomain(ctx, "sh.some")
+	require.NoError(t, err)
+	assert.Empty(t, moved)
+	assert.Equal(t, "sh.some", def.Host)
+	assert.True(t, def.IsDefault)
+
+	other, err := repo.CreateDomain(ctx, "go.some")
+	require.NoError(t, err)
+	assert.Equal(t, "go.some", other.Host)
+	assert.False(t, other.IsDefault)
+
+	_, err = repo.CreateDomain(ctx, "go.some")
+	assert.ErrorIs(t, err, errorz.ErrDomainExists)
+
+	domains, err := repo.ListDomains(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Domain{*def, *other}, domains)
+
+	// Switching the default keeps the IDs
+	newDef, _, err := repo.SetDefaultDomain(ctx, "go.some")
+	require.NoError(t, err)
+	assert.Equal(t, other.ID, newDef.ID)
+	assert.True(t, newDef.IsDefault)
+
+	domains, err = repo.ListDomains(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Domain{
+		{ID: def.ID, Host: "sh.some"},
+		{ID: other.ID, Host: "go.some", IsDefault: true},
+	}, domains)
+}
+
+func testAuditLinkChanges(t *testing.T, repo repository.Repo) {
+	ctx := audit.WithActor(context.Background(), audit.Actor{Principal: "alice", RequestID: "req-1"})
+	def, _ := setUpDomains(t, repo)
+
+	id, err := repo.StoreURL(ctx, &models.Link{
+		URL:      "https://example.com",
+		DomainID: def.ID,
+		Alias:    "docs",
+		Metadata: models.LinkMetadata{Tags: []string{"go"}},
+	})
+	require.NoError(t, err)
+	_, err = repo.StoreURL(ctx, &models.Link{URL: "https://example.net", DomainID: def.ID, Alias: "docs"})
+	require.ErrorIs(t, err, errorz.ErrAliasTaken, "failed change is not recorded")
+
+	require.NoError(t, repo.SetLinkURL(ctx, id, "https://example.org"))
+	require.NoError(t, repo.SetLinkMetadata(ctx, id, models.LinkMetadata{Title: "Docs"}))
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, repo.SetLinkExpiresAt(ctx, id, expiresAt))
+
+	link, err := repo.GetLink(ctx, id)
+	require.NoError(t, err)
+	createdAt := link.CreatedAt.UTC().Format(time.RFC3339Nano)
+
+	bob := audit.WithActor(context.Background(), audit.Actor{Principal: "bob", RequestID: "req-2"})
+	require.NoError(t, repo.DeleteLink(bob, id))
+	require.NoError(t, repo.DeleteLink(bob, id), "deleting the missing link records nothing")
+
+	events, err := repo.ListAuditEvents(ctx, models.AuditFilter{}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+
+	// Newest first
+	excepted := []struct {
+		Action   models.AuditAction
+		Actor    string
+		OldValue string
+		NewValue string
+	}{
+		{Action: models.AuditDelete, Actor: "bob",
+			OldValue: `{"url":"https://example.org","alias":"docs","expires_at":"2030-01-02T03:04:05Z","created_at":"` + createdAt + `","title":"Docs"}`},
+		{Action: models.AuditSetExpiresAt, Actor: "alice",
+			OldValue: `{"expires_at":null}`, NewValue: `{"expires_at":"2030-01-02T03:04:05Z"}`},
+		{Action: models.AuditUpdateMetadata, Actor: "alice",
+			OldValue: `{"title":"","notes":"","tags":["go"]}`, NewValue: `{"title":"Docs","notes":"","tags":[]}`},
+		{Action: models.AuditRetarget, Actor: "alice",
+			OldValue: `{"url":"https://example.com"}`, NewValue: `{"url":"https://example.org"}`},
+		{Action: models.AuditCreate, Actor: "alice",
+			NewValue: `{"url":"https://example.com","alias":"docs","tags":["go"]}`},
+	}
+	for i, event := range events {
+		assert.Equal(t, id, event.LinkID)
+		assert.Equal(t, def.ID, event.DomainID)
+		assert.Equal(t, "docs", event.Code)
+		assert.Equal(t, excepted[i].Action, event.Action)
+		assert.Equal(t, excepted[i].Actor, event.Actor)
+		assert.NotZero(t, event.ID)
+		assert.WithinDuration(t, time.Now(), event.CreatedAt, time.Minute)
+		assertJSON(t, excepted[i].OldValue, event.OldValue, "old value of %s", event.Action)
+		assertJSON(t, excepted[i].NewValue, event.NewValue, "new value of %s", event.Action)
+	}
+	assert.Equal(t, "req-2", events[0].RequestID)
+	assert.Equal(t, "req-1", events[4].RequestID)
+}
+
+func testAuditImport(t *testing.T, repo repository.Repo) {
+	ctx := audit.WithActor(context.Background(), audit.Actor{Principal: "linkio"})
+	def, _ := setUpDomains(t, repo)
+
+	oldTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err := repo.ImportLinks(ctx, []models.Link{{URL: "https://example.com", DomainID: def.ID, Alias: "taken", CreatedAt: oldTime}},
+		models.ConflictFail, false)
+	require.NoError(t, err)
+
+	createdAt := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	links := []models.Link{
+		{URL: "https://example.org", DomainID: def.ID, Alias: "taken", CreatedAt: createdAt},
+		{URL: "https://example.org", DomainID: def.ID, Alias: "new1", CreatedAt: createdAt},
+	}
+
+	_, err = repo.ImportLinks(ctx, links, models.ConflictOverwrite, true)
+	require.NoError(t, err)
+	_, err = repo.ImportLinks(ctx, links, models.ConflictSkip, false)
+	require.NoError(t, err)
+	_, err = repo.ImportLinks(ctx, links[:1], models.ConflictOverwrite, false)
+	require.NoError(t, err)
+
+	events, err := repo.ListAuditEvents(ctx, models.AuditFilter{Action: models.AuditImport}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 3, "dry run and skipped links are not recorded")
+
+	assert.Equal(t, "taken", events[0].Code)
+	assertJSON(t, `{"url":"https://example.com","created_at":"2019-01-01T00:00:00Z"}`, events[0].OldValue, "overwritten link")
+	assertJSON(t, `{"url":"https://example.org","created_at":"2020-05-01T12:00:00Z"}`, events[0].NewValue, "overwritten link")
+
+	assert.Equal(t, "new1", events[1].Code)
+	assert.Empty(t, events[1].OldValue)
+	assert.NotZero(t, events[1].LinkID)
+
+	assert.Equal(t, "taken", events[2].Code)
+	assert.Equal(t, "linkio", events[2].Actor)
+}
+
+func testListAuditEvents(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, other := setUpDomains(t, repo)
+
+	alice := audit.WithActor(ctx, audit.Actor{Principal: "alice"})
+	first := store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID, Alias: "docs"})
+	second := store(t, repo, models.Link{URL: "https://example.com", DomainID: other.ID, Alias: "docs"})
+	require.NoError(t, repo.SetLinkURL(alice, first.ID, "https://example.org"))
+	require.NoError(t, repo.SetLinkURL(alice, second.ID, "https://example.org"))
+
+	tests := []struct {
+		Name     string
+		Filter   models.AuditFilter
+		Excepted []models.AuditAction
+	}{
+		{Name: "All", Excepted: []models.AuditAction{models.AuditRetarget, models.AuditRetarget, models.AuditCreate, models.AuditCreate}},
+		{Name: "Code in domain", Filter: models.AuditFilter{DomainID: def.ID, Code: "docs"},
+			Excepted: []models.AuditAction{models.AuditRetarget, models.AuditCreate}},
+		{Name: "Actor", Filter: models.AuditFilter{Actor: "alice"},
+			Excepted: []models.AuditAction{models.AuditRetarget, models.AuditRetarget}},
+		{Name: "Action", Filter: models.AuditFilter{Action: models.AuditCreate},
+			Excepted: []models.AuditAction{models.AuditCreate, models.AuditCreate}},
+		{Name: "Nothing found", Filter: models.AuditFilter{Code: "missing"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			events, err := repo.ListAuditEvents(ctx, tt.Filter, 0, 10)
+			require.NoError(t, err)
+
+			var actions []models.AuditAction
+			for _, event := range events {
+				actions = append(actions, event.Action)
+			}
+			assert.Equal(t, tt.Excepted, actions)
+		})
+	}
+
+	page, err := repo.ListAuditEvents(ctx, models.AuditFilter{}, 0, 3)
+	require.NoError(t, err)
+	require.Len(t, page, 3)
+
+	page, err = repo.ListAuditEvents(ctx, models.AuditFilter{}, page[2].ID, 3)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, first.ID, page[0].LinkID)
+	assert.Equal(t, models.AuditCreate, page[0].Action)
+}
+
+func testRelayAuditEvents(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+	def, _ := setUpDomains(t, repo)
+
+	for i := 0; i < 3; i++ {
+		store(t, repo, models.Link{URL: "https://example.com", DomainID: def.ID})
+	}
+
+	var relayed []models.AuditEvent
+	publish := func(events []models.AuditEvent) error {
+		relayed = append(relayed, events...)
+		return nil
+	}
+
+	n, err := repo.RelayAuditEvents(ctx, 2, func([]models.AuditEvent) error { return errors.New("broker is down") })
+	require.Error(t, err)
+	assert.Zero(t, n)
+
+	n, err = repo.RelayAuditEvents(ctx, 2, publish)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "failed publish is retried")
+
+	n, err = repo.RelayAuditEvents(ctx, 2, publish)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = repo.RelayAuditEvents(ctx, 2, func([]models.AuditEvent) error {
+		t.Error("nothing to publish")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	require.Len(t, relayed, 3)
+	for i := 1; i < len(relayed); i++ {
+		assert.Less(t, relayed[i-1].ID, relayed[i].ID, "events are relayed in order")
+	}
+}
+
+// assertJSON compares JSON documents, empty excepted value means no value
+func assertJSON(t *testing.T, excepted string, actual []byte, msgAndArgs ...any) {
+	t.Helper()
+
+	if excepted == "" {
+		assert.Empty(t, actual, msgAndArgs...)
+		return
+	}
+	assert.JSONEq(t, excepted, string(actual), msgAndArgs...)
+}
+
+func testAPIKeys(t *testing.T, repo repository.Repo) {
+	ctx := context.Background()
+
+	before := time.Now().Add(-time.Second)
+	first, err := repo.CreateAPIKey(ctx, &models.APIKey{
+		Prefix:    "shk_first",
+		KeyHash:   "hash1",
+		Principal: "alice",
+		Name:      "CI",
+		Scopes:    []string{models.ScopeShorten, models.ScopeReadStats},
+	})
+	require.NoError(t, err)
+	assert.NotZero(t, first.ID)
+	assert.Equal(t, "shk_first", first.Prefix)
+	assert.Equal(t, "alice", first.Principal)
+	assert.Equal(t, "CI", first.Name)
+	assert.Equal(t, []string{models.ScopeShorten, models.ScopeReadStats}, first.Scopes)
+	assert.WithinRange(t, first.CreatedAt, before, time.Now().Add(time.Second))
+	assert.False(t, first.IsRevoked())
+
+	second, err := repo.CreateAPIKey(ctx, &models.APIKey{
+		Prefix:    "shk_second",
+		KeyHash:   "hash2",
+		Principal: "bob",
+		Scopes:    []string{models.ScopeManageLinks},
+	})
+	require.NoError(t, err)
+
+	found, err := repo.GetAPIKeyByHash(ctx, "hash1")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, found.ID)
+	assert.Equal(t, first.Scopes, found.Scopes)
+
+	_, err = repo.GetAPIKeyByHash(ctx, "missing")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	keys, err := repo.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, second.ID, keys[0].ID, "newest first")
+	assert.Equal(t, first.ID, keys[1].ID)
+
+	revoked, err := repo.RevokeAPIKey(ctx, first.ID)
+	require.NoError(t, err)
+	assert.True(t, revoked.IsRevoked())
+
+	again, err := repo.RevokeAPIKey(ctx, first.ID)
+	require.NoError(t, err)
+	assert.True(t, revoked.RevokedAt.Equal(again.RevokedAt), "revocation time is kept")
+
+	found, err = repo.GetAPIKeyByHash(ctx, "hash1")
+	require.NoError(t, err)
+	assert.True(t, found.IsRevoked())
+
+	_, err = repo.RevokeAPIKey(ctx, 1000)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,969 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/audit"
+	"github.com/misshanya/url-shortener/shortener/internal/errorz"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/misshanya/url-shortener/shortener/pkg/base62"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"strings"
+	"time"
+)
+
+// SQLiteRepo keeps links and domains in a SQLite database file, so the shortener runs without PostgreSQL.
+// Times are stored as Unix microseconds.
+type SQLiteRepo struct {
+	db *sql.DB
+}
+
+func NewSQLiteRepo(db *sql.DB) *SQLiteRepo {
+	return &SQLiteRepo{db: db}
+}
+
+// linkColumns are selected by every query returning links, in the order of scanLink
+const linkColumns = `id, url, options, password_hash, not_before, expires_at, domain_id, alias, owner, created_at, title, notes`
+
+// StoreURL stores the link with its options, password hash, activation window, alias and metadata
+// Unset fields are stored as NULL for plain links, so they can be found by GetID.
+// The link ID is assigned by SQLite if not allocated.
+// errorz.ErrCodeShadowed is returned if the generated code of the link is taken by an alias.
+func (r *SQLiteRepo) StoreURL(ctx context.Context, link *models.Link) (int64, error) {
+	var optionsJSON []byte
+	if !link.Options.IsZero() {
+		var err error
+		optionsJSON, err = json.Marshal(link.Options)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO urls (id, url, options, password_hash, not_before, expires_at, domain_id, alias, owner, created_at, title, notes)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id`,
+		sql.NullInt64{Int64: link.ID, Valid: link.ID != 0},
+		link.URL,
+		nullBytes(optionsJSON),
+		nullString(link.PasswordHash),
+		nullTime(link.NotBefore),
+		nullTime(link.ExpiresAt),
+		link.DomainID,
+		nullString(link.Alias),
+		nullString(link.Owner),
+		time.Now().UnixMicro(),
+		nullString(link.Metadata.Title),
+		nullString(link.Metadata.Notes),
+	).Scan(&id)
+	if isSQLiteUniqueViolation(err) {
+		return 0, errorz.ErrAliasTaken
+	} else if err != nil {
+		return 0, err
+	}
+
+	if link.Alias == "" {
+		var shadowed bool
+		err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM urls WHERE domain_id = ? AND alias = ?)`,
+			link.DomainID, base62.Encode(id),
+		).Scan(&shadowed)
+		if err != nil {
+			return 0, err
+		}
+
+		// The link is deleted instead of rolled back, so AUTOINCREMENT doesn't give the ID again
+		if shadowed {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM urls WHERE id = ?`, id); err != nil {
+				return 0, err
+			}
+			if err := tx.Commit(); err != nil {
+				return 0, err
+			}
+			return 0, errorz.ErrCodeShadowed
+		}
+	}
+
+	if err := setTags(ctx, tx, id, link.Metadata.Tags); err != nil {
+		return 0, err
+	}
+
+	stored := *link
+	stored.ID = id
+	event, err := newAuditEvent(ctx, models.AuditCreate, stored, nil, stateOf(stored))
+	if err != nil {
+		return 0, err
+	}
+	if err := writeSQLiteAudit(ctx, tx, event); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// GetID returns ID of the plain link (without options, password, activation window, alias and metadata)
+// for the URL in the domain created by the owner
+func (r *SQLiteRepo) GetID(ctx context.Context, url string, domainID int32, owner string) (int64, error) {
+	var id int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id FROM urls
+WHERE url = ?
+  AND domain_id = ?
+  AND owner IS ?
+  AND options IS NULL
+  AND password_hash IS NULL
+  AND not_before IS NULL
+  AND expires_at IS NULL
+  AND alias IS NULL
+  AND title IS NULL
+  AND notes IS NULL
+  AND NOT EXISTS (SELECT 1 FROM url_tags WHERE url_tags.url_id = urls.id)`,
+		url, domainID, nullString(owner),
+	).Scan(&id)
+	return id, err
+}
+
+func (r *SQLiteRepo) GetLink(ctx context.Context, id int64) (*models.Link, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+linkColumns+` FROM urls WHERE id = ?`, id)
+	return scanLink(row)
+}
+
+// GetLinkByAlias returns the link with the alias in the domain
+func (r *SQLiteRepo) GetLinkByAlias(ctx context.Context, domainID int32, alias string) (*models.Link, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+linkColumns+` FROM urls WHERE domain_id = ? AND alias = ?`, domainID, alias)
+	return scanLink(row)
+}
+
+// ListLinks returns up to limit links matching the filter after the cursor, newest first.
+// Zero domainID and cursor are not filtered.
+func (r *SQLiteRepo) ListLinks(ctx context.Context, filter models.LinkFilter, domainID int32, after models.LinkCursor, limit int32) ([]models.Link, error) {
+	var (
+		where []string
+		args  []any
+	)
+	add := func(cond string, condArgs ...any) {
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
+
+	if !after.IsZero() {
+		add("(created_at, id) < (?, ?)", after.CreatedAt.UnixMicro(), after.ID)
+	}
+	if filter.Owner != "" {
+		add("owner = ?", filter.Owner)
+	}
+	if domainID != 0 {
+		add("domain_id = ?", domainID)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		add("created_at >= ?", filter.CreatedFrom.UnixMicro())
+	}
+	if !filter.CreatedTo.IsZero() {
+		add("created_at < ?", filter.CreatedTo.UnixMicro())
+	}
+	if filter.Enabled != nil {
+		now := time.Now().UnixMicro()
+		add("((not_before IS NULL OR not_before <= ?) AND (expires_at IS NULL OR expires_at > ?)) = ?", now, now, *filter.Enabled)
+	}
+	if filter.Search != "" {
+		add(`url LIKE '%' || ? || '%' ESCAPE '\'`, likeEscaper.Replace(filter.Search))
+	}
+	if len(filter.Tags) > 0 {
+		args := make([]any, 0, len(filter.Tags)+1)
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+		args = append(args, len(filter.Tags))
+		add(`id IN (
+    SELECT url_tags.url_id FROM url_tags
+    JOIN tags ON tags.id = url_tags.tag_id
+    WHERE tags.name IN (`+placeholders(len(filter.Tags))+`)
+    GROUP BY url_tags.url_id
+    HAVING count(*) = ?)`, args...)
+	}
+
+	query := `SELECT ` + linkColumns + ` FROM urls`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := make([]models.Link, 0, limit)
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+
+	return links, rows.Err()
+}
+
+// DeleteLink deletes the link with its tags, deleting the missing link is not an error
+func (r *SQLiteRepo) DeleteLink(ctx context.Context, id int64) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		link, err := getLinkTx(ctx, tx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM urls WHERE id = ?`, id); err != nil {
+			return err
+		}
+
+		event, err := newAuditEvent(ctx, models.AuditDelete, *link, stateOf(*link), nil)
+		if err != nil {
+			return err
+		}
+		return writeSQLiteAudit(ctx, tx, event)
+	})
+}
+
+// ImportLinks stores the links with aliases keeping their creation time in one transaction.
+// Links with taken codes are handled by the policy, the failed import returns errorz.ErrAliasTaken.
+// Nothing is stored on dry run.
+func (r *SQLiteRepo) ImportLinks(ctx context.Context, links []models.Link, onConflict models.ConflictPolicy, dryRun bool) (models.ImportResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.ImportResult{}, err
+	}
+	defer tx.Rollback()
+
+	var (
+		result models.ImportResult
+		events []models.AuditEvent
+	)
+	for _, link := range links {
+		createdAt := link.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = time.Now()
+		}
+		newState := importState{URL: link.URL, CreatedAt: timeOrNil(createdAt.Truncate(time.Microsecond))}
+
+		var shadowed bool
+		err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM urls WHERE id = ? AND domain_id = ? AND alias IS NULL)`,
+			codeID(link.Alias), link.DomainID,
+		).Scan(&shadowed)
+		if err != nil {
+			return models.ImportResult{}, err
+		}
+		if shadowed {
+			result.Conflicts++
+			continue
+		}
+
+		var (
+			id       int64
+			oldURL   string
+			oldTime  int64
+			oldState any
+		)
+		err = tx.QueryRowContext(ctx, `SELECT id, url, created_at FROM urls WHERE domain_id = ? AND alias = ?`, link.DomainID, link.Alias).
+			Scan(&id, &oldURL, &oldTime)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = tx.QueryRowContext(ctx,
+				`INSERT INTO urls (url, domain_id, alias, created_at) VALUES (?, ?, ?, ?) RETURNING id`,
+				link.URL, link.DomainID, link.Alias, createdAt.UnixMicro(),
+			).Scan(&id)
+			result.Inserted++
+		case err != nil:
+		case onConflict == models.ConflictOverwrite:
+			_, err = tx.ExecContext(ctx, `UPDATE urls SET url = ?, created_at = ? WHERE id = ?`, link.URL, createdAt.UnixMicro(), id)
+			oldState = importState{URL: oldURL, CreatedAt: timeOrNil(time.UnixMicro(oldTime))}
+			result.Updated++
+		default:
+			result.Skipped++
+			continue
+		}
+		if err != nil {
+			return models.ImportResult{}, err
+		}
+
+		event, err := newAuditEvent(ctx, models.AuditImport, models.Link{ID: id, DomainID: link.DomainID, Alias: link.Alias}, oldState, newState)
+		if err != nil {
+			return models.ImportResult{}, err
+		}
+		events = append(events, event)
+	}
+
+	if onConflict == models.ConflictFail && result.Skipped+result.Conflicts > 0 {
+		return models.ImportResult{}, errorz.ErrAliasTaken
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	if err := advanceSQLiteIDs(ctx, tx, links); err != nil {
+		return models.ImportResult{}, err
+	}
+	if err := writeSQLiteAudit(ctx, tx, events...); err != nil {
+		return models.ImportResult{}, err
+	}
+
+	return result, tx.Commit()
+}
+
+// LeaseIDRange reserves size IDs for links and returns the first of them.
+// AUTOINCREMENT counter is moved past the range, so the strategies can be switched.
+func (r *SQLiteRepo) LeaseIDRange(ctx context.Context, size int64) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var start int64
+	err = tx.QueryRowContext(ctx,
+		`SELECT max(next_id, coalesce((SELECT seq + 1 FROM sqlite_sequence WHERE name = 'urls'), 1))
+FROM id_ranges WHERE name = 'urls'`,
+	).Scan(&start)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE id_ranges SET next_id = ? WHERE name = 'urls'`, start+size); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE sqlite_sequence SET seq = ? WHERE name = 'urls'`, start+size-1)
+	if err != nil {
+		return 0, err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return 0, err
+	} else if rows == 0 {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO sqlite_sequence (name, seq) VALUES ('urls', ?)`, start+size-1); err != nil {
+			return 0, err
+		}
+	}
+
+	return start, tx.Commit()
+}
+
+// advanceSQLiteIDs moves the leases and AUTOINCREMENT counter past the imported codes,
+// so they are not generated again
+func advanceSQLiteIDs(ctx context.Context, tx *sql.Tx, links []models.Link) error {
+	var lastID int64
+	err := tx.QueryRowContext(ctx,
+		`SELECT max(next_id - 1, coalesce((SELECT seq FROM sqlite_sequence WHERE name = 'urls'), 0))
+FROM id_ranges WHERE name = 'urls'`,
+	).Scan(&lastID)
+	if err != nil {
+		return err
+	}
+	id := maxSequenceCodeID(links, lastID)
+	if id == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE id_ranges SET next_id = max(next_id, ?) WHERE name = 'urls'`, id+1); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE sqlite_sequence SET seq = max(seq, ?) WHERE name = 'urls'`, id)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO sqlite_sequence (name, seq) VALUES ('urls', ?)`, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetLinkMetadata replaces title, notes and tags of the link
+func (r *SQLiteRepo) SetLinkMetadata(ctx context.Context, id int64, metadata models.LinkMetadata) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		link, err := getLinkTx(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE urls SET title = ?, notes = ? WHERE id = ?`,
+			nullString(metadata.Title), nullString(metadata.Notes), id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM url_tags WHERE url_id = ?`, id); err != nil {
+			return err
+		}
+		if err := setTags(ctx, tx, id, metadata.Tags); err != nil {
+			return err
+		}
+
+		event, err := newAuditEvent(ctx, models.AuditUpdateMetadata, *link, metadataStateOf(link.Metadata), metadataStateOf(metadata))
+		if err != nil {
+			return err
+		}
+		return writeSQLiteAudit(ctx, tx, event)
+	})
+}
+
+// SetLinkURL changes the destination of the link
+func (r *SQLiteRepo) SetLinkURL(ctx context.Context, id int64, url string) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		link, err := getLinkTx(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE urls SET url = ? WHERE id = ?`, url, id); err != nil {
+			return err
+		}
+
+		event, err := newAuditEvent(ctx, models.AuditRetarget, *link, urlState{URL: link.URL}, urlState{URL: url})
+		if err != nil {
+			return err
+		}
+		return writeSQLiteAudit(ctx, tx, event)
+	})
+}
+
+// SetLinkExpiresAt changes when the link expires, zero time removes the limit
+func (r *SQLiteRepo) SetLinkExpiresAt(ctx context.Context, id int64, expiresAt time.Time) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		link, err := getLinkTx(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE urls SET expires_at = ? WHERE id = ?`, nullTime(expiresAt), id); err != nil {
+			return err
+		}
+
+		event, err := newAuditEvent(ctx, models.AuditSetExpiresAt, *link,
+			expiresState{ExpiresAt: timeOrNil(link.ExpiresAt)}, expiresState{ExpiresAt: timeOrNil(expiresAt)})
+		if err != nil {
+			return err
+		}
+		return writeSQLiteAudit(ctx, tx, event)
+	})
+}
+
+// ListAuditEvents returns events newest first, before the cursor ID if it is not 0
+func (r *SQLiteRepo) ListAuditEvents(ctx context.Context, filter models.AuditFilter, cursor int64, limit int32) ([]models.AuditEvent, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if cursor != 0 {
+		where = append(where, "id < ?")
+		args = append(args, cursor)
+	}
+	if filter.DomainID != 0 {
+		where = append(where, "domain_id = ?")
+		args = append(args, filter.DomainID)
+	}
+	if filter.Code != "" {
+		where = append(where, "code = ?")
+		args = append(args, filter.Code)
+	}
+	if filter.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		where = append(where, "action = ?")
+		args = append(args, string(filter.Action))
+	}
+
+	query := `SELECT ` + auditColumns + ` FROM link_audit`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	return queryAuditEvents(ctx, r.db, query, args...)
+}
+
+// RelayAuditEvents passes up to limit events not relayed yet to publish in order of their IDs,
+// they are marked as relayed if publish succeeds. SQLite has a single writer, so no entry is committed out of order.
+func (r *SQLiteRepo) RelayAuditEvents(ctx context.Context, limit int32, publish func([]models.AuditEvent) error) (int, error) {
+	var relayed int
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var lastID int64
+		err := tx.QueryRowContext(ctx, `SELECT last_id FROM audit_relay WHERE name = ?`, auditRelayName).Scan(&lastID)
+		if err != nil {
+			return err
+		}
+
+		events, err := queryAuditEvents(ctx, tx,
+			`SELECT `+auditColumns+` FROM link_audit WHERE id > ? ORDER BY id LIMIT ?`, lastID, limit)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		if err := publish(events); err != nil {
+			return err
+		}
+
+		lastID = events[len(events)-1].ID
+		if _, err := tx.ExecContext(ctx, `UPDATE audit_relay SET last_id = ? WHERE name = ?`, lastID, auditRelayName); err != nil {
+			return err
+		}
+
+		relayed = len(events)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return relayed, nil
+}
+
+// GetLinkTags returns sorted tags of the links by their IDs, links without tags are omitted
+func (r *SQLiteRepo) GetLinkTags(ctx context.Context, ids []int64) (map[int64][]string, error) {
+	tags := make(map[int64][]string)
+	if len(ids) == 0 {
+		return tags, nil
+	}
+
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT url_tags.url_id, tags.name FROM url_tags
+JOIN tags ON tags.id = url_tags.tag_id
+WHERE url_tags.url_id IN (`+placeholders(len(ids))+`)
+ORDER BY url_tags.url_id, tags.name`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id   int64
+			name string
+		)
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		tags[id] = append(tags[id], name)
+	}
+
+	return tags, rows.Err()
+}
+
+// ListTags returns the tags with the number of their links, only of the links of the owner if it is set
+func (r *SQLiteRepo) ListTags(ctx context.Context, owner string) ([]models.TagCount, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT tags.name, count(url_tags.url_id) FROM tags
+JOIN url_tags ON url_tags.tag_id = tags.id
+JOIN urls ON urls.id = url_tags.url_id
+WHERE ? = '' OR urls.owner = ?
+GROUP BY tags.name
+ORDER BY tags.name`,
+		owner, owner,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]models.TagCount, 0)
+	for rows.Next() {
+		var tag models.TagCount
+		if err := rows.Scan(&tag.Name, &tag.Links); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+func (r *SQLiteRepo) CreateDomain(ctx context.Context, host string) (*models.Domain, error) {
+	var domain models.Domain
+	err := r.db.QueryRowContext(ctx, `INSERT INTO domains (host) VALUES (?) RETURNING id, host, is_default`, host).
+		Scan(&domain.ID, &domain.Host, &domain.IsDefault)
+	if isSQLiteUniqueViolation(err) {
+		return nil, errorz.ErrDomainExists
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &domain, nil
+}
+
+func (r *SQLiteRepo) ListDomains(ctx context.Context) ([]models.Domain, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, host, is_default FROM domains ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	domains := make([]models.Domain, 0)
+	for rows.Next() {
+		var domain models.Domain
+		if err := rows.Scan(&domain.ID, &domain.Host, &domain.IsDefault); err != nil {
+			return nil, err
+		}
+		domains = append(domains, domain)
+	}
+
+	return domains, rows.Err()
+}
+
+// SetDefaultDomain makes the host the default domain, registering it if needed.
+// Links without domain are moved to it, every move is audited.
+func (r *SQLiteRepo) SetDefaultDomain(ctx context.Context, host string) (*models.Domain, []models.Link, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE domains SET is_default = 0 WHERE host <> ?`, host); err != nil {
+		return nil, nil, err
+	}
+
+	var domain models.Domain
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO domains (host, is_default) VALUES (?, 1)
+ON CONFLICT (host) DO UPDATE SET is_default = 1
+RETURNING id, host, is_default`,
+		host,
+	).Scan(&domain.ID, &domain.Host, &domain.IsDefault)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `UPDATE urls SET domain_id = ? WHERE domain_id IS NULL RETURNING id, alias`, domain.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var moved []models.Link
+	for rows.Next() {
+		var alias sql.NullString
+		link := models.Link{DomainID: domain.ID}
+		if err := rows.Scan(&link.ID, &alias); err != nil {
+			return nil, nil, err
+		}
+		link.Alias = alias.String
+		moved = append(moved, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	events, err := movedLinkEvents(ctx, moved, domain.Host)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writeSQLiteAudit(ctx, tx, events...); err != nil {
+		return nil, nil, err
+	}
+
+	return &domain, moved, tx.Commit()
+}
+
+// setTags adds the tags to the link, registering new tags
+func setTags(ctx context.Context, tx *sql.Tx, id int64, tags []string) error {
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO tags (name) VALUES (?) ON CONFLICT (name) DO NOTHING`, tag); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO url_tags (url_id, tag_id) SELECT ?, id FROM tags WHERE name = ? ON CONFLICT DO NOTHING`,
+			id, tag,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanLink scans the link selected with linkColumns, the domain host is not set
+func scanLink(row interface{ Scan(dest ...any) error }) (*models.Link, error) {
+	var (
+		link                                     models.Link
+		options                                  []byte
+		passwordHash, alias, owner, title, notes sql.NullString
+		notBefore, expiresAt                     sql.NullInt64
+		domainID                                 sql.NullInt32
+		createdAt                                int64
+	)
+	err := row.Scan(
+		&link.ID,
+		&link.URL,
+		&options,
+		&passwordHash,
+		&notBefore,
+		&expiresAt,
+		&domainID,
+		&alias,
+		&owner,
+		&createdAt,
+		&title,
+		&notes,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	link.PasswordHash = passwordHash.String
+	link.NotBefore = timeFromMicro(notBefore)
+	link.ExpiresAt = timeFromMicro(expiresAt)
+	link.DomainID = domainID.Int32
+	link.Alias = alias.String
+	link.Owner = owner.String
+	link.CreatedAt = time.UnixMicro(createdAt)
+	link.Metadata = models.LinkMetadata{Title: title.String, Notes: notes.String}
+
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &link.Options); err != nil {
+			return nil, err
+		}
+	}
+
+	return &link, nil
+}
+
+// apiKeyColumns are scanned by scanAPIKey
+const apiKeyColumns = `id, prefix, key_hash, principal, name, scopes, created_at, revoked_at`
+
+func (r *SQLiteRepo) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAPIKey(r.db.QueryRowContext(ctx,
+		`INSERT INTO api_keys (prefix, key_hash, principal, name, scopes, created_at) VALUES (?, ?, ?, ?, ?, ?)
+RETURNING `+apiKeyColumns,
+		key.Prefix, key.KeyHash, key.Principal, key.Name, string(scopes), time.Now().UnixMicro(),
+	))
+}
+
+func (r *SQLiteRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = ?`, keyHash))
+}
+
+func (r *SQLiteRepo) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *SQLiteRepo) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ? RETURNING `+apiKeyColumns,
+		time.Now().UnixMicro(), id,
+	))
+}
+
+// inTx runs fn in one transaction, it is committed if fn succeeds
+func (r *SQLiteRepo) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// getLinkTx returns the link with its sorted tags in the transaction
+func getLinkTx(ctx context.Context, tx *sql.Tx, id int64) (*models.Link, error) {
+	link, err := scanLink(tx.QueryRowContext(ctx, `SELECT `+linkColumns+` FROM urls WHERE id = ?`, id))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT tags.name FROM url_tags JOIN tags ON tags.id = url_tags.tag_id WHERE url_tags.url_id = ? ORDER BY tags.name`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		link.Metadata.Tags = append(link.Metadata.Tags, name)
+	}
+
+	return link, rows.Err()
+}
+
+// auditColumns are selected by every query returning audit events, in the order of queryAuditEvents
+const auditColumns = `id, url_id, domain_id, code, action, actor, request_id, old_value, new_value, created_at`
+
+// writeSQLiteAudit appends the events in the transaction of the change they record
+func writeSQLiteAudit(ctx context.Context, tx *sql.Tx, events ...models.AuditEvent) error {
+	actor := audit.ActorFromContext(ctx)
+	createdAt := time.Now().UnixMicro()
+
+	for _, event := range events {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO link_audit (url_id, domain_id, code, action, actor, request_id, old_value, new_value, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			event.LinkID,
+			sql.NullInt32{Int32: event.DomainID, Valid: event.DomainID != 0},
+			event.Code,
+			string(event.Action),
+			nullString(actor.Principal),
+			nullString(actor.RequestID),
+			nullBytes(event.OldValue),
+			nullBytes(event.NewValue),
+			createdAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queryAuditEvents scans the events selected with auditColumns
+func queryAuditEvents(ctx context.Context, q interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}, query string, args ...any) ([]models.AuditEvent, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.AuditEvent
+	for rows.Next() {
+		var (
+			event                       models.AuditEvent
+			domainID                    sql.NullInt32
+			action                      string
+			actor, requestID, old, next sql.NullString
+			createdAt                   int64
+		)
+		err := rows.Scan(&event.ID, &event.LinkID, &domainID, &event.Code, &action, &actor, &requestID, &old, &next, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		event.DomainID = domainID.Int32
+		event.Action = models.AuditAction(action)
+		event.Actor = actor.String
+		event.RequestID = requestID.String
+		if old.Valid {
+			event.OldValue = json.RawMessage(old.String)
+		}
+		if next.Valid {
+			event.NewValue = json.RawMessage(next.String)
+		}
+		event.CreatedAt = time.UnixMicro(createdAt)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// placeholders returns n comma-separated query placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullBytes stores empty JSON as NULL
+func nullBytes(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+// scanAPIKey scans apiKeyColumns
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (*models.APIKey, error) {
+	var (
+		key       models.APIKey
+		scopes    string
+		createdAt int64
+		revokedAt sql.NullInt64
+	)
+	err := row.Scan(&key.ID, &key.Prefix, &key.KeyHash, &key.Principal, &key.Name, &scopes, &createdAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return nil, err
+	}
+	key.CreatedAt = time.UnixMicro(createdAt)
+	key.RevokedAt = timeFromMicro(revokedAt)
+
+	return &key, nil
+}
+
+// nullTime stores zero time as NULL and others as Unix microseconds
+func nullTime(t time.Time) sql.NullInt64 {
+	return sql.NullInt64{Int64: t.UnixMicro(), Valid: !t.IsZero()}
+}
+
+// timeFromMicro returns zero time for NULL
+func timeFromMicro(micro sql.NullInt64) time.Time {
+	if !micro.Valid {
+		return time.Time{}
+	}
+	return time.UnixMicro(micro.Int64)
+}
+
+// isSQLiteUniqueViolation reports whether err is caused by a unique constraint
+func isSQLiteUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
@@ -0,0 +1,60 @@
+package repository_test
+
+import (
+	"context"
+	"github.com/misshanya/url-shortener/shortener/internal/db"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/misshanya/url-shortener/shortener/internal/repository"
+	"github.com/misshanya/url-shortener/shortener/internal/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.Repo {
+		sqlDB, err := db.OpenSQLite(filepath.Join(t.TempDir(), "shortener.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { sqlDB.Close() })
+
+		require.NoError(t, db.MigrateSQLite(sqlDB))
+
+		return repository.NewSQLiteRepo(sqlDB)
+	})
+}
+
+func TestSQLiteRepo_SetDefaultDomainMovesLinks(t *testing.T) {
+	ctx := context.Background()
+	sqlDB, err := db.OpenSQLite(filepath.Join(t.TempDir(), "shortener.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, db.MigrateSQLite(sqlDB))
+
+	// Links created before domains were introduced
+	_, err = sqlDB.ExecContext(ctx, `INSERT INTO urls (id, url, alias, created_at) VALUES (62, 'https://go.dev', NULL, 0), (63, 'https://example.com', 'docs', 0)`)
+	require.NoError(t, err)
+
+	repo := repository.NewSQLiteRepo(sqlDB)
+	domain, moved, err := repo.SetDefaultDomain(ctx, "sh.some")
+	require.NoError(t, err)
+	assert.Equal(t, []models.Link{
+		{ID: 62, DomainID: domain.ID},
+		{ID: 63, DomainID: domain.ID, Alias: "docs"},
+	}, moved)
+
+	events, err := repo.ListAuditEvents(ctx, models.AuditFilter{}, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	for _, event := range events {
+		assert.Equal(t, models.AuditSetDomain, event.Action)
+		assert.JSONEq(t, `{"domain":null}`, string(event.OldValue))
+		assert.JSONEq(t, `{"domain":"sh.some"}`, string(event.NewValue))
+	}
+	assert.Equal(t, "docs", events[0].Code)
+	assert.Equal(t, "10", events[1].Code)
+
+	_, moved, err = repo.SetDefaultDomain(ctx, "sh.some")
+	require.NoError(t, err)
+	assert.Empty(t, moved, "links are moved once")
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"github.com/misshanya/url-shortener/shortener/internal/models"
 	"github.com/valkey-io/valkey-go"
@@ -16,33 +17,86 @@ func NewValkeyRepo(client valkey.Client) *ValkeyRepo {
 	return &ValkeyRepo{client: client}
 }
 
-func (r *ValkeyRepo) SetTop(ctx context.Context, top models.UnshortenedTop, ttl time.Duration) error {
+// cacheKey is the key of the link cached by the domain and code
+func cacheKey(domain, code string) string {
+	return domain + "/" + code
+}
+
+// SetTop caches links by their domains and codes with specified TTL
+func (r *ValkeyRepo) SetTop(ctx context.Context, top []models.Link, ttl time.Duration) error {
 	var errs error
-	for _, v := range top.Top {
-		err := r.client.Do(ctx,
+	for _, link := range top {
+		value, err := json.Marshal(link)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		err = r.client.Do(ctx,
 			r.client.B().
 				Set().
-				Key(v.ShortCode).
-				Value(v.OriginalURL).
+				Key(cacheKey(link.Domain, link.Code)).
+				Value(string(value)).
 				Nx().
 				Ex(ttl).
 				Build(),
 		).Error()
 		if err != nil {
-			errors.Join(errs, err)
+			errs = errors.Join(errs, err)
 		}
 	}
 
 	return errs
 }
 
-func (r *ValkeyRepo) GetURLByCode(ctx context.Context, code string) (string, error) {
-	url, err := r.client.Do(ctx, r.client.B().Get().Key(code).Build()).ToString()
+// GetLinkByCode returns cached link or nil if there is no link in cache
+func (r *ValkeyRepo) GetLinkByCode(ctx context.Context, domain, code string) (*models.Link, error) {
+	value, err := r.client.Do(ctx, r.client.B().Get().Key(cacheKey(domain, code)).Build()).AsBytes()
+	if errors.Is(err, valkey.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var link models.Link
+	if err := json.Unmarshal(value, &link); err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+// DeleteLinkByCode removes the cached link, so the next visit reads it from the db
+func (r *ValkeyRepo) DeleteLinkByCode(ctx context.Context, domain, code string) error {
+	return r.client.Do(ctx, r.client.B().Del().Key(cacheKey(domain, code)).Build()).Error()
+}
+
+// GetCacheEntry returns cached link with the time left until it expires,
+// or nil if there is no link in cache. TTL is 0 if the entry doesn't expire.
+func (r *ValkeyRepo) GetCacheEntry(ctx context.Context, domain, code string) (*models.Link, time.Duration, error) {
+	key := cacheKey(domain, code)
+	results := r.client.DoMulti(ctx,
+		r.client.B().Get().Key(key).Build(),
+		r.client.B().Pttl().Key(key).Build(),
+	)
+
+	value, err := results[0].AsBytes()
 	if errors.Is(err, valkey.Nil) {
-		return "", nil
+		return nil, 0, nil
 	} else if err != nil {
-		return "", err
+		return nil, 0, err
+	}
+
+	// PTTL is negative if the key has no TTL or is already gone
+	ttl, err := results[1].AsInt64()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var link models.Link
+	if err := json.Unmarshal(value, &link); err != nil {
+		return nil, 0, err
 	}
 
-	return url, nil
+	return &link, time.Duration(max(ttl, 0)) * time.Millisecond, nil
 }
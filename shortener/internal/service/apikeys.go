@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"slices"
+	"strings"
+)
+
+const (
+	// apiKeyPrefix starts every API key, so leaked keys are easy to find
+	apiKeyPrefix = "shk_"
+	// apiKeyBytes is the number of random bytes of the key
+	apiKeyBytes = 24
+	// apiKeyShownLen is the length of the beginning of the key kept in plain text
+	apiKeyShownLen = len(apiKeyPrefix) + 8
+)
+
+// CreateAPIKey issues a new API key of the principal, the key itself is returned only here,
+// only its hash is stored
+func (s *Service) CreateAPIKey(ctx context.Context, principal, name string, scopes []string) (*models.APIKey, string, error) {
+	ctx, span := s.t.Start(ctx, "CreateAPIKey")
+	defer span.End()
+
+	principal = strings.TrimSpace(principal)
+	if principal == "" {
+		return nil, "", status.Error(codes.InvalidArgument, "principal is required")
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		s.l.Error("failed to generate api key", "error", err)
+		return nil, "", status.Error(codes.Internal, "failed to create api key")
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey, err := s.pr.CreateAPIKey(ctx, &models.APIKey{
+		Prefix:    key[:apiKeyShownLen],
+		KeyHash:   hashAPIKey(key),
+		Principal: principal,
+		Name:      strings.TrimSpace(name),
+		Scopes:    scopes,
+	})
+	if err != nil {
+		s.l.Error("failed to create api key", "principal", principal, "error", err)
+		return nil, "", status.Error(codes.Internal, "failed to create api key")
+	}
+
+	return apiKey, key, nil
+}
+
+// ListAPIKeys returns every API key, newest first
+func (s *Service) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	ctx, span := s.t.Start(ctx, "ListAPIKeys")
+	defer span.End()
+
+	keys, err := s.pr.ListAPIKeys(ctx)
+	if err != nil {
+		s.l.Error("failed to list api keys", "error", err)
+		return nil, status.Error(codes.Internal, "failed to list api keys")
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey makes the key unusable, revoking the revoked key does nothing
+func (s *Service) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	ctx, span := s.t.Start(ctx, "RevokeAPIKey")
+	defer span.End()
+
+	key, err := s.pr.RevokeAPIKey(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "api key not found")
+	} else if err != nil {
+		s.l.Error("failed to revoke api key", "id", id, "error", err)
+		return nil, status.Error(codes.Internal, "failed to revoke api key")
+	}
+
+	return key, nil
+}
+
+// AuthenticateAPIKey returns the active API key, unknown and revoked keys are Unauthenticated
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	ctx, span := s.t.Start(ctx, "AuthenticateAPIKey")
+	defer span.End()
+
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, status.Error(codes.Unauthenticated, "invalid api key")
+	}
+
+	apiKey, err := s.pr.GetAPIKeyByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.Unauthenticated, "invalid api key")
+	} else if err != nil {
+		s.l.Error("failed to get api key", "error", err)
+		return nil, status.Error(codes.Internal, "failed to authenticate api key")
+	}
+	if apiKey.IsRevoked() {
+		return nil, status.Error(codes.Unauthenticated, "api key is revoked")
+	}
+
+	return apiKey, nil
+}
+
+// hashAPIKey hashes the key to look it up, the key is random enough to not need a slow hash
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeScopes checks the scopes are known and orders them like models.Scopes without duplicates
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(models.Scopes, scope) {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown scope %q", scope)
+		}
+	}
+
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range models.Scopes {
+		if slices.Contains(scopes, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_CreateAPIKey(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Principal      string
+		KeyName        string
+		Scopes         []string
+		ExceptedScopes []string
+		ExceptedErr    error
+		SetUpMocks     func(db *mockpostgresRepo)
+	}{
+		{
+			Name:           "Success",
+			Principal:      " apikey:ci ",
+			KeyName:        "CI",
+			Scopes:         []string{models.ScopeManageLinks, models.ScopeShorten, models.ScopeShorten},
+			ExceptedScopes: []string{models.ScopeShorten, models.ScopeManageLinks},
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("CreateAPIKey", mock.Anything, mock.MatchedBy(func(key *models.APIKey) bool {
+					return key.Principal == "apikey:ci" && key.Name == "CI" &&
+						strings.HasPrefix(key.Prefix, apiKeyPrefix) && len(key.KeyHash) == 64
+				})).
+					Return(func(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+						stored := *key
+						stored.ID = 1
+						return &stored, nil
+					}).Once()
+			},
+		},
+		{
+			Name:        "No principal",
+			Scopes:      []string{models.ScopeShorten},
+			ExceptedErr: status.Error(codes.InvalidArgument, "principal is required"),
+			SetUpMocks:  func(db *mockpostgresRepo) {},
+		},
+		{
+			Name:        "No scopes",
+			Principal:   "apikey:ci",
+			ExceptedErr: status.Error(codes.InvalidArgument, "at least one scope is required"),
+			SetUpMocks:  func(db *mockpostgresRepo) {},
+		},
+		{
+			Name:        "Unknown scope",
+			Principal:   "apikey:ci",
+			Scopes:      []string{"admin"},
+			ExceptedErr: status.Error(codes.InvalidArgument, `unknown scope "admin"`),
+			SetUpMocks:  func(db *mockpostgresRepo) {},
+		},
+		{
+			Name:        "Failed to store",
+			Principal:   "apikey:ci",
+			Scopes:      []string{models.ScopeShorten},
+			ExceptedErr: status.Error(codes.Internal, "failed to create api key"),
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("CreateAPIKey", mock.Anything, mock.Anything).
+					Return(nil, errors.New("some unknown error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockPostgres := mockpostgresRepo{}
+			tt.SetUpMocks(&mockPostgres)
+
+			service := newLinksTestService(&mockPostgres, nil)
+
+			apiKey, key, err := service.CreateAPIKey(context.Background(), tt.Principal, tt.KeyName, tt.Scopes)
+			assert.Equal(t, tt.ExceptedErr, err)
+			if tt.ExceptedErr == nil {
+				require.NotNil(t, apiKey)
+				assert.Equal(t, tt.ExceptedScopes, apiKey.Scopes)
+				assert.True(t, strings.HasPrefix(key, apiKey.Prefix), "prefix is the beginning of the key")
+				assert.Equal(t, hashAPIKey(key), apiKey.KeyHash)
+			}
+
+			mockPostgres.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_AuthenticateAPIKey(t *testing.T) {
+	const key = "shk_secret"
+	active := &models.APIKey{ID: 1, Principal: "apikey:ci", Scopes: []string{models.ScopeShorten}}
+
+	tests := []struct {
+		Name           string
+		Key            string
+		ExceptedAPIKey *models.APIKey
+		ExceptedErr    error
+		SetUpMocks     func(db *mockpostgresRepo)
+	}{
+		{
+			Name:           "Active key",
+			Key:            key,
+			ExceptedAPIKey: active,
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("GetAPIKeyByHash", mock.Anything, hashAPIKey(key)).
+					Return(active, nil).Once()
+			},
+		},
+		{
+			Name:        "Not a key",
+			Key:         "Bearer something",
+			ExceptedErr: status.Error(codes.Unauthenticated, "invalid api key"),
+			SetUpMocks:  func(db *mockpostgresRepo) {},
+		},
+		{
+			Name:        "Unknown key",
+			Key:         key,
+			ExceptedErr: status.Error(codes.Unauthenticated, "invalid api key"),
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("GetAPIKeyByHash", mock.Anything, hashAPIKey(key)).
+					Return(nil, sql.ErrNoRows).Once()
+			},
+		},
+		{
+			Name:        "Revoked key",
+			Key:         key,
+			ExceptedErr: status.Error(codes.Unauthenticated, "api key is revoked"),
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("GetAPIKeyByHash", mock.Anything, hashAPIKey(key)).
+					Return(&models.APIKey{ID: 1, RevokedAt: time.Now()}, nil).Once()
+			},
+		},
+		{
+			Name:        "Failed to get",
+			Key:         key,
+			ExceptedErr: status.Error(codes.Internal, "failed to authenticate api key"),
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("GetAPIKeyByHash", mock.Anything, hashAPIKey(key)).
+					Return(nil, errors.New("some unknown error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockPostgres := mockpostgresRepo{}
+			tt.SetUpMocks(&mockPostgres)
+
+			service := newLinksTestService(&mockPostgres, nil)
+
+			apiKey, err := service.AuthenticateAPIKey(context.Background(), tt.Key)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedAPIKey, apiKey)
+
+			mockPostgres.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_RevokeAPIKey(t *testing.T) {
+	mockPostgres := mockpostgresRepo{}
+	mockPostgres.On("RevokeAPIKey", mock.Anything, int64(5)).
+		Return(nil, sql.ErrNoRows).Once()
+
+	service := newLinksTestService(&mockPostgres, nil)
+
+	_, err := service.RevokeAPIKey(context.Background(), 5)
+	assert.Equal(t, status.Error(codes.NotFound, "api key not found"), err)
+
+	mockPostgres.AssertExpectations(t)
+}
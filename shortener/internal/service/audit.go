@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+)
+
+// auditRelayBatch is the number of audit events published to Kafka at once
+const auditRelayBatch = 100
+
+// ListAuditEvents returns a page of audit events matching the filter, newest first, and the cursor of the next page.
+// The code is looked up in the host, the default domain if it is empty, events of all links are returned without code.
+func (s *Service) ListAuditEvents(ctx context.Context, host string, filter models.AuditFilter, cursor string, pageSize int) ([]models.AuditEvent, string, error) {
+	ctx, span := s.t.Start(ctx, "ListAuditEvents")
+	defer span.End()
+
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", status.Error(codes.InvalidArgument, "bad cursor")
+	}
+
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	if host != "" || filter.Code != "" {
+		domain, err := s.resolveDomain(ctx, host, false)
+		if err != nil {
+			return nil, "", err
+		}
+		filter.DomainID = domain.ID
+	}
+
+	// One more event is taken to know if there is the next page
+	events, err := s.pr.ListAuditEvents(ctx, filter, after, int32(pageSize+1))
+	if err != nil {
+		s.l.Error("failed to list audit events", "error", err)
+		return nil, "", status.Error(codes.Internal, "failed to list audit events")
+	}
+
+	var next string
+	if len(events) > pageSize {
+		events = events[:pageSize]
+		next = encodeCursor(events[pageSize-1].ID)
+	}
+
+	if err := s.setAuditDomains(ctx, events); err != nil {
+		return nil, "", err
+	}
+
+	return events, next, nil
+}
+
+// PublishAuditEvents publishes audit events not published yet to Kafka in order, returns the number of them.
+// Events are kept for the next call if Kafka fails.
+func (s *Service) PublishAuditEvents(ctx context.Context) (int, error) {
+	ctx, span := s.t.Start(ctx, "PublishAuditEvents")
+	defer span.End()
+
+	var total int
+	for {
+		n, err := s.pr.RelayAuditEvents(ctx, auditRelayBatch, func(events []models.AuditEvent) error {
+			return s.writeAuditEvents(ctx, events)
+		})
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < auditRelayBatch {
+			return total, nil
+		}
+	}
+}
+
+// writeAuditEvents writes the events to Kafka, keyed by the link, so changes of the link keep their order
+func (s *Service) writeAuditEvents(ctx context.Context, events []models.AuditEvent) error {
+	if err := s.setAuditDomains(ctx, events); err != nil {
+		return err
+	}
+
+	msgs := make([]kafka.Message, len(events))
+	for i, event := range events {
+		value, err := json.Marshal(models.KafkaMessageAudit{
+			ID:        event.ID,
+			Code:      event.Code,
+			Domain:    event.Domain,
+			Action:    string(event.Action),
+			Actor:     event.Actor,
+			RequestID: event.RequestID,
+			OldValue:  event.OldValue,
+			NewValue:  event.NewValue,
+			CreatedAt: event.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		msgs[i] = kafka.Message{
+			Topic: "shortener.audit",
+			Key:   []byte(strconv.FormatInt(event.LinkID, 10)),
+			Value: value,
+		}
+	}
+
+	return s.kw.WriteMessages(ctx, msgs...)
+}
+
+// setAuditDomains sets hosts of the events by their domain IDs
+func (s *Service) setAuditDomains(ctx context.Context, events []models.AuditEvent) error {
+	ids := make([]int32, len(events))
+	for i := range events {
+		ids[i] = events[i].DomainID
+	}
+	hosts, err := s.domainHosts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range events {
+		events[i].Domain = hosts[events[i].DomainID]
+	}
+	return nil
+}
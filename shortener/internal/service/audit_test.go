@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_ListAuditEvents(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Host           string
+		Filter         models.AuditFilter
+		Cursor         string
+		PageSize       int
+		ExceptedEvents []models.AuditEvent
+		ExceptedCursor string
+		ExceptedErr    error
+		SetUpMocks     func(db *mockpostgresRepo)
+	}{
+		{
+			Name:     "Events of the code in the default domain",
+			Filter:   models.AuditFilter{Code: "docs"},
+			PageSize: 1,
+			ExceptedEvents: []models.AuditEvent{
+				{ID: 7, LinkID: 5, DomainID: 1, Domain: "sh.some", Code: "docs", Action: models.AuditRetarget},
+			},
+			ExceptedCursor: encodeCursor(7),
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("ListAuditEvents", mock.Anything, models.AuditFilter{DomainID: 1, Code: "docs"}, int64(0), int32(2)).
+					Return([]models.AuditEvent{
+						{ID: 7, LinkID: 5, DomainID: 1, Code: "docs", Action: models.AuditRetarget},
+						{ID: 3, LinkID: 5, DomainID: 1, Code: "docs", Action: models.AuditCreate},
+					}, nil).Once()
+			},
+		},
+		{
+			Name:   "Events of all links",
+			Filter: models.AuditFilter{Actor: "telegram:1"},
+			Cursor: encodeCursor(10),
+			ExceptedEvents: []models.AuditEvent{
+				{ID: 9, LinkID: 62, DomainID: 2, Domain: "go.some", Code: "10", Action: models.AuditDelete, Actor: "telegram:1"},
+			},
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("ListAuditEvents", mock.Anything, models.AuditFilter{Actor: "telegram:1"}, int64(10), int32(defaultPageSize+1)).
+					Return([]models.AuditEvent{
+						{ID: 9, LinkID: 62, DomainID: 2, Code: "10", Action: models.AuditDelete, Actor: "telegram:1"},
+					}, nil).Once()
+			},
+		},
+		{
+			Name:        "Bad cursor",
+			Cursor:      "not a cursor",
+			ExceptedErr: status.Error(codes.InvalidArgument, "bad cursor"),
+			SetUpMocks:  func(db *mockpostgresRepo) {},
+		},
+		{
+			Name:        "Unknown domain",
+			Host:        "unknown.some",
+			ExceptedErr: status.Error(codes.InvalidArgument, "unknown domain"),
+			SetUpMocks:  func(db *mockpostgresRepo) {},
+		},
+		{
+			Name:        "Failed to list",
+			ExceptedErr: status.Error(codes.Internal, "failed to list audit events"),
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("ListAuditEvents", mock.Anything, models.AuditFilter{}, int64(0), int32(defaultPageSize+1)).
+					Return(nil, errors.New("some unknown error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockPostgres := mockpostgresRepo{}
+			mockPostgres.On("ListDomains", mock.Anything).
+				Return(testDomains, nil).Maybe()
+
+			tt.SetUpMocks(&mockPostgres)
+
+			service := newLinksTestService(&mockPostgres, nil)
+
+			events, cursor, err := service.ListAuditEvents(context.Background(), tt.Host, tt.Filter, tt.Cursor, tt.PageSize)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedEvents, events)
+			assert.Equal(t, tt.ExceptedCursor, cursor)
+
+			mockPostgres.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_PublishAuditEvents(t *testing.T) {
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []models.AuditEvent{
+		{ID: 3, LinkID: 5, DomainID: 2, Code: "docs", Action: models.AuditRetarget, Actor: "telegram:1", RequestID: "req-1",
+			OldValue: json.RawMessage(`{"url":"https://go.dev"}`), NewValue: json.RawMessage(`{"url":"https://pkg.go.dev"}`), CreatedAt: createdAt},
+	}
+
+	// relay passes events to publish like the storage does and returns what it reports
+	relay := func(db *mockpostgresRepo, events []models.AuditEvent) {
+		db.On("RelayAuditEvents", mock.Anything, int32(auditRelayBatch), mock.Anything).
+			Return(func(ctx context.Context, limit int32, publish func([]models.AuditEvent) error) (int, error) {
+				if len(events) == 0 {
+					return 0, nil
+				}
+				if err := publish(events); err != nil {
+					return 0, err
+				}
+				return len(events), nil
+			}).Once()
+	}
+
+	tests := []struct {
+		Name          string
+		ExceptedCount int
+		ExceptedErr   error
+		SetUpMocks    func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter)
+	}{
+		{
+			Name:          "Successfully publish",
+			ExceptedCount: 1,
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter) {
+				relay(db, events)
+				excepted := kafka.Message{
+					Topic: "shortener.audit",
+					Key:   []byte("5"),
+					Value: []byte(`{"id":3,"code":"docs","domain":"go.some","action":"retarget","actor":"telegram:1",` +
+						`"request_id":"req-1","old_value":{"url":"https://go.dev"},"new_value":{"url":"https://pkg.go.dev"},` +
+						`"created_at":"2025-01-01T00:00:00Z"}`),
+				}
+				kafkaWriter.On("WriteMessages", mock.Anything, []kafka.Message{excepted}).
+					Return(nil).Once()
+			},
+		},
+		{
+			Name: "Nothing to publish",
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter) {
+				relay(db, nil)
+			},
+		},
+		{
+			Name:        "Kafka is down",
+			ExceptedErr: errors.New("kafka is down"),
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter) {
+				relay(db, events)
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(errors.New("kafka is down")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockPostgres := mockpostgresRepo{}
+			mockPostgres.On("ListDomains", mock.Anything).
+				Return(testDomains, nil).Maybe()
+			mockKafka := mockkafkaWriter{}
+
+			tt.SetUpMocks(&mockPostgres, &mockKafka)
+
+			tracerProvider := noop.NewTracerProvider()
+			service := New(
+				&mockPostgres,
+				nil,
+				slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})),
+				&mockKafka,
+				tracerProvider.Tracer(""),
+				nil,
+				nil,
+				testAnonymizer,
+				10,
+			)
+
+			count, err := service.PublishAuditEvents(context.Background())
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedCount, count)
+
+			mockPostgres.AssertExpectations(t)
+			mockKafka.AssertExpectations(t)
+		})
+	}
+}
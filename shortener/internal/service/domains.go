@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/errorz"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/misshanya/url-shortener/shortener/pkg/base62"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"sync"
+	"time"
+)
+
+// domainsTTL is how long the registered domains are cached,
+// so domains created by other instances are picked up
+const domainsTTL = time.Minute
+
+// domainRegistry caches the registered domains, they are rarely changed
+type domainRegistry struct {
+	mu       sync.RWMutex
+	byHost   map[string]models.Domain
+	def      models.Domain
+	loadedAt time.Time
+}
+
+// CreateDomain registers a new short domain
+func (s *Service) CreateDomain(ctx context.Context, host string) (*models.Domain, error) {
+	ctx, span := s.t.Start(ctx, "CreateDomain")
+	defer span.End()
+
+	domain, err := s.pr.CreateDomain(ctx, normalizeHost(host))
+	if errors.Is(err, errorz.ErrDomainExists) {
+		return nil, errorz.Conflict(errorz.ReasonDomainExists, "domain already exists")
+	} else if err != nil {
+		s.l.Error("failed to create domain", "host", host, "error", err)
+		return nil, status.Error(codes.Internal, "failed to create domain")
+	}
+
+	if err := s.loadDomains(ctx); err != nil {
+		s.l.Error("failed to reload domains", "error", err)
+	}
+
+	return domain, nil
+}
+
+// SetDefaultDomain makes the host the default domain, registering it if needed.
+// Links created before domains were introduced are moved to it and dropped from cache.
+func (s *Service) SetDefaultDomain(ctx context.Context, host string) (*models.Domain, error) {
+	ctx, span := s.t.Start(ctx, "SetDefaultDomain")
+	defer span.End()
+
+	domain, moved, err := s.pr.SetDefaultDomain(ctx, normalizeHost(host))
+	if err != nil {
+		s.l.Error("failed to set default domain", "host", host, "error", err)
+		return nil, status.Error(codes.Internal, "failed to set default domain")
+	}
+
+	for _, link := range moved {
+		link.Domain = domain.Host
+		link.Code = link.Alias
+		if link.Code == "" {
+			link.Code = base62.Encode(link.ID)
+		}
+		s.invalidateLink(ctx, &link)
+	}
+	if len(moved) > 0 {
+		s.l.Info("links are moved to the default domain", "host", domain.Host, "links", len(moved))
+	}
+
+	if err := s.loadDomains(ctx); err != nil {
+		s.l.Error("failed to reload domains", "error", err)
+	}
+
+	return domain, nil
+}
+
+func (s *Service) ListDomains(ctx context.Context) ([]models.Domain, error) {
+	ctx, span := s.t.Start(ctx, "ListDomains")
+	defer span.End()
+
+	domains, err := s.pr.ListDomains(ctx)
+	if err != nil {
+		s.l.Error("failed to list domains", "error", err)
+		return nil, status.Error(codes.Internal, "failed to list domains")
+	}
+
+	return domains, nil
+}
+
+// resolveDomain returns the registered domain by host, the default domain if host is empty.
+// Unknown hosts are resolved to the default domain if fallback is set.
+func (s *Service) resolveDomain(ctx context.Context, host string, fallback bool) (models.Domain, error) {
+	s.domains.mu.RLock()
+	stale := time.Since(s.domains.loadedAt) >= domainsTTL
+	s.domains.mu.RUnlock()
+
+	if stale {
+		if err := s.loadDomains(ctx); err != nil {
+			s.l.Error("failed to load domains", "error", err)
+			return models.Domain{}, status.Error(codes.Internal, "failed to load domains")
+		}
+	}
+
+	s.domains.mu.RLock()
+	defer s.domains.mu.RUnlock()
+
+	if host != "" {
+		if domain, ok := s.domains.byHost[normalizeHost(host)]; ok {
+			return domain, nil
+		}
+		if !fallback {
+			return models.Domain{}, status.Error(codes.InvalidArgument, "unknown domain")
+		}
+	}
+
+	if s.domains.def.Host == "" {
+		s.l.Error("no default domain")
+		return models.Domain{}, status.Error(codes.Internal, "no default domain")
+	}
+	return s.domains.def, nil
+}
+
+// domainHosts returns the hosts of the registered domains by their IDs,
+// reloading the domains if some of ids are not known yet
+func (s *Service) domainHosts(ctx context.Context, ids []int32) (map[int32]string, error) {
+	if _, err := s.resolveDomain(ctx, "", false); err != nil {
+		return nil, err
+	}
+
+	hosts := func() (map[int32]string, bool) {
+		s.domains.mu.RLock()
+		defer s.domains.mu.RUnlock()
+
+		hosts := make(map[int32]string, len(s.domains.byHost))
+		for _, domain := range s.domains.byHost {
+			hosts[domain.ID] = domain.Host
+		}
+		for _, id := range ids {
+			if _, ok := hosts[id]; !ok {
+				return hosts, false
+			}
+		}
+		return hosts, true
+	}
+
+	if h, ok := hosts(); ok {
+		return h, nil
+	}
+
+	if err := s.loadDomains(ctx); err != nil {
+		s.l.Error("failed to load domains", "error", err)
+		return nil, status.Error(codes.Internal, "failed to load domains")
+	}
+	h, _ := hosts()
+	return h, nil
+}
+
+// loadDomains replaces the cached domains with the registered ones
+func (s *Service) loadDomains(ctx context.Context) error {
+	ctx, span := s.t.Start(ctx, "load-domains")
+	defer span.End()
+
+	domains, err := s.pr.ListDomains(ctx)
+	if err != nil {
+		return err
+	}
+
+	byHost := make(map[string]models.Domain, len(domains))
+	var def models.Domain
+	for _, domain := range domains {
+		byHost[domain.Host] = domain
+		if domain.IsDefault {
+			def = domain
+		}
+	}
+
+	s.domains.mu.Lock()
+	s.domains.byHost = byHost
+	s.domains.def = def
+	s.domains.loadedAt = time.Now()
+	s.domains.mu.Unlock()
+
+	return nil
+}
+
+// normalizeHost brings the host to the form domains are registered with
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/misshanya/url-shortener/shortener/pkg/base62"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+// ListURLs returns a page of links matching the filter, newest first, and the cursor of the next page.
+// The next cursor is empty on the last page.
+func (s *Service) ListURLs(ctx context.Context, filter models.LinkFilter, cursor string, pageSize int) ([]models.Link, string, error) {
+	ctx, span := s.t.Start(ctx, "ListURLs")
+	defer span.End()
+
+	after, err := decodeLinkCursor(cursor)
+	if err != nil {
+		return nil, "", status.Error(codes.InvalidArgument, "bad cursor")
+	}
+
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+
+	var domainID int32
+	if filter.Domain != "" {
+		domain, err := s.resolveDomain(ctx, filter.Domain, false)
+		if err != nil {
+			return nil, "", err
+		}
+		domainID = domain.ID
+	}
+
+	// One more link is taken to know if there is the next page
+	links, err := s.pr.ListLinks(ctx, filter, domainID, after, int32(pageSize+1))
+	if err != nil {
+		s.l.Error("failed to list links", "error", err)
+		return nil, "", status.Error(codes.Internal, "failed to list links")
+	}
+
+	var next string
+	if len(links) > pageSize {
+		links = links[:pageSize]
+		next = encodeLinkCursor(links[pageSize-1])
+	}
+
+	ids := make([]int32, len(links))
+	for i := range links {
+		ids[i] = links[i].DomainID
+	}
+	hosts, err := s.domainHosts(ctx, ids)
+	if err != nil {
+		return nil, "", err
+	}
+
+	linkIDs := make([]int64, len(links))
+	for i := range links {
+		linkIDs[i] = links[i].ID
+	}
+	tags, err := s.pr.GetLinkTags(ctx, linkIDs)
+	if err != nil {
+		s.l.Error("failed to get tags of links", "error", err)
+		return nil, "", status.Error(codes.Internal, "failed to list links")
+	}
+
+	for i := range links {
+		links[i].Metadata.Tags = tags[links[i].ID]
+		links[i].Domain = hosts[links[i].DomainID]
+		links[i].Code = links[i].Alias
+		if links[i].Code == "" {
+			links[i].Code = base62.Encode(links[i].ID)
+		}
+	}
+
+	return links, next, nil
+}
+
+// DeleteURL deletes the link by code in the domain and drops it from cache
+func (s *Service) DeleteURL(ctx context.Context, host, short string) error {
+	ctx, span := s.t.Start(ctx, "DeleteURL")
+	defer span.End()
+
+	link, err := s.lookupLink(ctx, host, short)
+	if err != nil {
+		return err
+	}
+
+	if err := s.pr.DeleteLink(ctx, link.ID); err != nil {
+		s.l.Error("failed to delete link", "error", err)
+		return status.Error(codes.Internal, "failed to delete link")
+	}
+
+	s.invalidateLink(ctx, link)
+	return nil
+}
+
+// DisableURL expires the link by code in the domain right away and drops it from cache.
+// Already expired link is kept as it is.
+func (s *Service) DisableURL(ctx context.Context, host, short string) (*models.Link, error) {
+	ctx, span := s.t.Start(ctx, "DisableURL")
+	defer span.End()
+
+	link, err := s.lookupLink(ctx, host, short)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if link.ExpiresAt.IsZero() || link.ExpiresAt.After(now) {
+		err = s.pr.SetLinkExpiresAt(ctx, link.ID, now)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "short not found")
+		} else if err != nil {
+			s.l.Error("failed to disable link", "error", err)
+			return nil, status.Error(codes.Internal, "failed to disable link")
+		}
+		link.ExpiresAt = now
+	}
+
+	s.invalidateLink(ctx, link)
+	return s.withTags(ctx, link)
+}
+
+// RetargetURL changes the destination of the link by code in the domain and drops it from cache
+func (s *Service) RetargetURL(ctx context.Context, host, short, url string) (*models.Link, error) {
+	ctx, span := s.t.Start(ctx, "RetargetURL")
+	defer span.End()
+
+	link, err := s.lookupLink(ctx, host, short)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.pr.SetLinkURL(ctx, link.ID, url)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "short not found")
+	} else if err != nil {
+		s.l.Error("failed to retarget link", "error", err)
+		return nil, status.Error(codes.Internal, "failed to retarget link")
+	}
+	link.URL = url
+
+	s.invalidateLink(ctx, link)
+	return s.withTags(ctx, link)
+}
+
+// GetCacheEntry returns the link cached by code in the domain with the time left until it expires,
+// the link is nil if it is not cached
+func (s *Service) GetCacheEntry(ctx context.Context, host, short string) (*models.Link, time.Duration, error) {
+	ctx, span := s.t.Start(ctx, "GetCacheEntry")
+	defer span.End()
+
+	domain, err := s.resolveDomain(ctx, host, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	link, ttl, err := s.vr.GetCacheEntry(ctx, domain.Host, short)
+	if err != nil {
+		s.l.Error("failed to get cache entry", "error", err)
+		return nil, 0, status.Error(codes.Internal, "failed to get cache entry")
+	}
+
+	return link, ttl, nil
+}
+
+// invalidateLink drops the changed link from cache and tells the gateways to drop it from theirs.
+// The change is already stored, so the failure is only logged, the entry expires with its TTL.
+func (s *Service) invalidateLink(ctx context.Context, link *models.Link) {
+	ctx, span := s.t.Start(ctx, "invalidate-cache")
+	defer span.End()
+
+	if err := s.vr.DeleteLinkByCode(ctx, link.Domain, link.Code); err != nil {
+		s.l.Error("failed to drop link from cache", "code", link.Code, "domain", link.Domain, "error", err)
+	}
+
+	value, err := json.Marshal(models.KafkaMessageInvalidated{
+		Code:          link.Code,
+		Domain:        link.Domain,
+		InvalidatedAt: time.Now(),
+	})
+	if err != nil {
+		s.l.Error("failed to marshal KafkaMessageInvalidated", "error", err)
+		return
+	}
+
+	// Written in background like the unshortened events, the writer batches messages
+	go func() {
+		if err := s.kw.WriteMessages(context.WithoutCancel(ctx), kafka.Message{
+			Topic: "shortener.invalidated",
+			Key:   []byte(link.Domain + "/" + link.Code),
+			Value: value,
+		}); err != nil {
+			s.l.Error("failed to write invalidation to Kafka", "code", link.Code, "domain", link.Domain, "error", err)
+		}
+	}()
+}
+
+// withTags sets tags of the link from the db
+func (s *Service) withTags(ctx context.Context, link *models.Link) (*models.Link, error) {
+	tags, err := s.pr.GetLinkTags(ctx, []int64{link.ID})
+	if err != nil {
+		s.l.Error("failed to get tags of link", "error", err)
+		return nil, status.Error(codes.Internal, "failed to get tags of link")
+	}
+
+	link.Metadata.Tags = tags[link.ID]
+	return link, nil
+}
+
+// encodeLinkCursor makes an opaque cursor pointing after the link
+func encodeLinkCursor(link models.Link) string {
+	raw := strconv.FormatInt(link.CreatedAt.UnixMicro(), 10) + ":" + strconv.FormatInt(link.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeLinkCursor returns the position from the link cursor, the zero cursor for empty cursor
+func decodeLinkCursor(cursor string) (models.LinkCursor, error) {
+	if cursor == "" {
+		return models.LinkCursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return models.LinkCursor{}, err
+	}
+
+	createdAt, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return models.LinkCursor{}, strconv.ErrSyntax
+	}
+	micros, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return models.LinkCursor{}, err
+	}
+	after := models.LinkCursor{CreatedAt: time.UnixMicro(micros).UTC()}
+	after.ID, err = strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return models.LinkCursor{}, err
+	}
+	if after.ID <= 0 {
+		return models.LinkCursor{}, strconv.ErrRange
+	}
+
+	return after, nil
+}
+
+// encodeCursor makes an opaque cursor pointing after the ID
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+// decodeCursor returns the ID from the cursor, 0 for empty cursor
+func decodeCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if id <= 0 {
+		return 0, strconv.ErrRange
+	}
+
+	return id, nil
+}
@@ -0,0 +1,482 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_ListURLs(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Filter         models.LinkFilter
+		Cursor         string
+		PageSize       int
+		ExceptedLinks  []models.Link
+		ExceptedCursor string
+		ExceptedErr    error
+		SetUpMocks     func(db *mockpostgresRepo)
+	}{
+		{
+			Name:     "Last page",
+			Filter:   models.LinkFilter{Owner: "telegram:1"},
+			PageSize: 2,
+			ExceptedLinks: []models.Link{
+				{ID: 62, Code: "10", URL: "https://google.com", DomainID: 1, Domain: "sh.some", Owner: "telegram:1"},
+				{
+					ID: 5, Code: "docs", URL: "https://go.dev", DomainID: 2, Domain: "go.some", Alias: "docs", Owner: "telegram:1",
+					Metadata: models.LinkMetadata{Tags: []string{"docs", "go"}},
+				},
+			},
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("ListLinks", mock.Anything, models.LinkFilter{Owner: "telegram:1"}, int32(0), models.LinkCursor{}, int32(3)).
+					Return([]models.Link{
+						{ID: 62, URL: "https://google.com", DomainID: 1, Owner: "telegram:1"},
+						{ID: 5, URL: "https://go.dev", DomainID: 2, Alias: "docs", Owner: "telegram:1"},
+					}, nil).Once()
+				db.On("GetLinkTags", mock.Anything, []int64{62, 5}).
+					Return(map[int64][]string{5: {"docs", "go"}}, nil).Once()
+			},
+		},
+		{
+			Name:     "Next page",
+			Filter:   models.LinkFilter{Domain: "go.some"},
+			Cursor:   encodeLinkCursor(models.Link{ID: 100, CreatedAt: time.UnixMicro(1700000000000002).UTC()}),
+			PageSize: 1,
+			ExceptedLinks: []models.Link{
+				{ID: 62, Code: "10", URL: "https://google.com", DomainID: 2, Domain: "go.some", CreatedAt: time.UnixMicro(1700000000000001).UTC()},
+			},
+			ExceptedCursor: encodeLinkCursor(models.Link{ID: 62, CreatedAt: time.UnixMicro(1700000000000001).UTC()}),
+			SetUpMocks: func(db *mockpostgresRepo) {
+				after := models.LinkCursor{CreatedAt: time.UnixMicro(1700000000000002).UTC(), ID: 100}
+				db.On("ListLinks", mock.Anything, models.LinkFilter{Domain: "go.some"}, int32(2), after, int32(2)).
+					Return([]models.Link{
+						{ID: 62, URL: "https://google.com", DomainID: 2, CreatedAt: time.UnixMicro(1700000000000001).UTC()},
+						{ID: 61, URL: "https://google.com", DomainID: 2, CreatedAt: time.UnixMicro(1700000000000000).UTC()},
+					}, nil).Once()
+				db.On("GetLinkTags", mock.Anything, []int64{62}).
+					Return(map[int64][]string{}, nil).Once()
+			},
+		},
+		{
+			Name:          "Default page size",
+			ExceptedLinks: []models.Link{},
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("ListLinks", mock.Anything, models.LinkFilter{}, int32(0), models.LinkCursor{}, int32(defaultPageSize+1)).
+					Return([]models.Link{}, nil).Once()
+				db.On("GetLinkTags", mock.Anything, []int64{}).
+					Return(map[int64][]string{}, nil).Once()
+			},
+		},
+		{
+			Name:     "Tagged links",
+			Filter:   models.LinkFilter{Tags: []string{"docs"}},
+			PageSize: 10,
+			ExceptedLinks: []models.Link{
+				{ID: 5, Code: "5", URL: "https://go.dev", DomainID: 1, Domain: "sh.some", Metadata: models.LinkMetadata{Title: "Go", Tags: []string{"docs"}}},
+			},
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("ListLinks", mock.Anything, models.LinkFilter{Tags: []string{"docs"}}, int32(0), models.LinkCursor{}, int32(11)).
+					Return([]models.Link{
+						{ID: 5, URL: "https://go.dev", DomainID: 1, Metadata: models.LinkMetadata{Title: "Go"}},
+					}, nil).Once()
+				db.On("GetLinkTags", mock.Anything, []int64{5}).
+					Return(map[int64][]string{5: {"docs"}}, nil).Once()
+			},
+		},
+		{
+			Name:        "Bad cursor",
+			Cursor:      "not a cursor",
+			ExceptedErr: status.Error(codes.InvalidArgument, "bad cursor"),
+			SetUpMocks:  func(db *mockpostgresRepo) {},
+		},
+		{
+			Name:        "Cursor without creation time",
+			Cursor:      encodeCursor(100),
+			ExceptedErr: status.Error(codes.InvalidArgument, "bad cursor"),
+			SetUpMocks:  func(db *mockpostgresRepo) {},
+		},
+		{
+			Name:        "Unknown domain",
+			Filter:      models.LinkFilter{Domain: "unknown.some"},
+			ExceptedErr: status.Error(codes.InvalidArgument, "unknown domain"),
+			SetUpMocks:  func(db *mockpostgresRepo) {},
+		},
+		{
+			Name:        "Failed to list",
+			ExceptedErr: status.Error(codes.Internal, "failed to list links"),
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("ListLinks", mock.Anything, models.LinkFilter{}, int32(0), models.LinkCursor{}, int32(defaultPageSize+1)).
+					Return(nil, errors.New("some unknown error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockPostgres := mockpostgresRepo{}
+			mockPostgres.On("ListDomains", mock.Anything).
+				Return(testDomains, nil).Maybe()
+
+			tt.SetUpMocks(&mockPostgres)
+
+			tracerProvider := noop.NewTracerProvider()
+			tracer := tracerProvider.Tracer("")
+
+			service := New(
+				&mockPostgres,
+				nil,
+				slog.New(
+					slog.NewTextHandler(
+						os.Stdout,
+						&slog.HandlerOptions{},
+					),
+				),
+				nil,
+				tracer,
+				nil,
+				nil,
+				testAnonymizer,
+				10,
+			)
+
+			links, cursor, err := service.ListURLs(context.Background(), tt.Filter, tt.Cursor, tt.PageSize)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedLinks, links)
+			assert.Equal(t, tt.ExceptedCursor, cursor)
+
+			mockPostgres.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_DeleteURL(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Host        string
+		ShortCode   string
+		ExceptedErr error
+		SetUpMocks  func(db *mockpostgresRepo, cache *mockvalkeyRepo)
+	}{
+		{
+			Name:      "Successfully Delete by code",
+			ShortCode: "3a",
+			SetUpMocks: func(db *mockpostgresRepo, cache *mockvalkeyRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://go.dev"}, nil).Once()
+				db.On("DeleteLink", mock.Anything, int64(222)).
+					Return(nil).Once()
+				cache.On("DeleteLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(nil).Once()
+			},
+		},
+		{
+			Name:      "Deleted even if cache fails",
+			Host:      "go.some",
+			ShortCode: "docs",
+			SetUpMocks: func(db *mockpostgresRepo, cache *mockvalkeyRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(2), "docs").
+					Return(&models.Link{ID: 5, DomainID: 2, Alias: "docs"}, nil).Once()
+				db.On("DeleteLink", mock.Anything, int64(5)).
+					Return(nil).Once()
+				cache.On("DeleteLinkByCode", mock.Anything, "go.some", "docs").
+					Return(errors.New("some unknown error")).Once()
+			},
+		},
+		{
+			Name:        "Link not found",
+			ShortCode:   "not-a-code",
+			ExceptedErr: status.Error(codes.NotFound, "short not found"),
+			SetUpMocks: func(db *mockpostgresRepo, cache *mockvalkeyRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "not-a-code").
+					Return(nil, sql.ErrNoRows).Once()
+			},
+		},
+		{
+			Name:        "Failed to delete",
+			ShortCode:   "docs",
+			ExceptedErr: status.Error(codes.Internal, "failed to delete link"),
+			SetUpMocks: func(db *mockpostgresRepo, cache *mockvalkeyRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "docs").
+					Return(&models.Link{ID: 5, DomainID: 1, Alias: "docs"}, nil).Once()
+				db.On("DeleteLink", mock.Anything, int64(5)).
+					Return(errors.New("some unknown error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockPostgres := mockpostgresRepo{}
+			mockPostgres.On("ListDomains", mock.Anything).
+				Return(testDomains, nil).Maybe()
+			mockValkey := mockvalkeyRepo{}
+
+			tt.SetUpMocks(&mockPostgres, &mockValkey)
+
+			service := newLinksTestService(&mockPostgres, &mockValkey)
+
+			err := service.DeleteURL(context.Background(), tt.Host, tt.ShortCode)
+			assert.Equal(t, tt.ExceptedErr, err)
+
+			mockPostgres.AssertExpectations(t)
+			mockValkey.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_DisableURL(t *testing.T) {
+	t.Run("Expires active link", func(t *testing.T) {
+		mockPostgres := mockpostgresRepo{}
+		mockPostgres.On("ListDomains", mock.Anything).
+			Return(testDomains, nil).Maybe()
+		mockPostgres.On("GetLinkByAlias", mock.Anything, int32(1), "docs").
+			Return(&models.Link{ID: 5, DomainID: 1, Alias: "docs"}, nil).Once()
+		mockPostgres.On("SetLinkExpiresAt", mock.Anything, int64(5), mock.AnythingOfType("time.Time")).
+			Return(nil).Once()
+		mockPostgres.On("GetLinkTags", mock.Anything, []int64{5}).
+			Return(map[int64][]string{5: {"go"}}, nil).Once()
+		mockValkey := mockvalkeyRepo{}
+		mockValkey.On("DeleteLinkByCode", mock.Anything, "sh.some", "docs").
+			Return(nil).Once()
+
+		service := newLinksTestService(&mockPostgres, &mockValkey)
+
+		link, err := service.DisableURL(context.Background(), "", "docs")
+		assert.NoError(t, err)
+		assert.False(t, link.IsActive(time.Now()))
+		assert.Equal(t, []string{"go"}, link.Metadata.Tags)
+
+		mockPostgres.AssertExpectations(t)
+		mockValkey.AssertExpectations(t)
+	})
+
+	t.Run("Keeps expired link", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+
+		mockPostgres := mockpostgresRepo{}
+		mockPostgres.On("ListDomains", mock.Anything).
+			Return(testDomains, nil).Maybe()
+		mockPostgres.On("GetLinkByAlias", mock.Anything, int32(1), "docs").
+			Return(&models.Link{ID: 5, DomainID: 1, Alias: "docs", ExpiresAt: expiresAt}, nil).Once()
+		mockPostgres.On("GetLinkTags", mock.Anything, []int64{5}).
+			Return(map[int64][]string{}, nil).Once()
+		mockValkey := mockvalkeyRepo{}
+		mockValkey.On("DeleteLinkByCode", mock.Anything, "sh.some", "docs").
+			Return(nil).Once()
+
+		service := newLinksTestService(&mockPostgres, &mockValkey)
+
+		link, err := service.DisableURL(context.Background(), "", "docs")
+		assert.NoError(t, err)
+		assert.Equal(t, expiresAt, link.ExpiresAt)
+
+		mockPostgres.AssertExpectations(t)
+		mockValkey.AssertExpectations(t)
+	})
+}
+
+func Test_RetargetURL(t *testing.T) {
+	mockPostgres := mockpostgresRepo{}
+	mockPostgres.On("ListDomains", mock.Anything).
+		Return(testDomains, nil).Maybe()
+	mockPostgres.On("GetLinkByAlias", mock.Anything, int32(2), "docs").
+		Return(&models.Link{ID: 5, DomainID: 2, Alias: "docs", URL: "https://go.dev"}, nil).Once()
+	mockPostgres.On("SetLinkURL", mock.Anything, int64(5), "https://pkg.go.dev").
+		Return(nil).Once()
+	mockPostgres.On("GetLinkTags", mock.Anything, []int64{5}).
+		Return(map[int64][]string{}, nil).Once()
+	mockValkey := mockvalkeyRepo{}
+	mockValkey.On("DeleteLinkByCode", mock.Anything, "go.some", "docs").
+		Return(nil).Once()
+
+	service := newLinksTestService(&mockPostgres, &mockValkey)
+
+	link, err := service.RetargetURL(context.Background(), "go.some", "docs", "https://pkg.go.dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://pkg.go.dev", link.URL)
+	assert.Equal(t, "go.some", link.Domain)
+
+	mockPostgres.AssertExpectations(t)
+	mockValkey.AssertExpectations(t)
+}
+
+func Test_InvalidateLink(t *testing.T) {
+	mockPostgres := mockpostgresRepo{}
+	mockValkey := mockvalkeyRepo{}
+	mockValkey.On("DeleteLinkByCode", mock.Anything, "go.some", "docs").
+		Return(nil).Once()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	mockKafka := mockkafkaWriter{}
+	isInvalidation := mock.MatchedBy(func(msgs []kafka.Message) bool {
+		if len(msgs) != 1 || msgs[0].Topic != "shortener.invalidated" || string(msgs[0].Key) != "go.some/docs" {
+			return false
+		}
+		var msg models.KafkaMessageInvalidated
+		if err := json.Unmarshal(msgs[0].Value, &msg); err != nil {
+			return false
+		}
+		return msg.Code == "docs" && msg.Domain == "go.some" && !msg.InvalidatedAt.IsZero()
+	})
+	mockKafka.On("WriteMessages", mock.Anything, isInvalidation).
+		Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+
+	service := newLinksTestService(&mockPostgres, &mockValkey)
+	service.kw = &mockKafka
+
+	service.invalidateLink(context.Background(), &models.Link{ID: 5, Code: "docs", Domain: "go.some"})
+	wg.Wait()
+
+	mockValkey.AssertExpectations(t)
+	mockKafka.AssertExpectations(t)
+}
+
+func Test_SetDefaultDomain(t *testing.T) {
+	t.Run("Moved links are dropped from cache", func(t *testing.T) {
+		mockPostgres := mockpostgresRepo{}
+		mockPostgres.On("SetDefaultDomain", mock.Anything, "sh.some").
+			Return(&models.Domain{ID: 1, Host: "sh.some", IsDefault: true}, []models.Link{
+				{ID: 222, DomainID: 1},
+				{ID: 5, DomainID: 1, Alias: "docs"},
+			}, nil).Once()
+		mockPostgres.On("ListDomains", mock.Anything).
+			Return(testDomains, nil).Once()
+		mockValkey := mockvalkeyRepo{}
+		mockValkey.On("DeleteLinkByCode", mock.Anything, "sh.some", "3a").
+			Return(nil).Once()
+		mockValkey.On("DeleteLinkByCode", mock.Anything, "sh.some", "docs").
+			Return(nil).Once()
+
+		service := newLinksTestService(&mockPostgres, &mockValkey)
+
+		domain, err := service.SetDefaultDomain(context.Background(), "SH.some.")
+		assert.NoError(t, err)
+		assert.Equal(t, &models.Domain{ID: 1, Host: "sh.some", IsDefault: true}, domain)
+
+		mockPostgres.AssertExpectations(t)
+		mockValkey.AssertExpectations(t)
+	})
+
+	t.Run("Failed to set", func(t *testing.T) {
+		mockPostgres := mockpostgresRepo{}
+		mockPostgres.On("SetDefaultDomain", mock.Anything, "sh.some").
+			Return(nil, nil, errors.New("some unknown error")).Once()
+		mockValkey := mockvalkeyRepo{}
+
+		service := newLinksTestService(&mockPostgres, &mockValkey)
+
+		_, err := service.SetDefaultDomain(context.Background(), "sh.some")
+		assert.Equal(t, status.Error(codes.Internal, "failed to set default domain"), err)
+
+		mockPostgres.AssertExpectations(t)
+	})
+}
+
+func Test_GetCacheEntry(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Host        string
+		ShortCode   string
+		ExceptedErr error
+		ExceptedTTL time.Duration
+		ExceptedURL string
+		SetUpMocks  func(cache *mockvalkeyRepo)
+	}{
+		{
+			Name:        "Cached",
+			ShortCode:   "3a",
+			ExceptedTTL: time.Minute,
+			ExceptedURL: "https://go.dev",
+			SetUpMocks: func(cache *mockvalkeyRepo) {
+				cache.On("GetCacheEntry", mock.Anything, "sh.some", "3a").
+					Return(&models.Link{Code: "3a", URL: "https://go.dev"}, time.Minute, nil).Once()
+			},
+		},
+		{
+			Name:      "Not cached",
+			Host:      "go.some",
+			ShortCode: "3a",
+			SetUpMocks: func(cache *mockvalkeyRepo) {
+				cache.On("GetCacheEntry", mock.Anything, "go.some", "3a").
+					Return(nil, time.Duration(0), nil).Once()
+			},
+		},
+		{
+			Name:        "Failed to get",
+			ShortCode:   "3a",
+			ExceptedErr: status.Error(codes.Internal, "failed to get cache entry"),
+			SetUpMocks: func(cache *mockvalkeyRepo) {
+				cache.On("GetCacheEntry", mock.Anything, "sh.some", "3a").
+					Return(nil, time.Duration(0), errors.New("some unknown error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockPostgres := mockpostgresRepo{}
+			mockPostgres.On("ListDomains", mock.Anything).
+				Return(testDomains, nil).Maybe()
+			mockValkey := mockvalkeyRepo{}
+
+			tt.SetUpMocks(&mockValkey)
+
+			service := newLinksTestService(&mockPostgres, &mockValkey)
+
+			link, ttl, err := service.GetCacheEntry(context.Background(), tt.Host, tt.ShortCode)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedTTL, ttl)
+			if tt.ExceptedURL != "" {
+				assert.Equal(t, tt.ExceptedURL, link.URL)
+			} else {
+				assert.Nil(t, link)
+			}
+
+			mockValkey.AssertExpectations(t)
+		})
+	}
+}
+
+func newLinksTestService(db *mockpostgresRepo, cache *mockvalkeyRepo) *Service {
+	tracerProvider := noop.NewTracerProvider()
+	tracer := tracerProvider.Tracer("")
+
+	// Changed links are invalidated in background
+	kafkaWriter := &mockkafkaWriter{}
+	kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+		Return(nil).Maybe()
+
+	return New(
+		db,
+		cache,
+		slog.New(
+			slog.NewTextHandler(
+				os.Stdout,
+				&slog.HandlerOptions{},
+			),
+		),
+		kafkaWriter,
+		tracer,
+		nil,
+		nil,
+		testAnonymizer,
+		10,
+	)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetLinkMetadata replaces title, notes and tags of the link by code in the domain
+func (s *Service) SetLinkMetadata(ctx context.Context, host, short string, metadata models.LinkMetadata) error {
+	ctx, span := s.t.Start(ctx, "SetLinkMetadata")
+	defer span.End()
+
+	link, err := s.lookupLink(ctx, host, short)
+	if err != nil {
+		return err
+	}
+
+	err = s.pr.SetLinkMetadata(ctx, link.ID, metadata)
+	if errors.Is(err, sql.ErrNoRows) {
+		return status.Error(codes.NotFound, "short not found")
+	} else if err != nil {
+		s.l.Error("failed to set link metadata", "error", err)
+		return status.Error(codes.Internal, "failed to set link metadata")
+	}
+
+	return nil
+}
+
+// GetLinkMetadata returns title, notes and tags of the link by code in the domain.
+// The link must be created by the owner if it is set.
+func (s *Service) GetLinkMetadata(ctx context.Context, host, short, owner string) (*models.LinkMetadata, error) {
+	ctx, span := s.t.Start(ctx, "GetLinkMetadata")
+	defer span.End()
+
+	link, err := s.lookupLink(ctx, host, short)
+	if err != nil {
+		return nil, err
+	}
+	// Links of other owners are not found, so their codes are not revealed either
+	if owner != "" && link.Owner != owner {
+		return nil, status.Error(codes.NotFound, "short not found")
+	}
+
+	tags, err := s.pr.GetLinkTags(ctx, []int64{link.ID})
+	if err != nil {
+		s.l.Error("failed to get tags of link", "error", err)
+		return nil, status.Error(codes.Internal, "failed to get link metadata")
+	}
+
+	metadata := link.Metadata
+	metadata.Tags = tags[link.ID]
+	return &metadata, nil
+}
+
+// ListTags returns the tags in use with the number of their links, only of the links of the owner if it is set
+func (s *Service) ListTags(ctx context.Context, owner string) ([]models.TagCount, error) {
+	ctx, span := s.t.Start(ctx, "ListTags")
+	defer span.End()
+
+	tags, err := s.pr.ListTags(ctx, owner)
+	if err != nil {
+		s.l.Error("failed to list tags", "error", err)
+		return nil, status.Error(codes.Internal, "failed to list tags")
+	}
+
+	return tags, nil
+}
+
+// lookupLink returns the link by code in the registered domain from the db, whether it is active or not
+func (s *Service) lookupLink(ctx context.Context, host, short string) (*models.Link, error) {
+	domain, err := s.resolveDomain(ctx, host, false)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := s.findLink(ctx, domain, short)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "short not found")
+	} else if err != nil {
+		s.l.Error("failed to get short by url", "error", err)
+		return nil, status.Error(codes.Internal, "failed to get short by url")
+	}
+
+	return link, nil
+}
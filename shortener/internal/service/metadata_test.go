@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func Test_SetLinkMetadata(t *testing.T) {
+	metadata := models.LinkMetadata{Title: "Go", Notes: "Docs of the Go", Tags: []string{"docs", "go"}}
+
+	tests := []struct {
+		Name        string
+		Host        string
+		ShortCode   string
+		ExceptedErr error
+		SetUpMocks  func(db *mockpostgresRepo)
+	}{
+		{
+			Name:      "Successfully Set by code",
+			ShortCode: "3a",
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://go.dev"}, nil).Once()
+				db.On("SetLinkMetadata", mock.Anything, int64(222), metadata).
+					Return(nil).Once()
+			},
+		},
+		{
+			Name:      "Successfully Set by alias on domain",
+			Host:      "go.some",
+			ShortCode: "docs",
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(2), "docs").
+					Return(&models.Link{ID: 5, DomainID: 2, Alias: "docs", URL: "https://go.dev"}, nil).Once()
+				db.On("SetLinkMetadata", mock.Anything, int64(5), metadata).
+					Return(nil).Once()
+			},
+		},
+		{
+			Name:        "Unknown domain",
+			Host:        "unknown.some",
+			ShortCode:   "3a",
+			ExceptedErr: status.Error(codes.InvalidArgument, "unknown domain"),
+			SetUpMocks:  func(db *mockpostgresRepo) {},
+		},
+		{
+			Name:        "Link not found",
+			ShortCode:   "not-a-code",
+			ExceptedErr: status.Error(codes.NotFound, "short not found"),
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "not-a-code").
+					Return(nil, sql.ErrNoRows).Once()
+			},
+		},
+		{
+			Name:        "Failed to set",
+			ShortCode:   "docs",
+			ExceptedErr: status.Error(codes.Internal, "failed to set link metadata"),
+			SetUpMocks: func(db *mockpostgresRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "docs").
+					Return(&models.Link{ID: 5, DomainID: 1, Alias: "docs"}, nil).Once()
+				db.On("SetLinkMetadata", mock.Anything, int64(5), metadata).
+					Return(errors.New("some unknown error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockPostgres := mockpostgresRepo{}
+			mockPostgres.On("ListDomains", mock.Anything).
+				Return(testDomains, nil).Maybe()
+
+			tt.SetUpMocks(&mockPostgres)
+
+			service := newMetadataTestService(&mockPostgres)
+
+			err := service.SetLinkMetadata(context.Background(), tt.Host, tt.ShortCode, metadata)
+			assert.Equal(t, tt.ExceptedErr, err)
+
+			mockPostgres.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_GetLinkMetadata(t *testing.T) {
+	mockPostgres := mockpostgresRepo{}
+	mockPostgres.On("ListDomains", mock.Anything).
+		Return(testDomains, nil).Maybe()
+	mockPostgres.On("GetLinkByAlias", mock.Anything, int32(1), "docs").
+		Return(&models.Link{ID: 5, DomainID: 1, Alias: "docs", Owner: "telegram:1", Metadata: models.LinkMetadata{Title: "Go", Notes: "Docs"}}, nil).Times(3)
+	mockPostgres.On("GetLinkTags", mock.Anything, []int64{5}).
+		Return(map[int64][]string{5: {"docs", "go"}}, nil).Twice()
+
+	service := newMetadataTestService(&mockPostgres)
+
+	// Any link without the owner
+	metadata, err := service.GetLinkMetadata(context.Background(), "", "docs", "")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.LinkMetadata{Title: "Go", Notes: "Docs", Tags: []string{"docs", "go"}}, metadata)
+
+	metadata, err = service.GetLinkMetadata(context.Background(), "", "docs", "telegram:1")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.LinkMetadata{Title: "Go", Notes: "Docs", Tags: []string{"docs", "go"}}, metadata)
+
+	_, err = service.GetLinkMetadata(context.Background(), "", "docs", "telegram:2")
+	assert.Equal(t, status.Error(codes.NotFound, "short not found"), err, "links of other owners are not found")
+
+	mockPostgres.AssertExpectations(t)
+}
+
+func newMetadataTestService(db *mockpostgresRepo) *Service {
+	tracerProvider := noop.NewTracerProvider()
+	tracer := tracerProvider.Tracer("")
+
+	return New(
+		db,
+		nil,
+		slog.New(
+			slog.NewTextHandler(
+				os.Stdout,
+				&slog.HandlerOptions{},
+			),
+		),
+		nil,
+		tracer,
+		nil,
+		nil,
+		testAnonymizer,
+		10,
+	)
+}
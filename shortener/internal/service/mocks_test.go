@@ -40,53 +40,55 @@ func (_m *mockpostgresRepo) EXPECT() *mockpostgresRepo_Expecter {
 	return &mockpostgresRepo_Expecter{mock: &_m.Mock}
 }
 
-// GetID provides a mock function for the type mockpostgresRepo
-func (_mock *mockpostgresRepo) GetID(ctx context.Context, url string) (int64, error) {
-	ret := _mock.Called(ctx, url)
+// CreateAPIKey provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	ret := _mock.Called(ctx, key)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetID")
+		panic("no return value specified for CreateAPIKey")
 	}
 
-	var r0 int64
+	var r0 *models.APIKey
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
-		return returnFunc(ctx, url)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.APIKey) (*models.APIKey, error)); ok {
+		return returnFunc(ctx, key)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) int64); ok {
-		r0 = returnFunc(ctx, url)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.APIKey) *models.APIKey); ok {
+		r0 = returnFunc(ctx, key)
 	} else {
-		r0 = ret.Get(0).(int64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.APIKey)
+		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, url)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *models.APIKey) error); ok {
+		r1 = returnFunc(ctx, key)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// mockpostgresRepo_GetID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetID'
-type mockpostgresRepo_GetID_Call struct {
+// mockpostgresRepo_CreateAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateAPIKey'
+type mockpostgresRepo_CreateAPIKey_Call struct {
 	*mock.Call
 }
 
-// GetID is a helper method to define mock.On call
+// CreateAPIKey is a helper method to define mock.On call
 //   - ctx context.Context
-//   - url string
-func (_e *mockpostgresRepo_Expecter) GetID(ctx interface{}, url interface{}) *mockpostgresRepo_GetID_Call {
-	return &mockpostgresRepo_GetID_Call{Call: _e.mock.On("GetID", ctx, url)}
+//   - key *models.APIKey
+func (_e *mockpostgresRepo_Expecter) CreateAPIKey(ctx interface{}, key interface{}) *mockpostgresRepo_CreateAPIKey_Call {
+	return &mockpostgresRepo_CreateAPIKey_Call{Call: _e.mock.On("CreateAPIKey", ctx, key)}
 }
 
-func (_c *mockpostgresRepo_GetID_Call) Run(run func(ctx context.Context, url string)) *mockpostgresRepo_GetID_Call {
+func (_c *mockpostgresRepo_CreateAPIKey_Call) Run(run func(ctx context.Context, key *models.APIKey)) *mockpostgresRepo_CreateAPIKey_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 *models.APIKey
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(*models.APIKey)
 		}
 		run(
 			arg0,
@@ -96,55 +98,114 @@ func (_c *mockpostgresRepo_GetID_Call) Run(run func(ctx context.Context, url str
 	return _c
 }
 
-func (_c *mockpostgresRepo_GetID_Call) Return(n int64, err error) *mockpostgresRepo_GetID_Call {
-	_c.Call.Return(n, err)
+func (_c *mockpostgresRepo_CreateAPIKey_Call) Return(aPIKey *models.APIKey, err error) *mockpostgresRepo_CreateAPIKey_Call {
+	_c.Call.Return(aPIKey, err)
 	return _c
 }
 
-func (_c *mockpostgresRepo_GetID_Call) RunAndReturn(run func(ctx context.Context, url string) (int64, error)) *mockpostgresRepo_GetID_Call {
+func (_c *mockpostgresRepo_CreateAPIKey_Call) RunAndReturn(run func(ctx context.Context, key *models.APIKey) (*models.APIKey, error)) *mockpostgresRepo_CreateAPIKey_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// GetURL provides a mock function for the type mockpostgresRepo
-func (_mock *mockpostgresRepo) GetURL(ctx context.Context, id int64) (string, error) {
-	ret := _mock.Called(ctx, id)
+// CreateDomain provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) CreateDomain(ctx context.Context, host string) (*models.Domain, error) {
+	ret := _mock.Called(ctx, host)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetURL")
+		panic("no return value specified for CreateDomain")
 	}
 
-	var r0 string
+	var r0 *models.Domain
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) (string, error)); ok {
-		return returnFunc(ctx, id)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Domain, error)); ok {
+		return returnFunc(ctx, host)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) string); ok {
-		r0 = returnFunc(ctx, id)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Domain); ok {
+		r0 = returnFunc(ctx, host)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Domain)
+		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = returnFunc(ctx, id)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, host)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// mockpostgresRepo_GetURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetURL'
-type mockpostgresRepo_GetURL_Call struct {
+// mockpostgresRepo_CreateDomain_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateDomain'
+type mockpostgresRepo_CreateDomain_Call struct {
+	*mock.Call
+}
+
+// CreateDomain is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+func (_e *mockpostgresRepo_Expecter) CreateDomain(ctx interface{}, host interface{}) *mockpostgresRepo_CreateDomain_Call {
+	return &mockpostgresRepo_CreateDomain_Call{Call: _e.mock.On("CreateDomain", ctx, host)}
+}
+
+func (_c *mockpostgresRepo_CreateDomain_Call) Run(run func(ctx context.Context, host string)) *mockpostgresRepo_CreateDomain_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_CreateDomain_Call) Return(domain *models.Domain, err error) *mockpostgresRepo_CreateDomain_Call {
+	_c.Call.Return(domain, err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_CreateDomain_Call) RunAndReturn(run func(ctx context.Context, host string) (*models.Domain, error)) *mockpostgresRepo_CreateDomain_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteLink provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) DeleteLink(ctx context.Context, id int64) error {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteLink")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockpostgresRepo_DeleteLink_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteLink'
+type mockpostgresRepo_DeleteLink_Call struct {
 	*mock.Call
 }
 
-// GetURL is a helper method to define mock.On call
+// DeleteLink is a helper method to define mock.On call
 //   - ctx context.Context
 //   - id int64
-func (_e *mockpostgresRepo_Expecter) GetURL(ctx interface{}, id interface{}) *mockpostgresRepo_GetURL_Call {
-	return &mockpostgresRepo_GetURL_Call{Call: _e.mock.On("GetURL", ctx, id)}
+func (_e *mockpostgresRepo_Expecter) DeleteLink(ctx interface{}, id interface{}) *mockpostgresRepo_DeleteLink_Call {
+	return &mockpostgresRepo_DeleteLink_Call{Call: _e.mock.On("DeleteLink", ctx, id)}
 }
 
-func (_c *mockpostgresRepo_GetURL_Call) Run(run func(ctx context.Context, id int64)) *mockpostgresRepo_GetURL_Call {
+func (_c *mockpostgresRepo_DeleteLink_Call) Run(run func(ctx context.Context, id int64)) *mockpostgresRepo_DeleteLink_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -162,55 +223,57 @@ func (_c *mockpostgresRepo_GetURL_Call) Run(run func(ctx context.Context, id int
 	return _c
 }
 
-func (_c *mockpostgresRepo_GetURL_Call) Return(s string, err error) *mockpostgresRepo_GetURL_Call {
-	_c.Call.Return(s, err)
+func (_c *mockpostgresRepo_DeleteLink_Call) Return(err error) *mockpostgresRepo_DeleteLink_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *mockpostgresRepo_GetURL_Call) RunAndReturn(run func(ctx context.Context, id int64) (string, error)) *mockpostgresRepo_GetURL_Call {
+func (_c *mockpostgresRepo_DeleteLink_Call) RunAndReturn(run func(ctx context.Context, id int64) error) *mockpostgresRepo_DeleteLink_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// StoreURL provides a mock function for the type mockpostgresRepo
-func (_mock *mockpostgresRepo) StoreURL(ctx context.Context, url string) (int64, error) {
-	ret := _mock.Called(ctx, url)
+// GetAPIKeyByHash provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	ret := _mock.Called(ctx, keyHash)
 
 	if len(ret) == 0 {
-		panic("no return value specified for StoreURL")
+		panic("no return value specified for GetAPIKeyByHash")
 	}
 
-	var r0 int64
+	var r0 *models.APIKey
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
-		return returnFunc(ctx, url)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.APIKey, error)); ok {
+		return returnFunc(ctx, keyHash)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) int64); ok {
-		r0 = returnFunc(ctx, url)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.APIKey); ok {
+		r0 = returnFunc(ctx, keyHash)
 	} else {
-		r0 = ret.Get(0).(int64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.APIKey)
+		}
 	}
 	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, url)
+		r1 = returnFunc(ctx, keyHash)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// mockpostgresRepo_StoreURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StoreURL'
-type mockpostgresRepo_StoreURL_Call struct {
+// mockpostgresRepo_GetAPIKeyByHash_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAPIKeyByHash'
+type mockpostgresRepo_GetAPIKeyByHash_Call struct {
 	*mock.Call
 }
 
-// StoreURL is a helper method to define mock.On call
+// GetAPIKeyByHash is a helper method to define mock.On call
 //   - ctx context.Context
-//   - url string
-func (_e *mockpostgresRepo_Expecter) StoreURL(ctx interface{}, url interface{}) *mockpostgresRepo_StoreURL_Call {
-	return &mockpostgresRepo_StoreURL_Call{Call: _e.mock.On("StoreURL", ctx, url)}
+//   - keyHash string
+func (_e *mockpostgresRepo_Expecter) GetAPIKeyByHash(ctx interface{}, keyHash interface{}) *mockpostgresRepo_GetAPIKeyByHash_Call {
+	return &mockpostgresRepo_GetAPIKeyByHash_Call{Call: _e.mock.On("GetAPIKeyByHash", ctx, keyHash)}
 }
 
-func (_c *mockpostgresRepo_StoreURL_Call) Run(run func(ctx context.Context, url string)) *mockpostgresRepo_StoreURL_Call {
+func (_c *mockpostgresRepo_GetAPIKeyByHash_Call) Run(run func(ctx context.Context, keyHash string)) *mockpostgresRepo_GetAPIKeyByHash_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -228,90 +291,143 @@ func (_c *mockpostgresRepo_StoreURL_Call) Run(run func(ctx context.Context, url
 	return _c
 }
 
-func (_c *mockpostgresRepo_StoreURL_Call) Return(n int64, err error) *mockpostgresRepo_StoreURL_Call {
-	_c.Call.Return(n, err)
+func (_c *mockpostgresRepo_GetAPIKeyByHash_Call) Return(aPIKey *models.APIKey, err error) *mockpostgresRepo_GetAPIKeyByHash_Call {
+	_c.Call.Return(aPIKey, err)
 	return _c
 }
 
-func (_c *mockpostgresRepo_StoreURL_Call) RunAndReturn(run func(ctx context.Context, url string) (int64, error)) *mockpostgresRepo_StoreURL_Call {
+func (_c *mockpostgresRepo_GetAPIKeyByHash_Call) RunAndReturn(run func(ctx context.Context, keyHash string) (*models.APIKey, error)) *mockpostgresRepo_GetAPIKeyByHash_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// newMockvalkeyRepo creates a new instance of mockvalkeyRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
-// The first argument is typically a *testing.T value.
-func newMockvalkeyRepo(t interface {
-	mock.TestingT
-	Cleanup(func())
-}) *mockvalkeyRepo {
-	mock := &mockvalkeyRepo{}
-	mock.Mock.Test(t)
+// GetID provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) GetID(ctx context.Context, url string, domainID int32, owner string) (int64, error) {
+	ret := _mock.Called(ctx, url, domainID, owner)
 
-	t.Cleanup(func() { mock.AssertExpectations(t) })
+	if len(ret) == 0 {
+		panic("no return value specified for GetID")
+	}
 
-	return mock
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int32, string) (int64, error)); ok {
+		return returnFunc(ctx, url, domainID, owner)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int32, string) int64); ok {
+		r0 = returnFunc(ctx, url, domainID, owner)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int32, string) error); ok {
+		r1 = returnFunc(ctx, url, domainID, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// mockvalkeyRepo is an autogenerated mock type for the valkeyRepo type
-type mockvalkeyRepo struct {
-	mock.Mock
+// mockpostgresRepo_GetID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetID'
+type mockpostgresRepo_GetID_Call struct {
+	*mock.Call
 }
 
-type mockvalkeyRepo_Expecter struct {
-	mock *mock.Mock
+// GetID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - url string
+//   - domainID int32
+//   - owner string
+func (_e *mockpostgresRepo_Expecter) GetID(ctx interface{}, url interface{}, domainID interface{}, owner interface{}) *mockpostgresRepo_GetID_Call {
+	return &mockpostgresRepo_GetID_Call{Call: _e.mock.On("GetID", ctx, url, domainID, owner)}
 }
 
-func (_m *mockvalkeyRepo) EXPECT() *mockvalkeyRepo_Expecter {
-	return &mockvalkeyRepo_Expecter{mock: &_m.Mock}
+func (_c *mockpostgresRepo_GetID_Call) Run(run func(ctx context.Context, url string, domainID int32, owner string)) *mockpostgresRepo_GetID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int32
+		if args[2] != nil {
+			arg2 = args[2].(int32)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_GetID_Call) Return(n int64, err error) *mockpostgresRepo_GetID_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_GetID_Call) RunAndReturn(run func(ctx context.Context, url string, domainID int32, owner string) (int64, error)) *mockpostgresRepo_GetID_Call {
+	_c.Call.Return(run)
+	return _c
 }
 
-// GetURLByCode provides a mock function for the type mockvalkeyRepo
-func (_mock *mockvalkeyRepo) GetURLByCode(ctx context.Context, code string) (string, error) {
-	ret := _mock.Called(ctx, code)
+// GetLink provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) GetLink(ctx context.Context, id int64) (*models.Link, error) {
+	ret := _mock.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetURLByCode")
+		panic("no return value specified for GetLink")
 	}
 
-	var r0 string
+	var r0 *models.Link
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
-		return returnFunc(ctx, code)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) (*models.Link, error)); ok {
+		return returnFunc(ctx, id)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = returnFunc(ctx, code)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) *models.Link); ok {
+		r0 = returnFunc(ctx, id)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Link)
+		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, code)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = returnFunc(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// mockvalkeyRepo_GetURLByCode_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetURLByCode'
-type mockvalkeyRepo_GetURLByCode_Call struct {
+// mockpostgresRepo_GetLink_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLink'
+type mockpostgresRepo_GetLink_Call struct {
 	*mock.Call
 }
 
-// GetURLByCode is a helper method to define mock.On call
+// GetLink is a helper method to define mock.On call
 //   - ctx context.Context
-//   - code string
-func (_e *mockvalkeyRepo_Expecter) GetURLByCode(ctx interface{}, code interface{}) *mockvalkeyRepo_GetURLByCode_Call {
-	return &mockvalkeyRepo_GetURLByCode_Call{Call: _e.mock.On("GetURLByCode", ctx, code)}
+//   - id int64
+func (_e *mockpostgresRepo_Expecter) GetLink(ctx interface{}, id interface{}) *mockpostgresRepo_GetLink_Call {
+	return &mockpostgresRepo_GetLink_Call{Call: _e.mock.On("GetLink", ctx, id)}
 }
 
-func (_c *mockvalkeyRepo_GetURLByCode_Call) Run(run func(ctx context.Context, code string)) *mockvalkeyRepo_GetURLByCode_Call {
+func (_c *mockpostgresRepo_GetLink_Call) Run(run func(ctx context.Context, id int64)) *mockpostgresRepo_GetLink_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 int64
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(int64)
 		}
 		run(
 			arg0,
@@ -321,59 +437,70 @@ func (_c *mockvalkeyRepo_GetURLByCode_Call) Run(run func(ctx context.Context, co
 	return _c
 }
 
-func (_c *mockvalkeyRepo_GetURLByCode_Call) Return(s string, err error) *mockvalkeyRepo_GetURLByCode_Call {
-	_c.Call.Return(s, err)
+func (_c *mockpostgresRepo_GetLink_Call) Return(link *models.Link, err error) *mockpostgresRepo_GetLink_Call {
+	_c.Call.Return(link, err)
 	return _c
 }
 
-func (_c *mockvalkeyRepo_GetURLByCode_Call) RunAndReturn(run func(ctx context.Context, code string) (string, error)) *mockvalkeyRepo_GetURLByCode_Call {
+func (_c *mockpostgresRepo_GetLink_Call) RunAndReturn(run func(ctx context.Context, id int64) (*models.Link, error)) *mockpostgresRepo_GetLink_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SetTop provides a mock function for the type mockvalkeyRepo
-func (_mock *mockvalkeyRepo) SetTop(ctx context.Context, top models.UnshortenedTop, ttl time.Duration) error {
-	ret := _mock.Called(ctx, top, ttl)
+// GetLinkByAlias provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) GetLinkByAlias(ctx context.Context, domainID int32, alias string) (*models.Link, error) {
+	ret := _mock.Called(ctx, domainID, alias)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SetTop")
+		panic("no return value specified for GetLinkByAlias")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, models.UnshortenedTop, time.Duration) error); ok {
-		r0 = returnFunc(ctx, top, ttl)
+	var r0 *models.Link
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int32, string) (*models.Link, error)); ok {
+		return returnFunc(ctx, domainID, alias)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int32, string) *models.Link); ok {
+		r0 = returnFunc(ctx, domainID, alias)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Link)
+		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int32, string) error); ok {
+		r1 = returnFunc(ctx, domainID, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// mockvalkeyRepo_SetTop_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetTop'
-type mockvalkeyRepo_SetTop_Call struct {
+// mockpostgresRepo_GetLinkByAlias_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLinkByAlias'
+type mockpostgresRepo_GetLinkByAlias_Call struct {
 	*mock.Call
 }
 
-// SetTop is a helper method to define mock.On call
+// GetLinkByAlias is a helper method to define mock.On call
 //   - ctx context.Context
-//   - top models.UnshortenedTop
-//   - ttl time.Duration
-func (_e *mockvalkeyRepo_Expecter) SetTop(ctx interface{}, top interface{}, ttl interface{}) *mockvalkeyRepo_SetTop_Call {
-	return &mockvalkeyRepo_SetTop_Call{Call: _e.mock.On("SetTop", ctx, top, ttl)}
+//   - domainID int32
+//   - alias string
+func (_e *mockpostgresRepo_Expecter) GetLinkByAlias(ctx interface{}, domainID interface{}, alias interface{}) *mockpostgresRepo_GetLinkByAlias_Call {
+	return &mockpostgresRepo_GetLinkByAlias_Call{Call: _e.mock.On("GetLinkByAlias", ctx, domainID, alias)}
 }
 
-func (_c *mockvalkeyRepo_SetTop_Call) Run(run func(ctx context.Context, top models.UnshortenedTop, ttl time.Duration)) *mockvalkeyRepo_SetTop_Call {
+func (_c *mockpostgresRepo_GetLinkByAlias_Call) Run(run func(ctx context.Context, domainID int32, alias string)) *mockpostgresRepo_GetLinkByAlias_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 models.UnshortenedTop
+		var arg1 int32
 		if args[1] != nil {
-			arg1 = args[1].(models.UnshortenedTop)
+			arg1 = args[1].(int32)
 		}
-		var arg2 time.Duration
+		var arg2 string
 		if args[2] != nil {
-			arg2 = args[2].(time.Duration)
+			arg2 = args[2].(string)
 		}
 		run(
 			arg0,
@@ -384,105 +511,1618 @@ func (_c *mockvalkeyRepo_SetTop_Call) Run(run func(ctx context.Context, top mode
 	return _c
 }
 
-func (_c *mockvalkeyRepo_SetTop_Call) Return(err error) *mockvalkeyRepo_SetTop_Call {
-	_c.Call.Return(err)
+func (_c *mockpostgresRepo_GetLinkByAlias_Call) Return(link *models.Link, err error) *mockpostgresRepo_GetLinkByAlias_Call {
+	_c.Call.Return(link, err)
 	return _c
 }
 
-func (_c *mockvalkeyRepo_SetTop_Call) RunAndReturn(run func(ctx context.Context, top models.UnshortenedTop, ttl time.Duration) error) *mockvalkeyRepo_SetTop_Call {
+func (_c *mockpostgresRepo_GetLinkByAlias_Call) RunAndReturn(run func(ctx context.Context, domainID int32, alias string) (*models.Link, error)) *mockpostgresRepo_GetLinkByAlias_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// newMockkafkaWriter creates a new instance of mockkafkaWriter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
-// The first argument is typically a *testing.T value.
-func newMockkafkaWriter(t interface {
-	mock.TestingT
-	Cleanup(func())
-}) *mockkafkaWriter {
-	mock := &mockkafkaWriter{}
-	mock.Mock.Test(t)
-
-	t.Cleanup(func() { mock.AssertExpectations(t) })
-
-	return mock
-}
-
-// mockkafkaWriter is an autogenerated mock type for the kafkaWriter type
-type mockkafkaWriter struct {
-	mock.Mock
-}
-
-type mockkafkaWriter_Expecter struct {
-	mock *mock.Mock
-}
-
-func (_m *mockkafkaWriter) EXPECT() *mockkafkaWriter_Expecter {
-	return &mockkafkaWriter_Expecter{mock: &_m.Mock}
-}
-
-// WriteMessages provides a mock function for the type mockkafkaWriter
-func (_mock *mockkafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
-	var tmpRet mock.Arguments
-	if len(msgs) > 0 {
-		tmpRet = _mock.Called(ctx, msgs)
-	} else {
-		tmpRet = _mock.Called(ctx)
-	}
-	ret := tmpRet
+// GetLinkTags provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) GetLinkTags(ctx context.Context, ids []int64) (map[int64][]string, error) {
+	ret := _mock.Called(ctx, ids)
 
 	if len(ret) == 0 {
-		panic("no return value specified for WriteMessages")
+		panic("no return value specified for GetLinkTags")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, ...kafka.Message) error); ok {
-		r0 = returnFunc(ctx, msgs...)
+	var r0 map[int64][]string
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []int64) (map[int64][]string, error)); ok {
+		return returnFunc(ctx, ids)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []int64) map[int64][]string); ok {
+		r0 = returnFunc(ctx, ids)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int64][]string)
+		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(context.Context, []int64) error); ok {
+		r1 = returnFunc(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// mockkafkaWriter_WriteMessages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WriteMessages'
-type mockkafkaWriter_WriteMessages_Call struct {
+// mockpostgresRepo_GetLinkTags_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLinkTags'
+type mockpostgresRepo_GetLinkTags_Call struct {
 	*mock.Call
 }
 
-// WriteMessages is a helper method to define mock.On call
+// GetLinkTags is a helper method to define mock.On call
 //   - ctx context.Context
-//   - msgs ...kafka.Message
-func (_e *mockkafkaWriter_Expecter) WriteMessages(ctx interface{}, msgs ...interface{}) *mockkafkaWriter_WriteMessages_Call {
-	return &mockkafkaWriter_WriteMessages_Call{Call: _e.mock.On("WriteMessages",
-		append([]interface{}{ctx}, msgs...)...)}
+//   - ids []int64
+func (_e *mockpostgresRepo_Expecter) GetLinkTags(ctx interface{}, ids interface{}) *mockpostgresRepo_GetLinkTags_Call {
+	return &mockpostgresRepo_GetLinkTags_Call{Call: _e.mock.On("GetLinkTags", ctx, ids)}
 }
 
-func (_c *mockkafkaWriter_WriteMessages_Call) Run(run func(ctx context.Context, msgs ...kafka.Message)) *mockkafkaWriter_WriteMessages_Call {
+func (_c *mockpostgresRepo_GetLinkTags_Call) Run(run func(ctx context.Context, ids []int64)) *mockpostgresRepo_GetLinkTags_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 []kafka.Message
-		var variadicArgs []kafka.Message
-		if len(args) > 1 {
-			variadicArgs = args[1].([]kafka.Message)
+		var arg1 []int64
+		if args[1] != nil {
+			arg1 = args[1].([]int64)
 		}
-		arg1 = variadicArgs
 		run(
 			arg0,
-			arg1...,
+			arg1,
 		)
 	})
 	return _c
 }
 
-func (_c *mockkafkaWriter_WriteMessages_Call) Return(err error) *mockkafkaWriter_WriteMessages_Call {
-	_c.Call.Return(err)
+func (_c *mockpostgresRepo_GetLinkTags_Call) Return(int64ToStrings map[int64][]string, err error) *mockpostgresRepo_GetLinkTags_Call {
+	_c.Call.Return(int64ToStrings, err)
 	return _c
 }
 
-func (_c *mockkafkaWriter_WriteMessages_Call) RunAndReturn(run func(ctx context.Context, msgs ...kafka.Message) error) *mockkafkaWriter_WriteMessages_Call {
+func (_c *mockpostgresRepo_GetLinkTags_Call) RunAndReturn(run func(ctx context.Context, ids []int64) (map[int64][]string, error)) *mockpostgresRepo_GetLinkTags_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAPIKeys provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAPIKeys")
+	}
+
+	var r0 []models.APIKey
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) ([]models.APIKey, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) []models.APIKey); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.APIKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockpostgresRepo_ListAPIKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAPIKeys'
+type mockpostgresRepo_ListAPIKeys_Call struct {
+	*mock.Call
+}
+
+// ListAPIKeys is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *mockpostgresRepo_Expecter) ListAPIKeys(ctx interface{}) *mockpostgresRepo_ListAPIKeys_Call {
+	return &mockpostgresRepo_ListAPIKeys_Call{Call: _e.mock.On("ListAPIKeys", ctx)}
+}
+
+func (_c *mockpostgresRepo_ListAPIKeys_Call) Run(run func(ctx context.Context)) *mockpostgresRepo_ListAPIKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_ListAPIKeys_Call) Return(aPIKeys []models.APIKey, err error) *mockpostgresRepo_ListAPIKeys_Call {
+	_c.Call.Return(aPIKeys, err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_ListAPIKeys_Call) RunAndReturn(run func(ctx context.Context) ([]models.APIKey, error)) *mockpostgresRepo_ListAPIKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAuditEvents provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) ListAuditEvents(ctx context.Context, filter models.AuditFilter, cursor int64, limit int32) ([]models.AuditEvent, error) {
+	ret := _mock.Called(ctx, filter, cursor, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAuditEvents")
+	}
+
+	var r0 []models.AuditEvent
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.AuditFilter, int64, int32) ([]models.AuditEvent, error)); ok {
+		return returnFunc(ctx, filter, cursor, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.AuditFilter, int64, int32) []models.AuditEvent); ok {
+		r0 = returnFunc(ctx, filter, cursor, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AuditEvent)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, models.AuditFilter, int64, int32) error); ok {
+		r1 = returnFunc(ctx, filter, cursor, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockpostgresRepo_ListAuditEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAuditEvents'
+type mockpostgresRepo_ListAuditEvents_Call struct {
+	*mock.Call
+}
+
+// ListAuditEvents is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter models.AuditFilter
+//   - cursor int64
+//   - limit int32
+func (_e *mockpostgresRepo_Expecter) ListAuditEvents(ctx interface{}, filter interface{}, cursor interface{}, limit interface{}) *mockpostgresRepo_ListAuditEvents_Call {
+	return &mockpostgresRepo_ListAuditEvents_Call{Call: _e.mock.On("ListAuditEvents", ctx, filter, cursor, limit)}
+}
+
+func (_c *mockpostgresRepo_ListAuditEvents_Call) Run(run func(ctx context.Context, filter models.AuditFilter, cursor int64, limit int32)) *mockpostgresRepo_ListAuditEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 models.AuditFilter
+		if args[1] != nil {
+			arg1 = args[1].(models.AuditFilter)
+		}
+		var arg2 int64
+		if args[2] != nil {
+			arg2 = args[2].(int64)
+		}
+		var arg3 int32
+		if args[3] != nil {
+			arg3 = args[3].(int32)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_ListAuditEvents_Call) Return(auditEvents []models.AuditEvent, err error) *mockpostgresRepo_ListAuditEvents_Call {
+	_c.Call.Return(auditEvents, err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_ListAuditEvents_Call) RunAndReturn(run func(ctx context.Context, filter models.AuditFilter, cursor int64, limit int32) ([]models.AuditEvent, error)) *mockpostgresRepo_ListAuditEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListDomains provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) ListDomains(ctx context.Context) ([]models.Domain, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDomains")
+	}
+
+	var r0 []models.Domain
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) ([]models.Domain, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) []models.Domain); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Domain)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockpostgresRepo_ListDomains_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListDomains'
+type mockpostgresRepo_ListDomains_Call struct {
+	*mock.Call
+}
+
+// ListDomains is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *mockpostgresRepo_Expecter) ListDomains(ctx interface{}) *mockpostgresRepo_ListDomains_Call {
+	return &mockpostgresRepo_ListDomains_Call{Call: _e.mock.On("ListDomains", ctx)}
+}
+
+func (_c *mockpostgresRepo_ListDomains_Call) Run(run func(ctx context.Context)) *mockpostgresRepo_ListDomains_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_ListDomains_Call) Return(domains []models.Domain, err error) *mockpostgresRepo_ListDomains_Call {
+	_c.Call.Return(domains, err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_ListDomains_Call) RunAndReturn(run func(ctx context.Context) ([]models.Domain, error)) *mockpostgresRepo_ListDomains_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListLinks provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) ListLinks(ctx context.Context, filter models.LinkFilter, domainID int32, after models.LinkCursor, limit int32) ([]models.Link, error) {
+	ret := _mock.Called(ctx, filter, domainID, after, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListLinks")
+	}
+
+	var r0 []models.Link
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.LinkFilter, int32, models.LinkCursor, int32) ([]models.Link, error)); ok {
+		return returnFunc(ctx, filter, domainID, after, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.LinkFilter, int32, models.LinkCursor, int32) []models.Link); ok {
+		r0 = returnFunc(ctx, filter, domainID, after, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Link)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, models.LinkFilter, int32, models.LinkCursor, int32) error); ok {
+		r1 = returnFunc(ctx, filter, domainID, after, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockpostgresRepo_ListLinks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListLinks'
+type mockpostgresRepo_ListLinks_Call struct {
+	*mock.Call
+}
+
+// ListLinks is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter models.LinkFilter
+//   - domainID int32
+//   - after models.LinkCursor
+//   - limit int32
+func (_e *mockpostgresRepo_Expecter) ListLinks(ctx interface{}, filter interface{}, domainID interface{}, after interface{}, limit interface{}) *mockpostgresRepo_ListLinks_Call {
+	return &mockpostgresRepo_ListLinks_Call{Call: _e.mock.On("ListLinks", ctx, filter, domainID, after, limit)}
+}
+
+func (_c *mockpostgresRepo_ListLinks_Call) Run(run func(ctx context.Context, filter models.LinkFilter, domainID int32, after models.LinkCursor, limit int32)) *mockpostgresRepo_ListLinks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 models.LinkFilter
+		if args[1] != nil {
+			arg1 = args[1].(models.LinkFilter)
+		}
+		var arg2 int32
+		if args[2] != nil {
+			arg2 = args[2].(int32)
+		}
+		var arg3 models.LinkCursor
+		if args[3] != nil {
+			arg3 = args[3].(models.LinkCursor)
+		}
+		var arg4 int32
+		if args[4] != nil {
+			arg4 = args[4].(int32)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_ListLinks_Call) Return(links []models.Link, err error) *mockpostgresRepo_ListLinks_Call {
+	_c.Call.Return(links, err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_ListLinks_Call) RunAndReturn(run func(ctx context.Context, filter models.LinkFilter, domainID int32, after models.LinkCursor, limit int32) ([]models.Link, error)) *mockpostgresRepo_ListLinks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListTags provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) ListTags(ctx context.Context, owner string) ([]models.TagCount, error) {
+	ret := _mock.Called(ctx, owner)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTags")
+	}
+
+	var r0 []models.TagCount
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]models.TagCount, error)); ok {
+		return returnFunc(ctx, owner)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []models.TagCount); ok {
+		r0 = returnFunc(ctx, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TagCount)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockpostgresRepo_ListTags_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListTags'
+type mockpostgresRepo_ListTags_Call struct {
+	*mock.Call
+}
+
+// ListTags is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner string
+func (_e *mockpostgresRepo_Expecter) ListTags(ctx interface{}, owner interface{}) *mockpostgresRepo_ListTags_Call {
+	return &mockpostgresRepo_ListTags_Call{Call: _e.mock.On("ListTags", ctx, owner)}
+}
+
+func (_c *mockpostgresRepo_ListTags_Call) Run(run func(ctx context.Context, owner string)) *mockpostgresRepo_ListTags_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_ListTags_Call) Return(tagCounts []models.TagCount, err error) *mockpostgresRepo_ListTags_Call {
+	_c.Call.Return(tagCounts, err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_ListTags_Call) RunAndReturn(run func(ctx context.Context, owner string) ([]models.TagCount, error)) *mockpostgresRepo_ListTags_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RelayAuditEvents provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) RelayAuditEvents(ctx context.Context, limit int32, publish func([]models.AuditEvent) error) (int, error) {
+	ret := _mock.Called(ctx, limit, publish)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RelayAuditEvents")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int32, func([]models.AuditEvent) error) (int, error)); ok {
+		return returnFunc(ctx, limit, publish)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int32, func([]models.AuditEvent) error) int); ok {
+		r0 = returnFunc(ctx, limit, publish)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int32, func([]models.AuditEvent) error) error); ok {
+		r1 = returnFunc(ctx, limit, publish)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockpostgresRepo_RelayAuditEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RelayAuditEvents'
+type mockpostgresRepo_RelayAuditEvents_Call struct {
+	*mock.Call
+}
+
+// RelayAuditEvents is a helper method to define mock.On call
+//   - ctx context.Context
+//   - limit int32
+//   - publish func([]models.AuditEvent) error
+func (_e *mockpostgresRepo_Expecter) RelayAuditEvents(ctx interface{}, limit interface{}, publish interface{}) *mockpostgresRepo_RelayAuditEvents_Call {
+	return &mockpostgresRepo_RelayAuditEvents_Call{Call: _e.mock.On("RelayAuditEvents", ctx, limit, publish)}
+}
+
+func (_c *mockpostgresRepo_RelayAuditEvents_Call) Run(run func(ctx context.Context, limit int32, publish func([]models.AuditEvent) error)) *mockpostgresRepo_RelayAuditEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int32
+		if args[1] != nil {
+			arg1 = args[1].(int32)
+		}
+		var arg2 func([]models.AuditEvent) error
+		if args[2] != nil {
+			arg2 = args[2].(func([]models.AuditEvent) error)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_RelayAuditEvents_Call) Return(n int, err error) *mockpostgresRepo_RelayAuditEvents_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_RelayAuditEvents_Call) RunAndReturn(run func(ctx context.Context, limit int32, publish func([]models.AuditEvent) error) (int, error)) *mockpostgresRepo_RelayAuditEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeAPIKey provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 *models.APIKey
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) (*models.APIKey, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) *models.APIKey); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.APIKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockpostgresRepo_RevokeAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeAPIKey'
+type mockpostgresRepo_RevokeAPIKey_Call struct {
+	*mock.Call
+}
+
+// RevokeAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *mockpostgresRepo_Expecter) RevokeAPIKey(ctx interface{}, id interface{}) *mockpostgresRepo_RevokeAPIKey_Call {
+	return &mockpostgresRepo_RevokeAPIKey_Call{Call: _e.mock.On("RevokeAPIKey", ctx, id)}
+}
+
+func (_c *mockpostgresRepo_RevokeAPIKey_Call) Run(run func(ctx context.Context, id int64)) *mockpostgresRepo_RevokeAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_RevokeAPIKey_Call) Return(aPIKey *models.APIKey, err error) *mockpostgresRepo_RevokeAPIKey_Call {
+	_c.Call.Return(aPIKey, err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_RevokeAPIKey_Call) RunAndReturn(run func(ctx context.Context, id int64) (*models.APIKey, error)) *mockpostgresRepo_RevokeAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetDefaultDomain provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) SetDefaultDomain(ctx context.Context, host string) (*models.Domain, []models.Link, error) {
+	ret := _mock.Called(ctx, host)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetDefaultDomain")
+	}
+
+	var r0 *models.Domain
+	var r1 []models.Link
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Domain, []models.Link, error)); ok {
+		return returnFunc(ctx, host)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Domain); ok {
+		r0 = returnFunc(ctx, host)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Domain)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) []models.Link); ok {
+		r1 = returnFunc(ctx, host)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]models.Link)
+		}
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = returnFunc(ctx, host)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// mockpostgresRepo_SetDefaultDomain_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetDefaultDomain'
+type mockpostgresRepo_SetDefaultDomain_Call struct {
+	*mock.Call
+}
+
+// SetDefaultDomain is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+func (_e *mockpostgresRepo_Expecter) SetDefaultDomain(ctx interface{}, host interface{}) *mockpostgresRepo_SetDefaultDomain_Call {
+	return &mockpostgresRepo_SetDefaultDomain_Call{Call: _e.mock.On("SetDefaultDomain", ctx, host)}
+}
+
+func (_c *mockpostgresRepo_SetDefaultDomain_Call) Run(run func(ctx context.Context, host string)) *mockpostgresRepo_SetDefaultDomain_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_SetDefaultDomain_Call) Return(domain *models.Domain, links []models.Link, err error) *mockpostgresRepo_SetDefaultDomain_Call {
+	_c.Call.Return(domain, links, err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_SetDefaultDomain_Call) RunAndReturn(run func(ctx context.Context, host string) (*models.Domain, []models.Link, error)) *mockpostgresRepo_SetDefaultDomain_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetLinkExpiresAt provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) SetLinkExpiresAt(ctx context.Context, id int64, expiresAt time.Time) error {
+	ret := _mock.Called(ctx, id, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetLinkExpiresAt")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64, time.Time) error); ok {
+		r0 = returnFunc(ctx, id, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockpostgresRepo_SetLinkExpiresAt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetLinkExpiresAt'
+type mockpostgresRepo_SetLinkExpiresAt_Call struct {
+	*mock.Call
+}
+
+// SetLinkExpiresAt is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+//   - expiresAt time.Time
+func (_e *mockpostgresRepo_Expecter) SetLinkExpiresAt(ctx interface{}, id interface{}, expiresAt interface{}) *mockpostgresRepo_SetLinkExpiresAt_Call {
+	return &mockpostgresRepo_SetLinkExpiresAt_Call{Call: _e.mock.On("SetLinkExpiresAt", ctx, id, expiresAt)}
+}
+
+func (_c *mockpostgresRepo_SetLinkExpiresAt_Call) Run(run func(ctx context.Context, id int64, expiresAt time.Time)) *mockpostgresRepo_SetLinkExpiresAt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		var arg2 time.Time
+		if args[2] != nil {
+			arg2 = args[2].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_SetLinkExpiresAt_Call) Return(err error) *mockpostgresRepo_SetLinkExpiresAt_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_SetLinkExpiresAt_Call) RunAndReturn(run func(ctx context.Context, id int64, expiresAt time.Time) error) *mockpostgresRepo_SetLinkExpiresAt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetLinkMetadata provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) SetLinkMetadata(ctx context.Context, id int64, metadata models.LinkMetadata) error {
+	ret := _mock.Called(ctx, id, metadata)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetLinkMetadata")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64, models.LinkMetadata) error); ok {
+		r0 = returnFunc(ctx, id, metadata)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockpostgresRepo_SetLinkMetadata_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetLinkMetadata'
+type mockpostgresRepo_SetLinkMetadata_Call struct {
+	*mock.Call
+}
+
+// SetLinkMetadata is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+//   - metadata models.LinkMetadata
+func (_e *mockpostgresRepo_Expecter) SetLinkMetadata(ctx interface{}, id interface{}, metadata interface{}) *mockpostgresRepo_SetLinkMetadata_Call {
+	return &mockpostgresRepo_SetLinkMetadata_Call{Call: _e.mock.On("SetLinkMetadata", ctx, id, metadata)}
+}
+
+func (_c *mockpostgresRepo_SetLinkMetadata_Call) Run(run func(ctx context.Context, id int64, metadata models.LinkMetadata)) *mockpostgresRepo_SetLinkMetadata_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		var arg2 models.LinkMetadata
+		if args[2] != nil {
+			arg2 = args[2].(models.LinkMetadata)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_SetLinkMetadata_Call) Return(err error) *mockpostgresRepo_SetLinkMetadata_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_SetLinkMetadata_Call) RunAndReturn(run func(ctx context.Context, id int64, metadata models.LinkMetadata) error) *mockpostgresRepo_SetLinkMetadata_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetLinkURL provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) SetLinkURL(ctx context.Context, id int64, url string) error {
+	ret := _mock.Called(ctx, id, url)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetLinkURL")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = returnFunc(ctx, id, url)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockpostgresRepo_SetLinkURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetLinkURL'
+type mockpostgresRepo_SetLinkURL_Call struct {
+	*mock.Call
+}
+
+// SetLinkURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+//   - url string
+func (_e *mockpostgresRepo_Expecter) SetLinkURL(ctx interface{}, id interface{}, url interface{}) *mockpostgresRepo_SetLinkURL_Call {
+	return &mockpostgresRepo_SetLinkURL_Call{Call: _e.mock.On("SetLinkURL", ctx, id, url)}
+}
+
+func (_c *mockpostgresRepo_SetLinkURL_Call) Run(run func(ctx context.Context, id int64, url string)) *mockpostgresRepo_SetLinkURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_SetLinkURL_Call) Return(err error) *mockpostgresRepo_SetLinkURL_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_SetLinkURL_Call) RunAndReturn(run func(ctx context.Context, id int64, url string) error) *mockpostgresRepo_SetLinkURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StoreURL provides a mock function for the type mockpostgresRepo
+func (_mock *mockpostgresRepo) StoreURL(ctx context.Context, link *models.Link) (int64, error) {
+	ret := _mock.Called(ctx, link)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StoreURL")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Link) (int64, error)); ok {
+		return returnFunc(ctx, link)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *models.Link) int64); ok {
+		r0 = returnFunc(ctx, link)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, *models.Link) error); ok {
+		r1 = returnFunc(ctx, link)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockpostgresRepo_StoreURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StoreURL'
+type mockpostgresRepo_StoreURL_Call struct {
+	*mock.Call
+}
+
+// StoreURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - link *models.Link
+func (_e *mockpostgresRepo_Expecter) StoreURL(ctx interface{}, link interface{}) *mockpostgresRepo_StoreURL_Call {
+	return &mockpostgresRepo_StoreURL_Call{Call: _e.mock.On("StoreURL", ctx, link)}
+}
+
+func (_c *mockpostgresRepo_StoreURL_Call) Run(run func(ctx context.Context, link *models.Link)) *mockpostgresRepo_StoreURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *models.Link
+		if args[1] != nil {
+			arg1 = args[1].(*models.Link)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockpostgresRepo_StoreURL_Call) Return(n int64, err error) *mockpostgresRepo_StoreURL_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *mockpostgresRepo_StoreURL_Call) RunAndReturn(run func(ctx context.Context, link *models.Link) (int64, error)) *mockpostgresRepo_StoreURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockvalkeyRepo creates a new instance of mockvalkeyRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockvalkeyRepo(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockvalkeyRepo {
+	mock := &mockvalkeyRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockvalkeyRepo is an autogenerated mock type for the valkeyRepo type
+type mockvalkeyRepo struct {
+	mock.Mock
+}
+
+type mockvalkeyRepo_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockvalkeyRepo) EXPECT() *mockvalkeyRepo_Expecter {
+	return &mockvalkeyRepo_Expecter{mock: &_m.Mock}
+}
+
+// DeleteLinkByCode provides a mock function for the type mockvalkeyRepo
+func (_mock *mockvalkeyRepo) DeleteLinkByCode(ctx context.Context, domain string, code string) error {
+	ret := _mock.Called(ctx, domain, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteLinkByCode")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, domain, code)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockvalkeyRepo_DeleteLinkByCode_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteLinkByCode'
+type mockvalkeyRepo_DeleteLinkByCode_Call struct {
+	*mock.Call
+}
+
+// DeleteLinkByCode is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - code string
+func (_e *mockvalkeyRepo_Expecter) DeleteLinkByCode(ctx interface{}, domain interface{}, code interface{}) *mockvalkeyRepo_DeleteLinkByCode_Call {
+	return &mockvalkeyRepo_DeleteLinkByCode_Call{Call: _e.mock.On("DeleteLinkByCode", ctx, domain, code)}
+}
+
+func (_c *mockvalkeyRepo_DeleteLinkByCode_Call) Run(run func(ctx context.Context, domain string, code string)) *mockvalkeyRepo_DeleteLinkByCode_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockvalkeyRepo_DeleteLinkByCode_Call) Return(err error) *mockvalkeyRepo_DeleteLinkByCode_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockvalkeyRepo_DeleteLinkByCode_Call) RunAndReturn(run func(ctx context.Context, domain string, code string) error) *mockvalkeyRepo_DeleteLinkByCode_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCacheEntry provides a mock function for the type mockvalkeyRepo
+func (_mock *mockvalkeyRepo) GetCacheEntry(ctx context.Context, domain string, code string) (*models.Link, time.Duration, error) {
+	ret := _mock.Called(ctx, domain, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCacheEntry")
+	}
+
+	var r0 *models.Link
+	var r1 time.Duration
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.Link, time.Duration, error)); ok {
+		return returnFunc(ctx, domain, code)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.Link); ok {
+		r0 = returnFunc(ctx, domain, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Link)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) time.Duration); ok {
+		r1 = returnFunc(ctx, domain, code)
+	} else {
+		r1 = ret.Get(1).(time.Duration)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = returnFunc(ctx, domain, code)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// mockvalkeyRepo_GetCacheEntry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCacheEntry'
+type mockvalkeyRepo_GetCacheEntry_Call struct {
+	*mock.Call
+}
+
+// GetCacheEntry is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - code string
+func (_e *mockvalkeyRepo_Expecter) GetCacheEntry(ctx interface{}, domain interface{}, code interface{}) *mockvalkeyRepo_GetCacheEntry_Call {
+	return &mockvalkeyRepo_GetCacheEntry_Call{Call: _e.mock.On("GetCacheEntry", ctx, domain, code)}
+}
+
+func (_c *mockvalkeyRepo_GetCacheEntry_Call) Run(run func(ctx context.Context, domain string, code string)) *mockvalkeyRepo_GetCacheEntry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockvalkeyRepo_GetCacheEntry_Call) Return(link *models.Link, duration time.Duration, err error) *mockvalkeyRepo_GetCacheEntry_Call {
+	_c.Call.Return(link, duration, err)
+	return _c
+}
+
+func (_c *mockvalkeyRepo_GetCacheEntry_Call) RunAndReturn(run func(ctx context.Context, domain string, code string) (*models.Link, time.Duration, error)) *mockvalkeyRepo_GetCacheEntry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetLinkByCode provides a mock function for the type mockvalkeyRepo
+func (_mock *mockvalkeyRepo) GetLinkByCode(ctx context.Context, domain string, code string) (*models.Link, error) {
+	ret := _mock.Called(ctx, domain, code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLinkByCode")
+	}
+
+	var r0 *models.Link
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.Link, error)); ok {
+		return returnFunc(ctx, domain, code)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.Link); ok {
+		r0 = returnFunc(ctx, domain, code)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Link)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, domain, code)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockvalkeyRepo_GetLinkByCode_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLinkByCode'
+type mockvalkeyRepo_GetLinkByCode_Call struct {
+	*mock.Call
+}
+
+// GetLinkByCode is a helper method to define mock.On call
+//   - ctx context.Context
+//   - domain string
+//   - code string
+func (_e *mockvalkeyRepo_Expecter) GetLinkByCode(ctx interface{}, domain interface{}, code interface{}) *mockvalkeyRepo_GetLinkByCode_Call {
+	return &mockvalkeyRepo_GetLinkByCode_Call{Call: _e.mock.On("GetLinkByCode", ctx, domain, code)}
+}
+
+func (_c *mockvalkeyRepo_GetLinkByCode_Call) Run(run func(ctx context.Context, domain string, code string)) *mockvalkeyRepo_GetLinkByCode_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockvalkeyRepo_GetLinkByCode_Call) Return(link *models.Link, err error) *mockvalkeyRepo_GetLinkByCode_Call {
+	_c.Call.Return(link, err)
+	return _c
+}
+
+func (_c *mockvalkeyRepo_GetLinkByCode_Call) RunAndReturn(run func(ctx context.Context, domain string, code string) (*models.Link, error)) *mockvalkeyRepo_GetLinkByCode_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetTop provides a mock function for the type mockvalkeyRepo
+func (_mock *mockvalkeyRepo) SetTop(ctx context.Context, top []models.Link, ttl time.Duration) error {
+	ret := _mock.Called(ctx, top, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetTop")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, []models.Link, time.Duration) error); ok {
+		r0 = returnFunc(ctx, top, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockvalkeyRepo_SetTop_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetTop'
+type mockvalkeyRepo_SetTop_Call struct {
+	*mock.Call
+}
+
+// SetTop is a helper method to define mock.On call
+//   - ctx context.Context
+//   - top []models.Link
+//   - ttl time.Duration
+func (_e *mockvalkeyRepo_Expecter) SetTop(ctx interface{}, top interface{}, ttl interface{}) *mockvalkeyRepo_SetTop_Call {
+	return &mockvalkeyRepo_SetTop_Call{Call: _e.mock.On("SetTop", ctx, top, ttl)}
+}
+
+func (_c *mockvalkeyRepo_SetTop_Call) Run(run func(ctx context.Context, top []models.Link, ttl time.Duration)) *mockvalkeyRepo_SetTop_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []models.Link
+		if args[1] != nil {
+			arg1 = args[1].([]models.Link)
+		}
+		var arg2 time.Duration
+		if args[2] != nil {
+			arg2 = args[2].(time.Duration)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockvalkeyRepo_SetTop_Call) Return(err error) *mockvalkeyRepo_SetTop_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockvalkeyRepo_SetTop_Call) RunAndReturn(run func(ctx context.Context, top []models.Link, ttl time.Duration) error) *mockvalkeyRepo_SetTop_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockkafkaWriter creates a new instance of mockkafkaWriter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockkafkaWriter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockkafkaWriter {
+	mock := &mockkafkaWriter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockkafkaWriter is an autogenerated mock type for the kafkaWriter type
+type mockkafkaWriter struct {
+	mock.Mock
+}
+
+type mockkafkaWriter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockkafkaWriter) EXPECT() *mockkafkaWriter_Expecter {
+	return &mockkafkaWriter_Expecter{mock: &_m.Mock}
+}
+
+// WriteMessages provides a mock function for the type mockkafkaWriter
+func (_mock *mockkafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	var tmpRet mock.Arguments
+	if len(msgs) > 0 {
+		tmpRet = _mock.Called(ctx, msgs)
+	} else {
+		tmpRet = _mock.Called(ctx)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteMessages")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, ...kafka.Message) error); ok {
+		r0 = returnFunc(ctx, msgs...)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockkafkaWriter_WriteMessages_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WriteMessages'
+type mockkafkaWriter_WriteMessages_Call struct {
+	*mock.Call
+}
+
+// WriteMessages is a helper method to define mock.On call
+//   - ctx context.Context
+//   - msgs ...kafka.Message
+func (_e *mockkafkaWriter_Expecter) WriteMessages(ctx interface{}, msgs ...interface{}) *mockkafkaWriter_WriteMessages_Call {
+	return &mockkafkaWriter_WriteMessages_Call{Call: _e.mock.On("WriteMessages",
+		append([]interface{}{ctx}, msgs...)...)}
+}
+
+func (_c *mockkafkaWriter_WriteMessages_Call) Run(run func(ctx context.Context, msgs ...kafka.Message)) *mockkafkaWriter_WriteMessages_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []kafka.Message
+		var variadicArgs []kafka.Message
+		if len(args) > 1 {
+			variadicArgs = args[1].([]kafka.Message)
+		}
+		arg1 = variadicArgs
+		run(
+			arg0,
+			arg1...,
+		)
+	})
+	return _c
+}
+
+func (_c *mockkafkaWriter_WriteMessages_Call) Return(err error) *mockkafkaWriter_WriteMessages_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockkafkaWriter_WriteMessages_Call) RunAndReturn(run func(ctx context.Context, msgs ...kafka.Message) error) *mockkafkaWriter_WriteMessages_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMocktokenSigner creates a new instance of mocktokenSigner. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMocktokenSigner(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mocktokenSigner {
+	mock := &mocktokenSigner{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mocktokenSigner is an autogenerated mock type for the tokenSigner type
+type mocktokenSigner struct {
+	mock.Mock
+}
+
+type mocktokenSigner_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mocktokenSigner) EXPECT() *mocktokenSigner_Expecter {
+	return &mocktokenSigner_Expecter{mock: &_m.Mock}
+}
+
+// Sign provides a mock function for the type mocktokenSigner
+func (_mock *mocktokenSigner) Sign(code string) (string, time.Time) {
+	ret := _mock.Called(code)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Sign")
+	}
+
+	var r0 string
+	var r1 time.Time
+	if returnFunc, ok := ret.Get(0).(func(string) (string, time.Time)); ok {
+		return returnFunc(code)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) string); ok {
+		r0 = returnFunc(code)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) time.Time); ok {
+		r1 = returnFunc(code)
+	} else {
+		r1 = ret.Get(1).(time.Time)
+	}
+	return r0, r1
+}
+
+// mocktokenSigner_Sign_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Sign'
+type mocktokenSigner_Sign_Call struct {
+	*mock.Call
+}
+
+// Sign is a helper method to define mock.On call
+//   - code string
+func (_e *mocktokenSigner_Expecter) Sign(code interface{}) *mocktokenSigner_Sign_Call {
+	return &mocktokenSigner_Sign_Call{Call: _e.mock.On("Sign", code)}
+}
+
+func (_c *mocktokenSigner_Sign_Call) Run(run func(code string)) *mocktokenSigner_Sign_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *mocktokenSigner_Sign_Call) Return(s string, time1 time.Time) *mocktokenSigner_Sign_Call {
+	_c.Call.Return(s, time1)
+	return _c
+}
+
+func (_c *mocktokenSigner_Sign_Call) RunAndReturn(run func(code string) (string, time.Time)) *mocktokenSigner_Sign_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Verify provides a mock function for the type mocktokenSigner
+func (_mock *mocktokenSigner) Verify(code string, token string) bool {
+	ret := _mock.Called(code, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Verify")
+	}
+
+	var r0 bool
+	if returnFunc, ok := ret.Get(0).(func(string, string) bool); ok {
+		r0 = returnFunc(code, token)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+	return r0
+}
+
+// mocktokenSigner_Verify_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Verify'
+type mocktokenSigner_Verify_Call struct {
+	*mock.Call
+}
+
+// Verify is a helper method to define mock.On call
+//   - code string
+//   - token string
+func (_e *mocktokenSigner_Expecter) Verify(code interface{}, token interface{}) *mocktokenSigner_Verify_Call {
+	return &mocktokenSigner_Verify_Call{Call: _e.mock.On("Verify", code, token)}
+}
+
+func (_c *mocktokenSigner_Verify_Call) Run(run func(code string, token string)) *mocktokenSigner_Verify_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mocktokenSigner_Verify_Call) Return(b bool) *mocktokenSigner_Verify_Call {
+	_c.Call.Return(b)
+	return _c
+}
+
+func (_c *mocktokenSigner_Verify_Call) RunAndReturn(run func(code string, token string) bool) *mocktokenSigner_Verify_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockidAllocator creates a new instance of mockidAllocator. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockidAllocator(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockidAllocator {
+	mock := &mockidAllocator{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockidAllocator is an autogenerated mock type for the idAllocator type
+type mockidAllocator struct {
+	mock.Mock
+}
+
+type mockidAllocator_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockidAllocator) EXPECT() *mockidAllocator_Expecter {
+	return &mockidAllocator_Expecter{mock: &_m.Mock}
+}
+
+// NextID provides a mock function for the type mockidAllocator
+func (_mock *mockidAllocator) NextID(ctx context.Context) (int64, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NextID")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockidAllocator_NextID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NextID'
+type mockidAllocator_NextID_Call struct {
+	*mock.Call
+}
+
+// NextID is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *mockidAllocator_Expecter) NextID(ctx interface{}) *mockidAllocator_NextID_Call {
+	return &mockidAllocator_NextID_Call{Call: _e.mock.On("NextID", ctx)}
+}
+
+func (_c *mockidAllocator_NextID_Call) Run(run func(ctx context.Context)) *mockidAllocator_NextID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *mockidAllocator_NextID_Call) Return(n int64, err error) *mockidAllocator_NextID_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *mockidAllocator_NextID_Call) RunAndReturn(run func(ctx context.Context) (int64, error)) *mockidAllocator_NextID_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// newMockipAnonymizer creates a new instance of mockipAnonymizer. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func newMockipAnonymizer(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *mockipAnonymizer {
+	mock := &mockipAnonymizer{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// mockipAnonymizer is an autogenerated mock type for the ipAnonymizer type
+type mockipAnonymizer struct {
+	mock.Mock
+}
+
+type mockipAnonymizer_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *mockipAnonymizer) EXPECT() *mockipAnonymizer_Expecter {
+	return &mockipAnonymizer_Expecter{mock: &_m.Mock}
+}
+
+// Anonymize provides a mock function for the type mockipAnonymizer
+func (_mock *mockipAnonymizer) Anonymize(ip string) string {
+	ret := _mock.Called(ip)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Anonymize")
+	}
+
+	var r0 string
+	if returnFunc, ok := ret.Get(0).(func(string) string); ok {
+		r0 = returnFunc(ip)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	return r0
+}
+
+// mockipAnonymizer_Anonymize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Anonymize'
+type mockipAnonymizer_Anonymize_Call struct {
+	*mock.Call
+}
+
+// Anonymize is a helper method to define mock.On call
+//   - ip string
+func (_e *mockipAnonymizer_Expecter) Anonymize(ip interface{}) *mockipAnonymizer_Anonymize_Call {
+	return &mockipAnonymizer_Anonymize_Call{Call: _e.mock.On("Anonymize", ip)}
+}
+
+func (_c *mockipAnonymizer_Anonymize_Call) Run(run func(ip string)) *mockipAnonymizer_Anonymize_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *mockipAnonymizer_Anonymize_Call) Return(s string) *mockipAnonymizer_Anonymize_Call {
+	_c.Call.Return(s)
+	return _c
+}
+
+func (_c *mockipAnonymizer_Anonymize_Call) RunAndReturn(run func(ip string) string) *mockipAnonymizer_Anonymize_Call {
 	_c.Call.Return(run)
 	return _c
 }
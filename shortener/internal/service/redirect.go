@@ -0,0 +1,196 @@
+package service
+
+import (
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"hash/fnv"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// redirect is the destination resolved for the visit
+type redirect struct {
+	URL string
+
+	// Rule is the name of the matched routing rule, empty if no rule matched
+	Rule string
+
+	// Variant is the name of the chosen weighted destination
+	Variant string
+}
+
+// resolveRedirect picks the destination for the visitor and applies link options to it
+func resolveRedirect(link *models.Link, visit models.Visit) (redirect, error) {
+	if link.Options.IsZero() {
+		return redirect{URL: link.URL}, nil
+	}
+
+	r := redirect{URL: link.URL}
+	for i, rule := range link.Options.Rules {
+		if !matchRule(rule, visit) {
+			continue
+		}
+
+		r.URL = rule.URL
+		r.Rule = rule.Name
+		if r.Rule == "" {
+			r.Rule = strconv.Itoa(i + 1)
+		}
+		break
+	}
+
+	if r.Rule == "" && len(link.Options.Destinations) > 0 {
+		i := pickDestination(link.Options.Destinations, stickyKey(link.Code, visit))
+		destination := link.Options.Destinations[i]
+
+		r.URL = destination.URL
+		r.Variant = destination.Name
+		if r.Variant == "" {
+			r.Variant = strconv.Itoa(i + 1)
+		}
+	}
+
+	dest, err := buildDestination(r.URL, link, visit)
+	if err != nil {
+		return redirect{}, err
+	}
+	r.URL = dest
+
+	return r, nil
+}
+
+// buildDestination applies link options to the destination URL
+//
+// UTM templates are applied only if the URL doesn't set the parameter itself.
+// Forwarded query parameters override both of them, malformed ones are skipped.
+func buildDestination(rawURL string, link *models.Link, visit models.Visit) (string, error) {
+	if len(link.Options.UTM) == 0 && !link.Options.ForwardQuery {
+		return rawURL, nil
+	}
+
+	dest, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	query := dest.Query()
+
+	for key, value := range link.Options.UTM {
+		if query.Has(key) {
+			continue
+		}
+		query.Set(key, strings.ReplaceAll(value, "{code}", link.Code))
+	}
+
+	if link.Options.ForwardQuery && visit.Query != "" {
+		// The query is up to the visitor, malformed pairs are skipped and the others are still forwarded
+		incoming, _ := url.ParseQuery(visit.Query)
+		for key, values := range incoming {
+			query[key] = values
+		}
+	}
+
+	dest.RawQuery = query.Encode()
+	return dest.String(), nil
+}
+
+// platformGroups maps platform groups to the platforms they contain
+var platformGroups = map[string][]string{
+	"mobile":  {"ios", "android"},
+	"desktop": {"windows", "macos", "linux", "chromeos"},
+}
+
+// matchRule reports whether the visit matches all non-empty conditions of the rule
+func matchRule(rule models.RoutingRule, visit models.Visit) bool {
+	if rule.UserAgent != "" &&
+		!strings.Contains(strings.ToLower(visit.UserAgent), strings.ToLower(rule.UserAgent)) {
+		return false
+	}
+
+	if rule.Platform != "" && !matchPlatform(rule.Platform, visit.Platform) {
+		return false
+	}
+
+	if rule.AcceptLanguage != "" && !matchLanguage(rule.AcceptLanguage, visit.AcceptLanguage) {
+		return false
+	}
+
+	if len(rule.Countries) > 0 && !matchCountry(rule.Countries, visit.Country) {
+		return false
+	}
+
+	return true
+}
+
+func matchPlatform(want, platform string) bool {
+	want, platform = strings.ToLower(want), strings.ToLower(platform)
+	if platform == "" {
+		return false
+	}
+	if want == platform {
+		return true
+	}
+	for _, p := range platformGroups[want] {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
+
+// matchLanguage reports whether any language of the Accept-Language header starts with the prefix
+func matchLanguage(prefix, acceptLanguage string) bool {
+	prefix = strings.ToLower(prefix)
+	for _, tag := range strings.Split(acceptLanguage, ",") {
+		lang, _, _ := strings.Cut(tag, ";")
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang == prefix || strings.HasPrefix(lang, prefix+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCountry reports whether the country is one of the rule countries
+func matchCountry(countries []string, country string) bool {
+	if country == "" {
+		return false
+	}
+	for _, c := range countries {
+		if strings.EqualFold(c, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// stickyKey returns a key that is the same for all visits of the visitor to the link.
+// Without visitor ID, it falls back to the client attributes.
+func stickyKey(code string, visit models.Visit) string {
+	if visit.VisitorID != "" {
+		return code + "|" + visit.VisitorID
+	}
+	return code + "|" + visit.UserAgent + "|" + visit.AcceptLanguage
+}
+
+// pickDestination returns index of the destination chosen for the key by weight
+func pickDestination(destinations []models.Destination, key string) int {
+	var total uint64
+	for _, d := range destinations {
+		total += uint64(d.Weight)
+	}
+	if total == 0 {
+		return 0
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	point := h.Sum64() % total
+
+	for i, d := range destinations {
+		if point < uint64(d.Weight) {
+			return i
+		}
+		point -= uint64(d.Weight)
+	}
+	return len(destinations) - 1
+}
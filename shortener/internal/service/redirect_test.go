@@ -0,0 +1,256 @@
+package service
+
+import (
+	"fmt"
+	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_resolveRedirect(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Link         *models.Link
+		Visit        models.Visit
+		ExceptedURL  string
+		ExceptedRule string
+		WantErr      bool
+	}{
+		{
+			Name:        "Plain link is returned as is",
+			Link:        &models.Link{Code: "3a", URL: "https://go.dev/doc?b=2&a=1"},
+			Visit:       models.Visit{Query: "ref=newsletter"},
+			ExceptedURL: "https://go.dev/doc?b=2&a=1",
+		},
+		{
+			Name: "Query is not forwarded without option",
+			Link: &models.Link{
+				Code:    "3a",
+				URL:     "https://go.dev/doc",
+				Options: models.LinkOptions{UTM: map[string]string{"utm_source": "sh"}},
+			},
+			Visit:       models.Visit{Query: "ref=newsletter"},
+			ExceptedURL: "https://go.dev/doc?utm_source=sh",
+		},
+		{
+			Name: "Forwarded query is merged",
+			Link: &models.Link{
+				Code:    "3a",
+				URL:     "https://go.dev/doc?lang=en",
+				Options: models.LinkOptions{ForwardQuery: true},
+			},
+			Visit:       models.Visit{Query: "ref=newsletter"},
+			ExceptedURL: "https://go.dev/doc?lang=en&ref=newsletter",
+		},
+		{
+			Name: "UTM template doesn't override stored parameter",
+			Link: &models.Link{
+				Code: "3a",
+				URL:  "https://go.dev/?utm_source=blog",
+				Options: models.LinkOptions{UTM: map[string]string{
+					"utm_source":   "sh",
+					"utm_campaign": "link-{code}",
+				}},
+			},
+			ExceptedURL: "https://go.dev/?utm_campaign=link-3a&utm_source=blog",
+		},
+		{
+			Name: "Forwarded query overrides UTM template",
+			Link: &models.Link{
+				Code: "3a",
+				URL:  "https://go.dev/",
+				Options: models.LinkOptions{
+					ForwardQuery: true,
+					UTM:          map[string]string{"utm_source": "sh"},
+				},
+			},
+			Visit:       models.Visit{Query: "utm_source=newsletter"},
+			ExceptedURL: "https://go.dev/?utm_source=newsletter",
+		},
+		{
+			Name: "First matching rule is chosen",
+			Link: &models.Link{
+				Code: "3a",
+				URL:  "https://example.com",
+				Options: models.LinkOptions{Rules: []models.RoutingRule{
+					{URL: "https://apps.apple.com/app", Name: "ios", Platform: "ios"},
+					{URL: "https://play.google.com/app", Platform: "mobile"},
+					{URL: "https://example.com/android", Platform: "android"},
+				}},
+			},
+			Visit:        models.Visit{Platform: "Android"},
+			ExceptedURL:  "https://play.google.com/app",
+			ExceptedRule: "2",
+		},
+		{
+			Name: "Rule matches only when all conditions match",
+			Link: &models.Link{
+				Code: "3a",
+				URL:  "https://example.com",
+				Options: models.LinkOptions{Rules: []models.RoutingRule{
+					{URL: "https://example.com/de-ios", Name: "de-ios", Platform: "ios", AcceptLanguage: "de"},
+					{URL: "https://example.com/de", Name: "de", AcceptLanguage: "de"},
+				}},
+			},
+			Visit: models.Visit{
+				Platform:       "android",
+				AcceptLanguage: "en-US;q=0.8, de-CH, de;q=0.9",
+			},
+			ExceptedURL:  "https://example.com/de",
+			ExceptedRule: "de",
+		},
+		{
+			Name: "User-Agent is matched case-insensitively",
+			Link: &models.Link{
+				Code: "3a",
+				URL:  "https://example.com",
+				Options: models.LinkOptions{
+					UTM: map[string]string{"utm_source": "tg"},
+					Rules: []models.RoutingRule{
+						{URL: "https://t.me/channel", Name: "telegram", UserAgent: "telegram"},
+					},
+				},
+			},
+			Visit:        models.Visit{UserAgent: "Mozilla/5.0 Telegram-Android/11.0"},
+			ExceptedURL:  "https://t.me/channel?utm_source=tg",
+			ExceptedRule: "telegram",
+		},
+		{
+			Name: "Country rule matches any of its countries",
+			Link: &models.Link{
+				Code: "3a",
+				URL:  "https://example.com",
+				Options: models.LinkOptions{Rules: []models.RoutingRule{
+					{URL: "https://example.com/us", Name: "us", Countries: []string{"US"}},
+					{URL: "https://example.com/dach", Name: "dach", Countries: []string{"DE", "AT", "CH"}},
+				}},
+			},
+			Visit:        models.Visit{Country: "at"},
+			ExceptedURL:  "https://example.com/dach",
+			ExceptedRule: "dach",
+		},
+		{
+			Name: "Country rule doesn't match unknown country",
+			Link: &models.Link{
+				Code: "3a",
+				URL:  "https://example.com",
+				Options: models.LinkOptions{Rules: []models.RoutingRule{
+					{URL: "https://example.com/us", Countries: []string{"US"}},
+				}},
+			},
+			Visit:       models.Visit{},
+			ExceptedURL: "https://example.com",
+		},
+		{
+			Name: "Default URL is used if no rule matched",
+			Link: &models.Link{
+				Code: "3a",
+				URL:  "https://example.com",
+				Options: models.LinkOptions{Rules: []models.RoutingRule{
+					{URL: "https://apps.apple.com/app", Platform: "ios"},
+				}},
+			},
+			Visit:       models.Visit{UserAgent: "curl/8.0"},
+			ExceptedURL: "https://example.com",
+		},
+		{
+			Name: "Matched rule takes precedence over destinations",
+			Link: &models.Link{
+				Code: "3a",
+				URL:  "https://example.com",
+				Options: models.LinkOptions{
+					Rules: []models.RoutingRule{
+						{URL: "https://apps.apple.com/app", Name: "ios", Platform: "ios"},
+					},
+					Destinations: []models.Destination{
+						{URL: "https://example.com/a", Weight: 1, Name: "a"},
+					},
+				},
+			},
+			Visit:        models.Visit{Platform: "ios"},
+			ExceptedURL:  "https://apps.apple.com/app",
+			ExceptedRule: "ios",
+		},
+		{
+			Name: "Malformed forwarded query pairs are skipped",
+			Link: &models.Link{
+				Code:    "3a",
+				URL:     "https://go.dev/",
+				Options: models.LinkOptions{ForwardQuery: true},
+			},
+			Visit:       models.Visit{Query: "a=%zz&b=1"},
+			ExceptedURL: "https://go.dev/?b=1",
+		},
+		{
+			Name: "Invalid destination",
+			Link: &models.Link{
+				Code:    "3a",
+				URL:     "://go.dev",
+				Options: models.LinkOptions{ForwardQuery: true},
+			},
+			Visit:   models.Visit{Query: "b=1"},
+			WantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r, err := resolveRedirect(tt.Link, tt.Visit)
+			if tt.WantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ExceptedURL, r.URL)
+			assert.Equal(t, tt.ExceptedRule, r.Rule)
+		})
+	}
+}
+
+func Test_resolveRedirect_Destinations(t *testing.T) {
+	link := &models.Link{
+		Code: "3a",
+		URL:  "https://example.com",
+		Options: models.LinkOptions{Destinations: []models.Destination{
+			{URL: "https://example.com/a", Weight: 70, Name: "a"},
+			{URL: "https://example.com/b", Weight: 30},
+		}},
+	}
+
+	counts := make(map[string]int)
+	for i := range 10000 {
+		visit := models.Visit{VisitorID: fmt.Sprintf("visitor-%d", i)}
+
+		r, err := resolveRedirect(link, visit)
+		assert.NoError(t, err)
+		counts[r.Variant]++
+
+		// The same visitor always gets the same destination
+		again, err := resolveRedirect(link, visit)
+		assert.NoError(t, err)
+		assert.Equal(t, r, again)
+
+		switch r.Variant {
+		case "a":
+			assert.Equal(t, "https://example.com/a", r.URL)
+		case "2":
+			assert.Equal(t, "https://example.com/b", r.URL)
+		default:
+			t.Fatalf("unexpected variant %q", r.Variant)
+		}
+	}
+
+	assert.InDelta(t, 7000, counts["a"], 300)
+	assert.InDelta(t, 3000, counts["2"], 300)
+}
+
+func Test_pickDestination(t *testing.T) {
+	destinations := []models.Destination{
+		{URL: "https://example.com/a", Weight: 0},
+		{URL: "https://example.com/b", Weight: 5},
+	}
+
+	for i := range 100 {
+		assert.Equal(t, 1, pickDestination(destinations, fmt.Sprintf("key-%d", i)))
+	}
+}
@@ -5,11 +5,14 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/errorz"
 	"github.com/misshanya/url-shortener/shortener/internal/models"
 	"github.com/misshanya/url-shortener/shortener/pkg/base62"
+	"github.com/misshanya/url-shortener/shortener/pkg/botdetect"
 	"github.com/segmentio/kafka-go"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"log/slog"
@@ -18,37 +21,83 @@ import (
 )
 
 type postgresRepo interface {
-	StoreURL(ctx context.Context, url string) (int64, error)
-	GetID(ctx context.Context, url string) (int64, error)
-	GetURL(ctx context.Context, id int64) (string, error)
+	StoreURL(ctx context.Context, link *models.Link) (int64, error)
+	GetID(ctx context.Context, url string, domainID int32, owner string) (int64, error)
+	GetLink(ctx context.Context, id int64) (*models.Link, error)
+	GetLinkByAlias(ctx context.Context, domainID int32, alias string) (*models.Link, error)
+	DeleteLink(ctx context.Context, id int64) error
+	ListLinks(ctx context.Context, filter models.LinkFilter, domainID int32, after models.LinkCursor, limit int32) ([]models.Link, error)
+	SetLinkMetadata(ctx context.Context, id int64, metadata models.LinkMetadata) error
+	SetLinkURL(ctx context.Context, id int64, url string) error
+	SetLinkExpiresAt(ctx context.Context, id int64, expiresAt time.Time) error
+	GetLinkTags(ctx context.Context, ids []int64) (map[int64][]string, error)
+	ListTags(ctx context.Context, owner string) ([]models.TagCount, error)
+	CreateDomain(ctx context.Context, host string) (*models.Domain, error)
+	ListDomains(ctx context.Context) ([]models.Domain, error)
+	SetDefaultDomain(ctx context.Context, host string) (*models.Domain, []models.Link, error)
+	ListAuditEvents(ctx context.Context, filter models.AuditFilter, cursor int64, limit int32) ([]models.AuditEvent, error)
+	RelayAuditEvents(ctx context.Context, limit int32, publish func([]models.AuditEvent) error) (int, error)
+	CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error)
 }
 
 type valkeyRepo interface {
-	SetTop(ctx context.Context, top models.UnshortenedTop, ttl time.Duration) error
-	GetURLByCode(ctx context.Context, code string) (string, error)
+	SetTop(ctx context.Context, top []models.Link, ttl time.Duration) error
+	GetLinkByCode(ctx context.Context, domain, code string) (*models.Link, error)
+	GetCacheEntry(ctx context.Context, domain, code string) (*models.Link, time.Duration, error)
+	DeleteLinkByCode(ctx context.Context, domain, code string) error
 }
 
 type kafkaWriter interface {
 	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
 }
 
+type tokenSigner interface {
+	Sign(code string) (string, time.Time)
+	Verify(code, token string) bool
+}
+
+// idAllocator allocates IDs of new links, the storage assigns them if there is no allocator
+type idAllocator interface {
+	NextID(ctx context.Context) (int64, error)
+}
+
+// ipAnonymizer anonymises client IPs before they are published with the clicks
+type ipAnonymizer interface {
+	Anonymize(ip string) string
+}
+
 type Service struct {
-	pr postgresRepo
-	vr valkeyRepo
-	l  *slog.Logger
-	kw kafkaWriter
-	t  trace.Tracer
+	pr  postgresRepo
+	vr  valkeyRepo
+	l   *slog.Logger
+	kw  kafkaWriter
+	t   trace.Tracer
+	ts  tokenSigner
+	ids idAllocator
+	ip  ipAnonymizer
+
+	domains domainRegistry
 
 	maxWorkers int
 }
 
-func New(repo postgresRepo, vr valkeyRepo, logger *slog.Logger, kafkaWriter kafkaWriter, t trace.Tracer, maxWorkers int) *Service {
+// maxCodeAttempts limits how many times a new ID is taken
+// if the generated code is shadowed by an alias
+const maxCodeAttempts = 3
+
+func New(repo postgresRepo, vr valkeyRepo, logger *slog.Logger, kafkaWriter kafkaWriter, t trace.Tracer, ts tokenSigner, ids idAllocator, ip ipAnonymizer, maxWorkers int) *Service {
 	return &Service{
-		pr: repo,
-		vr: vr,
-		l:  logger,
-		kw: kafkaWriter,
-		t:  t,
+		pr:  repo,
+		vr:  vr,
+		l:   logger,
+		kw:  kafkaWriter,
+		t:   t,
+		ts:  ts,
+		ids: ids,
+		ip:  ip,
 
 		maxWorkers: maxWorkers,
 	}
@@ -58,30 +107,62 @@ func (s *Service) ShortenURL(ctx context.Context, short *models.Short) error {
 	ctx, span := s.t.Start(ctx, "ShortenURL")
 	defer span.End()
 
+	domain, err := s.resolveDomain(ctx, short.Domain, false)
+	if err != nil {
+		return err
+	}
+	short.Domain = domain.Host
+
 	// Try to get ID by URL, and if it exists, encode and return
-	ctxGet, spanGet := s.t.Start(ctx, "try-get-id-from-db")
-	id, err := s.pr.GetID(ctxGet, short.URL)
-	spanGet.End()
-	if err == nil {
-		short.Short = base62.Encode(id)
-		return nil
-	} else if !errors.Is(err, sql.ErrNoRows) {
-		s.l.Error("failed to get short by url", "error", err)
-		return status.Error(codes.Internal, "failed to get short by url")
+	// Links with options, password, activation window or alias are never shared, so there is nothing to look for
+	if short.IsPlain() {
+		ctxGet, spanGet := s.t.Start(ctx, "try-get-id-from-db")
+		id, err := s.pr.GetID(ctxGet, short.URL, domain.ID, short.Owner)
+		spanGet.End()
+		if err == nil {
+			short.Short = base62.Encode(id)
+			return nil
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			s.l.Error("failed to get short by url", "error", err)
+			return status.Error(codes.Internal, "failed to get short by url")
+		}
+	}
+
+	if short.Alias != "" {
+		if err := s.checkAlias(ctx, domain, short.Alias); err != nil {
+			return err
+		}
+	}
+
+	var passwordHash string
+	if short.Password != "" {
+		_, spanHash := s.t.Start(ctx, "hash-password")
+		hash, err := bcrypt.GenerateFromPassword([]byte(short.Password), bcrypt.DefaultCost)
+		spanHash.End()
+		if err != nil {
+			s.l.Error("failed to hash password", "error", err)
+			return status.Error(codes.Internal, "failed to hash password")
+		}
+		passwordHash = string(hash)
 	}
 
 	s.l.Info("shortening url", slog.String("url", short.URL))
 
-	ctxStore, spanStore := s.t.Start(ctx, "store-url")
-	id, err = s.pr.StoreURL(ctxStore, short.URL)
-	spanStore.End()
+	code, err := s.storeLink(ctx, &models.Link{
+		URL:          short.URL,
+		Options:      short.Options,
+		PasswordHash: passwordHash,
+		NotBefore:    short.NotBefore,
+		ExpiresAt:    short.ExpiresAt,
+		DomainID:     domain.ID,
+		Alias:        short.Alias,
+		Owner:        short.Owner,
+		Metadata:     short.Metadata,
+	})
 	if err != nil {
-		s.l.Error("failed to store short by url", "error", err)
-		return status.Error(codes.Internal, "failed to store short")
+		return err
 	}
-
-	// Encode via base62
-	short.Short = base62.Encode(id)
+	short.Short = code
 
 	// Write to Kafka that we are just shortened the URL
 
@@ -93,6 +174,8 @@ func (s *Service) ShortenURL(ctx context.Context, short *models.Short) error {
 		ShortenedAt: time.Now(),
 		OriginalURL: short.URL,
 		ShortCode:   short.Short,
+		Domain:      short.Domain,
+		Tags:        short.Metadata.Tags,
 	}
 	msgMarshaled, err := json.Marshal(msg)
 	if err != nil {
@@ -128,6 +211,69 @@ func (s *Service) ShortenURL(ctx context.Context, short *models.Short) error {
 	return nil
 }
 
+// storeLink stores the link and returns its code, the alias or the encoded ID
+func (s *Service) storeLink(ctx context.Context, link *models.Link) (string, error) {
+	for range maxCodeAttempts {
+		if s.ids != nil {
+			ctxAlloc, spanAlloc := s.t.Start(ctx, "allocate-id")
+			id, err := s.ids.NextID(ctxAlloc)
+			spanAlloc.End()
+			if err != nil {
+				s.l.Error("failed to allocate id", "error", err)
+				return "", status.Error(codes.Internal, "failed to store short")
+			}
+			link.ID = id
+		}
+
+		ctxStore, spanStore := s.t.Start(ctx, "store-url")
+		id, err := s.pr.StoreURL(ctxStore, link)
+		spanStore.End()
+		if errors.Is(err, errorz.ErrAliasTaken) {
+			return "", errorz.Conflict(errorz.ReasonAliasTaken, "alias is taken")
+		} else if errors.Is(err, errorz.ErrCodeShadowed) {
+			// Aliases are resolved first, so the code shadowed by an alias would never be reached.
+			// Nothing is stored, the next attempt gets another ID.
+			s.l.Info("generated code is taken by alias, retrying")
+			continue
+		} else if err != nil {
+			s.l.Error("failed to store short by url", "error", err)
+			return "", status.Error(codes.Internal, "failed to store short")
+		}
+
+		if link.Alias != "" {
+			return link.Alias, nil
+		}
+
+		// Encode via base62
+		return base62.Encode(id), nil
+	}
+
+	return "", status.Error(codes.Internal, "failed to generate code")
+}
+
+// checkAlias rejects the alias equal to the generated code of an existing link in the domain
+func (s *Service) checkAlias(ctx context.Context, domain models.Domain, alias string) error {
+	if !isCode(alias) {
+		return nil
+	}
+
+	ctx, span := s.t.Start(ctx, "check-alias-is-not-code")
+	defer span.End()
+
+	link, err := s.pr.GetLink(ctx, base62.Decode(alias))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		s.l.Error("failed to check alias", "alias", alias, "error", err)
+		return status.Error(codes.Internal, "failed to check alias")
+	}
+
+	if link.DomainID == domain.ID && link.Alias == "" {
+		return errorz.Conflict(errorz.ReasonAliasTaken, "alias is taken")
+	}
+	return nil
+}
+
 func (s *Service) ShortenURLBatch(ctx context.Context, shorts []*models.Short) {
 	ctx, span := s.t.Start(ctx, "ShortenURLBatch")
 	defer span.End()
@@ -165,45 +311,105 @@ func (s *Service) ShortenURLBatch(ctx context.Context, shorts []*models.Short) {
 	wg.Wait()
 }
 
-func (s *Service) GetURL(ctx context.Context, short string) (string, error) {
+// GetURL resolves the link by the code on the host, unknown hosts are resolved as the default domain
+func (s *Service) GetURL(ctx context.Context, host, short string, visit models.Visit) (*models.Redirect, error) {
 	ctx, span := s.t.Start(ctx, "GetURL")
 	defer span.End()
 
-	// Decode shorted into ID
-	id := base62.Decode(short)
+	link, r, err := s.resolve(ctx, host, short, visit)
+	if err != nil {
+		return nil, err
+	}
 
-	ctxGetCache, spanGetCache := s.t.Start(ctx, "get-url-from-cache")
-	url, err := s.vr.GetURLByCode(ctxGetCache, short)
-	spanGetCache.End()
+	s.writeUnshortened(ctx, link, short, r, visit, time.Now())
+
+	return redirectOf(link, r), nil
+}
+
+// ResolveURL resolves the link like GetURL does without recording the visit, the caller records it with RecordVisit
+func (s *Service) ResolveURL(ctx context.Context, host, short string, visit models.Visit) (*models.Redirect, error) {
+	ctx, span := s.t.Start(ctx, "ResolveURL")
+	defer span.End()
+
+	link, r, err := s.resolve(ctx, host, short, visit)
 	if err != nil {
-		s.l.Error("failed to get short by url from cache", "error", err)
+		return nil, err
 	}
 
-	if url == "" {
-		ctxGetDB, spanGetDB := s.t.Start(ctx, "get-url-from-db")
-		url, err = s.pr.GetURL(ctxGetDB, id)
-		spanGetDB.End()
-		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				return "", status.Error(codes.NotFound, "short not found")
-			}
-			s.l.Error("failed to get short by url", "error", err)
-			return "", status.Error(codes.Internal, "failed to get short by url")
-		}
-	} else {
-		s.l.Info("got from cache", "url", url)
+	return redirectOf(link, r), nil
+}
+
+// resolve picks the destination of the active link for the visitor
+func (s *Service) resolve(ctx context.Context, host, short string, visit models.Visit) (*models.Link, redirect, error) {
+	link, err := s.getActiveLink(ctx, host, short)
+	if err != nil {
+		return nil, redirect{}, err
+	}
+
+	if link.IsProtected() && !s.ts.Verify(tokenSubject(link), visit.LinkToken) {
+		return nil, redirect{}, status.Error(codes.PermissionDenied, "password required")
 	}
 
-	// Write to Kafka that we are just unshortened URL
+	r, err := resolveRedirect(link, visit)
+	if err != nil {
+		s.l.Error("failed to build destination", "code", short, "error", err)
+		return nil, redirect{}, status.Error(codes.Internal, "failed to build destination")
+	}
+
+	return link, r, nil
+}
 
+// redirectOf returns the redirect of the link to the resolved destination
+func redirectOf(link *models.Link, r redirect) *models.Redirect {
+	return &models.Redirect{
+		URL:          r.URL,
+		Interstitial: link.Options.Interstitial,
+		Cacheable:    link.Options.IsStatic() && !link.IsProtected(),
+		ExpiresAt:    link.ExpiresAt,
+		Rule:         r.Rule,
+		Variant:      r.Variant,
+	}
+}
+
+// RecordVisit writes the unshortened event of the visit the gateway served from its cache
+// or resolved with ResolveURL at the time
+func (s *Service) RecordVisit(ctx context.Context, host, short string, visit models.Visit, at time.Time) error {
+	ctx, span := s.t.Start(ctx, "RecordVisit")
+	defer span.End()
+
+	link, err := s.getActiveLink(ctx, host, short)
+	if err != nil {
+		return err
+	}
+
+	// Cached redirects are static, they have no rule or variant
+	s.writeUnshortened(ctx, link, short, redirect{URL: link.URL, Rule: visit.Rule, Variant: visit.Variant}, visit, at)
+	return nil
+}
+
+// writeUnshortened writes to Kafka that the link is unshortened, in background
+func (s *Service) writeUnshortened(ctx context.Context, link *models.Link, short string, r redirect, visit models.Visit, at time.Time) {
 	carrier := propagation.MapCarrier{}
 	propagator := propagation.TraceContext{}
 	propagator.Inject(ctx, carrier)
 
+	bot, botName := botdetect.Classify(visit.UserAgent, visit.AcceptLanguage)
 	msg := models.KafkaMessageUnshortened{
-		UnshortenedAt: time.Now(),
-		OriginalURL:   url,
+		UnshortenedAt: at,
+		OriginalURL:   link.URL,
 		ShortCode:     short,
+		Domain:        link.Domain,
+		Rule:          r.Rule,
+		Variant:       r.Variant,
+		Country:       visit.Country,
+
+		Referer:        visit.Referer,
+		UserAgent:      visit.UserAgent,
+		IP:             s.ip.Anonymize(visit.IP),
+		AcceptLanguage: visit.AcceptLanguage,
+
+		Bot:     bot,
+		BotName: botName,
 	}
 	msgMarshaled, err := json.Marshal(msg)
 	if err != nil {
@@ -235,23 +441,171 @@ func (s *Service) GetURL(ctx context.Context, short string) (string, error) {
 			s.l.Error("failed to write messages to Kafka", "error", err)
 		}
 	}()
+}
+
+// PreviewURL returns the active link by the code on the host without visiting it, so nothing is counted
+func (s *Service) PreviewURL(ctx context.Context, host, short string) (*models.Link, error) {
+	ctx, span := s.t.Start(ctx, "PreviewURL")
+	defer span.End()
 
-	return url, nil
+	return s.getActiveLink(ctx, host, short)
+}
+
+// VerifyLinkPassword checks the password of the protected link
+// and returns a token unlocking it with the token expiration time
+func (s *Service) VerifyLinkPassword(ctx context.Context, host, short, password string) (string, time.Time, error) {
+	ctx, span := s.t.Start(ctx, "VerifyLinkPassword")
+	defer span.End()
+
+	link, err := s.getActiveLink(ctx, host, short)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if !link.IsProtected() {
+		return "", time.Time{}, status.Error(codes.FailedPrecondition, "link is not password-protected")
+	}
+
+	_, spanCompare := s.t.Start(ctx, "compare-password")
+	err = bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password))
+	spanCompare.End()
+	if err != nil {
+		return "", time.Time{}, status.Error(codes.PermissionDenied, "wrong password")
+	}
+
+	token, expiresAt := s.ts.Sign(tokenSubject(link))
+	return token, expiresAt, nil
+}
+
+// tokenSubject is what the token unlocking the link is signed for,
+// so the token is not valid for the same code on another domain
+func tokenSubject(link *models.Link) string {
+	return link.Domain + "/" + link.Code
+}
+
+// getLink returns link by code in the domain from cache, or from the db if it is not cached
+func (s *Service) getLink(ctx context.Context, domain models.Domain, short string) (*models.Link, error) {
+	ctxGetCache, spanGetCache := s.t.Start(ctx, "get-url-from-cache")
+	link, err := s.vr.GetLinkByCode(ctxGetCache, domain.Host, short)
+	spanGetCache.End()
+	if err != nil {
+		s.l.Error("failed to get short by url from cache", "error", err)
+	}
+	if link != nil {
+		s.l.Info("got from cache", "url", link.URL)
+		return link, nil
+	}
+
+	ctxGetDB, spanGetDB := s.t.Start(ctx, "get-url-from-db")
+	link, err = s.findLink(ctxGetDB, domain, short)
+	spanGetDB.End()
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "short not found")
+		}
+		s.l.Error("failed to get short by url", "error", err)
+		return nil, status.Error(codes.Internal, "failed to get short by url")
+	}
+
+	return link, nil
+}
+
+// findLink looks for the link by code in the domain in the db.
+// Aliases are checked first, then the code is decoded into ID of the link without alias.
+func (s *Service) findLink(ctx context.Context, domain models.Domain, short string) (*models.Link, error) {
+	link, err := s.pr.GetLinkByAlias(ctx, domain.ID, short)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if link == nil {
+		if !isCode(short) {
+			return nil, sql.ErrNoRows
+		}
+
+		link, err = s.pr.GetLink(ctx, base62.Decode(short))
+		if err != nil {
+			return nil, err
+		}
+
+		// Codes are scoped by domains, and links with alias are reachable by it only
+		if link.DomainID != domain.ID || link.Alias != "" {
+			return nil, sql.ErrNoRows
+		}
+	}
+
+	link.Code = short
+	link.Domain = domain.Host
+
+	return link, nil
+}
+
+// isCode reports whether short can be a generated code, i.e. canonical base62 encoding of ID
+func isCode(short string) bool {
+	return base62.IsCanonical(short)
+}
+
+// getActiveLink returns link by code on the host if it is active at the moment
+func (s *Service) getActiveLink(ctx context.Context, host, short string) (*models.Link, error) {
+	domain, err := s.resolveDomain(ctx, host, true)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := s.getLink(ctx, domain, short)
+	if err != nil {
+		return nil, err
+	}
+
+	// Not active links are not found, so the code doesn't reveal anything
+	if !link.IsActive(time.Now()) {
+		return nil, status.Error(codes.NotFound, "short not found")
+	}
+
+	return link, nil
 }
 
 func (s *Service) SetTop(ctx context.Context, msg *models.KafkaMessageUnshortenedTop) {
 	ctx, span := s.t.Start(ctx, "SetTop")
 	defer span.End()
 
-	top := models.UnshortenedTop{
-		ValidUntil: msg.ValidUntil,
-		Top: []struct {
-			OriginalURL string
-			ShortCode   string
-		}(msg.Top),
+	// Cache whole links instead of URLs from the message,
+	// so options are applied to the cached links too
+	top := make([]models.Link, 0, len(msg.Top))
+	seen := make(map[string]struct{}, len(msg.Top))
+	for _, entry := range msg.Top {
+		// Events without domain were written before domains were introduced,
+		// they belong to the default domain
+		domain, err := s.resolveDomain(ctx, entry.Domain, true)
+		if err != nil {
+			s.l.Error("failed to resolve domain for top", "domain", entry.Domain, "error", err)
+			continue
+		}
+
+		key := domain.Host + "/" + entry.ShortCode
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		ctxGet, spanGet := s.t.Start(ctx, "get-link-from-db")
+		link, err := s.findLink(ctxGet, domain, entry.ShortCode)
+		spanGet.End()
+		if err != nil {
+			s.l.Error("failed to get link for top", "code", entry.ShortCode, "domain", domain.Host, "error", err)
+			continue
+		}
+
+		// Protected links are always checked against the db,
+		// and links out of their activation window must not be served from cache
+		if link.IsProtected() || !link.IsActive(time.Now()) {
+			continue
+		}
+
+		top = append(top, *link)
 	}
 
-	ttl := top.ValidUntil.Sub(time.Now())
+	ttl := msg.ValidUntil.Sub(time.Now())
 
 	ctxStore, spanStore := s.t.Start(ctx, "store top in cache")
 	err := s.vr.SetTop(ctxStore, top, ttl)
@@ -261,5 +615,5 @@ func (s *Service) SetTop(ctx context.Context, msg *models.KafkaMessageUnshortene
 		return
 	}
 
-	s.l.Info("cached top", "quantity", len(top.Top))
+	s.l.Info("cached top", "quantity", len(top))
 }
@@ -3,26 +3,52 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"github.com/misshanya/url-shortener/shortener/internal/errorz"
 	"github.com/misshanya/url-shortener/shortener/internal/models"
+	"github.com/misshanya/url-shortener/shortener/pkg/ipanon"
+	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.opentelemetry.io/otel/trace/noop"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"log/slog"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 )
 
+// testDomains are the registered domains returned by the mocked db
+var testDomains = []models.Domain{
+	{ID: 1, Host: "sh.some", IsDefault: true},
+	{ID: 2, Host: "go.some"},
+}
+
+// testAnonymizer truncates IPs like the default IP_ANONYMIZATION does
+var testAnonymizer, _ = ipanon.New(ipanon.ModeTruncate, "")
+
 func Test_ShortenURL(t *testing.T) {
 	tests := []struct {
 		Name         string
 		OriginalURL  string
+		Options      models.LinkOptions
+		Password     string
+		NotBefore    time.Time
+		Domain       string
+		Alias        string
+		Metadata     models.LinkMetadata
 		ExpectedCode string
 		WantErr      bool
-		SetUpMocks   func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup)
-		WaitForKafka bool
+		// ExpectedReason is the reason of the ErrorInfo details of the error, if any
+		ExpectedReason string
+		SetUpMocks     func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup)
+		WaitForKafka   bool
 	}{
 		{
 			Name:         "New URL",
@@ -30,42 +56,209 @@ func Test_ShortenURL(t *testing.T) {
 			ExpectedCode: "1",
 			WantErr:      false,
 			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
-				db.On("GetID", mock.Anything, "https://google.com").
+				db.On("GetID", mock.Anything, "https://google.com", int32(1), "").
 					Return(int64(0), sql.ErrNoRows).Once()
-				db.On("StoreURL", mock.Anything, "https://google.com").
+				db.On("StoreURL", mock.Anything, &models.Link{URL: "https://google.com", DomainID: 1}).
 					Return(int64(1), nil).Once()
 				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
 					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
 			},
 			WaitForKafka: true,
 		},
+		{
+			Name:         "URL with metadata is never looked up and tags are sent to Kafka",
+			OriginalURL:  "https://google.com",
+			Metadata:     models.LinkMetadata{Title: "Search", Tags: []string{"search"}},
+			ExpectedCode: "7",
+			WantErr:      false,
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				db.On("StoreURL", mock.Anything, &models.Link{
+					URL:      "https://google.com",
+					DomainID: 1,
+					Metadata: models.LinkMetadata{Title: "Search", Tags: []string{"search"}},
+				}).
+					Return(int64(7), nil).Once()
+				hasTags := mock.MatchedBy(func(msgs []kafka.Message) bool {
+					return len(msgs) == 1 && strings.Contains(string(msgs[0].Value), `"tags":["search"]`)
+				})
+				kafkaWriter.On("WriteMessages", mock.Anything, hasTags).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
 		{
 			Name:        "New URL, failed to store",
 			OriginalURL: "https://google.com",
 			WantErr:     true,
 			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
-				db.On("GetID", mock.Anything, "https://google.com").
+				db.On("GetID", mock.Anything, "https://google.com", int32(1), "").
 					Return(int64(0), sql.ErrNoRows).Once()
-				db.On("StoreURL", mock.Anything, "https://google.com").
+				db.On("StoreURL", mock.Anything, &models.Link{URL: "https://google.com", DomainID: 1}).
 					Return(int64(0), errors.New("some unknown error")).Once()
 			},
 		},
+		{
+			Name:         "New URL with options is never looked up",
+			OriginalURL:  "https://google.com",
+			Options:      models.LinkOptions{ForwardQuery: true},
+			ExpectedCode: "2",
+			WantErr:      false,
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				db.On("StoreURL", mock.Anything, &models.Link{
+					URL:      "https://google.com",
+					Options:  models.LinkOptions{ForwardQuery: true},
+					DomainID: 1,
+				}).
+					Return(int64(2), nil).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:         "Protected URL is never looked up and is stored hashed",
+			OriginalURL:  "https://google.com",
+			Password:     "s3cret",
+			ExpectedCode: "3",
+			WantErr:      false,
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				isHashed := mock.MatchedBy(func(link *models.Link) bool {
+					return link.URL == "https://google.com" &&
+						bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte("s3cret")) == nil
+				})
+				db.On("StoreURL", mock.Anything, isHashed).
+					Return(int64(3), nil).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:         "Scheduled URL is never looked up",
+			OriginalURL:  "https://google.com",
+			NotBefore:    time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+			ExpectedCode: "4",
+			WantErr:      false,
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				db.On("StoreURL", mock.Anything, &models.Link{
+					URL:       "https://google.com",
+					NotBefore: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+					DomainID:  1,
+				}).
+					Return(int64(4), nil).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
 		{
 			Name:         "Existing URL",
 			OriginalURL:  "https://google.com",
 			ExpectedCode: "1",
 			WantErr:      false,
 			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
-				db.On("GetID", mock.Anything, "https://google.com").
+				db.On("GetID", mock.Anything, "https://google.com", int32(1), "").
 					Return(int64(1), nil).Once()
 			},
 		},
+		{
+			Name:         "Existing URL on another domain",
+			OriginalURL:  "https://google.com",
+			Domain:       "Go.Some",
+			ExpectedCode: "5",
+			WantErr:      false,
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				db.On("GetID", mock.Anything, "https://google.com", int32(2), "").
+					Return(int64(5), nil).Once()
+			},
+		},
+		{
+			Name:        "Unknown domain",
+			OriginalURL: "https://google.com",
+			Domain:      "unknown.some",
+			WantErr:     true,
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+			},
+		},
+		{
+			Name:         "Alias is never looked up",
+			OriginalURL:  "https://google.com",
+			Alias:        "my-promo",
+			ExpectedCode: "my-promo",
+			WantErr:      false,
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				db.On("StoreURL", mock.Anything, &models.Link{URL: "https://google.com", DomainID: 1, Alias: "my-promo"}).
+					Return(int64(6), nil).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:           "Alias is taken",
+			OriginalURL:    "https://google.com",
+			Alias:          "my-promo",
+			WantErr:        true,
+			ExpectedReason: errorz.ReasonAliasTaken,
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				db.On("StoreURL", mock.Anything, &models.Link{URL: "https://google.com", DomainID: 1, Alias: "my-promo"}).
+					Return(int64(0), errorz.ErrAliasTaken).Once()
+			},
+		},
+		{
+			Name:           "Alias equal to the code of existing link",
+			OriginalURL:    "https://google.com",
+			Alias:          "3a",
+			WantErr:        true,
+			ExpectedReason: errorz.ReasonAliasTaken,
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://go.dev"}, nil).Once()
+			},
+		},
+		{
+			Name:         "Alias equal to the code of link on another domain",
+			OriginalURL:  "https://google.com",
+			Alias:        "3a",
+			ExpectedCode: "3a",
+			WantErr:      false,
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 2, URL: "https://go.dev"}, nil).Once()
+				db.On("StoreURL", mock.Anything, &models.Link{URL: "https://google.com", DomainID: 1, Alias: "3a"}).
+					Return(int64(7), nil).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:         "Generated code shadowed by alias",
+			OriginalURL:  "https://google.com",
+			Options:      models.LinkOptions{ForwardQuery: true},
+			ExpectedCode: "9",
+			WantErr:      false,
+			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				link := &models.Link{
+					URL:      "https://google.com",
+					Options:  models.LinkOptions{ForwardQuery: true},
+					DomainID: 1,
+				}
+				db.On("StoreURL", mock.Anything, link).
+					Return(int64(0), errorz.ErrCodeShadowed).Once()
+				db.On("StoreURL", mock.Anything, link).
+					Return(int64(9), nil).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
 		{
 			Name:        "Failed to get from DB",
 			OriginalURL: "https://google.com",
 			WantErr:     true,
 			SetUpMocks: func(db *mockpostgresRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
-				db.On("GetID", mock.Anything, "https://google.com").
+				db.On("GetID", mock.Anything, "https://google.com", int32(1), "").
 					Return(int64(0), errors.New("some unknown error")).Once()
 			},
 		},
@@ -74,6 +267,8 @@ func Test_ShortenURL(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.Name, func(t *testing.T) {
 			mockPostgres := mockpostgresRepo{}
+			mockPostgres.On("ListDomains", mock.Anything).
+				Return(testDomains, nil).Maybe()
 			mockKafka := mockkafkaWriter{}
 
 			var wg sync.WaitGroup
@@ -98,14 +293,28 @@ func Test_ShortenURL(t *testing.T) {
 				),
 				&mockKafka,
 				tracer,
+				nil,
+				nil,
+				testAnonymizer,
 				10,
 			)
 
-			short := &models.Short{URL: tt.OriginalURL}
+			short := &models.Short{
+				URL:       tt.OriginalURL,
+				Options:   tt.Options,
+				Password:  tt.Password,
+				NotBefore: tt.NotBefore,
+				Domain:    tt.Domain,
+				Alias:     tt.Alias,
+				Metadata:  tt.Metadata,
+			}
 
 			err := service.ShortenURL(context.Background(), short)
 			if tt.WantErr {
 				assert.Error(t, err)
+				if tt.ExpectedReason != "" {
+					assert.Equal(t, tt.ExpectedReason, errorInfoReason(err))
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.ExpectedCode, short.Short)
@@ -121,63 +330,408 @@ func Test_ShortenURL(t *testing.T) {
 	}
 }
 
+// errorInfoReason returns the reason of the ErrorInfo details of the status error
+func errorInfoReason(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.GetReason()
+		}
+	}
+	return ""
+}
+
+func Test_ShortenURL_AllocatedID(t *testing.T) {
+	tests := []struct {
+		Name          string
+		ExpectedCode  string
+		ExceptedError codes.Code
+		SetUpMocks    func(db *mockpostgresRepo, ids *mockidAllocator, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup)
+		WaitForKafka  bool
+	}{
+		{
+			Name:          "Link is stored with allocated ID",
+			ExpectedCode:  "JMAIjoW",
+			ExceptedError: codes.OK,
+			SetUpMocks: func(db *mockpostgresRepo, ids *mockidAllocator, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				db.On("GetID", mock.Anything, "https://google.com", int32(1), "").
+					Return(int64(0), sql.ErrNoRows).Once()
+				ids.On("NextID", mock.Anything).
+					Return(int64(1<<40), nil).Once()
+				db.On("StoreURL", mock.Anything, &models.Link{ID: 1 << 40, URL: "https://google.com", DomainID: 1}).
+					Return(int64(1<<40), nil).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:          "Allocation error",
+			ExceptedError: codes.Internal,
+			SetUpMocks: func(db *mockpostgresRepo, ids *mockidAllocator, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				db.On("GetID", mock.Anything, "https://google.com", int32(1), "").
+					Return(int64(0), sql.ErrNoRows).Once()
+				ids.On("NextID", mock.Anything).
+					Return(int64(0), errors.New("some unknown error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockPostgres := mockpostgresRepo{}
+			mockPostgres.On("ListDomains", mock.Anything).
+				Return(testDomains, nil).Maybe()
+			mockIDs := mockidAllocator{}
+			mockKafka := mockkafkaWriter{}
+
+			var wg sync.WaitGroup
+
+			if tt.WaitForKafka {
+				wg.Add(1)
+			}
+
+			tt.SetUpMocks(&mockPostgres, &mockIDs, &mockKafka, &wg)
+
+			service := New(
+				&mockPostgres,
+				nil,
+				slog.New(
+					slog.NewTextHandler(
+						os.Stdout,
+						&slog.HandlerOptions{},
+					),
+				),
+				&mockKafka,
+				noop.NewTracerProvider().Tracer(""),
+				nil,
+				&mockIDs,
+				testAnonymizer,
+				10,
+			)
+
+			short := &models.Short{URL: "https://google.com"}
+
+			err := service.ShortenURL(context.Background(), short)
+			assert.Equal(t, tt.ExceptedError, status.Code(err))
+			if err == nil {
+				assert.Equal(t, tt.ExpectedCode, short.Short)
+			}
+
+			if tt.WaitForKafka {
+				wg.Wait()
+			}
+
+			mockPostgres.AssertExpectations(t)
+			mockIDs.AssertExpectations(t)
+			mockKafka.AssertExpectations(t)
+		})
+	}
+}
+
 func Test_GetURL(t *testing.T) {
 	tests := []struct {
 		Name         string
+		Host         string
 		ShortCode    string
+		Visit        models.Visit
 		ExceptedURL  string
 		WantErr      bool
-		SetUpMocks   func(db *mockpostgresRepo, valkey *mockvalkeyRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup)
+		SetUpMocks   func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup)
 		WaitForKafka bool
+
+		ExceptedInterstitial bool
+		ExceptedCacheable    bool
 	}{
 		{
-			Name:        "Existing URL",
+			Name:              "Existing URL",
+			ShortCode:         "3a",
+			ExceptedURL:       "https://google.com",
+			ExceptedCacheable: true,
+			WantErr:           false,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://google.com"}, nil).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:      "Click context is sent to Kafka",
+			ShortCode: "3a",
+			Visit: models.Visit{
+				Referer:        "https://news.some/post",
+				UserAgent:      "Mozilla/5.0 (iPhone)",
+				AcceptLanguage: "de-CH",
+				Country:        "CH",
+				IP:             "203.0.113.7",
+			},
+			ExceptedURL:       "https://google.com",
+			ExceptedCacheable: true,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://google.com"}, nil).Once()
+				hasClickContext := mock.MatchedBy(func(msgs []kafka.Message) bool {
+					if len(msgs) != 1 {
+						return false
+					}
+					var msg models.KafkaMessageUnshortened
+					if err := json.Unmarshal(msgs[0].Value, &msg); err != nil {
+						return false
+					}
+					return msg.Referer == "https://news.some/post" &&
+						msg.UserAgent == "Mozilla/5.0 (iPhone)" &&
+						msg.AcceptLanguage == "de-CH" &&
+						msg.Country == "CH" &&
+						msg.IP == "203.0.113.0" &&
+						!msg.Bot
+				})
+				kafkaWriter.On("WriteMessages", mock.Anything, hasClickContext).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:              "Link unfurler is tagged as bot",
+			ShortCode:         "3a",
+			Visit:             models.Visit{UserAgent: "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"},
+			ExceptedURL:       "https://google.com",
+			ExceptedCacheable: true,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://google.com"}, nil).Once()
+				isBot := mock.MatchedBy(func(msgs []kafka.Message) bool {
+					var msg models.KafkaMessageUnshortened
+					return len(msgs) == 1 && json.Unmarshal(msgs[0].Value, &msg) == nil &&
+						msg.Bot && msg.BotName == "slack"
+				})
+				kafkaWriter.On("WriteMessages", mock.Anything, isBot).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:                 "Interstitial link",
+			ShortCode:            "3a",
+			ExceptedURL:          "https://google.com",
+			ExceptedCacheable:    true,
+			ExceptedInterstitial: true,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://google.com", Options: models.LinkOptions{Interstitial: true}}, nil).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:              "Existing URL in cache",
+			ShortCode:         "3a",
+			ExceptedURL:       "https://google.com",
+			ExceptedCacheable: true,
+			WantErr:           false,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(&models.Link{ID: 222, Code: "3a", URL: "https://google.com"}, nil).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:              "Alias on requested domain",
+			Host:              "go.some",
+			ShortCode:         "my-promo",
+			ExceptedURL:       "https://go.dev",
+			ExceptedCacheable: true,
+			WantErr:           false,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "go.some", "my-promo").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(2), "my-promo").
+					Return(&models.Link{ID: 300, DomainID: 2, URL: "https://go.dev", Alias: "my-promo"}, nil).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:              "Unknown host falls back to default domain",
+			Host:              "localhost:8080",
+			ShortCode:         "3a",
+			ExceptedURL:       "https://google.com",
+			ExceptedCacheable: true,
+			WantErr:           false,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://google.com"}, nil).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:      "Code of link on another domain",
+			Host:      "go.some",
+			ShortCode: "3a",
+			WantErr:   true,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "go.some", "3a").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(2), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://google.com"}, nil).Once()
+			},
+		},
+		{
+			Name:      "Code of link with alias",
+			ShortCode: "3a",
+			WantErr:   true,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://google.com", Alias: "promo"}, nil).Once()
+			},
+		},
+		{
+			Name:        "Cached URL with options",
 			ShortCode:   "3a",
-			ExceptedURL: "https://google.com",
+			Visit:       models.Visit{Query: "ref=newsletter"},
+			ExceptedURL: "https://google.com?ref=newsletter&utm_campaign=3a",
 			WantErr:     false,
-			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
-				valkey.On("GetURLByCode", mock.Anything, "3a").
-					Return("", nil).Once()
-				db.On("GetURL", mock.Anything, int64(222)).
-					Return("https://google.com", nil).Once()
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(&models.Link{
+						ID:   222,
+						Code: "3a",
+						URL:  "https://google.com",
+						Options: models.LinkOptions{
+							ForwardQuery: true,
+							UTM:          map[string]string{"utm_campaign": "{code}"},
+						},
+					}, nil).Once()
 				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
 					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
 			},
 			WaitForKafka: true,
 		},
 		{
-			Name:        "Existing URL in cache",
+			Name:        "Protected URL with valid token",
 			ShortCode:   "3a",
+			Visit:       models.Visit{LinkToken: "token"},
 			ExceptedURL: "https://google.com",
 			WantErr:     false,
-			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
-				valkey.On("GetURLByCode", mock.Anything, "3a").
-					Return("https://google.com", nil).Once()
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://google.com", PasswordHash: "hash"}, nil).Once()
+				signer.On("Verify", "sh.some/3a", "token").
+					Return(true).Once()
+				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:      "Not yet active URL",
+			ShortCode: "3a",
+			WantErr:   true,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://google.com", NotBefore: time.Now().Add(time.Hour)}, nil).Once()
+			},
+		},
+		{
+			Name:      "Expired URL in cache",
+			ShortCode: "3a",
+			WantErr:   true,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(&models.Link{ID: 222, Code: "3a", URL: "https://google.com", ExpiresAt: time.Now().Add(-time.Minute)}, nil).Once()
+			},
+		},
+		{
+			Name:              "URL within its activation window",
+			ShortCode:         "3a",
+			ExceptedURL:       "https://google.com",
+			ExceptedCacheable: true,
+			WantErr:           false,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{
+						ID:        222,
+						DomainID:  1,
+						URL:       "https://google.com",
+						NotBefore: time.Now().Add(-time.Hour),
+						ExpiresAt: time.Now().Add(time.Hour),
+					}, nil).Once()
 				kafkaWriter.On("WriteMessages", mock.Anything, mock.Anything).
 					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
 			},
 			WaitForKafka: true,
 		},
+		{
+			Name:      "Protected URL without token",
+			ShortCode: "3a",
+			WantErr:   true,
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://google.com", PasswordHash: "hash"}, nil).Once()
+				signer.On("Verify", "sh.some/3a", "").
+					Return(false).Once()
+			},
+		},
 		{
 			Name:      "Non-existing URL",
 			ShortCode: "3a",
 			WantErr:   true,
-			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
-				valkey.On("GetURLByCode", mock.Anything, "3a").
-					Return("", nil).Once()
-				db.On("GetURL", mock.Anything, int64(222)).
-					Return("", sql.ErrNoRows).Once()
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(nil, sql.ErrNoRows).Once()
 			},
 		},
 		{
 			Name:      "Failed to get from DB",
 			ShortCode: "3a",
 			WantErr:   true,
-			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
-				valkey.On("GetURLByCode", mock.Anything, "3a").
-					Return("", nil).Once()
-				db.On("GetURL", mock.Anything, int64(222)).
-					Return("", errors.New("some unknown error")).Once()
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(nil, errors.New("some unknown error")).Once()
 			},
 		},
 	}
@@ -185,8 +739,11 @@ func Test_GetURL(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.Name, func(t *testing.T) {
 			mockPostgres := mockpostgresRepo{}
+			mockPostgres.On("ListDomains", mock.Anything).
+				Return(testDomains, nil).Maybe()
 			mockKafka := mockkafkaWriter{}
 			mockValkey := mockvalkeyRepo{}
+			mockSigner := mocktokenSigner{}
 
 			var wg sync.WaitGroup
 
@@ -194,7 +751,7 @@ func Test_GetURL(t *testing.T) {
 				wg.Add(1)
 			}
 
-			tt.SetUpMocks(&mockPostgres, &mockValkey, &mockKafka, &wg)
+			tt.SetUpMocks(&mockPostgres, &mockValkey, &mockSigner, &mockKafka, &wg)
 
 			tracerProvider := noop.NewTracerProvider()
 			tracer := tracerProvider.Tracer("")
@@ -210,15 +767,20 @@ func Test_GetURL(t *testing.T) {
 				),
 				&mockKafka,
 				tracer,
+				&mockSigner,
+				nil,
+				testAnonymizer,
 				10,
 			)
 
-			url, err := service.GetURL(context.Background(), tt.ShortCode)
+			redirect, err := service.GetURL(context.Background(), tt.Host, tt.ShortCode, tt.Visit)
 			if tt.WantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.ExceptedURL, url)
+				assert.Equal(t, tt.ExceptedURL, redirect.URL)
+				assert.Equal(t, tt.ExceptedInterstitial, redirect.Interstitial)
+				assert.Equal(t, tt.ExceptedCacheable, redirect.Cacheable)
 			}
 
 			if tt.WaitForKafka {
@@ -227,16 +789,219 @@ func Test_GetURL(t *testing.T) {
 
 			mockPostgres.AssertExpectations(t)
 			mockValkey.AssertExpectations(t)
+			mockSigner.AssertExpectations(t)
 			mockKafka.AssertExpectations(t)
 		})
 	}
 }
 
+func Test_RecordVisit(t *testing.T) {
+	visitedAt := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		Name         string
+		ShortCode    string
+		ExceptedErr  error
+		SetUpMocks   func(db *mockpostgresRepo, valkey *mockvalkeyRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup)
+		WaitForKafka bool
+	}{
+		{
+			Name:      "Visit is sent to Kafka at its time",
+			ShortCode: "3a",
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(&models.Link{ID: 222, DomainID: 1, Domain: "sh.some", Code: "3a", URL: "https://google.com"}, nil).Once()
+				isVisit := mock.MatchedBy(func(msgs []kafka.Message) bool {
+					if len(msgs) != 1 || msgs[0].Topic != "shortener.unshortened" {
+						return false
+					}
+					var msg models.KafkaMessageUnshortened
+					if err := json.Unmarshal(msgs[0].Value, &msg); err != nil {
+						return false
+					}
+					return msg.UnshortenedAt.Equal(visitedAt) &&
+						msg.OriginalURL == "https://google.com" &&
+						msg.ShortCode == "3a" &&
+						msg.Domain == "sh.some" &&
+						msg.Country == "CH" &&
+						msg.Rule == "germany" &&
+						msg.Variant == "b"
+				})
+				kafkaWriter.On("WriteMessages", mock.Anything, isVisit).
+					Return(nil).Once().Run(func(args mock.Arguments) { wg.Done() })
+			},
+			WaitForKafka: true,
+		},
+		{
+			Name:        "Link is not active anymore",
+			ShortCode:   "3a",
+			ExceptedErr: status.Error(codes.NotFound, "short not found"),
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, kafkaWriter *mockkafkaWriter, wg *sync.WaitGroup) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(&models.Link{ID: 222, DomainID: 1, Code: "3a", URL: "https://google.com", ExpiresAt: time.Now().Add(-time.Minute)}, nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockPostgres := mockpostgresRepo{}
+			mockPostgres.On("ListDomains", mock.Anything).
+				Return(testDomains, nil).Maybe()
+			mockValkey := mockvalkeyRepo{}
+			mockKafka := mockkafkaWriter{}
+
+			var wg sync.WaitGroup
+			if tt.WaitForKafka {
+				wg.Add(1)
+			}
+
+			tt.SetUpMocks(&mockPostgres, &mockValkey, &mockKafka, &wg)
+
+			service := newLinksTestService(&mockPostgres, &mockValkey)
+			service.kw = &mockKafka
+
+			err := service.RecordVisit(context.Background(), "", tt.ShortCode, models.Visit{Country: "CH", Rule: "germany", Variant: "b"}, visitedAt)
+			assert.Equal(t, tt.ExceptedErr, err)
+
+			if tt.WaitForKafka {
+				wg.Wait()
+			}
+
+			mockPostgres.AssertExpectations(t)
+			mockValkey.AssertExpectations(t)
+			mockKafka.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_ResolveURL(t *testing.T) {
+	mockPostgres := mockpostgresRepo{}
+	mockPostgres.On("ListDomains", mock.Anything).
+		Return(testDomains, nil).Maybe()
+	mockValkey := mockvalkeyRepo{}
+	mockValkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+		Return(&models.Link{ID: 222, DomainID: 1, Domain: "sh.some", Code: "3a", URL: "https://google.com"}, nil).Once()
+	mockKafka := mockkafkaWriter{}
+
+	service := newLinksTestService(&mockPostgres, &mockValkey)
+	service.kw = &mockKafka
+
+	// The visit is not written, the caller records it
+	redirect, err := service.ResolveURL(context.Background(), "", "3a", models.Visit{})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Redirect{URL: "https://google.com", Cacheable: true}, redirect)
+
+	mockValkey.AssertExpectations(t)
+	mockKafka.AssertExpectations(t)
+}
+
+func Test_VerifyLinkPassword(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expiresAt := time.Now().Add(15 * time.Minute)
+
+	tests := []struct {
+		Name          string
+		ShortCode     string
+		Password      string
+		ExceptedToken string
+		ExceptedErr   error
+		SetUpMocks    func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner)
+	}{
+		{
+			Name:          "Correct password",
+			ShortCode:     "3a",
+			Password:      "s3cret",
+			ExceptedToken: "token",
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://google.com", PasswordHash: string(hash)}, nil).Once()
+				signer.On("Sign", "sh.some/3a").
+					Return("token", expiresAt).Once()
+			},
+		},
+		{
+			Name:        "Wrong password",
+			ShortCode:   "3a",
+			Password:    "qwerty",
+			ExceptedErr: status.Error(codes.PermissionDenied, "wrong password"),
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(nil, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://google.com", PasswordHash: string(hash)}, nil).Once()
+			},
+		},
+		{
+			Name:        "Link is not protected",
+			ShortCode:   "3a",
+			Password:    "s3cret",
+			ExceptedErr: status.Error(codes.FailedPrecondition, "link is not password-protected"),
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo, signer *mocktokenSigner) {
+				valkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+					Return(&models.Link{ID: 222, Code: "3a", URL: "https://google.com"}, nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockPostgres := mockpostgresRepo{}
+			mockPostgres.On("ListDomains", mock.Anything).
+				Return(testDomains, nil).Maybe()
+			mockValkey := mockvalkeyRepo{}
+			mockSigner := mocktokenSigner{}
+
+			tt.SetUpMocks(&mockPostgres, &mockValkey, &mockSigner)
+
+			tracerProvider := noop.NewTracerProvider()
+			tracer := tracerProvider.Tracer("")
+
+			service := New(
+				&mockPostgres,
+				&mockValkey,
+				slog.New(
+					slog.NewTextHandler(
+						os.Stdout,
+						&slog.HandlerOptions{},
+					),
+				),
+				nil,
+				tracer,
+				&mockSigner,
+				nil,
+				testAnonymizer,
+				10,
+			)
+
+			token, exp, err := service.VerifyLinkPassword(context.Background(), "", tt.ShortCode, tt.Password)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedToken, token)
+			if tt.ExceptedErr == nil {
+				assert.Equal(t, expiresAt, exp)
+			}
+
+			mockPostgres.AssertExpectations(t)
+			mockValkey.AssertExpectations(t)
+			mockSigner.AssertExpectations(t)
+		})
+	}
+}
+
 func Test_SetTop(t *testing.T) {
 	tests := []struct {
 		Name         string
 		InputMessage *models.KafkaMessageUnshortenedTop
-		SetUpMocks   func(valkey *mockvalkeyRepo, exceptedTop models.UnshortenedTop)
+		SetUpMocks   func(db *mockpostgresRepo, valkey *mockvalkeyRepo)
 	}{
 		{
 			Name: "Successfully cached top",
@@ -245,6 +1010,67 @@ func Test_SetTop(t *testing.T) {
 				Top: []struct {
 					OriginalURL string `json:"original_url"`
 					ShortCode   string `json:"short_code"`
+					Domain      string `json:"domain,omitempty"`
+				}{
+					{
+						OriginalURL: "https://go.dev",
+						ShortCode:   "3a",
+					},
+					{
+						OriginalURL: "https://github.com",
+						ShortCode:   "1",
+					},
+				},
+			},
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://go.dev"}, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "1").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(1)).
+					Return(&models.Link{ID: 1, DomainID: 1, URL: "https://github.com"}, nil).Once()
+				valkey.On("SetTop", mock.Anything, []models.Link{
+					{ID: 222, Code: "3a", URL: "https://go.dev", DomainID: 1, Domain: "sh.some"},
+					{ID: 1, Code: "1", URL: "https://github.com", DomainID: 1, Domain: "sh.some"},
+				}, mock.Anything).
+					Return(nil).Once()
+			},
+		},
+		{
+			Name: "Cached alias on its domain",
+			InputMessage: &models.KafkaMessageUnshortenedTop{
+				ValidUntil: time.Now().Add(time.Hour),
+				Top: []struct {
+					OriginalURL string `json:"original_url"`
+					ShortCode   string `json:"short_code"`
+					Domain      string `json:"domain,omitempty"`
+				}{
+					{
+						OriginalURL: "https://go.dev",
+						ShortCode:   "my-promo",
+						Domain:      "go.some",
+					},
+				},
+			},
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(2), "my-promo").
+					Return(&models.Link{ID: 300, DomainID: 2, URL: "https://go.dev", Alias: "my-promo"}, nil).Once()
+				valkey.On("SetTop", mock.Anything, []models.Link{
+					{ID: 300, Code: "my-promo", URL: "https://go.dev", DomainID: 2, Domain: "go.some", Alias: "my-promo"},
+				}, mock.Anything).
+					Return(nil).Once()
+			},
+		},
+		{
+			Name: "Skipped protected link",
+			InputMessage: &models.KafkaMessageUnshortenedTop{
+				ValidUntil: time.Now().Add(time.Hour),
+				Top: []struct {
+					OriginalURL string `json:"original_url"`
+					ShortCode   string `json:"short_code"`
+					Domain      string `json:"domain,omitempty"`
 				}{
 					{
 						OriginalURL: "https://go.dev",
@@ -256,8 +1082,76 @@ func Test_SetTop(t *testing.T) {
 					},
 				},
 			},
-			SetUpMocks: func(valkey *mockvalkeyRepo, exceptedTop models.UnshortenedTop) {
-				valkey.On("SetTop", mock.Anything, exceptedTop, mock.Anything).
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://go.dev", PasswordHash: "hash"}, nil).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "1").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(1)).
+					Return(&models.Link{ID: 1, DomainID: 1, URL: "https://github.com"}, nil).Once()
+				valkey.On("SetTop", mock.Anything, []models.Link{
+					{ID: 1, Code: "1", URL: "https://github.com", DomainID: 1, Domain: "sh.some"},
+				}, mock.Anything).
+					Return(nil).Once()
+			},
+		},
+		{
+			Name: "Skipped not yet active link",
+			InputMessage: &models.KafkaMessageUnshortenedTop{
+				ValidUntil: time.Now().Add(time.Hour),
+				Top: []struct {
+					OriginalURL string `json:"original_url"`
+					ShortCode   string `json:"short_code"`
+					Domain      string `json:"domain,omitempty"`
+				}{
+					{
+						OriginalURL: "https://go.dev",
+						ShortCode:   "3a",
+					},
+				},
+			},
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(&models.Link{ID: 222, DomainID: 1, URL: "https://go.dev", NotBefore: time.Now().Add(time.Hour)}, nil).Once()
+				valkey.On("SetTop", mock.Anything, []models.Link{}, mock.Anything).
+					Return(nil).Once()
+			},
+		},
+		{
+			Name: "Skipped link that failed to load",
+			InputMessage: &models.KafkaMessageUnshortenedTop{
+				ValidUntil: time.Now().Add(time.Hour),
+				Top: []struct {
+					OriginalURL string `json:"original_url"`
+					ShortCode   string `json:"short_code"`
+					Domain      string `json:"domain,omitempty"`
+				}{
+					{
+						OriginalURL: "https://go.dev",
+						ShortCode:   "3a",
+					},
+					{
+						OriginalURL: "https://github.com",
+						ShortCode:   "1",
+					},
+				},
+			},
+			SetUpMocks: func(db *mockpostgresRepo, valkey *mockvalkeyRepo) {
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "3a").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(222)).
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLinkByAlias", mock.Anything, int32(1), "1").
+					Return(nil, sql.ErrNoRows).Once()
+				db.On("GetLink", mock.Anything, int64(1)).
+					Return(&models.Link{ID: 1, DomainID: 1, URL: "https://github.com"}, nil).Once()
+				valkey.On("SetTop", mock.Anything, []models.Link{
+					{ID: 1, Code: "1", URL: "https://github.com", DomainID: 1, Domain: "sh.some"},
+				}, mock.Anything).
 					Return(nil).Once()
 			},
 		},
@@ -265,23 +1159,18 @@ func Test_SetTop(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.Name, func(t *testing.T) {
+			mockPostgres := mockpostgresRepo{}
+			mockPostgres.On("ListDomains", mock.Anything).
+				Return(testDomains, nil).Maybe()
 			mockValkey := mockvalkeyRepo{}
 
-			exceptedTop := models.UnshortenedTop{
-				ValidUntil: tt.InputMessage.ValidUntil,
-				Top: []struct {
-					OriginalURL string
-					ShortCode   string
-				}(tt.InputMessage.Top),
-			}
-
-			tt.SetUpMocks(&mockValkey, exceptedTop)
+			tt.SetUpMocks(&mockPostgres, &mockValkey)
 
 			tracerProvider := noop.NewTracerProvider()
 			tracer := tracerProvider.Tracer("")
 
 			service := New(
-				nil,
+				&mockPostgres,
 				&mockValkey,
 				slog.New(
 					slog.NewTextHandler(
@@ -291,12 +1180,75 @@ func Test_SetTop(t *testing.T) {
 				),
 				nil,
 				tracer,
+				nil,
+				nil,
+				testAnonymizer,
 				10,
 			)
 
 			service.SetTop(context.Background(), tt.InputMessage)
 
+			mockPostgres.AssertExpectations(t)
 			mockValkey.AssertExpectations(t)
 		})
 	}
 }
+
+func Test_isCode(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Short    string
+		Excepted bool
+	}{
+		{Name: "Code", Short: "1z", Excepted: true},
+		{Name: "Snowflake code", Short: "4HplznYO", Excepted: true},
+		{Name: "Greatest code", Short: "AzL8n0Y58m7", Excepted: true},
+		{Name: "Overflow", Short: "AzL8n0Y58m8", Excepted: false},
+		{Name: "Too long", Short: "100000000000", Excepted: false},
+		{Name: "Leading zero", Short: "01", Excepted: false},
+		{Name: "Not base62", Short: "my-link", Excepted: false},
+		{Name: "Empty", Short: "", Excepted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Excepted, isCode(tt.Short))
+		})
+	}
+}
+
+func Test_PreviewURL(t *testing.T) {
+	mockPostgres := mockpostgresRepo{}
+	mockPostgres.On("ListDomains", mock.Anything).
+		Return(testDomains, nil).Maybe()
+	mockValkey := mockvalkeyRepo{}
+	mockValkey.On("GetLinkByCode", mock.Anything, "sh.some", "3a").
+		Return(&models.Link{ID: 222, Code: "3a", DomainID: 1, URL: "https://google.com"}, nil).Once()
+	mockValkey.On("GetLinkByCode", mock.Anything, "sh.some", "3b").
+		Return(&models.Link{ID: 223, Code: "3b", DomainID: 1, URL: "https://google.com", ExpiresAt: time.Now().Add(-time.Hour)}, nil).Once()
+	// No unshortened event is written for the preview
+	mockKafka := mockkafkaWriter{}
+
+	tracerProvider := noop.NewTracerProvider()
+	service := New(
+		&mockPostgres,
+		&mockValkey,
+		slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{})),
+		&mockKafka,
+		tracerProvider.Tracer(""),
+		nil,
+		nil,
+		testAnonymizer,
+		10,
+	)
+
+	link, err := service.PreviewURL(context.Background(), "", "3a")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://google.com", link.URL)
+
+	_, err = service.PreviewURL(context.Background(), "", "3b")
+	assert.Equal(t, status.Error(codes.NotFound, "short not found"), err)
+
+	mockValkey.AssertExpectations(t)
+	mockKafka.AssertExpectations(t)
+}
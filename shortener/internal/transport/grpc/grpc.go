@@ -2,55 +2,561 @@ package grpc
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
 	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"github.com/misshanya/url-shortener/shortener/internal/audit"
+	"github.com/misshanya/url-shortener/shortener/internal/errorz"
 	"github.com/misshanya/url-shortener/shortener/internal/models"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 )
 
 type service interface {
 	ShortenURL(ctx context.Context, short *models.Short) error
 	ShortenURLBatch(ctx context.Context, shorts []*models.Short)
-	GetURL(ctx context.Context, short string) (string, error)
+	GetURL(ctx context.Context, host, short string, visit models.Visit) (*models.Redirect, error)
+	ResolveURL(ctx context.Context, host, short string, visit models.Visit) (*models.Redirect, error)
+	RecordVisit(ctx context.Context, host, short string, visit models.Visit, at time.Time) error
+	PreviewURL(ctx context.Context, host, short string) (*models.Link, error)
+	VerifyLinkPassword(ctx context.Context, host, short, password string) (string, time.Time, error)
+	CreateDomain(ctx context.Context, host string) (*models.Domain, error)
+	ListDomains(ctx context.Context) ([]models.Domain, error)
+	ListURLs(ctx context.Context, filter models.LinkFilter, cursor string, pageSize int) ([]models.Link, string, error)
+	SetLinkMetadata(ctx context.Context, host, short string, metadata models.LinkMetadata) error
+	GetLinkMetadata(ctx context.Context, host, short, owner string) (*models.LinkMetadata, error)
+	ListTags(ctx context.Context, owner string) ([]models.TagCount, error)
+	DeleteURL(ctx context.Context, host, short string) error
+	DisableURL(ctx context.Context, host, short string) (*models.Link, error)
+	RetargetURL(ctx context.Context, host, short, url string) (*models.Link, error)
+	GetCacheEntry(ctx context.Context, host, short string) (*models.Link, time.Duration, error)
+	ListAuditEvents(ctx context.Context, host string, filter models.AuditFilter, cursor string, pageSize int) ([]models.AuditEvent, string, error)
+	CreateAPIKey(ctx context.Context, principal, name string, scopes []string) (*models.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+// Metadata keys with the visitor attributes forwarded by the gateway
+const (
+	mdReferer        = "x-client-referer"
+	mdUserAgent      = "x-client-user-agent"
+	mdPlatform       = "x-client-platform"
+	mdAcceptLanguage = "x-client-accept-language"
+	mdCountry        = "x-client-country"
+	mdVisitorID      = "x-client-visitor-id"
+	mdIP             = "x-client-ip"
+	mdLinkToken      = "x-link-token"
+)
+
+// mdPrincipal is the metadata key with the principal calling the API, recorded as the owner of new links
+const mdPrincipal = "x-principal"
+
+// mdAuthorization is the metadata key with the bearer token of the admin RPCs
+const mdAuthorization = "authorization"
+
+// mdRequestID is the metadata key with the ID of the request, recorded in the audit log
+const mdRequestID = "x-request-id"
+
+// maxPasswordLength is the bcrypt limit of the password length in bytes
+const maxPasswordLength = 72
+
+// aliasPattern limits custom codes to URL-safe characters
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,64}$`)
+
+// checkAlias validates the custom code of the link
+func checkAlias(alias string) error {
+	if !aliasPattern.MatchString(alias) {
+		return invalidField("alias", errors.New("bad alias"))
+	}
+	if models.IsReservedAlias(alias) {
+		return invalidField("alias", errors.New("alias is reserved"))
+	}
+	return nil
+}
+
+// fieldError is the validation error of the request field, e.g. "rules.0.platform"
+type fieldError struct {
+	field string
+	err   error
+}
+
+func (e *fieldError) Error() string {
+	return e.err.Error()
+}
+
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
+
+// invalidField names the field of the validation error
+func invalidField(field string, err error) error {
+	return &fieldError{field: field, err: err}
+}
+
+// invalidArgument maps the validation error into the InvalidArgument status, the error of a field adds its violation to the details
+func invalidArgument(err error) error {
+	var fieldErr *fieldError
+	if errors.As(err, &fieldErr) {
+		return errorz.InvalidField(fieldErr.field, fieldErr.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// tagPattern limits tags to lower case words, so they are easy to type and query
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,31}$`)
+
+// Limits of the link metadata, lengths are in characters
+const (
+	maxTitleLength = 256
+	maxNotesLength = 4096
+	maxTags        = 20
+)
+
+var platforms = map[string]struct{}{
+	"ios": {}, "android": {}, "windows": {}, "macos": {}, "linux": {}, "chromeos": {},
+	"mobile": {}, "desktop": {},
 }
 
 type Handler struct {
 	service service
+
+	// adminToken is the bearer token of the admin RPCs, they are disabled if it is empty
+	adminToken string
+
 	pb.UnimplementedURLShortenerServiceServer
 }
 
-func NewHandler(grpcServer *grpc.Server, service service) {
-	shortenerGrpc := &Handler{service: service}
+func NewHandler(grpcServer *grpc.Server, service service, adminToken string) {
+	shortenerGrpc := &Handler{service: service, adminToken: adminToken}
 	pb.RegisterURLShortenerServiceServer(grpcServer, shortenerGrpc)
 }
 
+// linkOptions maps request into link options and validates them
+func linkOptions(req *pb.ShortenURLRequest) (models.LinkOptions, error) {
+	options := models.LinkOptions{
+		ForwardQuery: req.ForwardQuery,
+		Interstitial: req.Interstitial,
+	}
+
+	if len(req.Utm) > 0 {
+		options.UTM = make(map[string]string, len(req.Utm))
+		for key, value := range req.Utm {
+			if !strings.HasPrefix(key, "utm_") || len(key) == len("utm_") {
+				return models.LinkOptions{}, invalidField("utm", fmt.Errorf("bad UTM parameter %q", key))
+			}
+			options.UTM[key] = value
+		}
+	}
+
+	for i, rule := range req.Rules {
+		if _, err := url.ParseRequestURI(rule.Url); err != nil {
+			return models.LinkOptions{}, invalidField(fmt.Sprintf("rules.%d.url", i), fmt.Errorf("bad URL in rule %d", i+1))
+		}
+
+		platform := strings.ToLower(rule.Platform)
+		if _, ok := platforms[platform]; platform != "" && !ok {
+			return models.LinkOptions{}, invalidField(fmt.Sprintf("rules.%d.platform", i), fmt.Errorf("unknown platform %q in rule %d", rule.Platform, i+1))
+		}
+
+		var countries []string
+		for _, country := range rule.Countries {
+			if !isCountryCode(country) {
+				return models.LinkOptions{}, invalidField(fmt.Sprintf("rules.%d.countries", i), fmt.Errorf("bad country %q in rule %d", country, i+1))
+			}
+			countries = append(countries, strings.ToUpper(country))
+		}
+
+		if rule.UserAgent == "" && platform == "" && rule.AcceptLanguage == "" && len(countries) == 0 {
+			return models.LinkOptions{}, invalidField(fmt.Sprintf("rules.%d", i), fmt.Errorf("rule %d has no conditions", i+1))
+		}
+
+		options.Rules = append(options.Rules, models.RoutingRule{
+			URL:            rule.Url,
+			Name:           rule.Name,
+			UserAgent:      rule.UserAgent,
+			Platform:       platform,
+			AcceptLanguage: rule.AcceptLanguage,
+			Countries:      countries,
+		})
+	}
+
+	for i, destination := range req.Destinations {
+		if _, err := url.ParseRequestURI(destination.Url); err != nil {
+			return models.LinkOptions{}, invalidField(fmt.Sprintf("destinations.%d.url", i), fmt.Errorf("bad URL in destination %d", i+1))
+		}
+		if destination.Weight == 0 {
+			return models.LinkOptions{}, invalidField(fmt.Sprintf("destinations.%d.weight", i), fmt.Errorf("destination %d has zero weight", i+1))
+		}
+
+		options.Destinations = append(options.Destinations, models.Destination{
+			URL:    destination.Url,
+			Weight: destination.Weight,
+			Name:   destination.Name,
+		})
+	}
+
+	return options, nil
+}
+
+// activationWindow maps and validates the time the link is active, zero times are not limited
+func activationWindow(req *pb.ShortenURLRequest) (time.Time, time.Time, error) {
+	if req.NotBefore < 0 {
+		return time.Time{}, time.Time{}, invalidField("not_before", errors.New("bad activation window"))
+	}
+	if req.ExpiresAt < 0 {
+		return time.Time{}, time.Time{}, invalidField("expires_at", errors.New("bad activation window"))
+	}
+
+	var notBefore, expiresAt time.Time
+	if req.NotBefore > 0 {
+		notBefore = time.Unix(req.NotBefore, 0).UTC()
+	}
+	if req.ExpiresAt > 0 {
+		expiresAt = time.Unix(req.ExpiresAt, 0).UTC()
+		if !expiresAt.After(time.Now()) {
+			return time.Time{}, time.Time{}, invalidField("expires_at", errors.New("expiration time is in the past"))
+		}
+		if !notBefore.IsZero() && !expiresAt.After(notBefore) {
+			return time.Time{}, time.Time{}, invalidField("expires_at", errors.New("expiration time is not after activation time"))
+		}
+	}
+	return notBefore, expiresAt, nil
+}
+
+// domainHost validates the host of the domain and brings it to lower case
+func domainHost(host string) (string, error) {
+	u, err := url.Parse("//" + host)
+	if err != nil || host == "" || u.Host != host || u.User != nil || u.Hostname() == "" {
+		return "", errors.New("bad host")
+	}
+	return strings.ToLower(host), nil
+}
+
+// isCountryCode reports whether s looks like ISO 3166-1 alpha-2 code
+func isCountryCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// linkMetadata validates title, notes and tags, tags are brought to lower case, deduplicated and sorted
+func linkMetadata(title, notes string, tags []string) (models.LinkMetadata, error) {
+	if len([]rune(title)) > maxTitleLength {
+		return models.LinkMetadata{}, invalidField("title", errors.New("title is too long"))
+	}
+	if len([]rune(notes)) > maxNotesLength {
+		return models.LinkMetadata{}, invalidField("notes", errors.New("notes are too long"))
+	}
+
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return models.LinkMetadata{}, invalidField("tags", err)
+	}
+	if len(normalized) > maxTags {
+		return models.LinkMetadata{}, invalidField("tags", errors.New("too many tags"))
+	}
+
+	return models.LinkMetadata{Title: title, Notes: notes, Tags: normalized}, nil
+}
+
+// normalizeTags brings tags to lower case, deduplicates and sorts them, nil if there are no tags
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return nil, errors.New("bad tag")
+		}
+		normalized = append(normalized, tag)
+	}
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized), nil
+}
+
+// requireAdmin checks the admin token in the authorization metadata
+func (h *Handler) requireAdmin(ctx context.Context) error {
+	if h.adminToken == "" {
+		return status.Error(codes.PermissionDenied, "admin RPCs are disabled")
+	}
+
+	scheme, token, ok := strings.Cut(metadataValue(ctx, mdAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+		return status.Error(codes.Unauthenticated, "admin token required")
+	}
+	return nil
+}
+
+// callerScope tells whose links the caller may read: admins read all of them, other principals only their own.
+// The caller with neither the admin token nor a principal is rejected.
+func (h *Handler) callerScope(ctx context.Context) (principal string, admin bool, err error) {
+	if h.requireAdmin(ctx) == nil {
+		return "", true, nil
+	}
+
+	principal = principalFromContext(ctx)
+	if principal == "" {
+		return "", false, status.Error(codes.Unauthenticated, "principal or admin token required")
+	}
+	return principal, false, nil
+}
+
+// principalFromContext returns the principal calling the API, empty if anonymous
+func principalFromContext(ctx context.Context) string {
+	return metadataValue(ctx, mdPrincipal)
+}
+
+// metadataValue returns the first value of the incoming metadata key, empty if there is none
+func metadataValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// ActorInterceptor puts the principal and the request ID from the metadata into the request context,
+// so they are recorded with link changes. The request ID is generated if the caller didn't send one.
+func ActorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		requestID := metadataValue(ctx, mdRequestID)
+		if requestID == "" {
+			requestID = audit.NewRequestID()
+		}
+
+		ctx = audit.WithActor(ctx, audit.Actor{Principal: principalFromContext(ctx), RequestID: requestID})
+		return handler(ctx, req)
+	}
+}
+
+// auditFilter maps request into audit filter and validates it
+func auditFilter(req *pb.ListAuditEventsRequest) (models.AuditFilter, error) {
+	filter := models.AuditFilter{
+		Code:   req.Code,
+		Actor:  req.Actor,
+		Action: models.AuditAction(req.Action),
+	}
+
+	switch filter.Action {
+	case "", models.AuditCreate, models.AuditImport, models.AuditDelete,
+		models.AuditRetarget, models.AuditSetDomain, models.AuditSetExpiresAt, models.AuditUpdateMetadata:
+	default:
+		return models.AuditFilter{}, errors.New("bad action")
+	}
+
+	return filter, nil
+}
+
+// linkFilter maps request into link filter and validates it
+func linkFilter(req *pb.ListURLsRequest) (models.LinkFilter, error) {
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return models.LinkFilter{}, err
+	}
+
+	filter := models.LinkFilter{
+		Owner:   req.Owner,
+		Domain:  req.Domain,
+		Enabled: req.Enabled,
+		Search:  req.Search,
+		Tags:    tags,
+	}
+
+	if req.CreatedFrom < 0 || req.CreatedTo < 0 {
+		return models.LinkFilter{}, errors.New("bad created range")
+	}
+	if req.CreatedFrom > 0 {
+		filter.CreatedFrom = time.Unix(req.CreatedFrom, 0)
+	}
+	if req.CreatedTo > 0 {
+		filter.CreatedTo = time.Unix(req.CreatedTo, 0)
+		if !filter.CreatedTo.After(filter.CreatedFrom) {
+			return models.LinkFilter{}, errors.New("bad created range")
+		}
+	}
+
+	return filter, nil
+}
+
+// unixOrZero returns Unix seconds of t, 0 for zero time
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// apiKeyToProto maps the API key into the API message, the hash of the key is never sent
+func apiKeyToProto(key *models.APIKey) *pb.APIKey {
+	return &pb.APIKey{
+		Id:        key.ID,
+		Prefix:    key.Prefix,
+		Principal: key.Principal,
+		Name:      key.Name,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt.Unix(),
+		RevokedAt: unixOrZero(key.RevokedAt),
+	}
+}
+
+// linkToProto maps the link into the API message, Enabled tells if it is active at now
+func linkToProto(link *models.Link, now time.Time) *pb.Link {
+	return &pb.Link{
+		Code:              link.Code,
+		Domain:            link.Domain,
+		OriginalUrl:       link.URL,
+		Owner:             link.Owner,
+		CreatedAt:         unixOrZero(link.CreatedAt),
+		NotBefore:         unixOrZero(link.NotBefore),
+		ExpiresAt:         unixOrZero(link.ExpiresAt),
+		PasswordProtected: link.IsProtected(),
+		Enabled:           link.IsActive(now),
+		Title:             link.Metadata.Title,
+		Tags:              link.Metadata.Tags,
+	}
+}
+
+// visitFromRequest collects the visitor attributes from the request and its metadata
+func visitFromRequest(ctx context.Context, req *pb.GetURLRequest) models.Visit {
+	visit := models.Visit{Query: req.Query}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return visit
+	}
+
+	get := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	visit.Referer = get(mdReferer)
+	visit.UserAgent = get(mdUserAgent)
+	visit.Platform = get(mdPlatform)
+	visit.AcceptLanguage = get(mdAcceptLanguage)
+	visit.Country = strings.ToUpper(get(mdCountry))
+	visit.VisitorID = get(mdVisitorID)
+	visit.LinkToken = get(mdLinkToken)
+	visit.IP = get(mdIP)
+
+	return visit
+}
+
 func (h *Handler) ShortenURL(ctx context.Context, req *pb.ShortenURLRequest) (*pb.ShortenURLResponse, error) {
 	short := models.Short{URL: req.Url}
 
 	// Validate URL
 	if _, err := url.ParseRequestURI(short.URL); err != nil {
-		return nil, status.Error(codes.InvalidArgument, "bad URL")
+		return nil, errorz.InvalidField("url", "bad URL")
+	}
+
+	options, err := linkOptions(req)
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+	short.Options = options
+
+	if len(req.Password) > maxPasswordLength {
+		return nil, errorz.InvalidField("password", "password is too long")
+	}
+	short.Password = req.Password
+
+	short.NotBefore, short.ExpiresAt, err = activationWindow(req)
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	if req.Alias != "" {
+		if err := checkAlias(req.Alias); err != nil {
+			return nil, invalidArgument(err)
+		}
+	}
+	short.Alias = req.Alias
+	short.Domain = req.Domain
+	short.Owner = principalFromContext(ctx)
+
+	short.Metadata, err = linkMetadata(req.Title, req.Notes, req.Tags)
+	if err != nil {
+		return nil, invalidArgument(err)
 	}
 
 	if err := h.service.ShortenURL(ctx, &short); err != nil {
 		return nil, err
 	}
 
-	return &pb.ShortenURLResponse{Code: short.Short, OriginalUrl: short.URL}, nil
+	return &pb.ShortenURLResponse{Code: short.Short, OriginalUrl: short.URL, Domain: short.Domain}, nil
 }
 
 func (h *Handler) ShortenURLBatch(ctx context.Context, req *pb.ShortenURLBatchRequest) (*pb.ShortenURLBatchResponse, error) {
 	shorts := make([]*models.Short, len(req.Urls))
+	owner := principalFromContext(ctx)
 
 	// Validate and map URLs into models
 	for i, reqUrl := range req.Urls {
-		short := models.Short{URL: reqUrl.Url}
+		short := models.Short{URL: reqUrl.Url, Owner: owner}
 		shorts[i] = &short
 
 		if _, err := url.ParseRequestURI(reqUrl.Url); err != nil {
 			short.Error = err
+			continue
+		}
+
+		options, err := linkOptions(reqUrl)
+		if err != nil {
+			short.Error = err
+			continue
+		}
+		short.Options = options
+
+		if len(reqUrl.Password) > maxPasswordLength {
+			short.Error = errors.New("password is too long")
+			continue
+		}
+		short.Password = reqUrl.Password
+
+		short.NotBefore, short.ExpiresAt, err = activationWindow(reqUrl)
+		if err != nil {
+			short.Error = err
+			continue
+		}
+
+		if reqUrl.Alias != "" {
+			if err := checkAlias(reqUrl.Alias); err != nil {
+				short.Error = err
+				continue
+			}
+		}
+		short.Alias = reqUrl.Alias
+		short.Domain = reqUrl.Domain
+
+		short.Metadata, err = linkMetadata(reqUrl.Title, reqUrl.Notes, reqUrl.Tags)
+		if err != nil {
+			short.Error = err
+			continue
 		}
 	}
 
@@ -67,6 +573,7 @@ func (h *Handler) ShortenURLBatch(ctx context.Context, req *pb.ShortenURLBatchRe
 			continue
 		}
 		resp.Code = short.Short
+		resp.Domain = short.Domain
 	}
 
 	return &response, nil
@@ -74,11 +581,360 @@ func (h *Handler) ShortenURLBatch(ctx context.Context, req *pb.ShortenURLBatchRe
 
 func (h *Handler) GetURL(ctx context.Context, req *pb.GetURLRequest) (*pb.GetURLResponse, error) {
 	code := req.Code
+	visit := visitFromRequest(ctx, req)
+
+	resolve := h.service.GetURL
+	if req.SkipVisit {
+		resolve = h.service.ResolveURL
+	}
+	redirect, err := resolve(ctx, req.Domain, code, visit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetURLResponse{
+		Url:          redirect.URL,
+		Interstitial: redirect.Interstitial,
+		Cacheable:    redirect.Cacheable,
+		ExpiresAt:    unixOrZero(redirect.ExpiresAt),
+		Rule:         redirect.Rule,
+		Variant:      redirect.Variant,
+	}, nil
+}
+
+func (h *Handler) RecordVisits(ctx context.Context, req *pb.RecordVisitsRequest) (*pb.RecordVisitsResponse, error) {
+	if err := h.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var recorded int32
+	for _, v := range req.Visits {
+		visit := models.Visit{
+			Referer:        v.Referer,
+			UserAgent:      v.UserAgent,
+			AcceptLanguage: v.AcceptLanguage,
+			Country:        strings.ToUpper(v.Country),
+			IP:             v.Ip,
+			Rule:           v.Rule,
+			Variant:        v.Variant,
+		}
+		if err := h.service.RecordVisit(ctx, v.Domain, v.Code, visit, time.Unix(v.VisitedAt, 0)); err != nil {
+			continue
+		}
+		recorded++
+	}
+
+	return &pb.RecordVisitsResponse{Recorded: recorded}, nil
+}
+
+func (h *Handler) PreviewURL(ctx context.Context, req *pb.PreviewURLRequest) (*pb.LinkPreview, error) {
+	link, err := h.service.PreviewURL(ctx, req.Domain, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &pb.LinkPreview{
+		Code:              link.Code,
+		Domain:            link.Domain,
+		CreatedAt:         unixOrZero(link.CreatedAt),
+		PasswordProtected: link.IsProtected(),
+		Interstitial:      link.Options.Interstitial,
+	}
+	// The password protects the destination too
+	if !link.IsProtected() {
+		preview.Url = link.URL
+	}
+
+	return preview, nil
+}
+
+func (h *Handler) VerifyLinkPassword(ctx context.Context, req *pb.VerifyLinkPasswordRequest) (*pb.VerifyLinkPasswordResponse, error) {
+	if req.Password == "" {
+		return nil, status.Error(codes.InvalidArgument, "password is required")
+	}
+
+	token, expiresAt, err := h.service.VerifyLinkPassword(ctx, req.Domain, req.Code, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.VerifyLinkPasswordResponse{Token: token, ExpiresAt: expiresAt.Unix()}, nil
+}
+
+func (h *Handler) CreateDomain(ctx context.Context, req *pb.CreateDomainRequest) (*pb.Domain, error) {
+	if err := h.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	host, err := domainHost(req.Host)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	domain, err := h.service.CreateDomain(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Domain{Host: domain.Host, IsDefault: domain.IsDefault}, nil
+}
+
+func (h *Handler) ListDomains(ctx context.Context, req *pb.ListDomainsRequest) (*pb.ListDomainsResponse, error) {
+	domains, err := h.service.ListDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := pb.ListDomainsResponse{Domains: make([]*pb.Domain, len(domains))}
+	for i, domain := range domains {
+		response.Domains[i] = &pb.Domain{Host: domain.Host, IsDefault: domain.IsDefault}
+	}
+
+	return &response, nil
+}
+
+func (h *Handler) ListURLs(ctx context.Context, req *pb.ListURLsRequest) (*pb.ListURLsResponse, error) {
+	principal, admin, err := h.callerScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.PageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "bad page size")
+	}
+
+	filter, err := linkFilter(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !admin {
+		filter.Owner = principal
+	}
+
+	links, next, err := h.service.ListURLs(ctx, filter, req.Cursor, int(req.PageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	response := pb.ListURLsResponse{Links: make([]*pb.Link, len(links)), NextCursor: next}
+	for i := range links {
+		response.Links[i] = linkToProto(&links[i], now)
+
+		// Destinations of protected links are shown to admins only, like PreviewURL hides them
+		if !admin && links[i].IsProtected() {
+			response.Links[i].OriginalUrl = ""
+		}
+	}
+
+	return &response, nil
+}
+
+func (h *Handler) SetLinkMetadata(ctx context.Context, req *pb.SetLinkMetadataRequest) (*pb.LinkMetadata, error) {
+	if err := h.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	metadata, err := linkMetadata(req.Title, req.Notes, req.Tags)
+	if err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	if err := h.service.SetLinkMetadata(ctx, req.Domain, req.Code, metadata); err != nil {
+		return nil, err
+	}
+
+	return &pb.LinkMetadata{Title: metadata.Title, Notes: metadata.Notes, Tags: metadata.Tags}, nil
+}
+
+func (h *Handler) GetLinkMetadata(ctx context.Context, req *pb.GetLinkMetadataRequest) (*pb.LinkMetadata, error) {
+	principal, _, err := h.callerScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Admins read the metadata of any link, the principal is empty for them
+	metadata, err := h.service.GetLinkMetadata(ctx, req.Domain, req.Code, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.LinkMetadata{Title: metadata.Title, Notes: metadata.Notes, Tags: metadata.Tags}, nil
+}
+
+func (h *Handler) ListTags(ctx context.Context, req *pb.ListTagsRequest) (*pb.ListTagsResponse, error) {
+	principal, _, err := h.callerScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := h.service.ListTags(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	response := pb.ListTagsResponse{Tags: make([]*pb.Tag, len(tags))}
+	for i, tag := range tags {
+		response.Tags[i] = &pb.Tag{Name: tag.Name, Links: tag.Links}
+	}
+
+	return &response, nil
+}
+
+func (h *Handler) DeleteURL(ctx context.Context, req *pb.DeleteURLRequest) (*pb.DeleteURLResponse, error) {
+	if err := h.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := h.service.DeleteURL(ctx, req.Domain, req.Code); err != nil {
+		return nil, err
+	}
+
+	return &pb.DeleteURLResponse{}, nil
+}
+
+func (h *Handler) DisableURL(ctx context.Context, req *pb.DisableURLRequest) (*pb.Link, error) {
+	if err := h.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	link, err := h.service.DisableURL(ctx, req.Domain, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	return linkToProto(link, time.Now()), nil
+}
+
+func (h *Handler) RetargetURL(ctx context.Context, req *pb.RetargetURLRequest) (*pb.Link, error) {
+	if err := h.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := url.ParseRequestURI(req.Url); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "bad URL")
+	}
+
+	link, err := h.service.RetargetURL(ctx, req.Domain, req.Code, req.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	return linkToProto(link, time.Now()), nil
+}
+
+func (h *Handler) GetCacheEntry(ctx context.Context, req *pb.GetCacheEntryRequest) (*pb.CacheEntry, error) {
+	if err := h.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	link, ttl, err := h.service.GetCacheEntry(ctx, req.Domain, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return &pb.CacheEntry{}, nil
+	}
+
+	return &pb.CacheEntry{Cached: true, Link: linkToProto(link, time.Now()), Ttl: int64(ttl.Seconds())}, nil
+}
+
+func (h *Handler) ListAuditEvents(ctx context.Context, req *pb.ListAuditEventsRequest) (*pb.ListAuditEventsResponse, error) {
+	// Admins read the whole log, other principals only the changes they made
+	principal := principalFromContext(ctx)
+	adminErr := h.requireAdmin(ctx)
+	if adminErr != nil && principal == "" {
+		return nil, adminErr
+	}
+
+	if req.PageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "bad page size")
+	}
+
+	filter, err := auditFilter(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if adminErr != nil {
+		filter.Actor = principal
+	}
+
+	events, next, err := h.service.ListAuditEvents(ctx, req.Domain, filter, req.Cursor, int(req.PageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	response := pb.ListAuditEventsResponse{Events: make([]*pb.AuditEvent, len(events)), NextCursor: next}
+	for i, event := range events {
+		response.Events[i] = &pb.AuditEvent{
+			Id:        event.ID,
+			Code:      event.Code,
+			Domain:    event.Domain,
+			Action:    string(event.Action),
+			Actor:     event.Actor,
+			RequestId: event.RequestID,
+			OldValue:  string(event.OldValue),
+			NewValue:  string(event.NewValue),
+			CreatedAt: event.CreatedAt.UnixMicro(),
+		}
+	}
+
+	return &response, nil
+}
+
+func (h *Handler) CreateAPIKey(ctx context.Context, req *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	if err := h.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	apiKey, key, err := h.service.CreateAPIKey(ctx, req.Principal, req.Name, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateAPIKeyResponse{ApiKey: apiKeyToProto(apiKey), Key: key}, nil
+}
+
+func (h *Handler) ListAPIKeys(ctx context.Context, req *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	if err := h.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	keys, err := h.service.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := pb.ListAPIKeysResponse{ApiKeys: make([]*pb.APIKey, len(keys))}
+	for i := range keys {
+		response.ApiKeys[i] = apiKeyToProto(&keys[i])
+	}
+
+	return &response, nil
+}
+
+func (h *Handler) RevokeAPIKey(ctx context.Context, req *pb.RevokeAPIKeyRequest) (*pb.APIKey, error) {
+	if err := h.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	key, err := h.service.RevokeAPIKey(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKeyToProto(key), nil
+}
+
+func (h *Handler) AuthenticateAPIKey(ctx context.Context, req *pb.AuthenticateAPIKeyRequest) (*pb.APIKey, error) {
+	if req.Key == "" {
+		return nil, status.Error(codes.Unauthenticated, "api key is required")
+	}
 
-	originalURL, err := h.service.GetURL(ctx, code)
+	key, err := h.service.AuthenticateAPIKey(ctx, req.Key)
 	if err != nil {
 		return nil, err
 	}
 
-	return &pb.GetURLResponse{Url: originalURL}, nil
+	return apiKeyToProto(key), nil
 }
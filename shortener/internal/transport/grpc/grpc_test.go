@@ -4,12 +4,20 @@ import (
 	"context"
 	"errors"
 	pb "github.com/misshanya/url-shortener/gen/go/v1"
+	"github.com/misshanya/url-shortener/shortener/internal/audit"
+	"github.com/misshanya/url-shortener/shortener/internal/errorz"
 	"github.com/misshanya/url-shortener/shortener/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"strings"
 	"testing"
+	"time"
 )
 
 func Test_ShortenURL(t *testing.T) {
@@ -33,11 +41,197 @@ func Test_ShortenURL(t *testing.T) {
 				}).Once()
 			},
 		},
+		{
+			Name: "Successfully Shortened with metadata",
+			InputReq: &pb.ShortenURLRequest{
+				Url:   "https://go.dev",
+				Title: "Go",
+				Tags:  []string{"Go", " docs", "go"},
+			},
+			ExceptedResponse: &pb.ShortenURLResponse{Code: "3m", OriginalUrl: "https://go.dev"},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice, short *models.Short) {
+				short.Metadata = models.LinkMetadata{Title: "Go", Tags: []string{"docs", "go"}}
+				service.On("ShortenURL", mock.Anything, short).
+					Return(nil).Run(func(args mock.Arguments) {
+					shortArg := args.Get(1).(*models.Short)
+					shortArg.Short = "3m"
+				}).Once()
+			},
+		},
+		{
+			Name:             "Bad tag",
+			InputReq:         &pb.ShortenURLRequest{Url: "https://go.dev", Tags: []string{"go dev"}},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("tags", "bad tag"),
+			SetUpMocks:       func(service *mockservice, short *models.Short) {},
+		},
+		{
+			Name: "Successfully Shortened with options",
+			InputReq: &pb.ShortenURLRequest{
+				Url:          "https://go.dev",
+				ForwardQuery: true,
+				Utm:          map[string]string{"utm_source": "shortener"},
+			},
+			ExceptedResponse: &pb.ShortenURLResponse{Code: "3b", OriginalUrl: "https://go.dev"},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice, short *models.Short) {
+				short.Options = models.LinkOptions{
+					ForwardQuery: true,
+					UTM:          map[string]string{"utm_source": "shortener"},
+				}
+				service.On("ShortenURL", mock.Anything, short).
+					Return(nil).Run(func(args mock.Arguments) {
+					shortArg := args.Get(1).(*models.Short)
+					shortArg.Short = "3b"
+				}).Once()
+			},
+		},
+		{
+			Name:             "Successfully Shortened with password",
+			InputReq:         &pb.ShortenURLRequest{Url: "https://go.dev", Password: "s3cret"},
+			ExceptedResponse: &pb.ShortenURLResponse{Code: "3c", OriginalUrl: "https://go.dev"},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice, short *models.Short) {
+				short.Password = "s3cret"
+				service.On("ShortenURL", mock.Anything, short).
+					Return(nil).Run(func(args mock.Arguments) {
+					shortArg := args.Get(1).(*models.Short)
+					shortArg.Short = "3c"
+				}).Once()
+			},
+		},
+		{
+			Name: "Successfully Shortened with activation window",
+			InputReq: &pb.ShortenURLRequest{
+				Url:       "https://go.dev",
+				NotBefore: 1_700_000_000,
+				ExpiresAt: 4_100_000_000,
+			},
+			ExceptedResponse: &pb.ShortenURLResponse{Code: "3d", OriginalUrl: "https://go.dev"},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice, short *models.Short) {
+				short.NotBefore = time.Unix(1_700_000_000, 0).UTC()
+				short.ExpiresAt = time.Unix(4_100_000_000, 0).UTC()
+				service.On("ShortenURL", mock.Anything, short).
+					Return(nil).Run(func(args mock.Arguments) {
+					shortArg := args.Get(1).(*models.Short)
+					shortArg.Short = "3d"
+				}).Once()
+			},
+		},
+		{
+			Name:             "Successfully Shortened with alias on domain",
+			InputReq:         &pb.ShortenURLRequest{Url: "https://go.dev", Domain: "go.some", Alias: "my-promo"},
+			ExceptedResponse: &pb.ShortenURLResponse{Code: "my-promo", OriginalUrl: "https://go.dev", Domain: "go.some"},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice, short *models.Short) {
+				short.Domain = "go.some"
+				short.Alias = "my-promo"
+				service.On("ShortenURL", mock.Anything, short).
+					Return(nil).Run(func(args mock.Arguments) {
+					shortArg := args.Get(1).(*models.Short)
+					shortArg.Short = "my-promo"
+				}).Once()
+			},
+		},
+		{
+			Name:             "Invalid alias",
+			InputReq:         &pb.ShortenURLRequest{Url: "https://go.dev", Alias: "my promo"},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("alias", "bad alias"),
+			SetUpMocks:       func(service *mockservice, short *models.Short) {},
+		},
+		{
+			Name:             "Reserved alias",
+			InputReq:         &pb.ShortenURLRequest{Url: "https://go.dev", Alias: "docs"},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("alias", "alias is reserved"),
+			SetUpMocks:       func(service *mockservice, short *models.Short) {},
+		},
+		{
+			Name:             "Expiration time in the past",
+			InputReq:         &pb.ShortenURLRequest{Url: "https://go.dev", ExpiresAt: 1_700_000_000},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("expires_at", "expiration time is in the past"),
+			SetUpMocks:       func(service *mockservice, short *models.Short) {},
+		},
+		{
+			Name: "Expiration time before activation time",
+			InputReq: &pb.ShortenURLRequest{
+				Url:       "https://go.dev",
+				NotBefore: 4_100_000_000,
+				ExpiresAt: 4_000_000_000,
+			},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("expires_at", "expiration time is not after activation time"),
+			SetUpMocks:       func(service *mockservice, short *models.Short) {},
+		},
+		{
+			Name:             "Too long password",
+			InputReq:         &pb.ShortenURLRequest{Url: "https://go.dev", Password: strings.Repeat("a", 73)},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("password", "password is too long"),
+			SetUpMocks:       func(service *mockservice, short *models.Short) {},
+		},
+		{
+			Name: "Rule without conditions",
+			InputReq: &pb.ShortenURLRequest{
+				Url:   "https://go.dev",
+				Rules: []*pb.RoutingRule{{Url: "https://go.dev/ios"}},
+			},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("rules.0", "rule 1 has no conditions"),
+			SetUpMocks:       func(service *mockservice, short *models.Short) {},
+		},
+		{
+			Name: "Rule with unknown platform",
+			InputReq: &pb.ShortenURLRequest{
+				Url:   "https://go.dev",
+				Rules: []*pb.RoutingRule{{Url: "https://go.dev/ios", Platform: "symbian"}},
+			},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("rules.0.platform", `unknown platform "symbian" in rule 1`),
+			SetUpMocks:       func(service *mockservice, short *models.Short) {},
+		},
+		{
+			Name: "Rule with invalid country",
+			InputReq: &pb.ShortenURLRequest{
+				Url:   "https://go.dev",
+				Rules: []*pb.RoutingRule{{Url: "https://go.dev/de", Countries: []string{"DEU"}}},
+			},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("rules.0.countries", `bad country "DEU" in rule 1`),
+			SetUpMocks:       func(service *mockservice, short *models.Short) {},
+		},
+		{
+			Name: "Destination with zero weight",
+			InputReq: &pb.ShortenURLRequest{
+				Url: "https://go.dev",
+				Destinations: []*pb.Destination{
+					{Url: "https://go.dev/a", Weight: 70},
+					{Url: "https://go.dev/b"},
+				},
+			},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("destinations.1.weight", "destination 2 has zero weight"),
+			SetUpMocks:       func(service *mockservice, short *models.Short) {},
+		},
+		{
+			Name: "Invalid UTM parameter",
+			InputReq: &pb.ShortenURLRequest{
+				Url: "https://go.dev",
+				Utm: map[string]string{"source": "shortener"},
+			},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("utm", `bad UTM parameter "source"`),
+			SetUpMocks:       func(service *mockservice, short *models.Short) {},
+		},
 		{
 			Name:             "Invalid input URL",
 			InputReq:         &pb.ShortenURLRequest{Url: "some invalid url"},
 			ExceptedResponse: nil,
-			ExceptedErr:      status.Error(codes.InvalidArgument, "bad URL"),
+			ExceptedErr:      errorz.InvalidField("url", "bad URL"),
 			SetUpMocks:       func(service *mockservice, short *models.Short) {},
 		},
 		{
@@ -63,7 +257,7 @@ func Test_ShortenURL(t *testing.T) {
 			handler := Handler{service: &mockService}
 
 			resp, err := handler.ShortenURL(context.Background(), tt.InputReq)
-			assert.Equal(t, tt.ExceptedErr, err)
+			assertStatus(t, tt.ExceptedErr, err)
 			assert.Equal(t, tt.ExceptedResponse, resp)
 
 			mockService.AssertExpectations(t)
@@ -71,6 +265,31 @@ func Test_ShortenURL(t *testing.T) {
 	}
 }
 
+func Test_ShortenURL_ErrorDetails(t *testing.T) {
+	handler := Handler{service: &mockservice{}}
+
+	_, err := handler.ShortenURL(context.Background(), &pb.ShortenURLRequest{
+		Url:   "https://go.dev",
+		Rules: []*pb.RoutingRule{{Url: "https://go.dev/ios", Platform: "symbian"}},
+	})
+
+	s := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, s.Code())
+	if assert.Len(t, s.Details(), 2) {
+		info, ok := s.Details()[0].(*errdetails.ErrorInfo)
+		if assert.True(t, ok) {
+			assert.Equal(t, "INVALID_ARGUMENT", info.GetReason())
+			assert.Equal(t, "shortener", info.GetDomain())
+			assert.Equal(t, map[string]string{"field": "rules.0.platform"}, info.GetMetadata())
+		}
+		badRequest, ok := s.Details()[1].(*errdetails.BadRequest)
+		if assert.True(t, ok) && assert.Len(t, badRequest.GetFieldViolations(), 1) {
+			assert.Equal(t, "rules.0.platform", badRequest.GetFieldViolations()[0].GetField())
+			assert.Equal(t, `unknown platform "symbian" in rule 1`, badRequest.GetFieldViolations()[0].GetDescription())
+		}
+	}
+}
+
 func Test_ShortenURLBatch(t *testing.T) {
 	tests := []struct {
 		Name             string
@@ -141,6 +360,7 @@ func Test_GetURL(t *testing.T) {
 	tests := []struct {
 		Name             string
 		InputReq         *pb.GetURLRequest
+		Metadata         metadata.MD
 		ExceptedResponse *pb.GetURLResponse
 		ExceptedErr      error
 		SetUpMocks       func(service *mockservice, code string)
@@ -151,8 +371,48 @@ func Test_GetURL(t *testing.T) {
 			ExceptedResponse: &pb.GetURLResponse{Url: "https://go.dev"},
 			ExceptedErr:      nil,
 			SetUpMocks: func(service *mockservice, code string) {
-				service.On("GetURL", mock.Anything, code).
-					Return("https://go.dev", nil).Once()
+				service.On("GetURL", mock.Anything, "", code, models.Visit{}).
+					Return(&models.Redirect{URL: "https://go.dev"}, nil).Once()
+			},
+		},
+		{
+			Name:     "Visitor attributes are read from metadata",
+			InputReq: &pb.GetURLRequest{Code: "3a", Query: "ref=tg"},
+			Metadata: metadata.Pairs(
+				"x-client-referer", "https://news.some/post",
+				"x-client-user-agent", "Mozilla/5.0 (iPhone)",
+				"x-client-platform", "ios",
+				"x-client-accept-language", "en-US",
+				"x-client-country", "de",
+				"x-client-visitor-id", "f00d",
+				"x-client-ip", "203.0.113.7",
+				"x-link-token", "token",
+			),
+			ExceptedResponse: &pb.GetURLResponse{Url: "https://go.dev/ios"},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice, code string) {
+				service.On("GetURL", mock.Anything, "", code, models.Visit{
+					Query:          "ref=tg",
+					Referer:        "https://news.some/post",
+					UserAgent:      "Mozilla/5.0 (iPhone)",
+					Platform:       "ios",
+					AcceptLanguage: "en-US",
+					Country:        "DE",
+					VisitorID:      "f00d",
+					LinkToken:      "token",
+					IP:             "203.0.113.7",
+				}).
+					Return(&models.Redirect{URL: "https://go.dev/ios"}, nil).Once()
+			},
+		},
+		{
+			Name:             "Successfully Got URL on domain",
+			InputReq:         &pb.GetURLRequest{Code: "my-promo", Domain: "go.some"},
+			ExceptedResponse: &pb.GetURLResponse{Url: "https://go.dev"},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice, code string) {
+				service.On("GetURL", mock.Anything, "go.some", code, models.Visit{}).
+					Return(&models.Redirect{URL: "https://go.dev"}, nil).Once()
 			},
 		},
 		{
@@ -161,8 +421,38 @@ func Test_GetURL(t *testing.T) {
 			ExceptedResponse: nil,
 			ExceptedErr:      errors.New("some error"),
 			SetUpMocks: func(service *mockservice, code string) {
-				service.On("GetURL", mock.Anything, code).
-					Return("", errors.New("some error")).Once()
+				service.On("GetURL", mock.Anything, "", code, models.Visit{}).
+					Return(nil, errors.New("some error")).Once()
+			},
+		},
+		{
+			Name:             "Cacheable link",
+			InputReq:         &pb.GetURLRequest{Code: "3a"},
+			ExceptedResponse: &pb.GetURLResponse{Url: "https://go.dev", Cacheable: true, ExpiresAt: 1893492000},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice, code string) {
+				service.On("GetURL", mock.Anything, "", code, models.Visit{}).
+					Return(&models.Redirect{URL: "https://go.dev", Cacheable: true, ExpiresAt: time.Unix(1893492000, 0)}, nil).Once()
+			},
+		},
+		{
+			Name:             "Visit is skipped",
+			InputReq:         &pb.GetURLRequest{Code: "3a", SkipVisit: true},
+			ExceptedResponse: &pb.GetURLResponse{Url: "https://go.dev/de", Rule: "germany", Variant: "b"},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice, code string) {
+				service.On("ResolveURL", mock.Anything, "", code, models.Visit{}).
+					Return(&models.Redirect{URL: "https://go.dev/de", Rule: "germany", Variant: "b"}, nil).Once()
+			},
+		},
+		{
+			Name:             "Interstitial link",
+			InputReq:         &pb.GetURLRequest{Code: "3a"},
+			ExceptedResponse: &pb.GetURLResponse{Url: "https://go.dev", Interstitial: true},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice, code string) {
+				service.On("GetURL", mock.Anything, "", code, models.Visit{}).
+					Return(&models.Redirect{URL: "https://go.dev", Interstitial: true}, nil).Once()
 			},
 		},
 	}
@@ -175,7 +465,690 @@ func Test_GetURL(t *testing.T) {
 
 			handler := Handler{service: &mockService}
 
-			resp, err := handler.GetURL(context.Background(), tt.InputReq)
+			ctx := context.Background()
+			if tt.Metadata != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.Metadata)
+			}
+
+			resp, err := handler.GetURL(ctx, tt.InputReq)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedResponse, resp)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_RecordVisits(t *testing.T) {
+	mockService := mockservice{}
+	mockService.On("RecordVisit", mock.Anything, "go.some", "docs", models.Visit{
+		Referer:        "https://news.some/post",
+		UserAgent:      "Mozilla/5.0 (iPhone)",
+		AcceptLanguage: "en-US",
+		Country:        "DE",
+		IP:             "203.0.113.7",
+		Rule:           "germany",
+		Variant:        "b",
+	}, time.Unix(1893492000, 0)).
+		Return(nil).Once()
+	mockService.On("RecordVisit", mock.Anything, "", "gone", models.Visit{}, time.Unix(1893492001, 0)).
+		Return(status.Error(codes.NotFound, "short not found")).Once()
+
+	handler := Handler{service: &mockService, adminToken: "admin-secret"}
+
+	resp, err := handler.RecordVisits(adminContext(), &pb.RecordVisitsRequest{Visits: []*pb.Visit{
+		{
+			Code:           "docs",
+			Domain:         "go.some",
+			VisitedAt:      1893492000,
+			Referer:        "https://news.some/post",
+			UserAgent:      "Mozilla/5.0 (iPhone)",
+			AcceptLanguage: "en-US",
+			Ip:             "203.0.113.7",
+			Country:        "de",
+			Rule:           "germany",
+			Variant:        "b",
+		},
+		{Code: "gone", VisitedAt: 1893492001},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, &pb.RecordVisitsResponse{Recorded: 1}, resp)
+
+	mockService.AssertExpectations(t)
+}
+
+func Test_VerifyLinkPassword(t *testing.T) {
+	expiresAt := time.Unix(1_700_000_900, 0)
+
+	tests := []struct {
+		Name             string
+		InputReq         *pb.VerifyLinkPasswordRequest
+		ExceptedResponse *pb.VerifyLinkPasswordResponse
+		ExceptedErr      error
+		SetUpMocks       func(service *mockservice)
+	}{
+		{
+			Name:             "Successfully Verified",
+			InputReq:         &pb.VerifyLinkPasswordRequest{Code: "3a", Password: "s3cret"},
+			ExceptedResponse: &pb.VerifyLinkPasswordResponse{Token: "token", ExpiresAt: 1_700_000_900},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice) {
+				service.On("VerifyLinkPassword", mock.Anything, "", "3a", "s3cret").
+					Return("token", expiresAt, nil).Once()
+			},
+		},
+		{
+			Name:             "Empty password",
+			InputReq:         &pb.VerifyLinkPasswordRequest{Code: "3a"},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.InvalidArgument, "password is required"),
+			SetUpMocks:       func(service *mockservice) {},
+		},
+		{
+			Name:             "Wrong password",
+			InputReq:         &pb.VerifyLinkPasswordRequest{Code: "3a", Password: "qwerty"},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.PermissionDenied, "wrong password"),
+			SetUpMocks: func(service *mockservice) {
+				service.On("VerifyLinkPassword", mock.Anything, "", "3a", "qwerty").
+					Return("", time.Time{}, status.Error(codes.PermissionDenied, "wrong password")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			handler := Handler{service: &mockService}
+
+			resp, err := handler.VerifyLinkPassword(context.Background(), tt.InputReq)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedResponse, resp)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_CreateDomain(t *testing.T) {
+	tests := []struct {
+		Name             string
+		InputToken       string
+		InputReq         *pb.CreateDomainRequest
+		ExceptedResponse *pb.Domain
+		ExceptedErr      error
+		SetUpMocks       func(service *mockservice)
+	}{
+		{
+			Name:             "Successfully Created",
+			InputToken:       "Bearer admin-secret",
+			InputReq:         &pb.CreateDomainRequest{Host: "Go.Some"},
+			ExceptedResponse: &pb.Domain{Host: "go.some"},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice) {
+				service.On("CreateDomain", mock.Anything, "go.some").
+					Return(&models.Domain{ID: 2, Host: "go.some"}, nil).Once()
+			},
+		},
+		{
+			Name:             "Successfully Created with port",
+			InputToken:       "Bearer admin-secret",
+			InputReq:         &pb.CreateDomainRequest{Host: "localhost:8080"},
+			ExceptedResponse: &pb.Domain{Host: "localhost:8080"},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice) {
+				service.On("CreateDomain", mock.Anything, "localhost:8080").
+					Return(&models.Domain{ID: 3, Host: "localhost:8080"}, nil).Once()
+			},
+		},
+		{
+			Name:             "Host with path",
+			InputToken:       "Bearer admin-secret",
+			InputReq:         &pb.CreateDomainRequest{Host: "go.some/links"},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.InvalidArgument, "bad host"),
+			SetUpMocks:       func(service *mockservice) {},
+		},
+		{
+			Name:             "Empty host",
+			InputToken:       "Bearer admin-secret",
+			InputReq:         &pb.CreateDomainRequest{},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.InvalidArgument, "bad host"),
+			SetUpMocks:       func(service *mockservice) {},
+		},
+		{
+			Name:             "Domain already exists",
+			InputToken:       "Bearer admin-secret",
+			InputReq:         &pb.CreateDomainRequest{Host: "go.some"},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.AlreadyExists, "domain already exists"),
+			SetUpMocks: func(service *mockservice) {
+				service.On("CreateDomain", mock.Anything, "go.some").
+					Return(nil, status.Error(codes.AlreadyExists, "domain already exists")).Once()
+			},
+		},
+		{
+			Name:             "No admin token",
+			InputReq:         &pb.CreateDomainRequest{Host: "go.some"},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.Unauthenticated, "admin token required"),
+			SetUpMocks:       func(service *mockservice) {},
+		},
+		{
+			Name:             "Wrong admin token",
+			InputToken:       "Bearer guess",
+			InputReq:         &pb.CreateDomainRequest{Host: "go.some"},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.Unauthenticated, "admin token required"),
+			SetUpMocks:       func(service *mockservice) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			handler := Handler{service: &mockService, adminToken: "admin-secret"}
+
+			ctx := context.Background()
+			if tt.InputToken != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.InputToken))
+			}
+
+			resp, err := handler.CreateDomain(ctx, tt.InputReq)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedResponse, resp)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Admin RPCs are disabled without the token", func(t *testing.T) {
+		handler := Handler{service: &mockservice{}}
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "))
+		resp, err := handler.CreateDomain(ctx, &pb.CreateDomainRequest{Host: "go.some"})
+		assert.Equal(t, status.Error(codes.PermissionDenied, "admin RPCs are disabled"), err)
+		assert.Nil(t, resp)
+	})
+}
+
+func Test_ListDomains(t *testing.T) {
+	mockService := mockservice{}
+	mockService.On("ListDomains", mock.Anything).
+		Return([]models.Domain{
+			{ID: 1, Host: "sh.some", IsDefault: true},
+			{ID: 2, Host: "go.some"},
+		}, nil).Once()
+
+	handler := Handler{service: &mockService}
+
+	resp, err := handler.ListDomains(context.Background(), &pb.ListDomainsRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, &pb.ListDomainsResponse{Domains: []*pb.Domain{
+		{Host: "sh.some", IsDefault: true},
+		{Host: "go.some"},
+	}}, resp)
+
+	mockService.AssertExpectations(t)
+}
+
+func Test_ListURLs(t *testing.T) {
+	enabled := true
+	createdAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		Name             string
+		InputCtx         context.Context
+		InputReq         *pb.ListURLsRequest
+		ExceptedResponse *pb.ListURLsResponse
+		ExceptedErr      error
+		SetUpMocks       func(service *mockservice)
+	}{
+		{
+			Name:     "Successfully Listed",
+			InputCtx: adminContext(),
+			InputReq: &pb.ListURLsRequest{
+				Cursor:      "NjI",
+				PageSize:    10,
+				Owner:       "telegram:1",
+				Domain:      "go.some",
+				CreatedFrom: createdAt.Unix(),
+				Enabled:     &enabled,
+				Search:      "google",
+			},
+			ExceptedResponse: &pb.ListURLsResponse{
+				Links: []*pb.Link{
+					{
+						Code:              "1z",
+						Domain:            "go.some",
+						OriginalUrl:       "https://google.com",
+						Owner:             "telegram:1",
+						CreatedAt:         createdAt.Unix(),
+						PasswordProtected: true,
+						Enabled:           true,
+					},
+				},
+				NextCursor: "MTIx",
+			},
+			SetUpMocks: func(service *mockservice) {
+				service.On("ListURLs", mock.Anything, models.LinkFilter{
+					Owner:       "telegram:1",
+					Domain:      "go.some",
+					CreatedFrom: time.Unix(createdAt.Unix(), 0),
+					Enabled:     &enabled,
+					Search:      "google",
+				}, "NjI", 10).
+					Return([]models.Link{
+						{
+							ID:           123,
+							Code:         "1z",
+							Domain:       "go.some",
+							URL:          "https://google.com",
+							Owner:        "telegram:1",
+							CreatedAt:    createdAt,
+							PasswordHash: "hash",
+						},
+					}, "MTIx", nil).Once()
+			},
+		},
+		{
+			Name:             "Negative page size",
+			InputCtx:         adminContext(),
+			InputReq:         &pb.ListURLsRequest{PageSize: -1},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.InvalidArgument, "bad page size"),
+			SetUpMocks:       func(service *mockservice) {},
+		},
+		{
+			Name:             "Created range is empty",
+			InputCtx:         adminContext(),
+			InputReq:         &pb.ListURLsRequest{CreatedFrom: createdAt.Unix(), CreatedTo: createdAt.Unix()},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.InvalidArgument, "bad created range"),
+			SetUpMocks:       func(service *mockservice) {},
+		},
+		{
+			Name:             "Bad cursor",
+			InputCtx:         adminContext(),
+			InputReq:         &pb.ListURLsRequest{Cursor: "bad"},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.InvalidArgument, "bad cursor"),
+			SetUpMocks: func(service *mockservice) {
+				service.On("ListURLs", mock.Anything, models.LinkFilter{}, "bad", 0).
+					Return(nil, "", status.Error(codes.InvalidArgument, "bad cursor")).Once()
+			},
+		},
+		{
+			Name:     "Principal lists only own links",
+			InputCtx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(mdPrincipal, "telegram:1")),
+			InputReq: &pb.ListURLsRequest{Owner: "telegram:2"},
+			ExceptedResponse: &pb.ListURLsResponse{
+				Links: []*pb.Link{
+					{Code: "1z", Domain: "go.some", Owner: "telegram:1", CreatedAt: createdAt.Unix(), PasswordProtected: true, Enabled: true},
+					{Code: "2a", Domain: "go.some", OriginalUrl: "https://go.dev", Owner: "telegram:1", CreatedAt: createdAt.Unix(), Enabled: true},
+				},
+			},
+			SetUpMocks: func(service *mockservice) {
+				service.On("ListURLs", mock.Anything, models.LinkFilter{Owner: "telegram:1"}, "", 0).
+					Return([]models.Link{
+						{ID: 123, Code: "1z", Domain: "go.some", URL: "https://google.com", Owner: "telegram:1", CreatedAt: createdAt, PasswordHash: "hash"},
+						{ID: 124, Code: "2a", Domain: "go.some", URL: "https://go.dev", Owner: "telegram:1", CreatedAt: createdAt},
+					}, "", nil).Once()
+			},
+		},
+		{
+			Name:             "Anonymous",
+			InputCtx:         context.Background(),
+			InputReq:         &pb.ListURLsRequest{},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.Unauthenticated, "principal or admin token required"),
+			SetUpMocks:       func(service *mockservice) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			handler := Handler{service: &mockService, adminToken: "admin-secret"}
+
+			resp, err := handler.ListURLs(tt.InputCtx, tt.InputReq)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedResponse, resp)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_SetLinkMetadata(t *testing.T) {
+	tests := []struct {
+		Name             string
+		InputReq         *pb.SetLinkMetadataRequest
+		ExceptedResponse *pb.LinkMetadata
+		ExceptedErr      error
+		SetUpMocks       func(service *mockservice)
+	}{
+		{
+			Name:             "Successfully Set",
+			InputReq:         &pb.SetLinkMetadataRequest{Code: "3a", Domain: "go.some", Title: "Go", Tags: []string{"go", "Docs"}},
+			ExceptedResponse: &pb.LinkMetadata{Title: "Go", Tags: []string{"docs", "go"}},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice) {
+				service.On("SetLinkMetadata", mock.Anything, "go.some", "3a", models.LinkMetadata{Title: "Go", Tags: []string{"docs", "go"}}).
+					Return(nil).Once()
+			},
+		},
+		{
+			Name:             "Title is too long",
+			InputReq:         &pb.SetLinkMetadataRequest{Code: "3a", Title: strings.Repeat("ы", 257)},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("title", "title is too long"),
+			SetUpMocks:       func(service *mockservice) {},
+		},
+		{
+			Name:             "Too many tags",
+			InputReq:         &pb.SetLinkMetadataRequest{Code: "3a", Tags: strings.Split("a b c d e f g h i j k l m n o p q r s t u", " ")},
+			ExceptedResponse: nil,
+			ExceptedErr:      errorz.InvalidField("tags", "too many tags"),
+			SetUpMocks:       func(service *mockservice) {},
+		},
+		{
+			Name:             "Link not found",
+			InputReq:         &pb.SetLinkMetadataRequest{Code: "3a"},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.NotFound, "short not found"),
+			SetUpMocks: func(service *mockservice) {
+				service.On("SetLinkMetadata", mock.Anything, "", "3a", models.LinkMetadata{}).
+					Return(status.Error(codes.NotFound, "short not found")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			handler := Handler{service: &mockService, adminToken: "admin-secret"}
+
+			resp, err := handler.SetLinkMetadata(adminContext(), tt.InputReq)
+			assertStatus(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedResponse, resp)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_GetLinkMetadata(t *testing.T) {
+	tests := []struct {
+		Name             string
+		InputCtx         context.Context
+		ExceptedResponse *pb.LinkMetadata
+		ExceptedErr      error
+		SetUpMocks       func(service *mockservice)
+	}{
+		{
+			Name:             "Admin reads any link",
+			InputCtx:         adminContext(),
+			ExceptedResponse: &pb.LinkMetadata{Title: "Go", Notes: "Docs", Tags: []string{"docs"}},
+			SetUpMocks: func(service *mockservice) {
+				service.On("GetLinkMetadata", mock.Anything, "go.some", "docs", "").
+					Return(&models.LinkMetadata{Title: "Go", Notes: "Docs", Tags: []string{"docs"}}, nil).Once()
+			},
+		},
+		{
+			Name:             "Principal reads own link",
+			InputCtx:         metadata.NewIncomingContext(context.Background(), metadata.Pairs(mdPrincipal, "telegram:1")),
+			ExceptedResponse: &pb.LinkMetadata{Title: "Go"},
+			SetUpMocks: func(service *mockservice) {
+				service.On("GetLinkMetadata", mock.Anything, "go.some", "docs", "telegram:1").
+					Return(&models.LinkMetadata{Title: "Go"}, nil).Once()
+			},
+		},
+		{
+			Name:        "Link of another principal",
+			InputCtx:    metadata.NewIncomingContext(context.Background(), metadata.Pairs(mdPrincipal, "telegram:2")),
+			ExceptedErr: status.Error(codes.NotFound, "short not found"),
+			SetUpMocks: func(service *mockservice) {
+				service.On("GetLinkMetadata", mock.Anything, "go.some", "docs", "telegram:2").
+					Return(nil, status.Error(codes.NotFound, "short not found")).Once()
+			},
+		},
+		{
+			Name:        "Anonymous",
+			InputCtx:    context.Background(),
+			ExceptedErr: status.Error(codes.Unauthenticated, "principal or admin token required"),
+			SetUpMocks:  func(service *mockservice) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			handler := Handler{service: &mockService, adminToken: "admin-secret"}
+
+			resp, err := handler.GetLinkMetadata(tt.InputCtx, &pb.GetLinkMetadataRequest{Domain: "go.some", Code: "docs"})
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedResponse, resp)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_ListTags(t *testing.T) {
+	tests := []struct {
+		Name             string
+		InputCtx         context.Context
+		ExceptedResponse *pb.ListTagsResponse
+		ExceptedErr      error
+		SetUpMocks       func(service *mockservice)
+	}{
+		{
+			Name:     "Admin lists all tags",
+			InputCtx: adminContext(),
+			ExceptedResponse: &pb.ListTagsResponse{Tags: []*pb.Tag{
+				{Name: "docs", Links: 3},
+				{Name: "go", Links: 1},
+			}},
+			SetUpMocks: func(service *mockservice) {
+				service.On("ListTags", mock.Anything, "").
+					Return([]models.TagCount{{Name: "docs", Links: 3}, {Name: "go", Links: 1}}, nil).Once()
+			},
+		},
+		{
+			Name:             "Principal lists tags of own links",
+			InputCtx:         metadata.NewIncomingContext(context.Background(), metadata.Pairs(mdPrincipal, "telegram:1")),
+			ExceptedResponse: &pb.ListTagsResponse{Tags: []*pb.Tag{{Name: "docs", Links: 1}}},
+			SetUpMocks: func(service *mockservice) {
+				service.On("ListTags", mock.Anything, "telegram:1").
+					Return([]models.TagCount{{Name: "docs", Links: 1}}, nil).Once()
+			},
+		},
+		{
+			Name:        "Anonymous",
+			InputCtx:    context.Background(),
+			ExceptedErr: status.Error(codes.Unauthenticated, "principal or admin token required"),
+			SetUpMocks:  func(service *mockservice) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			handler := Handler{service: &mockService, adminToken: "admin-secret"}
+
+			resp, err := handler.ListTags(tt.InputCtx, &pb.ListTagsRequest{})
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedResponse, resp)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_RetargetURL(t *testing.T) {
+	tests := []struct {
+		Name             string
+		InputReq         *pb.RetargetURLRequest
+		ExceptedResponse *pb.Link
+		ExceptedErr      error
+		SetUpMocks       func(service *mockservice)
+	}{
+		{
+			Name:             "Successfully Retarget",
+			InputReq:         &pb.RetargetURLRequest{Code: "docs", Domain: "go.some", Url: "https://pkg.go.dev"},
+			ExceptedResponse: &pb.Link{Code: "docs", Domain: "go.some", OriginalUrl: "https://pkg.go.dev", Enabled: true, Tags: []string{"go"}},
+			ExceptedErr:      nil,
+			SetUpMocks: func(service *mockservice) {
+				service.On("RetargetURL", mock.Anything, "go.some", "docs", "https://pkg.go.dev").
+					Return(&models.Link{Code: "docs", Domain: "go.some", URL: "https://pkg.go.dev", Metadata: models.LinkMetadata{Tags: []string{"go"}}}, nil).Once()
+			},
+		},
+		{
+			Name:             "Bad URL",
+			InputReq:         &pb.RetargetURLRequest{Code: "docs", Url: "pkg.go.dev"},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.InvalidArgument, "bad URL"),
+			SetUpMocks:       func(service *mockservice) {},
+		},
+		{
+			Name:             "Link not found",
+			InputReq:         &pb.RetargetURLRequest{Code: "docs", Url: "https://pkg.go.dev"},
+			ExceptedResponse: nil,
+			ExceptedErr:      status.Error(codes.NotFound, "short not found"),
+			SetUpMocks: func(service *mockservice) {
+				service.On("RetargetURL", mock.Anything, "", "docs", "https://pkg.go.dev").
+					Return(nil, status.Error(codes.NotFound, "short not found")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			handler := Handler{service: &mockService, adminToken: "admin-secret"}
+
+			resp, err := handler.RetargetURL(adminContext(), tt.InputReq)
+			assert.Equal(t, tt.ExceptedErr, err)
+			assert.Equal(t, tt.ExceptedResponse, resp)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_GetCacheEntry(t *testing.T) {
+	mockService := mockservice{}
+	mockService.On("GetCacheEntry", mock.Anything, "", "3a").
+		Return(&models.Link{Code: "3a", Domain: "sh.some", URL: "https://go.dev"}, 90*time.Second, nil).Once()
+	mockService.On("GetCacheEntry", mock.Anything, "", "3b").
+		Return(nil, time.Duration(0), nil).Once()
+
+	handler := Handler{service: &mockService, adminToken: "admin-secret"}
+
+	resp, err := handler.GetCacheEntry(adminContext(), &pb.GetCacheEntryRequest{Code: "3a"})
+	assert.NoError(t, err)
+	assert.Equal(t, &pb.CacheEntry{
+		Cached: true,
+		Link:   &pb.Link{Code: "3a", Domain: "sh.some", OriginalUrl: "https://go.dev", Enabled: true},
+		Ttl:    90,
+	}, resp)
+
+	resp, err = handler.GetCacheEntry(adminContext(), &pb.GetCacheEntryRequest{Code: "3b"})
+	assert.NoError(t, err)
+	assert.Equal(t, &pb.CacheEntry{}, resp)
+
+	mockService.AssertExpectations(t)
+}
+
+func Test_ListAuditEvents(t *testing.T) {
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		Name             string
+		InputReq         *pb.ListAuditEventsRequest
+		ExceptedResponse *pb.ListAuditEventsResponse
+		ExceptedErr      error
+		SetUpMocks       func(service *mockservice)
+	}{
+		{
+			Name:     "Successfully Listed",
+			InputReq: &pb.ListAuditEventsRequest{Cursor: "MTIx", PageSize: 10, Code: "docs", Domain: "go.some", Action: "retarget"},
+			ExceptedResponse: &pb.ListAuditEventsResponse{
+				Events: []*pb.AuditEvent{
+					{
+						Id:        7,
+						Code:      "docs",
+						Domain:    "go.some",
+						Action:    "retarget",
+						Actor:     "telegram:1",
+						RequestId: "req-1",
+						OldValue:  `{"url":"https://go.dev"}`,
+						NewValue:  `{"url":"https://pkg.go.dev"}`,
+						CreatedAt: createdAt.UnixMicro(),
+					},
+					{Id: 3, Code: "docs", Domain: "go.some", Action: "create", CreatedAt: createdAt.UnixMicro()},
+				},
+				NextCursor: "Mw",
+			},
+			SetUpMocks: func(service *mockservice) {
+				service.On("ListAuditEvents", mock.Anything, "go.some",
+					models.AuditFilter{Code: "docs", Action: models.AuditRetarget}, "MTIx", 10).
+					Return([]models.AuditEvent{
+						{
+							ID: 7, LinkID: 5, DomainID: 2, Domain: "go.some", Code: "docs", Action: models.AuditRetarget,
+							Actor: "telegram:1", RequestID: "req-1", CreatedAt: createdAt,
+							OldValue: []byte(`{"url":"https://go.dev"}`), NewValue: []byte(`{"url":"https://pkg.go.dev"}`),
+						},
+						{ID: 3, LinkID: 5, DomainID: 2, Domain: "go.some", Code: "docs", Action: models.AuditCreate, CreatedAt: createdAt},
+					}, "Mw", nil).Once()
+			},
+		},
+		{
+			Name:        "Negative page size",
+			InputReq:    &pb.ListAuditEventsRequest{PageSize: -1},
+			ExceptedErr: status.Error(codes.InvalidArgument, "bad page size"),
+			SetUpMocks:  func(service *mockservice) {},
+		},
+		{
+			Name:        "Unknown action",
+			InputReq:    &pb.ListAuditEventsRequest{Action: "rename"},
+			ExceptedErr: status.Error(codes.InvalidArgument, "bad action"),
+			SetUpMocks:  func(service *mockservice) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+
+			tt.SetUpMocks(&mockService)
+
+			handler := Handler{service: &mockService, adminToken: "admin-secret"}
+
+			resp, err := handler.ListAuditEvents(adminContext(), tt.InputReq)
 			assert.Equal(t, tt.ExceptedErr, err)
 			assert.Equal(t, tt.ExceptedResponse, resp)
 
@@ -183,3 +1156,210 @@ func Test_GetURL(t *testing.T) {
 		})
 	}
 }
+
+func Test_ListAuditEvents_Principal(t *testing.T) {
+	mockService := mockservice{}
+	// Principals other than admins read only the changes they made
+	mockService.On("ListAuditEvents", mock.Anything, "", models.AuditFilter{Code: "docs", Actor: "apikey:stats"}, "", 0).
+		Return([]models.AuditEvent{}, "", nil).Once()
+
+	handler := Handler{service: &mockService, adminToken: "admin-secret"}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(mdPrincipal, "apikey:stats"))
+	resp, err := handler.ListAuditEvents(ctx, &pb.ListAuditEventsRequest{Code: "docs", Actor: "telegram:1"})
+	assert.NoError(t, err)
+	assert.Equal(t, &pb.ListAuditEventsResponse{Events: []*pb.AuditEvent{}}, resp)
+
+	mockService.AssertExpectations(t)
+}
+
+func Test_ActorInterceptor(t *testing.T) {
+	var actor audit.Actor
+	handler := func(ctx context.Context, req any) (any, error) {
+		actor = audit.ActorFromContext(ctx)
+		return nil, nil
+	}
+	interceptor := ActorInterceptor()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(mdPrincipal, "telegram:1", mdRequestID, "req-1"))
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, audit.Actor{Principal: "telegram:1", RequestID: "req-1"}, actor)
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	assert.NoError(t, err)
+	assert.Empty(t, actor.Principal)
+	assert.Len(t, actor.RequestID, 32, "request ID is generated")
+}
+
+func Test_PreviewURL(t *testing.T) {
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockService := mockservice{}
+	mockService.On("PreviewURL", mock.Anything, "sh.some", "3a").
+		Return(&models.Link{Code: "3a", Domain: "sh.some", URL: "https://go.dev", CreatedAt: createdAt,
+			Options: models.LinkOptions{Interstitial: true}}, nil).Once()
+	mockService.On("PreviewURL", mock.Anything, "sh.some", "3b").
+		Return(&models.Link{Code: "3b", Domain: "sh.some", URL: "https://go.dev", PasswordHash: "hash"}, nil).Once()
+	mockService.On("PreviewURL", mock.Anything, "sh.some", "3c").
+		Return(nil, status.Error(codes.NotFound, "short not found")).Once()
+
+	handler := Handler{service: &mockService}
+
+	resp, err := handler.PreviewURL(context.Background(), &pb.PreviewURLRequest{Code: "3a", Domain: "sh.some"})
+	assert.NoError(t, err)
+	assert.Equal(t, &pb.LinkPreview{Code: "3a", Domain: "sh.some", Url: "https://go.dev", CreatedAt: createdAt.Unix(), Interstitial: true}, resp)
+
+	resp, err = handler.PreviewURL(context.Background(), &pb.PreviewURLRequest{Code: "3b", Domain: "sh.some"})
+	assert.NoError(t, err)
+	assert.Equal(t, &pb.LinkPreview{Code: "3b", Domain: "sh.some", PasswordProtected: true}, resp, "destination is not revealed")
+
+	_, err = handler.PreviewURL(context.Background(), &pb.PreviewURLRequest{Code: "3c", Domain: "sh.some"})
+	assert.Equal(t, status.Error(codes.NotFound, "short not found"), err)
+
+	mockService.AssertExpectations(t)
+}
+
+func Test_ListAPIKeys(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	revoked := created.Add(time.Hour)
+
+	mockService := mockservice{}
+	mockService.On("ListAPIKeys", mock.Anything).
+		Return([]models.APIKey{
+			{ID: 2, Prefix: "shk_abcdefgh", KeyHash: "hash", Principal: "apikey:ci", Name: "CI", Scopes: []string{"shorten"}, CreatedAt: created},
+			{ID: 1, Prefix: "shk_12345678", Principal: "alice", Scopes: []string{"read-stats"}, CreatedAt: created, RevokedAt: revoked},
+		}, nil).Once()
+
+	handler := Handler{service: &mockService, adminToken: "admin-secret"}
+
+	resp, err := handler.ListAPIKeys(adminContext(), &pb.ListAPIKeysRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, &pb.ListAPIKeysResponse{ApiKeys: []*pb.APIKey{
+		{Id: 2, Prefix: "shk_abcdefgh", Principal: "apikey:ci", Name: "CI", Scopes: []string{"shorten"}, CreatedAt: created.Unix()},
+		{Id: 1, Prefix: "shk_12345678", Principal: "alice", Scopes: []string{"read-stats"}, CreatedAt: created.Unix(), RevokedAt: revoked.Unix()},
+	}}, resp)
+
+	mockService.AssertExpectations(t)
+}
+
+func Test_AdminRPCs(t *testing.T) {
+	tests := []struct {
+		Name string
+		Call func(handler *Handler, ctx context.Context) error
+	}{
+		{
+			Name: "CreateAPIKey",
+			Call: func(handler *Handler, ctx context.Context) error {
+				_, err := handler.CreateAPIKey(ctx, &pb.CreateAPIKeyRequest{Principal: "alice", Scopes: []string{"shorten"}})
+				return err
+			},
+		},
+		{
+			Name: "ListAPIKeys",
+			Call: func(handler *Handler, ctx context.Context) error {
+				_, err := handler.ListAPIKeys(ctx, &pb.ListAPIKeysRequest{})
+				return err
+			},
+		},
+		{
+			Name: "RevokeAPIKey",
+			Call: func(handler *Handler, ctx context.Context) error {
+				_, err := handler.RevokeAPIKey(ctx, &pb.RevokeAPIKeyRequest{Id: 1})
+				return err
+			},
+		},
+		{
+			Name: "DeleteURL",
+			Call: func(handler *Handler, ctx context.Context) error {
+				_, err := handler.DeleteURL(ctx, &pb.DeleteURLRequest{Code: "docs"})
+				return err
+			},
+		},
+		{
+			Name: "DisableURL",
+			Call: func(handler *Handler, ctx context.Context) error {
+				_, err := handler.DisableURL(ctx, &pb.DisableURLRequest{Code: "docs"})
+				return err
+			},
+		},
+		{
+			Name: "RetargetURL",
+			Call: func(handler *Handler, ctx context.Context) error {
+				_, err := handler.RetargetURL(ctx, &pb.RetargetURLRequest{Code: "docs", Url: "https://pkg.go.dev"})
+				return err
+			},
+		},
+		{
+			Name: "RecordVisits",
+			Call: func(handler *Handler, ctx context.Context) error {
+				_, err := handler.RecordVisits(ctx, &pb.RecordVisitsRequest{Visits: []*pb.Visit{{Code: "docs"}}})
+				return err
+			},
+		},
+		{
+			Name: "SetLinkMetadata",
+			Call: func(handler *Handler, ctx context.Context) error {
+				_, err := handler.SetLinkMetadata(ctx, &pb.SetLinkMetadataRequest{Code: "docs", Title: "Docs"})
+				return err
+			},
+		},
+		{
+			Name: "GetCacheEntry",
+			Call: func(handler *Handler, ctx context.Context) error {
+				_, err := handler.GetCacheEntry(ctx, &pb.GetCacheEntryRequest{Code: "docs"})
+				return err
+			},
+		},
+		{
+			Name: "ListAuditEvents",
+			Call: func(handler *Handler, ctx context.Context) error {
+				_, err := handler.ListAuditEvents(ctx, &pb.ListAuditEventsRequest{})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			mockService := mockservice{}
+			handler := Handler{service: &mockService, adminToken: "admin-secret"}
+
+			err := tt.Call(&handler, context.Background())
+			assert.Equal(t, status.Error(codes.Unauthenticated, "admin token required"), err)
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer guess"))
+			err = tt.Call(&handler, ctx)
+			assert.Equal(t, status.Error(codes.Unauthenticated, "admin token required"), err)
+
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_AuthenticateAPIKey(t *testing.T) {
+	mockService := mockservice{}
+	handler := Handler{service: &mockService}
+
+	resp, err := handler.AuthenticateAPIKey(context.Background(), &pb.AuthenticateAPIKeyRequest{})
+	assert.Equal(t, status.Error(codes.Unauthenticated, "api key is required"), err)
+	assert.Nil(t, resp)
+
+	mockService.AssertExpectations(t)
+}
+
+// assertStatus compares the status errors with their details
+func assertStatus(t *testing.T, expected, actual error) {
+	t.Helper()
+	if expected == nil {
+		assert.NoError(t, actual)
+		return
+	}
+	assert.True(t, proto.Equal(status.Convert(expected).Proto(), status.Convert(actual).Proto()),
+		"expected %v, actual %v", status.Convert(expected).Proto(), status.Convert(actual).Proto())
+}
+
+// adminContext carries the admin token of the handlers with the "admin-secret" token
+func adminContext() context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer admin-secret"))
+}
@@ -6,6 +6,7 @@ package grpc
 
 import (
 	"context"
+	"time"
 
 	"github.com/misshanya/url-shortener/shortener/internal/models"
 	mock "github.com/stretchr/testify/mock"
@@ -38,45 +39,1384 @@ func (_m *mockservice) EXPECT() *mockservice_Expecter {
 	return &mockservice_Expecter{mock: &_m.Mock}
 }
 
+// AuthenticateAPIKey provides a mock function for the type mockservice
+func (_mock *mockservice) AuthenticateAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	ret := _mock.Called(ctx, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AuthenticateAPIKey")
+	}
+
+	var r0 *models.APIKey
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.APIKey, error)); ok {
+		return returnFunc(ctx, key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.APIKey); ok {
+		r0 = returnFunc(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.APIKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockservice_AuthenticateAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AuthenticateAPIKey'
+type mockservice_AuthenticateAPIKey_Call struct {
+	*mock.Call
+}
+
+// AuthenticateAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - key string
+func (_e *mockservice_Expecter) AuthenticateAPIKey(ctx interface{}, key interface{}) *mockservice_AuthenticateAPIKey_Call {
+	return &mockservice_AuthenticateAPIKey_Call{Call: _e.mock.On("AuthenticateAPIKey", ctx, key)}
+}
+
+func (_c *mockservice_AuthenticateAPIKey_Call) Run(run func(ctx context.Context, key string)) *mockservice_AuthenticateAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_AuthenticateAPIKey_Call) Return(aPIKey *models.APIKey, err error) *mockservice_AuthenticateAPIKey_Call {
+	_c.Call.Return(aPIKey, err)
+	return _c
+}
+
+func (_c *mockservice_AuthenticateAPIKey_Call) RunAndReturn(run func(ctx context.Context, key string) (*models.APIKey, error)) *mockservice_AuthenticateAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateAPIKey provides a mock function for the type mockservice
+func (_mock *mockservice) CreateAPIKey(ctx context.Context, principal string, name string, scopes []string) (*models.APIKey, string, error) {
+	ret := _mock.Called(ctx, principal, name, scopes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAPIKey")
+	}
+
+	var r0 *models.APIKey
+	var r1 string
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []string) (*models.APIKey, string, error)); ok {
+		return returnFunc(ctx, principal, name, scopes)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []string) *models.APIKey); ok {
+		r0 = returnFunc(ctx, principal, name, scopes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.APIKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, []string) string); ok {
+		r1 = returnFunc(ctx, principal, name, scopes)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, string, []string) error); ok {
+		r2 = returnFunc(ctx, principal, name, scopes)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// mockservice_CreateAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateAPIKey'
+type mockservice_CreateAPIKey_Call struct {
+	*mock.Call
+}
+
+// CreateAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - principal string
+//   - name string
+//   - scopes []string
+func (_e *mockservice_Expecter) CreateAPIKey(ctx interface{}, principal interface{}, name interface{}, scopes interface{}) *mockservice_CreateAPIKey_Call {
+	return &mockservice_CreateAPIKey_Call{Call: _e.mock.On("CreateAPIKey", ctx, principal, name, scopes)}
+}
+
+func (_c *mockservice_CreateAPIKey_Call) Run(run func(ctx context.Context, principal string, name string, scopes []string)) *mockservice_CreateAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []string
+		if args[3] != nil {
+			arg3 = args[3].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_CreateAPIKey_Call) Return(aPIKey *models.APIKey, s string, err error) *mockservice_CreateAPIKey_Call {
+	_c.Call.Return(aPIKey, s, err)
+	return _c
+}
+
+func (_c *mockservice_CreateAPIKey_Call) RunAndReturn(run func(ctx context.Context, principal string, name string, scopes []string) (*models.APIKey, string, error)) *mockservice_CreateAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateDomain provides a mock function for the type mockservice
+func (_mock *mockservice) CreateDomain(ctx context.Context, host string) (*models.Domain, error) {
+	ret := _mock.Called(ctx, host)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateDomain")
+	}
+
+	var r0 *models.Domain
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (*models.Domain, error)); ok {
+		return returnFunc(ctx, host)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) *models.Domain); ok {
+		r0 = returnFunc(ctx, host)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Domain)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, host)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockservice_CreateDomain_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateDomain'
+type mockservice_CreateDomain_Call struct {
+	*mock.Call
+}
+
+// CreateDomain is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+func (_e *mockservice_Expecter) CreateDomain(ctx interface{}, host interface{}) *mockservice_CreateDomain_Call {
+	return &mockservice_CreateDomain_Call{Call: _e.mock.On("CreateDomain", ctx, host)}
+}
+
+func (_c *mockservice_CreateDomain_Call) Run(run func(ctx context.Context, host string)) *mockservice_CreateDomain_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_CreateDomain_Call) Return(domain *models.Domain, err error) *mockservice_CreateDomain_Call {
+	_c.Call.Return(domain, err)
+	return _c
+}
+
+func (_c *mockservice_CreateDomain_Call) RunAndReturn(run func(ctx context.Context, host string) (*models.Domain, error)) *mockservice_CreateDomain_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteURL provides a mock function for the type mockservice
+func (_mock *mockservice) DeleteURL(ctx context.Context, host string, short string) error {
+	ret := _mock.Called(ctx, host, short)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteURL")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, host, short)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockservice_DeleteURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteURL'
+type mockservice_DeleteURL_Call struct {
+	*mock.Call
+}
+
+// DeleteURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - short string
+func (_e *mockservice_Expecter) DeleteURL(ctx interface{}, host interface{}, short interface{}) *mockservice_DeleteURL_Call {
+	return &mockservice_DeleteURL_Call{Call: _e.mock.On("DeleteURL", ctx, host, short)}
+}
+
+func (_c *mockservice_DeleteURL_Call) Run(run func(ctx context.Context, host string, short string)) *mockservice_DeleteURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_DeleteURL_Call) Return(err error) *mockservice_DeleteURL_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockservice_DeleteURL_Call) RunAndReturn(run func(ctx context.Context, host string, short string) error) *mockservice_DeleteURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DisableURL provides a mock function for the type mockservice
+func (_mock *mockservice) DisableURL(ctx context.Context, host string, short string) (*models.Link, error) {
+	ret := _mock.Called(ctx, host, short)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DisableURL")
+	}
+
+	var r0 *models.Link
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.Link, error)); ok {
+		return returnFunc(ctx, host, short)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.Link); ok {
+		r0 = returnFunc(ctx, host, short)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Link)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, host, short)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockservice_DisableURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DisableURL'
+type mockservice_DisableURL_Call struct {
+	*mock.Call
+}
+
+// DisableURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - short string
+func (_e *mockservice_Expecter) DisableURL(ctx interface{}, host interface{}, short interface{}) *mockservice_DisableURL_Call {
+	return &mockservice_DisableURL_Call{Call: _e.mock.On("DisableURL", ctx, host, short)}
+}
+
+func (_c *mockservice_DisableURL_Call) Run(run func(ctx context.Context, host string, short string)) *mockservice_DisableURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_DisableURL_Call) Return(link *models.Link, err error) *mockservice_DisableURL_Call {
+	_c.Call.Return(link, err)
+	return _c
+}
+
+func (_c *mockservice_DisableURL_Call) RunAndReturn(run func(ctx context.Context, host string, short string) (*models.Link, error)) *mockservice_DisableURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCacheEntry provides a mock function for the type mockservice
+func (_mock *mockservice) GetCacheEntry(ctx context.Context, host string, short string) (*models.Link, time.Duration, error) {
+	ret := _mock.Called(ctx, host, short)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCacheEntry")
+	}
+
+	var r0 *models.Link
+	var r1 time.Duration
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.Link, time.Duration, error)); ok {
+		return returnFunc(ctx, host, short)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.Link); ok {
+		r0 = returnFunc(ctx, host, short)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Link)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) time.Duration); ok {
+		r1 = returnFunc(ctx, host, short)
+	} else {
+		r1 = ret.Get(1).(time.Duration)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = returnFunc(ctx, host, short)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// mockservice_GetCacheEntry_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCacheEntry'
+type mockservice_GetCacheEntry_Call struct {
+	*mock.Call
+}
+
+// GetCacheEntry is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - short string
+func (_e *mockservice_Expecter) GetCacheEntry(ctx interface{}, host interface{}, short interface{}) *mockservice_GetCacheEntry_Call {
+	return &mockservice_GetCacheEntry_Call{Call: _e.mock.On("GetCacheEntry", ctx, host, short)}
+}
+
+func (_c *mockservice_GetCacheEntry_Call) Run(run func(ctx context.Context, host string, short string)) *mockservice_GetCacheEntry_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_GetCacheEntry_Call) Return(link *models.Link, duration time.Duration, err error) *mockservice_GetCacheEntry_Call {
+	_c.Call.Return(link, duration, err)
+	return _c
+}
+
+func (_c *mockservice_GetCacheEntry_Call) RunAndReturn(run func(ctx context.Context, host string, short string) (*models.Link, time.Duration, error)) *mockservice_GetCacheEntry_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetLinkMetadata provides a mock function for the type mockservice
+func (_mock *mockservice) GetLinkMetadata(ctx context.Context, host string, short string, owner string) (*models.LinkMetadata, error) {
+	ret := _mock.Called(ctx, host, short, owner)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetLinkMetadata")
+	}
+
+	var r0 *models.LinkMetadata
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (*models.LinkMetadata, error)); ok {
+		return returnFunc(ctx, host, short, owner)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) *models.LinkMetadata); ok {
+		r0 = returnFunc(ctx, host, short, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.LinkMetadata)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, host, short, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockservice_GetLinkMetadata_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLinkMetadata'
+type mockservice_GetLinkMetadata_Call struct {
+	*mock.Call
+}
+
+// GetLinkMetadata is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - short string
+//   - owner string
+func (_e *mockservice_Expecter) GetLinkMetadata(ctx interface{}, host interface{}, short interface{}, owner interface{}) *mockservice_GetLinkMetadata_Call {
+	return &mockservice_GetLinkMetadata_Call{Call: _e.mock.On("GetLinkMetadata", ctx, host, short, owner)}
+}
+
+func (_c *mockservice_GetLinkMetadata_Call) Run(run func(ctx context.Context, host string, short string, owner string)) *mockservice_GetLinkMetadata_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_GetLinkMetadata_Call) Return(linkMetadata *models.LinkMetadata, err error) *mockservice_GetLinkMetadata_Call {
+	_c.Call.Return(linkMetadata, err)
+	return _c
+}
+
+func (_c *mockservice_GetLinkMetadata_Call) RunAndReturn(run func(ctx context.Context, host string, short string, owner string) (*models.LinkMetadata, error)) *mockservice_GetLinkMetadata_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetURL provides a mock function for the type mockservice
-func (_mock *mockservice) GetURL(ctx context.Context, short string) (string, error) {
-	ret := _mock.Called(ctx, short)
+func (_mock *mockservice) GetURL(ctx context.Context, host string, short string, visit models.Visit) (*models.Redirect, error) {
+	ret := _mock.Called(ctx, host, short, visit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetURL")
+	}
+
+	var r0 *models.Redirect
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, models.Visit) (*models.Redirect, error)); ok {
+		return returnFunc(ctx, host, short, visit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, models.Visit) *models.Redirect); ok {
+		r0 = returnFunc(ctx, host, short, visit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Redirect)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, models.Visit) error); ok {
+		r1 = returnFunc(ctx, host, short, visit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockservice_GetURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetURL'
+type mockservice_GetURL_Call struct {
+	*mock.Call
+}
+
+// GetURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - short string
+//   - visit models.Visit
+func (_e *mockservice_Expecter) GetURL(ctx interface{}, host interface{}, short interface{}, visit interface{}) *mockservice_GetURL_Call {
+	return &mockservice_GetURL_Call{Call: _e.mock.On("GetURL", ctx, host, short, visit)}
+}
+
+func (_c *mockservice_GetURL_Call) Run(run func(ctx context.Context, host string, short string, visit models.Visit)) *mockservice_GetURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 models.Visit
+		if args[3] != nil {
+			arg3 = args[3].(models.Visit)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_GetURL_Call) Return(redirect *models.Redirect, err error) *mockservice_GetURL_Call {
+	_c.Call.Return(redirect, err)
+	return _c
+}
+
+func (_c *mockservice_GetURL_Call) RunAndReturn(run func(ctx context.Context, host string, short string, visit models.Visit) (*models.Redirect, error)) *mockservice_GetURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAPIKeys provides a mock function for the type mockservice
+func (_mock *mockservice) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAPIKeys")
+	}
+
+	var r0 []models.APIKey
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) ([]models.APIKey, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) []models.APIKey); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.APIKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockservice_ListAPIKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAPIKeys'
+type mockservice_ListAPIKeys_Call struct {
+	*mock.Call
+}
+
+// ListAPIKeys is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *mockservice_Expecter) ListAPIKeys(ctx interface{}) *mockservice_ListAPIKeys_Call {
+	return &mockservice_ListAPIKeys_Call{Call: _e.mock.On("ListAPIKeys", ctx)}
+}
+
+func (_c *mockservice_ListAPIKeys_Call) Run(run func(ctx context.Context)) *mockservice_ListAPIKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_ListAPIKeys_Call) Return(aPIKeys []models.APIKey, err error) *mockservice_ListAPIKeys_Call {
+	_c.Call.Return(aPIKeys, err)
+	return _c
+}
+
+func (_c *mockservice_ListAPIKeys_Call) RunAndReturn(run func(ctx context.Context) ([]models.APIKey, error)) *mockservice_ListAPIKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAuditEvents provides a mock function for the type mockservice
+func (_mock *mockservice) ListAuditEvents(ctx context.Context, host string, filter models.AuditFilter, cursor string, pageSize int) ([]models.AuditEvent, string, error) {
+	ret := _mock.Called(ctx, host, filter, cursor, pageSize)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAuditEvents")
+	}
+
+	var r0 []models.AuditEvent
+	var r1 string
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, models.AuditFilter, string, int) ([]models.AuditEvent, string, error)); ok {
+		return returnFunc(ctx, host, filter, cursor, pageSize)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, models.AuditFilter, string, int) []models.AuditEvent); ok {
+		r0 = returnFunc(ctx, host, filter, cursor, pageSize)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.AuditEvent)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, models.AuditFilter, string, int) string); ok {
+		r1 = returnFunc(ctx, host, filter, cursor, pageSize)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, models.AuditFilter, string, int) error); ok {
+		r2 = returnFunc(ctx, host, filter, cursor, pageSize)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// mockservice_ListAuditEvents_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAuditEvents'
+type mockservice_ListAuditEvents_Call struct {
+	*mock.Call
+}
+
+// ListAuditEvents is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - filter models.AuditFilter
+//   - cursor string
+//   - pageSize int
+func (_e *mockservice_Expecter) ListAuditEvents(ctx interface{}, host interface{}, filter interface{}, cursor interface{}, pageSize interface{}) *mockservice_ListAuditEvents_Call {
+	return &mockservice_ListAuditEvents_Call{Call: _e.mock.On("ListAuditEvents", ctx, host, filter, cursor, pageSize)}
+}
+
+func (_c *mockservice_ListAuditEvents_Call) Run(run func(ctx context.Context, host string, filter models.AuditFilter, cursor string, pageSize int)) *mockservice_ListAuditEvents_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 models.AuditFilter
+		if args[2] != nil {
+			arg2 = args[2].(models.AuditFilter)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 int
+		if args[4] != nil {
+			arg4 = args[4].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_ListAuditEvents_Call) Return(auditEvents []models.AuditEvent, s string, err error) *mockservice_ListAuditEvents_Call {
+	_c.Call.Return(auditEvents, s, err)
+	return _c
+}
+
+func (_c *mockservice_ListAuditEvents_Call) RunAndReturn(run func(ctx context.Context, host string, filter models.AuditFilter, cursor string, pageSize int) ([]models.AuditEvent, string, error)) *mockservice_ListAuditEvents_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListDomains provides a mock function for the type mockservice
+func (_mock *mockservice) ListDomains(ctx context.Context) ([]models.Domain, error) {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDomains")
+	}
+
+	var r0 []models.Domain
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) ([]models.Domain, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) []models.Domain); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Domain)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockservice_ListDomains_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListDomains'
+type mockservice_ListDomains_Call struct {
+	*mock.Call
+}
+
+// ListDomains is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *mockservice_Expecter) ListDomains(ctx interface{}) *mockservice_ListDomains_Call {
+	return &mockservice_ListDomains_Call{Call: _e.mock.On("ListDomains", ctx)}
+}
+
+func (_c *mockservice_ListDomains_Call) Run(run func(ctx context.Context)) *mockservice_ListDomains_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_ListDomains_Call) Return(domains []models.Domain, err error) *mockservice_ListDomains_Call {
+	_c.Call.Return(domains, err)
+	return _c
+}
+
+func (_c *mockservice_ListDomains_Call) RunAndReturn(run func(ctx context.Context) ([]models.Domain, error)) *mockservice_ListDomains_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListTags provides a mock function for the type mockservice
+func (_mock *mockservice) ListTags(ctx context.Context, owner string) ([]models.TagCount, error) {
+	ret := _mock.Called(ctx, owner)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTags")
+	}
+
+	var r0 []models.TagCount
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]models.TagCount, error)); ok {
+		return returnFunc(ctx, owner)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []models.TagCount); ok {
+		r0 = returnFunc(ctx, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.TagCount)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockservice_ListTags_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListTags'
+type mockservice_ListTags_Call struct {
+	*mock.Call
+}
+
+// ListTags is a helper method to define mock.On call
+//   - ctx context.Context
+//   - owner string
+func (_e *mockservice_Expecter) ListTags(ctx interface{}, owner interface{}) *mockservice_ListTags_Call {
+	return &mockservice_ListTags_Call{Call: _e.mock.On("ListTags", ctx, owner)}
+}
+
+func (_c *mockservice_ListTags_Call) Run(run func(ctx context.Context, owner string)) *mockservice_ListTags_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_ListTags_Call) Return(tagCounts []models.TagCount, err error) *mockservice_ListTags_Call {
+	_c.Call.Return(tagCounts, err)
+	return _c
+}
+
+func (_c *mockservice_ListTags_Call) RunAndReturn(run func(ctx context.Context, owner string) ([]models.TagCount, error)) *mockservice_ListTags_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListURLs provides a mock function for the type mockservice
+func (_mock *mockservice) ListURLs(ctx context.Context, filter models.LinkFilter, cursor string, pageSize int) ([]models.Link, string, error) {
+	ret := _mock.Called(ctx, filter, cursor, pageSize)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListURLs")
+	}
+
+	var r0 []models.Link
+	var r1 string
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.LinkFilter, string, int) ([]models.Link, string, error)); ok {
+		return returnFunc(ctx, filter, cursor, pageSize)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, models.LinkFilter, string, int) []models.Link); ok {
+		r0 = returnFunc(ctx, filter, cursor, pageSize)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.Link)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, models.LinkFilter, string, int) string); ok {
+		r1 = returnFunc(ctx, filter, cursor, pageSize)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, models.LinkFilter, string, int) error); ok {
+		r2 = returnFunc(ctx, filter, cursor, pageSize)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// mockservice_ListURLs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListURLs'
+type mockservice_ListURLs_Call struct {
+	*mock.Call
+}
+
+// ListURLs is a helper method to define mock.On call
+//   - ctx context.Context
+//   - filter models.LinkFilter
+//   - cursor string
+//   - pageSize int
+func (_e *mockservice_Expecter) ListURLs(ctx interface{}, filter interface{}, cursor interface{}, pageSize interface{}) *mockservice_ListURLs_Call {
+	return &mockservice_ListURLs_Call{Call: _e.mock.On("ListURLs", ctx, filter, cursor, pageSize)}
+}
+
+func (_c *mockservice_ListURLs_Call) Run(run func(ctx context.Context, filter models.LinkFilter, cursor string, pageSize int)) *mockservice_ListURLs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 models.LinkFilter
+		if args[1] != nil {
+			arg1 = args[1].(models.LinkFilter)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_ListURLs_Call) Return(links []models.Link, s string, err error) *mockservice_ListURLs_Call {
+	_c.Call.Return(links, s, err)
+	return _c
+}
+
+func (_c *mockservice_ListURLs_Call) RunAndReturn(run func(ctx context.Context, filter models.LinkFilter, cursor string, pageSize int) ([]models.Link, string, error)) *mockservice_ListURLs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PreviewURL provides a mock function for the type mockservice
+func (_mock *mockservice) PreviewURL(ctx context.Context, host string, short string) (*models.Link, error) {
+	ret := _mock.Called(ctx, host, short)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PreviewURL")
+	}
+
+	var r0 *models.Link
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (*models.Link, error)); ok {
+		return returnFunc(ctx, host, short)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) *models.Link); ok {
+		r0 = returnFunc(ctx, host, short)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Link)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = returnFunc(ctx, host, short)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockservice_PreviewURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PreviewURL'
+type mockservice_PreviewURL_Call struct {
+	*mock.Call
+}
+
+// PreviewURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - short string
+func (_e *mockservice_Expecter) PreviewURL(ctx interface{}, host interface{}, short interface{}) *mockservice_PreviewURL_Call {
+	return &mockservice_PreviewURL_Call{Call: _e.mock.On("PreviewURL", ctx, host, short)}
+}
+
+func (_c *mockservice_PreviewURL_Call) Run(run func(ctx context.Context, host string, short string)) *mockservice_PreviewURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_PreviewURL_Call) Return(link *models.Link, err error) *mockservice_PreviewURL_Call {
+	_c.Call.Return(link, err)
+	return _c
+}
+
+func (_c *mockservice_PreviewURL_Call) RunAndReturn(run func(ctx context.Context, host string, short string) (*models.Link, error)) *mockservice_PreviewURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RecordVisit provides a mock function for the type mockservice
+func (_mock *mockservice) RecordVisit(ctx context.Context, host string, short string, visit models.Visit, at time.Time) error {
+	ret := _mock.Called(ctx, host, short, visit, at)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetURL")
+		panic("no return value specified for RecordVisit")
 	}
 
-	var r0 string
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, models.Visit, time.Time) error); ok {
+		r0 = returnFunc(ctx, host, short, visit, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockservice_RecordVisit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RecordVisit'
+type mockservice_RecordVisit_Call struct {
+	*mock.Call
+}
+
+// RecordVisit is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - short string
+//   - visit models.Visit
+//   - at time.Time
+func (_e *mockservice_Expecter) RecordVisit(ctx interface{}, host interface{}, short interface{}, visit interface{}, at interface{}) *mockservice_RecordVisit_Call {
+	return &mockservice_RecordVisit_Call{Call: _e.mock.On("RecordVisit", ctx, host, short, visit, at)}
+}
+
+func (_c *mockservice_RecordVisit_Call) Run(run func(ctx context.Context, host string, short string, visit models.Visit, at time.Time)) *mockservice_RecordVisit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 models.Visit
+		if args[3] != nil {
+			arg3 = args[3].(models.Visit)
+		}
+		var arg4 time.Time
+		if args[4] != nil {
+			arg4 = args[4].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_RecordVisit_Call) Return(err error) *mockservice_RecordVisit_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *mockservice_RecordVisit_Call) RunAndReturn(run func(ctx context.Context, host string, short string, visit models.Visit, at time.Time) error) *mockservice_RecordVisit_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResolveURL provides a mock function for the type mockservice
+func (_mock *mockservice) ResolveURL(ctx context.Context, host string, short string, visit models.Visit) (*models.Redirect, error) {
+	ret := _mock.Called(ctx, host, short, visit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResolveURL")
+	}
+
+	var r0 *models.Redirect
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (string, error)); ok {
-		return returnFunc(ctx, short)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, models.Visit) (*models.Redirect, error)); ok {
+		return returnFunc(ctx, host, short, visit)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = returnFunc(ctx, short)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, models.Visit) *models.Redirect); ok {
+		r0 = returnFunc(ctx, host, short, visit)
 	} else {
-		r0 = ret.Get(0).(string)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Redirect)
+		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = returnFunc(ctx, short)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, models.Visit) error); ok {
+		r1 = returnFunc(ctx, host, short, visit)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// mockservice_GetURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetURL'
-type mockservice_GetURL_Call struct {
+// mockservice_ResolveURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResolveURL'
+type mockservice_ResolveURL_Call struct {
 	*mock.Call
 }
 
-// GetURL is a helper method to define mock.On call
+// ResolveURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - short string
+//   - visit models.Visit
+func (_e *mockservice_Expecter) ResolveURL(ctx interface{}, host interface{}, short interface{}, visit interface{}) *mockservice_ResolveURL_Call {
+	return &mockservice_ResolveURL_Call{Call: _e.mock.On("ResolveURL", ctx, host, short, visit)}
+}
+
+func (_c *mockservice_ResolveURL_Call) Run(run func(ctx context.Context, host string, short string, visit models.Visit)) *mockservice_ResolveURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 models.Visit
+		if args[3] != nil {
+			arg3 = args[3].(models.Visit)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_ResolveURL_Call) Return(redirect *models.Redirect, err error) *mockservice_ResolveURL_Call {
+	_c.Call.Return(redirect, err)
+	return _c
+}
+
+func (_c *mockservice_ResolveURL_Call) RunAndReturn(run func(ctx context.Context, host string, short string, visit models.Visit) (*models.Redirect, error)) *mockservice_ResolveURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetargetURL provides a mock function for the type mockservice
+func (_mock *mockservice) RetargetURL(ctx context.Context, host string, short string, url string) (*models.Link, error) {
+	ret := _mock.Called(ctx, host, short, url)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetargetURL")
+	}
+
+	var r0 *models.Link
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (*models.Link, error)); ok {
+		return returnFunc(ctx, host, short, url)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) *models.Link); ok {
+		r0 = returnFunc(ctx, host, short, url)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Link)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, host, short, url)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockservice_RetargetURL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetargetURL'
+type mockservice_RetargetURL_Call struct {
+	*mock.Call
+}
+
+// RetargetURL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - short string
+//   - url string
+func (_e *mockservice_Expecter) RetargetURL(ctx interface{}, host interface{}, short interface{}, url interface{}) *mockservice_RetargetURL_Call {
+	return &mockservice_RetargetURL_Call{Call: _e.mock.On("RetargetURL", ctx, host, short, url)}
+}
+
+func (_c *mockservice_RetargetURL_Call) Run(run func(ctx context.Context, host string, short string, url string)) *mockservice_RetargetURL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_RetargetURL_Call) Return(link *models.Link, err error) *mockservice_RetargetURL_Call {
+	_c.Call.Return(link, err)
+	return _c
+}
+
+func (_c *mockservice_RetargetURL_Call) RunAndReturn(run func(ctx context.Context, host string, short string, url string) (*models.Link, error)) *mockservice_RetargetURL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RevokeAPIKey provides a mock function for the type mockservice
+func (_mock *mockservice) RevokeAPIKey(ctx context.Context, id int64) (*models.APIKey, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAPIKey")
+	}
+
+	var r0 *models.APIKey
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) (*models.APIKey, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int64) *models.APIKey); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.APIKey)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// mockservice_RevokeAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RevokeAPIKey'
+type mockservice_RevokeAPIKey_Call struct {
+	*mock.Call
+}
+
+// RevokeAPIKey is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int64
+func (_e *mockservice_Expecter) RevokeAPIKey(ctx interface{}, id interface{}) *mockservice_RevokeAPIKey_Call {
+	return &mockservice_RevokeAPIKey_Call{Call: _e.mock.On("RevokeAPIKey", ctx, id)}
+}
+
+func (_c *mockservice_RevokeAPIKey_Call) Run(run func(ctx context.Context, id int64)) *mockservice_RevokeAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 int64
+		if args[1] != nil {
+			arg1 = args[1].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_RevokeAPIKey_Call) Return(aPIKey *models.APIKey, err error) *mockservice_RevokeAPIKey_Call {
+	_c.Call.Return(aPIKey, err)
+	return _c
+}
+
+func (_c *mockservice_RevokeAPIKey_Call) RunAndReturn(run func(ctx context.Context, id int64) (*models.APIKey, error)) *mockservice_RevokeAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetLinkMetadata provides a mock function for the type mockservice
+func (_mock *mockservice) SetLinkMetadata(ctx context.Context, host string, short string, metadata models.LinkMetadata) error {
+	ret := _mock.Called(ctx, host, short, metadata)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetLinkMetadata")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, models.LinkMetadata) error); ok {
+		r0 = returnFunc(ctx, host, short, metadata)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// mockservice_SetLinkMetadata_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetLinkMetadata'
+type mockservice_SetLinkMetadata_Call struct {
+	*mock.Call
+}
+
+// SetLinkMetadata is a helper method to define mock.On call
 //   - ctx context.Context
+//   - host string
 //   - short string
-func (_e *mockservice_Expecter) GetURL(ctx interface{}, short interface{}) *mockservice_GetURL_Call {
-	return &mockservice_GetURL_Call{Call: _e.mock.On("GetURL", ctx, short)}
+//   - metadata models.LinkMetadata
+func (_e *mockservice_Expecter) SetLinkMetadata(ctx interface{}, host interface{}, short interface{}, metadata interface{}) *mockservice_SetLinkMetadata_Call {
+	return &mockservice_SetLinkMetadata_Call{Call: _e.mock.On("SetLinkMetadata", ctx, host, short, metadata)}
 }
 
-func (_c *mockservice_GetURL_Call) Run(run func(ctx context.Context, short string)) *mockservice_GetURL_Call {
+func (_c *mockservice_SetLinkMetadata_Call) Run(run func(ctx context.Context, host string, short string, metadata models.LinkMetadata)) *mockservice_SetLinkMetadata_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -86,20 +1426,30 @@ func (_c *mockservice_GetURL_Call) Run(run func(ctx context.Context, short strin
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 models.LinkMetadata
+		if args[3] != nil {
+			arg3 = args[3].(models.LinkMetadata)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
+			arg3,
 		)
 	})
 	return _c
 }
 
-func (_c *mockservice_GetURL_Call) Return(s string, err error) *mockservice_GetURL_Call {
-	_c.Call.Return(s, err)
+func (_c *mockservice_SetLinkMetadata_Call) Return(err error) *mockservice_SetLinkMetadata_Call {
+	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *mockservice_GetURL_Call) RunAndReturn(run func(ctx context.Context, short string) (string, error)) *mockservice_GetURL_Call {
+func (_c *mockservice_SetLinkMetadata_Call) RunAndReturn(run func(ctx context.Context, host string, short string, metadata models.LinkMetadata) error) *mockservice_SetLinkMetadata_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -206,3 +1556,87 @@ func (_c *mockservice_ShortenURLBatch_Call) RunAndReturn(run func(ctx context.Co
 	_c.Run(run)
 	return _c
 }
+
+// VerifyLinkPassword provides a mock function for the type mockservice
+func (_mock *mockservice) VerifyLinkPassword(ctx context.Context, host string, short string, password string) (string, time.Time, error) {
+	ret := _mock.Called(ctx, host, short, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyLinkPassword")
+	}
+
+	var r0 string
+	var r1 time.Time
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (string, time.Time, error)); ok {
+		return returnFunc(ctx, host, short, password)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) string); ok {
+		r0 = returnFunc(ctx, host, short, password)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) time.Time); ok {
+		r1 = returnFunc(ctx, host, short, password)
+	} else {
+		r1 = ret.Get(1).(time.Time)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, string, string) error); ok {
+		r2 = returnFunc(ctx, host, short, password)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// mockservice_VerifyLinkPassword_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'VerifyLinkPassword'
+type mockservice_VerifyLinkPassword_Call struct {
+	*mock.Call
+}
+
+// VerifyLinkPassword is a helper method to define mock.On call
+//   - ctx context.Context
+//   - host string
+//   - short string
+//   - password string
+func (_e *mockservice_Expecter) VerifyLinkPassword(ctx interface{}, host interface{}, short interface{}, password interface{}) *mockservice_VerifyLinkPassword_Call {
+	return &mockservice_VerifyLinkPassword_Call{Call: _e.mock.On("VerifyLinkPassword", ctx, host, short, password)}
+}
+
+func (_c *mockservice_VerifyLinkPassword_Call) Run(run func(ctx context.Context, host string, short string, password string)) *mockservice_VerifyLinkPassword_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *mockservice_VerifyLinkPassword_Call) Return(s string, time1 time.Time, err error) *mockservice_VerifyLinkPassword_Call {
+	_c.Call.Return(s, time1, err)
+	return _c
+}
+
+func (_c *mockservice_VerifyLinkPassword_Call) RunAndReturn(run func(ctx context.Context, host string, short string, password string) (string, time.Time, error)) *mockservice_VerifyLinkPassword_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -34,3 +34,21 @@ func Decode(encoded string) int64 {
 
 	return decoded
 }
+
+// maxEncoded is the encoding of the greatest int64
+const maxEncoded = "AzL8n0Y58m7"
+
+// IsCanonical reports whether encoded is the encoding of some non-negative int64,
+// i.e. it has no leading zeros, no other characters and doesn't overflow
+func IsCanonical(encoded string) bool {
+	// Digits are in ASCII order, so encodings of the same length compare as strings
+	if encoded == "" || len(encoded) > len(maxEncoded) || len(encoded) == len(maxEncoded) && encoded > maxEncoded {
+		return false
+	}
+	for _, r := range encoded {
+		if !strings.ContainsRune(alphabet, r) {
+			return false
+		}
+	}
+	return encoded == "0" || encoded[0] != '0'
+}
@@ -13,6 +13,10 @@ var pairs = []pair{
 	{10, "A"},
 	{222, "3a"},
 	{951, "FL"},
+
+	// Snowflake-sized IDs
+	{1 << 40, "JMAIjoW"},
+	{1<<63 - 1, "AzL8n0Y58m7"},
 }
 
 func TestEncode(t *testing.T) {
@@ -30,3 +34,17 @@ func TestDecode(t *testing.T) {
 		}
 	}
 }
+
+func TestIsCanonical(t *testing.T) {
+	for _, pair := range pairs {
+		if !IsCanonical(pair.encoded) {
+			t.Errorf("%q is not canonical", pair.encoded)
+		}
+	}
+
+	for _, encoded := range []string{"", "01", "my-link", "AzL8n0Y58m8", "100000000000"} {
+		if IsCanonical(encoded) {
+			t.Errorf("%q is canonical", encoded)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package botdetect tells link unfurlers, crawlers, uptime monitors and scripts from people clicking the links
+package botdetect
+
+import "strings"
+
+// signature is a User-Agent substring of the known bot, matched case-insensitively
+type signature struct {
+	token string
+	name  string
+}
+
+// signatures go from the specific to the generic ones, the first match names the bot
+var signatures = []signature{
+	// Link unfurlers of messengers and social networks
+	{"slackbot", "slack"},
+	{"slack-imgproxy", "slack"},
+	{"telegrambot", "telegram"},
+	{"twitterbot", "twitter"},
+	{"discordbot", "discord"},
+	{"whatsapp", "whatsapp"},
+	{"facebookexternalhit", "facebook"},
+	{"facebookcatalog", "facebook"},
+	{"linkedinbot", "linkedin"},
+	{"skypeuripreview", "skype"},
+	{"vkshare", "vk"},
+	{"pinterestbot", "pinterest"},
+	{"redditbot", "reddit"},
+	{"mastodon", "mastodon"},
+	{"embedly", "embedly"},
+	{"iframely", "iframely"},
+
+	// Uptime monitors
+	{"uptimerobot", "uptimerobot"},
+	{"pingdom", "pingdom"},
+	{"statuscake", "statuscake"},
+	{"better uptime", "betteruptime"},
+	{"site24x7", "site24x7"},
+	{"newrelicpinger", "newrelic"},
+	{"datadog", "datadog"},
+	{"checkly", "checkly"},
+	{"updown.io", "updown"},
+
+	// Search engines
+	{"googlebot", "google"},
+	{"bingbot", "bing"},
+	{"bingpreview", "bing"},
+	{"yandex.com/bots", "yandex"},
+	{"applebot", "apple"},
+	{"duckduckbot", "duckduckgo"},
+	{"baiduspider", "baidu"},
+
+	// Scripts and headless browsers
+	{"headlesschrome", "headless"},
+	{"phantomjs", "headless"},
+	{"lighthouse", "lighthouse"},
+	{"curl/", "curl"},
+	{"wget/", "wget"},
+	{"python-requests", "python"},
+	{"python-urllib", "python"},
+	{"aiohttp", "python"},
+	{"go-http-client", "go"},
+	{"okhttp", "okhttp"},
+	{"java/", "java"},
+	{"apache-httpclient", "java"},
+	{"libwww-perl", "perl"},
+	{"axios", "node"},
+	{"node-fetch", "node"},
+	{"postmanruntime", "postman"},
+
+	// Generic words of self-declared bots, "bot" is matched as the end of a token by hasBotToken
+	{"crawl", nameOther},
+	{"spider", nameOther},
+	{"preview", nameOther},
+	{"monitor", nameOther},
+}
+
+// nameOther names the self-declared bots not known by their signatures
+const nameOther = "other"
+
+// botTokenEnds are the characters ending a User-Agent token like "SomeBot/1.0" or "(compatible; somebot)".
+// Spaces and underscores are not among them, so devices like "CUBOT NOTE 20" or "CUBOT_X30" are not bots.
+const botTokenEnds = "/;)+,"
+
+// hasBotToken reports whether a token of the lowercase User-Agent ends with "bot"
+func hasBotToken(ua string) bool {
+	for i := 0; ; {
+		j := strings.Index(ua[i:], "bot")
+		if j < 0 {
+			return false
+		}
+		end := i + j + len("bot")
+		if end == len(ua) || strings.IndexByte(botTokenEnds, ua[end]) >= 0 {
+			return true
+		}
+		i = end
+	}
+}
+
+// NameHeuristic names the bots recognised by the request shape rather than by the User-Agent
+const NameHeuristic = "heuristic"
+
+// Classify reports whether the visitor is a bot and names it.
+// Besides the known User-Agents, requests without User-Agent
+// and non-browser clients without Accept-Language are bots, browsers always send both.
+func Classify(userAgent, acceptLanguage string) (bool, string) {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true, NameHeuristic
+	}
+
+	for _, sig := range signatures {
+		if strings.Contains(ua, sig.token) {
+			return true, sig.name
+		}
+	}
+	if hasBotToken(ua) {
+		return true, nameOther
+	}
+
+	if acceptLanguage == "" && !strings.HasPrefix(ua, "mozilla/") {
+		return true, NameHeuristic
+	}
+
+	return false, ""
+}
@@ -0,0 +1,39 @@
+package botdetect
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name           string
+		userAgent      string
+		acceptLanguage string
+		exceptedBot    bool
+		exceptedName   string
+	}{
+		{"iPhone Safari", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 Version/17.5 Mobile/15E148 Safari/604.1", "en-US", false, ""},
+		{"Desktop Chrome", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36", "de-DE,de;q=0.9", false, ""},
+		{"Browser without language", "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0", "", false, ""},
+		{"Pinterest in-app browser", "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/126.0 Mobile Safari/537.36 [Pinterest/Android]", "en-US", false, ""},
+		{"Slack", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", "", true, "slack"},
+		{"Telegram", "TelegramBot (like TwitterBot)", "", true, "telegram"},
+		{"Twitter", "Twitterbot/1.0", "", true, "twitter"},
+		{"Facebook", "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", "en-US", true, "facebook"},
+		{"UptimeRobot", "Mozilla/5.0+(compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)", "", true, "uptimerobot"},
+		{"Googlebot", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "", true, "google"},
+		{"curl", "curl/8.8.0", "", true, "curl"},
+		{"Unknown bot", "Mozilla/5.0 (compatible; SomeBot/1.0)", "en", true, "other"},
+		{"Unknown bot at the end of comment", "Mozilla/5.0 (compatible; somebot)", "en", true, "other"},
+		{"CUBOT phone", "Mozilla/5.0 (Linux; Android 11; CUBOT NOTE 20 PRO) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Mobile Safari/537.36", "en-US", false, ""},
+		{"CUBOT phone with underscore", "Mozilla/5.0 (Linux; Android 10; CUBOT_X30 Build/QP1A.190711.020; wv) AppleWebKit/537.36 Chrome/126.0 Mobile Safari/537.36", "en-US", false, ""},
+		{"No User-Agent", "", "en-US", true, NameHeuristic},
+		{"Unknown client without language", "MyApp/1.0", "", true, NameHeuristic},
+		{"Unknown client with language", "MyApp/1.0", "en-US", false, ""},
+	}
+
+	for _, tt := range tests {
+		bot, name := Classify(tt.userAgent, tt.acceptLanguage)
+		if bot != tt.exceptedBot || name != tt.exceptedName {
+			t.Errorf("%s: Classify() = %v, %q, excepted %v, %q", tt.name, bot, name, tt.exceptedBot, tt.exceptedName)
+		}
+	}
+}
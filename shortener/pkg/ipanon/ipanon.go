@@ -0,0 +1,76 @@
+// Package ipanon anonymises client IPs of the clicks before they leave the shortener
+package ipanon
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Mode is how the IP is published
+type Mode string
+
+const (
+	// ModeFull publishes the IP as is
+	ModeFull Mode = "full"
+
+	// ModeTruncate publishes the network of the IP, /24 of IPv4 and /48 of IPv6
+	ModeTruncate Mode = "truncate"
+
+	// ModeHash publishes keyed hash of the IP, clicks from the same IP are still counted together
+	ModeHash Mode = "hash"
+
+	// ModeDrop publishes no IP
+	ModeDrop Mode = "drop"
+)
+
+// Network sizes kept by ModeTruncate
+const (
+	truncateBitsV4 = 24
+	truncateBitsV6 = 48
+)
+
+type Anonymizer struct {
+	mode Mode
+	key  []byte
+}
+
+// New creates the anonymiser of the mode, ModeHash requires the key
+func New(mode Mode, key string) (*Anonymizer, error) {
+	switch mode {
+	case ModeFull, ModeTruncate, ModeDrop:
+	case ModeHash:
+		if key == "" {
+			return nil, errors.New("ip hash key is required to hash IPs")
+		}
+	default:
+		return nil, fmt.Errorf("unknown ip anonymization mode %q", mode)
+	}
+	return &Anonymizer{mode: mode, key: []byte(key)}, nil
+}
+
+// Anonymize returns the IP to publish, it is empty if the IP is empty or invalid
+func (a *Anonymizer) Anonymize(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	switch a.mode {
+	case ModeFull:
+		return parsed.String()
+	case ModeTruncate:
+		if v4 := parsed.To4(); v4 != nil {
+			return v4.Mask(net.CIDRMask(truncateBitsV4, 32)).String()
+		}
+		return parsed.Mask(net.CIDRMask(truncateBitsV6, 128)).String()
+	case ModeHash:
+		mac := hmac.New(sha256.New, a.key)
+		mac.Write([]byte(parsed.String()))
+		return hex.EncodeToString(mac.Sum(nil)[:16])
+	}
+	return ""
+}
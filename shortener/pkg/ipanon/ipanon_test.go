@@ -0,0 +1,82 @@
+package ipanon
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Anonymize(t *testing.T) {
+	tests := []struct {
+		Name           string
+		Mode           Mode
+		IP             string
+		ExceptedResult string
+	}{
+		{
+			Name:           "Full",
+			Mode:           ModeFull,
+			IP:             "203.0.113.7",
+			ExceptedResult: "203.0.113.7",
+		},
+		{
+			Name:           "Truncate IPv4",
+			Mode:           ModeTruncate,
+			IP:             "203.0.113.7",
+			ExceptedResult: "203.0.113.0",
+		},
+		{
+			Name:           "Truncate IPv4-mapped IPv6",
+			Mode:           ModeTruncate,
+			IP:             "::ffff:203.0.113.7",
+			ExceptedResult: "203.0.113.0",
+		},
+		{
+			Name:           "Truncate IPv6",
+			Mode:           ModeTruncate,
+			IP:             "2001:db8:85a3:8d3:1319:8a2e:370:7348",
+			ExceptedResult: "2001:db8:85a3::",
+		},
+		{
+			Name:           "Drop",
+			Mode:           ModeDrop,
+			IP:             "203.0.113.7",
+			ExceptedResult: "",
+		},
+		{
+			Name:           "Invalid IP",
+			Mode:           ModeFull,
+			IP:             "unknown",
+			ExceptedResult: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			a, err := New(tt.Mode, "")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ExceptedResult, a.Anonymize(tt.IP))
+		})
+	}
+}
+
+func Test_AnonymizeHash(t *testing.T) {
+	a, err := New(ModeHash, "secret")
+	assert.NoError(t, err)
+
+	hash := a.Anonymize("203.0.113.7")
+	assert.Len(t, hash, 32)
+	assert.Equal(t, hash, a.Anonymize("203.0.113.7"))
+	assert.NotEqual(t, hash, a.Anonymize("203.0.113.8"))
+
+	other, err := New(ModeHash, "other")
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, other.Anonymize("203.0.113.7"))
+}
+
+func Test_New(t *testing.T) {
+	_, err := New(ModeHash, "")
+	assert.Error(t, err)
+
+	_, err = New("mask", "")
+	assert.Error(t, err)
+}
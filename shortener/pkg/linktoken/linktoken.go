@@ -0,0 +1,50 @@
+package linktoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signer issues and verifies tokens unlocking password-protected links.
+// The token is "<expiration unix time>.<HMAC-SHA256 of the code and expiration>".
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func New(secret []byte, ttl time.Duration) *Signer {
+	return &Signer{secret: secret, ttl: ttl, now: time.Now}
+}
+
+// Sign issues a token for the code
+func (s *Signer) Sign(code string) (string, time.Time) {
+	expiresAt := s.now().Add(s.ttl).Truncate(time.Second)
+	exp := strconv.FormatInt(expiresAt.Unix(), 10)
+	return exp + "." + s.mac(code, exp), expiresAt
+}
+
+// Verify reports whether the token is issued for the code and is not expired
+func (s *Signer) Verify(code, token string) bool {
+	exp, mac, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || !s.now().Before(time.Unix(expUnix, 0)) {
+		return false
+	}
+
+	return hmac.Equal([]byte(mac), []byte(s.mac(code, exp)))
+}
+
+func (s *Signer) mac(code, exp string) string {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(code + "|" + exp))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
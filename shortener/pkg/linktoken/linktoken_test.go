@@ -0,0 +1,38 @@
+package linktoken
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := New([]byte("secret"), 15*time.Minute)
+	s.now = func() time.Time { return now }
+
+	token, expiresAt := s.Sign("3a")
+	if !expiresAt.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("Expiration %v is not equal to excepted %v", expiresAt, now.Add(15*time.Minute))
+	}
+
+	if !s.Verify("3a", token) {
+		t.Errorf("Token %q is not valid for its code", token)
+	}
+	if s.Verify("3b", token) {
+		t.Errorf("Token %q is valid for another code", token)
+	}
+	if New([]byte("other"), time.Minute).Verify("3a", token) {
+		t.Errorf("Token %q is valid with another secret", token)
+	}
+
+	for _, bad := range []string{"", "abc", "1700000900.", "x." + token} {
+		if s.Verify("3a", bad) {
+			t.Errorf("Malformed token %q is valid", bad)
+		}
+	}
+
+	now = expiresAt
+	if s.Verify("3a", token) {
+		t.Errorf("Expired token %q is valid", token)
+	}
+}
@@ -0,0 +1,32 @@
+// Package shorturl builds the short links of codes.
+// The gateway and the bot share it, so a link is shown the same way everywhere.
+package shorturl
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Build builds the short URL of the code on the domain.
+// Links on the public host are built from the public host as is, other domains share its scheme,
+// or have no scheme either if the public host has none.
+func Build(publicHost, domain, code string) string {
+	if domain == "" {
+		return publicHost + code
+	}
+
+	public, err := url.Parse(publicHost)
+	if err != nil || public.Host == "" {
+		// "localhost:8080/" is parsed as the scheme "localhost", so the host is taken up to the path
+		host, _, _ := strings.Cut(publicHost, "/")
+		if strings.EqualFold(host, domain) {
+			return publicHost + code
+		}
+		return domain + "/" + code
+	}
+
+	if strings.EqualFold(public.Host, domain) {
+		return publicHost + code
+	}
+	return public.Scheme + "://" + domain + "/" + code
+}
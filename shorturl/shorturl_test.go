@@ -0,0 +1,26 @@
+package shorturl
+
+import "testing"
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		publicHost string
+		domain     string
+		code       string
+		excepted   string
+	}{
+		{"https://sh.some/", "", "1z", "https://sh.some/1z"},
+		{"https://sh.some/", "sh.some", "1z", "https://sh.some/1z"},
+		{"https://sh.some/", "SH.some", "1z", "https://sh.some/1z"},
+		{"https://sh.some/", "go.some", "docs", "https://go.some/docs"},
+		{"http://localhost:8080/", "go.some:8080", "1z", "http://go.some:8080/1z"},
+		{"localhost:8080/", "", "1z", "localhost:8080/1z"},
+		{"localhost:8080/", "LOCALHOST:8080", "1z", "localhost:8080/1z"},
+		{"localhost:8080/", "go.some", "1z", "go.some/1z"},
+	}
+	for _, tt := range tests {
+		if got := Build(tt.publicHost, tt.domain, tt.code); got != tt.excepted {
+			t.Errorf("Build(%q, %q, %q) = %q, excepted %q", tt.publicHost, tt.domain, tt.code, got, tt.excepted)
+		}
+	}
+}
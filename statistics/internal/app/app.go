@@ -97,6 +97,7 @@ func New(cfg *config.Config, l *slog.Logger) (*App, error) {
 		valkeyRepo,
 		tracer,
 		cfg.ClickHouse.BatchSize,
+		cfg.CountBots,
 	)
 	a.consumer = consumer.New(a.l, a.kafkaReader, a.svc, tracer)
 	a.producer = producer.New(a.l, a.svc, a.kafkaWriter, cfg.TopTTL, cfg.LockTTL, cfg.TopAmount, tracer)
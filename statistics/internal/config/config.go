@@ -15,6 +15,9 @@ type Config struct {
 	TopTTL    int `env:"TOP_TTL" env-default:"3600"`
 	TopAmount int `env:"TOP_AMOUNT" env-default:"100"`
 	LockTTL   int `env:"PRODUCER_LOCK_INTERVAL_SECONDS" env-default:"30"`
+
+	// CountBots makes bot clicks count in the top and the unshortened counter, they are stored anyway
+	CountBots bool `env:"COUNT_BOTS" env-default:"false"`
 }
 
 type kafka struct {
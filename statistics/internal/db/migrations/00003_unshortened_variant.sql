@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE default.unshortened ADD COLUMN IF NOT EXISTS Variant String DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE default.unshortened DROP COLUMN IF EXISTS Variant;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE default.shortened ADD COLUMN IF NOT EXISTS Domain LowCardinality(String) DEFAULT '';
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE default.unshortened ADD COLUMN IF NOT EXISTS Domain LowCardinality(String) DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE default.unshortened DROP COLUMN IF EXISTS Domain;
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE default.shortened DROP COLUMN IF EXISTS Domain;
+-- +goose StatementEnd
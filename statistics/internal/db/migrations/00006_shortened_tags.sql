@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE default.shortened ADD COLUMN IF NOT EXISTS Tags Array(LowCardinality(String)) DEFAULT [];
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE default.shortened DROP COLUMN IF EXISTS Tags;
+-- +goose StatementEnd
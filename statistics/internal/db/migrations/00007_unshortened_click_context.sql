@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE default.unshortened
+    ADD COLUMN IF NOT EXISTS Referer String DEFAULT '',
+    ADD COLUMN IF NOT EXISTS UserAgent String DEFAULT '',
+    ADD COLUMN IF NOT EXISTS IP String DEFAULT '',
+    ADD COLUMN IF NOT EXISTS AcceptLanguage LowCardinality(String) DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE default.unshortened
+    DROP COLUMN IF EXISTS Referer,
+    DROP COLUMN IF EXISTS UserAgent,
+    DROP COLUMN IF EXISTS IP,
+    DROP COLUMN IF EXISTS AcceptLanguage;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE default.unshortened
+    ADD COLUMN IF NOT EXISTS IsBot Bool DEFAULT false,
+    ADD COLUMN IF NOT EXISTS BotName LowCardinality(String) DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE default.unshortened
+    DROP COLUMN IF EXISTS IsBot,
+    DROP COLUMN IF EXISTS BotName;
+-- +goose StatementEnd
@@ -8,12 +8,17 @@ import (
 type Metrics struct {
 	Shortened   prometheus.Counter
 	Unshortened prometheus.Counter
+
+	// UnshortenedBots counts the clicks of bots, they are excluded from Unshortened unless COUNT_BOTS is set
+	UnshortenedBots prometheus.Counter
 }
 
 func New() *Metrics {
 	return &Metrics{
 		Shortened:   promauto.NewCounter(prometheus.CounterOpts{Name: "shortener_shortened_total"}),
 		Unshortened: promauto.NewCounter(prometheus.CounterOpts{Name: "shortener_unshortened_total"}),
+
+		UnshortenedBots: promauto.NewCounter(prometheus.CounterOpts{Name: "shortener_unshortened_bots_total"}),
 	}
 }
 
@@ -24,3 +29,7 @@ func (m *Metrics) Shorten() {
 func (m *Metrics) Unshorten() {
 	m.Unshortened.Inc()
 }
+
+func (m *Metrics) UnshortenBot() {
+	m.UnshortenedBots.Inc()
+}
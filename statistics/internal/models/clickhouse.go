@@ -11,7 +11,9 @@ type ClickHouseEventShortened struct {
 	EventID     uuid.UUID
 	OriginalURL string
 	ShortCode   string
+	Domain      string
 	ShortenedAt time.Time
+	Tags        []string
 }
 
 type ClickHouseEventUnshortened struct {
@@ -19,5 +21,18 @@ type ClickHouseEventUnshortened struct {
 	EventID       uuid.UUID
 	OriginalURL   string
 	ShortCode     string
+	Domain        string
 	UnshortenedAt time.Time
+	Variant       string
+	Country       string
+
+	Referer        string
+	UserAgent      string
+	AcceptLanguage string
+
+	// IP is anonymised by the shortener according to its IP_ANONYMIZATION
+	IP string
+
+	IsBot   bool
+	BotName string
 }
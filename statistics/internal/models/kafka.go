@@ -6,12 +6,27 @@ type KafkaMessageShortened struct {
 	ShortenedAt time.Time `json:"shortened_at"`
 	OriginalURL string    `json:"original_url"`
 	ShortCode   string    `json:"short_code"`
+	Domain      string    `json:"domain,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
 }
 
 type KafkaMessageUnshortened struct {
 	UnshortenedAt time.Time `json:"unshortened_at"`
 	OriginalURL   string    `json:"original_url"`
 	ShortCode     string    `json:"short_code"`
+	Domain        string    `json:"domain,omitempty"`
+	Variant       string    `json:"variant,omitempty"`
+	Country       string    `json:"country,omitempty"`
+
+	// Click context of the visit
+	Referer        string `json:"referer,omitempty"`
+	UserAgent      string `json:"user_agent,omitempty"`
+	IP             string `json:"ip,omitempty"`
+	AcceptLanguage string `json:"accept_language,omitempty"`
+
+	// Bot is set if the visit came from a link unfurler, crawler, monitor or script
+	Bot     bool   `json:"bot"`
+	BotName string `json:"bot_name,omitempty"`
 }
 
 type KafkaMessageUnshortenedTop struct {
@@ -19,5 +34,6 @@ type KafkaMessageUnshortenedTop struct {
 	Top        []struct {
 		OriginalURL string `json:"original_url"`
 		ShortCode   string `json:"short_code"`
+		Domain      string `json:"domain,omitempty"`
 	} `json:"top"`
 }
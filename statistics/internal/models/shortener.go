@@ -7,5 +7,6 @@ type UnshortenedTop struct {
 	Top        []struct {
 		OriginalURL string
 		ShortCode   string
+		Domain      string
 	}
 }
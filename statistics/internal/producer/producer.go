@@ -50,6 +50,7 @@ func (p *Producer) sendTopToKafka(ctx context.Context, top models.UnshortenedTop
 		Top: []struct {
 			OriginalURL string `json:"original_url"`
 			ShortCode   string `json:"short_code"`
+			Domain      string `json:"domain,omitempty"`
 		}(top.Top),
 	}
 	msgMarshaled, err := json.Marshal(msg)
@@ -29,6 +29,7 @@ func Test_sendTopToKafka(t *testing.T) {
 				Top: []struct {
 					OriginalURL string
 					ShortCode   string
+					Domain      string
 				}{
 					{
 						OriginalURL: "https://go.dev",
@@ -53,6 +54,7 @@ func Test_sendTopToKafka(t *testing.T) {
 				Top: []struct {
 					OriginalURL string
 					ShortCode   string
+					Domain      string
 				}{
 					{
 						OriginalURL: "https://go.dev",
@@ -134,6 +136,7 @@ func Test_ProduceTop(t *testing.T) {
 				Top: []struct {
 					OriginalURL string
 					ShortCode   string
+					Domain      string
 				}{
 					{
 						OriginalURL: "https://go.dev",
@@ -177,6 +180,7 @@ func Test_ProduceTop(t *testing.T) {
 				Top: []struct {
 					OriginalURL string
 					ShortCode   string
+					Domain      string
 				}{},
 			},
 			SetUpMocks: func(
@@ -47,16 +47,17 @@ func (r *ClickHouseRepo) WriteUnshortened(ctx context.Context, events []models.C
 	return batch.Send()
 }
 
-func (r *ClickHouseRepo) GetTopUnshortened(ctx context.Context, amount, ttl int) (*models.UnshortenedTop, error) {
+// GetTopUnshortened returns the most clicked links of the last ttl seconds, bot clicks count only if includeBots is set
+func (r *ClickHouseRepo) GetTopUnshortened(ctx context.Context, amount, ttl int, includeBots bool) (*models.UnshortenedTop, error) {
 	var top models.UnshortenedTop
 
-	query := `SELECT OriginalURL, ShortCode
+	query := `SELECT OriginalURL, ShortCode, Domain
 FROM unshortened
-WHERE timeDiff(UnshortenedAt, now()) < $2
-GROUP BY OriginalURL, ShortCode
+WHERE timeDiff(UnshortenedAt, now()) < $2 AND ($3 OR NOT IsBot)
+GROUP BY OriginalURL, ShortCode, Domain
 ORDER BY COUNT(*) DESC
 LIMIT $1;`
-	err := r.conn.Select(ctx, &top.Top, query, amount, ttl)
+	err := r.conn.Select(ctx, &top.Top, query, amount, ttl, includeBots)
 	if err != nil {
 		return nil, err
 	}
@@ -40,8 +40,8 @@ func (_m *mockclickHouseRepo) EXPECT() *mockclickHouseRepo_Expecter {
 }
 
 // GetTopUnshortened provides a mock function for the type mockclickHouseRepo
-func (_mock *mockclickHouseRepo) GetTopUnshortened(ctx context.Context, amount int, ttl int) (*models.UnshortenedTop, error) {
-	ret := _mock.Called(ctx, amount, ttl)
+func (_mock *mockclickHouseRepo) GetTopUnshortened(ctx context.Context, amount int, ttl int, includeBots bool) (*models.UnshortenedTop, error) {
+	ret := _mock.Called(ctx, amount, ttl, includeBots)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetTopUnshortened")
@@ -49,18 +49,18 @@ func (_mock *mockclickHouseRepo) GetTopUnshortened(ctx context.Context, amount i
 
 	var r0 *models.UnshortenedTop
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) (*models.UnshortenedTop, error)); ok {
-		return returnFunc(ctx, amount, ttl)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, bool) (*models.UnshortenedTop, error)); ok {
+		return returnFunc(ctx, amount, ttl, includeBots)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int) *models.UnshortenedTop); ok {
-		r0 = returnFunc(ctx, amount, ttl)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, int, int, bool) *models.UnshortenedTop); ok {
+		r0 = returnFunc(ctx, amount, ttl, includeBots)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*models.UnshortenedTop)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
-		r1 = returnFunc(ctx, amount, ttl)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, int, int, bool) error); ok {
+		r1 = returnFunc(ctx, amount, ttl, includeBots)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -76,11 +76,12 @@ type mockclickHouseRepo_GetTopUnshortened_Call struct {
 //   - ctx context.Context
 //   - amount int
 //   - ttl int
-func (_e *mockclickHouseRepo_Expecter) GetTopUnshortened(ctx interface{}, amount interface{}, ttl interface{}) *mockclickHouseRepo_GetTopUnshortened_Call {
-	return &mockclickHouseRepo_GetTopUnshortened_Call{Call: _e.mock.On("GetTopUnshortened", ctx, amount, ttl)}
+//   - includeBots bool
+func (_e *mockclickHouseRepo_Expecter) GetTopUnshortened(ctx interface{}, amount interface{}, ttl interface{}, includeBots interface{}) *mockclickHouseRepo_GetTopUnshortened_Call {
+	return &mockclickHouseRepo_GetTopUnshortened_Call{Call: _e.mock.On("GetTopUnshortened", ctx, amount, ttl, includeBots)}
 }
 
-func (_c *mockclickHouseRepo_GetTopUnshortened_Call) Run(run func(ctx context.Context, amount int, ttl int)) *mockclickHouseRepo_GetTopUnshortened_Call {
+func (_c *mockclickHouseRepo_GetTopUnshortened_Call) Run(run func(ctx context.Context, amount int, ttl int, includeBots bool)) *mockclickHouseRepo_GetTopUnshortened_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -94,10 +95,15 @@ func (_c *mockclickHouseRepo_GetTopUnshortened_Call) Run(run func(ctx context.Co
 		if args[2] != nil {
 			arg2 = args[2].(int)
 		}
+		var arg3 bool
+		if args[3] != nil {
+			arg3 = args[3].(bool)
+		}
 		run(
 			arg0,
 			arg1,
 			arg2,
+			arg3,
 		)
 	})
 	return _c
@@ -108,7 +114,7 @@ func (_c *mockclickHouseRepo_GetTopUnshortened_Call) Return(unshortenedTop *mode
 	return _c
 }
 
-func (_c *mockclickHouseRepo_GetTopUnshortened_Call) RunAndReturn(run func(ctx context.Context, amount int, ttl int) (*models.UnshortenedTop, error)) *mockclickHouseRepo_GetTopUnshortened_Call {
+func (_c *mockclickHouseRepo_GetTopUnshortened_Call) RunAndReturn(run func(ctx context.Context, amount int, ttl int, includeBots bool) (*models.UnshortenedTop, error)) *mockclickHouseRepo_GetTopUnshortened_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -320,6 +326,39 @@ func (_c *mockmetricsProvider_Unshorten_Call) RunAndReturn(run func()) *mockmetr
 	return _c
 }
 
+// UnshortenBot provides a mock function for the type mockmetricsProvider
+func (_mock *mockmetricsProvider) UnshortenBot() {
+	_mock.Called()
+	return
+}
+
+// mockmetricsProvider_UnshortenBot_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UnshortenBot'
+type mockmetricsProvider_UnshortenBot_Call struct {
+	*mock.Call
+}
+
+// UnshortenBot is a helper method to define mock.On call
+func (_e *mockmetricsProvider_Expecter) UnshortenBot() *mockmetricsProvider_UnshortenBot_Call {
+	return &mockmetricsProvider_UnshortenBot_Call{Call: _e.mock.On("UnshortenBot")}
+}
+
+func (_c *mockmetricsProvider_UnshortenBot_Call) Run(run func()) *mockmetricsProvider_UnshortenBot_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *mockmetricsProvider_UnshortenBot_Call) Return() *mockmetricsProvider_UnshortenBot_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *mockmetricsProvider_UnshortenBot_Call) RunAndReturn(run func()) *mockmetricsProvider_UnshortenBot_Call {
+	_c.Run(run)
+	return _c
+}
+
 // newMocktopLockProvider creates a new instance of mocktopLockProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMocktopLockProvider(t interface {
@@ -13,12 +13,13 @@ import (
 type clickHouseRepo interface {
 	WriteShortened(ctx context.Context, events []models.ClickHouseEventShortened) error
 	WriteUnshortened(ctx context.Context, events []models.ClickHouseEventUnshortened) error
-	GetTopUnshortened(ctx context.Context, amount, ttl int) (*models.UnshortenedTop, error)
+	GetTopUnshortened(ctx context.Context, amount, ttl int, includeBots bool) (*models.UnshortenedTop, error)
 }
 
 type metricsProvider interface {
 	Shorten()
 	Unshorten()
+	UnshortenBot()
 }
 
 type topLockProvider interface {
@@ -35,6 +36,9 @@ type Service struct {
 	unshortenedCh chan models.ClickHouseEventUnshortened
 	DBBatchSize   int
 
+	// countBots makes bot clicks count in the top and the unshortened counter
+	countBots bool
+
 	t trace.Tracer
 }
 
@@ -47,6 +51,7 @@ func New(
 	topLock topLockProvider,
 	t trace.Tracer,
 	DBBatchSize int,
+	countBots bool,
 ) *Service {
 	return &Service{
 		l:       l,
@@ -57,6 +62,7 @@ func New(
 		shortenedCh:   shortenedCh,
 		unshortenedCh: unshortenedCh,
 		DBBatchSize:   DBBatchSize,
+		countBots:     countBots,
 
 		t: t,
 	}
@@ -69,6 +75,8 @@ func (s *Service) Shortened(ctx context.Context, msg *models.KafkaMessageShorten
 	s.l.Info("Shortened URL",
 		"url", msg.OriginalURL,
 		"code", msg.ShortCode,
+		"domain", msg.Domain,
+		"tags", msg.Tags,
 		"shortened at", msg.ShortenedAt,
 	)
 
@@ -89,7 +97,9 @@ func (s *Service) Shortened(ctx context.Context, msg *models.KafkaMessageShorten
 		EventID:     uuid.New(),
 		OriginalURL: msg.OriginalURL,
 		ShortCode:   msg.ShortCode,
+		Domain:      msg.Domain,
 		ShortenedAt: msg.ShortenedAt,
+		Tags:        msg.Tags,
 	}
 	spanClickHouse.End()
 }
@@ -101,12 +111,22 @@ func (s *Service) Unshortened(ctx context.Context, msg *models.KafkaMessageUnsho
 	s.l.Info("Clicked on shortened URL",
 		"url", msg.OriginalURL,
 		"code", msg.ShortCode,
+		"domain", msg.Domain,
+		"variant", msg.Variant,
+		"country", msg.Country,
+		"referer", msg.Referer,
+		"bot", msg.BotName,
 		"clicked at", msg.UnshortenedAt,
 	)
 
 	// Update metrics
 	_, spanMetrics := s.t.Start(ctx, "Update metrics")
-	s.m.Unshorten()
+	if msg.Bot {
+		s.m.UnshortenBot()
+	}
+	if !msg.Bot || s.countBots {
+		s.m.Unshorten()
+	}
 	spanMetrics.End()
 
 	// Send to the ClickHouse batch channel
@@ -121,7 +141,18 @@ func (s *Service) Unshortened(ctx context.Context, msg *models.KafkaMessageUnsho
 		EventID:       uuid.New(),
 		OriginalURL:   msg.OriginalURL,
 		ShortCode:     msg.ShortCode,
+		Domain:        msg.Domain,
 		UnshortenedAt: msg.UnshortenedAt,
+		Variant:       msg.Variant,
+		Country:       msg.Country,
+
+		Referer:        msg.Referer,
+		UserAgent:      msg.UserAgent,
+		AcceptLanguage: msg.AcceptLanguage,
+		IP:             msg.IP,
+
+		IsBot:   msg.Bot,
+		BotName: msg.BotName,
 	}
 	spanClickHouse.End()
 }
@@ -210,7 +241,7 @@ func (s *Service) GetTopUnshortened(ctx context.Context, amount, ttl int) (*mode
 	ctx, span := s.t.Start(ctx, "GetTopUnshortened")
 	defer span.End()
 
-	return s.r.GetTopUnshortened(ctx, amount, ttl)
+	return s.r.GetTopUnshortened(ctx, amount, ttl, s.countBots)
 }
 
 func (s *Service) LockTopWrite(ctx context.Context, ttl int) error {
@@ -59,6 +59,7 @@ func Test_Shortened(t *testing.T) {
 				nil,
 				tracer,
 				10,
+				false,
 			)
 
 			service.Shortened(context.Background(), tt.InputMessage)
@@ -94,6 +95,7 @@ func Test_Unshortened(t *testing.T) {
 	tests := []struct {
 		Name         string
 		InputMessage *models.KafkaMessageUnshortened
+		CountBots    bool
 		SetUpMocks   func(metrics *mockmetricsProvider)
 	}{
 		{
@@ -107,6 +109,63 @@ func Test_Unshortened(t *testing.T) {
 				metrics.On("Unshorten").Once()
 			},
 		},
+		{
+			Name: "Successfully Unshortened with variant",
+			InputMessage: &models.KafkaMessageUnshortened{
+				UnshortenedAt: time.Now(),
+				OriginalURL:   "https://go.dev",
+				ShortCode:     "3a",
+				Domain:        "sh.some",
+				Variant:       "b",
+				Country:       "DE",
+			},
+			SetUpMocks: func(metrics *mockmetricsProvider) {
+				metrics.On("Unshorten").Once()
+			},
+		},
+		{
+			Name: "Successfully Unshortened with click context",
+			InputMessage: &models.KafkaMessageUnshortened{
+				UnshortenedAt:  time.Now(),
+				OriginalURL:    "https://go.dev",
+				ShortCode:      "3a",
+				Referer:        "https://news.some/post",
+				UserAgent:      "Mozilla/5.0 (iPhone)",
+				AcceptLanguage: "de-CH",
+				IP:             "203.0.113.7",
+			},
+			SetUpMocks: func(metrics *mockmetricsProvider) {
+				metrics.On("Unshorten").Once()
+			},
+		},
+		{
+			Name: "Bot is not counted",
+			InputMessage: &models.KafkaMessageUnshortened{
+				UnshortenedAt: time.Now(),
+				OriginalURL:   "https://go.dev",
+				ShortCode:     "3a",
+				Bot:           true,
+				BotName:       "slack",
+			},
+			SetUpMocks: func(metrics *mockmetricsProvider) {
+				metrics.On("UnshortenBot").Once()
+			},
+		},
+		{
+			Name: "Bot is counted with COUNT_BOTS",
+			InputMessage: &models.KafkaMessageUnshortened{
+				UnshortenedAt: time.Now(),
+				OriginalURL:   "https://go.dev",
+				ShortCode:     "3a",
+				Bot:           true,
+				BotName:       "slack",
+			},
+			CountBots: true,
+			SetUpMocks: func(metrics *mockmetricsProvider) {
+				metrics.On("UnshortenBot").Once()
+				metrics.On("Unshorten").Once()
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -134,6 +193,7 @@ func Test_Unshortened(t *testing.T) {
 				nil,
 				tracer,
 				10,
+				tt.CountBots,
 			)
 
 			service.Unshortened(context.Background(), tt.InputMessage)
@@ -148,6 +208,15 @@ func Test_Unshortened(t *testing.T) {
 				assert.Equal(t, tt.InputMessage.ShortCode, event.ShortCode)
 				assert.Equal(t, tt.InputMessage.OriginalURL, event.OriginalURL)
 				assert.Equal(t, tt.InputMessage.UnshortenedAt, event.UnshortenedAt)
+				assert.Equal(t, tt.InputMessage.Variant, event.Variant)
+				assert.Equal(t, tt.InputMessage.Country, event.Country)
+				assert.Equal(t, tt.InputMessage.Domain, event.Domain)
+				assert.Equal(t, tt.InputMessage.Referer, event.Referer)
+				assert.Equal(t, tt.InputMessage.UserAgent, event.UserAgent)
+				assert.Equal(t, tt.InputMessage.AcceptLanguage, event.AcceptLanguage)
+				assert.Equal(t, tt.InputMessage.IP, event.IP, "the IP is anonymised by the shortener")
+				assert.Equal(t, tt.InputMessage.Bot, event.IsBot)
+				assert.Equal(t, tt.InputMessage.BotName, event.BotName)
 			case <-ctx.Done():
 				t.Fatal("didn't get event in the channel")
 			}
@@ -224,6 +293,7 @@ func TestService_ShortenedBatchWriter(t *testing.T) {
 				nil,
 				tracer,
 				tt.BatchSize,
+				false,
 			)
 
 			ctx, cancel := context.WithCancel(context.Background())
@@ -321,6 +391,7 @@ func TestService_UnshortenedBatchWriter(t *testing.T) {
 				nil,
 				tracer,
 				tt.BatchSize,
+				false,
 			)
 
 			ctx, cancel := context.WithCancel(context.Background())
@@ -361,8 +432,9 @@ func TestService_UnshortenedBatchWriter(t *testing.T) {
 
 func TestService_GetTopUnshortened(t *testing.T) {
 	type input struct {
-		Amount int
-		TTL    int
+		Amount    int
+		TTL       int
+		CountBots bool
 	}
 
 	tests := []struct {
@@ -373,16 +445,14 @@ func TestService_GetTopUnshortened(t *testing.T) {
 		SetUpMocks     func(clickhouse *mockclickHouseRepo, i input, r *models.UnshortenedTop)
 	}{
 		{
-			Name: "Successfully Get Top",
-			Input: struct {
-				Amount int
-				TTL    int
-			}{Amount: 10, TTL: 5},
+			Name:  "Successfully Get Top",
+			Input: input{Amount: 10, TTL: 5},
 			ExceptedResult: &models.UnshortenedTop{
 				ValidUntil: time.Now(),
 				Top: []struct {
 					OriginalURL string
 					ShortCode   string
+					Domain      string
 				}{
 					{
 						OriginalURL: "https://go.dev",
@@ -396,20 +466,29 @@ func TestService_GetTopUnshortened(t *testing.T) {
 			},
 			WantErr: false,
 			SetUpMocks: func(clickhouse *mockclickHouseRepo, i input, r *models.UnshortenedTop) {
-				clickhouse.On("GetTopUnshortened", mock.Anything, i.Amount, i.TTL).
+				clickhouse.On("GetTopUnshortened", mock.Anything, i.Amount, i.TTL, i.CountBots).
+					Return(r, nil).Once()
+			},
+		},
+		{
+			Name:  "Successfully Get Top with bots",
+			Input: input{Amount: 10, TTL: 5, CountBots: true},
+			ExceptedResult: &models.UnshortenedTop{
+				ValidUntil: time.Now(),
+			},
+			WantErr: false,
+			SetUpMocks: func(clickhouse *mockclickHouseRepo, i input, r *models.UnshortenedTop) {
+				clickhouse.On("GetTopUnshortened", mock.Anything, i.Amount, i.TTL, i.CountBots).
 					Return(r, nil).Once()
 			},
 		},
 		{
-			Name: "Failed To Get Top",
-			Input: struct {
-				Amount int
-				TTL    int
-			}{Amount: 10, TTL: 5},
+			Name:           "Failed To Get Top",
+			Input:          input{Amount: 10, TTL: 5},
 			ExceptedResult: nil,
 			WantErr:        true,
 			SetUpMocks: func(clickhouse *mockclickHouseRepo, i input, r *models.UnshortenedTop) {
-				clickhouse.On("GetTopUnshortened", mock.Anything, i.Amount, i.TTL).
+				clickhouse.On("GetTopUnshortened", mock.Anything, i.Amount, i.TTL, i.CountBots).
 					Return(nil, errors.New("some unknown error")).Once()
 			},
 		},
@@ -437,6 +516,7 @@ func TestService_GetTopUnshortened(t *testing.T) {
 				nil,
 				tracer,
 				10,
+				tt.Input.CountBots,
 			)
 
 			result, err := service.GetTopUnshortened(context.Background(), tt.Input.Amount, tt.Input.TTL)
@@ -502,6 +582,7 @@ func TestService_LockTopWrite(t *testing.T) {
 				&mockLockProv,
 				tracer,
 				10,
+				false,
 			)
 
 			err := service.LockTopWrite(context.Background(), tt.InputTTL)